@@ -3,11 +3,14 @@ package api
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"os"
 	"runtime"
 	"sync"
 
@@ -21,25 +24,33 @@ import (
 
 // Config contains the configuration fields for the Benthos API.
 type Config struct {
-	Address        string              `json:"address" yaml:"address"`
-	Enabled        bool                `json:"enabled" yaml:"enabled"`
-	RootPath       string              `json:"root_path" yaml:"root_path"`
-	DebugEndpoints bool                `json:"debug_endpoints" yaml:"debug_endpoints"`
-	CertFile       string              `json:"cert_file" yaml:"cert_file"`
-	KeyFile        string              `json:"key_file" yaml:"key_file"`
-	CORS           httpdocs.ServerCORS `json:"cors" yaml:"cors"`
+	Address         string              `json:"address" yaml:"address"`
+	Enabled         bool                `json:"enabled" yaml:"enabled"`
+	RootPath        string              `json:"root_path" yaml:"root_path"`
+	DebugEndpoints  bool                `json:"debug_endpoints" yaml:"debug_endpoints"`
+	CertFile        string              `json:"cert_file" yaml:"cert_file"`
+	KeyFile         string              `json:"key_file" yaml:"key_file"`
+	ClientCAFile    string              `json:"client_ca_file" yaml:"client_ca_file"`
+	CORS            httpdocs.ServerCORS `json:"cors" yaml:"cors"`
+	ReadyComponents []string            `json:"ready_components" yaml:"ready_components"`
+	ErrorEvents     bool                `json:"error_events" yaml:"error_events"`
+	ErrorSamples    bool                `json:"error_samples" yaml:"error_samples"`
 }
 
 // NewConfig creates a new API config with default values.
 func NewConfig() Config {
 	return Config{
-		Address:        "0.0.0.0:4195",
-		Enabled:        true,
-		RootPath:       "/benthos",
-		DebugEndpoints: false,
-		CertFile:       "",
-		KeyFile:        "",
-		CORS:           httpdocs.NewServerCORS(),
+		Address:         "0.0.0.0:4195",
+		Enabled:         true,
+		RootPath:        "/benthos",
+		DebugEndpoints:  false,
+		CertFile:        "",
+		KeyFile:         "",
+		ClientCAFile:    "",
+		CORS:            httpdocs.NewServerCORS(),
+		ReadyComponents: []string{"input", "output"},
+		ErrorEvents:     false,
+		ErrorSamples:    false,
 	}
 }
 
@@ -104,6 +115,11 @@ func New(
 			return nil, errors.New("both cert_file and key_file must be specified, or neither")
 		}
 	}
+	if conf.ClientCAFile != "" {
+		if server.TLSConfig, err = clientAuthTLSConfig(conf); err != nil {
+			return nil, err
+		}
+	}
 
 	t := &Type{
 		conf:      conf,
@@ -182,21 +198,45 @@ func New(
 			"/debug/stack", "DEBUG: Returns a snapshot of the current service stack trace.",
 			handleStackTrace,
 		)
+		t.RegisterEndpoint(
+			"/debug/vars", "DEBUG: Returns the public variables of the service in JSON format, including runtime memory statistics.",
+			expvar.Handler().ServeHTTP,
+		)
+		t.RegisterEndpoint(
+			"/debug/dump", "DEBUG: Responds with a zip archive containing a snapshot of the heap, goroutine and other runtime profiles of the service. Set the query parameter gc to true in order to run a garbage collection before the heap profile is taken.",
+			handleDebugDump,
+		)
 		t.RegisterEndpoint(
 			"/debug/pprof/profile", "DEBUG: Responds with a pprof-formatted cpu profile.",
 			pprof.Profile,
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/heap", "DEBUG: Responds with a pprof-formatted heap profile.",
-			pprof.Index,
+			pprof.Handler("heap").ServeHTTP,
+		)
+		t.RegisterEndpoint(
+			"/debug/pprof/allocs", "DEBUG: Responds with a pprof-formatted profile of all past memory allocations.",
+			pprof.Handler("allocs").ServeHTTP,
+		)
+		t.RegisterEndpoint(
+			"/debug/pprof/goroutine", "DEBUG: Responds with a pprof-formatted profile of the stack traces of all current goroutines.",
+			pprof.Handler("goroutine").ServeHTTP,
+		)
+		t.RegisterEndpoint(
+			"/debug/pprof/threadcreate", "DEBUG: Responds with a pprof-formatted profile of the stack traces that led to the creation of new OS threads.",
+			pprof.Handler("threadcreate").ServeHTTP,
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/block", "DEBUG: Responds with a pprof-formatted block profile.",
-			pprof.Index,
+			pprof.Handler("block").ServeHTTP,
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/mutex", "DEBUG: Responds with a pprof-formatted mutex profile.",
-			pprof.Index,
+			pprof.Handler("mutex").ServeHTTP,
+		)
+		t.RegisterEndpoint(
+			"/debug/pprof/cmdline", "DEBUG: Responds with the command line invocation of the service.",
+			pprof.Cmdline,
 		)
 		t.RegisterEndpoint(
 			"/debug/pprof/symbol", "DEBUG: looks up the program counters listed"+
@@ -211,6 +251,11 @@ func New(
 				" parameter, or for 1 second if not specified.",
 			pprof.Trace,
 		)
+
+		// Changing the log level at runtime can increase the verbosity of all
+		// components to the point of logging message contents, and therefore
+		// isn't available unless debug endpoints are explicitly enabled.
+		t.registerLogLevelEndpoint()
 	}
 
 	t.RegisterEndpoint("/ping", "Ping me.", handlePing)
@@ -259,6 +304,32 @@ func (t *Type) RegisterEndpoint(path, desc string, handlerFunc http.HandlerFunc)
 	t.handlers[path] = handlerFunc
 }
 
+// clientAuthTLSConfig creates a TLS config that requires clients to present a
+// certificate signed by a certificate authority within the client CA file.
+func clientAuthTLSConfig(conf Config) (*tls.Config, error) {
+	if conf.CertFile == "" {
+		return nil, errors.New("cert_file and key_file must be specified when client_ca_file is set")
+	}
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	caBytes, err := os.ReadFile(conf.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, errors.New("client CA file does not contain any certificates")
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
 // ListenAndServe launches the API and blocks until the server closes or fails.
 func (t *Type) ListenAndServe() error {
 	if !t.conf.Enabled {
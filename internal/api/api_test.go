@@ -1,9 +1,25 @@
 package api_test
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -85,3 +101,199 @@ func TestAPIEnableCORSNoHeaders(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "must specify at least one allowed origin")
 }
+
+func TestAPILogLevel(t *testing.T) {
+	lConf := log.NewConfig()
+	lConf.LogLevel = "INFO"
+
+	logger, err := log.NewV2(io.Discard, lConf)
+	require.NoError(t, err)
+
+	conf := api.NewConfig()
+	conf.DebugEndpoints = true
+
+	s, err := api.New("", "", conf, nil, logger, metrics.Noop())
+	require.NoError(t, err)
+
+	handler := s.Handler()
+
+	for _, test := range []struct {
+		method string
+		url    string
+		code   int
+		body   string
+	}{
+		{method: "GET", url: "/log/level", code: 200, body: `{"level":"INFO","paths":{}}`},
+		{method: "POST", url: "/log/level?level=debug", code: 200, body: `{"level":"DEBUG","paths":{}}`},
+		{method: "POST", url: "/log/level?level=trace&path=root.input", code: 200, body: `{"level":"DEBUG","paths":{"root.input":"TRACE"}}`},
+		{method: "POST", url: "/log/level?level=nope", code: 400},
+		{method: "POST", url: "/log/level", code: 400},
+		{method: "DELETE", url: "/log/level?path=root.input", code: 200, body: `{"level":"DEBUG","paths":{}}`},
+		{method: "DELETE", url: "/log/level", code: 200, body: `{"level":"INFO","paths":{}}`},
+	} {
+		request, _ := http.NewRequest(test.method, test.url, http.NoBody)
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+
+		assert.Equal(t, test.code, response.Code, test.url)
+		if test.body != "" {
+			assert.Equal(t, test.body, response.Body.String(), test.url)
+		}
+	}
+}
+
+func TestAPILogLevelDisabled(t *testing.T) {
+	s, err := api.New("", "", api.NewConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	request, _ := http.NewRequest("POST", "/log/level?level=trace", http.NoBody)
+	response := httptest.NewRecorder()
+	s.Handler().ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestAPIDebugEndpoints(t *testing.T) {
+	conf := api.NewConfig()
+	conf.DebugEndpoints = true
+
+	s, err := api.New("", "", conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	handler := s.Handler()
+
+	request, _ := http.NewRequest("GET", "/debug/vars", http.NoBody)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), `"memstats"`)
+
+	request, _ = http.NewRequest("GET", "/benthos/debug/pprof/goroutine?debug=1", http.NoBody)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "goroutine profile:")
+
+	request, _ = http.NewRequest("GET", "/debug/dump?gc=true", http.NoBody)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/zip", response.Header().Get("Content-Type"))
+
+	zr, err := zip.NewReader(bytes.NewReader(response.Body.Bytes()), int64(response.Body.Len()))
+	require.NoError(t, err)
+
+	var files []string
+	for _, f := range zr.File {
+		files = append(files, f.Name)
+	}
+	assert.Contains(t, files, "heap.pprof")
+	assert.Contains(t, files, "goroutine.txt")
+}
+
+func writeTestCert(t *testing.T, dir, name string, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	return cert, key
+}
+
+func TestAPIClientCA(t *testing.T) {
+	dir := t.TempDir()
+	notAfter := time.Now().Add(time.Hour)
+
+	ca, caKey := writeTestCert(t, dir, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	writeTestCert(t, dir, "server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	writeTestCert(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "alice"},
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	conf := api.NewConfig()
+	conf.ClientCAFile = filepath.Join(dir, "ca.pem")
+	_, err := api.New("", "", conf, nil, log.Noop(), metrics.Noop())
+	require.EqualError(t, err, "cert_file and key_file must be specified when client_ca_file is set")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	conf.Address = listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	conf.CertFile = filepath.Join(dir, "server.pem")
+	conf.KeyFile = filepath.Join(dir, "server.key")
+	s, err := api.New("", "", conf, nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	var principal string
+	s.RegisterEndpoint("/whoami", "", func(w http.ResponseWriter, r *http.Request) {
+		principal = r.TLS.VerifiedChains[0][0].Subject.CommonName
+	})
+	go func() {
+		_ = s.ListenAndServe()
+	}()
+	t.Cleanup(func() {
+		_ = s.Shutdown(context.Background())
+	})
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: certs, MinVersion: tls.VersionTLS12},
+		}}
+	}
+
+	url := "https://" + conf.Address + "/whoami"
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", conf.Address)
+		if err == nil {
+			conn.Close()
+		}
+		return err == nil
+	}, time.Second*5, time.Millisecond*10)
+
+	// Clients without a certificate are rejected.
+	_, err = newClient().Get(url)
+	require.Error(t, err)
+
+	clientCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key"))
+	require.NoError(t, err)
+
+	res, err := newClient(clientCert).Get(url)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "alice", principal)
+}
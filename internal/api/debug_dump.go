@@ -0,0 +1,56 @@
+package api
+
+import (
+	"archive/zip"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// debugDumpProfiles lists the profiles written to a debug dump along with the
+// debug level used for each.
+var debugDumpProfiles = []struct {
+	name     string
+	fileName string
+	debug    int
+}{
+	{name: "heap", fileName: "heap.pprof", debug: 0},
+	{name: "allocs", fileName: "allocs.pprof", debug: 0},
+	{name: "goroutine", fileName: "goroutine.pprof", debug: 0},
+	{name: "goroutine", fileName: "goroutine.txt", debug: 2},
+	{name: "block", fileName: "block.pprof", debug: 0},
+	{name: "mutex", fileName: "mutex.pprof", debug: 0},
+	{name: "threadcreate", fileName: "threadcreate.pprof", debug: 0},
+}
+
+// handleDebugDump responds with a zip archive containing a snapshot of the
+// heap, goroutine and other runtime profiles of the service. When the query
+// parameter gc is set to true a garbage collection is run before the heap
+// profile is taken.
+func handleDebugDump(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("gc") == "true" {
+		runtime.GC()
+	}
+
+	fileName := fmt.Sprintf("benthos_dump_%v.zip", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%v"`, fileName))
+
+	zw := zip.NewWriter(w)
+	for _, p := range debugDumpProfiles {
+		prof := pprof.Lookup(p.name)
+		if prof == nil {
+			continue
+		}
+		fw, err := zw.Create(p.fileName)
+		if err != nil {
+			return
+		}
+		if err := prof.WriteTo(fw, p.debug); err != nil {
+			return
+		}
+	}
+	_ = zw.Close()
+}
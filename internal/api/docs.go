@@ -14,10 +14,21 @@ func Spec() docs.FieldSpecs {
 			"root_path", "Specifies a general prefix for all endpoints, this can help isolate the service endpoints when using a reverse proxy with other shared services. All endpoints will still be registered at the root as well as behind the prefix, e.g. with a root_path set to `/foo` the endpoint `/version` will be accessible from both `/version` and `/foo/version`.",
 		).HasDefault("/benthos"),
 		docs.FieldBool(
-			"debug_endpoints", "Whether to register a few extra endpoints that can be useful for debugging performance or behavioral problems.",
+			"debug_endpoints", "Whether to register a few extra endpoints that can be useful for debugging performance or behavioral problems, including `/log/level` for changing the log level at runtime. These endpoints are not authenticated and should not be enabled when the server is reachable by untrusted clients.",
 		).HasDefault(false),
 		docs.FieldString("cert_file", "An optional certificate file for enabling TLS.").Advanced().HasDefault(""),
 		docs.FieldString("key_file", "An optional key file for enabling TLS.").Advanced().HasDefault(""),
+		docs.FieldString("client_ca_file", "An optional file containing the certificate authorities used to verify client certificates. When set clients must present a certificate signed by one of them, and `cert_file` and `key_file` must also be set.").Advanced().HasDefault(""),
 		httpdocs.ServerCORSFieldSpec(),
+		docs.FieldString(
+			"ready_components", "A list of the components that must be connected in order for the `/ready` endpoint to report that Benthos is ready, options are `input` and `output`. In streams mode this applies to every stream.",
+			[]string{"output"},
+		).Array().Advanced().HasDefault([]string{"input", "output"}),
+		docs.FieldBool(
+			"error_events", "Whether to record the failures observed by each component of a pipeline, which are exposed by the `/errors` endpoint and the metrics `error_events` and `error_dead_lettered`. Recording failures adds a small overhead to the execution of every processor and is therefore disabled by default.",
+		).Advanced().HasDefault(false).AtVersion("4.2.0"),
+		docs.FieldBool(
+			"error_samples", "Whether failure events returned by the `/errors` endpoint, when `error_events` is enabled, should include a truncated sample of the payload of the message that failed. Payloads may contain sensitive data and are therefore omitted by default.",
+		).Advanced().HasDefault(false).AtVersion("4.2.0"),
 	}
 }
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+type logLevels struct {
+	Level string            `json:"level"`
+	Paths map[string]string `json:"paths"`
+}
+
+// logLevelHandler returns a handler for reading and modifying the log level of
+// a running service. A POST request sets the level specified by the query
+// parameter `level`, optionally scoped to the components at or within the path
+// specified by the query parameter `path`. A DELETE request removes the level of
+// a path, or resets all levels when a path is not specified.
+func logLevelHandler(l log.LevelSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Query().Get("path")
+
+		switch r.Method {
+		case "GET":
+		case "POST":
+			level := r.URL.Query().Get("level")
+			if level == "" {
+				http.Error(w, "Bad request: a level must be specified", http.StatusBadRequest)
+				return
+			}
+			if err := l.SetLevel(level, path); err != nil {
+				http.Error(w, fmt.Sprintf("Bad request: %v", err), http.StatusBadRequest)
+				return
+			}
+		case "DELETE":
+			l.ResetLevel(path)
+		default:
+			http.Error(w, "Method not supported", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var levels logLevels
+		levels.Level, levels.Paths = l.Levels()
+
+		resBytes, err := json.Marshal(levels)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
+
+func (t *Type) registerLogLevelEndpoint() {
+	ls, ok := t.log.(log.LevelSetter)
+	if !ok {
+		return
+	}
+	t.RegisterEndpoint(
+		"/log/level", "Returns the current log level, POST with the query parameter `level` (and optionally `path`) in order to change it, or DELETE in order to reset it.",
+		logLevelHandler(ls),
+	)
+}
@@ -77,6 +77,12 @@ func (e *Environment) NewMapping(blobl string) (*mapping.Executor, error) {
 	return exec, nil
 }
 
+// MappingFilePath returns the resolved path of the file that a mapping is read
+// from when the mapping consists solely of a `from "<path>"` statement.
+func (e *Environment) MappingFilePath(blobl string) (string, bool) {
+	return parser.MappingImportPath(e.pCtx, blobl)
+}
+
 // Deactivated returns a version of the environment where constructors are
 // disabled for all functions and methods, allowing mappings to be parsed and
 // validated but not executed.
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -44,6 +45,11 @@ type Statement struct {
 	input      []rune
 	assignment Assignment
 	query      query.Function
+
+	// Derived when the statement is added to an executor so that they aren't
+	// recalculated for each execution.
+	line   int
+	target TargetPath
 }
 
 // NewStatement initialises a new mapping statement from an Assignment and
@@ -51,7 +57,9 @@ type Statement struct {
 // parsed expression that created the statement.
 func NewStatement(input []rune, assignment Assignment, query query.Function) Statement {
 	return Statement{
-		input, assignment, query,
+		input:      input,
+		assignment: assignment,
+		query:      query,
 	}
 }
 
@@ -66,6 +74,11 @@ type Executor struct {
 	statements []Statement
 
 	maxMapStacks int
+
+	orderedKeys bool
+	objectKeys  []string
+
+	trace TraceFunc
 }
 
 const defaultMaxMapStacks = 5000
@@ -75,7 +88,23 @@ const defaultMaxMapStacks = 5000
 // is an optional slice pointing to the parsed expression that created the
 // executor.
 func NewExecutor(annotation string, input []rune, maps map[string]query.Function, statements ...Statement) *Executor {
-	return &Executor{annotation, input, maps, statements, defaultMaxMapStacks}
+	if len(statements) > 0 {
+		statements = append([]Statement(nil), statements...)
+	}
+	for i, stmt := range statements {
+		if len(input) > 0 && len(stmt.input) > 0 {
+			statements[i].line, _ = LineAndColOf(input, stmt.input)
+		}
+		statements[i].target = stmt.assignment.Target()
+	}
+	return &Executor{
+		annotation:   annotation,
+		input:        input,
+		maps:         maps,
+		statements:   statements,
+		maxMapStacks: defaultMaxMapStacks,
+		orderedKeys:  orderedKeysDefault,
+	}
 }
 
 // SetMaxMapRecursion configures the maximum recursion allowed for maps, if the
@@ -85,6 +114,22 @@ func (e *Executor) SetMaxMapRecursion(m int) {
 	e.maxMapStacks = m
 }
 
+// SetOrderedKeys configures whether the mapping preserves the order of object
+// keys when the result is serialized. When enabled the keys of each object are
+// written in the order they appear within the source document, followed by the
+// order in which they are assigned and then the order in which they appear in
+// object literals of the mapping, with any remaining keys sorted.
+func (e *Executor) SetOrderedKeys(enabled bool) {
+	e.orderedKeys = enabled
+}
+
+// SetObjectKeyOrder sets the keys of object literals within the mapping in the
+// order that they were parsed, which is used in order to preserve the key
+// order of literals when ordered keys are enabled.
+func (e *Executor) SetObjectKeyOrder(keys []string) {
+	e.objectKeys = keys
+}
+
 // Annotation returns a string annotation that describes the mapping executor.
 func (e *Executor) Annotation() string {
 	return e.annotation
@@ -124,22 +169,63 @@ func (e *Executor) QueryPart(index int, msg Message) (bool, error) {
 // query.Delete value, in which case nil is returned and the part should be
 // discarded.
 func (e *Executor) MapPart(index int, msg Message) (*message.Part, error) {
-	return e.mapPart(nil, index, msg)
+	return e.mapPart(nil, index, msg, false)
 }
 
 // MapOnto maps into an existing message part, where mappings are appended to
 // the message rather than being used to construct a new message.
 func (e *Executor) MapOnto(part *message.Part, index int, msg Message) (*message.Part, error) {
-	return e.mapPart(part, index, msg)
+	return e.mapPart(part, index, msg, false)
+}
+
+// MapBatch executes the bloblang mapping once on an entire batch in order to
+// produce a single message part. The messages of the batch are each parsed as
+// JSON documents and the context of the mapping is an array of them, and
+// functions that reference the message being mapped do so from the perspective
+// of the first message of the batch, which the resulting message part also
+// inherits the metadata of.
+//
+// A nil part is returned if the mapping results in a query.Delete value.
+func (e *Executor) MapBatch(msg Message) (*message.Part, error) {
+	if msg.Len() == 0 {
+		return nil, errors.New("unable to map an empty batch")
+	}
+	return e.mapPart(nil, 0, msg, true)
 }
 
-func (e *Executor) mapPart(appendTo *message.Part, index int, reference Message) (*message.Part, error) {
+func batchValue(reference Message) (*interface{}, error) {
+	values := make([]interface{}, reference.Len())
+	for i := range values {
+		jObj, err := reference.Get(i).JSON()
+		if err != nil {
+			if errors.Is(err, message.ErrMessagePartNotExist) {
+				return nil, fmt.Errorf("message %v is empty", i)
+			}
+			return nil, fmt.Errorf("parse message %v as json: %w", i, err)
+		}
+		values[i] = jObj
+	}
+	var value interface{} = values
+	return &value, nil
+}
+
+// Variables only live for the duration of a mapping execution and therefore
+// the maps used to store them are reused.
+var varsPool = sync.Pool{
+	New: func() interface{} {
+		return map[string]interface{}{}
+	},
+}
+
+func (e *Executor) mapPart(appendTo *message.Part, index int, reference Message, wholeBatch bool) (*message.Part, error) {
 	var valuePtr *interface{}
 	var parseErr error
 
 	lazyValue := func() *interface{} {
 		if valuePtr == nil && parseErr == nil {
-			if jObj, err := reference.Get(index).JSON(); err == nil {
+			if wholeBatch {
+				valuePtr, parseErr = batchValue(reference)
+			} else if jObj, err := reference.Get(index).JSON(); err == nil {
 				valuePtr = &jObj
 			} else {
 				if errors.Is(err, message.ErrMessagePartNotExist) {
@@ -155,19 +241,40 @@ func (e *Executor) mapPart(appendTo *message.Part, index int, reference Message)
 	var newPart *message.Part
 	var newValue interface{} = query.Nothing(nil)
 
+	// When mapping onto an existing part its structured contents are only
+	// obtained as mutable (which might clone them) once they're assigned to.
+	valueMutable := true
+
 	if appendTo == nil {
 		newPart = reference.Get(index).Copy()
 	} else {
 		newPart = appendTo
 		if appendObj, err := appendTo.JSON(); err == nil {
 			newValue = appendObj
+			valueMutable = false
 		}
 	}
 
-	vars := map[string]interface{}{}
-
-	for _, stmt := range e.statements {
-		res, err := stmt.query.Exec(query.FunctionContext{
+	vars := varsPool.Get().(map[string]interface{})
+	defer func() {
+		for k := range vars {
+			delete(vars, k)
+		}
+		varsPool.Put(vars)
+	}()
+
+	for i := range e.statements {
+		stmt := &e.statements[i]
+		if jAssign, isJSON := stmt.assignment.(*JSONAssignment); isJSON && !valueMutable {
+			// Assignments to the root replace the value entirely.
+			if len(jAssign.path) > 0 {
+				if appendObj, err := appendTo.JSONMut(); err == nil {
+					newValue = appendObj
+				}
+			}
+			valueMutable = true
+		}
+		res, err := e.execStatement(stmt, query.FunctionContext{
 			Maps:     e.maps,
 			Vars:     vars,
 			Index:    index,
@@ -176,10 +283,6 @@ func (e *Executor) mapPart(appendTo *message.Part, index int, reference Message)
 			NewValue: &newValue,
 		}.WithValueFunc(lazyValue))
 		if err != nil {
-			var line int
-			if len(e.input) > 0 && len(stmt.input) > 0 {
-				line, _ = LineAndColOf(e.input, stmt.input)
-			}
 			var ctxErr query.ErrNoContext
 			if parseErr != nil && errors.As(err, &ctxErr) {
 				if ctxErr.FieldName != "" {
@@ -188,7 +291,7 @@ func (e *Executor) mapPart(appendTo *message.Part, index int, reference Message)
 					err = fmt.Errorf("unable to reference message as structured (with 'this'): %w", parseErr)
 				}
 			}
-			return nil, fmt.Errorf("failed assignment (line %v): %w", line, err)
+			return nil, fmt.Errorf("failed assignment (line %v): %w", stmt.line, err)
 		}
 		if _, isNothing := res.(query.Nothing); isNothing {
 			// Skip assignment entirely
@@ -199,11 +302,7 @@ func (e *Executor) mapPart(appendTo *message.Part, index int, reference Message)
 			Meta:  newPart,
 			Value: &newValue,
 		}); err != nil {
-			var line int
-			if len(e.input) > 0 && len(stmt.input) > 0 {
-				line, _ = LineAndColOf(e.input, stmt.input)
-			}
-			return nil, fmt.Errorf("failed to assign result (line %v): %w", line, err)
+			return nil, fmt.Errorf("failed to assign result (line %v): %w", stmt.line, err)
 		}
 	}
 
@@ -220,7 +319,23 @@ func (e *Executor) mapPart(appendTo *message.Part, index int, reference Message)
 		case []byte:
 			newPart.Set(t)
 		default:
-			newPart.SetJSON(newValue)
+			if !e.orderedKeys {
+				newPart.SetJSON(newValue)
+				break
+			}
+			var sources []*message.Part
+			if wholeBatch {
+				for i := 0; i < reference.Len(); i++ {
+					sources = append(sources, reference.Get(i))
+				}
+			} else {
+				sources = append(sources, reference.Get(index))
+			}
+			resBytes, err := e.orderedJSON(newValue, wholeBatch, sources...)
+			if err != nil {
+				return nil, fmt.Errorf("failed to serialize result: %w", err)
+			}
+			newPart.Set(resBytes)
 		}
 	}
 	return newPart, nil
@@ -234,7 +349,8 @@ func (e *Executor) QueryTargets(ctx query.TargetsContext) (query.TargetsContext,
 	childCtx.Maps = e.maps
 
 	var paths []query.TargetPath
-	for _, stmt := range e.statements {
+	for i := range e.statements {
+		stmt := &e.statements[i]
 		_, tmpPaths := stmt.query.QueryTargets(childCtx)
 		paths = append(paths, tmpPaths...)
 	}
@@ -246,7 +362,8 @@ func (e *Executor) QueryTargets(ctx query.TargetsContext) (query.TargetsContext,
 // within the mapping.
 func (e *Executor) AssignmentTargets() []TargetPath {
 	var paths []TargetPath
-	for _, stmt := range e.statements {
+	for i := range e.statements {
+		stmt := &e.statements[i]
 		paths = append(paths, stmt.assignment.Target())
 	}
 	return paths
@@ -262,10 +379,11 @@ func (e *Executor) Exec(ctx query.FunctionContext) (interface{}, error) {
 	var newObj interface{} = query.Nothing(nil)
 	ctx.NewValue = &newObj
 
-	for _, stmt := range e.statements {
-		res, err := stmt.query.Exec(ctx)
+	for i := range e.statements {
+		stmt := &e.statements[i]
+		res, err := e.execStatement(stmt, ctx)
 		if err != nil {
-			return nil, formatExecErr(err, true, e.input, stmt.input)
+			return nil, formatExecErr(err, true, stmt.line)
 		}
 		if _, isNothing := res.(query.Nothing); isNothing {
 			// Skip assignment entirely
@@ -276,7 +394,7 @@ func (e *Executor) Exec(ctx query.FunctionContext) (interface{}, error) {
 			// Meta: meta, Prevented for now due to .from(int)
 			Value: &newObj,
 		}); err != nil {
-			return nil, formatExecErr(err, false, e.input, stmt.input)
+			return nil, formatExecErr(err, false, stmt.line)
 		}
 	}
 
@@ -285,17 +403,18 @@ func (e *Executor) Exec(ctx query.FunctionContext) (interface{}, error) {
 
 // ExecOnto a provided assignment context.
 func (e *Executor) ExecOnto(ctx query.FunctionContext, onto AssignmentContext) error {
-	for _, stmt := range e.statements {
-		res, err := stmt.query.Exec(ctx)
+	for i := range e.statements {
+		stmt := &e.statements[i]
+		res, err := e.execStatement(stmt, ctx)
 		if err != nil {
-			return formatExecErr(err, true, e.input, stmt.input)
+			return formatExecErr(err, true, stmt.line)
 		}
 		if _, isNothing := res.(query.Nothing); isNothing {
 			// Skip assignment entirely
 			continue
 		}
 		if err = stmt.assignment.Apply(res, onto); err != nil {
-			return formatExecErr(err, false, e.input, stmt.input)
+			return formatExecErr(err, false, stmt.line)
 		}
 	}
 	return nil
@@ -355,17 +474,12 @@ func (e *errStacks) Error() string {
 	return fmt.Sprintf("entering %v exceeded maximum allowed stacks of %v, this could be due to unbounded recursion", e.annotation, e.maxStacks)
 }
 
-func formatExecErr(err error, onExec bool, input, stmtInput []rune) error {
+func formatExecErr(err error, onExec bool, line int) error {
 	var u *failedAssignmentErr
 	if errors.As(err, &u) {
 		return u
 	}
 
-	var line int
-	if len(input) > 0 && len(stmtInput) > 0 {
-		line, _ = LineAndColOf(input, stmtInput)
-	}
-
 	var e *errStacks
 	if errors.As(err, &e) {
 		err = e
@@ -278,6 +278,150 @@ func TestAssignments(t *testing.T) {
 	}
 }
 
+func TestMapOntoCopyOnWrite(t *testing.T) {
+	original := message.NewPart([]byte(`{"foo":"bar"}`))
+	_, err := original.JSON()
+	require.NoError(t, err)
+
+	mapping := NewExecutor("", nil, nil,
+		NewStatement(nil, NewJSONAssignment("baz"), query.NewLiteralFunction("", "buz")),
+	)
+
+	res, err := mapping.MapOnto(original.Copy(), 0, message.QuickBatch(nil))
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"baz":"buz","foo":"bar"}`, string(res.Get()))
+	assert.Equal(t, `{"foo":"bar"}`, string(original.Get()))
+
+	originalJSON, err := original.JSON()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"foo": "bar"}, originalJSON)
+}
+
+func TestMapTrace(t *testing.T) {
+	metaKey := "baz"
+	input := []rune("root.foo = \"bar\"\nmeta baz = nothing\nlet buz = fail")
+	mapping := NewExecutor("", input, nil,
+		NewStatement(input, NewJSONAssignment("foo"), query.NewLiteralFunction("", "bar")),
+		NewStatement(input[17:], NewMetaAssignment(&metaKey), query.NewLiteralFunction("", query.Nothing(nil))),
+		NewStatement(input[36:], NewVarAssignment("buz"), query.ClosureFunction("", func(ctx query.FunctionContext) (interface{}, error) {
+			return nil, errors.New("nope")
+		}, nil)),
+	)
+
+	var entries []TraceEntry
+	traced := mapping.WithTrace(func(entry TraceEntry) {
+		entries = append(entries, entry)
+	})
+
+	_, err := traced.MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+	require.Error(t, err)
+
+	require.Len(t, entries, 3)
+	for i, e := range entries {
+		assert.Equal(t, i+1, e.Line)
+		assert.GreaterOrEqual(t, int64(e.Elapsed), int64(0))
+		entries[i].Elapsed = 0
+	}
+	assert.Equal(t, "line 1: root.foo = \"bar\" (0s)", entries[0].String())
+	assert.Equal(t, "line 2: meta baz skipped after 0s", entries[1].String())
+	assert.Equal(t, "line 3: let buz failed after 0s: nope", entries[2].String())
+
+	// The original executor is not traced.
+	entries = nil
+	_, err = mapping.MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+	require.Error(t, err)
+	assert.Empty(t, entries)
+
+	// Trace functions are cumulative.
+	var lines []int
+	_, err = traced.WithTrace(func(entry TraceEntry) {
+		lines = append(lines, entry.Line)
+	}).MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+	require.Error(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, []int{1, 2, 3}, lines)
+}
+
+func TestMapVarsReset(t *testing.T) {
+	mapping := NewExecutor("", nil, nil,
+		NewStatement(nil, NewJSONAssignment("vars"), query.ClosureFunction("", func(ctx query.FunctionContext) (interface{}, error) {
+			return int64(len(ctx.Vars)), nil
+		}, nil)),
+		NewStatement(nil, NewVarAssignment("foo"), query.NewLiteralFunction("", "bar")),
+	)
+
+	for i := 0; i < 3; i++ {
+		part, err := mapping.MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+		require.NoError(t, err)
+		assert.Equal(t, `{"vars":0}`, string(part.Get()))
+	}
+}
+
+func TestMapBatch(t *testing.T) {
+	msg := message.QuickBatch([][]byte{
+		[]byte(`{"id":"foo"}`),
+		[]byte(`{"id":"bar"}`),
+	})
+	msg.Get(0).MetaSet("topic", "first")
+	msg.Get(1).MetaSet("topic", "second")
+
+	mapping := NewExecutor("", nil, nil,
+		NewStatement(nil, NewJSONAssignment("docs"), query.NewFieldFunction("")),
+	)
+
+	res, err := mapping.MapBatch(msg)
+	require.NoError(t, err)
+	assert.Equal(t, `{"docs":[{"id":"foo"},{"id":"bar"}]}`, string(res.Get()))
+	assert.Equal(t, "first", res.MetaGet("topic"))
+
+	_, err = mapping.MapBatch(message.QuickBatch([][]byte{
+		[]byte(`{"id":"foo"}`),
+		[]byte(`not json`),
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parse message 1 as json")
+
+	_, err = mapping.MapBatch(message.QuickBatch(nil))
+	require.EqualError(t, err, "unable to map an empty batch")
+}
+
+func TestMapOrderedKeys(t *testing.T) {
+	mapping := NewExecutor("", nil, nil,
+		NewStatement(nil, NewJSONAssignment(), query.NewFieldFunction("")),
+		NewStatement(nil, NewJSONAssignment("zed"), query.NewLiteralFunction("", "new")),
+		NewStatement(nil, NewJSONAssignment("nested", "new"), query.NewLiteralFunction("", map[string]interface{}{
+			"b": "<b>", "c": "c", "a": "a",
+		})),
+		NewStatement(nil, NewJSONAssignment("alpha"), query.NewLiteralFunction("", "newer")),
+	)
+	mapping.SetObjectKeyOrder([]string{"c", "b"})
+
+	input := `{"id":"foo","nested":{"z":[{"y":1,"x":2},{"x":3,"w":4}],"b":true},"delta":1.50}`
+
+	res, err := mapping.MapPart(0, message.QuickBatch([][]byte{[]byte(input)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"alpha":"newer","delta":1.50,"id":"foo","nested":{"b":true,"new":{"a":"a","b":"<b>","c":"c"},"z":[{"x":2,"y":1},{"w":4,"x":3}]},"zed":"new"}`, string(res.Get()))
+
+	mapping.SetOrderedKeys(true)
+
+	res, err = mapping.MapPart(0, message.QuickBatch([][]byte{[]byte(input)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"foo","nested":{"z":[{"y":1,"x":2},{"x":3,"w":4}],"b":true,"new":{"c":"c","b":"<b>","a":"a"}},"delta":1.50,"zed":"new","alpha":"newer"}`, string(res.Get()))
+
+	batchMapping := NewExecutor("", nil, nil,
+		NewStatement(nil, NewJSONAssignment("docs"), query.NewFieldFunction("")),
+	)
+	batchMapping.SetOrderedKeys(true)
+
+	res, err = batchMapping.MapBatch(message.QuickBatch([][]byte{
+		[]byte(`{"id":"foo","b":1}`),
+		[]byte(`{"id":"bar","a":2}`),
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"docs":[{"id":"foo","b":1},{"id":"bar","a":2}]}`, string(res.Get()))
+}
+
 func TestTargets(t *testing.T) {
 	function := func(name string, args ...interface{}) query.Function {
 		t.Helper()
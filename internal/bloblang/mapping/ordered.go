@@ -0,0 +1,217 @@
+package mapping
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// orderedKeysDefault is whether executors preserve the order of object keys
+// unless configured otherwise, which can be enabled for all mappings with the
+// environment variable BENTHOS_ORDERED_KEYS.
+var orderedKeysDefault = os.Getenv("BENTHOS_ORDERED_KEYS") == "true"
+
+// The separator of path segments within a keyOrder, and the segment used for
+// the elements of an array.
+const (
+	keyPathSep  = "\x00"
+	keyPathElem = keyPathSep + "*"
+)
+
+// keyOrder describes the preferred order of the keys of objects within a
+// structured document. Keys are ranked by the path of the object they belong
+// to, where the elements of an array share the same path. Keys that aren't
+// ranked by path fall back to the order of the object literals of a mapping,
+// and then the order that they first appear in the source documents, which
+// covers source objects that were moved to a new path.
+type keyOrder struct {
+	paths    map[string]map[string]int
+	sources  map[string]int
+	literals map[string]int
+}
+
+func newKeyOrder(literalKeys []string) *keyOrder {
+	o := &keyOrder{
+		paths:    map[string]map[string]int{},
+		sources:  map[string]int{},
+		literals: map[string]int{},
+	}
+	for _, k := range literalKeys {
+		addRank(o.literals, k)
+	}
+	return o
+}
+
+func addRank(ranks map[string]int, key string) {
+	if _, exists := ranks[key]; !exists {
+		ranks[key] = len(ranks)
+	}
+}
+
+func (o *keyOrder) add(path, key string) {
+	keys, exists := o.paths[path]
+	if !exists {
+		keys = map[string]int{}
+		o.paths[path] = keys
+	}
+	addRank(keys, key)
+}
+
+// addSource ranks the keys of a serialized JSON document in the order that
+// they appear. Parsing stops at the first invalid token as the ranks are only
+// a preference.
+func (o *keyOrder) addSource(path string, dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, isDelim := tok.(json.Delim)
+	if !isDelim {
+		return nil
+	}
+	switch delim {
+	case '{':
+		for dec.More() {
+			if tok, err = dec.Token(); err != nil {
+				return err
+			}
+			key, _ := tok.(string)
+			o.add(path, key)
+			addRank(o.sources, key)
+			if err = o.addSource(path+keyPathSep+key, dec); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for dec.More() {
+			if err = o.addSource(path+keyPathElem, dec); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// addAssignments ranks the keys of the paths of JSON assignments in the order
+// that they're assigned.
+func (o *keyOrder) addAssignments(statements []Statement) {
+	for _, stmt := range statements {
+		jAssign, isJSON := stmt.assignment.(*JSONAssignment)
+		if !isJSON {
+			continue
+		}
+		var path string
+		for _, key := range jAssign.path {
+			o.add(path, key)
+			path += keyPathSep + key
+		}
+	}
+}
+
+// sortKeys returns the keys of an object at a given path in their preferred
+// order.
+func (o *keyOrder) sortKeys(path string, obj map[string]interface{}) []string {
+	pathRanks := o.paths[path]
+	rank := func(k string) (tier, n int) {
+		if n, exists := pathRanks[k]; exists {
+			return 0, n
+		}
+		if n, exists := o.literals[k]; exists {
+			return 1, n
+		}
+		if n, exists := o.sources[k]; exists {
+			return 2, n
+		}
+		return 3, 0
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		iTier, iN := rank(keys[i])
+		jTier, jN := rank(keys[j])
+		if iTier != jTier {
+			return iTier < jTier
+		}
+		if iN != jN {
+			return iN < jN
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// orderedJSON serializes the result of a mapping with the keys of objects in
+// the order described by the source documents, the assignments of the mapping
+// and its object literals.
+func (e *Executor) orderedJSON(value interface{}, wholeBatch bool, sources ...*message.Part) ([]byte, error) {
+	order := newKeyOrder(e.objectKeys)
+
+	sourcePath := ""
+	if wholeBatch {
+		sourcePath = keyPathElem
+	}
+	for _, p := range sources {
+		_ = order.addSource(sourcePath, json.NewDecoder(bytes.NewReader(p.Get())))
+	}
+	order.addAssignments(e.statements)
+
+	var buf bytes.Buffer
+	if err := writeOrdered(&buf, "", value, order); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeOrdered(buf *bytes.Buffer, path string, value interface{}, order *keyOrder) error {
+	switch t := value.(type) {
+	case map[string]interface{}:
+		buf.WriteByte('{')
+		for i, k := range order.sortKeys(path, t) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONValue(buf, k); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeOrdered(buf, path+keyPathSep+k, t[k], order); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, v := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeOrdered(buf, path+keyPathElem, v, order); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+	return writeJSONValue(buf, value)
+}
+
+// writeJSONValue serializes a value in the same way as a message part, which
+// does not escape HTML characters.
+func writeJSONValue(buf *bytes.Buffer, value interface{}) error {
+	var vBuf bytes.Buffer
+	enc := json.NewEncoder(&vBuf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return err
+	}
+	buf.Write(bytes.TrimSuffix(vBuf.Bytes(), []byte("\n")))
+	return nil
+}
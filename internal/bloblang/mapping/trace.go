@@ -0,0 +1,89 @@
+package mapping
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+// TraceEntry describes the execution of a single statement of a mapping.
+type TraceEntry struct {
+	// Line is the line number of the statement within the mapping, or zero
+	// if unknown.
+	Line int
+
+	// Target is the destination of the statement, which is shared between
+	// entries and must not be modified.
+	Target TargetPath
+
+	// Result is the value produced by the query of the statement, which is
+	// query.Nothing when the assignment was skipped.
+	Result interface{}
+
+	// Err is the error returned by the query of the statement, if any.
+	Err error
+
+	// Elapsed is the time taken to execute the query of the statement.
+	Elapsed time.Duration
+}
+
+// String returns a human readable representation of the trace entry.
+func (t TraceEntry) String() string {
+	if t.Err != nil {
+		return fmt.Sprintf("line %v: %v failed after %v: %v", t.Line, t.Target, t.Elapsed, t.Err)
+	}
+	if _, isNothing := t.Result.(query.Nothing); isNothing {
+		return fmt.Sprintf("line %v: %v skipped after %v", t.Line, t.Target, t.Elapsed)
+	}
+	var resStr string
+	switch t.Result.(type) {
+	case query.Delete:
+		resStr = "deleted()"
+	case string, []byte:
+		resStr = fmt.Sprintf("%q", query.IToString(t.Result))
+	default:
+		resStr = query.IToString(t.Result)
+	}
+	return fmt.Sprintf("line %v: %v = %v (%v)", t.Line, t.Target, resStr, t.Elapsed)
+}
+
+// TraceFunc is called for each statement executed by a mapping.
+type TraceFunc func(entry TraceEntry)
+
+// WithTrace returns a copy of the executor that calls a function with the
+// result of each statement as it is executed, which is useful for inspecting
+// the intermediate values of a mapping or instrumenting its statements. Trace
+// functions are cumulative, and so any already added to the executor are
+// called before the new function.
+func (e *Executor) WithTrace(fn TraceFunc) *Executor {
+	newE := *e
+	if prev := e.trace; prev != nil {
+		newE.trace = func(entry TraceEntry) {
+			prev(entry)
+			fn(entry)
+		}
+	} else {
+		newE.trace = fn
+	}
+	return &newE
+}
+
+func (e *Executor) execStatement(stmt *Statement, ctx query.FunctionContext) (interface{}, error) {
+	if e.trace == nil {
+		return stmt.query.Exec(ctx)
+	}
+
+	start := time.Now()
+	res, err := stmt.query.Exec(ctx)
+	elapsed := time.Since(start)
+
+	e.trace(TraceEntry{
+		Line:    stmt.line,
+		Target:  stmt.target,
+		Result:  res,
+		Err:     err,
+		Elapsed: elapsed,
+	})
+	return res, err
+}
@@ -136,9 +136,32 @@ func InRange(lower, upper rune) Func {
 	}
 }
 
-// SpacesAndTabs parses any number of space or tab characters.
+// SpacesAndTabs parses any number of space or tab characters, along with any
+// block comments that do not span multiple lines.
 func SpacesAndTabs() Func {
-	return Expect(InSet(' ', '\t'), "whitespace")
+	spaces, comment := InSet(' ', '\t'), BlockComment()
+	return Expect(func(input []rune) Result {
+		remaining := input
+		for {
+			res := spaces(remaining)
+			if res.Err != nil {
+				if res = comment(remaining); res.Err != nil {
+					if res.Err.IsFatal() {
+						return Fail(res.Err, input)
+					}
+					break
+				}
+				if strings.ContainsRune(res.Payload.(string), '\n') {
+					break
+				}
+			}
+			remaining = res.Remaining
+		}
+		if len(remaining) == len(input) {
+			return Fail(NewError(input, "whitespace"), input)
+		}
+		return Success(string(input[:len(input)-len(remaining)]), remaining)
+	}, "whitespace")
 }
 
 // Term parses a single instance of a string.
@@ -371,7 +394,8 @@ func JoinStringPayloads(p Func) Func {
 	}
 }
 
-// Comment parses a # comment (always followed by a line break).
+// Comment parses a # comment (always followed by a line break or the end of the
+// input).
 func Comment() Func {
 	p := JoinStringPayloads(
 		Sequence(
@@ -379,7 +403,7 @@ func Comment() Func {
 			JoinStringPayloads(
 				Optional(UntilFail(NotChar('\n'))),
 			),
-			Newline(),
+			OneOf(Newline(), EndOfInput()),
 		),
 	)
 	return func(input []rune) Result {
@@ -387,6 +411,22 @@ func Comment() Func {
 	}
 }
 
+// BlockComment parses a /* */ comment, which may span multiple lines. The
+// result is the entire comment including its delimiters.
+func BlockComment() Func {
+	return func(input []rune) Result {
+		if len(input) < 2 || input[0] != '/' || input[1] != '*' {
+			return Fail(NewError(input, "block comment"), input)
+		}
+		for i := 2; i < len(input)-1; i++ {
+			if input[i] == '*' && input[i+1] == '/' {
+				return Success(string(input[:i+2]), input[i+2:])
+			}
+		}
+		return Fail(NewFatalError(input[len(input):], errors.New("required"), "end of block comment"), input)
+	}
+}
+
 // SnakeCase parses any number of characters of a camel case string. This parser
 // is very strict and does not support double underscores, prefix or suffix
 // underscores.
@@ -483,9 +523,17 @@ func Newline() Func {
 }
 
 // NewlineAllowComment parses an optional comment followed by a mandatory line
-// break.
+// break, or a block comment that spans multiple lines.
 func NewlineAllowComment() Func {
-	return Expect(OneOf(Comment(), Newline()), "line break")
+	comment := BlockComment()
+	multilineComment := func(input []rune) Result {
+		res := comment(input)
+		if res.Err == nil && !strings.ContainsRune(res.Payload.(string), '\n') {
+			return Fail(NewError(input, "line break"), input)
+		}
+		return res
+	}
+	return Expect(OneOf(Comment(), Newline(), multilineComment), "line break")
 }
 
 // UntilFail applies a parser until it fails, and returns a slice containing all
@@ -647,6 +695,9 @@ func DiscardAll(parser Func) Func {
 		for res.Err == nil {
 			res = parser(res.Remaining)
 		}
+		if res.Err.IsFatal() {
+			return Fail(res.Err, input)
+		}
 		res.Payload = nil
 		res.Err = nil
 		return res
@@ -1693,6 +1693,16 @@ func TestSpacesAndTabs(t *testing.T) {
 			remaining: "nononono",
 			err:       NewError([]rune("nononono"), "whitespace"),
 		},
+		"block comments": {
+			input:     " /* foo */\t/* bar */ and this",
+			remaining: "and this",
+			result:    " /* foo */\t/* bar */ ",
+		},
+		"multiple line block comment": {
+			input:     " /* foo\nbar */ and this",
+			remaining: "/* foo\nbar */ and this",
+			result:    " ",
+		},
 	}
 
 	for name, test := range tests {
@@ -1705,6 +1715,55 @@ func TestSpacesAndTabs(t *testing.T) {
 	}
 }
 
+func TestBlockComment(t *testing.T) {
+	comment := BlockComment()
+
+	tests := map[string]struct {
+		input     string
+		result    interface{}
+		remaining string
+		err       *Error
+	}{
+		"empty input": {
+			err: NewError([]rune(""), "block comment"),
+		},
+		"not a comment": {
+			input:     "/ 2",
+			remaining: "/ 2",
+			err:       NewError([]rune("/ 2"), "block comment"),
+		},
+		"single line": {
+			input:     "/* foo */ and this",
+			remaining: " and this",
+			result:    "/* foo */",
+		},
+		"multiple lines": {
+			input:     "/* foo\n * bar\n */\nand this",
+			remaining: "\nand this",
+			result:    "/* foo\n * bar\n */",
+		},
+		"followed by an asterisk": {
+			input:     "/* foo */* bar",
+			remaining: "* bar",
+			result:    "/* foo */",
+		},
+		"never ends": {
+			input:     "/* foo",
+			remaining: "/* foo",
+			err:       NewFatalError([]rune(""), errors.New("required"), "end of block comment"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := comment([]rune(test.input))
+			require.Equal(t, test.err, res.Err, "Error")
+			assert.Equal(t, test.result, res.Payload, "Result")
+			assert.Equal(t, test.remaining, string(res.Remaining), "Remaining")
+		})
+	}
+}
+
 func TestNewline(t *testing.T) {
 	inSet := Newline()
 
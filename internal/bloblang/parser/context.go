@@ -6,7 +6,9 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/wasm"
 )
 
 // Context contains context used throughout a Bloblang parser for
@@ -16,6 +18,31 @@ type Context struct {
 	Methods      *query.MethodSet
 	namedContext *namedContext
 	importer     Importer
+
+	// The resolved paths of the files currently being imported, which is used
+	// in order to detect import cycles.
+	importChain []string
+
+	// Files that have already been imported during a parse, keyed by their
+	// resolved path, which allows the same file to be imported by multiple
+	// files without its maps colliding.
+	imported map[string]*mapping.Executor
+
+	// The keys of object literals in the order that they were parsed, which
+	// is given to executors in order to preserve key order when serializing.
+	objectKeys *[]string
+
+	// Methods provided by WASM modules declared with use statements, keyed by
+	// their name.
+	wasmMethods map[string]*wasm.Module
+}
+
+// withObjectKeys returns a Context that records the keys of object literals
+// that are parsed.
+func (pCtx Context) withObjectKeys() (Context, *[]string) {
+	keys := []string{}
+	pCtx.objectKeys = &keys
+	return pCtx, &keys
 }
 
 // EmptyContext returns a parser context with no functions, methods or import
@@ -71,9 +98,19 @@ func (pCtx Context) InitFunction(name string, args *query.ParsedParams) (query.F
 // InitMethod attempts to initialise a method from the available constructors of
 // the parser context.
 func (pCtx Context) InitMethod(name string, target query.Function, args *query.ParsedParams) (query.Function, error) {
+	if mod, exists := pCtx.wasmMethods[name]; exists {
+		return mod.Method(name, target), nil
+	}
 	return pCtx.Methods.Init(name, target, args)
 }
 
+func (pCtx Context) methodParams(name string) (query.Params, error) {
+	if _, exists := pCtx.wasmMethods[name]; exists {
+		return query.NewParams(), nil
+	}
+	return pCtx.Methods.Params(name)
+}
+
 // WithImporter returns a Context where imports are made from the provided
 // Importer implementation.
 func (pCtx Context) WithImporter(importer Importer) Context {
@@ -129,6 +166,10 @@ type Importer interface {
 	// Import a file from a relative or absolute path.
 	Import(pathStr string) ([]byte, error)
 
+	// Resolve a relative or absolute path into the path that Import would
+	// read from, which identifies the file across importers.
+	ResolvePath(pathStr string) string
+
 	// Derive a new importer where relative import paths are resolved from the
 	// directory of the provided file path. The provided path could be absolute,
 	// or relative itself in which case it should be resolved from the
@@ -150,17 +191,20 @@ func newOSImporter() Importer {
 }
 
 func (i *osImporter) Import(pathStr string) ([]byte, error) {
-	if !filepath.IsAbs(pathStr) {
-		pathStr = filepath.Join(i.relativePath, pathStr)
-	}
-
-	f, err := os.Open(pathStr)
+	f, err := os.Open(i.ResolvePath(pathStr))
 	if err != nil {
 		return nil, err
 	}
 	return io.ReadAll(f)
 }
 
+func (i *osImporter) ResolvePath(pathStr string) string {
+	if !filepath.IsAbs(pathStr) {
+		pathStr = filepath.Join(i.relativePath, pathStr)
+	}
+	return pathStr
+}
+
 func (i *osImporter) RelativeToFile(filePath string) Importer {
 	dir := filepath.Dir(filePath)
 	if dir == "" || dir == "." {
@@ -192,11 +236,14 @@ func newCustomImporter(readFn func(name string) ([]byte, error)) Importer {
 }
 
 func (i *customImporter) Import(pathStr string) ([]byte, error) {
+	return i.readFn(i.ResolvePath(pathStr))
+}
+
+func (i *customImporter) ResolvePath(pathStr string) string {
 	if !filepath.IsAbs(pathStr) {
 		pathStr = filepath.Join(i.relativePath, pathStr)
 	}
-
-	return i.readFn(pathStr)
+	return pathStr
 }
 
 func (i *customImporter) RelativeToFile(filePath string) Importer {
@@ -223,6 +270,10 @@ func (d disabledImporter) Import(pathStr string) ([]byte, error) {
 	return nil, errors.New("imports are disabled in this context")
 }
 
+func (d disabledImporter) ResolvePath(pathStr string) string {
+	return pathStr
+}
+
 func (d disabledImporter) RelativeToFile(filePath string) Importer {
 	return d
 }
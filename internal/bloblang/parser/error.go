@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // LineAndColOf returns the line and column position of a tailing clip from an
@@ -14,10 +15,11 @@ func LineAndColOf(input, clip []rune) (line, col int) {
 
 	lines := strings.Split(string(input), "\n")
 	for ; line < len(lines); line++ {
-		if char < (len(lines[line]) + 1) {
+		lineLen := utf8.RuneCountInString(lines[line])
+		if char < (lineLen + 1) {
 			break
 		}
-		char = char - len(lines[line]) - 1
+		char = char - lineLen - 1
 	}
 
 	return line + 1, char + 1
@@ -150,23 +152,24 @@ func (e *Error) Add(from *Error) {
 	}
 }
 
-// ErrorAtPosition returns a human readable error string including the line and
-// character position of the error.
-func (e *Error) ErrorAtPosition(input []rune) string {
-	importErr, isImport := e.Err.(*ImportError)
-
-	var errStr string
-	if isImport {
-		errStr = fmt.Sprintf(
+// ErrorMessage returns a human readable error string without any information
+// about the position of the error, which is useful when the position is
+// reported separately.
+func (e *Error) ErrorMessage() string {
+	if importErr, isImport := e.Err.(*ImportError); isImport {
+		return fmt.Sprintf(
 			"failed to parse import '%v': %v", importErr.filepath,
 			importErr.perr.ErrorAtPosition(importErr.content),
 		)
-	} else {
-		errStr = e.errorMsg(false)
 	}
+	return e.errorMsg(false)
+}
 
+// ErrorAtPosition returns a human readable error string including the line and
+// character position of the error.
+func (e *Error) ErrorAtPosition(input []rune) string {
 	line, char := LineAndColOf(input, e.Input)
-	return fmt.Sprintf("line %v char %v: %v", line, char, errStr)
+	return fmt.Sprintf("line %v char %v: %v", line, char, e.ErrorMessage())
 }
 
 // ErrorAtChar returns a human readable error string including the character
@@ -203,19 +206,32 @@ func (e *Error) ErrorAtPositionStructured(filepath string, input []rune) string
 	}
 
 	line, char := 0, len(input)-len(e.Input)
-	var contextLine string
+	var contextLine, caretPadding string
 
-	lines := strings.Split(string(input), "\n")
-	for ; line < len(lines); line++ {
-		if char < (len(lines[line]) + 1) {
-			maxLen := len(lines[line])
+	for _, lineStr := range strings.Split(string(input), "\n") {
+		lineRunes := []rune(lineStr)
+		if char < (len(lineRunes) + 1) {
+			maxLen := len(lineRunes)
 			if char < (maxLen - 60) {
 				maxLen = char + 60
 			}
-			contextLine = lines[line][:maxLen]
+			contextLine = string(lineRunes[:maxLen])
+
+			// Tabs are preserved in the padding so that the caret remains
+			// aligned with the context line.
+			padding := make([]rune, char)
+			for i, r := range lineRunes[:char] {
+				if r == '\t' {
+					padding[i] = '\t'
+				} else {
+					padding[i] = ' '
+				}
+			}
+			caretPadding = string(padding)
 			break
 		}
-		char = char - len(lines[line]) - 1
+		char = char - len(lineRunes) - 1
+		line++
 	}
 
 	lineStr := strconv.FormatInt(int64(line+1), 10)
@@ -234,7 +250,7 @@ func (e *Error) ErrorAtPositionStructured(filepath string, input []rune) string
 		lineStr, char+1, errStr,
 		linePadding,
 		lineStr, contextLine,
-		linePadding, strings.Repeat(" ", char))
+		linePadding, caretPadding)
 
 	if isImport {
 		structuredMsg = structuredMsg + "\n\n" + importErr.perr.ErrorAtPositionStructured(importErr.filepath, importErr.content)
@@ -100,6 +100,11 @@ func TestErrorPositionalStrings(t *testing.T) {
 			err:   NewError([]rune("i"), "foo", "bar", "baz"),
 			exp:   `line 3 char 2: expected foo, bar, or baz`,
 		},
+		{
+			input: "héllo wörld\nänd input data",
+			err:   NewError([]rune("input data"), "foo", "bar", "baz"),
+			exp:   `line 2 char 5: expected foo, bar, or baz`,
+		},
 	}
 
 	for _, test := range tests {
@@ -169,6 +174,19 @@ func TestErrorPositionalStringsStructured(t *testing.T) {
 4 | long input string input
   |                   ^---`,
 		},
+		{
+			input: "héllo wörld\nänd input data",
+			err:   NewError([]rune("input data"), "foo", "bar", "baz"),
+			exp: `line 2 char 5: expected foo, bar, or baz
+  |
+2 | änd input data
+  |     ^---`,
+		},
+		{
+			input: "root = {\n\t\"foo\": this.bar,\n\t\"baz\" this.buz\n}",
+			err:   NewError([]rune("this.buz\n}"), "colon"),
+			exp:   "line 3 char 8: expected colon\n  |\n3 | \t\"baz\" this.buz\n  | \t      ^---",
+		},
 	}
 
 	for _, test := range tests {
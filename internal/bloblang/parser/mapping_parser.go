@@ -9,6 +9,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/wasm"
 )
 
 // ParseMapping parses a bloblang mapping and returns an executor to run it, or
@@ -18,6 +19,7 @@ import (
 // messages.
 func ParseMapping(pCtx Context, expr string) (*mapping.Executor, *Error) {
 	in := []rune(expr)
+	pCtx.imported = map[string]*mapping.Executor{}
 
 	resDirectImport := singleRootImport(pCtx)(in)
 	if resDirectImport.Err != nil && resDirectImport.Err.IsFatal() {
@@ -50,9 +52,12 @@ func parseExecutor(pCtx Context) Func {
 	return func(input []rune) Result {
 		maps := map[string]query.Function{}
 		statements := []mapping.Statement{}
+		pCtx, objectKeys := pCtx.withObjectKeys()
+		pCtx.wasmMethods = map[string]*wasm.Module{}
 
 		statement := OneOf(
 			importParser(maps, pCtx),
+			useParser(pCtx),
 			mapParser(maps, pCtx),
 			letStatementParser(pCtx),
 			metaStatementParser(false, pCtx),
@@ -60,6 +65,9 @@ func parseExecutor(pCtx Context) Func {
 		)
 
 		res := allWhitespace(input)
+		if res.Err != nil {
+			return res
+		}
 
 		res = statement(res.Remaining)
 		if res.Err != nil {
@@ -80,7 +88,9 @@ func parseExecutor(pCtx Context) Func {
 				return Fail(res.Err, input)
 			}
 
-			res = allWhitespace(res.Remaining)
+			if res = allWhitespace(res.Remaining); res.Err != nil {
+				return Fail(res.Err, input)
+			}
 			if len(res.Remaining) == 0 {
 				break
 			}
@@ -92,21 +102,38 @@ func parseExecutor(pCtx Context) Func {
 				statements = append(statements, mStmt)
 			}
 		}
-		return Success(mapping.NewExecutor("", input, maps, statements...), res.Remaining)
+		exec := mapping.NewExecutor("", input, maps, statements...)
+		exec.SetObjectKeyOrder(*objectKeys)
+		return Success(exec, res.Remaining)
 	}
 }
 
-func singleRootImport(pCtx Context) Func {
+func singleRootImportParser() Func {
 	whitespace := SpacesAndTabs()
 	allWhitespace := DiscardAll(OneOf(whitespace, Newline()))
 
-	parser := Sequence(
+	return Sequence(
 		allWhitespace,
 		Term("from"),
 		whitespace,
 		QuotedString(),
 		allWhitespace,
 	)
+}
+
+// MappingImportPath returns the resolved path of the file that a mapping is
+// read from when the mapping consists solely of a `from "<path>"` statement.
+func MappingImportPath(pCtx Context, expr string) (string, bool) {
+	res := singleRootImportParser()([]rune(expr))
+	if res.Err != nil || len(res.Remaining) > 0 {
+		return "", false
+	}
+	fpath := res.Payload.([]interface{})[3].(string)
+	return pCtx.importer.ResolvePath(fpath), true
+}
+
+func singleRootImport(pCtx Context) Func {
+	parser := singleRootImportParser()
 
 	return func(input []rune) Result {
 		res := parser(input)
@@ -115,20 +142,48 @@ func singleRootImport(pCtx Context) Func {
 		}
 
 		fpath := res.Payload.([]interface{})[3].(string)
-		contents, err := pCtx.importer.Import(fpath)
+		exec, err := parseImport(pCtx, input, fpath)
 		if err != nil {
-			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
+			return Fail(err, input)
 		}
+		return Success(exec, res.Remaining)
+	}
+}
 
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
-
-		importContent := []rune(string(contents))
-		execRes := parseExecutor(nextCtx)(importContent)
-		if execRes.Err != nil {
-			return Fail(NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
+// parseImport reads and parses a file imported by a mapping. Files that have
+// already been parsed are reused, and a fatal error is returned when the file
+// is already being imported further up the chain of imports.
+func parseImport(pCtx Context, input []rune, fpath string) (*mapping.Executor, *Error) {
+	resolved := pCtx.importer.ResolvePath(fpath)
+	for i, p := range pCtx.importChain {
+		if p == resolved {
+			cycle := append(append([]string{}, pCtx.importChain[i:]...), resolved)
+			return nil, NewFatalError(input, fmt.Errorf("import cycle detected: %v", strings.Join(cycle, " -> ")))
 		}
-		return Success(execRes.Payload.(*mapping.Executor), res.Remaining)
 	}
+	if exec, exists := pCtx.imported[resolved]; exists {
+		return exec, nil
+	}
+
+	contents, err := pCtx.importer.Import(fpath)
+	if err != nil {
+		return nil, NewFatalError(input, fmt.Errorf("failed to read import: %w", err))
+	}
+
+	nextCtx := pCtx.WithImporterRelativeToFile(fpath)
+	nextCtx.importChain = append(append([]string{}, pCtx.importChain...), resolved)
+
+	importContent := []rune(string(contents))
+	execRes := parseExecutor(nextCtx)(importContent)
+	if execRes.Err != nil {
+		return nil, NewFatalError(input, NewImportError(fpath, importContent, execRes.Err))
+	}
+
+	exec := execRes.Payload.(*mapping.Executor)
+	if pCtx.imported != nil {
+		pCtx.imported[resolved] = exec
+	}
+	return exec, nil
 }
 
 func singleRootMapping(pCtx Context) Func {
@@ -136,6 +191,7 @@ func singleRootMapping(pCtx Context) Func {
 	allWhitespace := DiscardAll(OneOf(whitespace, Newline()))
 
 	return func(input []rune) Result {
+		pCtx, objectKeys := pCtx.withObjectKeys()
 		res := queryParser(pCtx)(input)
 		if res.Err != nil {
 			return res
@@ -162,7 +218,9 @@ func singleRootMapping(pCtx Context) Func {
 		}
 
 		stmt := mapping.NewStatement(input, mapping.NewJSONAssignment(), fn)
-		return Success(mapping.NewExecutor("", input, map[string]query.Function{}, stmt), nil)
+		exec := mapping.NewExecutor("", input, map[string]query.Function{}, stmt)
+		exec.SetObjectKeyOrder(*objectKeys)
+		return Success(exec, nil)
 	}
 }
 
@@ -200,20 +258,11 @@ func importParser(maps map[string]query.Function, pCtx Context) Func {
 		}
 
 		fpath := res.Payload.([]interface{})[2].(string)
-		contents, err := pCtx.importer.Import(fpath)
-		if err != nil {
-			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
-		}
-
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
-
-		importContent := []rune(string(contents))
-		execRes := parseExecutor(nextCtx)(importContent)
-		if execRes.Err != nil {
-			return Fail(NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
+		exec, perr := parseImport(pCtx, input, fpath)
+		if perr != nil {
+			return Fail(perr, input)
 		}
 
-		exec := execRes.Payload.(*mapping.Executor)
 		if len(exec.Maps()) == 0 {
 			err := fmt.Errorf("no maps to import from '%v'", fpath)
 			return Fail(NewFatalError(input, err), input)
@@ -221,8 +270,12 @@ func importParser(maps map[string]query.Function, pCtx Context) Func {
 
 		collisions := []string{}
 		for k, v := range exec.Maps() {
-			if _, exists := maps[k]; exists {
-				collisions = append(collisions, k)
+			if existing, exists := maps[k]; exists {
+				// The same map can be reached through multiple imports of a
+				// common file, which isn't a collision.
+				if existingExec, ok := existing.(*mapping.Executor); !ok || existingExec != v {
+					collisions = append(collisions, k)
+				}
 			} else {
 				maps[k] = v
 			}
@@ -236,6 +289,57 @@ func importParser(maps map[string]query.Function, pCtx Context) Func {
 	}
 }
 
+func useParser(pCtx Context) Func {
+	p := Sequence(
+		Term("use"),
+		SpacesAndTabs(),
+		Term("wasm"),
+		SpacesAndTabs(),
+		MustBe(
+			Expect(
+				QuotedString(),
+				"filepath",
+			),
+		),
+	)
+
+	return func(input []rune) Result {
+		res := p(input)
+		if res.Err != nil {
+			return res
+		}
+
+		fpath := res.Payload.([]interface{})[4].(string)
+		contents, err := pCtx.importer.Import(fpath)
+		if err != nil {
+			return Fail(NewFatalError(input, fmt.Errorf("failed to read module: %w", err)), input)
+		}
+
+		mod, err := wasm.NewModule(fpath, contents)
+		if err != nil {
+			return Fail(NewFatalError(input, err), input)
+		}
+
+		collisions := []string{}
+		for _, name := range mod.Methods() {
+			if _, exists := pCtx.wasmMethods[name]; exists {
+				collisions = append(collisions, name)
+			} else if _, err := pCtx.Methods.Params(name); err == nil {
+				collisions = append(collisions, name)
+			}
+		}
+		if len(collisions) > 0 {
+			err := fmt.Errorf("method name collisions from module '%v': %v", fpath, collisions)
+			return Fail(NewFatalError(input, err), input)
+		}
+		for _, name := range mod.Methods() {
+			pCtx.wasmMethods[name] = mod
+		}
+
+		return Success(fpath, res.Remaining)
+	}
+}
+
 func mapParser(maps map[string]query.Function, pCtx Context) Func {
 	newline := NewlineAllowComment()
 	whitespace := SpacesAndTabs()
@@ -23,10 +23,30 @@ func TestMappingErrors(t *testing.T) {
 	require.NoError(t, os.WriteFile(noMapsFile, []byte(`foo = "this is valid but has no maps"`), 0o777))
 	require.NoError(t, os.WriteFile(goodMapFile, []byte(`map foo { foo = "this is valid" }`), 0o777))
 
+	cycleAFile := filepath.Join(dir, "cycle_a.blobl")
+	cycleBFile := filepath.Join(dir, "cycle_b.blobl")
+	require.NoError(t, os.WriteFile(cycleAFile, []byte(`import "./cycle_b.blobl"
+map a { root = this }`), 0o777))
+	require.NoError(t, os.WriteFile(cycleBFile, []byte(`import "./cycle_a.blobl"
+map b { root = this }`), 0o777))
+
 	tests := map[string]struct {
 		mapping     string
 		errContains string
 	}{
+		"unterminated block comment": {
+			mapping: `root.foo = "bar"
+/* this never ends
+root.baz = "buz"`,
+			errContains: "line 3 char 17: required: expected end of block comment",
+		},
+		"error after block comment": {
+			mapping: `/*
+  header
+*/
+root.foo = "bar" /* trailing */ root.baz = "buz"`,
+			errContains: "line 4 char 33: expected line break",
+		},
 		"bad variable name": {
 			mapping:     `let foo+bar = baz`,
 			errContains: "line 1 char 8: expected whitespace",
@@ -37,12 +57,12 @@ func TestMappingErrors(t *testing.T) {
 		},
 		"no mappings": {
 			mapping:     ``,
-			errContains: `line 1 char 1: expected import, map, or assignment`,
+			errContains: `line 1 char 1: expected import, use, map, or assignment`,
 		},
 		"no mappings 2": {
 			mapping: `
    `,
-			errContains: `line 2 char 4: expected import, map, or assignment`,
+			errContains: `line 2 char 4: expected import, use, map, or assignment`,
 		},
 		"double mapping": {
 			mapping:     `foo = bar bar = baz`,
@@ -83,13 +103,13 @@ meta foo = "bar"`,
 		"bad char 2": {
 			mapping: `let foo = bar
 !foo = bar`,
-			errContains: `line 2 char 1: expected import, map, or assignment`,
+			errContains: `line 2 char 1: expected import, use, map, or assignment`,
 		},
 		"bad char 3": {
 			mapping: `let foo = bar
 !foo = bar
 this = that`,
-			errContains: `line 2 char 1: expected import, map, or assignment`,
+			errContains: `line 2 char 1: expected import, use, map, or assignment`,
 		},
 		"bad query": {
 			mapping:     `foo = blah.`,
@@ -149,10 +169,34 @@ import "%v"
 foo = bar.apply("foo")`, goodMapFile),
 			errContains: fmt.Sprintf(`line 3 char 1: map name collisions from import '%v': [foo]`, goodMapFile),
 		},
+		"import cycle": {
+			mapping: fmt.Sprintf(`import "%v"
+
+foo = bar.apply("a")`, cycleAFile),
+			errContains: fmt.Sprintf(`import cycle detected: %v -> %v -> %v`, cycleAFile, cycleBFile, cycleAFile),
+		},
+		"if expression missing branch braces": {
+			mapping:     `root = if this.a > 1 "big"`,
+			errContains: "line 1 char 22: required: expected {",
+		},
+		"if expression unclosed branch": {
+			mapping: `root = if this.a > 1 {
+  "big"
+`,
+			errContains: "line 3 char 1: required: expected }",
+		},
+		"if expression empty branch": {
+			mapping:     `root = if this.a > 1 { } else { "small" }`,
+			errContains: "line 1 char 24: required: expected query",
+		},
+		"if expression else missing braces": {
+			mapping:     `root = if this.a > 1 { "big" } else "small"`,
+			errContains: "line 1 char 37: required: expected {",
+		},
 		"quotes at root": {
 			mapping: `
 "root.something" = 5 + 2`,
-			errContains: "line 2 char 1: expected import, map, or assignment",
+			errContains: "line 2 char 1: expected import, use, map, or assignment",
 		},
 	}
 
@@ -179,6 +223,15 @@ func TestMappings(t *testing.T) {
 	directMapFile := filepath.Join(dir, "direct_map.blobl")
 	require.NoError(t, os.WriteFile(directMapFile, []byte(`root.nested = this`), 0o777))
 
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "common"), 0o777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "common", "base.blobl"), []byte(`map base { root.base = this }`), 0o777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "common", "left.blobl"), []byte(`import "./base.blobl"
+map left { root = this.apply("base") }`), 0o777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "common", "right.blobl"), []byte(`import "./base.blobl"
+map right { root = this.apply("base") }`), 0o777))
+	leftMapFile := filepath.Join(dir, "common", "left.blobl")
+	rightMapFile := filepath.Join(dir, "common", "right.blobl")
+
 	type part struct {
 		Content string
 		Meta    map[string]string
@@ -190,6 +243,20 @@ func TestMappings(t *testing.T) {
 		mapping string
 		output  part
 	}{
+		"block and trailing comments": {
+			mapping: `/*
+ * A header comment.
+ */
+root.a = this.foo /* the foo */ + this.bar # trailing
+root.b = { /* inline */ "c": this.bar } /* spans
+multiple lines */ root.d = this.foo # no line break`,
+			input: []part{
+				{Content: `{"foo":5,"bar":3}`},
+			},
+			output: part{
+				Content: `{"a":8,"b":{"c":3},"d":5}`,
+			},
+		},
 		"compressed arithmetic": {
 			mapping: `this.foo+this.bar`,
 			input: []part{
@@ -457,6 +524,40 @@ root = this.apply("foo")`, goodMapFile),
 				Content: `{"foo":"this is valid","nested":{"outter":{"inner":"hello world"}}}`,
 			},
 		},
+		"if expression with variables and maps": {
+			mapping: `map describe {
+  root.size = if this.a > 10 { "big" } else { "small" }
+}
+
+let size = this.apply("describe").size
+root.size = $size
+root.doubled = if $size == "big" {
+  this.a * 2
+} else if this.a > 5 {
+  this.a + 1
+} else {
+  deleted()
+}`,
+			input: []part{
+				{Content: `{"a":7}`},
+			},
+			output: part{
+				Content: `{"doubled":8,"size":"small"}`,
+			},
+		},
+		"test diamond imported maps": {
+			mapping: fmt.Sprintf(`import "%v"
+import "%v"
+
+root.left = this.apply("left")
+root.right = this.apply("right")`, leftMapFile, rightMapFile),
+			input: []part{
+				{Content: `{"inner":"hello world"}`},
+			},
+			output: part{
+				Content: `{"left":{"base":{"inner":"hello world"}},"right":{"base":{"inner":"hello world"}}}`,
+			},
+		},
 		"test directly imported map": {
 			mapping: fmt.Sprintf(`from "%v"`, directMapFile),
 			input: []part{
@@ -502,3 +603,124 @@ root = this.apply("foo")`, goodMapFile),
 		})
 	}
 }
+
+func TestMappingOrderedKeys(t *testing.T) {
+	mapping := `
+root = this
+root.user = {"name": this.name.uppercase(), "id": this.id, "age": 31}
+root.tags = ["z", {"second": 2, "first": 1}]
+root.after = "last"
+`
+
+	exec, perr := ParseMapping(GlobalContext(), mapping)
+	require.Nil(t, perr)
+	exec.SetOrderedKeys(true)
+
+	msg := message.QuickBatch([][]byte{[]byte(`{"name":"foo","id":"bar","created":"2021"}`)})
+	resPart, err := exec.MapPart(0, msg)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"foo","id":"bar","created":"2021","user":{"name":"FOO","id":"bar","age":31},"tags":["z",{"second":2,"first":1}],"after":"last"}`, string(resPart.Get()))
+}
+
+func TestMappingImportPath(t *testing.T) {
+	pCtx := GlobalContext().WithImporterRelativeToFile("/foo/bar/config.yaml")
+
+	for _, test := range []struct {
+		mapping string
+		path    string
+		ok      bool
+	}{
+		{mapping: `from "baz.blobl"`, path: "/foo/bar/baz.blobl", ok: true},
+		{mapping: "\n  from \"/baz.blobl\"\n", path: "/baz.blobl", ok: true},
+		{mapping: `from "baz.blobl"
+root.foo = "bar"`},
+		{mapping: `root = this`},
+	} {
+		path, ok := MappingImportPath(pCtx, test.mapping)
+		assert.Equal(t, test.ok, ok, test.mapping)
+		assert.Equal(t, test.path, path, test.mapping)
+	}
+}
+
+// echoModule is a WASM module exporting the methods `echo`, which returns its
+// input, `spin`, which never returns, and `fail`, which traps.
+var echoModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x10, 0x03, 0x60,
+	0x01, 0x7f, 0x01, 0x7f, 0x60, 0x01, 0x7f, 0x00, 0x60, 0x02, 0x7f, 0x7f,
+	0x01, 0x7e, 0x03, 0x06, 0x05, 0x00, 0x01, 0x02, 0x02, 0x02, 0x05, 0x03,
+	0x01, 0x00, 0x01, 0x06, 0x07, 0x01, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b,
+	0x07, 0x37, 0x06, 0x06, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x02, 0x00,
+	0x08, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x00, 0x00, 0x0a,
+	0x64, 0x65, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x00, 0x01,
+	0x04, 0x65, 0x63, 0x68, 0x6f, 0x00, 0x02, 0x04, 0x73, 0x70, 0x69, 0x6e,
+	0x00, 0x03, 0x04, 0x66, 0x61, 0x69, 0x6c, 0x00, 0x04, 0x0a, 0x2a, 0x05,
+	0x0b, 0x00, 0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b,
+	0x02, 0x00, 0x0b, 0x0c, 0x00, 0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20,
+	0x01, 0xad, 0x84, 0x0b, 0x08, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x00,
+	0x0b, 0x03, 0x00, 0x00, 0x0b,
+}
+
+func TestMappingUseWasm(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "echo.wasm"), echoModule, 0o777))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "maps.blobl"), []byte(`use wasm "./echo.wasm"
+map shout {
+  root = this.echo().uppercase()
+}`), 0o777))
+
+	pCtx := GlobalContext().WithImporterRelativeToFile(filepath.Join(dir, "mapping.blobl"))
+
+	exec, perr := ParseMapping(pCtx, `use wasm "./echo.wasm"
+import "./maps.blobl"
+root.a = this.foo.echo()
+root.b = this.foo.apply("shout")
+root.c = this.echo().parse_json().foo
+`)
+	require.Nil(t, perr)
+
+	resPart, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"foo":"bar"}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":"bar","b":"BAR","c":"bar"}`, string(resPart.Get()))
+
+	exec, perr = ParseMapping(pCtx, `use wasm "./echo.wasm"
+root = this.foo.fail()`)
+	require.Nil(t, perr)
+
+	_, err = exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"foo":"bar"}`)}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed assignment (line 2): field `this.foo`: ")
+
+	for _, test := range []struct {
+		mapping     string
+		errContains string
+	}{
+		{
+			mapping:     `root = this.echo()`,
+			errContains: "unrecognised method 'echo'",
+		},
+		{
+			mapping:     "use wasm \"./echo.wasm\"\nroot = this.echo(\"nope\")",
+			errContains: "wrong number of arguments, expected 0, got 1",
+		},
+		{
+			mapping:     "use wasm \"./echo.wasm\"\nuse wasm \"./echo.wasm\"",
+			errContains: "method name collisions from module './echo.wasm': [echo fail spin]",
+		},
+		{
+			mapping:     `use wasm "./maps.blobl"`,
+			errContains: "failed to compile module ./maps.blobl",
+		},
+		{
+			mapping:     `use wasm "./nope.wasm"`,
+			errContains: "failed to read module",
+		},
+	} {
+		_, perr := ParseMapping(pCtx, test.mapping)
+		require.NotNil(t, perr, test.mapping)
+		assert.Contains(t, perr.ErrorAtPosition([]rune(test.mapping)), test.errContains, test.mapping)
+	}
+
+	_, perr = ParseMapping(GlobalContext().DisabledImports(), `use wasm "./echo.wasm"`)
+	require.NotNil(t, perr)
+	assert.Contains(t, perr.ErrorAtPosition(nil), "imports are disabled in this context")
+}
@@ -9,10 +9,18 @@ import (
 //------------------------------------------------------------------------------
 
 func arithmeticOpParser() Func {
+	// A slash followed by an asterisk is the beginning of a block comment
+	// rather than a division.
+	divParser := func(input []rune) Result {
+		if len(input) > 1 && input[0] == '/' && input[1] == '*' {
+			return Fail(NewError(input, "/"), input)
+		}
+		return Char('/')(input)
+	}
 	opParser := OneOf(
 		Char('+'),
 		Char('-'),
-		Char('/'),
+		divParser,
 		Char('*'),
 		Char('%'),
 		Term("&&"),
@@ -287,6 +287,20 @@ func TestArithmeticParser(t *testing.T) {
 				{content: `{"foo":"from_foo"}`},
 			},
 		},
+		"coalesce chained fallbacks": {
+			input:  `json("foo") | json("bar") | "default"`,
+			output: `default`,
+			messages: []easyMsg{
+				{content: `{"foo":null}`},
+			},
+		},
+		"coalesce skips errors": {
+			input:  `json("foo").number() | json("bar").uppercase() | "default"`,
+			output: `FROM_BAR`,
+			messages: []easyMsg{
+				{content: `{"foo":"not a number","bar":"from_bar"}`},
+			},
+		},
 		"coalesce deleted": {
 			input:    `deleted() | "this"`,
 			output:   `this`,
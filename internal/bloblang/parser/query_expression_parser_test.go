@@ -200,6 +200,29 @@ func TestExpressionsParser(t *testing.T) {
 			output:   `second`,
 			messages: []easyMsg{},
 		},
+		"match type checks": {
+			input: `match json("value") {
+  this.type() == "string" => "was a string"
+  this.type() == "number" && this > 10 => "was a big number"
+  this.type() == "number" => "was a number"
+  _ => "was something else"
+}`,
+			output: `was a number`,
+			messages: []easyMsg{
+				{content: `{"value":7}`},
+			},
+		},
+		"match type checks fallback": {
+			input: `match json("value") {
+  this.type() == "string" => "was a string"
+  this.type() == "number" => "was a number"
+  _ => "was something else"
+}`,
+			output: `was something else`,
+			messages: []easyMsg{
+				{content: `{"value":["foo"]}`},
+			},
+		},
 		"if statement inline": {
 			input:  `if "foo" == "foo" { "foo" } else { "bar" }`,
 			output: `foo`,
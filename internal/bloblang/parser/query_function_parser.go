@@ -23,7 +23,7 @@ func functionArgsParser(pCtx Context) Func {
 			MustBe(Expect(
 				OneOf(
 					namedArgParser(pCtx),
-					queryParser(pCtx),
+					argValueParser(queryParser(pCtx)),
 				), "function argument"),
 			),
 			MustBe(Expect(Sequence(Discard(SpacesAndTabs()), comma, whitespace), "comma")),
@@ -35,7 +35,24 @@ func functionArgsParser(pCtx Context) Func {
 
 type namedArg struct {
 	name  string
+	value argValue
+}
+
+// argValue is the value of an argument along with the input it was parsed
+// from, which is used in order to point errors at the argument.
+type argValue struct {
 	value interface{}
+	input []rune
+}
+
+func argValueParser(p Func) Func {
+	return func(input []rune) Result {
+		res := p(input)
+		if res.Err == nil {
+			res.Payload = argValue{value: res.Payload, input: input}
+		}
+		return res
+	}
 }
 
 func namedArgParser(pCtx Context) Func {
@@ -46,7 +63,7 @@ func namedArgParser(pCtx Context) Func {
 		SnakeCase(),
 		colon,
 		whitespace,
-		MustBe(Expect(queryParser(pCtx), "argument value")),
+		MustBe(Expect(argValueParser(queryParser(pCtx)), "argument value")),
 	)
 
 	return func(input []rune) Result {
@@ -56,7 +73,7 @@ func namedArgParser(pCtx Context) Func {
 		}
 
 		resSlice := res.Payload.([]interface{})
-		res.Payload = namedArg{name: resSlice[0].(string), value: resSlice[3]}
+		res.Payload = namedArg{name: resSlice[0].(string), value: resSlice[3].(argValue)}
 		return res
 	}
 }
@@ -263,9 +280,12 @@ func fieldLiteralRootParser(pCtx Context) Func {
 	}
 }
 
-func extractArgsParserResult(paramsDef query.Params, args []interface{}) (*query.ParsedParams, error) {
+// extractArgsParserResult populates parameters from parsed arguments, and also
+// returns the input of each argument keyed by the name of its parameter.
+func extractArgsParserResult(paramsDef query.Params, args []interface{}) (*query.ParsedParams, map[string][]rune, error) {
 	var namelessArgs []interface{}
 	var namedArgs map[string]interface{}
+	argInputs := map[string][]rune{}
 
 	for _, arg := range args {
 		namedArg, isNamed := arg.(namedArg)
@@ -274,16 +294,21 @@ func extractArgsParserResult(paramsDef query.Params, args []interface{}) (*query
 				namedArgs = map[string]interface{}{}
 			}
 			if _, exists := namedArgs[namedArg.name]; exists {
-				return nil, fmt.Errorf("duplicate named arg: %v", namedArg.name)
+				return nil, nil, fmt.Errorf("duplicate named arg: %v", namedArg.name)
 			}
-			namedArgs[namedArg.name] = namedArg.value
+			namedArgs[namedArg.name] = namedArg.value.value
+			argInputs[namedArg.name] = namedArg.value.input
 		} else {
-			namelessArgs = append(namelessArgs, arg)
+			value := arg.(argValue)
+			if i := len(namelessArgs); i < len(paramsDef.Definitions) {
+				argInputs[paramsDef.Definitions[i].Name] = value.input
+			}
+			namelessArgs = append(namelessArgs, value.value)
 		}
 	}
 
 	if len(namelessArgs) > 0 && len(namedArgs) > 0 {
-		return nil, errors.New("cannot mix named and nameless arguments")
+		return nil, nil, errors.New("cannot mix named and nameless arguments")
 	}
 
 	var parsedParams *query.ParsedParams
@@ -294,10 +319,22 @@ func extractArgsParserResult(paramsDef query.Params, args []interface{}) (*query
 		parsedParams, err = paramsDef.PopulateNameless(namelessArgs...)
 	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return parsedParams, nil
+	return parsedParams, argInputs, nil
+}
+
+// paramErrorInput returns the input of the argument that caused an error, or
+// the provided input if the error was not caused by a specific argument.
+func paramErrorInput(input []rune, argInputs map[string][]rune, err error) []rune {
+	var pErr *query.ErrInvalidParam
+	if errors.As(err, &pErr) {
+		if argInput, exists := argInputs[pErr.Name]; exists {
+			return argInput
+		}
+	}
+	return input
 }
 
 func methodParser(fn query.Function, pCtx Context) Func {
@@ -318,19 +355,19 @@ func methodParser(fn query.Function, pCtx Context) Func {
 		seqSlice := res.Payload.([]interface{})
 
 		targetMethod := seqSlice[0].(string)
-		params, err := pCtx.Methods.Params(targetMethod)
+		params, err := pCtx.methodParams(targetMethod)
 		if err != nil {
 			return Fail(NewFatalError(input, err), input)
 		}
 
-		parsedParams, err := extractArgsParserResult(params, seqSlice[1].([]interface{}))
+		parsedParams, argInputs, err := extractArgsParserResult(params, seqSlice[1].([]interface{}))
 		if err != nil {
 			return Fail(NewFatalError(input, err), input)
 		}
 
 		method, err := pCtx.InitMethod(targetMethod, fn, parsedParams)
 		if err != nil {
-			return Fail(NewFatalError(input, err), input)
+			return Fail(NewFatalError(paramErrorInput(input, argInputs, err), err), input)
 		}
 		return Success(method, res.Remaining)
 	}
@@ -359,14 +396,14 @@ func functionParser(pCtx Context) Func {
 			return Fail(NewFatalError(input, err), input)
 		}
 
-		parsedParams, err := extractArgsParserResult(params, seqSlice[1].([]interface{}))
+		parsedParams, argInputs, err := extractArgsParserResult(params, seqSlice[1].([]interface{}))
 		if err != nil {
 			return Fail(NewFatalError(input, err), input)
 		}
 
 		fn, err := pCtx.InitFunction(targetFunc, parsedParams)
 		if err != nil {
-			return Fail(NewFatalError(input, err), input)
+			return Fail(NewFatalError(paramErrorInput(input, argInputs, err), err), input)
 		}
 		return Success(fn, res.Remaining)
 	}
@@ -84,6 +84,9 @@ func dynamicObjectParser(pCtx Context) Func {
 		for _, sequenceValue := range res.Payload.([]interface{}) {
 			slice := sequenceValue.([]interface{})
 			values = append(values, [2]interface{}{slice[0], slice[4]})
+			if key, isStr := slice[0].(string); isStr && pCtx.objectKeys != nil {
+				*pCtx.objectKeys = append(*pCtx.objectKeys, key)
+			}
 		}
 
 		lit, err := query.NewMapLiteral(values)
@@ -154,6 +154,20 @@ func TestMethodParser(t *testing.T) {
 				{content: `{"foo":"yep"}`},
 			},
 		},
+		"json catch lambda": {
+			input:  `json("foo").number().catch(err -> "failed: " + err)`,
+			output: "failed: json path `foo`: strconv.ParseFloat: parsing \"nope\": invalid syntax",
+			messages: []easyMsg{
+				{content: `{"foo":"nope"}`},
+			},
+		},
+		"json catch lambda context": {
+			input:  `json("foo").number().catch(_ -> "default")`,
+			output: `default`,
+			messages: []easyMsg{
+				{content: `{"foo":"nope"}`},
+			},
+		},
 		"meta from all": {
 			input:  `meta("foo").from_all()`,
 			output: `["bar",null,"baz"]`,
@@ -54,6 +54,14 @@ func TestFunctionParserErrors(t *testing.T) {
 			input: `json("foo") + `,
 			err:   `line 1 char 15: expected query`,
 		},
+		"bad operators 2": {
+			input: `this.a + * 2`,
+			err:   `line 1 char 10: expected query`,
+		},
+		"bad operators 3": {
+			input: `1 + 2 &&`,
+			err:   `line 1 char 9: expected query`,
+		},
 		"bad expression": {
 			input: `(json("foo") `,
 			err:   `line 1 char 14: required: expected closing bracket`,
@@ -66,6 +74,66 @@ func TestFunctionParserErrors(t *testing.T) {
 			input: `(json("foo") + meta("bar") `,
 			err:   `line 1 char 28: required: expected closing bracket`,
 		},
+		"bad regexp pattern": {
+			input: `this.value.re_match("[0-9")`,
+			err:   "line 1 char 21: error parsing regexp: missing closing ]: `[0-9`",
+		},
+		"bad regexp pattern named": {
+			input: `this.value.re_replace_all(value: "x", pattern: "(")`,
+			err:   "line 1 char 48: error parsing regexp: missing closing ): `(`",
+		},
+		"regexp pattern without named groups": {
+			input: `this.value.re_capture_groups("[0-9]+")`,
+			err:   "line 1 char 30: pattern `[0-9]+` does not contain any named capture groups",
+		},
+		"bad timestamp duration": {
+			input: `this.ts.timestamp_add("ten minutes")`,
+			err:   `line 1 char 23: time: invalid duration "ten minutes"`,
+		},
+		"bad timezone": {
+			input: `this.ts.format_timestamp(tz: "Nowhere/Special", format: "2006")`,
+			err:   `line 1 char 30: failed to parse timezone location name: unknown time zone Nowhere/Special`,
+		},
+		"bad hmac algorithm": {
+			input: `this.value.hmac("sha3", "static-key")`,
+			err:   `line 1 char 17: unrecognized hmac hash type: sha3`,
+		},
+		"bad aes vector": {
+			input: `this.value.encrypt_aes("cbc", "0123456789abcdef", "short")`,
+			err:   `line 1 char 51: expected 16 byte initialization vector for scheme cbc, got 5 bytes`,
+		},
+		"bad aes key": {
+			input: `this.value.encrypt_aes("ctr", "tooshort", "0123456789abcdef")`,
+			err:   `line 1 char 31: crypto/aes: invalid key size 8`,
+		},
+		"bad csv delimiter": {
+			input: `this.value.parse_csv(delimiter: "::")`,
+			err:   `line 1 char 33: delimiter value must be exactly one character, got "::"`,
+		},
+		"bad cidr": {
+			input: `this.ip.ip_in_cidr("10.0.0.0")`,
+			err:   `line 1 char 20: invalid CIDR address: 10.0.0.0`,
+		},
+		"bad json schema": {
+			input: `this.json_schema("""{"type":5}""")`,
+			err:   `line 1 char 18: failed to parse json schema definition: Invalid type. Expected: string/array of strings, given: type`,
+		},
+		"bad zip argument": {
+			input: `this.things.zip(["a"], "b")`,
+			err:   `line 1 char 13: argument 1: expected array value, got string ("b")`,
+		},
+		"bad geohash precision": {
+			input: `this.location.geohash_encode(13)`,
+			err:   `line 1 char 30: precision must be between 1 and 12, got 13`,
+		},
+		"bad patch operation": {
+			input: `this.doc.patch([{"op":"nope","path":"/foo"}])`,
+			err:   `line 1 char 10: operation 0: unrecognised operation: nope`,
+		},
+		"bad floor timestamp duration": {
+			input: `this.ts.floor_timestamp("-5m")`,
+			err:   `line 1 char 25: must be greater than zero`,
+		},
 		"bad method": {
 			input: `json("foo").not_a_thing()`,
 			err:   `line 1 char 13: unrecognised method 'not_a_thing'`,
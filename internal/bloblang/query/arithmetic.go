@@ -93,14 +93,20 @@ var ErrDivideByZero = errors.New("attempted to divide by zero")
 type intArithmeticFunc func(left, right int64) (int64, error)
 type floatArithmeticFunc func(left, right float64) (float64, error)
 
-// Takes two arithmetic funcs, one for integer values and one for float values
-// and returns a generic arithmetic func. If both values can be represented as
-// integers the integer func is called, otherwise the float func is called.
-func numberDegradationFunc(op ArithmeticOperator, iFn intArithmeticFunc, fFn floatArithmeticFunc) arithmeticOpFunc {
+// Takes three arithmetic funcs, one for integer values, one for float values
+// and one for exact values, and returns a generic arithmetic func. If either
+// value is a Decimal, or an unsigned integer too large for the integer func,
+// then the exact func is called. Otherwise, if both values can be represented
+// as integers the integer func is called, otherwise the float func is called.
+func numberDegradationFunc(op ArithmeticOperator, iFn intArithmeticFunc, fFn floatArithmeticFunc, dFn decimalArithmeticFunc) arithmeticOpFunc {
 	return func(lhs, rhs Function, left, right interface{}) (interface{}, error) {
 		left = ISanitize(left)
 		right = ISanitize(right)
 
+		if requiresExactArithmetic(left, right) {
+			return exactArithmetic(op, lhs, rhs, left, right, dFn)
+		}
+
 		if leftFloat, leftIsFloat := left.(float64); leftIsFloat {
 			rightFloat, err := IGetNumber(right)
 			if err != nil {
@@ -139,10 +145,18 @@ func prodOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 			func(lhs, rhs float64) (float64, error) {
 				return lhs * rhs, nil
 			},
+			decimalMul,
 		), true
 	case ArithmeticDiv:
-		// Only executes on float values.
+		// Only executes on float values, unless exact values are required.
 		return func(lFn, rFn Function, left, right interface{}) (interface{}, error) {
+			if requiresExactArithmetic(ISanitize(left), ISanitize(right)) {
+				res, err := exactArithmetic(op, lFn, rFn, ISanitize(left), ISanitize(right), decimalDiv)
+				if errors.Is(err, ErrDivideByZero) {
+					err = ErrFrom(err, rFn)
+				}
+				return res, err
+			}
 			lhs, err := IGetNumber(left)
 			if err != nil {
 				return nil, NewTypeMismatch(op.String(), lFn, rFn, left, right)
@@ -157,8 +171,15 @@ func prodOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 			return lhs / rhs, nil
 		}, true
 	case ArithmeticMod:
-		// Only executes on integer values.
+		// Only executes on integer values, unless exact values are required.
 		return func(lFn, rFn Function, left, right interface{}) (interface{}, error) {
+			if requiresExactArithmetic(ISanitize(left), ISanitize(right)) {
+				res, err := exactArithmetic(op, lFn, rFn, ISanitize(left), ISanitize(right), decimalMod)
+				if errors.Is(err, ErrDivideByZero) {
+					err = ErrFrom(err, rFn)
+				}
+				return res, err
+			}
 			lhs, err := IGetInt(left)
 			if err != nil {
 				return nil, NewTypeMismatch(op.String(), lFn, rFn, left, right)
@@ -186,10 +207,11 @@ func sumOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 			func(left, right float64) (float64, error) {
 				return left + right, nil
 			},
+			decimalAdd,
 		)
 		return func(lFn, rFn Function, left, right interface{}) (interface{}, error) {
 			switch left.(type) {
-			case float64, int, int64, uint64, json.Number:
+			case float64, int, int64, uint64, json.Number, Decimal:
 				return numberAdd(lFn, rFn, left, right)
 			case string, []byte:
 				lhs, err := IGetString(left)
@@ -212,6 +234,7 @@ func sumOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 			func(lhs, rhs float64) (float64, error) {
 				return lhs - rhs, nil
 			},
+			decimalSub,
 		), true
 	}
 	return nil, false
@@ -305,6 +328,36 @@ func compareGenericFn(op ArithmeticOperator) func(lhs, rhs interface{}) bool {
 	return nil
 }
 
+func compareExactFn(op ArithmeticOperator) func(cmp int) bool {
+	switch op {
+	case ArithmeticEq:
+		return func(cmp int) bool {
+			return cmp == 0
+		}
+	case ArithmeticNeq:
+		return func(cmp int) bool {
+			return cmp != 0
+		}
+	case ArithmeticGt:
+		return func(cmp int) bool {
+			return cmp > 0
+		}
+	case ArithmeticGte:
+		return func(cmp int) bool {
+			return cmp >= 0
+		}
+	case ArithmeticLt:
+		return func(cmp int) bool {
+			return cmp < 0
+		}
+	case ArithmeticLte:
+		return func(cmp int) bool {
+			return cmp <= 0
+		}
+	}
+	return nil
+}
+
 func compareOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 	switch op {
 	case ArithmeticEq,
@@ -315,9 +368,15 @@ func compareOp(op ArithmeticOperator) (arithmeticOpFunc, bool) {
 		ArithmeticLte:
 		strOpFn := compareStrFn(op)
 		numOpFn := compareNumFn(op)
+		exactOpFn := compareExactFn(op)
 		boolOpFn := compareBoolFn(op)
 		genericOpFn := compareGenericFn(op)
 		return func(lFn, rFn Function, left, right interface{}) (interface{}, error) {
+			if requiresExactArithmetic(ISanitize(left), ISanitize(right)) {
+				if cmp, err := exactCompare(ISanitize(left), ISanitize(right)); err == nil {
+					return exactOpFn(cmp), nil
+				}
+			}
 			switch lhs := restrictForComparison(left).(type) {
 			case string:
 				if strOpFn == nil {
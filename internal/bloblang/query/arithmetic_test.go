@@ -19,6 +19,7 @@ func TestArithmeticNumberDegradation(t *testing.T) {
 		func(left, right float64) (float64, error) {
 			return left / right, nil
 		},
+		decimalDiv,
 	)
 
 	testCases := []struct {
@@ -116,6 +117,138 @@ func TestArithmeticNumberDegradation(t *testing.T) {
 	}
 }
 
+func TestArithmeticExact(t *testing.T) {
+	mustDecimal := func(s string) Decimal {
+		d, err := NewDecimal(s)
+		require.NoError(t, err)
+		return d
+	}
+
+	testCases := []struct {
+		name   string
+		left   interface{}
+		right  interface{}
+		op     ArithmeticOperator
+		result string
+		typ    interface{}
+	}{
+		{
+			name:   "decimal add",
+			left:   mustDecimal("0.1"),
+			right:  mustDecimal("0.2"),
+			op:     ArithmeticAdd,
+			result: "0.3",
+			typ:    Decimal{},
+		},
+		{
+			name:   "decimal multiply float",
+			left:   mustDecimal("19.99"),
+			right:  3.0,
+			op:     ArithmeticMul,
+			result: "59.97",
+			typ:    Decimal{},
+		},
+		{
+			name:   "decimal subtract int",
+			left:   int64(10),
+			right:  mustDecimal("0.01"),
+			op:     ArithmeticSub,
+			result: "9.99",
+			typ:    Decimal{},
+		},
+		{
+			name:   "decimal divide",
+			left:   mustDecimal("1"),
+			right:  int64(3),
+			op:     ArithmeticDiv,
+			result: "0.3333333333333333333333333333333333",
+			typ:    Decimal{},
+		},
+		{
+			name:   "decimal modulo",
+			left:   mustDecimal("10.5"),
+			right:  int64(3),
+			op:     ArithmeticMod,
+			result: "1.5",
+			typ:    Decimal{},
+		},
+		{
+			name:   "large unsigned add",
+			left:   uint64(18446744073709551000),
+			right:  int64(15),
+			op:     ArithmeticAdd,
+			result: "18446744073709551015",
+			typ:    uint64(0),
+		},
+		{
+			name:   "large unsigned subtract",
+			left:   json.Number("18446744073709551615"),
+			right:  json.Number("18446744073709551614"),
+			op:     ArithmeticSub,
+			result: "1",
+			typ:    int64(0),
+		},
+		{
+			name:   "large unsigned overflow",
+			left:   uint64(18446744073709551615),
+			right:  int64(1),
+			op:     ArithmeticAdd,
+			result: "18446744073709551616",
+			typ:    Decimal{},
+		},
+		{
+			name:   "decimal compare",
+			left:   mustDecimal("0.30"),
+			right:  0.3,
+			op:     ArithmeticEq,
+			result: "true",
+			typ:    false,
+		},
+		{
+			name:   "large unsigned compare",
+			left:   json.Number("18446744073709551615"),
+			right:  json.Number("18446744073709551614"),
+			op:     ArithmeticGt,
+			result: "true",
+			typ:    false,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			fn, err := NewArithmeticExpression(
+				[]Function{
+					NewLiteralFunction("left", test.left),
+					NewLiteralFunction("right", test.right),
+				},
+				[]ArithmeticOperator{test.op},
+			)
+			require.NoError(t, err)
+
+			res, err := fn.Exec(FunctionContext{})
+			require.NoError(t, err)
+			assert.IsType(t, test.typ, res)
+			assert.Equal(t, test.result, IToString(res))
+		})
+	}
+}
+
+func TestArithmeticExactDivideByZero(t *testing.T) {
+	d, err := NewDecimal("1.5")
+	require.NoError(t, err)
+
+	_, err = NewArithmeticExpression(
+		[]Function{
+			NewLiteralFunction("left", d),
+			NewLiteralFunction("right", int64(0)),
+		},
+		[]ArithmeticOperator{ArithmeticDiv},
+	)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "attempted to divide by zero")
+}
+
 func TestArithmeticComparisons(t *testing.T) {
 	testCases := []struct {
 		name        string
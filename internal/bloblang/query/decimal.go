@@ -0,0 +1,250 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalPlaces is the number of decimal places that a Decimal is rounded to
+// when it cannot be represented exactly, such as the result of dividing one by
+// three.
+const maxDecimalPlaces = 34
+
+// Decimal is an exact decimal number. Arithmetic between a Decimal and any
+// other number results in a Decimal rather than degrading to a float64, and
+// it is serialized as a JSON number without any loss of precision.
+type Decimal struct {
+	r *big.Rat
+}
+
+// NewDecimal parses a Decimal from a string representation of a number, which
+// may use an exponent.
+func NewDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		return Decimal{}, fmt.Errorf("failed to parse '%v' as a decimal", s)
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("failed to parse '%v' as a decimal", s)
+	}
+	return Decimal{r: r}, nil
+}
+
+// String returns the decimal representation of the number, which is exact
+// unless it would require more than 34 decimal places.
+func (d Decimal) String() string {
+	if d.r.IsInt() {
+		return d.r.Num().String()
+	}
+	if places := exactDecimalPlaces(d.r.Denom()); places >= 0 && places <= maxDecimalPlaces {
+		return d.r.FloatString(places)
+	}
+	s := strings.TrimRight(d.r.FloatString(maxDecimalPlaces), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MarshalJSON serializes the Decimal as a JSON number.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Float64 returns the nearest float64 value of the Decimal.
+func (d Decimal) Float64() float64 {
+	f, _ := d.r.Float64()
+	return f
+}
+
+// exactDecimalPlaces returns the number of decimal places required in order to
+// exactly represent a fraction with the provided denominator, or -1 if it
+// cannot be represented exactly.
+func exactDecimalPlaces(denom *big.Int) int {
+	d := new(big.Int).Set(denom)
+	rem := new(big.Int)
+
+	countFactor := func(f int64) (n int) {
+		fBig := big.NewInt(f)
+		for {
+			q, r := new(big.Int).QuoRem(d, fBig, rem)
+			if r.Sign() != 0 {
+				return
+			}
+			d = q
+			n++
+		}
+	}
+
+	twos, fives := countFactor(2), countFactor(5)
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return -1
+	}
+	if twos > fives {
+		return twos
+	}
+	return fives
+}
+
+//------------------------------------------------------------------------------
+
+// IGetRat takes a boxed value and attempts to extract an exact rational number
+// from it. Floats are converted from their shortest decimal representation.
+func IGetRat(v interface{}) (*big.Rat, error) {
+	switch t := v.(type) {
+	case Decimal:
+		return t.r, nil
+	case int:
+		return new(big.Rat).SetInt64(int64(t)), nil
+	case int64:
+		return new(big.Rat).SetInt64(t), nil
+	case uint64:
+		return new(big.Rat).SetInt(new(big.Int).SetUint64(t)), nil
+	case float64:
+		r, ok := new(big.Rat).SetString(strconv.FormatFloat(t, 'g', -1, 64))
+		if !ok {
+			return nil, fmt.Errorf("unable to represent %v as a decimal", t)
+		}
+		return r, nil
+	case json.Number:
+		d, err := NewDecimal(t.String())
+		if err != nil {
+			return nil, err
+		}
+		return d.r, nil
+	}
+	return nil, NewTypeError(v, ValueNumber)
+}
+
+// IToDecimal takes a boxed value and attempts to extract a Decimal from it or
+// parse one.
+func IToDecimal(v interface{}) (Decimal, error) {
+	switch t := v.(type) {
+	case string:
+		return NewDecimal(t)
+	case []byte:
+		return NewDecimal(string(t))
+	}
+	r, err := IGetRat(v)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return Decimal{r: r}, nil
+}
+
+// requiresExactArithmetic returns true when either value of an arithmetic
+// operation is a Decimal, or an unsigned integer too large to be represented as
+// a signed integer.
+func requiresExactArithmetic(left, right interface{}) bool {
+	for _, v := range []interface{}{left, right} {
+		switch t := v.(type) {
+		case Decimal:
+			return true
+		case uint64:
+			if t > maxInt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type decimalArithmeticFunc func(left, right *big.Rat) (*big.Rat, error)
+
+// exactArithmetic executes an arithmetic function on two numbers as exact
+// rationals. If either value was a Decimal then a Decimal is returned,
+// otherwise integer results are returned as an int64 or uint64 where they fit.
+func exactArithmetic(op ArithmeticOperator, lhs, rhs Function, left, right interface{}, dFn decimalArithmeticFunc) (interface{}, error) {
+	leftRat, err := IGetRat(left)
+	if err != nil {
+		return nil, NewTypeMismatch(op.String(), lhs, rhs, left, right)
+	}
+	rightRat, err := IGetRat(right)
+	if err != nil {
+		return nil, NewTypeMismatch(op.String(), lhs, rhs, left, right)
+	}
+
+	res, err := dFn(leftRat, rightRat)
+	if err != nil {
+		return nil, err
+	}
+
+	_, leftIsDec := left.(Decimal)
+	_, rightIsDec := right.(Decimal)
+	if !leftIsDec && !rightIsDec && res.IsInt() {
+		if n := res.Num(); n.IsInt64() {
+			return n.Int64(), nil
+		} else if n.IsUint64() {
+			return n.Uint64(), nil
+		}
+	}
+	return Decimal{r: res}, nil
+}
+
+func decimalAdd(left, right *big.Rat) (*big.Rat, error) {
+	return new(big.Rat).Add(left, right), nil
+}
+
+func decimalSub(left, right *big.Rat) (*big.Rat, error) {
+	return new(big.Rat).Sub(left, right), nil
+}
+
+func decimalMul(left, right *big.Rat) (*big.Rat, error) {
+	return new(big.Rat).Mul(left, right), nil
+}
+
+func decimalDiv(left, right *big.Rat) (*big.Rat, error) {
+	if right.Sign() == 0 {
+		return nil, ErrDivideByZero
+	}
+	return new(big.Rat).Quo(left, right), nil
+}
+
+func decimalMod(left, right *big.Rat) (*big.Rat, error) {
+	if right.Sign() == 0 {
+		return nil, ErrDivideByZero
+	}
+	quo := new(big.Rat).Quo(left, right)
+	truncated := new(big.Int).Quo(quo.Num(), quo.Denom())
+	return new(big.Rat).Sub(left, new(big.Rat).Mul(right, new(big.Rat).SetInt(truncated))), nil
+}
+
+// exactCompare compares two numbers as exact rationals, returning an error if
+// either value is not a number.
+func exactCompare(left, right interface{}) (int, error) {
+	leftRat, err := IGetRat(left)
+	if err != nil {
+		return 0, err
+	}
+	rightRat, err := IGetRat(right)
+	if err != nil {
+		return 0, err
+	}
+	return leftRat.Cmp(rightRat), nil
+}
+
+// ratToInt64 converts a rational into an int64, returning an error if it is
+// not an integer or exceeds the capacity of an int64.
+func ratToInt64(r *big.Rat) (int64, error) {
+	if !r.IsInt() {
+		return 0, errors.New("value contains decimal places")
+	}
+	if !r.Num().IsInt64() {
+		return 0, errors.New("value exceeds the capacity of a 64-bit signed integer")
+	}
+	return r.Num().Int64(), nil
+}
+
+// ratToUint64 converts a rational into a uint64, returning an error if it is
+// not an integer or exceeds the capacity of a uint64.
+func ratToUint64(r *big.Rat) (uint64, error) {
+	if !r.IsInt() {
+		return 0, errors.New("value contains decimal places")
+	}
+	if !r.Num().IsUint64() {
+		return 0, errors.New("value exceeds the capacity of a 64-bit unsigned integer")
+	}
+	return r.Num().Uint64(), nil
+}
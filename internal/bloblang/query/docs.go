@@ -153,6 +153,8 @@ var (
 	MethodCategoryParsing        = "Parsing"
 	MethodCategoryObjectAndArray = "Object & Array Manipulation"
 	MethodCategoryGeoIP          = "GeoIP"
+	MethodCategoryNetwork        = "Network"
+	MethodCategoryGeo            = "Geospatial"
 	MethodCategoryDeprecated     = "Deprecated"
 	MethodCategoryPlugin         = "Plugin"
 )
@@ -22,6 +22,31 @@ func (e ErrNoContext) Error() string {
 
 //------------------------------------------------------------------------------
 
+// ErrInvalidParam is an error returned by a function or method constructor
+// when the value of a specific parameter is invalid, which allows parsers to
+// point at the argument that caused the error.
+type ErrInvalidParam struct {
+	Name string
+	Err  error
+}
+
+// NewErrInvalidParam wraps an error caused by the value of a named parameter.
+func NewErrInvalidParam(name string, err error) *ErrInvalidParam {
+	return &ErrInvalidParam{Name: name, Err: err}
+}
+
+// Error returns the message of the underlying error.
+func (e *ErrInvalidParam) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ErrInvalidParam) Unwrap() error {
+	return e.Err
+}
+
+//------------------------------------------------------------------------------
+
 // TypeError represents an error where a value of a type was required for a
 // function, method or operator but instead a different type was found.
 type TypeError struct {
@@ -2,9 +2,15 @@ package query
 
 import (
 	"context"
+	crand "crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"math/rand"
+	"strconv"
 	"sync"
 	"time"
 
@@ -401,6 +407,10 @@ func jsonFunction(args *ParsedParams) (Function, error) {
 	if err != nil {
 		return nil, err
 	}
+	return jsonPathFunction(path), nil
+}
+
+func jsonPathFunction(path string) Function {
 	var argPath []string
 	if len(path) > 0 {
 		argPath = gabs.DotPathToSlice(path)
@@ -424,7 +434,7 @@ func jsonFunction(args *ParsedParams) (Function, error) {
 		}
 		ctx = ctx.WithValues(paths)
 		return ctx, paths
-	}), nil
+	})
 }
 
 //------------------------------------------------------------------------------
@@ -447,37 +457,126 @@ var _ = registerFunction(
 		if err != nil {
 			return nil, err
 		}
-		if len(key) > 0 {
-			return ClosureFunction("meta field "+key, func(ctx FunctionContext) (interface{}, error) {
-				v := ctx.MsgBatch.Get(ctx.Index).MetaGet(key)
-				if v == "" {
-					return nil, nil
-				}
-				return v, nil
-			}, func(ctx TargetsContext) (TargetsContext, []TargetPath) {
-				paths := []TargetPath{
-					NewTargetPath(TargetMetadata, key),
-				}
-				ctx = ctx.WithValues(paths)
-				return ctx, paths
-			}), nil
-		}
-		return ClosureFunction("meta object", func(ctx FunctionContext) (interface{}, error) {
-			kvs := map[string]interface{}{}
-			_ = ctx.MsgBatch.Get(ctx.Index).MetaIter(func(k, v string) error {
-				if len(v) > 0 {
-					kvs[k] = v
-				}
-				return nil
-			})
-			return kvs, nil
+		return metaKeyFunction(key), nil
+	},
+)
+
+func metaKeyFunction(key string) Function {
+	if len(key) > 0 {
+		return ClosureFunction("meta field "+key, func(ctx FunctionContext) (interface{}, error) {
+			v := ctx.MsgBatch.Get(ctx.Index).MetaGet(key)
+			if v == "" {
+				return nil, nil
+			}
+			return v, nil
 		}, func(ctx TargetsContext) (TargetsContext, []TargetPath) {
 			paths := []TargetPath{
-				NewTargetPath(TargetMetadata),
+				NewTargetPath(TargetMetadata, key),
 			}
 			ctx = ctx.WithValues(paths)
 			return ctx, paths
-		}), nil
+		})
+	}
+	return ClosureFunction("meta object", func(ctx FunctionContext) (interface{}, error) {
+		kvs := map[string]interface{}{}
+		_ = ctx.MsgBatch.Get(ctx.Index).MetaIter(func(k, v string) error {
+			if len(v) > 0 {
+				kvs[k] = v
+			}
+			return nil
+		})
+		return kvs, nil
+	}, func(ctx TargetsContext) (TargetsContext, []TargetPath) {
+		paths := []TargetPath{
+			NewTargetPath(TargetMetadata),
+		}
+		ctx = ctx.WithValues(paths)
+		return ctx, paths
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// fromIndexFunction executes a function from the perspective of another message
+// of the batch, where a negative index counts backwards from the end of the
+// batch.
+func fromIndexFunction(index int64, fn Function) Function {
+	return ClosureFunction(fn.Annotation()+" from "+strconv.FormatInt(index, 10), func(ctx FunctionContext) (interface{}, error) {
+		i := int(index)
+		if i < 0 {
+			i = ctx.MsgBatch.Len() + i
+		}
+		if i < 0 || i >= ctx.MsgBatch.Len() {
+			return nil, fmt.Errorf("index %v is out of bounds for a batch of size %v", index, ctx.MsgBatch.Len())
+		}
+		ctx.Index = i
+		return fn.Exec(ctx)
+	}, fn.QueryTargets)
+}
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "content_from",
+		"Returns the full raw contents of a message of the batch by its index as a byte array, where a negative index counts backwards from the end of the batch. This is equivalent to `content().from(index)`.",
+		NewExampleSpec("",
+			`root.first_doc = content_from(0).string()`,
+		),
+	).Param(ParamInt64("index", "The index of the message within the batch.")),
+	func(args *ParsedParams) (Function, error) {
+		index, err := args.FieldInt64("index")
+		if err != nil {
+			return nil, err
+		}
+		return fromIndexFunction(index, ClosureFunction("content", func(ctx FunctionContext) (interface{}, error) {
+			return ctx.MsgBatch.Get(ctx.Index).Get(), nil
+		}, nil)), nil
+	},
+)
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "json_from",
+		"Returns the value of a field within a JSON message of the batch by its index, where a negative index counts backwards from the end of the batch. This is equivalent to `json(path).from(index)`.",
+		NewExampleSpec("",
+			`root = this
+root.user = json_from(0, "user")`,
+		),
+	).
+		Param(ParamInt64("index", "The index of the message within the batch.")).
+		Param(ParamString("path", "An optional [dot path][field_paths] identifying a field to obtain.").Default("")),
+	func(args *ParsedParams) (Function, error) {
+		index, err := args.FieldInt64("index")
+		if err != nil {
+			return nil, err
+		}
+		path, err := args.FieldString("path")
+		if err != nil {
+			return nil, err
+		}
+		return fromIndexFunction(index, jsonPathFunction(path)), nil
+	},
+)
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryMessage, "meta_from",
+		"Returns the value of a metadata key from a message of the batch by its index, or `null` if the key does not exist, where a negative index counts backwards from the end of the batch. This is equivalent to `meta(key).from(index)`.",
+		NewExampleSpec("",
+			`root.topic = meta_from(0, "kafka_topic")`,
+		),
+	).
+		Param(ParamInt64("index", "The index of the message within the batch.")).
+		Param(ParamString("key", "An optional key of a metadata value to obtain, if omitted the entire metadata contents are returned as an object.").Default("")),
+	func(args *ParsedParams) (Function, error) {
+		index, err := args.FieldInt64("index")
+		if err != nil {
+			return nil, err
+		}
+		key, err := args.FieldString("key")
+		if err != nil {
+			return nil, err
+		}
+		return fromIndexFunction(index, metaKeyFunction(key)), nil
 	},
 )
 
@@ -555,7 +654,7 @@ var _ = registerFunction(
 var _ = registerFunction(
 	NewFunctionSpec(
 		FunctionCategoryGeneral, "random_int",
-		"Generates a non-negative pseudo-random 64-bit integer. An optional integer argument can be provided in order to seed the random number generator.",
+		"Generates a non-negative pseudo-random 64-bit integer. An optional integer argument can be provided in order to seed the random number generator, and the optional parameters `min` and `max` restrict the result to an inclusive range.",
 		NewExampleSpec("",
 			`root.first = random_int()
 root.second = random_int(1)`,
@@ -563,12 +662,17 @@ root.second = random_int(1)`,
 		NewExampleSpec("It is possible to specify a dynamic seed argument, in which case the argument will only be resolved once during the lifetime of the mapping.",
 			`root.first = random_int(timestamp_unix_nano())`,
 		),
+		NewExampleSpec("",
+			`root.dice = random_int(seed: timestamp_unix_nano(), min: 1, max: 6)`,
+		),
 	).
 		Param(ParamQuery(
 			"seed",
 			"A seed to use, if a query is provided it will only be resolved once during the lifetime of the mapping.",
 			true,
-		).Default(NewLiteralFunction("", 0))),
+		).Default(NewLiteralFunction("", 0))).
+		Param(ParamInt64("min", "The minimum value the random generated number will have.").Default(0)).
+		Param(ParamInt64("max", "The maximum value the random generated number will have.").Default(int64(math.MaxInt64))),
 	randomIntFunction,
 )
 
@@ -577,31 +681,157 @@ func randomIntFunction(args *ParsedParams) (Function, error) {
 	if err != nil {
 		return nil, err
 	}
+	min, err := args.FieldInt64("min")
+	if err != nil {
+		return nil, err
+	}
+	max, err := args.FieldInt64("max")
+	if err != nil {
+		return nil, err
+	}
+	if min < 0 {
+		return nil, NewErrInvalidParam("min", fmt.Errorf("min (%v) must not be negative", min))
+	}
+	if max < min {
+		return nil, NewErrInvalidParam("max", fmt.Errorf("max (%v) must be greater than or equal to min (%v)", max, min))
+	}
 
-	var randMut sync.Mutex
-	var r *rand.Rand
-
+	src := &seededRandSource{seedFn: seedFn}
 	return ClosureFunction("function random_int", func(ctx FunctionContext) (interface{}, error) {
-		randMut.Lock()
-		defer randMut.Unlock()
+		var v int64
+		err := src.with(ctx, func(r *rand.Rand) {
+			if span := max - min; span < math.MaxInt64 {
+				v = min + r.Int63n(span+1)
+			} else {
+				v = r.Int63()
+			}
+		})
+		return v, err
+	}, nil), nil
+}
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "random_float",
+		"Generates a pseudo-random 64-bit float in the range `[0.0, 1.0)`. An optional argument can be provided in order to seed the random number generator for a deterministic sequence.",
+		NewExampleSpec("",
+			`root.sampled = random_float() < 0.1`,
+		),
+		NewExampleSpec("",
+			`root.price = (random_float(seed: 42) * 100).round()`,
+		),
+	).Param(randSeedParam()),
+	func(args *ParsedParams) (Function, error) {
+		src, err := newSeededRandSource(args)
+		if err != nil {
+			return nil, err
+		}
+		return ClosureFunction("function random_float", func(ctx FunctionContext) (interface{}, error) {
+			var v float64
+			err := src.with(ctx, func(r *rand.Rand) {
+				v = r.Float64()
+			})
+			return v, err
+		}, nil), nil
+	},
+)
 
-		if r == nil {
-			seedI, err := seedFn.Exec(ctx)
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "random_pick",
+		"Returns a pseudo-randomly chosen element of an array. An optional argument can be provided in order to seed the random number generator for a deterministic sequence.",
+		NewExampleSpec("",
+			`root.colour = random_pick(["red", "green", "blue"])`,
+		),
+		NewExampleSpec("The array can be a query, which is resolved each time the function is invoked.",
+			`root.server = random_pick(values: this.servers, seed: 42)`,
+		),
+	).
+		Param(ParamQuery("values", "An array of values to pick from.", false)).
+		Param(randSeedParam()),
+	func(args *ParsedParams) (Function, error) {
+		valuesFn, err := args.FieldQuery("values")
+		if err != nil {
+			return nil, err
+		}
+		src, err := newSeededRandSource(args)
+		if err != nil {
+			return nil, err
+		}
+		return ClosureFunction("function random_pick", func(ctx FunctionContext) (interface{}, error) {
+			v, err := valuesFn.Exec(ctx)
 			if err != nil {
-				return nil, fmt.Errorf("failed to seed random number generator: %v", err)
+				return nil, err
+			}
+			values, ok := v.([]interface{})
+			if !ok {
+				return nil, ErrFrom(NewTypeError(v, ValueArray), valuesFn)
+			}
+			if len(values) == 0 {
+				return nil, errors.New("cannot pick from an empty array")
 			}
+			var i int
+			if err := src.with(ctx, func(r *rand.Rand) {
+				i = r.Intn(len(values))
+			}); err != nil {
+				return nil, err
+			}
+			return values[i], nil
+		}, valuesFn.QueryTargets), nil
+	},
+)
 
-			seed, err := IToInt(seedI)
+func randSeedParam() ParamDefinition {
+	return ParamQuery(
+		"seed",
+		"An optional seed to use for a deterministic sequence, if a query is provided it will only be resolved once during the lifetime of the mapping.",
+		true,
+	).Optional()
+}
+
+// seededRandSource lazily creates a random number generator that is shared by
+// all invocations of a function, and is seeded from a query when one is
+// specified.
+type seededRandSource struct {
+	seedFn Function
+
+	mut sync.Mutex
+	r   *rand.Rand
+}
+
+func newSeededRandSource(args *ParsedParams) (*seededRandSource, error) {
+	seedFn, err := args.FieldOptionalQuery("seed")
+	if err != nil {
+		return nil, err
+	}
+	return &seededRandSource{seedFn: seedFn}, nil
+}
+
+func (s *seededRandSource) with(ctx FunctionContext, fn func(r *rand.Rand)) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.r == nil {
+		var seed int64
+		if s.seedFn == nil {
+			var b [8]byte
+			if _, err := io.ReadFull(crand.Reader, b[:]); err != nil {
+				return fmt.Errorf("failed to seed random number generator: %v", err)
+			}
+			seed = int64(binary.BigEndian.Uint64(b[:]))
+		} else {
+			seedI, err := s.seedFn.Exec(ctx)
 			if err != nil {
-				return nil, fmt.Errorf("failed to seed random number generator: %v", err)
+				return fmt.Errorf("failed to seed random number generator: %v", err)
+			}
+			if seed, err = IToInt(seedI); err != nil {
+				return fmt.Errorf("failed to seed random number generator: %v", err)
 			}
-
-			r = rand.New(rand.NewSource(seed))
 		}
-
-		v := int64(r.Int())
-		return v, nil
-	}, nil), nil
+		s.r = rand.New(rand.NewSource(seed))
+	}
+	fn(s.r)
+	return nil
 }
 
 //------------------------------------------------------------------------------
@@ -682,21 +912,79 @@ root.doc.contents = (this.body.content | this.thing.body)`,
 
 //------------------------------------------------------------------------------
 
-var _ = registerSimpleFunction(
+var _ = registerFunction(
 	NewFunctionSpec(
 		FunctionCategoryGeneral, "uuid_v4",
 		"Generates a new RFC-4122 UUID each time it is invoked and prints a string representation.",
 		NewExampleSpec("", `root.id = uuid_v4()`),
-	),
-	func(_ FunctionContext) (interface{}, error) {
-		u4, err := uuid.NewV4()
+		NewExampleSpec("An optional seed can be provided in order to generate a deterministic sequence of UUIDs, which is useful for testing.", `root.id = uuid_v4(10)`),
+	).Param(idSeedParam()),
+	func(args *ParsedParams) (Function, error) {
+		src, err := newIDRandomSource(args)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
-		return u4.String(), nil
+		return ClosureFunction("function uuid_v4", func(ctx FunctionContext) (interface{}, error) {
+			var u4 uuid.UUID
+			if err := src.read(ctx, u4[:]); err != nil {
+				return nil, err
+			}
+			u4.SetVersion(uuid.V4)
+			u4.SetVariant(uuid.VariantRFC4122)
+			return u4.String(), nil
+		}, nil), nil
 	},
 )
 
+// idRandomSource provides the random component of generated identifiers, which
+// is cryptographically secure unless a seed is specified.
+type idRandomSource struct {
+	seedFn Function
+
+	mut sync.Mutex
+	r   *rand.Rand
+}
+
+func idSeedParam() ParamDefinition {
+	return ParamQuery(
+		"seed",
+		"An optional seed for generating a deterministic sequence of random components, if a query is provided it will only be resolved once during the lifetime of the mapping.",
+		true,
+	).Optional()
+}
+
+func newIDRandomSource(args *ParsedParams) (*idRandomSource, error) {
+	seedFn, err := args.FieldOptionalQuery("seed")
+	if err != nil {
+		return nil, err
+	}
+	return &idRandomSource{seedFn: seedFn}, nil
+}
+
+func (s *idRandomSource) read(ctx FunctionContext, b []byte) error {
+	if s.seedFn == nil {
+		_, err := io.ReadFull(crand.Reader, b)
+		return err
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.r == nil {
+		seedI, err := s.seedFn.Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to seed random number generator: %v", err)
+		}
+		seed, err := IToInt(seedI)
+		if err != nil {
+			return fmt.Errorf("failed to seed random number generator: %v", err)
+		}
+		s.r = rand.New(rand.NewSource(seed))
+	}
+	_, err := s.r.Read(b)
+	return err
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerFunction(
@@ -737,17 +1025,77 @@ func nanoidFunction(args *ParsedParams) (Function, error) {
 
 //------------------------------------------------------------------------------
 
-var _ = registerSimpleFunction(
+var _ = registerFunction(
 	NewFunctionSpec(
 		FunctionCategoryGeneral, "ksuid",
 		"Generates a new ksuid each time it is invoked and prints a string representation.",
 		NewExampleSpec("", `root.id = ksuid()`),
-	),
-	func(_ FunctionContext) (interface{}, error) {
-		return ksuid.New().String(), nil
+		NewExampleSpec("An optional seed can be provided in order to generate a deterministic sequence for the random component of each ksuid, which is useful for testing. The timestamp component still reflects the time of generation.", `root.id = ksuid(10)`),
+	).Param(idSeedParam()),
+	func(args *ParsedParams) (Function, error) {
+		src, err := newIDRandomSource(args)
+		if err != nil {
+			return nil, err
+		}
+		return ClosureFunction("function ksuid", func(ctx FunctionContext) (interface{}, error) {
+			payload := make([]byte, 16)
+			if err := src.read(ctx, payload); err != nil {
+				return nil, err
+			}
+			id, err := ksuid.FromParts(time.Now(), payload)
+			if err != nil {
+				return nil, err
+			}
+			return id.String(), nil
+		}, nil), nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "ulid",
+		"Generates a new ULID each time it is invoked and prints a string representation. ULIDs are lexicographically sortable by the millisecond they were generated.",
+		NewExampleSpec("", `root.id = ulid()`),
+		NewExampleSpec("An optional seed can be provided in order to generate a deterministic sequence for the random component of each ULID, which is useful for testing. The timestamp component still reflects the time of generation.", `root.id = ulid(10)`),
+	).Param(idSeedParam()),
+	func(args *ParsedParams) (Function, error) {
+		src, err := newIDRandomSource(args)
+		if err != nil {
+			return nil, err
+		}
+		return ClosureFunction("function ulid", func(ctx FunctionContext) (interface{}, error) {
+			var id [16]byte
+			ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+			for i := 0; i < 6; i++ {
+				id[i] = byte(ms >> (8 * (5 - i)))
+			}
+			if err := src.read(ctx, id[6:]); err != nil {
+				return nil, err
+			}
+			return encodeULID(id), nil
+		}, nil), nil
 	},
 )
 
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// encodeULID encodes the 128 bits of a ULID as 26 characters of Crockford's
+// base32.
+func encodeULID(id [16]byte) string {
+	n := new(big.Int).SetBytes(id[:])
+	mask := big.NewInt(31)
+	digit := new(big.Int)
+
+	out := make([]byte, 26)
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = ulidAlphabet[digit.And(n, mask).Int64()]
+		n.Rsh(n, 5)
+	}
+	return string(out)
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerFunction(
@@ -0,0 +1,200 @@
+package query
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strings"
+)
+
+var (
+	fakeFirstNames = []string{
+		"Alice", "Amara", "Ben", "Carlos", "Chen", "Chloe", "Daniel", "Elena",
+		"Fatima", "George", "Hannah", "Hiro", "Isabel", "Jack", "Kofi", "Laura",
+		"Liam", "Maya", "Mohammed", "Nina", "Oliver", "Priya", "Quinn", "Rosa",
+		"Samuel", "Sofia", "Tom", "Uma", "Victor", "Wei", "Yara", "Zoe",
+	}
+	fakeLastNames = []string{
+		"Anderson", "Brown", "Chen", "Costa", "Davies", "Dubois", "Evans",
+		"Fischer", "Garcia", "Hughes", "Ito", "Jones", "Kim", "Kowalski", "Lee",
+		"Martin", "Mensah", "Nguyen", "Novak", "Okafor", "Patel", "Quinn",
+		"Rossi", "Schmidt", "Silva", "Smith", "Taylor", "Walker", "Williams",
+		"Wilson", "Young", "Zhang",
+	}
+	fakeWords = []string{
+		"alpha", "amber", "anchor", "beacon", "bridge", "canyon", "cedar",
+		"comet", "copper", "delta", "ember", "falcon", "forest", "garden",
+		"harbor", "horizon", "island", "jade", "lantern", "maple", "meadow",
+		"nova", "orbit", "pebble", "prairie", "quartz", "river", "saffron",
+		"signal", "summit", "thunder", "valley", "willow", "zephyr",
+	}
+	fakeDomainSuffixes = []string{"com", "net", "org", "io", "dev", "co.uk"}
+	fakeCities         = []string{
+		"Amsterdam", "Austin", "Berlin", "Buenos Aires", "Cairo", "Cape Town",
+		"Chicago", "Dublin", "Lagos", "Lisbon", "London", "Melbourne",
+		"Mexico City", "Montreal", "Mumbai", "Nairobi", "Osaka", "Paris",
+		"Seoul", "Singapore", "Stockholm", "Toronto", "Vancouver", "Warsaw",
+	}
+	fakeCountries = []string{
+		"Argentina", "Australia", "Brazil", "Canada", "Egypt", "France",
+		"Germany", "India", "Ireland", "Japan", "Kenya", "Mexico",
+		"Netherlands", "Nigeria", "Poland", "Portugal", "Singapore",
+		"South Africa", "South Korea", "Sweden", "United Kingdom",
+		"United States",
+	}
+	fakeStreetSuffixes  = []string{"Street", "Avenue", "Road", "Lane", "Drive", "Way", "Court", "Place"}
+	fakeCompanySuffixes = []string{"Inc", "LLC", "Ltd", "Group", "Labs", "Systems", "Partners"}
+)
+
+func fakePick(r *rand.Rand, values []string) string {
+	return values[r.Intn(len(values))]
+}
+
+func fakeDigits(r *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + r.Intn(10))
+	}
+	return string(b)
+}
+
+func fakeDomain(r *rand.Rand) string {
+	return fakePick(r, fakeWords) + "." + fakePick(r, fakeDomainSuffixes)
+}
+
+func fakeUsername(r *rand.Rand) string {
+	return strings.ToLower(fakePick(r, fakeFirstNames)) + "." + strings.ToLower(fakePick(r, fakeLastNames)) + fakeDigits(r, 2)
+}
+
+func fakeSentence(r *rand.Rand) string {
+	words := make([]string, 4+r.Intn(6))
+	for i := range words {
+		words[i] = fakePick(r, fakeWords)
+	}
+	s := strings.Join(words, " ")
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+var fakeGenerators = map[string]func(r *rand.Rand) string{
+	"city": func(r *rand.Rand) string {
+		return fakePick(r, fakeCities)
+	},
+	"company": func(r *rand.Rand) string {
+		word := fakePick(r, fakeWords)
+		return strings.ToUpper(word[:1]) + word[1:] + " " + fakePick(r, fakeCompanySuffixes)
+	},
+	"country": func(r *rand.Rand) string {
+		return fakePick(r, fakeCountries)
+	},
+	"domain": fakeDomain,
+	"email": func(r *rand.Rand) string {
+		return fakeUsername(r) + "@" + fakeDomain(r)
+	},
+	"first_name": func(r *rand.Rand) string {
+		return fakePick(r, fakeFirstNames)
+	},
+	"hex_color": func(r *rand.Rand) string {
+		return fmt.Sprintf("#%06x", r.Intn(1<<24))
+	},
+	"ipv4": func(r *rand.Rand) string {
+		return fmt.Sprintf("%v.%v.%v.%v", 1+r.Intn(223), r.Intn(256), r.Intn(256), 1+r.Intn(254))
+	},
+	"ipv6": func(r *rand.Rand) string {
+		ip := make(net.IP, net.IPv6len)
+		_, _ = r.Read(ip)
+		// Restrict to global unicast addresses (2000::/3).
+		ip[0] = 0x20 | (ip[0] & 0x1f)
+		return ip.String()
+	},
+	"last_name": func(r *rand.Rand) string {
+		return fakePick(r, fakeLastNames)
+	},
+	"mac_address": func(r *rand.Rand) string {
+		mac := make(net.HardwareAddr, 6)
+		_, _ = r.Read(mac)
+		// Locally administered unicast addresses.
+		mac[0] = (mac[0] | 0x02) & 0xfe
+		return mac.String()
+	},
+	"name": func(r *rand.Rand) string {
+		return fakePick(r, fakeFirstNames) + " " + fakePick(r, fakeLastNames)
+	},
+	"phone_number": func(r *rand.Rand) string {
+		return fmt.Sprintf("+1-%v%v-555-%v", 2+r.Intn(8), fakeDigits(r, 2), fakeDigits(r, 4))
+	},
+	"sentence": fakeSentence,
+	"street_address": func(r *rand.Rand) string {
+		word := fakePick(r, fakeWords)
+		return fmt.Sprintf("%v %v%v %v", 1+r.Intn(9999), strings.ToUpper(word[:1]), word[1:], fakePick(r, fakeStreetSuffixes))
+	},
+	"url": func(r *rand.Rand) string {
+		return "https://www." + fakeDomain(r) + "/" + fakePick(r, fakeWords)
+	},
+	"username": fakeUsername,
+	"uuid": func(r *rand.Rand) string {
+		b := make([]byte, 16)
+		_, _ = r.Read(b)
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	},
+	"word": func(r *rand.Rand) string {
+		return fakePick(r, fakeWords)
+	},
+	"zip_code": func(r *rand.Rand) string {
+		return fakeDigits(r, 5)
+	},
+}
+
+func fakeKinds() []string {
+	kinds := make([]string, 0, len(fakeGenerators))
+	for k := range fakeGenerators {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "fake",
+		"Generates a pseudo-random, realistic looking value of a given kind, which is useful for producing synthetic data with a `generate` input. The supported kinds are `"+strings.Join(fakeKinds(), "`, `")+"`. An optional argument can be provided in order to seed the random number generator for a deterministic sequence.",
+		NewExampleSpec("",
+			`root.user = {
+  "name": fake("name"),
+  "email": fake("email"),
+  "ip": fake("ipv4"),
+  "address": fake("street_address") + ", " + fake("city"),
+}`,
+		),
+		NewExampleSpec("A seed can be provided in order to generate the same sequence of values each time the mapping is created.",
+			`root.id = fake(kind: "uuid", seed: 42)`,
+		),
+	).
+		Param(ParamString("kind", "The kind of value to generate.")).
+		Param(randSeedParam()),
+	func(args *ParsedParams) (Function, error) {
+		kind, err := args.FieldString("kind")
+		if err != nil {
+			return nil, err
+		}
+		gen, exists := fakeGenerators[kind]
+		if !exists {
+			return nil, NewErrInvalidParam("kind", fmt.Errorf("unrecognised kind '%v', expected one of: %v", kind, strings.Join(fakeKinds(), ", ")))
+		}
+		src, err := newSeededRandSource(args)
+		if err != nil {
+			return nil, err
+		}
+		return ClosureFunction("function fake", func(ctx FunctionContext) (interface{}, error) {
+			var res string
+			if err := src.with(ctx, func(r *rand.Rand) {
+				res = gen(r)
+			}); err != nil {
+				return nil, err
+			}
+			return res, nil
+		}, nil), nil
+	},
+)
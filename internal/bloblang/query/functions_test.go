@@ -2,9 +2,14 @@ package query
 
 import (
 	"fmt"
+	"net"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/gofrs/uuid"
+	"github.com/segmentio/ksuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -39,6 +44,17 @@ func TestFunctions(t *testing.T) {
 		vars     map[string]interface{}
 		index    int
 	}{
+		"check geo_distance": {
+			input: mustMethod(
+				mustFunc("geo_distance", 51.5074, -0.1278, 48.8566, 2.3522),
+				"round",
+			),
+			output: int64(344),
+		},
+		"check geo_distance same point": {
+			input:  mustFunc("geo_distance", 10.0, 20.0, 10.0, 20.0),
+			output: float64(0),
+		},
 		"check throw function 1": {
 			input: mustFunc("throw", "foo"),
 			err:   "foo",
@@ -112,6 +128,56 @@ func TestFunctions(t *testing.T) {
 				}},
 			},
 		},
+		"check content_from function": {
+			input:  mustFunc("content_from", 1),
+			output: []byte(`{"foo":"second"}`),
+			messages: []easyMsg{
+				{content: `{"foo":"first"}`},
+				{content: `{"foo":"second"}`},
+			},
+		},
+		"check json_from function": {
+			input:  mustFunc("json_from", 1, "foo"),
+			output: "second",
+			messages: []easyMsg{
+				{content: `{"foo":"first"}`},
+				{content: `{"foo":"second"}`},
+			},
+		},
+		"check json_from function negative index": {
+			input:  mustFunc("json_from", -2, "foo"),
+			output: "first",
+			index:  1,
+			messages: []easyMsg{
+				{content: `{"foo":"first"}`},
+				{content: `{"foo":"second"}`},
+			},
+		},
+		"check json_from function out of bounds": {
+			input: mustFunc("json_from", 2),
+			err:   "index 2 is out of bounds for a batch of size 2",
+			messages: []easyMsg{
+				{content: `{"foo":"first"}`},
+				{content: `{"foo":"second"}`},
+			},
+		},
+		"check meta_from function": {
+			input:  mustFunc("meta_from", 0, "foo"),
+			output: "first",
+			index:  1,
+			messages: []easyMsg{
+				{content: "", meta: map[string]string{"foo": "first"}},
+				{content: "", meta: map[string]string{"foo": "second"}},
+			},
+		},
+		"check meta_from function object": {
+			input:  mustFunc("meta_from", 1),
+			output: map[string]interface{}{"foo": "second"},
+			messages: []easyMsg{
+				{content: "", meta: map[string]string{"foo": "first"}},
+				{content: "", meta: map[string]string{"foo": "second"}},
+			},
+		},
 		"check range start > end": {
 			input: mustFunc("range", mustFunc("var", "start"), 0, 1),
 			vars: map[string]interface{}{
@@ -276,6 +342,99 @@ func TestKsuidFunction(t *testing.T) {
 	assert.NotEmpty(t, res)
 }
 
+func TestKsuidFunctionSeeded(t *testing.T) {
+	payloads := make([]string, 2)
+	for i := range payloads {
+		e, err := InitFunctionHelper("ksuid", int64(10))
+		require.NoError(t, err)
+
+		res, err := e.Exec(FunctionContext{})
+		require.NoError(t, err)
+
+		id, err := ksuid.Parse(res.(string))
+		require.NoError(t, err)
+		payloads[i] = string(id.Payload())
+	}
+	assert.Equal(t, payloads[0], payloads[1])
+}
+
+func TestUUIDV4Function(t *testing.T) {
+	e, err := InitFunctionHelper("uuid_v4")
+	require.NoError(t, err)
+
+	first, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	second, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	u, err := uuid.FromString(first.(string))
+	require.NoError(t, err)
+	assert.Equal(t, byte(uuid.V4), u.Version())
+	assert.Equal(t, uuid.VariantRFC4122, u.Variant())
+}
+
+func TestUUIDV4FunctionSeeded(t *testing.T) {
+	var results [2][]interface{}
+	for i := range results {
+		e, err := InitFunctionHelper("uuid_v4", int64(10))
+		require.NoError(t, err)
+
+		for j := 0; j < 3; j++ {
+			res, err := e.Exec(FunctionContext{})
+			require.NoError(t, err)
+			results[i] = append(results[i], res)
+		}
+	}
+	assert.Equal(t, results[0], results[1])
+	assert.NotEqual(t, results[0][0], results[0][1])
+}
+
+func TestULIDFunction(t *testing.T) {
+	e, err := InitFunctionHelper("ulid")
+	require.NoError(t, err)
+
+	before := time.Now().Add(-time.Millisecond)
+	first, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	require.Len(t, first, 26)
+	assert.Regexp(t, "^[0-9A-HJKMNP-TV-Z]{26}$", first)
+
+	// The first ten characters encode the timestamp in milliseconds.
+	var ms int64
+	for _, c := range first.(string)[:10] {
+		ms = ms*32 + int64(strings.IndexRune(ulidAlphabet, c))
+	}
+	assert.GreaterOrEqual(t, ms, before.UnixNano()/int64(time.Millisecond))
+
+	time.Sleep(time.Millisecond * 2)
+	second, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Less(t, first.(string), second.(string))
+}
+
+func TestULIDFunctionSeeded(t *testing.T) {
+	var results [2]string
+	for i := range results {
+		e, err := InitFunctionHelper("ulid", int64(10))
+		require.NoError(t, err)
+
+		res, err := e.Exec(FunctionContext{})
+		require.NoError(t, err)
+		results[i] = res.(string)[10:]
+	}
+	assert.Equal(t, results[0], results[1])
+}
+
+func TestEncodeULID(t *testing.T) {
+	var id [16]byte
+	assert.Equal(t, "00000000000000000000000000", encodeULID(id))
+	for i := range id {
+		id[i] = 0xff
+	}
+	assert.Equal(t, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", encodeULID(id))
+}
+
 func TestRandomInt(t *testing.T) {
 	e, err := InitFunctionHelper("random_int")
 	require.Nil(t, err)
@@ -399,3 +558,171 @@ func TestRandomIntDynamicParallel(t *testing.T) {
 	close(startChan)
 	wg.Wait()
 }
+
+func TestRandomIntRange(t *testing.T) {
+	e, err := InitFunctionHelper("random_int", 10, 5, 8)
+	require.NoError(t, err)
+
+	tallies := map[int64]int64{}
+	for i := 0; i < 100; i++ {
+		res, err := e.Exec(FunctionContext{})
+		require.NoError(t, err)
+		require.IsType(t, int64(0), res)
+		assert.GreaterOrEqual(t, res.(int64), int64(5))
+		assert.LessOrEqual(t, res.(int64), int64(8))
+		tallies[res.(int64)]++
+	}
+	assert.Len(t, tallies, 4)
+
+	_, err = InitFunctionHelper("random_int", 10, 5, 4)
+	require.EqualError(t, err, "max (4) must be greater than or equal to min (5)")
+
+	_, err = InitFunctionHelper("random_int", 10, -1, 4)
+	require.EqualError(t, err, "min (-1) must not be negative")
+}
+
+func TestRandomFloat(t *testing.T) {
+	e, err := InitFunctionHelper("random_float")
+	require.NoError(t, err)
+
+	tallies := map[float64]int64{}
+	for i := 0; i < 100; i++ {
+		res, err := e.Exec(FunctionContext{})
+		require.NoError(t, err)
+		require.IsType(t, float64(0), res)
+		assert.GreaterOrEqual(t, res.(float64), 0.0)
+		assert.Less(t, res.(float64), 1.0)
+		tallies[res.(float64)]++
+	}
+	assert.GreaterOrEqual(t, len(tallies), 90)
+}
+
+func TestRandomFloatSeeded(t *testing.T) {
+	var first []interface{}
+	for j := 0; j < 2; j++ {
+		e, err := InitFunctionHelper("random_float", 42)
+		require.NoError(t, err)
+
+		var results []interface{}
+		for i := 0; i < 10; i++ {
+			res, err := e.Exec(FunctionContext{})
+			require.NoError(t, err)
+			results = append(results, res)
+		}
+		if j == 0 {
+			first = results
+		} else {
+			assert.Equal(t, first, results)
+		}
+	}
+}
+
+func TestRandomPick(t *testing.T) {
+	values := []interface{}{"a", "b", "c"}
+
+	e, err := InitFunctionHelper("random_pick", NewFieldFunction(""))
+	require.NoError(t, err)
+
+	tallies := map[interface{}]int64{}
+	for i := 0; i < 100; i++ {
+		res, err := e.Exec(FunctionContext{}.WithValue(values))
+		require.NoError(t, err)
+		tallies[res]++
+	}
+	assert.Len(t, tallies, 3)
+
+	_, err = e.Exec(FunctionContext{}.WithValue([]interface{}{}))
+	require.EqualError(t, err, "cannot pick from an empty array")
+
+	_, err = e.Exec(FunctionContext{}.WithValue("nope"))
+	require.EqualError(t, err, "expected array value, got string from field `this` (\"nope\")")
+}
+
+func TestRandomPickSeeded(t *testing.T) {
+	values := []interface{}{"a", "b", "c", "d", "e", "f"}
+
+	var first []interface{}
+	for j := 0; j < 2; j++ {
+		e, err := InitFunctionHelper("random_pick", NewFieldFunction(""), 42)
+		require.NoError(t, err)
+
+		var results []interface{}
+		for i := 0; i < 20; i++ {
+			res, err := e.Exec(FunctionContext{}.WithValue(values))
+			require.NoError(t, err)
+			results = append(results, res)
+		}
+		if j == 0 {
+			first = results
+		} else {
+			assert.Equal(t, first, results)
+		}
+	}
+}
+
+func TestFake(t *testing.T) {
+	checks := map[string]func(s string) bool{
+		"email": func(s string) bool {
+			return strings.Count(s, "@") == 1
+		},
+		"ipv4": func(s string) bool {
+			ip := net.ParseIP(s)
+			return ip != nil && ip.To4() != nil
+		},
+		"ipv6": func(s string) bool {
+			ip := net.ParseIP(s)
+			return ip != nil && ip.To4() == nil
+		},
+		"mac_address": func(s string) bool {
+			_, err := net.ParseMAC(s)
+			return err == nil
+		},
+		"uuid": func(s string) bool {
+			return len(s) == 36 && s[14] == '4'
+		},
+		"zip_code": func(s string) bool {
+			return len(s) == 5
+		},
+	}
+
+	for _, kind := range fakeKinds() {
+		e, err := InitFunctionHelper("fake", kind)
+		require.NoError(t, err, kind)
+
+		for i := 0; i < 10; i++ {
+			res, err := e.Exec(FunctionContext{})
+			require.NoError(t, err, kind)
+
+			s, ok := res.(string)
+			require.True(t, ok, kind)
+			assert.NotEmpty(t, s, kind)
+			if check, exists := checks[kind]; exists {
+				assert.True(t, check(s), "%v: %v", kind, s)
+			}
+		}
+	}
+
+	_, err := InitFunctionHelper("fake", "nope")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unrecognised kind 'nope'")
+}
+
+func TestFakeSeeded(t *testing.T) {
+	var first []interface{}
+	for j := 0; j < 2; j++ {
+		e, err := InitFunctionHelper("fake", "name", 42)
+		require.NoError(t, err)
+
+		var results []interface{}
+		for i := 0; i < 10; i++ {
+			res, err := e.Exec(FunctionContext{})
+			require.NoError(t, err)
+			results = append(results, res)
+		}
+		if j == 0 {
+			first = results
+		} else {
+			assert.Equal(t, first, results)
+		}
+	}
+}
@@ -16,6 +16,13 @@ func registerSimpleMethod(spec MethodSpec, ctor simpleMethodConstructor) struct{
 		if err != nil {
 			return nil, err
 		}
+		if lit, isLit := target.(*Literal); isLit && !spec.Impure && !args.hasQueries() {
+			// Pure methods executed on a literal are folded into a literal of
+			// the result, errors are left to be returned during execution.
+			if res, err := fn(lit.Value, FunctionContext{}); err == nil {
+				return NewLiteralFunction("method "+spec.Name, res), nil
+			}
+		}
 		return ClosureFunction("method "+spec.Name, func(ctx FunctionContext) (interface{}, error) {
 			v, err := target.Exec(ctx)
 			if err != nil {
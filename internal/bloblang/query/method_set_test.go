@@ -103,3 +103,38 @@ func TestMethodBadName(t *testing.T) {
 		})
 	}
 }
+
+func TestMethodLiteralFolding(t *testing.T) {
+	fn, err := InitMethodHelper("uppercase", NewLiteralFunction("", "foo"))
+	require.NoError(t, err)
+
+	lit, isLit := fn.(*Literal)
+	require.True(t, isLit, "%T", fn)
+	assert.Equal(t, "FOO", lit.Value)
+
+	fn, err = InitMethodHelper("number", NewLiteralFunction("", "nope"))
+	require.NoError(t, err)
+
+	_, isLit = fn.(*Literal)
+	assert.False(t, isLit, "errors should be returned at execution")
+	_, err = fn.Exec(FunctionContext{})
+	assert.Error(t, err)
+
+	fn, err = InitMethodHelper("uppercase", NewFieldFunction("foo"))
+	require.NoError(t, err)
+
+	_, isLit = fn.(*Literal)
+	assert.False(t, isLit)
+
+	fn, err = InitMethodHelper("map_each", NewLiteralFunction("", []interface{}{"a"}), NewVarFunction("foo"))
+	require.NoError(t, err)
+
+	_, isLit = fn.(*Literal)
+	assert.False(t, isLit, "query arguments should prevent folding")
+
+	res, err := fn.Exec(FunctionContext{
+		Vars: map[string]interface{}{"foo": "bar"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"bar"}, res)
+}
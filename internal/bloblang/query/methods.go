@@ -76,6 +76,35 @@ func applyMethod(target Function, args *ParsedParams) (Function, error) {
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec("array", "").InCategory(
+		MethodCategoryCoercion,
+		"Returns the value if it is already an array, otherwise returns an array containing the value as its only element. This is useful for normalizing fields that contain either a single value or an array of values.",
+		NewExampleSpec("",
+			`root.tags = this.tags.array()`,
+			`{"tags":"foo"}`,
+			`{"tags":["foo"]}`,
+			`{"tags":["foo","bar"]}`,
+			`{"tags":["foo","bar"]}`,
+		),
+		NewExampleSpec("In order to turn a missing field into an empty array rather than an array containing `null` combine it with an `or` method:",
+			`root.tags = this.tags.or([]).array()`,
+			`{"id":"foo"}`,
+			`{"tags":[]}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			if arr, isArr := v.([]interface{}); isArr {
+				return arr, nil
+			}
+			return []interface{}{v}, nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerMethod(
 	NewMethodSpec("bool", "").InCategory(
 		MethodCategoryCoercion,
@@ -128,7 +157,14 @@ var _ = registerMethod(
 			`not structured data`,
 			`<Message deleted>`,
 		),
-	).Param(ParamQuery("fallback", "A value to yield, or query to execute, if the target query fails.", true)),
+		NewExampleSpec("The fallback can also be a named context lambda, in which case the error message of the target query is captured under the context name, allowing you to report it.",
+			`root.doc = this.doc.number().catch(err -> {"error": err, "input": this.doc})`,
+			`{"doc":"10"}`,
+			`{"doc":10}`,
+			`{"doc":"ten"}`,
+			"{\"doc\":{\"error\":\"field `this.doc`: strconv.ParseFloat: parsing \\\"ten\\\": invalid syntax\",\"input\":\"ten\"}}",
+		),
+	).Param(ParamQuery("fallback", "A value to yield, or query to execute, if the target query fails. When the query is a named context lambda the error message is captured under the context name.", true)),
 	catchMethod,
 )
 
@@ -140,6 +176,9 @@ func catchMethod(fn Function, args *ParsedParams) (Function, error) {
 	return ClosureFunction("method catch", func(ctx FunctionContext) (interface{}, error) {
 		res, err := fn.Exec(ctx)
 		if err != nil {
+			if _, isLambda := catchFn.(*NamedContextFunction); isLambda {
+				return catchFn.Exec(ctx.WithValue(err.Error()))
+			}
 			return catchFn.Exec(ctx)
 		}
 		return res, err
@@ -348,8 +387,14 @@ type notMethod struct {
 	fn Function
 }
 
-// Not returns a logical NOT of a child function.
+// Not returns a logical NOT of a child function. If the child is a boolean
+// literal then the result is folded into a literal.
 func Not(fn Function) Function {
+	if lit, isLit := fn.(*Literal); isLit {
+		if b, isBool := lit.Value.(bool); isBool {
+			return NewLiteralFunction("not "+lit.Annotation(), !b)
+		}
+	}
 	return &notMethod{
 		fn: fn,
 	}
@@ -376,7 +421,7 @@ func (n *notMethod) QueryTargets(ctx TargetsContext) (TargetsContext, []TargetPa
 }
 
 func notMethodCtor(target Function, _ *ParsedParams) (Function, error) {
-	return &notMethod{fn: target}, nil
+	return Not(target), nil
 }
 
 //------------------------------------------------------------------------------
@@ -447,6 +492,77 @@ func numberCoerceMethod(target Function, args *ParsedParams) (Function, error) {
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"int64", "",
+	).InCategory(
+		MethodCategoryCoercion,
+		"Converts a numerical value into a 64-bit signed integer, or parses a string as one. If the value contains decimal places or exceeds the capacity of a 64-bit signed integer an error is returned.",
+		NewExampleSpec("",
+			`root.id = this.id.int64()
+root.count = this.count.int64()`,
+			`{"count":"10","id":9007199254740993}`,
+			`{"count":10,"id":9007199254740993}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			d, err := IToDecimal(ISanitize(v))
+			if err != nil {
+				return nil, err
+			}
+			return ratToInt64(d.r)
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"uint64", "",
+	).InCategory(
+		MethodCategoryCoercion,
+		"Converts a numerical value into a 64-bit unsigned integer, or parses a string as one. If the value contains decimal places, is negative, or exceeds the capacity of a 64-bit unsigned integer an error is returned.",
+		NewExampleSpec("",
+			`root.id = this.id.uint64()`,
+			`{"id":"18446744073709551615"}`,
+			`{"id":18446744073709551615}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			d, err := IToDecimal(ISanitize(v))
+			if err != nil {
+				return nil, err
+			}
+			return ratToUint64(d.r)
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"decimal", "",
+	).InCategory(
+		MethodCategoryCoercion,
+		"Converts a numerical value into an exact decimal, or parses a string as one. Arithmetic between a decimal and other numbers results in an exact decimal rather than a floating point number, which is useful for values such as currencies. Decimals that cannot be represented exactly, such as the result of dividing one by three, are rounded to 34 decimal places.",
+		NewExampleSpec("",
+			`root.total = this.price.decimal() * this.quantity
+root.float_total = this.price * this.quantity`,
+			`{"price":0.1,"quantity":3}`,
+			`{"float_total":0.30000000000000004,"total":0.3}`,
+			`{"price":1.1,"quantity":3}`,
+			`{"float_total":3.3000000000000003,"total":3.3}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			return IToDecimal(ISanitize(v))
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerMethod(
 	NewMethodSpec(
 		"or", "If the result of the target query fails or resolves to `null`, returns the argument instead. This is an explicit method alternative to the coalesce pipe operator `|`.",
@@ -0,0 +1,240 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	earthRadiusKm   = 6371.0088
+	geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+)
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "geo_distance",
+		"Calculates the great-circle distance in kilometres between two points described by their latitude and longitude in decimal degrees, using the haversine formula.",
+		NewExampleSpec("",
+			`root.distance_km = geo_distance(this.from.lat, this.from.lon, this.to.lat, this.to.lon).round()`,
+		),
+	).
+		Param(ParamFloat("lat1", "The latitude of the first point.")).
+		Param(ParamFloat("lon1", "The longitude of the first point.")).
+		Param(ParamFloat("lat2", "The latitude of the second point.")).
+		Param(ParamFloat("lon2", "The longitude of the second point.")),
+	func(args *ParsedParams) (Function, error) {
+		var coords [4]float64
+		for i, name := range []string{"lat1", "lon1", "lat2", "lon2"} {
+			v, err := args.FieldFloat(name)
+			if err != nil {
+				return nil, err
+			}
+			coords[i] = v
+		}
+		for i, name := range []string{"lat1", "lon1", "lat2", "lon2"} {
+			if err := checkCoordinate(name, coords[i], i%2 == 0); err != nil {
+				return nil, err
+			}
+		}
+		distance := haversine(coords[0], coords[1], coords[2], coords[3])
+		return ClosureFunction("function geo_distance", func(ctx FunctionContext) (interface{}, error) {
+			return distance, nil
+		}, nil), nil
+	},
+)
+
+func checkCoordinate(name string, v float64, isLat bool) error {
+	limit := 180.0
+	if isLat {
+		limit = 90.0
+	}
+	if math.IsNaN(v) || v < -limit || v > limit {
+		return fmt.Errorf("%v must be between -%v and %v, got %v", name, limit, limit, v)
+	}
+	return nil
+}
+
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 {
+		return deg * math.Pi / 180
+	}
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"geohash_encode", "",
+	).InCategory(
+		MethodCategoryGeo,
+		"Encodes an object containing the fields `lat` and `lon`, in decimal degrees, as a [geohash](https://en.wikipedia.org/wiki/Geohash) string of a given precision.",
+		NewExampleSpec("",
+			`root.hash = this.location.geohash_encode(11)`,
+			`{"location":{"lat":57.64911,"lon":10.40744}}`,
+			`{"hash":"u4pruydqqvj"}`,
+		),
+	).Param(ParamInt64("precision", "The number of characters of the resulting geohash, between 1 and 12.").Default(12)),
+	func(args *ParsedParams) (simpleMethod, error) {
+		precision, err := args.FieldInt64("precision")
+		if err != nil {
+			return nil, err
+		}
+		if precision < 1 || precision > 12 {
+			return nil, NewErrInvalidParam("precision", fmt.Errorf("precision must be between 1 and 12, got %v", precision))
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, NewTypeError(v, ValueObject)
+			}
+			var coords [2]float64
+			for i, name := range []string{"lat", "lon"} {
+				fv, exists := obj[name]
+				if !exists {
+					return nil, fmt.Errorf("field %v is missing", name)
+				}
+				f, err := IGetNumber(fv)
+				if err != nil {
+					return nil, fmt.Errorf("field %v: %w", name, err)
+				}
+				if err := checkCoordinate(name, f, i == 0); err != nil {
+					return nil, err
+				}
+				coords[i] = f
+			}
+			return geohashEncode(coords[0], coords[1], int(precision)), nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"geohash_decode", "",
+	).InCategory(
+		MethodCategoryGeo,
+		"Decodes a [geohash](https://en.wikipedia.org/wiki/Geohash) string into an object containing the fields `lat` and `lon` describing the centre of the area it represents.",
+		NewExampleSpec("",
+			`root.location = this.hash.geohash_decode()`,
+			`{"hash":"u4"}`,
+			`{"location":{"lat":59.0625,"lon":5.625}}`,
+		),
+	),
+	func(args *ParsedParams) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			minLat, minLon, maxLat, maxLon, err := geohashBounds(s)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"lat": (minLat + maxLat) / 2,
+				"lon": (minLon + maxLon) / 2,
+			}, nil
+		}), nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"geohash_bounds", "",
+	).InCategory(
+		MethodCategoryGeo,
+		"Decodes a [geohash](https://en.wikipedia.org/wiki/Geohash) string into an object describing the bounding box of the area it represents, with the fields `min_lat`, `min_lon`, `max_lat` and `max_lon`.",
+		NewExampleSpec("",
+			`root.box = this.hash.geohash_bounds()`,
+			`{"hash":"u4"}`,
+			`{"box":{"max_lat":61.875,"max_lon":11.25,"min_lat":56.25,"min_lon":0}}`,
+		),
+	),
+	func(args *ParsedParams) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			minLat, minLon, maxLat, maxLon, err := geohashBounds(s)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"min_lat": minLat,
+				"min_lon": minLon,
+				"max_lat": maxLat,
+				"max_lon": maxLon,
+			}, nil
+		}), nil
+	},
+)
+
+func geohashEncode(lat, lon float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+
+	var b strings.Builder
+	evenBit := true
+	for b.Len() < precision {
+		idx := 0
+		for bit := 0; bit < 5; bit++ {
+			idx <<= 1
+			if evenBit {
+				if mid := (minLon + maxLon) / 2; lon >= mid {
+					idx |= 1
+					minLon = mid
+				} else {
+					maxLon = mid
+				}
+			} else {
+				if mid := (minLat + maxLat) / 2; lat >= mid {
+					idx |= 1
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			evenBit = !evenBit
+		}
+		b.WriteByte(geohashAlphabet[idx])
+	}
+	return b.String()
+}
+
+func geohashBounds(hash string) (minLat, minLon, maxLat, maxLon float64, err error) {
+	if hash == "" {
+		err = errors.New("geohash must not be empty")
+		return
+	}
+
+	minLat, maxLat = -90.0, 90.0
+	minLon, maxLon = -180.0, 180.0
+
+	evenBit := true
+	for _, c := range strings.ToLower(hash) {
+		idx := strings.IndexRune(geohashAlphabet, c)
+		if idx < 0 {
+			err = fmt.Errorf("invalid geohash character: %q", c)
+			return
+		}
+		for bit := 4; bit >= 0; bit-- {
+			set := (idx>>bit)&1 == 1
+			if evenBit {
+				mid := (minLon + maxLon) / 2
+				if set {
+					minLon = mid
+				} else {
+					maxLon = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if set {
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			evenBit = !evenBit
+		}
+	}
+	return
+}
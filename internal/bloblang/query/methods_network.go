@@ -0,0 +1,137 @@
+package query
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address: %v", s)
+	}
+	return ip, nil
+}
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"ip_in_cidr", "",
+	).InCategory(
+		MethodCategoryNetwork,
+		"Checks whether a string IP address belongs to a network described by a CIDR notation range, such as `10.0.0.0/8` or `2001:db8::/32`.",
+		NewExampleSpec("",
+			`root.internal = this.ip.ip_in_cidr("10.0.0.0/8")`,
+			`{"ip":"10.2.3.4"}`,
+			`{"internal":true}`,
+			`{"ip":"192.168.0.1"}`,
+			`{"internal":false}`,
+		),
+	).Param(ParamString("cidr", "The network range in CIDR notation.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		cidrStr, err := args.FieldString("cidr")
+		if err != nil {
+			return nil, err
+		}
+		_, network, err := net.ParseCIDR(cidrStr)
+		if err != nil {
+			return nil, NewErrInvalidParam("cidr", err)
+		}
+		return stringMethod(func(s string) (interface{}, error) {
+			ip, err := parseIP(s)
+			if err != nil {
+				return nil, err
+			}
+			return network.Contains(ip), nil
+		}), nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"ip_version", "",
+	).InCategory(
+		MethodCategoryNetwork,
+		"Returns the version of a string IP address, either `4` or `6`.",
+		NewExampleSpec("",
+			`root.version = this.ip.ip_version()`,
+			`{"ip":"10.2.3.4"}`,
+			`{"version":4}`,
+			`{"ip":"2001:db8::1"}`,
+			`{"version":6}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			ip, err := parseIP(s)
+			if err != nil {
+				return nil, err
+			}
+			if ip.To4() != nil {
+				return int64(4), nil
+			}
+			return int64(6), nil
+		}), nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"ip_to_int", "",
+	).InCategory(
+		MethodCategoryNetwork,
+		"Converts a string IPv4 address into its integer representation, which can be reversed with the method [`int_to_ip`](#int_to_ip).",
+		NewExampleSpec("",
+			`root.ip_int = this.ip.ip_to_int()`,
+			`{"ip":"10.2.3.4"}`,
+			`{"ip_int":167904004}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			ip, err := parseIP(s)
+			if err != nil {
+				return nil, err
+			}
+			ip4 := ip.To4()
+			if ip4 == nil {
+				return nil, fmt.Errorf("IPv6 address %v cannot be converted to an integer", s)
+			}
+			return int64(binary.BigEndian.Uint32(ip4)), nil
+		}), nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"int_to_ip", "",
+	).InCategory(
+		MethodCategoryNetwork,
+		"Converts an integer into its IPv4 address string representation.",
+		NewExampleSpec("",
+			`root.ip = this.ip_int.int_to_ip()`,
+			`{"ip_int":167904004}`,
+			`{"ip":"10.2.3.4"}`,
+		),
+		NewExampleSpec("Addresses can be anonymized by zeroing the host portion of their integer representation:",
+			`root.ip = ((this.ip.ip_to_int() / 256).floor() * 256).int_to_ip()`,
+			`{"ip":"10.2.3.4"}`,
+			`{"ip":"10.2.3.0"}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			i, err := IGetInt(v)
+			if err != nil {
+				return nil, err
+			}
+			if i < 0 || i > 0xFFFFFFFF {
+				return nil, errors.New("value is out of range for an IPv4 address")
+			}
+			ip := make(net.IP, net.IPv4len)
+			binary.BigEndian.PutUint32(ip, uint32(i))
+			return ip.String(), nil
+		}, nil
+	},
+)
@@ -0,0 +1,452 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"merge_patch", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Applies a [JSON Merge Patch (RFC 7396)](https://datatracker.ietf.org/doc/html/rfc7396) to a value. Objects of the patch are merged recursively into the target, fields of the patch set to `null` are removed from the target, and all other values of the patch replace the target.",
+		NewExampleSpec("",
+			`root = this.doc.merge_patch(this.patch)`,
+			`{"doc":{"title":"Hello","author":{"given":"John","family":"Doe"},"tags":["a","b"]},"patch":{"title":"Goodbye","author":{"family":null},"tags":["c"]}}`,
+			`{"author":{"given":"John"},"tags":["c"],"title":"Goodbye"}`,
+		),
+	).Param(ParamAny("patch", "The merge patch to apply.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		patch, err := args.Field("patch")
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			return mergePatch(v, patch), nil
+		}, nil
+	},
+)
+
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return IClone(patch)
+	}
+
+	targetObj := map[string]interface{}{}
+	if tObj, ok := target.(map[string]interface{}); ok {
+		for k, v := range tObj {
+			targetObj[k] = v
+		}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+		targetObj[k] = mergePatch(targetObj[k], v)
+	}
+	return targetObj
+}
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"patch", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Applies a [JSON Patch (RFC 6902)](https://datatracker.ietf.org/doc/html/rfc6902) to a value, where the patch is an array of operation objects. The operations `add`, `remove`, `replace`, `move`, `copy` and `test` are supported, and paths are expressed as [JSON Pointers](https://datatracker.ietf.org/doc/html/rfc6901). If any operation fails, including a `test` operation that doesn't match, then the method fails and the target is left unchanged.",
+		NewExampleSpec("",
+			`root = this.doc.patch(this.ops)`,
+			`{"doc":{"name":"foo","tags":["a"]},"ops":[{"op":"test","path":"/name","value":"foo"},{"op":"replace","path":"/name","value":"bar"},{"op":"add","path":"/tags/-","value":"b"},{"op":"move","from":"/name","path":"/id"}]}`,
+			`{"id":"bar","tags":["a","b"]}`,
+		),
+	).Param(ParamArray("operations", "An array of JSON Patch operations to apply.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		opsArr, err := args.FieldArray("operations")
+		if err != nil {
+			return nil, err
+		}
+		ops, err := parsePatchOperations(opsArr)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			res := IClone(v)
+			for i, op := range ops {
+				if res, err = op.apply(res); err != nil {
+					return nil, fmt.Errorf("operation %v (%v): %w", i, op.op, err)
+				}
+			}
+			return res, nil
+		}, nil
+	},
+)
+
+type patchOperation struct {
+	op    string
+	path  []string
+	from  []string
+	value interface{}
+}
+
+func parsePatchOperations(opsArr []interface{}) ([]patchOperation, error) {
+	ops := make([]patchOperation, 0, len(opsArr))
+	for i, opV := range opsArr {
+		op, err := parsePatchOperation(opV)
+		if err != nil {
+			return nil, fmt.Errorf("operation %v: %w", i, err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+func parsePatchOperation(v interface{}) (op patchOperation, err error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return op, NewTypeError(v, ValueObject)
+	}
+
+	if op.op, ok = obj["op"].(string); !ok {
+		return op, errors.New("expected string field `op`")
+	}
+	pathStr, ok := obj["path"].(string)
+	if !ok {
+		return op, errors.New("expected string field `path`")
+	}
+	if op.path, err = parseJSONPointer(pathStr); err != nil {
+		return op, err
+	}
+
+	switch op.op {
+	case "add", "replace", "test":
+		var exists bool
+		if op.value, exists = obj["value"]; !exists {
+			return op, fmt.Errorf("expected field `value` for operation %v", op.op)
+		}
+	case "move", "copy":
+		fromStr, ok := obj["from"].(string)
+		if !ok {
+			return op, fmt.Errorf("expected string field `from` for operation %v", op.op)
+		}
+		if op.from, err = parseJSONPointer(fromStr); err != nil {
+			return op, err
+		}
+		if op.op == "move" && len(op.from) < len(op.path) && isPathPrefix(op.from, op.path) {
+			return op, errors.New("a value cannot be moved into one of its children")
+		}
+	case "remove":
+	default:
+		return op, fmt.Errorf("unrecognised operation: %v", op.op)
+	}
+	return op, nil
+}
+
+func (p patchOperation) apply(root interface{}) (interface{}, error) {
+	switch p.op {
+	case "add":
+		return pointerAdd(root, p.path, IClone(p.value))
+	case "remove":
+		res, _, err := pointerRemove(root, p.path)
+		return res, err
+	case "replace":
+		res, _, err := pointerRemove(root, p.path)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(res, p.path, IClone(p.value))
+	case "move":
+		res, v, err := pointerRemove(root, p.from)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(res, p.path, v)
+	case "copy":
+		v, err := pointerGet(root, p.from)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(root, p.path, IClone(v))
+	case "test":
+		v, err := pointerGet(root, p.path)
+		if err != nil {
+			return nil, err
+		}
+		if !patchEqual(v, p.value) {
+			return nil, fmt.Errorf("value at path %v does not match", formatJSONPointer(p.path))
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unrecognised operation: %v", p.op)
+}
+
+//------------------------------------------------------------------------------
+
+func parseJSONPointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("path %q must be empty or begin with a slash", p)
+	}
+	path := strings.Split(p[1:], "/")
+	for i, s := range path {
+		path[i] = strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+	}
+	return path, nil
+}
+
+func formatJSONPointer(path []string) string {
+	var b strings.Builder
+	for _, s := range path {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+func isPathPrefix(prefix, path []string) bool {
+	for i, s := range prefix {
+		if path[i] != s {
+			return false
+		}
+	}
+	return true
+}
+
+func pointerIndex(key string, length int, allowEnd bool) (int, error) {
+	if allowEnd && key == "-" {
+		return length, nil
+	}
+	i, err := strconv.Atoi(key)
+	if err != nil || i < 0 || (i > 0 && key[0] == '0') {
+		return 0, fmt.Errorf("invalid array index: %v", key)
+	}
+	if i > length || (i == length && !allowEnd) {
+		return 0, fmt.Errorf("array index %v out of bounds", key)
+	}
+	return i, nil
+}
+
+func pointerGet(node interface{}, path []string) (interface{}, error) {
+	for i, key := range path {
+		switch t := node.(type) {
+		case map[string]interface{}:
+			v, exists := t[key]
+			if !exists {
+				return nil, fmt.Errorf("path %v does not exist", formatJSONPointer(path[:i+1]))
+			}
+			node = v
+		case []interface{}:
+			idx, err := pointerIndex(key, len(t), false)
+			if err != nil {
+				return nil, fmt.Errorf("path %v: %w", formatJSONPointer(path[:i+1]), err)
+			}
+			node = t[idx]
+		default:
+			return nil, fmt.Errorf("path %v does not exist", formatJSONPointer(path[:i+1]))
+		}
+	}
+	return node, nil
+}
+
+// pointerAdd adds a value to a path and returns the resulting root, as arrays
+// modified by insertions need to be replaced within their parent.
+func pointerAdd(root interface{}, path []string, value interface{}) (interface{}, error) {
+	var add func(node interface{}, depth int) (interface{}, error)
+	add = func(node interface{}, depth int) (interface{}, error) {
+		if depth == len(path) {
+			return value, nil
+		}
+		key, last := path[depth], depth == len(path)-1
+		switch t := node.(type) {
+		case map[string]interface{}:
+			if last {
+				t[key] = value
+				return t, nil
+			}
+			child, exists := t[key]
+			if !exists {
+				return nil, fmt.Errorf("path %v does not exist", formatJSONPointer(path[:depth+1]))
+			}
+			newChild, err := add(child, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			t[key] = newChild
+			return t, nil
+		case []interface{}:
+			idx, err := pointerIndex(key, len(t), last)
+			if err != nil {
+				return nil, fmt.Errorf("path %v: %w", formatJSONPointer(path[:depth+1]), err)
+			}
+			if last {
+				t = append(t, nil)
+				copy(t[idx+1:], t[idx:])
+				t[idx] = value
+				return t, nil
+			}
+			newChild, err := add(t[idx], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			t[idx] = newChild
+			return t, nil
+		}
+		return nil, fmt.Errorf("path %v does not exist", formatJSONPointer(path[:depth+1]))
+	}
+	return add(root, 0)
+}
+
+// pointerRemove removes the value at a path and returns the resulting root as
+// well as the removed value.
+func pointerRemove(root interface{}, path []string) (res, removed interface{}, err error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("the root cannot be removed")
+	}
+	var remove func(node interface{}, depth int) (interface{}, error)
+	remove = func(node interface{}, depth int) (interface{}, error) {
+		key, last := path[depth], depth == len(path)-1
+		switch t := node.(type) {
+		case map[string]interface{}:
+			child, exists := t[key]
+			if !exists {
+				return nil, fmt.Errorf("path %v does not exist", formatJSONPointer(path[:depth+1]))
+			}
+			if last {
+				removed = child
+				delete(t, key)
+				return t, nil
+			}
+			newChild, err := remove(child, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			t[key] = newChild
+			return t, nil
+		case []interface{}:
+			idx, err := pointerIndex(key, len(t), false)
+			if err != nil {
+				return nil, fmt.Errorf("path %v: %w", formatJSONPointer(path[:depth+1]), err)
+			}
+			if last {
+				removed = t[idx]
+				return append(t[:idx], t[idx+1:]...), nil
+			}
+			newChild, err := remove(t[idx], depth+1)
+			if err != nil {
+				return nil, err
+			}
+			t[idx] = newChild
+			return t, nil
+		}
+		return nil, fmt.Errorf("path %v does not exist", formatJSONPointer(path[:depth+1]))
+	}
+	res, err = remove(root, 0)
+	return
+}
+
+// patchEqual returns whether two values are equal for the purposes of a patch,
+// where values of different types are never equal.
+func patchEqual(a, b interface{}) bool {
+	return ITypeOf(a) == ITypeOf(b) && ICompare(a, b)
+}
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"diff", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Compares a value with an argument value and returns a [JSON Patch (RFC 6902)](https://datatracker.ietf.org/doc/html/rfc6902) that transforms the target into the argument, which can be applied with the method [`patch`](#patch). Fields of objects are compared recursively, as are elements of arrays by their index.",
+		NewExampleSpec("",
+			`root = this.before.diff(this.after)`,
+			`{"before":{"name":"foo","age":30,"tags":["a","b"]},"after":{"name":"bar","tags":["a"],"active":true}}`,
+			`[{"op":"add","path":"/active","value":true},{"op":"remove","path":"/age"},{"op":"replace","path":"/name","value":"bar"},{"op":"remove","path":"/tags/1"}]`,
+		),
+	).Param(ParamAny("other", "The value to compare against.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		other, err := args.Field("other")
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			return jsonDiff(nil, v, other, []interface{}{}), nil
+		}, nil
+	},
+)
+
+func patchOp(op string, path []string, value interface{}) map[string]interface{} {
+	obj := map[string]interface{}{
+		"op":   op,
+		"path": formatJSONPointer(path),
+	}
+	if op != "remove" {
+		obj["value"] = IClone(value)
+	}
+	return obj
+}
+
+func jsonDiff(path []string, from, to interface{}, ops []interface{}) []interface{} {
+	childPath := func(key string) []string {
+		return append(append(make([]string, 0, len(path)+1), path...), key)
+	}
+
+	switch fromT := from.(type) {
+	case map[string]interface{}:
+		toT, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(fromT)+len(toT))
+		for k := range fromT {
+			keys = append(keys, k)
+		}
+		for k := range toT {
+			if _, exists := fromT[k]; !exists {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fromV, fromExists := fromT[k]
+			toV, toExists := toT[k]
+			switch {
+			case !toExists:
+				ops = append(ops, patchOp("remove", childPath(k), nil))
+			case !fromExists:
+				ops = append(ops, patchOp("add", childPath(k), toV))
+			default:
+				ops = jsonDiff(childPath(k), fromV, toV, ops)
+			}
+		}
+		return ops
+	case []interface{}:
+		toT, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		i := 0
+		for ; i < len(fromT) && i < len(toT); i++ {
+			ops = jsonDiff(childPath(strconv.Itoa(i)), fromT[i], toT[i], ops)
+		}
+		for j := len(fromT) - 1; j >= i; j-- {
+			ops = append(ops, patchOp("remove", childPath(strconv.Itoa(j)), nil))
+		}
+		for ; i < len(toT); i++ {
+			ops = append(ops, patchOp("add", childPath(strconv.Itoa(i)), toT[i]))
+		}
+		return ops
+	}
+	if !patchEqual(from, to) {
+		ops = append(ops, patchOp("replace", path, to))
+	}
+	return ops
+}
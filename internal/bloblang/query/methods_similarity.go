@@ -0,0 +1,239 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"levenshtein", "",
+	).InCategory(
+		MethodCategoryStrings,
+		"Returns the [Levenshtein distance](https://en.wikipedia.org/wiki/Levenshtein_distance) between a string and an argument string, which is the minimum number of single character insertions, deletions or substitutions required to change one into the other. The comparison is case sensitive.",
+		NewExampleSpec("",
+			`root.distance = this.a.levenshtein(this.b)`,
+			`{"a":"kitten","b":"sitting"}`,
+			`{"distance":3}`,
+		),
+	).Param(ParamString("other", "The string to compare against.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		other, err := args.FieldString("other")
+		if err != nil {
+			return nil, err
+		}
+		otherRunes := []rune(other)
+		return stringMethod(func(s string) (interface{}, error) {
+			return int64(levenshtein([]rune(s), otherRunes)), nil
+		}), nil
+	},
+)
+
+func levenshtein(a, b []rune) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prevDiag := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			prevDiag, row[j] = row[j], minInt(minInt(row[j]+1, row[j-1]+1), prevDiag+cost)
+		}
+	}
+	return row[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"jaro_winkler", "",
+	).InCategory(
+		MethodCategoryStrings,
+		"Returns the [Jaro-Winkler similarity](https://en.wikipedia.org/wiki/Jaro%E2%80%93Winkler_distance) between a string and an argument string as a number between `0`, where the strings have nothing in common, and `1`, where they are identical. Strings that share a common prefix are scored higher. The comparison is case sensitive.",
+		NewExampleSpec("",
+			`root.similarity = (this.a.jaro_winkler(this.b) * 1000).round() / 1000`,
+			`{"a":"martha","b":"marhta"}`,
+			`{"similarity":0.961}`,
+		),
+		NewExampleSpec("Strings can be normalised before being compared in order to ignore differences such as case.",
+			`root.same = this.a.lowercase().jaro_winkler(this.b.lowercase()) > 0.9`,
+			`{"a":"Benthos","b":"benthso"}`,
+			`{"same":true}`,
+		),
+	).Param(ParamString("other", "The string to compare against.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		other, err := args.FieldString("other")
+		if err != nil {
+			return nil, err
+		}
+		otherRunes := []rune(other)
+		return stringMethod(func(s string) (interface{}, error) {
+			return jaroWinkler([]rune(s), otherRunes), nil
+		}), nil
+	},
+)
+
+func jaro(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	matchDistance := len(a)
+	if len(b) > matchDistance {
+		matchDistance = len(b)
+	}
+	if matchDistance = matchDistance/2 - 1; matchDistance < 0 {
+		matchDistance = 0
+	}
+
+	aMatches := make([]bool, len(a))
+	bMatches := make([]bool, len(b))
+
+	matches := 0
+	for i := range a {
+		start, end := i-matchDistance, i+matchDistance+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(b) {
+			end = len(b)
+		}
+		for j := start; j < end; j++ {
+			if bMatches[j] || a[i] != b[j] {
+				continue
+			}
+			aMatches[i], bMatches[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range a {
+		if !aMatches[i] {
+			continue
+		}
+		for !bMatches[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	return (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions/2))/m) / 3
+}
+
+func jaroWinkler(a, b []rune) float64 {
+	sim := jaro(a, b)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && prefix < 4 && a[prefix] == b[prefix] {
+		prefix++
+	}
+	return sim + float64(prefix)*0.1*(1-sim)
+}
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"soundex", "",
+	).InCategory(
+		MethodCategoryStrings,
+		"Returns the [American Soundex](https://en.wikipedia.org/wiki/Soundex) code of a string, which is a letter followed by three digits that indexes the string by how it sounds when pronounced in English. Characters other than the letters A to Z are ignored, and an empty string is returned when there are no such letters.",
+		NewExampleSpec("",
+			`root.codes = this.names.map_each(name -> name.soundex())`,
+			`{"names":["Robert","Rupert","Tymczak","Pfister"]}`,
+			`{"codes":["R163","R163","T522","P236"]}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			return soundex(s), nil
+		}), nil
+	},
+)
+
+func soundexCode(r rune) byte {
+	switch r {
+	case 'B', 'F', 'P', 'V':
+		return '1'
+	case 'C', 'G', 'J', 'K', 'Q', 'S', 'X', 'Z':
+		return '2'
+	case 'D', 'T':
+		return '3'
+	case 'L':
+		return '4'
+	case 'M', 'N':
+		return '5'
+	case 'R':
+		return '6'
+	case 'H', 'W':
+		// Ignored, and don't separate letters with the same code.
+		return 1
+	}
+	// Vowels are ignored but separate letters with the same code.
+	return 0
+}
+
+func soundex(s string) string {
+	var b strings.Builder
+	var last byte
+	for _, r := range s {
+		r = unicode.ToUpper(r)
+		if r < 'A' || r > 'Z' {
+			continue
+		}
+		code := soundexCode(r)
+		if b.Len() == 0 {
+			b.WriteRune(r)
+			last = code
+			continue
+		}
+		switch code {
+		case 0:
+			last = 0
+		case 1:
+		default:
+			if code != last {
+				b.WriteByte(code)
+			}
+			last = code
+		}
+		if b.Len() == 4 {
+			break
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	for b.Len() < 4 {
+		b.WriteByte('0')
+	}
+	return b.String()
+}
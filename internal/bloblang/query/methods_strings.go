@@ -10,17 +10,20 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/ascii85"
+	"encoding/base32"
 	"encoding/base64"
 	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"html"
 	"io"
 	"net/url"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -29,6 +32,7 @@ import (
 	"github.com/itchyny/timefmt-go"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/rickb777/date/period"
+	"github.com/russross/blackfriday/v2"
 	"github.com/tilinna/z85"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -88,7 +92,7 @@ var _ = registerSimpleMethod(
 		"encode", "",
 	).InCategory(
 		MethodCategoryEncoding,
-		"Encodes a string or byte array target according to a chosen scheme and returns a string result. Available schemes are: `base64`, `base64url`, `hex`, `ascii85`.",
+		"Encodes a string or byte array target according to a chosen scheme and returns a string result. Available schemes are: `base64`, `base64url`, `base64rawurl`, `base64raw`, `base32`, `hex`, `ascii85`, `percent`.",
 		// NOTE: z85 has been removed from the list until we can support
 		// misaligned data automatically. It'll still be supported for backwards
 		// compatibility, but given it behaves differently to `ascii85` I think
@@ -98,6 +102,11 @@ var _ = registerSimpleMethod(
 			`{"value":"hello world"}`,
 			`{"encoded":"68656c6c6f20776f726c64"}`,
 		),
+		NewExampleSpec("The `percent` scheme escapes all characters other than the unreserved characters of RFC 3986, making it suitable for both paths and query parameters:",
+			`root.encoded = this.value.encode("percent")`,
+			`{"value":"hello world/and all?"}`,
+			`{"encoded":"hello%20world%2Fand%20all%3F"}`,
+		),
 		NewExampleSpec("",
 			`root.encoded = content().encode("ascii85")`,
 			`this is totally unstructured data`,
@@ -128,6 +137,22 @@ var _ = registerSimpleMethod(
 				e.Close()
 				return buf.String(), nil
 			}
+		case "base64rawurl":
+			schemeFn = func(b []byte) (string, error) {
+				return base64.RawURLEncoding.EncodeToString(b), nil
+			}
+		case "base64raw":
+			schemeFn = func(b []byte) (string, error) {
+				return base64.RawStdEncoding.EncodeToString(b), nil
+			}
+		case "base32":
+			schemeFn = func(b []byte) (string, error) {
+				return base32.StdEncoding.EncodeToString(b), nil
+			}
+		case "percent":
+			schemeFn = func(b []byte) (string, error) {
+				return percentEncode(b), nil
+			}
 		case "hex":
 			schemeFn = func(b []byte) (string, error) {
 				var buf bytes.Buffer
@@ -179,6 +204,24 @@ var _ = registerSimpleMethod(
 	},
 )
 
+// percentEncode escapes all bytes other than the unreserved characters of
+// RFC 3986.
+func percentEncode(b []byte) string {
+	const upperHex = "0123456789ABCDEF"
+	var buf strings.Builder
+	for _, c := range b {
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			buf.WriteByte(c)
+			continue
+		}
+		buf.WriteByte('%')
+		buf.WriteByte(upperHex[c>>4])
+		buf.WriteByte(upperHex[c&15])
+	}
+	return buf.String()
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerSimpleMethod(
@@ -186,7 +229,7 @@ var _ = registerSimpleMethod(
 		"decode", "",
 	).InCategory(
 		MethodCategoryEncoding,
-		"Decodes an encoded string target according to a chosen scheme and returns the result as a byte array. When mapping the result to a JSON field the value should be cast to a string using the method [`string`][methods.string], or encoded using the method [`encode`][methods.encode], otherwise it will be base64 encoded by default.\n\nAvailable schemes are: `base64`, `base64url`, `hex`, `ascii85`.",
+		"Decodes an encoded string target according to a chosen scheme and returns the result as a byte array. When mapping the result to a JSON field the value should be cast to a string using the method [`string`][methods.string], or encoded using the method [`encode`][methods.encode], otherwise it will be base64 encoded by default.\n\nAvailable schemes are: `base64`, `base64url`, `base64rawurl`, `base64raw`, `base32`, `hex`, `ascii85`, `percent`.",
 		// NOTE: z85 has been removed from the list until we can support
 		// misaligned data automatically. It'll still be supported for backwards
 		// compatibility, but given it behaves differently to `ascii85` I think
@@ -196,6 +239,11 @@ var _ = registerSimpleMethod(
 			`{"value":"68656c6c6f20776f726c64"}`,
 			`{"decoded":"hello world"}`,
 		),
+		NewExampleSpec("",
+			`root.decoded = this.value.decode("base64rawurl").string()`,
+			`{"value":"aGVsbG8_d29ybGQ"}`,
+			`{"decoded":"hello?world"}`,
+		),
 		NewExampleSpec("",
 			`root = this.encoded.decode("ascii85")`,
 			"{\"encoded\":\"FD,B0+DGm>FDl80Ci\\\"A>F`)8BEckl6F`M&(+Cno&@/\"}",
@@ -220,6 +268,29 @@ var _ = registerSimpleMethod(
 				e := base64.NewDecoder(base64.URLEncoding, bytes.NewReader(b))
 				return io.ReadAll(e)
 			}
+		case "base64rawurl":
+			schemeFn = func(b []byte) ([]byte, error) {
+				e := base64.NewDecoder(base64.RawURLEncoding, bytes.NewReader(b))
+				return io.ReadAll(e)
+			}
+		case "base64raw":
+			schemeFn = func(b []byte) ([]byte, error) {
+				e := base64.NewDecoder(base64.RawStdEncoding, bytes.NewReader(b))
+				return io.ReadAll(e)
+			}
+		case "base32":
+			schemeFn = func(b []byte) ([]byte, error) {
+				e := base32.NewDecoder(base32.StdEncoding, bytes.NewReader(b))
+				return io.ReadAll(e)
+			}
+		case "percent":
+			schemeFn = func(b []byte) ([]byte, error) {
+				res, err := url.PathUnescape(string(b))
+				if err != nil {
+					return nil, err
+				}
+				return []byte(res), nil
+			}
 		case "hex":
 			schemeFn = func(b []byte) ([]byte, error) {
 				e := hex.NewDecoder(bytes.NewReader(b))
@@ -267,7 +338,7 @@ var _ = registerSimpleMethod(
 		"encrypt_aes", "",
 	).InCategory(
 		MethodCategoryEncoding,
-		"Encrypts a string or byte array target according to a chosen AES encryption method and returns a string result. The algorithms require a key and an initialization vector / nonce. Available schemes are: `ctr`, `ofb`, `cbc`.",
+		"Encrypts a string or byte array target according to a chosen AES encryption method and returns a string result. The algorithms require a key and an initialization vector / nonce. Available schemes are: `ctr`, `ofb`, `cbc`, `gcm`.",
 		NewExampleSpec("",
 			`let key = "2b7e151628aed2a6abf7158809cf4f3c".decode("hex")
 let vector = "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff".decode("hex")
@@ -275,8 +346,13 @@ root.encrypted = this.value.encrypt_aes("ctr", $key, $vector).encode("hex")`,
 			`{"value":"hello world!"}`,
 			`{"encrypted":"84e9b31ff7400bdf80be7254"}`,
 		),
+		NewExampleSpec("The `gcm` scheme authenticates the result, which has the authentication tag appended to it, and expects a 12 byte nonce. Keys can be obtained from environment variables with the function [`env`](/docs/guides/bloblang/functions#env):",
+			`let key = env("AES_KEY").decode("hex")
+let nonce = "000102030405060708090a0b".decode("hex")
+root.encrypted = this.value.encrypt_aes("gcm", $key, $nonce).encode("hex")`,
+		),
 	).
-		Param(ParamString("scheme", "The scheme to use for encryption, one of `ctr`, `ofb`, `cbc`.")).
+		Param(ParamString("scheme", "The scheme to use for encryption, one of `ctr`, `ofb`, `cbc`, `gcm`.")).
 		Param(ParamString("key", "A key to encrypt with.")).
 		Param(ParamString("iv", "An initialization vector / nonce.")),
 	func(args *ParsedParams) (simpleMethod, error) {
@@ -298,6 +374,9 @@ root.encrypted = this.value.encrypt_aes("ctr", $key, $vector).encode("hex")`,
 
 		block, err := aes.NewCipher(key)
 		if err != nil {
+			return nil, NewErrInvalidParam("key", err)
+		}
+		if err := checkAESVector(schemeStr, iv); err != nil {
 			return nil, err
 		}
 
@@ -328,8 +407,16 @@ root.encrypted = this.value.encrypt_aes("ctr", $key, $vector).encode("hex")`,
 				stream.CryptBlocks(ciphertext, b)
 				return string(ciphertext), nil
 			}
+		case "gcm":
+			aead, err := cipher.NewGCM(block)
+			if err != nil {
+				return nil, err
+			}
+			schemeFn = func(b []byte) (string, error) {
+				return string(aead.Seal(nil, iv, b, nil)), nil
+			}
 		default:
-			return nil, fmt.Errorf("unrecognized encryption type: %v", schemeStr)
+			return nil, NewErrInvalidParam("scheme", fmt.Errorf("unrecognized encryption type: %v", schemeStr))
 		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			var res string
@@ -354,7 +441,7 @@ var _ = registerSimpleMethod(
 		"decrypt_aes", "",
 	).InCategory(
 		MethodCategoryEncoding,
-		"Decrypts an encrypted string or byte array target according to a chosen AES encryption method and returns the result as a byte array. The algorithms require a key and an initialization vector / nonce. Available schemes are: `ctr`, `ofb`, `cbc`.",
+		"Decrypts an encrypted string or byte array target according to a chosen AES encryption method and returns the result as a byte array. The algorithms require a key and an initialization vector / nonce. Available schemes are: `ctr`, `ofb`, `cbc`, `gcm`.",
 		NewExampleSpec("",
 			`let key = "2b7e151628aed2a6abf7158809cf4f3c".decode("hex")
 let vector = "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff".decode("hex")
@@ -362,8 +449,15 @@ root.decrypted = this.value.decode("hex").decrypt_aes("ctr", $key, $vector).stri
 			`{"value":"84e9b31ff7400bdf80be7254"}`,
 			`{"decrypted":"hello world!"}`,
 		),
+		NewExampleSpec("The `gcm` scheme fails when the target has been tampered with or the key is wrong:",
+			`let key = "2b7e151628aed2a6abf7158809cf4f3c".decode("hex")
+let nonce = "000102030405060708090a0b".decode("hex")
+root.decrypted = this.value.decode("hex").decrypt_aes("gcm", $key, $nonce).string()`,
+			`{"value":"33aa573ad748f92d92b470a783022540c7580ffc2e410674a62e7587"}`,
+			`{"decrypted":"hello world!"}`,
+		),
 	).
-		Param(ParamString("scheme", "The scheme to use for decryption, one of `ctr`, `ofb`, `cbc`.")).
+		Param(ParamString("scheme", "The scheme to use for decryption, one of `ctr`, `ofb`, `cbc`, `gcm`.")).
 		Param(ParamString("key", "A key to decrypt with.")).
 		Param(ParamString("iv", "An initialization vector / nonce.")),
 	func(args *ParsedParams) (simpleMethod, error) {
@@ -385,6 +479,9 @@ root.decrypted = this.value.decode("hex").decrypt_aes("ctr", $key, $vector).stri
 
 		block, err := aes.NewCipher(key)
 		if err != nil {
+			return nil, NewErrInvalidParam("key", err)
+		}
+		if err := checkAESVector(schemeStr, iv); err != nil {
 			return nil, err
 		}
 
@@ -409,12 +506,25 @@ root.decrypted = this.value.decode("hex").decrypt_aes("ctr", $key, $vector).stri
 				if len(b)%aes.BlockSize != 0 {
 					return nil, fmt.Errorf("ciphertext is not a multiple of the block size")
 				}
+				plaintext := make([]byte, len(b))
 				stream := cipher.NewCBCDecrypter(block, iv)
-				stream.CryptBlocks(b, b)
-				return b, nil
+				stream.CryptBlocks(plaintext, b)
+				return plaintext, nil
+			}
+		case "gcm":
+			aead, err := cipher.NewGCM(block)
+			if err != nil {
+				return nil, err
+			}
+			schemeFn = func(b []byte) ([]byte, error) {
+				plaintext, err := aead.Open(nil, iv, b, nil)
+				if err != nil {
+					return nil, fmt.Errorf("failed to decrypt: %w", err)
+				}
+				return plaintext, nil
 			}
 		default:
-			return nil, fmt.Errorf("unrecognized decryption type: %v", schemeStr)
+			return nil, NewErrInvalidParam("scheme", fmt.Errorf("unrecognized decryption type: %v", schemeStr))
 		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			var res []byte
@@ -432,6 +542,24 @@ root.decrypted = this.value.decode("hex").decrypt_aes("ctr", $key, $vector).stri
 	},
 )
 
+// checkAESVector returns an error if an initialization vector / nonce is the
+// wrong size for an AES scheme, as the cipher modes panic otherwise.
+func checkAESVector(scheme string, iv []byte) error {
+	var expected int
+	switch scheme {
+	case "ctr", "ofb", "cbc":
+		expected = aes.BlockSize
+	case "gcm":
+		expected = 12
+	default:
+		return nil
+	}
+	if len(iv) != expected {
+		return NewErrInvalidParam("iv", fmt.Errorf("expected %v byte initialization vector for scheme %v, got %v bytes", expected, scheme, len(iv)))
+	}
+	return nil
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerSimpleMethod(
@@ -455,6 +583,42 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"render_markdown", "",
+	).InCategory(
+		MethodCategoryStrings,
+		"Renders a string of markdown as HTML. Since the markdown might contain raw HTML the result is sanitized such that only elements and attributes that are safe to display from user generated content remain, which removes scripts, styles and event handlers.",
+		NewExampleSpec("",
+			`root.html = this.body.render_markdown()`,
+			`{"body":"# Hello\n\nThis is **important**."}`,
+			`{"html":"<h1>Hello</h1>\n\n<p>This is <strong>important</strong>.</p>\n"}`,
+		),
+		NewExampleSpec("Raw HTML that isn't safe to display is removed.",
+			`root.html = this.body.render_markdown()`,
+			`{"body":"Click [here](https://example.com) <script>alert(1)</script>"}`,
+			`{"html":"<p>Click <a href=\"https://example.com\" rel=\"nofollow\">here</a> </p>\n"}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		p := bluemonday.UGCPolicy()
+		render := func(b []byte) []byte {
+			return p.SanitizeBytes(blackfriday.Run(b))
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			switch t := v.(type) {
+			case string:
+				return string(render([]byte(t))), nil
+			case []byte:
+				return render(t), nil
+			}
+			return nil, NewTypeError(v, ValueString)
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"index_of", "",
@@ -471,20 +635,36 @@ var _ = registerSimpleMethod(
 			`the cat meowed, the dog woofed`,
 			`{"index":8}`,
 		),
-	).Param(ParamString("value", "A string to search for.")),
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Returns the index of the first element of an array target matching the argument, or `-1` if the target doesn't contain the argument. Numerical comparisons are made irrespective of the representation type (float versus integer).",
+		NewExampleSpec("",
+			`root.index = this.things.index_of("bar")`,
+			`{"things":["foo","bar","baz"]}`,
+			`{"index":1}`,
+		),
+	).Param(ParamAny("value", "A value to search for.")),
 	func(args *ParsedParams) (simpleMethod, error) {
-		substring, err := args.FieldString("value")
+		value, err := args.Field("value")
 		if err != nil {
 			return nil, err
 		}
+		substring := IToString(value)
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			switch t := v.(type) {
 			case string:
 				return int64(strings.Index(t, substring)), nil
 			case []byte:
 				return int64(bytes.Index(t, []byte(substring))), nil
+			case []interface{}:
+				for i, e := range t {
+					if ICompare(e, value) {
+						return int64(i), nil
+					}
+				}
+				return int64(-1), nil
 			}
-			return nil, NewTypeError(v, ValueString)
+			return nil, NewTypeError(v, ValueString, ValueArray)
 		}, nil
 	},
 )
@@ -554,6 +734,162 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"parse_url", "",
+	).InCategory(
+		MethodCategoryParsing,
+		"Attempts to parse a URL from a string value, returning an object containing its `scheme`, `host`, `port`, `path`, `query` and `fragment`, as well as the `user` and `password` when they are set. The `port` field is only present when the URL specifies a port. Query parameters are decoded into an object where each key maps to a string, or to an array of strings when the parameter is repeated.",
+		NewExampleSpec("",
+			`root.url = this.url.parse_url()`,
+			`{"url":"https://example.com:8080/docs/guides?tag=foo&tag=bar&page=2#intro"}`,
+			`{"url":{"fragment":"intro","host":"example.com","path":"/docs/guides","port":8080,"query":{"page":"2","tag":["foo","bar"]},"scheme":"https"}}`,
+		),
+		NewExampleSpec("",
+			`root.page = this.url.parse_url().query.page.number()`,
+			`{"url":"/search?q=benthos%20docs&page=2"}`,
+			`{"page":2}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			u, err := url.Parse(s)
+			if err != nil {
+				return nil, err
+			}
+
+			query := map[string]interface{}{}
+			for k, vs := range u.Query() {
+				if len(vs) == 1 {
+					query[k] = vs[0]
+					continue
+				}
+				values := make([]interface{}, len(vs))
+				for i, v := range vs {
+					values[i] = v
+				}
+				query[k] = values
+			}
+
+			obj := map[string]interface{}{
+				"scheme":   u.Scheme,
+				"host":     u.Hostname(),
+				"path":     u.Path,
+				"query":    query,
+				"fragment": u.Fragment,
+			}
+			if portStr := u.Port(); portStr != "" {
+				port, err := strconv.ParseInt(portStr, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid port: %w", err)
+				}
+				obj["port"] = port
+			}
+			if u.User != nil {
+				obj["user"] = u.User.Username()
+				if password, set := u.User.Password(); set {
+					obj["password"] = password
+				}
+			}
+			return obj, nil
+		}), nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"build_url", "",
+	).InCategory(
+		MethodCategoryParsing,
+		"Builds a URL string from an object of the same structure as the result of the method [`parse_url`](#parse_url). All fields are optional, query parameters are encoded in alphabetical order and parameters with an array value are repeated for each element.",
+		NewExampleSpec("",
+			`root.url = this.build_url()`,
+			`{"scheme":"https","host":"example.com","port":8080,"path":"/docs/guides","query":{"tag":["foo","bar"],"page":2}}`,
+			`{"url":"https://example.com:8080/docs/guides?page=2&tag=foo&tag=bar"}`,
+		),
+		NewExampleSpec("",
+			`root.url = this.url.parse_url().assign({"query":{"page":"3"}}).build_url()`,
+			`{"url":"https://example.com/search?q=benthos&page=2"}`,
+			`{"url":"https://example.com/search?page=3&q=benthos"}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, NewTypeError(v, ValueObject)
+			}
+
+			getStr := func(k string) (string, error) {
+				v, exists := obj[k]
+				if !exists || v == nil {
+					return "", nil
+				}
+				s, ok := v.(string)
+				if !ok {
+					return "", fmt.Errorf("field %v: %w", k, NewTypeError(v, ValueString))
+				}
+				return s, nil
+			}
+
+			var u url.URL
+			var err error
+			if u.Scheme, err = getStr("scheme"); err != nil {
+				return nil, err
+			}
+			if u.Host, err = getStr("host"); err != nil {
+				return nil, err
+			}
+			if u.Path, err = getStr("path"); err != nil {
+				return nil, err
+			}
+			if u.Fragment, err = getStr("fragment"); err != nil {
+				return nil, err
+			}
+			if port, exists := obj["port"]; exists && port != nil {
+				u.Host += ":" + IToString(port)
+			}
+
+			user, err := getStr("user")
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := obj["password"]; exists {
+				password, err := getStr("password")
+				if err != nil {
+					return nil, err
+				}
+				u.User = url.UserPassword(user, password)
+			} else if user != "" {
+				u.User = url.User(user)
+			}
+
+			if q, exists := obj["query"]; exists && q != nil {
+				qObj, ok := q.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("field query: %w", NewTypeError(q, ValueObject))
+				}
+				values := url.Values{}
+				for k, qv := range qObj {
+					if arr, isArr := qv.([]interface{}); isArr {
+						for _, e := range arr {
+							values.Add(k, IToString(e))
+						}
+						continue
+					}
+					values.Add(k, IToString(qv))
+				}
+				u.RawQuery = values.Encode()
+			}
+			return u.String(), nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"filepath_join", "",
@@ -809,6 +1145,67 @@ root.h2 = this.value.hash("hmac_sha1","static-key").encode("hex")`,
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"hmac", "",
+	).InCategory(
+		MethodCategoryEncoding,
+		`
+Computes a keyed-hash message authentication code of a string or byte array according to a chosen hash algorithm and returns the result as a byte array. When mapping the result to a JSON field the value should be encoded using the method `+"[`encode`][methods.encode]"+`, otherwise it will be base64 encoded by default.
+
+Available algorithms are: `+"`md5`, `sha1`, `sha256`, `sha512`"+`.`,
+		NewExampleSpec("",
+			`root.signature = this.value.hmac("sha256", "static-key").encode("hex")`,
+			`{"value":"hello world"}`,
+			`{"signature":"b1cdce8b2add1f96135b2506f8ab748ae8ef15c49c0320357a6d168c42e20746"}`,
+		),
+		NewExampleSpec("Verifying the signature of a webhook, where the signature is provided as a hex encoded header:",
+			`root = this
+root.verified = "sha256=" + content().hmac("sha256", "static-key").encode("hex") == meta("signature")`,
+		),
+	).
+		Param(ParamString("algorithm", "The hash algorithm to use.")).
+		Param(ParamString("key", "The secret key to use.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		algorithmStr, err := args.FieldString("algorithm")
+		if err != nil {
+			return nil, err
+		}
+		key, err := args.FieldString("key")
+		if err != nil {
+			return nil, err
+		}
+		var hashCtor func() hash.Hash
+		switch algorithmStr {
+		case "md5":
+			hashCtor = md5.New
+		case "sha1":
+			hashCtor = sha1.New
+		case "sha256":
+			hashCtor = sha256.New
+		case "sha512":
+			hashCtor = sha512.New
+		default:
+			return nil, NewErrInvalidParam("algorithm", fmt.Errorf("unrecognized hmac hash type: %v", algorithmStr))
+		}
+		keyBytes := []byte(key)
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			hasher := hmac.New(hashCtor, keyBytes)
+			switch t := v.(type) {
+			case string:
+				_, _ = hasher.Write([]byte(t))
+			case []byte:
+				_, _ = hasher.Write(t)
+			default:
+				return nil, NewTypeError(v, ValueString)
+			}
+			return hasher.Sum(nil), nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"join", "",
@@ -919,17 +1316,45 @@ var _ = registerSimpleMethod(
 		"parse_csv", "",
 	).InCategory(
 		MethodCategoryParsing,
-		"Attempts to parse a string into an array of objects by following the CSV format described in RFC 4180. The first line is assumed to be a header row, which determines the keys of values in each object.",
+		"Attempts to parse a string into an array of objects by following the CSV format described in RFC 4180. By default the first line is assumed to be a header row, which determines the keys of values in each object.",
 		NewExampleSpec("",
 			`root.orders = this.orders.parse_csv()`,
 			`{"orders":"foo,bar\nfoo 1,bar 1\nfoo 2,bar 2"}`,
 			`{"orders":[{"bar":"bar 1","foo":"foo 1"},{"bar":"bar 2","foo":"foo 2"}]}`,
 		),
-	),
+		NewExampleSpec("Parsing CSV without a header row results in an array of arrays of strings:",
+			`root.orders = this.orders.parse_csv(parse_header_row: false, delimiter: ";")`,
+			`{"orders":"foo 1;bar 1\nfoo 2;bar 2"}`,
+			`{"orders":[["foo 1","bar 1"],["foo 2","bar 2"]]}`,
+		),
+	).
+		Param(ParamBool("parse_header_row", "Whether to reference the first row as a header row. If set to true the output structure for messages will be an object where field keys are determined by the header row. Otherwise, the output will be an array of row arrays.").Default(true)).
+		Param(ParamString("delimiter", "The delimiter to use for splitting values in each record. It must be a single character.").Default(",")),
 	parseCSVMethod,
 )
 
-func parseCSVMethod(*ParsedParams) (simpleMethod, error) {
+// csvDelimiterParam extracts a single character delimiter parameter.
+func csvDelimiterParam(args *ParsedParams) (rune, error) {
+	delimStr, err := args.FieldString("delimiter")
+	if err != nil {
+		return 0, err
+	}
+	delimRunes := []rune(delimStr)
+	if len(delimRunes) != 1 {
+		return 0, NewErrInvalidParam("delimiter", fmt.Errorf("delimiter value must be exactly one character, got %q", delimStr))
+	}
+	return delimRunes[0], nil
+}
+
+func parseCSVMethod(args *ParsedParams) (simpleMethod, error) {
+	parseHeaderRow, err := args.FieldBool("parse_header_row")
+	if err != nil {
+		return nil, err
+	}
+	delim, err := csvDelimiterParam(args)
+	if err != nil {
+		return nil, err
+	}
 	return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 		var csvBytes []byte
 		switch t := v.(type) {
@@ -942,10 +1367,24 @@ func parseCSVMethod(*ParsedParams) (simpleMethod, error) {
 		}
 
 		r := csv.NewReader(bytes.NewReader(csvBytes))
+		r.Comma = delim
 		strRecords, err := r.ReadAll()
 		if err != nil {
 			return nil, err
 		}
+
+		if !parseHeaderRow {
+			records := make([]interface{}, 0, len(strRecords))
+			for _, strRecord := range strRecords {
+				record := make([]interface{}, 0, len(strRecord))
+				for _, r := range strRecord {
+					record = append(record, r)
+				}
+				records = append(records, record)
+			}
+			return records, nil
+		}
+
 		if len(strRecords) == 0 {
 			return nil, errors.New("zero records were parsed")
 		}
@@ -970,6 +1409,98 @@ func parseCSVMethod(*ParsedParams) (simpleMethod, error) {
 	}, nil
 }
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"format_csv", "",
+	).InCategory(
+		MethodCategoryParsing,
+		"Serializes an array of objects into a CSV string following the format described in RFC 4180. A header row is written first, containing the keys of all objects in alphabetical order, and keys missing from an object result in an empty value. An array of arrays is serialized without a header row. Values that aren't strings are serialized as they would be with the method [`string`][methods.string].",
+		NewExampleSpec("",
+			`root.orders = this.orders.format_csv()`,
+			`{"orders":[{"foo":"foo 1","bar":"bar 1"},{"foo":"foo 2","bar":2}]}`,
+			`{"orders":"bar,foo\nbar 1,foo 1\n2,foo 2\n"}`,
+		),
+		NewExampleSpec("",
+			`root.orders = this.orders.format_csv(delimiter: ";")`,
+			`{"orders":[["foo 1","bar 1"],["foo 2","bar 2"]]}`,
+			`{"orders":"foo 1;bar 1\nfoo 2;bar 2\n"}`,
+		),
+	).
+		Param(ParamString("delimiter", "The delimiter to use for separating values in each record. It must be a single character.").Default(",")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		delim, err := csvDelimiterParam(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			rows, ok := v.([]interface{})
+			if !ok {
+				return nil, NewTypeError(v, ValueArray)
+			}
+
+			var buf bytes.Buffer
+			w := csv.NewWriter(&buf)
+			w.Comma = delim
+
+			var headers []string
+			var objRows bool
+			if len(rows) > 0 {
+				if _, objRows = rows[0].(map[string]interface{}); objRows {
+					seen := map[string]struct{}{}
+					for _, row := range rows {
+						obj, _ := row.(map[string]interface{})
+						for k := range obj {
+							if _, exists := seen[k]; !exists {
+								seen[k] = struct{}{}
+								headers = append(headers, k)
+							}
+						}
+					}
+					sort.Strings(headers)
+					if err := w.Write(headers); err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			for i, row := range rows {
+				var record []string
+				switch t := row.(type) {
+				case map[string]interface{}:
+					if !objRows {
+						return nil, fmt.Errorf("row %v: expected array value, got object", i)
+					}
+					record = make([]string, len(headers))
+					for j, k := range headers {
+						if v, exists := t[k]; exists {
+							record[j] = IToString(v)
+						}
+					}
+				case []interface{}:
+					if objRows {
+						return nil, fmt.Errorf("row %v: expected object value, got array", i)
+					}
+					record = make([]string, len(t))
+					for j, v := range t {
+						record[j] = IToString(v)
+					}
+				default:
+					return nil, fmt.Errorf("row %v: %w", i, NewTypeError(row, ValueObject, ValueArray))
+				}
+				if err := w.Write(record); err != nil {
+					return nil, err
+				}
+			}
+
+			w.Flush()
+			if err := w.Error(); err != nil {
+				return nil, err
+			}
+			return buf.String(), nil
+		}, nil
+	},
+)
+
 //------------------------------------------------------------------------------
 
 var _ = registerSimpleMethod(
@@ -1009,12 +1540,17 @@ var _ = registerSimpleMethod(
 		"parse_yaml", "",
 	).InCategory(
 		MethodCategoryParsing,
-		"Attempts to parse a string as a single YAML document and returns the result.",
+		"Attempts to parse a string as a YAML document and returns the result. When the string contains multiple YAML documents, separated by `---`, the result is an array of the documents.",
 		NewExampleSpec("",
 			`root.doc = this.doc.parse_yaml()`,
 			`{"doc":"foo: bar"}`,
 			`{"doc":{"foo":"bar"}}`,
 		),
+		NewExampleSpec("",
+			`root.docs = this.docs.parse_yaml()`,
+			`{"docs":"foo: bar\n---\nfoo: baz"}`,
+			`{"docs":[{"foo":"bar"},{"foo":"baz"}]}`,
+		),
 	),
 	func(*ParsedParams) (simpleMethod, error) {
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
@@ -1027,11 +1563,25 @@ var _ = registerSimpleMethod(
 			default:
 				return nil, NewTypeError(v, ValueString)
 			}
-			var sObj interface{}
-			if err := yaml.Unmarshal(yamlBytes, &sObj); err != nil {
-				return nil, fmt.Errorf("failed to parse value as YAML: %w", err)
+			var docs []interface{}
+			dec := yaml.NewDecoder(bytes.NewReader(yamlBytes))
+			for {
+				var sObj interface{}
+				if err := dec.Decode(&sObj); err != nil {
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					return nil, fmt.Errorf("failed to parse value as YAML: %w", err)
+				}
+				docs = append(docs, sObj)
+			}
+			switch len(docs) {
+			case 0:
+				return nil, nil
+			case 1:
+				return docs[0], nil
 			}
-			return sObj, nil
+			return docs, nil
 		}, nil
 	},
 )
@@ -1199,12 +1749,24 @@ var _ = registerSimpleMethod(
 			`{"doc":{"timestamp":"2020-Aug-14"}}`,
 			`{"doc":{"timestamp":"2020-08-14T00:00:00Z"}}`,
 		),
-	).Beta().Param(ParamString("format", "The format of the target string.")),
+		NewExampleSpec(
+			"An optional timezone can be specified, which is used when the format of the target string does not include one.",
+			`root.doc.timestamp = this.doc.timestamp.parse_timestamp("2006-Jan-02 15:04", "America/New_York")`,
+			`{"doc":{"timestamp":"2020-Aug-14 11:45"}}`,
+			`{"doc":{"timestamp":"2020-08-14T11:45:00-04:00"}}`,
+		),
+	).Beta().
+		Param(ParamString("format", "The format of the target string.")).
+		Param(ParamString("tz", "An optional timezone to use when the target string does not specify one, otherwise UTC is used.").Optional()),
 	func(args *ParsedParams) (simpleMethod, error) {
 		layout, err := args.FieldString("format")
 		if err != nil {
 			return nil, err
 		}
+		timezone, err := optionalTimezoneParam(args, time.UTC)
+		if err != nil {
+			return nil, err
+		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			var str string
 			switch t := v.(type) {
@@ -1215,7 +1777,7 @@ var _ = registerSimpleMethod(
 			default:
 				return nil, NewTypeError(v, ValueString)
 			}
-			ut, err := time.Parse(layout, str)
+			ut, err := time.ParseInLocation(layout, str, timezone)
 			if err != nil {
 				return nil, err
 			}
@@ -1238,12 +1800,24 @@ var _ = registerSimpleMethod(
 			`{"doc":{"timestamp":"2020-Aug-14"}}`,
 			`{"doc":{"timestamp":"2020-08-14T00:00:00Z"}}`,
 		),
-	).Beta().Param(ParamString("format", "The format of the target string.")),
+		NewExampleSpec(
+			"An optional timezone can be specified, which is used when the format of the target string does not include one.",
+			`root.doc.timestamp = this.doc.timestamp.parse_timestamp_strptime("%Y-%b-%d %H:%M", "Asia/Tokyo")`,
+			`{"doc":{"timestamp":"2020-Aug-14 11:45"}}`,
+			`{"doc":{"timestamp":"2020-08-14T11:45:00+09:00"}}`,
+		),
+	).Beta().
+		Param(ParamString("format", "The format of the target string.")).
+		Param(ParamString("tz", "An optional timezone to use when the target string does not specify one, otherwise UTC is used.").Optional()),
 	func(args *ParsedParams) (simpleMethod, error) {
 		layout, err := args.FieldString("format")
 		if err != nil {
 			return nil, err
 		}
+		timezone, err := optionalTimezoneParam(args, time.UTC)
+		if err != nil {
+			return nil, err
+		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			var str string
 			switch t := v.(type) {
@@ -1254,7 +1828,7 @@ var _ = registerSimpleMethod(
 			default:
 				return nil, NewTypeError(v, ValueString)
 			}
-			ut, err := timefmt.Parse(str, layout)
+			ut, err := timefmt.ParseInLocation(str, layout, timezone)
 			if err != nil {
 				return nil, err
 			}
@@ -1351,16 +1925,10 @@ var _ = registerSimpleMethod(
 		if err != nil {
 			return nil, err
 		}
-		var timezone *time.Location
-		tzOpt, err := args.FieldOptionalString("tz")
+		timezone, err := optionalTimezoneParam(args, nil)
 		if err != nil {
 			return nil, err
 		}
-		if tzOpt != nil {
-			if timezone, err = time.LoadLocation(*tzOpt); err != nil {
-				return nil, fmt.Errorf("failed to parse timezone location name: %w", err)
-			}
-		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			target, err := IGetTimestamp(v)
 			if err != nil {
@@ -1406,16 +1974,10 @@ var _ = registerSimpleMethod(
 		if err != nil {
 			return nil, err
 		}
-		var timezone *time.Location
-		tzOpt, err := args.FieldOptionalString("tz")
+		timezone, err := optionalTimezoneParam(args, nil)
 		if err != nil {
 			return nil, err
 		}
-		if tzOpt != nil {
-			if timezone, err = time.LoadLocation(*tzOpt); err != nil {
-				return nil, fmt.Errorf("failed to parse timezone location name: %w", err)
-			}
-		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			target, err := IGetTimestamp(v)
 			if err != nil {
@@ -1483,6 +2045,202 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"timestamp_add", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Adds a duration to a timestamp value and returns the result as a string following ISO 8601. Timestamp values can either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format. The duration can either be a string such as `1h30m`, following the format accepted by [`parse_duration`](#parse_duration), or an integer of nanoseconds.",
+		NewExampleSpec("",
+			`root.expires_at = this.created_at.timestamp_add("1h30m")`,
+			`{"created_at":"2020-08-14T11:45:26Z"}`,
+			`{"expires_at":"2020-08-14T13:15:26Z"}`,
+		),
+		NewExampleSpec("",
+			`root.expires_at = this.created_at.timestamp_add(this.ttl_ns)`,
+			`{"created_at":"2020-08-14T11:45:26Z","ttl_ns":5000000000}`,
+			`{"expires_at":"2020-08-14T11:45:31Z"}`,
+		),
+	).Beta().Param(ParamAny("duration", "A duration string or an integer of nanoseconds to add.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		return timestampShiftMethod(args, 1)
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"timestamp_sub", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Subtracts a duration from a timestamp value and returns the result as a string following ISO 8601. Timestamp values can either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format. The duration can either be a string such as `1h30m`, following the format accepted by [`parse_duration`](#parse_duration), or an integer of nanoseconds.",
+		NewExampleSpec("",
+			`root.window_start = this.created_at.timestamp_sub("15m")`,
+			`{"created_at":"2020-08-14T11:45:26Z"}`,
+			`{"window_start":"2020-08-14T11:30:26Z"}`,
+		),
+	).Beta().Param(ParamAny("duration", "A duration string or an integer of nanoseconds to subtract.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		return timestampShiftMethod(args, -1)
+	},
+)
+
+func timestampShiftMethod(args *ParsedParams, sign time.Duration) (simpleMethod, error) {
+	durationV, err := args.Field("duration")
+	if err != nil {
+		return nil, err
+	}
+	d, err := IGetDuration(durationV)
+	if err != nil {
+		return nil, NewErrInvalidParam("duration", err)
+	}
+	return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+		target, err := IGetTimestamp(v)
+		if err != nil {
+			return nil, err
+		}
+		return target.Add(sign * d).Format(time.RFC3339Nano), nil
+	}, nil
+}
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"timestamp_diff", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Returns the duration between a timestamp value and another as an integer of nanoseconds, which is negative when the argument is later. Timestamp values can either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format.",
+		NewExampleSpec("",
+			`root.took_ms = this.finished_at.timestamp_diff(this.started_at) / 1000000`,
+			`{"started_at":"2020-08-14T11:45:26Z","finished_at":"2020-08-14T11:45:27.5Z"}`,
+			`{"took_ms":1500}`,
+		),
+	).Beta().Param(ParamAny("timestamp", "The timestamp to subtract.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		otherV, err := args.Field("timestamp")
+		if err != nil {
+			return nil, err
+		}
+		other, err := IGetTimestamp(otherV)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			target, err := IGetTimestamp(v)
+			if err != nil {
+				return nil, err
+			}
+			return target.Sub(other).Nanoseconds(), nil
+		}, nil
+	},
+)
+
+func bucketDurationParam(args *ParsedParams) (time.Duration, error) {
+	durationV, err := args.Field("duration")
+	if err != nil {
+		return 0, err
+	}
+	d, err := IGetDuration(durationV)
+	if err != nil {
+		return 0, NewErrInvalidParam("duration", err)
+	}
+	if d <= 0 {
+		return 0, NewErrInvalidParam("duration", errors.New("must be greater than zero"))
+	}
+	return d, nil
+}
+
+// floorTimestamp returns the start of the bucket of size d that contains t,
+// where buckets are aligned to the unix epoch. The location of t is preserved.
+func floorTimestamp(t time.Time, d time.Duration) time.Time {
+	n := t.UnixNano()
+	rem := n % int64(d)
+	if rem < 0 {
+		rem += int64(d)
+	}
+	return time.Unix(0, n-rem).In(t.Location())
+}
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"floor_timestamp", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Rounds a timestamp value down to the start of the window of a given duration that contains it, and returns the result as a string following ISO 8601. Windows are aligned to the unix epoch, and therefore a duration of `5m` snaps timestamps to multiples of five minutes past the hour. This is useful for computing grouping keys for aggregations. Timestamp values can either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format. The duration can either be a string such as `5m`, following the format accepted by [`parse_duration`](#parse_duration), or an integer of nanoseconds.",
+		NewExampleSpec("",
+			`root.bucket = this.created_at.floor_timestamp("5m")`,
+			`{"created_at":"2020-08-14T11:48:26Z"}`,
+			`{"bucket":"2020-08-14T11:45:00Z"}`,
+		),
+		NewExampleSpec("Windows are aligned to the unix epoch rather than the timezone of the timestamp, and therefore windows of a day or longer start at midnight UTC.",
+			`root.day = this.created_at.floor_timestamp("24h")`,
+			`{"created_at":"2020-08-14T11:48:26+02:00"}`,
+			`{"day":"2020-08-14T02:00:00+02:00"}`,
+		),
+	).Beta().Param(ParamAny("duration", "A duration string or an integer of nanoseconds, which is the size of each window.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		d, err := bucketDurationParam(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			target, err := IGetTimestamp(v)
+			if err != nil {
+				return nil, err
+			}
+			return floorTimestamp(target, d).Format(time.RFC3339Nano), nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"window_bounds", "",
+	).InCategory(
+		MethodCategoryTime,
+		"Returns an object with the fields `start` and `end` describing the window of a given duration that contains a timestamp value, where the start is inclusive and the end is exclusive. Both are strings following ISO 8601. Windows are aligned to the unix epoch in the same way as [`floor_timestamp`](#floor_timestamp). Timestamp values can either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format. The duration can either be a string such as `1h`, following the format accepted by [`parse_duration`](#parse_duration), or an integer of nanoseconds.",
+		NewExampleSpec("",
+			`root.window = this.created_at.window_bounds("1h")`,
+			`{"created_at":"2020-08-14T11:45:26Z"}`,
+			`{"window":{"end":"2020-08-14T12:00:00Z","start":"2020-08-14T11:00:00Z"}}`,
+		),
+	).Beta().Param(ParamAny("duration", "A duration string or an integer of nanoseconds, which is the size of each window.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		d, err := bucketDurationParam(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			target, err := IGetTimestamp(v)
+			if err != nil {
+				return nil, err
+			}
+			start := floorTimestamp(target, d)
+			return map[string]interface{}{
+				"start": start.Format(time.RFC3339Nano),
+				"end":   start.Add(d).Format(time.RFC3339Nano),
+			}, nil
+		}, nil
+	},
+)
+
+// optionalTimezoneParam loads the location of an optional tz parameter, or
+// returns a default location when it is not set.
+func optionalTimezoneParam(args *ParsedParams, def *time.Location) (*time.Location, error) {
+	tzOpt, err := args.FieldOptionalString("tz")
+	if err != nil {
+		return nil, err
+	}
+	if tzOpt == nil {
+		return def, nil
+	}
+	timezone, err := time.LoadLocation(*tzOpt)
+	if err != nil {
+		return nil, NewErrInvalidParam("tz", fmt.Errorf("failed to parse timezone location name: %w", err))
+	}
+	return timezone, nil
+}
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"quote", "",
@@ -1661,7 +2419,7 @@ var _ = registerSimpleMethod(
 		}
 		re, err := regexp.Compile(reStr)
 		if err != nil {
-			return nil, err
+			return nil, NewErrInvalidParam("pattern", err)
 		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			var result []interface{}
@@ -1707,7 +2465,7 @@ var _ = registerSimpleMethod(
 		}
 		re, err := regexp.Compile(reStr)
 		if err != nil {
-			return nil, err
+			return nil, NewErrInvalidParam("pattern", err)
 		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			var result []interface{}
@@ -1766,7 +2524,7 @@ var _ = registerSimpleMethod(
 		}
 		re, err := regexp.Compile(reStr)
 		if err != nil {
-			return nil, err
+			return nil, NewErrInvalidParam("pattern", err)
 		}
 		groups := re.SubexpNames()
 		for i, k := range groups {
@@ -1823,7 +2581,7 @@ var _ = registerSimpleMethod(
 		}
 		re, err := regexp.Compile(reStr)
 		if err != nil {
-			return nil, err
+			return nil, NewErrInvalidParam("pattern", err)
 		}
 		groups := re.SubexpNames()
 		for i, k := range groups {
@@ -1866,6 +2624,62 @@ var _ = registerSimpleMethod(
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"re_capture_groups", "",
+	).InCategory(
+		MethodCategoryRegexp,
+		"Returns an object containing the named capture groups of the first match of a regular expression, where each key is the name of a group. Groups that did not participate in the match are empty strings, and an empty object is returned when the expression does not match at all. The pattern must contain at least one named capture group.",
+		NewExampleSpec("",
+			`root.date = this.value.re_capture_groups("(?P<year>\\d{4})-(?P<month>\\d{2})-(?P<day>\\d{2})")`,
+			`{"value":"published on 2021-10-28"}`,
+			`{"date":{"day":"28","month":"10","year":"2021"}}`,
+			`{"value":"never published"}`,
+			`{"date":{}}`,
+		),
+	).Param(ParamString("pattern", "The pattern to match against, which must contain named capture groups.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		reStr, err := args.FieldString("pattern")
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(reStr)
+		if err != nil {
+			return nil, NewErrInvalidParam("pattern", err)
+		}
+		groups := map[int]string{}
+		for i, k := range re.SubexpNames() {
+			if k != "" {
+				groups[i] = k
+			}
+		}
+		if len(groups) == 0 {
+			return nil, NewErrInvalidParam("pattern", fmt.Errorf("pattern `%v` does not contain any named capture groups", reStr))
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			var matches []string
+			switch t := v.(type) {
+			case string:
+				matches = re.FindStringSubmatch(t)
+			case []byte:
+				matches = re.FindStringSubmatch(string(t))
+			default:
+				return nil, NewTypeError(v, ValueString)
+			}
+			result := make(map[string]interface{}, len(groups))
+			if matches == nil {
+				return result, nil
+			}
+			for i, k := range groups {
+				result[k] = matches[i]
+			}
+			return result, nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"re_match", "",
@@ -1887,7 +2701,7 @@ var _ = registerSimpleMethod(
 		}
 		re, err := regexp.Compile(reStr)
 		if err != nil {
-			return nil, err
+			return nil, NewErrInvalidParam("pattern", err)
 		}
 		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
 			var result bool
@@ -1937,7 +2751,7 @@ func reReplaceAllImpl(args *ParsedParams) (simpleMethod, error) {
 	}
 	re, err := regexp.Compile(reStr)
 	if err != nil {
-		return nil, err
+		return nil, NewErrInvalidParam("pattern", err)
 	}
 	with, err := args.FieldString("value")
 	if err != nil {
@@ -606,7 +606,7 @@ var _ = registerSimpleMethod(
 		}
 		schema, err := jsonschema.NewSchema(jsonschema.NewStringLoader(schemaStr))
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse json schema definition: %w", err)
+			return nil, NewErrInvalidParam("schema", fmt.Errorf("failed to parse json schema definition: %w", err))
 		}
 		return func(res interface{}, ctx FunctionContext) (interface{}, error) {
 			result, err := schema.Validate(jsonschema.NewGoLoader(res))
@@ -699,11 +699,13 @@ var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"length", "",
 	).InCategory(
-		MethodCategoryStrings, "Returns the length of a string.",
+		MethodCategoryStrings, "Returns the length of a string in bytes. Characters outside of the ASCII range are encoded with multiple bytes and therefore count more than once. In order to count the user-perceived characters of a string use the method [`grapheme_count`](#grapheme_count).",
 		NewExampleSpec("",
 			`root.foo_len = this.foo.length()`,
 			`{"foo":"hello world"}`,
 			`{"foo_len":11}`,
+			`{"foo":"café"}`,
+			`{"foo_len":5}`,
 		),
 	).InCategory(
 		MethodCategoryObjectAndArray, "Returns the length of an array or object (number of keys).",
@@ -1562,3 +1564,56 @@ func mapWithout(m map[string]interface{}, paths [][]string) map[string]interface
 	}
 	return newMap
 }
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"zip", "",
+	).InCategory(
+		MethodCategoryObjectAndArray,
+		"Combines an array with any number of array arguments into a single array, where each element is an array containing the elements of each input array at the same index. The result has the length of the shortest input array.",
+		NewExampleSpec("",
+			`root.pairs = this.keys.zip(this.values)`,
+			`{"keys":["a","b","c"],"values":[1,2,3]}`,
+			`{"pairs":[["a",1],["b",2],["c",3]]}`,
+		),
+		NewExampleSpec("",
+			`root.points = this.xs.zip(this.ys, this.zs).map_each(p -> {"x":p.index(0),"y":p.index(1),"z":p.index(2)})`,
+			`{"xs":[1,2],"ys":[3,4],"zs":[5,6,7]}`,
+			`{"points":[{"x":1,"y":3,"z":5},{"x":2,"y":4,"z":6}]}`,
+		),
+	).VariadicParams(),
+	func(args *ParsedParams) (simpleMethod, error) {
+		var others [][]interface{}
+		for i, arg := range args.Raw() {
+			arr, ok := arg.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("argument %v: %w", i, NewTypeError(arg, ValueArray))
+			}
+			others = append(others, arr)
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, NewTypeError(v, ValueArray)
+			}
+			length := len(arr)
+			for _, other := range others {
+				if len(other) < length {
+					length = len(other)
+				}
+			}
+			zipped := make([]interface{}, length)
+			for i := 0; i < length; i++ {
+				elements := make([]interface{}, 0, len(others)+1)
+				elements = append(elements, arr[i])
+				for _, other := range others {
+					elements = append(elements, other[i])
+				}
+				zipped[i] = elements
+			}
+			return zipped, nil
+		}, nil
+	},
+)
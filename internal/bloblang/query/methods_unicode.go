@@ -0,0 +1,368 @@
+package query
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"normalize_nfc", "",
+	).InCategory(
+		MethodCategoryStrings,
+		"Returns a string in [Unicode Normalization Form C](https://unicode.org/reports/tr15/), where characters are canonically composed. This ensures that visually identical strings, such as one containing `é` and one containing `e` followed by a combining acute accent, are also equal byte for byte.",
+		NewExampleSpec("",
+			`root.same = this.a.normalize_nfc() == this.b.normalize_nfc()
+root.lengths = [ this.a.bytes().length(), this.a.normalize_nfc().bytes().length() ]`,
+			`{"a":"café","b":"café"}`,
+			`{"lengths":[6,5],"same":true}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			switch t := v.(type) {
+			case string:
+				return norm.NFC.String(t), nil
+			case []byte:
+				return norm.NFC.Bytes(t), nil
+			}
+			return nil, NewTypeError(v, ValueString)
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+// Decomposes characters, removes any combining marks and then composes what
+// remains.
+func stripAccents(s string) string {
+	res, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s)
+	if err != nil {
+		return s
+	}
+	return res
+}
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"strip_accents", "",
+	).InCategory(
+		MethodCategoryStrings,
+		"Removes diacritical marks, such as accents and cedillas, from the characters of a string. Characters that are not composed with a diacritical mark, including letters such as `ø` and `ß`, are unchanged. In order to reduce a string to ASCII characters use the method `transliterate` instead.",
+		NewExampleSpec("",
+			`root.name = this.name.strip_accents()`,
+			`{"name":"Crème Brûlée à São Paulo"}`,
+			`{"name":"Creme Brulee a Sao Paulo"}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			return stripAccents(s), nil
+		}), nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+var transliterations = map[rune]string{
+	// Latin
+	'Æ': "AE", 'æ': "ae", 'Ð': "D", 'ð': "d", 'Đ': "D", 'đ': "d",
+	'Ħ': "H", 'ħ': "h", 'ı': "i", 'Ł': "L", 'ł': "l", 'Ŋ': "NG", 'ŋ': "ng",
+	'Ø': "O", 'ø': "o", 'Œ': "OE", 'œ': "oe", 'ß': "ss", 'Þ': "TH", 'þ': "th",
+	'Ŧ': "T", 'ŧ': "t",
+
+	// Greek
+	'Α': "A", 'Β': "V", 'Γ': "G", 'Δ': "D", 'Ε': "E", 'Ζ': "Z", 'Η': "I",
+	'Θ': "TH", 'Ι': "I", 'Κ': "K", 'Λ': "L", 'Μ': "M", 'Ν': "N", 'Ξ': "X",
+	'Ο': "O", 'Π': "P", 'Ρ': "R", 'Σ': "S", 'Τ': "T", 'Υ': "Y", 'Φ': "F",
+	'Χ': "CH", 'Ψ': "PS", 'Ω': "O",
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+	'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+	'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+
+	// Cyrillic
+	'А': "A", 'Б': "B", 'В': "V", 'Г': "G", 'Ґ': "G", 'Д': "D", 'Е': "E",
+	'Є': "YE", 'Ж': "ZH", 'З': "Z", 'И': "I", 'І': "I", 'Ї': "YI", 'Й': "Y",
+	'К': "K", 'Л': "L", 'М': "M", 'Н': "N", 'О': "O", 'П': "P", 'Р': "R",
+	'С': "S", 'Т': "T", 'У': "U", 'Ф': "F", 'Х': "KH", 'Ц': "TS", 'Ч': "CH",
+	'Ш': "SH", 'Щ': "SHCH", 'Ъ': "", 'Ы': "Y", 'Ь': "", 'Э': "E", 'Ю': "YU",
+	'Я': "YA",
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e",
+	'є': "ye", 'ж': "zh", 'з': "z", 'и': "i", 'і': "i", 'ї': "yi", 'й': "y",
+	'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch",
+	'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+
+	// Punctuation and symbols
+	'‘': "'", '’': "'", '‚': "'", '‹': "'", '›': "'",
+	'“': "\"", '”': "\"", '„': "\"", '«': "\"", '»': "\"",
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '−': "-",
+	'•': "*", '×': "x", '÷': "/", '©': "(c)", '®': "(r)", '€': "EUR",
+	'£': "GBP", '¥': "JPY", '¿': "?", '¡': "!",
+}
+
+func transliterate(s string) string {
+	// Compatibility decomposition also reduces characters such as ligatures
+	// and full width forms to their plain equivalents.
+	s = norm.NFKD.String(s)
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			b.WriteRune(r)
+			continue
+		}
+		if t, exists := transliterations[r]; exists {
+			b.WriteString(t)
+		}
+	}
+	return b.String()
+}
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"transliterate", "",
+	).InCategory(
+		MethodCategoryStrings,
+		"Converts a string to ASCII by removing diacritical marks and replacing characters with ASCII approximations, which is useful for generating identifiers or search keys from multilingual text. Latin, Greek and Cyrillic letters as well as common punctuation are supported, and any other characters that have no ASCII approximation are removed.",
+		NewExampleSpec("",
+			`root.slug = this.title.transliterate().lowercase().re_replace_all("[^a-z0-9]+", "-")`,
+			`{"title":"Ærøskøbing Straße"}`,
+			`{"slug":"aeroskobing-strasse"}`,
+			`{"title":"Привет, мир"}`,
+			`{"slug":"privet-mir"}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			return transliterate(s), nil
+		}), nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"grapheme_count", "",
+	).InCategory(
+		MethodCategoryStrings,
+		"Returns the number of user-perceived characters (grapheme clusters) of a string, where characters composed of multiple code points such as accented letters, flags and emoji are counted once. This is useful for enforcing limits on the number of characters displayed, whereas the method `length` returns the number of bytes of a string.",
+		NewExampleSpec("",
+			`root.chars = this.value.grapheme_count()
+root.bytes = this.value.length()`,
+			`{"value":"naïve 🇬🇧"}`,
+			`{"bytes":15,"chars":7}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return stringMethod(func(s string) (interface{}, error) {
+			return graphemeCount(s), nil
+		}), nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"truncate", "",
+	).InCategory(
+		MethodCategoryStrings,
+		"Truncates a string to a maximum number of characters, where a character is a user-perceived character (grapheme cluster) rather than a byte or code point. This ensures that characters composed of multiple code points, such as accented letters, flags and emoji, are never split. Strings that are already within the limit are unchanged.",
+		NewExampleSpec("",
+			`root.preview = this.text.truncate(5)`,
+			`{"text":"hello world"}`,
+			`{"preview":"hello"}`,
+			`{"text":"👍🏽👍🏽👍🏽👍🏽👍🏽👍🏽"}`,
+			`{"preview":"👍🏽👍🏽👍🏽👍🏽👍🏽"}`,
+		),
+	).Param(ParamInt64("count", "The maximum number of characters to keep.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		count, err := args.FieldInt64("count")
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, NewErrInvalidParam("count", errors.New("must not be negative"))
+		}
+		return stringMethod(func(s string) (interface{}, error) {
+			i := 0
+			for n := int64(0); n < count && i < len(s); n++ {
+				i += graphemeLen(s[i:])
+			}
+			return s[:i], nil
+		}), nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
+func graphemeCount(s string) int64 {
+	var n int64
+	for i := 0; i < len(s); n++ {
+		i += graphemeLen(s[i:])
+	}
+	return n
+}
+
+type hangulKind int
+
+const (
+	hangulNone hangulKind = iota
+	hangulL
+	hangulV
+	hangulT
+	hangulLV
+	hangulLVT
+)
+
+func hangulKindOf(r rune) hangulKind {
+	switch {
+	case (r >= 0x1100 && r <= 0x115F) || (r >= 0xA960 && r <= 0xA97C):
+		return hangulL
+	case (r >= 0x1160 && r <= 0x11A7) || (r >= 0xD7B0 && r <= 0xD7C6):
+		return hangulV
+	case (r >= 0x11A8 && r <= 0x11FF) || (r >= 0xD7CB && r <= 0xD7FB):
+		return hangulT
+	case r >= 0xAC00 && r <= 0xD7A3:
+		if (r-0xAC00)%28 == 0 {
+			return hangulLV
+		}
+		return hangulLVT
+	}
+	return hangulNone
+}
+
+func hangulJoins(prev, next hangulKind) bool {
+	switch prev {
+	case hangulL:
+		return next == hangulL || next == hangulV || next == hangulLV || next == hangulLVT
+	case hangulV, hangulLV:
+		return next == hangulV || next == hangulT
+	case hangulT, hangulLVT:
+		return next == hangulT
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// Characters that never begin a grapheme cluster of their own and instead
+// extend the one before them.
+func isGraphemeExtend(r rune) bool {
+	switch {
+	case r == 0x200D: // Zero width joiner
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF: // Emoji skin tone modifiers
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // Tags, used by subdivision flags
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc)
+}
+
+var indicConsonants = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0915, Hi: 0x0939, Stride: 1}, // Devanagari
+		{Lo: 0x0958, Hi: 0x095F, Stride: 1},
+		{Lo: 0x0978, Hi: 0x097F, Stride: 1},
+		{Lo: 0x0995, Hi: 0x09B9, Stride: 1}, // Bengali
+		{Lo: 0x09DC, Hi: 0x09DF, Stride: 1},
+		{Lo: 0x09F0, Hi: 0x09F1, Stride: 1},
+		{Lo: 0x0A95, Hi: 0x0AB9, Stride: 1}, // Gujarati
+		{Lo: 0x0AF9, Hi: 0x0AF9, Stride: 1},
+		{Lo: 0x0B15, Hi: 0x0B39, Stride: 1}, // Oriya
+		{Lo: 0x0B5C, Hi: 0x0B5F, Stride: 1},
+		{Lo: 0x0B71, Hi: 0x0B71, Stride: 1},
+		{Lo: 0x0C15, Hi: 0x0C39, Stride: 1}, // Telugu
+		{Lo: 0x0C58, Hi: 0x0C5A, Stride: 1},
+		{Lo: 0x0D15, Hi: 0x0D3A, Stride: 1}, // Malayalam
+	},
+}
+
+// Consonants of the Indic scripts where a consonant followed by a virama
+// (linker) and another consonant forms a conjunct, which is a single grapheme
+// cluster.
+func isIndicConsonant(r rune) bool {
+	return unicode.Is(indicConsonants, r) && unicode.Is(unicode.Lo, r)
+}
+
+func isIndicLinker(r rune) bool {
+	switch r {
+	case 0x094D, 0x09CD, 0x0ACD, 0x0B4D, 0x0C4D, 0x0D4D:
+		return true
+	}
+	return false
+}
+
+type conjunctState int
+
+const (
+	conjunctNone conjunctState = iota
+	conjunctConsonant
+	conjunctLinked
+)
+
+// graphemeLen returns the number of bytes taken by the first grapheme cluster
+// of a string. This follows the extended grapheme cluster rules of
+// https://unicode.org/reports/tr29/ for the cases that are common in text
+// payloads, which are combining marks, emoji sequences, regional indicator
+// (flag) pairs, Hangul syllables, Indic conjuncts and CRLF.
+func graphemeLen(s string) int {
+	r, i := utf8.DecodeRuneInString(s)
+	if r == '\r' {
+		if i < len(s) && s[i] == '\n' {
+			return i + 1
+		}
+		return i
+	}
+	if r < 0x20 || r == 0x7f {
+		return i
+	}
+
+	prev := r
+	regionalPair := isRegionalIndicator(r)
+	conjunct := conjunctNone
+	if isIndicConsonant(r) {
+		conjunct = conjunctConsonant
+	}
+	for i < len(s) {
+		next, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case isGraphemeExtend(next):
+			if conjunct != conjunctNone {
+				if isIndicLinker(next) {
+					conjunct = conjunctLinked
+				} else if unicode.Is(unicode.Mc, next) {
+					conjunct = conjunctNone
+				}
+			}
+		case conjunct == conjunctLinked && isIndicConsonant(next):
+			conjunct = conjunctConsonant
+		case prev == 0x200D && unicode.Is(unicode.So, next):
+		case regionalPair && isRegionalIndicator(next):
+			regionalPair = false
+		case hangulJoins(hangulKindOf(prev), hangulKindOf(next)):
+		default:
+			return i
+		}
+		if next != 0x200D && !isRegionalIndicator(next) {
+			regionalPair = false
+		}
+		prev = next
+		i += size
+	}
+	return i
+}
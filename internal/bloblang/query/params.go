@@ -434,6 +434,20 @@ func (p *ParsedParams) dynamic() []Function {
 	return fns
 }
 
+// hasQueries returns true if any of the arguments are query functions, which
+// includes both dynamic arguments and query parameters.
+func (p *ParsedParams) hasQueries() bool {
+	if p == nil {
+		return false
+	}
+	for _, v := range p.values {
+		if _, isFn := v.(Function); isFn {
+			return true
+		}
+	}
+	return false
+}
+
 // ResolveDynamic attempts to execute all dynamic arguments with a given context
 // and populate a new parsed parameters set with the values, ready to be used in
 // a function or method.
@@ -483,6 +497,10 @@ func (p *ParsedParams) Index(i int) (interface{}, error) {
 
 // Field returns an argument value with a given name.
 func (p *ParsedParams) Field(n string) (interface{}, error) {
+	if p == nil {
+		// Constructors initialised without parameters have no values set.
+		return nil, nil
+	}
 	index, ok := p.source.nameToIndex[n]
 	if !ok {
 		return nil, fmt.Errorf("parameter %v not found", n)
@@ -3,6 +3,7 @@ package query_test
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -157,6 +158,41 @@ func TestMappings(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "json_schema lists all violations",
+			mapping: `root = this.json_schema("""{
+  "type":"object",
+  "properties":{
+    "foo":{"type":"string"},
+    "bar":{"type":"integer"}
+  },
+  "required":["foo","bar"]
+}""")`,
+			inputOutputs: [][2]string{
+				{
+					`{"foo":"a","bar":1}`,
+					`{"bar":1,"foo":"a"}`,
+				},
+				{
+					`{"foo":5}`,
+					"Error(\"failed assignment (line 1): field `this`: (root) bar is required\nfoo invalid type. expected: string, given: integer\")",
+				},
+			},
+		},
+		{
+			name:    "json_schema errors are recoverable",
+			mapping: `root = this.json_schema("""{"type":"object","required":["foo"]}""").catch({"invalid":true})`,
+			inputOutputs: [][2]string{
+				{
+					`{"foo":"a"}`,
+					`{"foo":"a"}`,
+				},
+				{
+					`{"bar":"a"}`,
+					`{"invalid":true}`,
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -182,3 +218,21 @@ func TestMappings(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONSchemaFromFile(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type":"object","required":["foo"]}`), 0o644))
+
+	m, err := bloblang.GlobalEnvironment().NewMapping(fmt.Sprintf(`root = this.json_schema(file(%q))`, schemaPath))
+	require.NoError(t, err)
+
+	// The schema is loaded and compiled when the mapping is parsed.
+	require.NoError(t, os.Remove(schemaPath))
+
+	p, err := m.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"foo":"bar"}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, string(p.Get()))
+
+	_, err = m.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"bar":"baz"}`)}))
+	require.EqualError(t, err, "failed assignment (line 1): field `this`: (root) foo is required")
+}
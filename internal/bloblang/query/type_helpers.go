@@ -55,7 +55,7 @@ func ITypeOf(i interface{}) ValueType {
 		return ValueString
 	case []byte:
 		return ValueBytes
-	case int, int64, uint64, float64, json.Number:
+	case int, int64, uint64, float64, json.Number, Decimal:
 		return ValueNumber
 	case bool:
 		return ValueBool
@@ -100,6 +100,8 @@ func IGetNumber(v interface{}) (float64, error) {
 		return t, nil
 	case json.Number:
 		return t.Float64()
+	case Decimal:
+		return t.Float64(), nil
 	}
 	return 0, NewTypeError(v, ValueNumber)
 }
@@ -121,6 +123,8 @@ func IGetFloat32(v interface{}) (float32, error) {
 	case json.Number:
 		v, e := t.Float64()
 		return float32(v), e
+	case Decimal:
+		return float32(t.Float64()), nil
 	}
 	return 0, NewTypeError(v, ValueNumber)
 }
@@ -146,6 +150,8 @@ func IGetInt(v interface{}) (int64, error) {
 			return int64(f), nil
 		}
 		return 0, err
+	case Decimal:
+		return int64(t.Float64()), nil
 	}
 	return 0, NewTypeError(v, ValueNumber)
 }
@@ -224,6 +230,22 @@ func IGetTimestamp(v interface{}) (time.Time, error) {
 	return time.Time{}, NewTypeError(v, ValueNumber, ValueString)
 }
 
+// IGetDuration takes a boxed value and attempts to extract a duration from it,
+// either by parsing a duration string or from an integer of nanoseconds.
+func IGetDuration(v interface{}) (time.Duration, error) {
+	switch t := v.(type) {
+	case []byte:
+		return time.ParseDuration(string(t))
+	case string:
+		return time.ParseDuration(t)
+	}
+	i, err := IGetInt(v)
+	if err != nil {
+		return 0, NewTypeError(v, ValueNumber, ValueString)
+	}
+	return time.Duration(i), nil
+}
+
 // IIsNull returns whether a bloblang type is null, this includes Delete and
 // Nothing types.
 func IIsNull(i interface{}) bool {
@@ -256,10 +278,10 @@ func restrictForComparison(v interface{}) interface{} {
 
 // ISanitize takes a boxed value of any type and attempts to convert it into one
 // of the following types: string, []byte, int64, uint64, float64, bool,
-// []interface{}, map[string]interface{}, Delete, Nothing.
+// []interface{}, map[string]interface{}, Delete, Nothing, Decimal.
 func ISanitize(i interface{}) interface{} {
 	switch t := i.(type) {
-	case string, []byte, int64, uint64, float64, bool, []interface{}, map[string]interface{}, Delete, Nothing:
+	case string, []byte, int64, uint64, float64, bool, []interface{}, map[string]interface{}, Delete, Nothing, Decimal:
 		return i
 	case json.RawMessage:
 		return []byte(t)
@@ -267,6 +289,9 @@ func ISanitize(i interface{}) interface{} {
 		if i, err := t.Int64(); err == nil {
 			return i
 		}
+		if u, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+			return u
+		}
 		if f, err := t.Float64(); err == nil {
 			return f
 		}
@@ -304,6 +329,8 @@ func IToBytes(i interface{}) []byte {
 		return strconv.AppendUint(nil, t, 10)
 	case float64:
 		return strconv.AppendFloat(nil, t, 'g', -1, 64)
+	case Decimal:
+		return []byte(t.String())
 	case bool:
 		if t {
 			return []byte("true")
@@ -332,6 +359,8 @@ func IToString(i interface{}) string {
 		return strconv.FormatFloat(t, 'g', -1, 64)
 	case json.Number:
 		return t.String()
+	case Decimal:
+		return t.String()
 	case bool:
 		if t {
 			return "true"
@@ -358,6 +387,8 @@ func IToNumber(v interface{}) (float64, error) {
 		return t, nil
 	case json.Number:
 		return t.Float64()
+	case Decimal:
+		return t.Float64(), nil
 	case []byte:
 		return strconv.ParseFloat(string(t), 64)
 	case string:
@@ -386,6 +417,8 @@ func IToInt(v interface{}) (int64, error) {
 		return int64(t), nil
 	case json.Number:
 		return t.Int64()
+	case Decimal:
+		return ratToInt64(t.r)
 	case []byte:
 		return strconv.ParseInt(string(t), 10, 64)
 	case string:
@@ -454,6 +487,10 @@ func ICompare(left, right interface{}) bool {
 	if left == nil && right == nil {
 		return true
 	}
+	if requiresExactArithmetic(ISanitize(left), ISanitize(right)) {
+		cmp, err := exactCompare(ISanitize(left), ISanitize(right))
+		return err == nil && cmp == 0
+	}
 	switch lhs := restrictForComparison(left).(type) {
 	case string:
 		rhs, err := IGetString(right)
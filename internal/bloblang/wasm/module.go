@@ -0,0 +1,217 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/wasmpool"
+)
+
+const (
+	// MemoryLimitPages is the maximum number of 64KiB pages of memory that an
+	// instance of a module can use.
+	MemoryLimitPages = 1024
+
+	// CallTimeout is the maximum time a single method call can take before it
+	// is aborted.
+	CallTimeout = time.Second
+)
+
+var methodNameRegexp = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+// Module is a compiled WASM module where each exported function with a
+// signature `(ptr i32, len i32) i64` can be executed as a Bloblang method.
+//
+// The target of the method is written to memory allocated by calling the
+// exported function `allocate(size i32) i32`, and the result is read from the
+// pointer and length packed into the upper and lower 32 bits of the returned
+// value respectively. If the module exports a function `deallocate(ptr i32)`
+// then it is called for both the input and the result once the call is
+// complete.
+//
+// Instances of the module are reused across calls but never shared between
+// concurrent calls. Instances that fail during a call are discarded.
+type Module struct {
+	name    string
+	runtime wazero.Runtime
+	methods []string
+	pool    *wasmpool.Pool
+}
+
+// NewModule compiles a WASM module from its bytes, the name is used in error
+// messages.
+//
+// Compiled code is released by the runtime once the module is garbage
+// collected, and therefore the module does not need to be closed.
+func NewModule(name string, b []byte) (*Module, error) {
+	ctx := context.Background()
+
+	r, err := wasmpool.NewRuntime(ctx, MemoryLimitPages)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err := r.CompileModule(ctx, b)
+	if err != nil {
+		_ = r.Close(ctx)
+		return nil, fmt.Errorf("failed to compile module %v: %w", name, err)
+	}
+
+	m := &Module{
+		name:    name,
+		runtime: r,
+	}
+	for fnName, def := range compiled.ExportedFunctions() {
+		if fnName == "allocate" || fnName == "deallocate" || !methodNameRegexp.MatchString(fnName) {
+			continue
+		}
+		if !sameTypes(def.ParamTypes(), api.ValueTypeI32, api.ValueTypeI32) ||
+			!sameTypes(def.ResultTypes(), api.ValueTypeI64) {
+			continue
+		}
+		m.methods = append(m.methods, fnName)
+	}
+	sort.Strings(m.methods)
+	if len(m.methods) == 0 {
+		_ = r.Close(ctx)
+		return nil, fmt.Errorf("module %v does not export any functions with the signature (i32, i32) i64", name)
+	}
+
+	m.pool = wasmpool.New(r, compiled, wazero.NewModuleConfig().WithName(""), func(inst *wasmpool.Instance) error {
+		if inst.Function("allocate") == nil {
+			return errors.New("does not export function 'allocate'")
+		}
+		if inst.Module.Memory() == nil {
+			return errors.New("does not export a memory")
+		}
+		return nil
+	})
+
+	// Instantiate a first instance in order to surface problems with the
+	// module at parse time rather than when the first method is executed.
+	inst, err := m.pool.Get(ctx)
+	if err != nil {
+		_ = r.Close(ctx)
+		return nil, fmt.Errorf("module %v %w", name, err)
+	}
+	m.pool.Put(inst)
+	return m, nil
+}
+
+func sameTypes(types []api.ValueType, exp ...api.ValueType) bool {
+	if len(types) != len(exp) {
+		return false
+	}
+	for i, t := range types {
+		if t != exp[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Methods returns the names of the exported functions that can be executed as
+// methods, in alphabetical order.
+func (m *Module) Methods() []string {
+	return m.methods
+}
+
+// Method returns a function that executes a method of the module with the
+// result of a target function.
+func (m *Module) Method(name string, target query.Function) query.Function {
+	return query.ClosureFunction("method "+name, func(ctx query.FunctionContext) (interface{}, error) {
+		v, err := target.Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res, err := m.call(name, query.IToBytes(v))
+		if err != nil {
+			return nil, query.ErrFrom(err, target)
+		}
+		return string(res), nil
+	}, target.QueryTargets)
+}
+
+func (m *Module) call(name string, input []byte) ([]byte, error) {
+	ctx, done := context.WithTimeout(context.Background(), CallTimeout)
+	defer done()
+
+	inst, err := m.pool.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("module %v %w", m.name, err)
+	}
+
+	res, err := callMethod(ctx, inst, name, input)
+	if err != nil {
+		// The state of an instance that failed mid-execution can't be trusted
+		// and so it is discarded.
+		m.pool.Discard(inst)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("execution exceeded the time limit of %v", CallTimeout)
+		}
+		return nil, err
+	}
+	m.pool.Put(inst)
+	return res, nil
+}
+
+//------------------------------------------------------------------------------
+
+func callMethod(ctx context.Context, inst *wasmpool.Instance, name string, input []byte) ([]byte, error) {
+	mem := inst.Module.Memory()
+
+	var inPtr uint32
+	if len(input) > 0 {
+		res, err := inst.Function("allocate").Call(ctx, uint64(len(input)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to allocate module memory: %w", err)
+		}
+		inPtr = uint32(res[0])
+		if !mem.Write(inPtr, input) {
+			return nil, fmt.Errorf("memory write out of range: %v bytes at %v", len(input), inPtr)
+		}
+	}
+
+	res, err := inst.Function(name).Call(ctx, uint64(inPtr), uint64(len(input)))
+	if err != nil {
+		return nil, err
+	}
+	outPtr, outLen := uint32(res[0]>>32), uint32(res[0])
+
+	var output []byte
+	if outLen > 0 {
+		b, ok := mem.Read(outPtr, outLen)
+		if !ok {
+			return nil, fmt.Errorf("memory read out of range: %v bytes at %v", outLen, outPtr)
+		}
+		// The returned slice is a view of the module memory, which is reused
+		// by later calls.
+		output = make([]byte, len(b))
+		copy(output, b)
+	}
+
+	if deallocate := inst.Function("deallocate"); deallocate != nil {
+		var ptrs []uint32
+		if len(input) > 0 {
+			ptrs = append(ptrs, inPtr)
+		}
+		// A function is allowed to return its input.
+		if outLen > 0 && (len(input) == 0 || outPtr != inPtr) {
+			ptrs = append(ptrs, outPtr)
+		}
+		for _, ptr := range ptrs {
+			if _, err := deallocate.Call(ctx, uint64(ptr)); err != nil {
+				return nil, fmt.Errorf("failed to deallocate module memory: %w", err)
+			}
+		}
+	}
+	return output, nil
+}
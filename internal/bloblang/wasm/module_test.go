@@ -0,0 +1,144 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+func leb128(v uint32) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		if v >>= 7; v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+func wasmStr(s string) []byte {
+	return append(leb128(uint32(len(s))), s...)
+}
+
+func wasmVec(items ...[]byte) []byte {
+	b := leb128(uint32(len(items)))
+	for _, item := range items {
+		b = append(b, item...)
+	}
+	return b
+}
+
+func wasmSection(id byte, contents []byte) []byte {
+	return append(append([]byte{id}, leb128(uint32(len(contents)))...), contents...)
+}
+
+func wasmBody(code ...byte) []byte {
+	body := append([]byte{0x00}, code...)
+	return append(leb128(uint32(len(body))), body...)
+}
+
+// testModule assembles a module with a bump allocator and the methods `echo`,
+// which returns its input, `spin`, which never returns, and `fail`, which
+// traps. The memory of the module starts with a number of pages.
+func testModule(memPages uint32) []byte {
+	const (
+		i32, i64 = 0x7f, 0x7e
+	)
+
+	funcType := func(params, results []byte) []byte {
+		b := append([]byte{0x60}, leb128(uint32(len(params)))...)
+		b = append(b, params...)
+		b = append(b, leb128(uint32(len(results)))...)
+		return append(b, results...)
+	}
+	export := func(name string, kind, idx byte) []byte {
+		return append(wasmStr(name), kind, idx)
+	}
+
+	var m []byte
+	m = append(m, 0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00)
+	m = append(m, wasmSection(1, wasmVec(
+		funcType([]byte{i32}, []byte{i32}),      // 0: allocate
+		funcType([]byte{i32}, nil),              // 1: deallocate
+		funcType([]byte{i32, i32}, []byte{i64}), // 2: methods
+	))...)
+	m = append(m, wasmSection(3, wasmVec(
+		[]byte{0}, []byte{1}, []byte{2}, []byte{2}, []byte{2},
+	))...)
+	m = append(m, wasmSection(5, wasmVec(
+		append([]byte{0x00}, leb128(memPages)...),
+	))...)
+	m = append(m, wasmSection(6, wasmVec(
+		[]byte{i32, 0x01, 0x41, 0x80, 0x08, 0x0b}, // mut i32 = 1024
+	))...)
+	m = append(m, wasmSection(7, wasmVec(
+		export("memory", 0x02, 0),
+		export("allocate", 0x00, 0),
+		export("deallocate", 0x00, 1),
+		export("echo", 0x00, 2),
+		export("spin", 0x00, 3),
+		export("fail", 0x00, 4),
+	))...)
+	m = append(m, wasmSection(10, wasmVec(
+		// global.get 0, global.get 0, local.get 0, i32.add, global.set 0
+		wasmBody(0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b),
+		wasmBody(0x0b),
+		// (i64(ptr) << 32) | i64(len)
+		wasmBody(0x20, 0x00, 0xad, 0x42, 0x20, 0x86, 0x20, 0x01, 0xad, 0x84, 0x0b),
+		// loop br 0 end unreachable
+		wasmBody(0x03, 0x40, 0x0c, 0x00, 0x0b, 0x00, 0x0b),
+		// unreachable
+		wasmBody(0x00, 0x0b),
+	))...)
+	return m
+}
+
+func TestModuleMethods(t *testing.T) {
+	mod, err := NewModule("test.wasm", testModule(1))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"echo", "fail", "spin"}, mod.Methods())
+
+	for _, input := range []interface{}{"hello world", []byte("bytes"), "", map[string]interface{}{"foo": "bar"}} {
+		res, err := mod.Method("echo", query.NewLiteralFunction("", input)).Exec(query.FunctionContext{})
+		require.NoError(t, err)
+		assert.Equal(t, string(query.IToBytes(input)), res)
+	}
+
+	_, err = mod.Method("fail", query.NewFieldFunction("foo")).Exec(query.FunctionContext{}.WithValue(map[string]interface{}{
+		"foo": "bar",
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field `this.foo`: ")
+	assert.Contains(t, err.Error(), "unreachable")
+
+	// Instances that fail are discarded and replaced.
+	res, err := mod.Method("echo", query.NewLiteralFunction("", "again")).Exec(query.FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, "again", res)
+}
+
+func TestModuleTimeout(t *testing.T) {
+	mod, err := NewModule("test.wasm", testModule(1))
+	require.NoError(t, err)
+
+	_, err = mod.Method("spin", query.NewLiteralFunction("", "foo")).Exec(query.FunctionContext{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "execution exceeded the time limit of 1s")
+}
+
+func TestModuleErrors(t *testing.T) {
+	_, err := NewModule("test.wasm", []byte("not a module"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compile module test.wasm")
+
+	_, err = NewModule("test.wasm", testModule(MemoryLimitPages+1))
+	require.Error(t, err)
+}
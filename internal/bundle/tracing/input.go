@@ -4,6 +4,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
@@ -59,6 +60,12 @@ func (t *tracedInput) Connected() bool {
 	return t.wrapped.Connected()
 }
 
+// ConnectionError returns the most recent connection error of the wrapped
+// component.
+func (t *tracedInput) ConnectionError() error {
+	return component.ConnectionErrorOf(t.wrapped)
+}
+
 func (t *tracedInput) CloseAsync() {
 	t.wrapped.CloseAsync()
 }
@@ -0,0 +1,443 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// Event types specific to message traces.
+var (
+	EventProcess EventType = "PROCESS"
+	EventAck     EventType = "ACK"
+)
+
+// MessageEvent describes a single step in the life of a traced message.
+type MessageEvent struct {
+	Time       time.Time `json:"time"`
+	Path       string    `json:"path"`
+	Type       EventType `json:"type"`
+	Mutated    bool      `json:"mutated,omitempty"`
+	Content    string    `json:"content,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationNs int64     `json:"duration_ns,omitempty"`
+}
+
+// MessageTrace is the event log of a single traced message.
+type MessageTrace struct {
+	ID        uint64         `json:"id"`
+	Started   time.Time      `json:"started"`
+	Finished  time.Time      `json:"finished"`
+	Delivered bool           `json:"delivered"`
+	Error     string         `json:"error,omitempty"`
+	Events    []MessageEvent `json:"events"`
+}
+
+type messageTrace struct {
+	mut   sync.Mutex
+	trace MessageTrace
+}
+
+func (t *messageTrace) add(e MessageEvent) {
+	t.mut.Lock()
+	t.trace.Events = append(t.trace.Events, e)
+	t.mut.Unlock()
+}
+
+func (t *messageTrace) finish(err error) MessageTrace {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.trace.Finished = time.Now()
+	t.trace.Delivered = err == nil
+	if err != nil {
+		t.trace.Error = err.Error()
+	}
+
+	traceCopy := t.trace
+	traceCopy.Events = make([]MessageEvent, len(t.trace.Events))
+	copy(traceCopy.Events, t.trace.Events)
+	return traceCopy
+}
+
+type messageTraceKeyType int
+
+const messageTraceKey messageTraceKeyType = iota
+
+func getMessageTrace(p *message.Part) *messageTrace {
+	t, _ := p.GetContext().Value(messageTraceKey).(*messageTrace)
+	return t
+}
+
+//------------------------------------------------------------------------------
+
+// MessageTraces stores the most recently completed message traces.
+type MessageTraces struct {
+	nextID uint64
+
+	mut    sync.Mutex
+	traces []MessageTrace
+	next   int
+}
+
+func newMessageTraces(maxTraces int) *MessageTraces {
+	if maxTraces <= 0 {
+		maxTraces = 1
+	}
+	return &MessageTraces{
+		traces: make([]MessageTrace, 0, maxTraces),
+	}
+}
+
+func (m *MessageTraces) add(t MessageTrace) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if len(m.traces) < cap(m.traces) {
+		m.traces = append(m.traces, t)
+	} else {
+		m.traces[m.next] = t
+	}
+	m.next = (m.next + 1) % cap(m.traces)
+}
+
+// Traces returns the most recently completed message traces, ordered from
+// oldest to newest.
+func (m *MessageTraces) Traces() []MessageTrace {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	traces := make([]MessageTrace, 0, len(m.traces))
+	if len(m.traces) < cap(m.traces) {
+		return append(traces, m.traces...)
+	}
+	traces = append(traces, m.traces[m.next:]...)
+	return append(traces, m.traces[:m.next]...)
+}
+
+// HandlerFunc returns an HTTP handler where a GET request returns the most
+// recently completed message traces as JSON and a DELETE request removes them.
+func (m *MessageTraces) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodDelete:
+			m.mut.Lock()
+			m.traces = m.traces[:0]
+			m.next = 0
+			m.mut.Unlock()
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		resBytes, err := json.Marshal(m.Traces())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MessageTracedBundle modifies a provided bundle environment so that a sample
+// of messages consumed by the input of a stream have an event log attached,
+// which is added to by each processor that touches the message. Traces are
+// completed once the message is acknowledged and are added to the returned
+// store.
+func MessageTracedBundle(b *bundle.Environment, conf MessageTracingConfig) (*bundle.Environment, *MessageTraces) {
+	traces := newMessageTraces(conf.MaxTraces)
+	tracedEnv := b.Clone()
+
+	for _, spec := range b.InputDocs() {
+		_ = tracedEnv.InputAdd(func(iConf input.Config, nm bundle.NewManagement, pcf ...processor.PipelineConstructorFunc) (input.Streamed, error) {
+			i, err := b.InputInit(iConf, nm, pcf...)
+			if err != nil {
+				return nil, err
+			}
+			// Only the root input of a stream begins traces.
+			if path := nm.Path(); len(path) != 1 || path[0] != "input" {
+				return i, nil
+			}
+			return traceMessagesInput(conf, traces, nm, i), nil
+		}, spec)
+	}
+
+	for _, spec := range b.ProcessorDocs() {
+		_ = tracedEnv.ProcessorAdd(func(pConf processor.Config, nm bundle.NewManagement) (processor.V1, error) {
+			p, err := b.ProcessorInit(pConf, nm)
+			if err != nil {
+				return nil, err
+			}
+			return &messageTracedProcessor{
+				path:           "root." + query.SliceToDotPath(nm.Path()...),
+				includeContent: conf.IncludeContent,
+				wrapped:        p,
+			}, nil
+		}, spec)
+	}
+
+	return tracedEnv, traces
+}
+
+//------------------------------------------------------------------------------
+
+type messageTracedInput struct {
+	conf    MessageTracingConfig
+	traces  *MessageTraces
+	mgr     bundle.NewManagement
+	path    string
+	wrapped input.Streamed
+	tChan   chan message.Transaction
+	shutSig *shutdown.Signaller
+}
+
+func traceMessagesInput(conf MessageTracingConfig, traces *MessageTraces, mgr bundle.NewManagement, i input.Streamed) input.Streamed {
+	t := &messageTracedInput{
+		conf:    conf,
+		traces:  traces,
+		mgr:     mgr,
+		path:    "root." + query.SliceToDotPath(mgr.Path()...),
+		wrapped: i,
+		tChan:   make(chan message.Transaction),
+		shutSig: shutdown.NewSignaller(),
+	}
+	go t.loop()
+	return t
+}
+
+func (t *messageTracedInput) sample(tran message.Transaction) message.Transaction {
+	var started []*messageTrace
+	var parts []*message.Part
+	_ = tran.Payload.Iter(func(i int, p *message.Part) error {
+		if rand.Float64() >= t.conf.SamplingRatio {
+			if parts != nil {
+				parts = append(parts, p)
+			}
+			return nil
+		}
+		if parts == nil {
+			parts = make([]*message.Part, 0, tran.Payload.Len())
+			for j := 0; j < i; j++ {
+				parts = append(parts, tran.Payload.Get(j))
+			}
+		}
+		mt := &messageTrace{trace: MessageTrace{
+			ID:      atomic.AddUint64(&t.traces.nextID, 1),
+			Started: time.Now(),
+		}}
+		e := MessageEvent{Time: mt.trace.Started, Path: t.path, Type: EventConsume}
+		if t.conf.IncludeContent {
+			e.Content = string(p.Get())
+		}
+		mt.add(e)
+		started = append(started, mt)
+		parts = append(parts, p.WithContext(context.WithValue(p.GetContext(), messageTraceKey, mt)))
+		return nil
+	})
+	if len(started) == 0 {
+		return tran
+	}
+
+	tran.Payload.SetAll(parts)
+	return message.NewTransactionFunc(tran.Payload, func(ctx context.Context, err error) error {
+		for _, mt := range started {
+			ackEvent := MessageEvent{Time: time.Now(), Path: t.path, Type: EventAck}
+			if err != nil {
+				ackEvent.Error = err.Error()
+			}
+			mt.add(ackEvent)
+			t.complete(mt.finish(err))
+		}
+		return tran.Ack(ctx, err)
+	})
+}
+
+func (t *messageTracedInput) complete(mt MessageTrace) {
+	t.traces.add(mt)
+	if t.conf.Output == "" {
+		return
+	}
+
+	traceBytes, err := json.Marshal(mt)
+	if err != nil {
+		return
+	}
+	go func() {
+		ctx, done := t.shutSig.CloseNowCtx(context.Background())
+		defer done()
+
+		var writeErr error
+		if err := t.mgr.AccessOutput(ctx, t.conf.Output, func(o output.Sync) {
+			writeErr = o.WriteTransaction(ctx, message.NewTransactionFunc(
+				message.QuickBatch([][]byte{traceBytes}),
+				func(context.Context, error) error { return nil },
+			))
+		}); err != nil {
+			writeErr = err
+		}
+		if writeErr != nil {
+			t.mgr.Logger().Errorf("Failed to send message trace to output resource '%v': %v\n", t.conf.Output, writeErr)
+		}
+	}()
+}
+
+func (t *messageTracedInput) loop() {
+	defer close(t.tChan)
+	readChan := t.wrapped.TransactionChan()
+	for {
+		tran, open := <-readChan
+		if !open {
+			return
+		}
+		select {
+		case t.tChan <- t.sample(tran):
+		case <-t.shutSig.CloseNowChan():
+			// Stop flushing if we fully timed out
+			return
+		}
+	}
+}
+
+func (t *messageTracedInput) TransactionChan() <-chan message.Transaction {
+	return t.tChan
+}
+
+func (t *messageTracedInput) Connected() bool {
+	return t.wrapped.Connected()
+}
+
+// ConnectionError returns the most recent connection error of the wrapped
+// component.
+func (t *messageTracedInput) ConnectionError() error {
+	return component.ConnectionErrorOf(t.wrapped)
+}
+
+func (t *messageTracedInput) CloseAsync() {
+	t.wrapped.CloseAsync()
+}
+
+func (t *messageTracedInput) WaitForClose(timeout time.Duration) error {
+	err := t.wrapped.WaitForClose(timeout)
+	t.shutSig.CloseNow()
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+type traceSnapshot struct {
+	content []byte
+	err     string
+}
+
+type messageTracedProcessor struct {
+	path           string
+	includeContent bool
+	wrapped        processor.V1
+}
+
+func (t *messageTracedProcessor) ProcessMessage(m *message.Batch) ([]*message.Batch, error) {
+	var before map[*messageTrace]traceSnapshot
+	_ = m.Iter(func(i int, p *message.Part) error {
+		mt := getMessageTrace(p)
+		if mt == nil {
+			return nil
+		}
+		if before == nil {
+			before = map[*messageTrace]traceSnapshot{}
+		}
+		snap := traceSnapshot{content: append([]byte(nil), p.Get()...)}
+		if err := p.ErrorGet(); err != nil {
+			snap.err = err.Error()
+		}
+		before[mt] = snap
+		return nil
+	})
+	if before == nil {
+		return t.wrapped.ProcessMessage(m)
+	}
+
+	started := time.Now()
+	outMsgs, res := t.wrapped.ProcessMessage(m)
+	took := time.Since(started)
+
+	seen := map[*messageTrace]struct{}{}
+	for _, outMsg := range outMsgs {
+		_ = outMsg.Iter(func(i int, p *message.Part) error {
+			mt := getMessageTrace(p)
+			snap, exists := before[mt]
+			if mt == nil || !exists {
+				return nil
+			}
+			seen[mt] = struct{}{}
+
+			e := MessageEvent{
+				Time:       started,
+				Path:       t.path,
+				Type:       EventProcess,
+				DurationNs: took.Nanoseconds(),
+			}
+			if !bytes.Equal(snap.content, p.Get()) {
+				e.Mutated = true
+				if t.includeContent {
+					e.Content = string(p.Get())
+				}
+			}
+			if err := p.ErrorGet(); err != nil && err.Error() != snap.err {
+				e.Type = EventError
+				e.Error = err.Error()
+			}
+			mt.add(e)
+			return nil
+		})
+	}
+
+	for mt := range before {
+		if _, exists := seen[mt]; exists {
+			continue
+		}
+		e := MessageEvent{
+			Time:       started,
+			Path:       t.path,
+			Type:       EventDelete,
+			DurationNs: took.Nanoseconds(),
+		}
+		if res != nil {
+			e.Type = EventError
+			e.Error = res.Error()
+		}
+		mt.add(e)
+	}
+	return outMsgs, res
+}
+
+func (t *messageTracedProcessor) CloseAsync() {
+	t.wrapped.CloseAsync()
+}
+
+func (t *messageTracedProcessor) WaitForClose(timeout time.Duration) error {
+	return t.wrapped.WaitForClose(timeout)
+}
+
+// Unwrap returns the traced processor, which allows components such as the
+// workflow processor to access the underlying implementation of resources.
+func (t *messageTracedProcessor) Unwrap() processor.V1 {
+	return t.wrapped
+}
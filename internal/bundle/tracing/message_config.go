@@ -0,0 +1,39 @@
+package tracing
+
+import "github.com/benthosdev/benthos/v4/internal/docs"
+
+// MessageTracingConfig contains configuration fields for per-message event
+// tracing.
+type MessageTracingConfig struct {
+	Enabled        bool    `json:"enabled" yaml:"enabled"`
+	SamplingRatio  float64 `json:"sampling_ratio" yaml:"sampling_ratio"`
+	MaxTraces      int     `json:"max_traces" yaml:"max_traces"`
+	IncludeContent bool    `json:"include_content" yaml:"include_content"`
+	Output         string  `json:"output" yaml:"output"`
+}
+
+// NewMessageTracingConfig creates a new message tracing config with default
+// values.
+func NewMessageTracingConfig() MessageTracingConfig {
+	return MessageTracingConfig{
+		Enabled:        false,
+		SamplingRatio:  0.001,
+		MaxTraces:      100,
+		IncludeContent: false,
+		Output:         "",
+	}
+}
+
+// MessageTracingSpec returns a field spec for the message tracing
+// configuration fields.
+func MessageTracingSpec() docs.FieldSpec {
+	return docs.FieldObject(
+		"message_tracing", "Attach an event log to a sample of the messages consumed by the input of a pipeline, describing each processor that touched them, whether they were mutated, any errors flagged and the time taken. Completed traces are available via the HTTP endpoint `/debug/message_traces` and can also be sent to an output resource.",
+	).WithChildren(
+		docs.FieldBool("enabled", "Whether message tracing is enabled.").HasDefault(false),
+		docs.FieldFloat("sampling_ratio", "The ratio of messages to trace, where `1` traces every message.").HasDefault(0.001),
+		docs.FieldInt("max_traces", "The maximum number of completed traces to keep in memory for the HTTP endpoint.").HasDefault(100),
+		docs.FieldBool("include_content", "Whether to include the contents of a message within each event that mutates it. This can be useful for answering questions such as where a field went missing, but may expose sensitive data.").HasDefault(false),
+		docs.FieldString("output", "An optional label of an [output resource](/docs/configuration/resources) to send completed traces to as JSON documents.").HasDefault(""),
+	).Advanced()
+}
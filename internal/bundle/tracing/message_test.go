@@ -0,0 +1,157 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/tracing"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundleMessageTracing(t *testing.T) {
+	conf := tracing.NewMessageTracingConfig()
+	conf.Enabled = true
+	conf.SamplingRatio = 1
+	conf.IncludeContent = true
+
+	tenv, traces := tracing.MessageTracedBundle(bundle.GlobalEnvironment, conf)
+
+	inConfig := input.NewConfig()
+	inConfig.Type = "generate"
+	inConfig.Generate.Count = 2
+	inConfig.Generate.Interval = "1us"
+	inConfig.Generate.Mapping = `root.id = count("message tracing test")`
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		mock.NewManager(),
+		log.Noop(),
+		metrics.Noop(),
+		manager.OptSetEnvironment(tenv),
+	)
+	require.NoError(t, err)
+
+	in, err := mgr.IntoPath("input").NewInput(inConfig)
+	require.NoError(t, err)
+
+	procs := map[string]string{
+		"0": `root = this.merge({"foo":"bar"})`,
+		"1": `root = this`,
+		"2": `root = if this.id == 2 { throw("nope") }`,
+	}
+	var pipeline []processor.V1
+	for _, k := range []string{"0", "1", "2"} {
+		pConf := processor.NewConfig()
+		pConf.Type = "bloblang"
+		pConf.Bloblang = procs[k]
+		p, err := mgr.IntoPath("pipeline", "processors", k).NewProcessor(pConf)
+		require.NoError(t, err)
+		pipeline = append(pipeline, p)
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case tran := <-in.TransactionChan():
+			batches, res := processor.ExecuteAll(pipeline, tran.Payload)
+			require.NoError(t, res)
+			require.Len(t, batches, 1)
+
+			var ackErr error
+			if i == 1 {
+				ackErr = errors.New("failed to deliver")
+			}
+			require.NoError(t, tran.Ack(ctx, ackErr))
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+	}
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second))
+
+	completed := traces.Traces()
+	require.Len(t, completed, 2)
+
+	first := completed[0]
+	assert.True(t, first.Delivered)
+	require.Len(t, first.Events, 5)
+
+	assert.Equal(t, tracing.EventConsume, first.Events[0].Type)
+	assert.Equal(t, "root.input", first.Events[0].Path)
+	assert.Equal(t, `{"id":1}`, first.Events[0].Content)
+
+	assert.Equal(t, tracing.EventProcess, first.Events[1].Type)
+	assert.Equal(t, "root.pipeline.processors.0", first.Events[1].Path)
+	assert.True(t, first.Events[1].Mutated)
+	assert.Equal(t, `{"foo":"bar","id":1}`, first.Events[1].Content)
+
+	assert.Equal(t, tracing.EventProcess, first.Events[2].Type)
+	assert.Equal(t, "root.pipeline.processors.1", first.Events[2].Path)
+	assert.False(t, first.Events[2].Mutated)
+	assert.Empty(t, first.Events[2].Content)
+
+	assert.Equal(t, tracing.EventProcess, first.Events[3].Type)
+	assert.Equal(t, tracing.EventAck, first.Events[4].Type)
+
+	second := completed[1]
+	assert.False(t, second.Delivered)
+	assert.Equal(t, "failed to deliver", second.Error)
+	require.Len(t, second.Events, 5)
+	assert.Equal(t, tracing.EventError, second.Events[3].Type)
+	assert.Equal(t, "root.pipeline.processors.2", second.Events[3].Path)
+	assert.Contains(t, second.Events[3].Error, "nope")
+}
+
+func TestBundleMessageTracingWorkflowBranchResources(t *testing.T) {
+	conf := tracing.NewMessageTracingConfig()
+	conf.Enabled = true
+	conf.SamplingRatio = 1
+
+	tenv, _ := tracing.MessageTracedBundle(bundle.GlobalEnvironment, conf)
+
+	blobConf := processor.NewConfig()
+	blobConf.Type = "bloblang"
+	blobConf.Bloblang = `root = this.id + 1`
+
+	branchConf := processor.NewConfig()
+	branchConf.Label = "foo"
+	branchConf.Type = "branch"
+	branchConf.Branch.RequestMap = `root = this`
+	branchConf.Branch.Processors = append(branchConf.Branch.Processors, blobConf)
+	branchConf.Branch.ResultMap = `root.result = this`
+
+	resConf := manager.NewResourceConfig()
+	resConf.ResourceProcessors = append(resConf.ResourceProcessors, branchConf)
+
+	mgr, err := manager.New(resConf, mock.NewManager(), log.Noop(), metrics.Noop(), manager.OptSetEnvironment(tenv))
+	require.NoError(t, err)
+
+	wConf := processor.NewConfig()
+	wConf.Type = "workflow"
+	wConf.Workflow.BranchResources = []string{"foo"}
+
+	proc, err := mgr.IntoPath("pipeline", "processors", "0").NewProcessor(wConf)
+	require.NoError(t, err)
+
+	batches, res := proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(`{"id":1}`)}))
+	require.NoError(t, res)
+	require.Len(t, batches, 1)
+	require.Equal(t, 1, batches[0].Len())
+	assert.NoError(t, batches[0].Get(0).ErrorGet())
+	assert.Equal(t, `{"id":1,"meta":{"workflow":{"succeeded":["foo"]}},"result":2}`, string(batches[0].Get(0).Get()))
+}
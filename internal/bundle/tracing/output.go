@@ -4,6 +4,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
@@ -58,6 +59,12 @@ func (t *tracedOutput) Connected() bool {
 	return t.wrapped.Connected()
 }
 
+// ConnectionError returns the most recent connection error of the wrapped
+// component.
+func (t *tracedOutput) ConnectionError() error {
+	return component.ConnectionErrorOf(t.wrapped)
+}
+
 func (t *tracedOutput) CloseAsync() {
 	t.wrapped.CloseAsync()
 }
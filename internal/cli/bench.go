@@ -0,0 +1,528 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	imetrics "github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/pipeline"
+)
+
+const defaultBenchMapping = `root = {"id": uuid_v4(), "timestamp": now(), "value": random_int()}`
+
+// benchLatencySamples is the maximum number of latency samples kept for
+// calculating percentiles, beyond which samples are replaced at random.
+const benchLatencySamples = 100000
+
+//------------------------------------------------------------------------------
+
+// allocSamplePool provides metric samples for reading heap allocations without
+// allocating within the measured section of a processor call.
+var allocSamplePool = sync.Pool{
+	New: func() interface{} {
+		return []metrics.Sample{
+			{Name: "/gc/heap/allocs:bytes"},
+			{Name: "/gc/heap/allocs:objects"},
+		}
+	},
+}
+
+func readHeapAllocs(samples []metrics.Sample) (bytes, objects uint64) {
+	metrics.Read(samples)
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		bytes = samples[0].Value.Uint64()
+	}
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		objects = samples[1].Value.Uint64()
+	}
+	return
+}
+
+// benchProcessor wraps a processor in order to record the time spent and
+// memory allocated during each call. Allocations are read from process-wide
+// runtime metrics and therefore include those of concurrent goroutines.
+type benchProcessor struct {
+	processor.V1
+
+	path    string
+	typeStr string
+
+	calls        int64
+	partsIn      int64
+	partsOut     int64
+	failed       int64
+	nanos        int64
+	allocBytes   uint64
+	allocObjects uint64
+}
+
+func (b *benchProcessor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
+	samples := allocSamplePool.Get().([]metrics.Sample)
+	defer allocSamplePool.Put(samples)
+
+	beforeBytes, beforeObjects := readHeapAllocs(samples)
+	start := time.Now()
+
+	res, err := b.V1.ProcessMessage(msg)
+
+	elapsed := time.Since(start)
+	afterBytes, afterObjects := readHeapAllocs(samples)
+
+	atomic.AddInt64(&b.calls, 1)
+	atomic.AddInt64(&b.nanos, int64(elapsed))
+	atomic.AddInt64(&b.partsIn, int64(msg.Len()))
+	atomic.AddUint64(&b.allocBytes, afterBytes-beforeBytes)
+	atomic.AddUint64(&b.allocObjects, afterObjects-beforeObjects)
+
+	var out, failed int64
+	for _, m := range res {
+		out += int64(m.Len())
+		_ = m.Iter(func(i int, p *message.Part) error {
+			if p.ErrorGet() != nil {
+				failed++
+			}
+			return nil
+		})
+	}
+	atomic.AddInt64(&b.partsOut, out)
+	atomic.AddInt64(&b.failed, failed)
+	return res, err
+}
+
+//------------------------------------------------------------------------------
+
+// latencyRecorder collects the latencies of delivered messages, keeping a
+// uniform random sample once the number of samples exceeds its capacity.
+type latencyRecorder struct {
+	mut     sync.Mutex
+	count   int64
+	samples []time.Duration
+	max     time.Duration
+}
+
+func (l *latencyRecorder) record(d time.Duration) {
+	l.mut.Lock()
+	l.count++
+	if d > l.max {
+		l.max = d
+	}
+	if len(l.samples) < benchLatencySamples {
+		l.samples = append(l.samples, d)
+	} else if i := rand.Int63n(l.count); i < benchLatencySamples {
+		l.samples[i] = d
+	}
+	l.mut.Unlock()
+}
+
+// percentile returns the latency at a given percentile (between 0 and 1),
+// where the samples must already be sorted.
+func (l *latencyRecorder) percentile(p float64) time.Duration {
+	if len(l.samples) == 0 {
+		return 0
+	}
+	i := int(float64(len(l.samples)-1) * p)
+	return l.samples[i]
+}
+
+//------------------------------------------------------------------------------
+
+// benchGenerator creates the synthetic messages fed into a benchmarked
+// pipeline, either by cycling through sample documents or by executing a
+// Bloblang mapping.
+type benchGenerator struct {
+	samples [][]byte
+	index   int
+	mapping func() (*message.Part, error)
+}
+
+func newBenchGenerator(samplePath, mapping string) (*benchGenerator, error) {
+	if samplePath != "" && mapping != "" {
+		return nil, errors.New("cannot specify both a sample file and a mapping")
+	}
+	if samplePath != "" {
+		f, err := os.Open(samplePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open sample file: %w", err)
+		}
+		defer f.Close()
+
+		g := &benchGenerator{}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			if line := scanner.Bytes(); len(strings.TrimSpace(string(line))) > 0 {
+				g.samples = append(g.samples, append([]byte(nil), line...))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read sample file: %w", err)
+		}
+		if len(g.samples) == 0 {
+			return nil, errors.New("sample file does not contain any documents")
+		}
+		return g, nil
+	}
+
+	if mapping == "" {
+		mapping = defaultBenchMapping
+	}
+	exec, err := bloblang.GlobalEnvironment().NewMapping(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mapping: %w", err)
+	}
+	return &benchGenerator{
+		mapping: func() (*message.Part, error) {
+			return exec.MapPart(0, message.QuickBatch(nil))
+		},
+	}, nil
+}
+
+func (g *benchGenerator) next() (*message.Part, error) {
+	if g.mapping != nil {
+		p, err := g.mapping()
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return message.NewPart(nil), nil
+		}
+		return p, nil
+	}
+	p := message.NewPart(g.samples[g.index])
+	g.index = (g.index + 1) % len(g.samples)
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// benchResults summarises a benchmark run.
+type benchResults struct {
+	elapsed    time.Duration
+	sent       int64
+	sentBytes  int64
+	delivered  int64
+	rejected   int64
+	latencies  *latencyRecorder
+	processors []*benchProcessor
+}
+
+func benchProcessors(mgr bundle.NewManagement, confs []processor.Config, path ...string) ([]*benchProcessor, error) {
+	var procs []*benchProcessor
+	for i, pConf := range confs {
+		pPath := append(append([]string{}, path...), strconv.Itoa(i))
+		p, err := mgr.IntoPath(pPath...).NewProcessor(pConf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create processor %v: %w", strings.Join(pPath, "."), err)
+		}
+		typeStr := pConf.Type
+		if pConf.Label != "" {
+			typeStr = pConf.Label + ": " + typeStr
+		}
+		procs = append(procs, &benchProcessor{
+			V1:      p,
+			path:    strings.Join(pPath, "."),
+			typeStr: typeStr,
+		})
+	}
+	return procs, nil
+}
+
+// runBench replaces the input of a config with a synthetic generator, runs the
+// processors and output of the config for a duration and returns the results.
+func runBench(conf config.Type, gen *benchGenerator, duration time.Duration, keepOutput bool) (*benchResults, error) {
+	mgr, err := manager.New(conf.ResourceConfig, mock.NewManager(), log.Noop(), imetrics.Noop())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resources: %w", err)
+	}
+	defer func() {
+		mgr.CloseAsync()
+		_ = mgr.WaitForClose(time.Second * 10)
+	}()
+
+	var procs []*benchProcessor
+	for _, p := range []struct {
+		confs []processor.Config
+		path  []string
+	}{
+		{conf.Input.Processors, []string{"input", "processors"}},
+		{conf.Pipeline.Processors, []string{"pipeline", "processors"}},
+	} {
+		ps, err := benchProcessors(mgr, p.confs, p.path...)
+		if err != nil {
+			return nil, err
+		}
+		procs = append(procs, ps...)
+	}
+
+	outConf := conf.Output
+	if !keepOutput {
+		// The output processors are still executed, but as part of the
+		// pipeline so that they are measured.
+		ps, err := benchProcessors(mgr, conf.Output.Processors, "output", "processors")
+		if err != nil {
+			return nil, err
+		}
+		procs = append(procs, ps...)
+		outConf = output.NewConfig()
+		outConf.Type = "drop"
+	}
+
+	v1Procs := make([]processor.V1, len(procs))
+	for i, p := range procs {
+		v1Procs[i] = p
+	}
+
+	var pipe processor.Pipeline
+	if conf.Pipeline.Threads == 1 {
+		pipe = pipeline.NewProcessor(v1Procs...)
+	} else if pipe, err = pipeline.NewPool(conf.Pipeline.Threads, mgr.Logger(), v1Procs...); err != nil {
+		return nil, fmt.Errorf("failed to create pipeline: %w", err)
+	}
+
+	out, err := mgr.IntoPath("output").NewOutput(outConf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output: %w", err)
+	}
+
+	tranChan := make(chan message.Transaction)
+	if err := pipe.Consume(tranChan); err != nil {
+		return nil, err
+	}
+	if err := out.Consume(pipe.TransactionChan()); err != nil {
+		return nil, err
+	}
+
+	res := &benchResults{
+		latencies:  &latencyRecorder{},
+		processors: procs,
+	}
+
+	var pending sync.WaitGroup
+	ctx, done := context.WithTimeout(context.Background(), duration)
+	defer done()
+
+	start := time.Now()
+generatorLoop:
+	for {
+		part, err := gen.next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate message: %w", err)
+		}
+		partBytes := int64(len(part.Get()))
+		batch := message.QuickBatch(nil)
+		batch.Append(part)
+
+		sentAt := time.Now()
+		pending.Add(1)
+		tran := message.NewTransactionFunc(batch, func(ctx context.Context, err error) error {
+			if err != nil {
+				atomic.AddInt64(&res.rejected, 1)
+			} else {
+				atomic.AddInt64(&res.delivered, 1)
+			}
+			res.latencies.record(time.Since(sentAt))
+			pending.Done()
+			return nil
+		})
+
+		select {
+		case tranChan <- tran:
+			res.sent++
+			res.sentBytes += partBytes
+		case <-ctx.Done():
+			pending.Done()
+			break generatorLoop
+		}
+	}
+
+	// Wait for every message sent to be delivered before measuring the elapsed
+	// time, which is bounded in case the output is unable to deliver them.
+	acked := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(acked)
+	}()
+	select {
+	case <-acked:
+	case <-time.After(time.Second * 10):
+	}
+	res.elapsed = time.Since(start)
+
+	close(tranChan)
+	pipe.CloseAsync()
+	_ = pipe.WaitForClose(time.Second * 10)
+	out.CloseAsync()
+	_ = out.WaitForClose(time.Second * 10)
+	for _, p := range procs {
+		p.CloseAsync()
+		_ = p.WaitForClose(time.Second * 10)
+	}
+
+	res.latencies.mut.Lock()
+	sort.Slice(res.latencies.samples, func(i, j int) bool {
+		return res.latencies.samples[i] < res.latencies.samples[j]
+	})
+	res.latencies.mut.Unlock()
+	return res, nil
+}
+
+func formatBytes(b float64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	i := 0
+	for ; b >= 1024 && i < len(units)-1; i++ {
+		b /= 1024
+	}
+	return strconv.FormatFloat(b, 'f', 1, 64) + units[i]
+}
+
+func printBenchResults(w io.Writer, res *benchResults) {
+	seconds := res.elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+
+	fmt.Fprintf(w, "Elapsed:    %v\n", res.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "Sent:       %v messages (%v)\n", res.sent, formatBytes(float64(res.sentBytes)))
+	fmt.Fprintf(w, "Delivered:  %v messages\n", res.delivered)
+	if res.rejected > 0 {
+		fmt.Fprintf(w, "Rejected:   %v messages\n", red(res.rejected))
+	}
+	fmt.Fprintf(w, "Throughput: %.1f msg/s (%v/s)\n", float64(res.delivered)/seconds, formatBytes(float64(res.sentBytes)/seconds))
+
+	l := res.latencies
+	fmt.Fprintf(w, "Latency:    p50 %v, p90 %v, p99 %v, max %v\n",
+		l.percentile(0.5), l.percentile(0.9), l.percentile(0.99), l.max)
+
+	if len(res.processors) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nProcessors:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  PATH\tTYPE\tCALLS\tIN\tOUT\tERRORS\tTIME/CALL\tPROCESS BYTES/CALL*\tPROCESS ALLOCS/CALL*")
+	for _, p := range res.processors {
+		calls := p.calls
+		if calls == 0 {
+			calls = 1
+		}
+		fmt.Fprintf(tw, "  %v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			p.path, p.typeStr, p.calls, p.partsIn, p.partsOut, p.failed,
+			time.Duration(p.nanos/calls),
+			formatBytes(float64(p.allocBytes)/float64(calls)),
+			p.allocObjects/uint64(calls),
+		)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(w, "\n* Allocations are process-wide and include those made concurrently by other")
+	fmt.Fprintln(w, "  goroutines, such as the generator, output and other pipeline threads, during")
+	fmt.Fprintln(w, "  each call.")
+}
+
+func benchCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "bench",
+		Usage: "Benchmark the processors of a config with synthetic data",
+		Description: `
+Replaces the input of a config with a generator of synthetic messages and runs
+the processors of the config for a duration, reporting throughput, latency
+percentiles and the time and memory allocations of each processor:
+
+  benthos -c ./config.yaml bench
+  benthos -c ./config.yaml bench --duration 30s --sample ./docs.jsonl
+  benthos -c ./config.yaml bench --mapping 'root.id = uuid_v4()'
+
+Messages are either read from a --sample file, where each line is a document
+and lines are cycled through, or generated with a Bloblang --mapping. When
+neither are specified a default mapping is used.
+
+By default the output of the config is replaced with a drop output and its
+processors are executed as part of the pipeline, use --keep-output in order to
+also benchmark the configured output.
+
+Allocation stats are read from the Go runtime and are process-wide, meaning
+the stats of each processor call include allocations made concurrently by the
+generator, the output and other pipeline threads. They are therefore an upper
+bound, which is closest to the cost of the processor itself when the pipeline
+runs a single thread.`[1:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "duration",
+				Value: "10s",
+				Usage: "The period of time to run the benchmark for.",
+			},
+			&cli.StringFlag{
+				Name:  "sample",
+				Value: "",
+				Usage: "A file of newline delimited documents to use as messages.",
+			},
+			&cli.StringFlag{
+				Name:  "mapping",
+				Value: "",
+				Usage: "A Bloblang mapping used to generate messages.",
+			},
+			&cli.BoolFlag{
+				Name:  "keep-output",
+				Value: false,
+				Usage: "Write messages to the configured output rather than dropping them.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			duration, err := time.ParseDuration(c.String("duration"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to parse duration: %v\n", err)
+				os.Exit(1)
+			}
+
+			gen, err := newBenchGenerator(c.String("sample"), c.String("mapping"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to create generator: %v\n", red(err))
+				os.Exit(1)
+			}
+
+			confReader := readConfig(c.StringSlice("config"), false, c.StringSlice("resources"), nil, c.StringSlice("set"))
+			conf := config.New()
+			lints, err := confReader.Read(&conf)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", red(err))
+				os.Exit(1)
+			}
+			for _, lint := range lints {
+				fmt.Fprintln(os.Stderr, yellow(lint))
+			}
+
+			res, err := runBench(conf, gen, duration, c.Bool("keep-output"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Benchmark error: %v\n", red(err))
+				os.Exit(1)
+			}
+			printBenchResults(os.Stdout, res)
+			os.Exit(0)
+			return nil
+		},
+	}
+}
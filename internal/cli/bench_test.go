@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// benchTestUpper is a processor that uppercases messages.
+type benchTestUpper struct{}
+
+func (benchTestUpper) Process(ctx context.Context, p *message.Part) ([]*message.Part, error) {
+	p = p.Copy()
+	p.Set(bytes.ToUpper(p.Get()))
+	return []*message.Part{p}, nil
+}
+
+func (benchTestUpper) Close(ctx context.Context) error {
+	return nil
+}
+
+// benchTestOutput is an output that acknowledges and records every message it
+// receives.
+type benchTestOutput struct {
+	mut      sync.Mutex
+	received []string
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// lastBenchTestOutput holds the most recently created bench_test_output.
+var lastBenchTestOutput = struct {
+	sync.Mutex
+	out *benchTestOutput
+}{}
+
+func (o *benchTestOutput) Consume(ts <-chan message.Transaction) error {
+	go func() {
+		defer o.closeOnce.Do(func() { close(o.closed) })
+		for t := range ts {
+			o.mut.Lock()
+			_ = t.Payload.Iter(func(i int, p *message.Part) error {
+				o.received = append(o.received, string(p.Get()))
+				return nil
+			})
+			o.mut.Unlock()
+			_ = t.Ack(context.Background(), nil)
+		}
+	}()
+	return nil
+}
+
+func (o *benchTestOutput) Connected() bool {
+	return true
+}
+
+func (o *benchTestOutput) CloseAsync() {}
+
+func (o *benchTestOutput) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-o.closed:
+	case <-time.After(timeout):
+	}
+	return nil
+}
+
+func init() {
+	if err := bundle.AllProcessors.Add(func(conf processor.Config, mgr bundle.NewManagement) (processor.V1, error) {
+		return processor.NewV2ToV1Processor("bench_test_upper", benchTestUpper{}, mgr.Metrics()), nil
+	}, docs.ComponentSpec{Name: "bench_test_upper"}); err != nil {
+		panic(err)
+	}
+	if err := bundle.AllOutputs.Add(func(output.Config, bundle.NewManagement, ...processor.PipelineConstructorFunc) (output.Streamed, error) {
+		out := &benchTestOutput{closed: make(chan struct{})}
+		lastBenchTestOutput.Lock()
+		lastBenchTestOutput.out = out
+		lastBenchTestOutput.Unlock()
+		return out, nil
+	}, docs.ComponentSpec{Name: "bench_test_output"}); err != nil {
+		panic(err)
+	}
+}
+
+func TestLatencyRecorderPercentile(t *testing.T) {
+	l := &latencyRecorder{}
+	assert.Equal(t, time.Duration(0), l.percentile(0.5))
+
+	for i := 1; i <= 100; i++ {
+		l.record(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, int64(100), l.count)
+	assert.Equal(t, 100*time.Millisecond, l.max)
+
+	assert.Equal(t, 1*time.Millisecond, l.percentile(0))
+	assert.Equal(t, 50*time.Millisecond, l.percentile(0.5))
+	assert.Equal(t, 90*time.Millisecond, l.percentile(0.9))
+	assert.Equal(t, 99*time.Millisecond, l.percentile(0.99))
+	assert.Equal(t, 100*time.Millisecond, l.percentile(1))
+
+	single := &latencyRecorder{}
+	single.record(time.Second)
+	assert.Equal(t, time.Second, single.percentile(0))
+	assert.Equal(t, time.Second, single.percentile(0.99))
+}
+
+func TestLatencyRecorderSampleLimit(t *testing.T) {
+	l := &latencyRecorder{}
+	for i := 0; i < benchLatencySamples+1000; i++ {
+		l.record(time.Millisecond)
+	}
+	l.record(time.Second)
+
+	assert.Equal(t, int64(benchLatencySamples+1001), l.count)
+	assert.Len(t, l.samples, benchLatencySamples)
+	assert.Equal(t, time.Second, l.max)
+}
+
+func TestRunBench(t *testing.T) {
+	samplePath := filepath.Join(t.TempDir(), "samples.jsonl")
+	require.NoError(t, os.WriteFile(samplePath, []byte("foo\n\nbar\n"), 0o644))
+
+	gen, err := newBenchGenerator(samplePath, "")
+	require.NoError(t, err)
+
+	conf := config.New()
+	conf.Output.Type = "bench_test_output"
+	conf.Pipeline.Threads = 1
+
+	procConf := processor.NewConfig()
+	procConf.Type = "bench_test_upper"
+	procConf.Label = "upper"
+	conf.Pipeline.Processors = append(conf.Pipeline.Processors, procConf)
+
+	res, err := runBench(conf, gen, time.Millisecond*100, true)
+	require.NoError(t, err)
+
+	assert.Greater(t, res.sent, int64(0))
+	assert.Equal(t, res.sent, res.delivered)
+	assert.Equal(t, int64(0), res.rejected)
+	assert.Equal(t, res.sent, res.latencies.count)
+	assert.Equal(t, res.sent*3, res.sentBytes)
+
+	require.Len(t, res.processors, 1)
+	p := res.processors[0]
+	assert.Equal(t, "pipeline.processors.0", p.path)
+	assert.Equal(t, "upper: bench_test_upper", p.typeStr)
+	assert.Equal(t, res.sent, p.calls)
+	assert.Equal(t, res.sent, p.partsIn)
+	assert.Equal(t, res.sent, p.partsOut)
+	assert.Equal(t, int64(0), p.failed)
+
+	lastBenchTestOutput.Lock()
+	out := lastBenchTestOutput.out
+	lastBenchTestOutput.Unlock()
+
+	out.mut.Lock()
+	received := out.received
+	out.mut.Unlock()
+	require.Len(t, received, int(res.sent))
+	assert.Equal(t, "FOO", received[0])
+	assert.Equal(t, "BAR", received[1])
+
+	var buf bytes.Buffer
+	printBenchResults(&buf, res)
+	assert.Contains(t, buf.String(), "pipeline.processors.0")
+	assert.Contains(t, buf.String(), "Allocations are process-wide")
+}
+
+func TestRunBenchBadConfig(t *testing.T) {
+	gen, err := newBenchGenerator("", "")
+	require.NoError(t, err)
+
+	conf := config.New()
+	procConf := processor.NewConfig()
+	procConf.Type = "does_not_exist"
+	conf.Pipeline.Processors = append(conf.Pipeline.Processors, procConf)
+
+	_, err = runBench(conf, gen, time.Millisecond*100, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create processor pipeline.processors.0")
+}
@@ -0,0 +1,287 @@
+package blobl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func benchCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "bench",
+		Usage: "Benchmark a Bloblang mapping against a corpus of documents",
+		Description: `
+Executes a mapping against a corpus of newline delimited documents for a
+duration, cycling through the documents, and reports the throughput, memory
+allocations and the cost of each statement of the mapping:
+
+  benthos blobl bench --mapping ./mapping.blobl --input ./corpus.jsonl
+  benthos blobl bench -m ./mapping.blobl -i ./corpus.jsonl --threads 4 --duration 30s
+
+The cost of each statement is measured during the same run, and therefore
+includes a small overhead. Allocation stats are read from the Go runtime and are
+approximate, especially when running multiple threads.`[1:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "mapping",
+				Aliases:  []string{"m"},
+				Usage:    "A file containing the mapping to benchmark.",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:     "input",
+				Aliases:  []string{"i"},
+				Usage:    "A file of newline delimited documents to execute the mapping against.",
+				Required: true,
+			},
+			&cli.IntFlag{
+				Name:    "threads",
+				Aliases: []string{"t"},
+				Value:   1,
+				Usage:   "The number of threads executing the mapping in parallel.",
+			},
+			&cli.StringFlag{
+				Name:  "duration",
+				Value: "10s",
+				Usage: "The period of time to run the benchmark for.",
+			},
+		},
+		Action: runBench,
+	}
+}
+
+func runBench(c *cli.Context) error {
+	duration, err := time.ParseDuration(c.String("duration"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, red("failed to parse duration: %v\n"), err)
+		os.Exit(1)
+	}
+
+	threads := c.Int("threads")
+	if threads < 1 {
+		threads = 1
+	}
+
+	mappingFile := c.String("mapping")
+	mappingBytes, err := os.ReadFile(mappingFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, red("failed to read mapping file: %v\n"), err)
+		os.Exit(1)
+	}
+	exec, err := parseMapping(mappingFile, string(mappingBytes), nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	corpus, err := readCorpus(c.String("input"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, red(err))
+		os.Exit(1)
+	}
+
+	printBenchResults(os.Stdout, benchMapping(exec, corpus, threads, duration))
+	os.Exit(0)
+	return nil
+}
+
+func readCorpus(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file: %w", err)
+	}
+	defer f.Close()
+
+	var corpus [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); len(line) > 0 {
+			corpus = append(corpus, append([]byte(nil), line...))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input file: %w", err)
+	}
+	if len(corpus) == 0 {
+		return nil, errors.New("input file does not contain any documents")
+	}
+	return corpus, nil
+}
+
+//------------------------------------------------------------------------------
+
+type statementStats struct {
+	line   int
+	target string
+	calls  int64
+	errors int64
+	nanos  int64
+}
+
+// benchWorker executes a mapping and records the cost of each statement, each
+// worker has its own stats in order to avoid contention.
+type benchWorker struct {
+	executions int64
+	errors     int64
+	statements map[int]*statementStats
+}
+
+func (w *benchWorker) record(entry mapping.TraceEntry) {
+	s, exists := w.statements[entry.Line]
+	if !exists {
+		s = &statementStats{
+			line:   entry.Line,
+			target: entry.Target.String(),
+		}
+		w.statements[entry.Line] = s
+	}
+	s.calls++
+	s.nanos += int64(entry.Elapsed)
+	if entry.Err != nil {
+		s.errors++
+	}
+}
+
+type benchResults struct {
+	elapsed      time.Duration
+	executions   int64
+	errors       int64
+	allocBytes   uint64
+	allocObjects uint64
+	statements   []*statementStats
+}
+
+func readHeapAllocs() (bytes, objects uint64) {
+	samples := []metrics.Sample{
+		{Name: "/gc/heap/allocs:bytes"},
+		{Name: "/gc/heap/allocs:objects"},
+	}
+	metrics.Read(samples)
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		bytes = samples[0].Value.Uint64()
+	}
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		objects = samples[1].Value.Uint64()
+	}
+	return
+}
+
+func benchMapping(exec *mapping.Executor, corpus [][]byte, threads int, duration time.Duration) *benchResults {
+	ctx, done := context.WithTimeout(context.Background(), duration)
+	defer done()
+
+	workers := make([]*benchWorker, threads)
+	for i := range workers {
+		workers[i] = &benchWorker{statements: map[int]*statementStats{}}
+	}
+
+	beforeBytes, beforeObjects := readHeapAllocs()
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i, w := range workers {
+		wg.Add(1)
+		go func(offset int, w *benchWorker) {
+			defer wg.Done()
+
+			wExec := exec.WithTrace(w.record)
+			for j := offset; ctx.Err() == nil; j++ {
+				msg := message.QuickBatch([][]byte{corpus[j%len(corpus)]})
+				if _, err := wExec.MapPart(0, msg); err != nil {
+					w.errors++
+				}
+				w.executions++
+			}
+		}(i, w)
+	}
+	wg.Wait()
+
+	res := &benchResults{elapsed: time.Since(start)}
+	afterBytes, afterObjects := readHeapAllocs()
+	res.allocBytes = afterBytes - beforeBytes
+	res.allocObjects = afterObjects - beforeObjects
+
+	statements := map[int]*statementStats{}
+	for _, w := range workers {
+		res.executions += w.executions
+		res.errors += w.errors
+		for line, s := range w.statements {
+			total, exists := statements[line]
+			if !exists {
+				total = &statementStats{line: s.line, target: s.target}
+				statements[line] = total
+			}
+			total.calls += s.calls
+			total.errors += s.errors
+			total.nanos += s.nanos
+		}
+	}
+	for _, s := range statements {
+		res.statements = append(res.statements, s)
+	}
+	sort.Slice(res.statements, func(i, j int) bool {
+		return res.statements[i].line < res.statements[j].line
+	})
+	return res
+}
+
+func formatBytes(b float64) string {
+	units := []string{"B", "KB", "MB", "GB"}
+	i := 0
+	for ; b >= 1024 && i < len(units)-1; i++ {
+		b /= 1024
+	}
+	return strconv.FormatFloat(b, 'f', 1, 64) + units[i]
+}
+
+func printBenchResults(w io.Writer, res *benchResults) {
+	seconds := res.elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+	execs := res.executions
+	if execs == 0 {
+		execs = 1
+	}
+
+	fmt.Fprintf(w, "Elapsed:     %v\n", res.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "Executions:  %v\n", res.executions)
+	if res.errors > 0 {
+		fmt.Fprintf(w, "Errors:      %v\n", red(res.errors))
+	}
+	fmt.Fprintf(w, "Throughput:  %.1f exec/s\n", float64(res.executions)/seconds)
+	fmt.Fprintf(w, "Time/exec:   %v\n", time.Duration(int64(res.elapsed)/execs))
+	fmt.Fprintf(w, "Bytes/exec:  %v\n", formatBytes(float64(res.allocBytes)/float64(execs)))
+	fmt.Fprintf(w, "Allocs/exec: %v\n", res.allocObjects/uint64(execs))
+
+	if len(res.statements) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nStatements:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  LINE\tTARGET\tCALLS\tERRORS\tTIME/CALL")
+	for _, s := range res.statements {
+		calls := s.calls
+		if calls == 0 {
+			calls = 1
+		}
+		fmt.Fprintf(tw, "  %v\t%v\t%v\t%v\t%v\n", s.line, s.target, s.calls, s.errors, time.Duration(s.nanos/calls))
+	}
+	_ = tw.Flush()
+}
@@ -19,6 +19,7 @@ import (
 )
 
 var red = color.New(color.FgRed).SprintFunc()
+var yellow = color.New(color.FgYellow).SprintFunc()
 
 // CliCommand is a cli.Command definition for running a blobl mapping.
 func CliCommand() *cli.Command {
@@ -32,6 +33,8 @@ Provides a convenient tool for mapping JSON documents over the command line:
 
   echo '{"foo":"bar"}' | benthos blobl -f ./mapping.blobl
 
+  echo '{"foo":"bar"}' | benthos blobl -f ./mapping.blobl --watch
+
 Find out more about Bloblang at: https://benthos.dev/docs/guides/bloblang/about`[1:],
 		Flags: []cli.Flag{
 			&cli.IntFlag{
@@ -55,6 +58,15 @@ Find out more about Bloblang at: https://benthos.dev/docs/guides/bloblang/about`
 				Aliases: []string{"f"},
 				Usage:   "execute a mapping from a file.",
 			},
+			&cli.BoolFlag{
+				Name:    "watch",
+				Aliases: []string{"w"},
+				Usage:   "watch the mapping file for changes, and each time it is modified execute the new mapping against the most recently consumed document. Requires a mapping file.",
+			},
+			&cli.BoolFlag{
+				Name:  "trace",
+				Usage: "print the line, assignment target, result and execution time of each statement of the mapping to stderr.",
+			},
 			&cli.IntFlag{
 				Name:  "max-token-length",
 				Usage: "Set the buffer size for document lines.",
@@ -63,6 +75,7 @@ Find out more about Bloblang at: https://benthos.dev/docs/guides/bloblang/about`
 		},
 		Action: run,
 		Subcommands: []*cli.Command{
+			benchCliCommand(),
 			{
 				Name:        "server",
 				Usage:       "EXPERIMENTAL: Run a web server that hosts a Bloblang app",
@@ -234,17 +247,40 @@ func run(c *cli.Context) error {
 		m = string(mappingBytes)
 	}
 
-	bEnv := bloblang.NewEnvironment().WithImporterRelativeToFile(file)
-	exec, err := bEnv.NewMapping(m)
-	if err != nil {
-		if perr, ok := err.(*parser.Error); ok {
-			fmt.Fprintf(os.Stderr, "%v %v\n", red("failed to parse mapping:"), perr.ErrorAtPositionStructured("", []rune(m)))
-		} else {
-			fmt.Fprintln(os.Stderr, red(err.Error()))
+	var trace mapping.TraceFunc
+	if c.Bool("trace") {
+		trace = func(entry mapping.TraceEntry) {
+			fmt.Fprintln(os.Stderr, yellow(entry.String()))
 		}
+	}
+
+	exec, err := parseMapping(file, m, trace)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
+	var watched *watchedMapping
+	if c.Bool("watch") {
+		if len(file) == 0 {
+			fmt.Fprintln(os.Stderr, red("invalid flags, a mapping file must be specified in order to watch it for changes"))
+			os.Exit(1)
+		}
+		if watched, err = newWatchedMapping(file, exec, trace, execCache, raw, pretty); err != nil {
+			fmt.Fprintf(os.Stderr, red("failed to watch mapping file: %v\n"), err)
+			os.Exit(1)
+		}
+		t = 1
+	}
+
+	watchDone := make(chan struct{})
+	if watched != nil {
+		go func() {
+			defer close(watchDone)
+			watched.run()
+		}()
+	}
+
 	inputsChan := make(chan []byte)
 	go func() {
 		defer close(inputsChan)
@@ -280,7 +316,13 @@ func run(c *cli.Context) error {
 					return
 				}
 
-				resultStr, err := execCache.executeMapping(exec, raw, pretty, input)
+				var resultStr string
+				var err error
+				if watched != nil {
+					resultStr, err = watched.execute(input)
+				} else {
+					resultStr, err = execCache.executeMapping(exec, raw, pretty, input)
+				}
 				if err != nil {
 					fmt.Fprintln(os.Stderr, red(fmt.Sprintf("failed to execute map: %v", err)))
 					continue
@@ -293,6 +335,26 @@ func run(c *cli.Context) error {
 	for res := range resultsChan {
 		fmt.Println(res)
 	}
+	if watched != nil {
+		// Continue executing mapping changes against the last document until
+		// the process is interrupted.
+		<-watchDone
+	}
 	os.Exit(0)
 	return nil
 }
+
+func parseMapping(file, m string, trace mapping.TraceFunc) (*mapping.Executor, error) {
+	bEnv := bloblang.NewEnvironment().WithImporterRelativeToFile(file)
+	exec, err := bEnv.NewMapping(m)
+	if err != nil {
+		if perr, ok := err.(*parser.Error); ok {
+			return nil, fmt.Errorf("%v %v", red("failed to parse mapping:"), perr.ErrorAtPositionStructured("", []rune(m)))
+		}
+		return nil, errors.New(red(err.Error()))
+	}
+	if trace != nil {
+		exec = exec.WithTrace(trace)
+	}
+	return exec, nil
+}
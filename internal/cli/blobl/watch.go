@@ -0,0 +1,133 @@
+package blobl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+)
+
+// watchedMapping keeps track of a mapping file that is parsed again each time
+// it is modified, along with the most recent document consumed so that it can
+// be re-evaluated against the new mapping.
+type watchedMapping struct {
+	path    string
+	watcher *fsnotify.Watcher
+
+	trace       mapping.TraceFunc
+	raw, pretty bool
+
+	mut       sync.Mutex
+	execCache *execCache
+	exec      *mapping.Executor
+	lastInput []byte
+}
+
+func newWatchedMapping(path string, exec *mapping.Executor, trace mapping.TraceFunc, execCache *execCache, raw, pretty bool) (*watchedMapping, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// Editors commonly replace files rather than writing to them, therefore
+	// we watch the parent directory and filter for events on our file.
+	path = filepath.Clean(path)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+	return &watchedMapping{
+		path:      path,
+		watcher:   watcher,
+		trace:     trace,
+		raw:       raw,
+		pretty:    pretty,
+		execCache: execCache,
+		exec:      exec,
+	}, nil
+}
+
+// execute records a consumed document and executes the current mapping
+// against it.
+func (w *watchedMapping) execute(input []byte) (string, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	w.lastInput = input
+	return w.execCache.executeMapping(w.exec, w.raw, w.pretty, input)
+}
+
+// reload parses the mapping file and, when successful, replaces the current
+// mapping and executes it against the most recently consumed document. The
+// returned bool is false when no document has been consumed yet.
+func (w *watchedMapping) reload() (string, bool, error) {
+	mappingBytes, err := os.ReadFile(w.path)
+	if err != nil {
+		return "", false, fmt.Errorf(red("failed to read mapping file: %v"), err)
+	}
+	exec, err := parseMapping(w.path, string(mappingBytes), w.trace)
+	if err != nil {
+		return "", false, err
+	}
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	w.exec = exec
+	if w.lastInput == nil {
+		return "", false, nil
+	}
+	resultStr, err := w.execCache.executeMapping(exec, w.raw, w.pretty, w.lastInput)
+	if err != nil {
+		return "", false, errors.New(red(fmt.Sprintf("failed to execute map: %v", err)))
+	}
+	return resultStr, true, nil
+}
+
+// run blocks while watching the mapping file for changes, printing the result
+// of executing each new version of the mapping against the most recently
+// consumed document.
+func (w *watchedMapping) run() {
+	defer w.watcher.Close()
+
+	// Changes are collapsed over a short period as a single save often
+	// results in multiple events.
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	var changed bool
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == w.path && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				changed = true
+			}
+		case <-ticker.C:
+			if !changed {
+				continue
+			}
+			changed = false
+
+			resultStr, ok, err := w.reload()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			if ok {
+				fmt.Println(resultStr)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Fprintln(os.Stderr, red(fmt.Sprintf("mapping file watcher error: %v", err)))
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+var green = color.New(color.FgGreen).SprintFunc()
+
+// connectionCheckKey is the key read from caches in order to check whether
+// they are reachable, a missing key is considered a success.
+const connectionCheckKey = "benthos_connection_check"
+
+// connectionCheck is the result of attempting to connect a component.
+type connectionCheck struct {
+	path    string
+	typeStr string
+	err     error
+}
+
+// connectable is implemented by both streamed inputs and outputs.
+type connectable interface {
+	Connected() bool
+}
+
+// waitForConnection polls a component until it reports that it is connected,
+// returning the most recent connection error (if any) once the context is
+// cancelled.
+func waitForConnection(ctx context.Context, c connectable) error {
+	ticker := time.NewTicker(time.Millisecond * 50)
+	defer ticker.Stop()
+	for {
+		if c.Connected() {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if err := component.ConnectionErrorOf(c); err != nil {
+				return err
+			}
+			return errors.New("timed out waiting for a connection")
+		}
+	}
+}
+
+func closeChecked(c interface {
+	CloseAsync()
+	WaitForClose(time.Duration) error
+}, timeout time.Duration) {
+	c.CloseAsync()
+	_ = c.WaitForClose(timeout)
+}
+
+// checkConnections creates every input, output, cache and rate limit of a
+// config and attempts to connect them, returning the result of each.
+func checkConnections(conf config.Type, timeout time.Duration) ([]connectionCheck, error) {
+	mgr, err := manager.New(conf.ResourceConfig, mock.NewManager(), log.Noop(), metrics.Noop())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resources: %w", err)
+	}
+	defer func() {
+		mgr.CloseAsync()
+		_ = mgr.WaitForClose(timeout)
+	}()
+
+	var checks []connectionCheck
+
+	if conf.Input.Type != "" {
+		check := connectionCheck{path: "input", typeStr: conf.Input.Type}
+		var in input.Streamed
+		if in, check.err = mgr.IntoPath("input").NewInput(conf.Input); check.err == nil {
+			ctx, done := context.WithTimeout(context.Background(), timeout)
+			check.err = waitForConnection(ctx, in)
+			done()
+			closeChecked(in, timeout)
+		}
+		checks = append(checks, check)
+	}
+
+	if conf.Output.Type != "" {
+		check := connectionCheck{path: "output", typeStr: conf.Output.Type}
+		var out output.Streamed
+		if out, check.err = mgr.IntoPath("output").NewOutput(conf.Output); check.err == nil {
+			if check.err = out.Consume(make(chan message.Transaction)); check.err == nil {
+				ctx, done := context.WithTimeout(context.Background(), timeout)
+				check.err = waitForConnection(ctx, out)
+				done()
+			}
+			closeChecked(out, timeout)
+		}
+		checks = append(checks, check)
+	}
+
+	for _, c := range conf.ResourceInputs {
+		check := connectionCheck{path: "input_resources." + c.Label, typeStr: c.Type}
+		ctx, done := context.WithTimeout(context.Background(), timeout)
+		if err := mgr.AccessInput(ctx, c.Label, func(in input.Streamed) {
+			check.err = waitForConnection(ctx, in)
+		}); err != nil {
+			check.err = err
+		}
+		done()
+		checks = append(checks, check)
+	}
+
+	for _, c := range conf.ResourceOutputs {
+		check := connectionCheck{path: "output_resources." + c.Label, typeStr: c.Type}
+		ctx, done := context.WithTimeout(context.Background(), timeout)
+		if err := mgr.AccessOutput(ctx, c.Label, func(out output.Sync) {
+			check.err = waitForConnection(ctx, out)
+		}); err != nil {
+			check.err = err
+		}
+		done()
+		checks = append(checks, check)
+	}
+
+	for _, c := range conf.ResourceCaches {
+		check := connectionCheck{path: "cache_resources." + c.Label, typeStr: c.Type}
+		ctx, done := context.WithTimeout(context.Background(), timeout)
+		if err := mgr.AccessCache(ctx, c.Label, func(ca cache.V1) {
+			if _, check.err = ca.Get(ctx, connectionCheckKey); errors.Is(check.err, component.ErrKeyNotFound) {
+				check.err = nil
+			}
+		}); err != nil {
+			check.err = err
+		}
+		done()
+		checks = append(checks, check)
+	}
+
+	for _, c := range conf.ResourceRateLimits {
+		check := connectionCheck{path: "rate_limit_resources." + c.Label, typeStr: c.Type}
+		ctx, done := context.WithTimeout(context.Background(), timeout)
+		if err := mgr.AccessRateLimit(ctx, c.Label, func(rl ratelimit.V1) {
+			_, check.err = rl.Access(ctx)
+		}); err != nil {
+			check.err = err
+		}
+		done()
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+// printConnectionChecks writes the result of each connection check and
+// returns whether all of them succeeded.
+func printConnectionChecks(w io.Writer, checks []connectionCheck) bool {
+	success := true
+	for _, c := range checks {
+		if c.err != nil {
+			success = false
+			fmt.Fprintf(w, "%v (%v): %v\n", c.path, c.typeStr, red("FAILED: "+c.err.Error()))
+		} else {
+			fmt.Fprintf(w, "%v (%v): %v\n", c.path, c.typeStr, green("OK"))
+		}
+	}
+	return success
+}
+
+func checkCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "check",
+		Usage: "Check a config for linting errors and, optionally, connectivity",
+		Description: `
+Parses a config along with any resource files and reports linting errors,
+exiting with a status code 1 if any are found:
+
+  benthos -c ./config.yaml check
+  benthos -c ./config.yaml -r ./resources.yaml check --connect
+
+With the --connect flag each input, output, cache and rate limit of the config
+is also created and given until the --timeout period to establish a
+connection, after which it is torn down and the result reported. This is
+useful for validating credentials and network policies before deploying.
+
+Inputs that connect successfully may consume data during the check, which is
+never acknowledged and therefore should be redelivered by inputs that support
+it.`[1:],
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "connect",
+				Value: false,
+				Usage: "Attempt to connect every input, output, cache and rate limit of the config.",
+			},
+			&cli.StringFlag{
+				Name:  "timeout",
+				Value: "10s",
+				Usage: "The maximum period of time to wait for each component to connect.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			timeout, err := time.ParseDuration(c.String("timeout"))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to parse timeout: %v\n", err)
+				os.Exit(1)
+			}
+
+			confReader := readConfig(c.StringSlice("config"), false, c.StringSlice("resources"), nil, c.StringSlice("set"))
+			conf := config.New()
+			lints, err := confReader.Read(&conf)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", red(err))
+				os.Exit(1)
+			}
+			if len(lints) > 0 {
+				for _, lint := range lints {
+					fmt.Fprintln(os.Stderr, yellow(lint))
+				}
+				os.Exit(1)
+			}
+
+			if !c.Bool("connect") {
+				os.Exit(0)
+			}
+
+			checks, err := checkConnections(conf, timeout)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Connection check error: %v\n", red(err))
+				os.Exit(1)
+			}
+			if !printConnectionChecks(os.Stdout, checks) {
+				os.Exit(1)
+			}
+			os.Exit(0)
+			return nil
+		},
+	}
+}
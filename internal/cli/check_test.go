@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+var errCheckTestRefused = errors.New("connection refused")
+
+// checkTestComponent is a fake input and output that is either connected or
+// reports a connection error.
+type checkTestComponent struct {
+	err error
+}
+
+func (c *checkTestComponent) TransactionChan() <-chan message.Transaction {
+	return make(chan message.Transaction)
+}
+
+func (c *checkTestComponent) Consume(<-chan message.Transaction) error {
+	return nil
+}
+
+func (c *checkTestComponent) Connected() bool {
+	return c.err == nil
+}
+
+func (c *checkTestComponent) ConnectionError() error {
+	return c.err
+}
+
+func (c *checkTestComponent) CloseAsync() {}
+
+func (c *checkTestComponent) WaitForClose(time.Duration) error {
+	return nil
+}
+
+type checkTestCache struct {
+	mock.Cache
+	err error
+}
+
+func (c *checkTestCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.Cache.Get(ctx, key)
+}
+
+func init() {
+	for name, err := range map[string]error{
+		"check_test_ok":   nil,
+		"check_test_fail": errCheckTestRefused,
+	} {
+		err := err
+		spec := docs.ComponentSpec{Name: name}
+		if aErr := bundle.AllInputs.Add(func(input.Config, bundle.NewManagement, ...iprocessor.PipelineConstructorFunc) (input.Streamed, error) {
+			return &checkTestComponent{err: err}, nil
+		}, spec); aErr != nil {
+			panic(aErr)
+		}
+		if aErr := bundle.AllOutputs.Add(func(output.Config, bundle.NewManagement, ...iprocessor.PipelineConstructorFunc) (output.Streamed, error) {
+			return &checkTestComponent{err: err}, nil
+		}, spec); aErr != nil {
+			panic(aErr)
+		}
+		if aErr := bundle.AllCaches.Add(func(cache.Config, bundle.NewManagement) (cache.V1, error) {
+			return &checkTestCache{Cache: mock.Cache{Values: map[string]mock.CacheItem{}}, err: err}, nil
+		}, spec); aErr != nil {
+			panic(aErr)
+		}
+		if aErr := bundle.AllRateLimits.Add(func(ratelimit.Config, bundle.NewManagement) (ratelimit.V1, error) {
+			return mock.RateLimit(func(context.Context) (time.Duration, error) {
+				return 0, err
+			}), nil
+		}, spec); aErr != nil {
+			panic(aErr)
+		}
+	}
+}
+
+func checkTestConfig(typeStr string) config.Type {
+	conf := config.New()
+	conf.Input.Type = typeStr
+	conf.Output.Type = typeStr
+
+	inConf := input.NewConfig()
+	inConf.Type = typeStr
+	inConf.Label = "foo"
+	conf.ResourceInputs = append(conf.ResourceInputs, inConf)
+
+	outConf := output.NewConfig()
+	outConf.Type = typeStr
+	outConf.Label = "bar"
+	conf.ResourceOutputs = append(conf.ResourceOutputs, outConf)
+
+	cacheConf := cache.NewConfig()
+	cacheConf.Type = typeStr
+	cacheConf.Label = "baz"
+	conf.ResourceCaches = append(conf.ResourceCaches, cacheConf)
+
+	rlConf := ratelimit.NewConfig()
+	rlConf.Type = typeStr
+	rlConf.Label = "buz"
+	conf.ResourceRateLimits = append(conf.ResourceRateLimits, rlConf)
+	return conf
+}
+
+func TestCheckConnectionsSuccess(t *testing.T) {
+	checks, err := checkConnections(checkTestConfig("check_test_ok"), time.Second)
+	require.NoError(t, err)
+
+	assert.Equal(t, []connectionCheck{
+		{path: "input", typeStr: "check_test_ok"},
+		{path: "output", typeStr: "check_test_ok"},
+		{path: "input_resources.foo", typeStr: "check_test_ok"},
+		{path: "output_resources.bar", typeStr: "check_test_ok"},
+		{path: "cache_resources.baz", typeStr: "check_test_ok"},
+		{path: "rate_limit_resources.buz", typeStr: "check_test_ok"},
+	}, checks)
+
+	var buf bytes.Buffer
+	assert.True(t, printConnectionChecks(&buf, checks))
+	assert.Contains(t, buf.String(), "input (check_test_ok): ")
+	assert.NotContains(t, buf.String(), "FAILED")
+}
+
+func TestCheckConnectionsFailure(t *testing.T) {
+	checks, err := checkConnections(checkTestConfig("check_test_fail"), time.Millisecond*200)
+	require.NoError(t, err)
+
+	require.Len(t, checks, 6)
+	for i, path := range []string{
+		"input",
+		"output",
+		"input_resources.foo",
+		"output_resources.bar",
+		"cache_resources.baz",
+		"rate_limit_resources.buz",
+	} {
+		assert.Equal(t, path, checks[i].path)
+		assert.Equal(t, "check_test_fail", checks[i].typeStr)
+		assert.ErrorIs(t, checks[i].err, errCheckTestRefused, path)
+	}
+
+	var buf bytes.Buffer
+	assert.False(t, printConnectionChecks(&buf, checks))
+	assert.Contains(t, buf.String(), "cache_resources.baz (check_test_fail): ")
+	assert.Contains(t, buf.String(), "FAILED: connection refused")
+}
+
+func TestCheckConnectionsBadResources(t *testing.T) {
+	conf := config.New()
+
+	cacheConf := cache.NewConfig()
+	cacheConf.Type = "does_not_exist"
+	cacheConf.Label = "foo"
+	conf.ResourceCaches = append(conf.ResourceCaches, cacheConf)
+
+	_, err := checkConnections(conf, time.Millisecond*200)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to create resources")
+}
@@ -1,8 +1,10 @@
 package cli
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -92,6 +94,62 @@ func addExpression(conf *config.Type, expression string) error {
 	return nil
 }
 
+// promptExpression interactively asks for the inputs, processors and outputs
+// of a config and returns them in the form of a create expression. Unrecognised
+// component types are reported and the question is asked again.
+func promptExpression(in io.Reader, out io.Writer) (string, error) {
+	scanner := bufio.NewScanner(in)
+
+	ask := func(name string, docsFor func(string) bool) (string, error) {
+	prompt:
+		for {
+			fmt.Fprintf(out, "Which %v would you like? (comma separated, leave empty for the default): ", name)
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", io.ErrUnexpectedEOF
+			}
+
+			var types []string
+			for _, t := range strings.Split(scanner.Text(), ",") {
+				if t = strings.TrimSpace(t); t == "" {
+					continue
+				}
+				if !docsFor(t) {
+					fmt.Fprintf(out, "Unrecognised %v type '%v', run `benthos list %v` to see all options.\n", strings.TrimSuffix(name, "s"), t, name)
+					continue prompt
+				}
+				types = append(types, t)
+			}
+			return strings.Join(types, ","), nil
+		}
+	}
+
+	inputs, err := ask("inputs", func(t string) bool {
+		_, exists := bundle.AllInputs.DocsFor(t)
+		return exists
+	})
+	if err != nil {
+		return "", err
+	}
+	processors, err := ask("processors", func(t string) bool {
+		_, exists := bundle.AllProcessors.DocsFor(t)
+		return exists
+	})
+	if err != nil {
+		return "", err
+	}
+	outputs, err := ask("outputs", func(t string) bool {
+		_, exists := bundle.AllOutputs.DocsFor(t)
+		return exists
+	})
+	if err != nil {
+		return "", err
+	}
+	return inputs + "/" + processors + "/" + outputs, nil
+}
+
 type minimalCreateConfig struct {
 	Input              input.Config       `json:"input" yaml:"input"`
 	Pipeline           pipeline.Config    `json:"pipeline" yaml:"pipeline"`
@@ -113,7 +171,12 @@ forward slashes:
   benthos create stdin/bloblang,awk/nats
   benthos create file,http_server/protobuf/http_client
 
-If the expression is omitted a default config is created.`[1:],
+If the expression is omitted a default config is created. Alternatively, the
+components can be chosen by answering a series of prompts with the
+--interactive flag, and the descriptions of each component and field can be
+added to the config as comments with the --comments flag:
+
+  benthos create --interactive --comments > ./config.yaml`[1:],
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:    "small",
@@ -121,11 +184,35 @@ If the expression is omitted a default config is created.`[1:],
 				Value:   false,
 				Usage:   "Print only the main components of a Benthos config (input, pipeline, output) and omit all fields marked as advanced.",
 			},
+			&cli.BoolFlag{
+				Name:    "interactive",
+				Aliases: []string{"i"},
+				Value:   false,
+				Usage:   "Choose the components of the config by answering prompts rather than providing an expression.",
+			},
+			&cli.BoolFlag{
+				Name:  "comments",
+				Value: false,
+				Usage: "Add the descriptions of each component and field to the config as comments.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			conf := config.New()
 
-			if expression := c.Args().First(); len(expression) > 0 {
+			expression := c.Args().First()
+			if c.Bool("interactive") {
+				if len(expression) > 0 {
+					fmt.Fprintln(os.Stderr, "Generate error: an expression cannot be provided in interactive mode")
+					os.Exit(1)
+				}
+				var err error
+				if expression, err = promptExpression(os.Stdin, os.Stderr); err != nil {
+					fmt.Fprintf(os.Stderr, "Generate error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			if len(expression) > 0 {
 				if err := addExpression(&conf, expression); err != nil {
 					fmt.Fprintf(os.Stderr, "Generate error: %v\n", err)
 					os.Exit(1)
@@ -157,6 +244,7 @@ If the expression is omitted a default config is created.`[1:],
 				sanitConf.RemoveDeprecated = true
 				sanitConf.ForExample = true
 				sanitConf.Filter = filter
+				sanitConf.DocsComments = c.Bool("comments")
 
 				err = config.Spec().SanitiseYAML(&node, sanitConf)
 			}
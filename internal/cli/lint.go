@@ -46,6 +46,26 @@ func lintFile(path string, rejectDeprecated bool) (pathLints []pathLint) {
 	return
 }
 
+// lintMergedFiles lints the config resulting from deep merging one or more
+// config files, where each lint and error is already prefixed with the path of
+// the file it originates from.
+func lintMergedFiles(paths []string, rejectDeprecated bool) (pathLints []pathLint) {
+	conf := config.New()
+	lints, err := config.ReadFilesLinted(paths, testSuffix, rejectDeprecated, &conf)
+	if err != nil {
+		pathLints = append(pathLints, pathLint{
+			err: err.Error(),
+		})
+		return
+	}
+	for _, l := range lints {
+		pathLints = append(pathLints, pathLint{
+			lint: l,
+		})
+	}
+	return
+}
+
 func lintMDSnippets(path string, rejectDeprecated bool) (pathLints []pathLint) {
 	rawBytes, err := os.ReadFile(path)
 	if err != nil {
@@ -119,6 +139,7 @@ func lintCliCommand() *cli.Command {
 Exits with a status code 1 if any linting errors are detected:
 
   benthos -c target.yaml lint
+  benthos -c base.yaml -c prod.yaml lint
   benthos lint ./configs/*.yaml
   benthos lint ./foo.yaml ./bar.yaml
   benthos lint ./configs/...
@@ -138,14 +159,13 @@ files with the .yaml or .yml extension.`[1:],
 				fmt.Fprintf(os.Stderr, "Lint paths error: %v\n", err)
 				os.Exit(1)
 			}
-			if conf := c.String("config"); len(conf) > 0 {
-				targets = append(targets, conf)
-			}
-
 			rejectDeprecated := c.Bool("deprecated")
 
 			var pathLintMut sync.Mutex
 			var pathLints []pathLint
+			if confs := c.StringSlice("config"); len(confs) > 0 {
+				pathLints = append(pathLints, lintMergedFiles(confs, rejectDeprecated)...)
+			}
 			threads := runtime.NumCPU()
 			var wg sync.WaitGroup
 			wg.Add(threads)
@@ -182,7 +202,9 @@ files with the .yaml or .yml extension.`[1:],
 				if len(lint.err) > 0 {
 					message = red(lint.err)
 				}
-				if lint.line > 0 {
+				if lint.source == "" {
+					fmt.Fprintln(os.Stderr, message)
+				} else if lint.line > 0 {
 					fmt.Fprintf(os.Stderr, "%v: from snippet at line %v: %v\n", lint.source, lint.line, message)
 				} else {
 					fmt.Fprintf(os.Stderr, "%v: %v\n", lint.source, message)
@@ -24,12 +24,13 @@ components will be shown.
 
   benthos list
   benthos list --format json inputs output
+  benthos list --format jsonschema > benthos.schema.json
   benthos list rate-limits buffers`[1:],
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "format",
 				Value: "text",
-				Usage: "Print the component list in a specific format. Options are text, json or cue.",
+				Usage: "Print the component list in a specific format. Options are text, json, json-full, json-full-scrubbed, jsonschema or cue, where json-full includes the fields, types, defaults and descriptions of each component.",
 			},
 		},
 		Action: func(c *cli.Context) error {
@@ -105,11 +106,20 @@ func listComponents(c *cli.Context) {
 			panic(err)
 		}
 		fmt.Println(string(jsonBytes))
+	case "jsonschema":
+		jsonBytes, err := json.Marshal(schema.JSONSchema())
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(string(jsonBytes))
 	case "cue":
 		source, err := cuegen.GenerateSchema(schema)
 		if err != nil {
 			panic(err)
 		}
 		fmt.Println(string(source))
+	default:
+		fmt.Fprintf(os.Stderr, "Format not recognised: %v\n", c.String("format"))
+		os.Exit(1)
 	}
 }
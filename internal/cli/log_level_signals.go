@@ -0,0 +1,48 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+// watchLogLevelSignals listens for SIGUSR1 and SIGUSR2 signals, where SIGUSR1
+// increases the verbosity of the logger by one level and SIGUSR2 resets it back
+// to the configured level. The returned func stops listening.
+func watchLogLevelSignals(logger log.Modular) func() {
+	ls, ok := logger.(log.LevelSetter)
+	if !ok {
+		return func() {}
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	doneChan := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-sigChan:
+				if sig == syscall.SIGUSR1 {
+					logger.Infof("Received SIGUSR1, log level increased to %v.\n", log.IncreaseLevel(ls))
+				} else {
+					ls.ResetLevel("")
+					level, _ := ls.Levels()
+					logger.Infof("Received SIGUSR2, log level reset to %v.\n", level)
+				}
+			case <-doneChan:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigChan)
+		close(doneChan)
+	}
+}
@@ -0,0 +1,11 @@
+package cli
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+// watchLogLevelSignals is a no-op on windows as SIGUSR1 and SIGUSR2 are not
+// supported.
+func watchLogLevelSignals(logger log.Modular) func() {
+	return func() {}
+}
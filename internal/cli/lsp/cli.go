@@ -0,0 +1,30 @@
+package lsp
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+)
+
+// CliCommand is a cli.Command definition for running a Bloblang language
+// server.
+func CliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "lsp",
+		Usage: "EXPERIMENTAL: Run a Bloblang language server over stdio",
+		Description: `
+Runs a language server that communicates over stdin and stdout using the
+Language Server Protocol, providing diagnostics, completion and hover
+documentation for Bloblang mapping files within editors:
+
+  benthos lsp
+
+Configure your editor to launch this command as the language server for files
+with the .blobl extension.`[1:],
+		Action: func(c *cli.Context) error {
+			return NewServer(bloblang.GlobalEnvironment(), os.Stdin, os.Stdout).Serve()
+		},
+	}
+}
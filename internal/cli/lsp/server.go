@@ -0,0 +1,493 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+// Error codes defined by JSON-RPC and the Language Server Protocol.
+const (
+	codeParseError     = -32700
+	codeInvalidParams  = -32602
+	codeMethodNotFound = -32601
+)
+
+// Kinds defined by the Language Server Protocol.
+const (
+	diagnosticSeverityError = 1
+	completionKindMethod    = 2
+	completionKindFunction  = 3
+	textDocumentSyncFull    = 1
+)
+
+type rpcRequest struct {
+	ID     *json.RawMessage `json:"id,omitempty"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result"`
+}
+
+type rpcErrorResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Error   *rpcError        `json:"error"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type textRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type diagnostic struct {
+	Range    textRange `json:"range"`
+	Severity int       `json:"severity"`
+	Source   string    `json:"source"`
+	Message  string    `json:"message"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type completionItem struct {
+	Label         string         `json:"label"`
+	Kind          int            `json:"kind"`
+	Detail        string         `json:"detail,omitempty"`
+	Documentation *markupContent `json:"documentation,omitempty"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position position `json:"position"`
+}
+
+//------------------------------------------------------------------------------
+
+// Server is a minimal Language Server Protocol implementation for Bloblang
+// mapping files, providing diagnostics from the mapping parser, completion of
+// function and method names, and hover documentation.
+//
+// Positions are measured in runes rather than UTF-16 code units, which only
+// differs for characters outside of the basic multilingual plane.
+type Server struct {
+	env *bloblang.Environment
+	in  *bufio.Reader
+	out io.Writer
+
+	docs      map[string]string
+	functions map[string]query.FunctionSpec
+	methods   map[string]query.MethodSpec
+
+	shutdown bool
+}
+
+// NewServer creates a language server that reads messages from an input and
+// writes messages to an output, where mappings are parsed with the provided
+// environment.
+func NewServer(env *bloblang.Environment, in io.Reader, out io.Writer) *Server {
+	s := &Server{
+		env:       env,
+		in:        bufio.NewReader(in),
+		out:       out,
+		docs:      map[string]string{},
+		functions: map[string]query.FunctionSpec{},
+		methods:   map[string]query.MethodSpec{},
+	}
+	env.WalkFunctions(func(name string, spec query.FunctionSpec) {
+		if spec.Status != query.StatusHidden {
+			s.functions[name] = spec
+		}
+	})
+	env.WalkMethods(func(name string, spec query.MethodSpec) {
+		if spec.Status != query.StatusHidden {
+			s.methods[name] = spec
+		}
+	})
+	return s
+}
+
+// Serve processes messages until an exit notification is received or the
+// input is closed.
+func (s *Server) Serve() error {
+	for {
+		body, err := s.readMessage()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.respond(nil, nil, &rpcError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("received exit notification before shutdown request")
+			}
+			return nil
+		}
+
+		result, rErr := s.handle(req)
+		if req.ID == nil {
+			// Notifications do not receive responses.
+			continue
+		}
+		if err := s.respond(req.ID, result, rErr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(req rpcRequest) (interface{}, *rpcError) {
+	invalidParams := func(err error) *rpcError {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync": textDocumentSyncFull,
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{"."},
+				},
+				"hoverProvider": true,
+			},
+			"serverInfo": map[string]interface{}{
+				"name": "benthos",
+			},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		s.publishDiagnostics(params.TextDocument.URI)
+		return nil, nil
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if l := len(params.ContentChanges); l > 0 {
+			s.docs[params.TextDocument.URI] = params.ContentChanges[l-1].Text
+			s.publishDiagnostics(params.TextDocument.URI)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+			"uri":         params.TextDocument.URI,
+			"diagnostics": []diagnostic{},
+		})
+		return nil, nil
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.completion(params), nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.hover(params), nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not supported: %v", req.Method)}
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Server) diagnostics(uri, text string) []diagnostic {
+	env := s.env.Deactivated()
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		env = env.WithImporterRelativeToFile(u.Path)
+	}
+
+	_, err := env.NewMapping(text)
+	if err == nil {
+		return []diagnostic{}
+	}
+
+	var pos position
+	msg := err.Error()
+	if perr, ok := err.(*parser.Error); ok {
+		msg = perr.ErrorMessage()
+		pos = positionOf([]rune(text), len(text)-len(string(perr.Input)))
+	}
+	return []diagnostic{{
+		Range:    textRange{Start: pos, End: pos},
+		Severity: diagnosticSeverityError,
+		Source:   "bloblang",
+		Message:  msg,
+	}}
+}
+
+// positionOf returns the line and character of a byte offset within a document.
+func positionOf(input []rune, offset int) (pos position) {
+	for _, r := range input {
+		if offset -= utf8.RuneLen(r); offset < 0 {
+			break
+		}
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+		} else {
+			pos.Character++
+		}
+	}
+	return
+}
+
+func (s *Server) publishDiagnostics(uri string) {
+	s.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": s.diagnostics(uri, s.docs[uri]),
+	})
+}
+
+// wordAt returns the identifier that surrounds a position within a document,
+// the portion of it before the position, and whether the identifier is
+// preceded by a dot and is therefore a method.
+func (s *Server) wordAt(uri string, pos position) (word, prefix string, isMethod bool) {
+	lines := strings.Split(s.docs[uri], "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return
+	}
+	line := []rune(lines[pos.Line])
+
+	end := pos.Character
+	if end < 0 {
+		end = 0
+	}
+	if end > len(line) {
+		end = len(line)
+	}
+	start := end
+	for start > 0 && isIdentRune(line[start-1]) {
+		start--
+	}
+	prefix = string(line[start:end])
+	for end < len(line) && isIdentRune(line[end]) {
+		end++
+	}
+	word = string(line[start:end])
+	isMethod = start > 0 && line[start-1] == '.'
+	return
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+func (s *Server) completion(params textDocumentPositionParams) []completionItem {
+	_, prefix, isMethod := s.wordAt(params.TextDocument.URI, params.Position)
+
+	items := []completionItem{}
+	if isMethod {
+		for name, spec := range s.methods {
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			items = append(items, completionItem{
+				Label:         name,
+				Kind:          completionKindMethod,
+				Detail:        signature(name, spec.Params),
+				Documentation: &markupContent{Kind: "markdown", Value: methodDescription(spec)},
+			})
+		}
+	} else {
+		for name, spec := range s.functions {
+			if !strings.HasPrefix(name, prefix) {
+				continue
+			}
+			items = append(items, completionItem{
+				Label:         name,
+				Kind:          completionKindFunction,
+				Detail:        signature(name, spec.Params),
+				Documentation: &markupContent{Kind: "markdown", Value: spec.Description},
+			})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+	return items
+}
+
+func (s *Server) hover(params textDocumentPositionParams) interface{} {
+	word, _, isMethod := s.wordAt(params.TextDocument.URI, params.Position)
+	if word == "" {
+		return nil
+	}
+
+	var sig, desc string
+	if isMethod {
+		spec, exists := s.methods[word]
+		if !exists {
+			return nil
+		}
+		sig, desc = signature(word, spec.Params), methodDescription(spec)
+	} else {
+		spec, exists := s.functions[word]
+		if !exists {
+			return nil
+		}
+		sig, desc = signature(word, spec.Params), spec.Description
+	}
+	return map[string]interface{}{
+		"contents": markupContent{
+			Kind:  "markdown",
+			Value: "```coffee\n" + sig + "\n```\n\n" + desc,
+		},
+	}
+}
+
+func signature(name string, params query.Params) string {
+	if params.Variadic {
+		return name + "(...)"
+	}
+	names := make([]string, 0, len(params.Definitions))
+	for _, def := range params.Definitions {
+		names = append(names, def.Name)
+	}
+	return name + "(" + strings.Join(names, ", ") + ")"
+}
+
+func methodDescription(spec query.MethodSpec) string {
+	if spec.Description != "" || len(spec.Categories) == 0 {
+		return spec.Description
+	}
+	return spec.Categories[0].Description
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Server) readMessage() ([]byte, error) {
+	contentLength := -1
+	for {
+		line, err := s.in.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if v := strings.TrimPrefix(line, "Content-Length:"); v != line {
+			if contentLength, err = strconv.Atoi(strings.TrimSpace(v)); err != nil {
+				return nil, fmt.Errorf("invalid content length: %w", err)
+			}
+		}
+	}
+	if contentLength < 0 {
+		return nil, errors.New("message is missing a content length header")
+	}
+	body := make([]byte, contentLength)
+	if _, err := io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *Server) writeMessage(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (s *Server) respond(id *json.RawMessage, result interface{}, rErr *rpcError) error {
+	if id == nil {
+		null := json.RawMessage("null")
+		id = &null
+	}
+	if rErr != nil {
+		return s.writeMessage(rpcErrorResponse{
+			JSONRPC: "2.0",
+			ID:      id,
+			Error:   rErr,
+		})
+	}
+	return s.writeMessage(rpcResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  result,
+	})
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	_ = s.writeMessage(rpcNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
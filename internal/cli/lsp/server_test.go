@@ -0,0 +1,141 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+)
+
+func encodeMessages(t *testing.T, msgs ...interface{}) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	for _, m := range msgs {
+		body, err := json.Marshal(m)
+		require.NoError(t, err)
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	return buf.String()
+}
+
+func decodeMessages(t *testing.T, raw string) []map[string]interface{} {
+	t.Helper()
+
+	s := &Server{in: bufio.NewReader(strings.NewReader(raw))}
+
+	var msgs []map[string]interface{}
+	for {
+		body, err := s.readMessage()
+		if err != nil {
+			break
+		}
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &m))
+		msgs = append(msgs, m)
+	}
+	return msgs
+}
+
+func request(id int, method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+func notification(method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+}
+
+func TestServerSession(t *testing.T) {
+	uri := "file:///tmp/foo.blobl"
+	pos := func(line, char int) map[string]interface{} {
+		return map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri},
+			"position":     map[string]interface{}{"line": line, "character": char},
+		}
+	}
+
+	input := encodeMessages(t,
+		request(1, "initialize", map[string]interface{}{}),
+		notification("initialized", map[string]interface{}{}),
+		notification("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"uri":  uri,
+				"text": "root.foo = this.foo.uppercase()\nroot.bar = this.bar.nope()",
+			},
+		}),
+		notification("textDocument/didChange", map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": uri},
+			"contentChanges": []interface{}{map[string]interface{}{"text": "root.foo = this.foo.upp()\nroot.bar = uuid_v4()"}},
+		}),
+		request(2, "textDocument/completion", pos(0, 23)),
+		request(3, "textDocument/hover", pos(1, 13)),
+		request(4, "textDocument/hover", pos(1, 2)),
+		request(5, "textDocument/formatting", map[string]interface{}{}),
+		request(6, "shutdown", nil),
+		notification("exit", nil),
+	)
+
+	var out bytes.Buffer
+	require.NoError(t, NewServer(bloblang.NewEnvironment(), strings.NewReader(input), &out).Serve())
+
+	msgs := decodeMessages(t, out.String())
+	require.Len(t, msgs, 8)
+
+	// Initialize
+	assert.Equal(t, float64(1), msgs[0]["id"])
+	caps := msgs[0]["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	assert.Equal(t, true, caps["hoverProvider"])
+
+	// Diagnostics after opening
+	assert.Equal(t, "textDocument/publishDiagnostics", msgs[1]["method"])
+	diags := msgs[1]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	require.Len(t, diags, 1)
+	diag := diags[0].(map[string]interface{})
+	assert.Equal(t, "unrecognised method 'nope'", diag["message"])
+	assert.Equal(t, map[string]interface{}{"line": float64(1), "character": float64(20)}, diag["range"].(map[string]interface{})["start"])
+
+	// Diagnostics after changing
+	assert.Equal(t, "textDocument/publishDiagnostics", msgs[2]["method"])
+	diags = msgs[2]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	require.Len(t, diags, 1)
+	assert.Equal(t, "unrecognised method 'upp'", diags[0].(map[string]interface{})["message"])
+
+	// Completion
+	assert.Equal(t, float64(2), msgs[3]["id"])
+	var labels []string
+	for _, item := range msgs[3]["result"].([]interface{}) {
+		labels = append(labels, item.(map[string]interface{})["label"].(string))
+	}
+	assert.Equal(t, []string{"uppercase"}, labels)
+
+	// Hover over a function
+	assert.Equal(t, float64(3), msgs[4]["id"])
+	contents := msgs[4]["result"].(map[string]interface{})["contents"].(map[string]interface{})
+	assert.Equal(t, "markdown", contents["kind"])
+	assert.Contains(t, contents["value"], "uuid_v4(seed)")
+
+	// Hover over something that isn't a function
+	assert.Equal(t, float64(4), msgs[5]["id"])
+	assert.Nil(t, msgs[5]["result"])
+
+	// Unsupported method
+	assert.Equal(t, float64(5), msgs[6]["id"])
+	assert.Equal(t, float64(codeMethodNotFound), msgs[6]["error"].(map[string]interface{})["code"])
+
+	// Shutdown
+	assert.Equal(t, float64(6), msgs[7]["id"])
+	assert.Contains(t, msgs[7], "result")
+}
+
+func TestServerExitWithoutShutdown(t *testing.T) {
+	input := encodeMessages(t, notification("exit", nil))
+	err := NewServer(bloblang.NewEnvironment(), strings.NewReader(input), &bytes.Buffer{}).Serve()
+	require.Error(t, err)
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+// watchReloadSignals listens for SIGHUP signals, upon which all config files
+// are read again and any changes are applied. The returned func stops
+// listening.
+func watchReloadSignals(logger log.Modular, confReader *config.Reader) func() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	doneChan := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sigChan:
+				logger.Infoln("Received SIGHUP, reloading config files.")
+				confReader.TriggerReload()
+			case <-doneChan:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigChan)
+		close(doneChan)
+	}
+}
@@ -0,0 +1,11 @@
+package cli
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+// watchReloadSignals is a no-op on windows as SIGHUP is not supported.
+func watchReloadSignals(logger log.Modular, confReader *config.Reader) func() {
+	return func() {}
+}
@@ -5,18 +5,21 @@ import (
 	"fmt"
 	"os"
 	"runtime/debug"
+	"time"
 
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
 	"github.com/benthosdev/benthos/v4/internal/cli/blobl"
+	"github.com/benthosdev/benthos/v4/internal/cli/lsp"
 	"github.com/benthosdev/benthos/v4/internal/cli/studio"
 	clitemplate "github.com/benthosdev/benthos/v4/internal/cli/template"
 	"github.com/benthosdev/benthos/v4/internal/cli/test"
 	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/filepath"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 	"github.com/benthosdev/benthos/v4/internal/template"
 )
 
@@ -100,6 +103,54 @@ func cmdVersion() {
 
 //------------------------------------------------------------------------------
 
+func echoConfig(c *cli.Context, redact, lintDeprecated bool) int {
+	mainPaths := c.StringSlice("config")
+
+	confReader := readConfig(mainPaths, false, c.StringSlice("resources"), nil, c.StringSlice("set"))
+	conf := config.New()
+	if _, err := confReader.Read(&conf); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+		return 1
+	}
+
+	if lintDeprecated && len(mainPaths) > 0 {
+		lintConf := config.New()
+		lints, err := config.ReadFilesLinted(mainPaths, testSuffix, true, &lintConf)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+			return 1
+		}
+		for _, lint := range lints {
+			fmt.Fprintln(os.Stderr, lint)
+		}
+	}
+
+	var node yaml.Node
+	err := node.Encode(conf)
+	if err == nil {
+		sanitConf := docs.NewSanitiseConfig()
+		sanitConf.RemoveTypeField = true
+		sanitConf.RedactSecrets = redact
+		err = config.Spec().SanitiseYAML(&node, sanitConf)
+	}
+	if err == nil && redact {
+		err = confReader.RedactEnvVariables(&node)
+	}
+	if err == nil {
+		var configYAML []byte
+		if configYAML, err = config.MarshalYAML(node); err == nil {
+			fmt.Println(string(configYAML))
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Echo error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+//------------------------------------------------------------------------------
+
 // RunWithOpts runs the Benthos service after first applying opt funcs, which
 // are used for specify service customisations.
 func RunWithOpts(opts ...func()) {
@@ -109,6 +160,19 @@ func RunWithOpts(opts ...func()) {
 	Run()
 }
 
+func durationFlag(c *cli.Context, name string) time.Duration {
+	periodStr := c.String(name)
+	if periodStr == "" {
+		return 0
+	}
+	period, err := time.ParseDuration(periodStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse --%v period: %v\n", name, err)
+		os.Exit(1)
+	}
+	return period
+}
+
 // Run the Benthos service, if the pipeline is started successfully then this
 // call blocks until either the pipeline shuts down or a termination signal is
 // received.
@@ -134,13 +198,12 @@ func Run() {
 		&cli.StringSliceFlag{
 			Name:    "set",
 			Aliases: []string{"s"},
-			Usage:   "set a field (identified by a dot path) in the main configuration file, e.g. `\"metrics.type=prometheus\"`",
+			Usage:   "set a field (identified by a dot path) in the main configuration file, e.g. `\"metrics.type=prometheus\"`, values beginning with [ or { are parsed as YAML",
 		},
-		&cli.StringFlag{
+		&cli.StringSliceFlag{
 			Name:    "config",
 			Aliases: []string{"c"},
-			Value:   "",
-			Usage:   "a path to a configuration file",
+			Usage:   "a path to a configuration file, or a directory of them, when specified multiple times each config is deep merged over the previous",
 		},
 		&cli.StringSliceFlag{
 			Name:    "resources",
@@ -163,6 +226,26 @@ func Run() {
 			Value:   false,
 			Usage:   "EXPERIMENTAL: watch config files for changes and automatically apply them",
 		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Value: false,
+			Usage: "print the resolved config with interpolated values redacted and any linting errors, including deprecated fields, and then exit without running",
+		},
+		&cli.StringFlag{
+			Name:  "secrets-refresh",
+			Value: "",
+			Usage: "EXPERIMENTAL: a period (e.g. `5m`) at which to refresh secrets referenced by config files, changes are applied by reloading only the files that reference them and do not require the --watcher flag",
+		},
+		&cli.StringFlag{
+			Name:  "secrets-timeout",
+			Value: "30s",
+			Usage: "the maximum period to wait when obtaining a secret from a secrets provider, a period of 0s disables the timeout",
+		},
+		&cli.StringFlag{
+			Name:  "secrets-cache-ttl",
+			Value: "10m",
+			Usage: "a period after which cached secret values expire and are obtained again the next time a config referencing them is read, a period of 0s caches values indefinitely",
+		},
 	}
 	if len(customFlags) > 0 {
 		flags = append(flags, customFlags...)
@@ -180,6 +263,9 @@ Either run Benthos as a stream processor or choose a command:
   benthos -r "./production/*.yaml" -c ./config.yaml`[1:],
 		Flags: flags,
 		Before: func(c *cli.Context) error {
+			secrets.SetTimeout(durationFlag(c, "secrets-timeout"))
+			secrets.SetCacheTTL(durationFlag(c, "secrets-cache-ttl"))
+
 			if dotEnvFile := c.String("env-file"); dotEnvFile != "" {
 				vars, err := parser.ParseDotEnvFile(dotEnvFile)
 				if err != nil {
@@ -222,8 +308,11 @@ Either run Benthos as a stream processor or choose a command:
 				_ = cli.ShowAppHelp(c)
 				os.Exit(1)
 			}
+			if c.Bool("dry-run") {
+				os.Exit(echoConfig(c, true, true))
+			}
 			os.Exit(cmdService(
-				c.String("config"),
+				c.StringSlice("config"),
 				c.StringSlice("resources"),
 				c.StringSlice("set"),
 				c.String("log.level"),
@@ -231,7 +320,9 @@ Either run Benthos as a stream processor or choose a command:
 				c.Bool("watcher"),
 				false,
 				false,
+				false,
 				nil,
+				durationFlag(c, "secrets-refresh"),
 			))
 			return nil
 		},
@@ -244,35 +335,32 @@ This simple command is useful for sanity checking a config if it isn't
 behaving as expected, as it shows you a normalised version after environment
 variables have been resolved:
 
-  benthos -c ./config.yaml echo | less`[1:],
+  benthos -c ./config.yaml echo | less
+
+The values of environment variables, files, secrets and fields that hold
+credentials can be hidden with the --redact flag, and linting errors including deprecated fields can be printed
+to stderr with the --deprecated flag. Running Benthos with the --dry-run flag
+is equivalent to running this command with both flags set.`[1:],
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "redact",
+						Value: false,
+						Usage: "Replace values obtained from environment variables, files or secrets with the expressions that produced them, and hide the values of fields that hold credentials.",
+					},
+					&cli.BoolFlag{
+						Name:  "deprecated",
+						Value: false,
+						Usage: "Print linting errors, including the presence of deprecated fields, to stderr.",
+					},
+				},
 				Action: func(c *cli.Context) error {
-					confReader := readConfig(c.String("config"), false, c.StringSlice("resources"), nil, c.StringSlice("set"))
-					conf := config.New()
-					if _, err := confReader.Read(&conf); err != nil {
-						fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
-						os.Exit(1)
-					}
-					var node yaml.Node
-					err := node.Encode(conf)
-					if err == nil {
-						sanitConf := docs.NewSanitiseConfig()
-						sanitConf.RemoveTypeField = true
-						err = config.Spec().SanitiseYAML(&node, sanitConf)
-					}
-					if err == nil {
-						var configYAML []byte
-						if configYAML, err = config.MarshalYAML(node); err == nil {
-							fmt.Println(string(configYAML))
-						}
-					}
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "Echo error: %v\n", err)
-						os.Exit(1)
-					}
+					os.Exit(echoConfig(c, c.Bool("redact"), c.Bool("deprecated")))
 					return nil
 				},
 			},
 			lintCliCommand(),
+			checkCliCommand(),
+			benchCliCommand(),
 			{
 				Name:  "streams",
 				Usage: "Run Benthos in streams mode",
@@ -298,18 +386,25 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 						Value: false,
 						Usage: "Disable the HTTP API for streams mode",
 					},
+					&cli.BoolFlag{
+						Name:  "api-secrets",
+						Value: false,
+						Usage: "Allow stream configs submitted via the HTTP API, or read from a streams store, to reference secrets and files",
+					},
 				},
 				Action: func(c *cli.Context) error {
 					os.Exit(cmdService(
-						c.String("config"),
+						c.StringSlice("config"),
 						c.StringSlice("resources"),
 						c.StringSlice("set"),
 						c.String("log.level"),
 						!c.Bool("chilled"),
 						c.Bool("watcher"),
 						!c.Bool("no-api"),
+						c.Bool("api-secrets"),
 						true,
 						c.Args().Slice(),
+						durationFlag(c, "secrets-refresh"),
 					))
 					return nil
 				},
@@ -319,6 +414,7 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 			test.CliCommand(testSuffix),
 			clitemplate.CliCommand(),
 			blobl.CliCommand(),
+			lsp.CliCommand(),
 			studio.CliCommand(Version, DateBuilt),
 		},
 	}
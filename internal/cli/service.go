@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
 	"runtime/pprof"
 	"strings"
 	"sync"
@@ -17,14 +18,19 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/tracing"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/tracer"
 	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/config/schema"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/flowcontrol"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/stream"
+	"github.com/benthosdev/benthos/v4/internal/stream/audit"
 	strmmgr "github.com/benthosdev/benthos/v4/internal/stream/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream/store"
 )
 
 //------------------------------------------------------------------------------
@@ -37,7 +43,11 @@ type stoppable interface {
 
 //------------------------------------------------------------------------------
 
-func readConfig(path string, streamsMode bool, resourcesPaths, streamsPaths, overrides []string) *config.Reader {
+func readConfig(paths []string, streamsMode bool, resourcesPaths, streamsPaths, overrides []string, extraOpts ...config.OptFunc) *config.Reader {
+	var path string
+	if len(paths) > 0 {
+		path = paths[0]
+	}
 	if path == "" {
 		// Iterate default config paths
 		for _, dpath := range []string{
@@ -56,22 +66,49 @@ func readConfig(path string, streamsMode bool, resourcesPaths, streamsPaths, ove
 		config.OptAddOverrides(overrides...),
 		config.OptTestSuffix(testSuffix),
 	}
+	if len(paths) > 1 {
+		opts = append(opts, config.OptAddOverlayPaths(paths[1:]...))
+	}
 	if streamsMode {
 		opts = append(opts, config.OptSetStreamPaths(streamsPaths...))
 	}
+	opts = append(opts, extraOpts...)
 	return config.NewReader(path, resourcesPaths, opts...)
 }
 
 //------------------------------------------------------------------------------
 
 func initStreamsMode(
-	strict, watching, enableAPI bool,
+	strict, watching, enableAPI, apiSecrets bool,
+	readyComponents []string,
+	auditConf audit.Config,
+	storeConf store.Config,
+	drainTimeout, flushTimeout time.Duration,
 	confReader *config.Reader,
 	manager *manager.Type,
 	logger log.Modular,
 	stats *metrics.Namespaced,
 ) stoppable {
-	streamMgr := strmmgr.New(manager, strmmgr.OptAPIEnabled(enableAPI))
+	auditLog, err := audit.NewLog(auditConf, manager)
+	if err != nil {
+		logger.Errorf("Failed to create streams audit log: %v\n", err)
+		os.Exit(1)
+	}
+
+	streamStore, err := store.New(storeConf)
+	if err != nil {
+		logger.Errorf("Failed to create streams store: %v\n", err)
+		os.Exit(1)
+	}
+
+	streamMgr := strmmgr.New(manager,
+		strmmgr.OptAPIEnabled(enableAPI),
+		strmmgr.OptAPISecrets(apiSecrets),
+		strmmgr.OptReadyComponents(readyComponents...),
+		strmmgr.OptAuditLog(auditLog),
+		strmmgr.OptStore(streamStore),
+		strmmgr.OptShutdownTimeouts(drainTimeout, flushTimeout),
+	)
 
 	streamConfs := map[string]stream.Config{}
 	lints, err := confReader.ReadStreams(streamConfs)
@@ -96,6 +133,10 @@ func initStreamsMode(
 			os.Exit(1)
 		}
 	}
+	if err := streamMgr.SyncStore(context.Background()); err != nil {
+		logger.Errorf("Failed to read streams from store: %v\n", err)
+		os.Exit(1)
+	}
 	logger.Infoln("Launching benthos in streams mode, use CTRL+C to close.")
 
 	if err := confReader.SubscribeStreamChanges(func(id string, newStreamConf stream.Config) bool {
@@ -119,6 +160,10 @@ func initStreamsMode(
 			os.Exit(1)
 		}
 	}
+	if err := confReader.BeginSecretsRefresh(manager, strict); err != nil {
+		logger.Errorf("Failed to begin refreshing secrets: %v", err)
+		os.Exit(1)
+	}
 	return streamMgr
 }
 
@@ -165,6 +210,7 @@ func (s *swappableStopper) Replace(fn func() (stoppable, error)) error {
 func initNormalMode(
 	conf config.Type,
 	strict, watching bool,
+	drainTimeout, flushTimeout time.Duration,
 	confReader *config.Reader,
 	manager *manager.Type,
 	logger log.Modular,
@@ -180,6 +226,8 @@ func initNormalMode(
 					close(stoppedChan)
 				}
 			}),
+			stream.OptReadyComponents(conf.HTTP.ReadyComponents...),
+			stream.OptShutdownTimeouts(drainTimeout, flushTimeout),
 		)
 	}
 
@@ -193,6 +241,10 @@ func initNormalMode(
 	logger.Infoln("Launching a benthos instance, use CTRL+C to close.")
 
 	if err := confReader.SubscribeConfigChanges(func(newStreamConf stream.Config) bool {
+		if reflect.DeepEqual(conf.Config, newStreamConf) {
+			logger.Infoln("Main config stream fields are unchanged, skipping pipeline restart.")
+			return true
+		}
 		if err := stoppableStream.Replace(func() (stoppable, error) {
 			conf.Config = newStreamConf
 			return streamInit()
@@ -214,21 +266,30 @@ func initNormalMode(
 			os.Exit(1)
 		}
 	}
+	if err := confReader.BeginSecretsRefresh(manager, strict); err != nil {
+		logger.Errorf("Failed to begin refreshing secrets: %v", err)
+		os.Exit(1)
+	}
 
 	newStream = &stoppableStream
 	return
 }
 
 func cmdService(
-	confPath string,
+	confPaths []string,
 	resourcesPaths []string,
 	confOverrides []string,
 	overrideLogLevel string,
-	strict, watching, enableStreamsAPI bool,
+	strict, watching, enableStreamsAPI, streamsAPISecrets bool,
 	streamsMode bool,
 	streamsPaths []string,
+	secretsRefresh time.Duration,
 ) int {
-	confReader := readConfig(confPath, streamsMode, resourcesPaths, streamsPaths, confOverrides)
+	var readerOpts []config.OptFunc
+	if secretsRefresh > 0 {
+		readerOpts = append(readerOpts, config.OptSecretsRefreshPeriod(secretsRefresh))
+	}
+	confReader := readConfig(confPaths, streamsMode, resourcesPaths, streamsPaths, confOverrides, readerOpts...)
 	conf := config.New()
 
 	lints, err := confReader.Read(&conf)
@@ -266,6 +327,7 @@ func cmdService(
 	for _, lint := range lints {
 		logger.Infoln(lint)
 	}
+	defer watchLogLevelSignals(logger)()
 
 	// Create our metrics type.
 	var stats *metrics.Namespaced
@@ -305,21 +367,80 @@ func cmdService(
 		return 1
 	}
 
+	mgrOpts := []manager.OptFunc{
+		manager.OptSetStreamsMode(streamsMode),
+		manager.OptSetErrorEvents(conf.HTTP.ErrorEvents),
+	}
+	if conf.MessageTracing.Enabled {
+		tracedEnv, traces := tracing.MessageTracedBundle(bundle.GlobalEnvironment, conf.MessageTracing)
+		mgrOpts = append(mgrOpts, manager.OptSetEnvironment(tracedEnv))
+		httpServer.RegisterEndpoint(
+			"/debug/message_traces",
+			"Returns the most recently completed message traces. A DELETE request removes them.",
+			traces.HandlerFunc(),
+		)
+	}
+
+	var flowCtrl *flowcontrol.Controller
+	if conf.FlowControl.Enabled {
+		if flowCtrl, err = flowcontrol.New(conf.FlowControl); err != nil {
+			logger.Errorf("Failed to initialise flow control: %v\n", err)
+			return 1
+		}
+		mgrOpts = append(mgrOpts, manager.OptSetFlowControl(flowCtrl))
+		httpServer.RegisterEndpoint(
+			"/flow_control",
+			"Returns the current pressure, pause state and circuit state of each stream as JSON.",
+			flowCtrl.HandlerFunc(),
+		)
+	}
+
 	// Create resource manager.
-	manager, err := manager.New(conf.ResourceConfig, httpServer, logger, stats, manager.OptSetStreamsMode(streamsMode))
+	manager, err := manager.New(conf.ResourceConfig, httpServer, logger, stats, mgrOpts...)
 	if err != nil {
 		logger.Errorf("Failed to create resource: %v\n", err)
 		return 1
 	}
+	httpServer.RegisterEndpoint(
+		"/schema",
+		"Returns the names of all registered components as JSON. Set the query parameter `format` to `json-full` for the full schema of each component including its fields, types, defaults and descriptions, or `jsonschema` for a JSON Schema of the config format.",
+		schema.HandlerFunc(Version, DateBuilt),
+	)
+	if errEvents := manager.ErrorEvents(); errEvents != nil {
+		errEvents.IncludeSamples(conf.HTTP.ErrorSamples)
+		httpServer.RegisterEndpoint(
+			"/errors",
+			"Returns a summary of the failures observed by all components along with the most recent failure events. A DELETE request resets them.",
+			errEvents.HandlerFunc(),
+		)
+	}
+
+	var drainTimeout, flushTimeout time.Duration
+	if tout := conf.ShutdownDrainTimeout; len(tout) > 0 {
+		if drainTimeout, err = time.ParseDuration(tout); err != nil {
+			logger.Errorf("Failed to parse shutdown drain timeout period string: %v\n", err)
+			return 1
+		}
+	}
+	if tout := conf.ShutdownFlushTimeout; len(tout) > 0 {
+		if flushTimeout, err = time.ParseDuration(tout); err != nil {
+			logger.Errorf("Failed to parse shutdown flush timeout period string: %v\n", err)
+			return 1
+		}
+	}
 
 	var stoppableStream stoppable
 	var dataStreamClosedChan chan struct{}
 
 	// Create data streams.
 	if streamsMode {
-		stoppableStream = initStreamsMode(strict, watching, enableStreamsAPI, confReader, manager, logger, stats)
+		stoppableStream = initStreamsMode(strict, watching, enableStreamsAPI, streamsAPISecrets, conf.HTTP.ReadyComponents, conf.StreamsAuditLog, conf.StreamsStore, drainTimeout, flushTimeout, confReader, manager, logger, stats)
 	} else {
-		stoppableStream, dataStreamClosedChan = initNormalMode(conf, strict, watching, confReader, manager, logger, stats)
+		stoppableStream, dataStreamClosedChan = initNormalMode(conf, strict, watching, drainTimeout, flushTimeout, confReader, manager, logger, stats)
+	}
+
+	if watching {
+		defer watchReloadSignals(logger, confReader)()
 	}
 
 	// Start HTTP server.
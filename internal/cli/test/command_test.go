@@ -256,3 +256,48 @@ tests:
 		t.Error("Unexpected result")
 	}
 }
+
+func TestCommandRunMapping(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"cities.blobl": `root.Cities = this.locations.filter(loc -> loc.state == "WA").map_each(loc -> loc.name).sort().join(", ")
+meta count = this.locations.length()`,
+		"cities_test.yaml": `
+tests:
+  - name: test cities mapping
+    target_mapping: './cities.blobl'
+    input_batch:
+      - json_content:
+          locations:
+            - { name: Seattle, state: WA }
+            - { name: New York, state: NY }
+            - { name: Bellevue, state: WA }
+    output_batches:
+      -
+        - json_equals: { Cities: "Bellevue, Seattle" }
+          metadata_equals: { count: 3 }`,
+		"bad/cities.blobl": `root.Cities = this.locations.map_each(loc -> loc.name).join(", ")`,
+		"bad/cities_test.yaml": `
+tests:
+  - name: test cities mapping
+    target_mapping: './cities.blobl'
+    input_batch:
+      - json_content:
+          locations:
+            - { name: Seattle, state: WA }
+            - { name: New York, state: NY }
+    output_batches:
+      -
+        - json_equals: { Cities: "Seattle" }`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !test.RunAll([]string{filepath.Join(testDir, "cities_test.yaml")}, "_benthos_test", true, log.Noop(), nil) {
+		t.Error("Unexpected result")
+	}
+
+	if test.RunAll([]string{filepath.Join(testDir, "bad", "cities_test.yaml")}, "_benthos_test", true, log.Noop(), nil) {
+		t.Error("Unexpected result")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/Jeffail/gabs/v2"
@@ -95,7 +96,7 @@ func (p *ProcessorsProvider) ProvideBloblang(pathStr string) ([]processor.V1, er
 	pCtx := parser.GlobalContext().WithImporterRelativeToFile(pathStr)
 	exec, mapErr := parser.ParseMapping(pCtx, string(mappingBytes))
 	if mapErr != nil {
-		return nil, mapErr
+		return nil, fmt.Errorf("failed to parse mapping: %v", mapErr.ErrorAtPositionStructured(pathStr, []rune(string(mappingBytes))))
 	}
 
 	return []processor.V1{
@@ -213,6 +214,42 @@ func resolveProcessorsPointer(targetFile, jsonPtr string) (filePath, procPath st
 	return
 }
 
+// applyLabelMocks replaces the components of a config that have a label
+// matching a mock, removing each applied mock from the provided map. The label
+// of a replaced component is carried over to the mock unless the mock
+// specifies its own, so that mocked resources can still be referenced.
+func applyLabelMocks(confSpec docs.FieldSpecs, root *yaml.Node, labelsToPaths map[string][]string, mocks map[string]yaml.Node) error {
+	for k, v := range mocks {
+		mockPathSlice, exists := labelsToPaths[k]
+		if !exists {
+			continue
+		}
+		mockNode := v
+		if mockNode.Kind == yaml.MappingNode {
+			hasLabel := false
+			for i := 0; i < len(mockNode.Content)-1; i += 2 {
+				if mockNode.Content[i].Value == "label" {
+					hasLabel = true
+					break
+				}
+			}
+			if !hasLabel {
+				var labelKey, labelValue yaml.Node
+				labelKey.SetString("label")
+				labelValue.SetString(k)
+				content := make([]*yaml.Node, 0, len(mockNode.Content)+2)
+				content = append(content, &labelKey, &labelValue)
+				mockNode.Content = append(content, mockNode.Content...)
+			}
+		}
+		if err := confSpec.SetYAMLPath(docs.DeprecatedProvider, root, &mockNode, mockPathSlice...); err != nil {
+			return fmt.Errorf("failed to set mock '%v': %w", k, err)
+		}
+		delete(mocks, k)
+	}
+	return nil
+}
+
 func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) (cachedConfig, error) {
 	cacheKey := confTargetID(jsonPtr, environment, mocks)
 
@@ -249,27 +286,6 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
 	}
 
-	mgrWrapper := manager.NewResourceConfig()
-	if err = yaml.Unmarshal(configBytes, &mgrWrapper); err != nil {
-		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
-	}
-
-	for _, path := range p.resourcesPaths {
-		resourceBytes, _, err := config.ReadFileEnvSwap(path)
-		if err != nil {
-			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
-		}
-		extraMgrWrapper := manager.NewResourceConfig()
-		if err = yaml.Unmarshal(resourceBytes, &extraMgrWrapper); err != nil {
-			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
-		}
-		if err = mgrWrapper.AddFrom(&extraMgrWrapper); err != nil {
-			return confs, fmt.Errorf("failed to merge resources from '%v': %v", path, err)
-		}
-	}
-
-	confs.mgr = mgrWrapper
-
 	root := &yaml.Node{}
 	if err = yaml.Unmarshal(configBytes, root); err != nil {
 		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
@@ -293,29 +309,58 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 	}
 
 	labelsToPaths := map[string][]string{}
-	if len(remainingMocks) > 0 {
-		confSpec.YAMLLabelsToPaths(docs.DeprecatedProvider, root, labelsToPaths, nil)
-		for k, v := range remainingMocks {
-			mockPathSlice, exists := labelsToPaths[k]
-			if !exists {
-				return confs, fmt.Errorf("mock for label '%v' could not be applied as the label was not found in the test target file, it is not currently possible to mock resources imported separate to the test file", k)
-			}
-			if err = confSpec.SetYAMLPath(docs.DeprecatedProvider, root, &v, mockPathSlice...); err != nil {
-				return confs, fmt.Errorf("failed to set mock '%v': %w", k, err)
+	confSpec.YAMLLabelsToPaths(docs.DeprecatedProvider, root, labelsToPaths, nil)
+	if err = applyLabelMocks(confSpec, root, labelsToPaths, remainingMocks); err != nil {
+		return confs, err
+	}
+
+	mgrWrapper := manager.NewResourceConfig()
+	if err = root.Decode(&mgrWrapper); err != nil {
+		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
+	}
+
+	for _, path := range p.resourcesPaths {
+		resourceBytes, _, err := config.ReadFileEnvSwap(path)
+		if err != nil {
+			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+		}
+		resourceRoot := &yaml.Node{}
+		if err = yaml.Unmarshal(resourceBytes, resourceRoot); err != nil {
+			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+		}
+		if len(remainingMocks) > 0 {
+			resourceLabelsToPaths := map[string][]string{}
+			confSpec.YAMLLabelsToPaths(docs.DeprecatedProvider, resourceRoot, resourceLabelsToPaths, nil)
+			if err = applyLabelMocks(confSpec, resourceRoot, resourceLabelsToPaths, remainingMocks); err != nil {
+				return confs, err
 			}
-			delete(remainingMocks, k)
+		}
+		extraMgrWrapper := manager.NewResourceConfig()
+		if err = resourceRoot.Decode(&extraMgrWrapper); err != nil {
+			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+		}
+		if err = mgrWrapper.AddFrom(&extraMgrWrapper); err != nil {
+			return confs, fmt.Errorf("failed to merge resources from '%v': %v", path, err)
 		}
 	}
 
+	if len(remainingMocks) > 0 {
+		var missing []string
+		for k := range remainingMocks {
+			missing = append(missing, k)
+		}
+		sort.Strings(missing)
+		return confs, fmt.Errorf("mock for label '%v' could not be applied as the label was not found in the test target file or any resource files", missing[0])
+	}
+
+	confs.mgr = mgrWrapper
+
 	var pathSlice []string
 	if strings.HasPrefix(procPath, "/") {
 		if pathSlice, err = gabs.JSONPointerToSlice(procPath); err != nil {
 			return confs, fmt.Errorf("failed to parse case processors path '%v': %w", procPath, err)
 		}
 	} else {
-		if len(labelsToPaths) == 0 {
-			confSpec.YAMLLabelsToPaths(docs.DeprecatedProvider, root, labelsToPaths, nil)
-		}
 		if pathSlice, exists = labelsToPaths[procPath]; !exists {
 			return confs, fmt.Errorf("target for label '%v' failed as the label was not found in the test target file, it is not currently possible to target resources imported separate to the test file", procPath)
 		}
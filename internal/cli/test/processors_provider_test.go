@@ -291,6 +291,73 @@ pipeline:
 	assert.Equal(t, "starts with first mock first proc second mock second proc", string(msgs[0].Get(0).Get()))
 }
 
+func TestProcessorsProviderMocksResources(t *testing.T) {
+	files := map[string]string{
+		"config1.yaml": `
+pipeline:
+  processors:
+    - resource: fetch_api
+    - cache:
+        resource: lookup
+        operator: get
+        key: ${! content() }
+
+cache_resources:
+  - label: lookup
+    redis:
+      url: tcp://localhost:6379
+`,
+		"resources.yaml": `
+processor_resources:
+  - label: fetch_api
+    http:
+      url: http://example.com/lookup
+      verb: GET
+`,
+	}
+
+	testDir, err := initTestFiles(t, files)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.RemoveAll(testDir)
+	})
+
+	mocks := map[string]yaml.Node{}
+	require.NoError(t, yaml.Unmarshal([]byte(`
+fetch_api:
+  bloblang: 'root = "key_" + content().string()'
+lookup:
+  memory:
+    init_values:
+      key_foo: canned response
+`), &mocks))
+
+	provider := test.NewProcessorsProvider(
+		filepath.Join(testDir, "config1.yaml"),
+		test.OptAddResourcesPaths([]string{filepath.Join(testDir, "resources.yaml")}),
+	)
+	procs, err := provider.Provide("/pipeline/processors", nil, mocks)
+	require.NoError(t, err)
+	require.Len(t, procs, 2)
+
+	msgs, res := processor.ExecuteAll(procs, message.QuickBatch([][]byte{[]byte("foo")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+
+	assert.Equal(t, "canned response", string(msgs[0].Get(0).Get()))
+
+	require.NoError(t, yaml.Unmarshal([]byte(`
+not_a_label:
+  bloblang: 'root = "nope"'
+`), &mocks))
+
+	_, err = provider.Provide("/pipeline/processors", nil, mocks)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mock for label 'not_a_label' could not be applied")
+}
+
 func TestProcessorsExtraResources(t *testing.T) {
 	files := map[string]string{
 		"resources1.yaml": `
@@ -390,3 +457,28 @@ pipeline:
 	_, err = provider.Provide("/pipeline/processors", nil, nil)
 	require.EqualError(t, err, "failed to initialise resources: cache resource label 'barcache' collides with a previously defined resource")
 }
+
+func TestProcessorsProviderBloblang(t *testing.T) {
+	testDir, err := initTestFiles(t, map[string]string{
+		"good.blobl": `root.foo = this.foo.uppercase()`,
+		"bad.blobl": `root.foo = this.foo.uppercase()
+root.bar = this.bar.nope()`,
+	})
+	require.NoError(t, err)
+
+	provider := test.NewProcessorsProvider(filepath.Join(testDir, "foo_test.yaml"))
+
+	procs, err := provider.ProvideBloblang("good.blobl")
+	require.NoError(t, err)
+	require.Len(t, procs, 1)
+
+	msgs, res := procs[0].ProcessMessage(message.QuickBatch([][]byte{[]byte(`{"foo":"hello"}`)}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, `{"foo":"HELLO"}`, string(msgs[0].Get(0).Get()))
+
+	_, err = provider.ProvideBloblang("bad.blobl")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad.blobl: line 2 char 21: unrecognised method 'nope'")
+	assert.Contains(t, err.Error(), "2 | root.bar = this.bar.nope()")
+}
@@ -0,0 +1,42 @@
+package component
+
+import "sync"
+
+// ConnectionErrorer is implemented by components that are able to report the
+// most recent error encountered whilst attempting to connect to their target.
+type ConnectionErrorer interface {
+	// ConnectionError returns the most recent error encountered whilst
+	// connecting, or nil if the last connection attempt was successful.
+	ConnectionError() error
+}
+
+// ConnectionErrorOf returns the most recent connection error of a component,
+// or nil if the component has not failed to connect or does not support
+// reporting connection errors.
+func ConnectionErrorOf(c interface{}) error {
+	if ce, ok := c.(ConnectionErrorer); ok {
+		return ce.ConnectionError()
+	}
+	return nil
+}
+
+// LastError is a thread safe container for the most recent error encountered by
+// a component.
+type LastError struct {
+	mut sync.RWMutex
+	err error
+}
+
+// Set the last error, a nil error clears it.
+func (l *LastError) Set(err error) {
+	l.mut.Lock()
+	l.err = err
+	l.mut.Unlock()
+}
+
+// Get the last error, or nil if there isn't one.
+func (l *LastError) Get() error {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+	return l.err
+}
@@ -20,6 +20,7 @@ import (
 // input.Async component.
 type AsyncReader struct {
 	connected   int32
+	connErr     component.LastError
 	connBackoff backoff.BackOff
 
 	allowSkipAcks bool
@@ -72,6 +73,8 @@ func (r *AsyncReader) loop() {
 		mFailedConn = r.stats.GetCounter("input_connection_failed")
 		mLostConn   = r.stats.GetCounter("input_connection_lost")
 		mLatency    = r.stats.GetTimer("input_latency_ns")
+		mInFlight   = r.stats.GetGauge("input_in_flight")
+		mBatchSize  = r.stats.GetHistogram("input_batch_size")
 	)
 
 	defer func() {
@@ -108,6 +111,7 @@ func (r *AsyncReader) loop() {
 				}
 				r.log.Errorf("Failed to connect to %v: %v\n", r.typeStr, err)
 				mFailedConn.Incr(1)
+				r.connErr.Set(err)
 				select {
 				case <-time.After(r.connBackoff.NextBackOff()):
 				case <-initConnCtx.Done():
@@ -115,6 +119,7 @@ func (r *AsyncReader) loop() {
 				}
 			} else {
 				r.connBackoff.Reset()
+				r.connErr.Set(nil)
 				return true
 			}
 		}
@@ -161,11 +166,16 @@ func (r *AsyncReader) loop() {
 		} else {
 			r.connBackoff.Reset()
 			mRcvd.Incr(int64(msg.Len()))
+			mBatchSize.Observe(int64(msg.Len()))
 			r.log.Tracef("Consumed %v messages from '%v'.\n", msg.Len(), r.typeStr)
 		}
 
 		startedAt := time.Now()
 
+		// The batch belongs to downstream components once it has been sent,
+		// and so the size is captured beforehand.
+		inFlight := int64(msg.Len())
+
 		resChan := make(chan error)
 		tracing.InitSpans("input_"+r.typeStr, msg)
 		select {
@@ -175,12 +185,16 @@ func (r *AsyncReader) loop() {
 		}
 
 		pendingAcks.Add(1)
+		mInFlight.Incr(inFlight)
 		go func(
 			m *message.Batch,
 			aFn AsyncAckFn,
 			rChan chan error,
 		) {
-			defer pendingAcks.Done()
+			defer func() {
+				mInFlight.Decr(inFlight)
+				pendingAcks.Done()
+			}()
 
 			var res error
 			var open bool
@@ -218,6 +232,12 @@ func (r *AsyncReader) Connected() bool {
 	return atomic.LoadInt32(&r.connected) == 1
 }
 
+// ConnectionError returns the most recent error encountered whilst connecting
+// to the target, or nil if the last attempt was successful.
+func (r *AsyncReader) ConnectionError() error {
+	return r.connErr.Get()
+}
+
 // CloseAsync shuts down the AsyncReader input and stops processing requests.
 func (r *AsyncReader) CloseAsync() {
 	r.shutSig.CloseAtLeisure()
@@ -816,3 +816,53 @@ func benchmarkAsyncReaderGenerateN(b *testing.B, capacity int) {
 		}
 	}
 }
+
+func TestAsyncReaderInFlightAndBatchSize(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	readerImpl := newMockAsyncReader()
+	readerImpl.msgsToSnd = []*message.Batch{message.QuickBatch([][]byte{
+		[]byte("foo"), []byte("bar"),
+	})}
+
+	stats := metrics.NewLocal()
+	r, err := NewAsyncReader(
+		"foo", true, readerImpl,
+		log.Noop(), metrics.NewNamespaced(stats),
+	)
+	require.NoError(t, err)
+
+	go func() {
+		readerImpl.connChan <- nil
+		readerImpl.readChan <- nil
+		readerImpl.ackChan <- nil
+	}()
+
+	var ts message.Transaction
+	select {
+	case ts = <-r.TransactionChan():
+	case <-tCtx.Done():
+		t.Fatal("Timed out")
+	}
+
+	assert.Eventually(t, func() bool {
+		return stats.GetCounters()["input_in_flight"] == 2
+	}, time.Second, time.Millisecond*10)
+
+	batchSize := stats.GetTimings()["input_batch_size"]
+	require.NotNil(t, batchSize)
+	assert.Equal(t, int64(1), batchSize.Count())
+	assert.Equal(t, int64(2), batchSize.Max())
+
+	require.NoError(t, ts.Ack(tCtx, nil))
+
+	assert.Eventually(t, func() bool {
+		return stats.GetCounters()["input_in_flight"] == 0
+	}, time.Second, time.Millisecond*10)
+
+	r.CloseAsync()
+	close(readerImpl.readChan)
+	close(readerImpl.connChan)
+	require.NoError(t, r.WaitForClose(time.Second))
+}
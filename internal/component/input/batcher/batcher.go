@@ -170,6 +170,12 @@ func (m *Impl) Connected() bool {
 	return m.child.Connected()
 }
 
+// ConnectionError returns the most recent connection error of the wrapped
+// component.
+func (m *Impl) ConnectionError() error {
+	return component.ConnectionErrorOf(m.child)
+}
+
 // TransactionChan returns the channel used for consuming messages from this
 // buffer.
 func (m *Impl) TransactionChan() <-chan message.Transaction {
@@ -3,6 +3,7 @@ package input
 import (
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -57,6 +58,12 @@ func (i *WithPipeline) Connected() bool {
 	return i.in.Connected()
 }
 
+// ConnectionError returns the most recent connection error of the wrapped
+// component.
+func (i *WithPipeline) ConnectionError() error {
+	return component.ConnectionErrorOf(i.in)
+}
+
 //------------------------------------------------------------------------------
 
 // CloseAsync triggers a closure of this object but does not block.
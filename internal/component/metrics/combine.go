@@ -61,6 +61,16 @@ func (c *combinedTimer) Timing(delta int64) {
 	c.c2.Timing(delta)
 }
 
+type combinedHistogram struct {
+	c1 StatHistogram
+	c2 StatHistogram
+}
+
+func (c *combinedHistogram) Observe(value int64) {
+	c.c1.Observe(value)
+	c.c2.Observe(value)
+}
+
 type combinedGauge struct {
 	c1 StatGauge
 	c2 StatGauge
@@ -107,6 +117,18 @@ func (c *combinedTimerVec) With(labelValues ...string) StatTimer {
 	}
 }
 
+type combinedHistogramVec struct {
+	c1 StatHistogramVec
+	c2 StatHistogramVec
+}
+
+func (c *combinedHistogramVec) With(labelValues ...string) StatHistogram {
+	return &combinedHistogram{
+		c1: c.c1.With(labelValues...),
+		c2: c.c2.With(labelValues...),
+	}
+}
+
 type combinedGaugeVec struct {
 	c1 StatGaugeVec
 	c2 StatGaugeVec
@@ -149,6 +171,20 @@ func (c *combinedWrapper) GetTimerVec(path string, n ...string) StatTimerVec {
 	}
 }
 
+func (c *combinedWrapper) GetHistogram(path string) StatHistogram {
+	return &combinedHistogram{
+		c1: c.t1.GetHistogram(path),
+		c2: c.t2.GetHistogram(path),
+	}
+}
+
+func (c *combinedWrapper) GetHistogramVec(path string, n ...string) StatHistogramVec {
+	return &combinedHistogramVec{
+		c1: c.t1.GetHistogramVec(path, n...),
+		c2: c.t2.GetHistogramVec(path, n...),
+	}
+}
+
 func (c *combinedWrapper) GetGauge(path string) StatGauge {
 	return &combinedGauge{
 		c1: c.t1.GetGauge(path),
@@ -14,6 +14,7 @@ type Config struct {
 	Type          string           `json:"type" yaml:"type"`
 	Mapping       string           `json:"mapping" yaml:"mapping"`
 	AWSCloudWatch CloudWatchConfig `json:"aws_cloudwatch" yaml:"aws_cloudwatch"`
+	Datadog       DatadogConfig    `json:"datadog" yaml:"datadog"`
 	JSONAPI       JSONAPIConfig    `json:"json_api" yaml:"json_api"`
 	InfluxDB      InfluxDBConfig   `json:"influxdb" yaml:"influxdb"`
 	None          struct{}         `json:"none" yaml:"none"`
@@ -28,6 +29,7 @@ func NewConfig() Config {
 		Type:          docs.DefaultTypeOf(docs.TypeMetrics),
 		Mapping:       "",
 		AWSCloudWatch: NewCloudWatchConfig(),
+		Datadog:       NewDatadogConfig(),
 		JSONAPI:       NewJSONAPIConfig(),
 		InfluxDB:      NewInfluxDBConfig(),
 		None:          struct{}{},
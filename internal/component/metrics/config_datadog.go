@@ -0,0 +1,25 @@
+package metrics
+
+// DatadogConfig is config for the Datadog metrics type.
+type DatadogConfig struct {
+	Address         string            `json:"address" yaml:"address"`
+	Namespace       string            `json:"namespace" yaml:"namespace"`
+	Tags            map[string]string `json:"tags" yaml:"tags"`
+	TimingType      string            `json:"timing_type" yaml:"timing_type"`
+	TimingUnits     string            `json:"timing_units" yaml:"timing_units"`
+	OriginDetection bool              `json:"origin_detection" yaml:"origin_detection"`
+	FlushPeriod     string            `json:"flush_period" yaml:"flush_period"`
+}
+
+// NewDatadogConfig creates an DatadogConfig struct with default values.
+func NewDatadogConfig() DatadogConfig {
+	return DatadogConfig{
+		Address:         "localhost:8125",
+		Namespace:       "",
+		Tags:            map[string]string{},
+		TimingType:      "distribution",
+		TimingUnits:     "ms",
+		OriginDetection: true,
+		FlushPeriod:     "100ms",
+	}
+}
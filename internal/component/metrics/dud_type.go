@@ -14,6 +14,9 @@ func (d DudStat) Decr(count int64) {}
 // Timing does nothing.
 func (d DudStat) Timing(delta int64) {}
 
+// Observe does nothing.
+func (d DudStat) Observe(value int64) {}
+
 // Set does nothing.
 func (d DudStat) Set(value int64) {}
 
@@ -50,6 +53,18 @@ func (d DudType) GetTimerVec(path string, n ...string) StatTimerVec {
 	})
 }
 
+// GetHistogram returns a DudStat.
+func (d DudType) GetHistogram(path string) StatHistogram {
+	return DudStat{}
+}
+
+// GetHistogramVec returns a DudStat.
+func (d DudType) GetHistogramVec(path string, n ...string) StatHistogramVec {
+	return FakeHistogramVec(func(...string) StatHistogram {
+		return DudStat{}
+	})
+}
+
 // GetGauge returns a DudStat.
 func (d DudType) GetGauge(path string) StatGauge {
 	return DudStat{}
@@ -49,6 +49,12 @@ func (l *LocalTiming) Timing(delta int64) {
 	l.lock.Unlock()
 }
 
+// Observe records a histogram value. Histograms are stored alongside timings
+// as the underlying timer records raw values.
+func (l *LocalTiming) Observe(value int64) {
+	l.Timing(value)
+}
+
 //------------------------------------------------------------------------------
 
 // Local is a metrics aggregator that stores metrics locally.
@@ -139,6 +145,9 @@ func createLabelledPath(name string, tagNames, tagValues []string) string {
 		for k, v := range tagNames {
 			tags[v] = tagValues[k]
 		}
+
+		// Sort a copy as the names slice is often reused across calls.
+		tagNames = append([]string(nil), tagNames...)
 		sort.Strings(tagNames)
 
 		b.WriteByte('{')
@@ -192,6 +201,11 @@ func (l *Local) GetTimer(path string) StatTimer {
 	return l.GetTimerVec(path).With()
 }
 
+// GetHistogram returns a stat histogram object for a path.
+func (l *Local) GetHistogram(path string) StatHistogram {
+	return l.GetHistogramVec(path).With()
+}
+
 // GetGauge returns a stat gauge object for a path.
 func (l *Local) GetGauge(path string) StatGauge {
 	return l.GetGaugeVec(path).With()
@@ -230,6 +244,22 @@ func (l *Local) GetTimerVec(path string, k ...string) StatTimerVec {
 	})
 }
 
+// GetHistogramVec returns a stat histogram object for a path with the labels
+// and values, histograms are included in the results of GetTimings.
+func (l *Local) GetHistogramVec(path string, k ...string) StatHistogramVec {
+	return FakeHistogramVec(func(v ...string) StatHistogram {
+		newPath := createLabelledPath(path, k, v)
+		l.mut.Lock()
+		st, exists := l.flatTimings[newPath]
+		if !exists {
+			st = &LocalTiming{t: metrics.NewTimer()}
+			l.flatTimings[newPath] = st
+		}
+		l.mut.Unlock()
+		return st
+	})
+}
+
 // GetGaugeVec returns a stat timer object for a path with the labels
 // discarded.
 func (l *Local) GetGaugeVec(path string, k ...string) StatGaugeVec {
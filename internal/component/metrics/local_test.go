@@ -90,6 +90,20 @@ func TestCounter(t *testing.T) {
 	assert.Equal(t, expTimingAvgs, actTimingAvgs)
 }
 
+func TestCounterVecUnsortedLabels(t *testing.T) {
+	nm := NewLocal()
+
+	ctr := nm.GetCounterVec("counter", "zzz", "aaa")
+	ctr.With("foo", "bar").Incr(1)
+	ctr.With("foo", "bar").Incr(2)
+	ctr.With("baz", "buz").Incr(3)
+
+	assert.Equal(t, map[string]int64{
+		"counter{aaa=\"bar\",zzz=\"foo\"}": 3,
+		"counter{aaa=\"buz\",zzz=\"baz\"}": 3,
+	}, nm.GetCounters())
+}
+
 func TestReverseName(t *testing.T) {
 	tests := map[string]struct {
 		input     string
@@ -3,6 +3,8 @@ package metrics
 import (
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
@@ -51,9 +53,34 @@ func (m *Mapping) WithStaticVars(kvs map[string]interface{}) *Mapping {
 	return &newM
 }
 
+// dynamicLabel describes a label whose value is only known at the time that a
+// metric is emitted. The index refers to the position of the label value
+// within the original list of label names.
+type dynamicLabel struct {
+	name  string
+	index int
+}
+
+// dynamicLabelPrefix is used to construct placeholder metadata values for
+// dynamic labels, which allows a mapping to rename or delete them without
+// knowing their values.
+const dynamicLabelPrefix = "\x00benthos_dynamic_label_"
+
+// isDerivedFromDynamic returns true if a value produced by a mapping contains
+// all or part of the placeholder of a dynamic label, which is the case when a
+// mapping attempts to derive a static value from a dynamic one.
+func isDerivedFromDynamic(v string) bool {
+	return strings.Contains(v, "\x00")
+}
+
 func (m *Mapping) mapPath(path string, labelNames, labelValues []string) (outPath string, outLabelNames, outLabelValues []string) {
+	outPath, outLabelNames, outLabelValues, _ = m.mapPathWithDynamic(path, labelNames, labelValues, nil)
+	return
+}
+
+func (m *Mapping) mapPathWithDynamic(path string, labelNames, labelValues []string, dynLabels []dynamicLabel) (outPath string, outLabelNames, outLabelValues []string, outDynLabels []dynamicLabel) {
 	if m == nil || m.m == nil {
-		return path, labelNames, labelValues
+		return path, labelNames, labelValues, dynLabels
 	}
 
 	part := message.NewPart(nil)
@@ -61,6 +88,9 @@ func (m *Mapping) mapPath(path string, labelNames, labelValues []string) (outPat
 	for i, v := range labelNames {
 		part.MetaSet(v, labelValues[i])
 	}
+	for _, l := range dynLabels {
+		part.MetaSet(l.name, dynamicLabelPrefix+strconv.Itoa(l.index))
+	}
 	msg := message.QuickBatch(nil)
 	msg.Append(part)
 
@@ -85,13 +115,29 @@ func (m *Mapping) mapPath(path string, labelNames, labelValues []string) (outPat
 		Value: &v,
 	}); err != nil {
 		m.logger.Errorf("Failed to apply path mapping on '%v': %v\n", path, err)
-		return path, nil, nil
+		return path, nil, nil, dynLabels
 	}
 
+	var derivedLabel string
 	_ = outPart.MetaIter(func(k, v string) error {
+		if strings.HasPrefix(v, dynamicLabelPrefix) {
+			if i, err := strconv.Atoi(strings.TrimPrefix(v, dynamicLabelPrefix)); err == nil {
+				outDynLabels = append(outDynLabels, dynamicLabel{name: k, index: i})
+				return nil
+			}
+		}
+		if isDerivedFromDynamic(v) {
+			derivedLabel = k
+		}
 		outLabelNames = append(outLabelNames, k)
 		return nil
 	})
+	if derivedLabel != "" {
+		// The values of dynamic labels are only known when the metric is
+		// emitted, and therefore a mapping can only rename or delete them.
+		m.logger.Errorf("Failed to apply path mapping on '%v': label '%v' has a value derived from a dynamic label, which is not supported, dynamic labels can only be renamed or deleted\n", path, derivedLabel)
+		return path, labelNames, labelValues, dynLabels
+	}
 	if len(outLabelNames) > 0 {
 		sort.Strings(outLabelNames)
 		for _, k := range outLabelNames {
@@ -100,20 +146,27 @@ func (m *Mapping) mapPath(path string, labelNames, labelValues []string) (outPat
 			outLabelValues = append(outLabelValues, v)
 		}
 	}
+	sort.Slice(outDynLabels, func(i, j int) bool {
+		return outDynLabels[i].index < outDynLabels[j].index
+	})
 
 	switch t := v.(type) {
 	case query.Delete:
 		m.logger.Tracef("Deleting metrics path: %v\n", path)
-		return "", nil, nil
+		return "", nil, nil, nil
 	case query.Nothing:
 		m.logger.Tracef("Metrics path '%v' registered unchanged.\n", path)
 		outPath = path
 		return
 	case string:
+		if isDerivedFromDynamic(t) {
+			m.logger.Errorf("Failed to apply path mapping on '%v': the new path is derived from a dynamic label, which is not supported\n", path)
+			return path, labelNames, labelValues, dynLabels
+		}
 		m.logger.Tracef("Updated metrics path '%v' to: %v\n", path, t)
 		outPath = t
 		return
 	}
 	m.logger.Errorf("Path mapping returned invalid result, expected string, found %T\n", v)
-	return path, labelNames, labelValues
+	return path, labelNames, labelValues, dynLabels
 }
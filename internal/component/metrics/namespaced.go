@@ -76,7 +76,7 @@ func (n *Namespaced) HandlerFunc() http.HandlerFunc {
 
 //------------------------------------------------------------------------------
 
-func (n *Namespaced) getPathAndLabels(path string) (newPath string, labelKeys, labelValues []string) {
+func (n *Namespaced) getPathAndLabels(path string, dynNames ...string) (newPath string, labelKeys, labelValues []string, dynLabels []dynamicLabel) {
 	newPath = path
 	if n.labels != nil && len(n.labels) > 0 {
 		labelKeys = make([]string, 0, len(n.labels))
@@ -89,55 +89,101 @@ func (n *Namespaced) getPathAndLabels(path string) (newPath string, labelKeys, l
 			labelValues = append(labelValues, n.labels[k])
 		}
 	}
+	for i, name := range dynNames {
+		dynLabels = append(dynLabels, dynamicLabel{name: name, index: i})
+	}
 	for _, mapping := range n.mappings {
-		if newPath, labelKeys, labelValues = mapping.mapPath(newPath, labelKeys, labelValues); newPath == "" {
+		if newPath, labelKeys, labelValues, dynLabels = mapping.mapPathWithDynamic(newPath, labelKeys, labelValues, dynLabels); newPath == "" {
 			return
 		}
 	}
 	return
 }
 
-type counterVecWithStatic struct {
+// vecLabels describes how the label values provided to a vector stat should be
+// transformed before being passed to the child, which involves prefixing
+// static values and selecting the dynamic values that survived mappings.
+type vecLabels struct {
 	staticValues []string
-	child        StatCounterVec
+	dynIndexes   []int
+}
+
+func newVecLabels(dynNames []string, staticKeys, staticValues []string, dynLabels []dynamicLabel) (names []string, labels *vecLabels) {
+	names = make([]string, 0, len(staticKeys)+len(dynLabels))
+	names = append(names, staticKeys...)
+
+	unchanged := len(staticKeys) == 0 && len(dynLabels) == len(dynNames)
+	dynIndexes := make([]int, 0, len(dynLabels))
+	for i, l := range dynLabels {
+		names = append(names, l.name)
+		dynIndexes = append(dynIndexes, l.index)
+		if l.index != i || l.name != dynNames[i] {
+			unchanged = false
+		}
+	}
+	if unchanged {
+		return dynNames, nil
+	}
+	return names, &vecLabels{
+		staticValues: staticValues,
+		dynIndexes:   dynIndexes,
+	}
+}
+
+func (v *vecLabels) values(values []string) []string {
+	newValues := make([]string, 0, len(v.staticValues)+len(v.dynIndexes))
+	newValues = append(newValues, v.staticValues...)
+	for _, i := range v.dynIndexes {
+		if i < len(values) {
+			newValues = append(newValues, values[i])
+		} else {
+			newValues = append(newValues, "")
+		}
+	}
+	return newValues
+}
+
+type counterVecWithStatic struct {
+	labels *vecLabels
+	child  StatCounterVec
 }
 
 func (c *counterVecWithStatic) With(values ...string) StatCounter {
-	newValues := make([]string, 0, len(c.staticValues)+len(values))
-	newValues = append(newValues, c.staticValues...)
-	newValues = append(newValues, values...)
-	return c.child.With(newValues...)
+	return c.child.With(c.labels.values(values)...)
 }
 
 type timerVecWithStatic struct {
-	staticValues []string
-	child        StatTimerVec
+	labels *vecLabels
+	child  StatTimerVec
 }
 
 func (c *timerVecWithStatic) With(values ...string) StatTimer {
-	newValues := make([]string, 0, len(c.staticValues)+len(values))
-	newValues = append(newValues, c.staticValues...)
-	newValues = append(newValues, values...)
-	return c.child.With(newValues...)
+	return c.child.With(c.labels.values(values)...)
+}
+
+type histogramVecWithStatic struct {
+	labels *vecLabels
+	child  StatHistogramVec
+}
+
+func (c *histogramVecWithStatic) With(values ...string) StatHistogram {
+	return c.child.With(c.labels.values(values)...)
 }
 
 type gaugeVecWithStatic struct {
-	staticValues []string
-	child        StatGaugeVec
+	labels *vecLabels
+	child  StatGaugeVec
 }
 
 func (c *gaugeVecWithStatic) With(values ...string) StatGauge {
-	newValues := make([]string, 0, len(c.staticValues)+len(values))
-	newValues = append(newValues, c.staticValues...)
-	newValues = append(newValues, values...)
-	return c.child.With(newValues...)
+	return c.child.With(c.labels.values(values)...)
 }
 
 //------------------------------------------------------------------------------
 
 // GetCounter returns an editable counter stat for a given path.
 func (n *Namespaced) GetCounter(path string) StatCounter {
-	path, labelKeys, labelValues := n.getPathAndLabels(path)
+	path, labelKeys, labelValues, _ := n.getPathAndLabels(path)
 	if path == "" {
 		return DudStat{}
 	}
@@ -151,19 +197,17 @@ func (n *Namespaced) GetCounter(path string) StatCounter {
 // these labels must be consistent with any other metrics registered on the same
 // path.
 func (n *Namespaced) GetCounterVec(path string, labelNames ...string) StatCounterVec {
-	path, staticKeys, staticValues := n.getPathAndLabels(path)
+	path, staticKeys, staticValues, dynLabels := n.getPathAndLabels(path, labelNames...)
 	if path == "" {
 		return FakeCounterVec(func(...string) StatCounter {
 			return DudStat{}
 		})
 	}
-	if len(staticKeys) > 0 {
-		newNames := make([]string, 0, len(staticKeys)+len(labelNames))
-		newNames = append(newNames, staticKeys...)
-		newNames = append(newNames, labelNames...)
+	newNames, labels := newVecLabels(labelNames, staticKeys, staticValues, dynLabels)
+	if labels != nil {
 		return &counterVecWithStatic{
-			staticValues: staticValues,
-			child:        n.child.GetCounterVec(path, newNames...),
+			labels: labels,
+			child:  n.child.GetCounterVec(path, newNames...),
 		}
 	}
 	return n.child.GetCounterVec(path, labelNames...)
@@ -171,7 +215,7 @@ func (n *Namespaced) GetCounterVec(path string, labelNames ...string) StatCounte
 
 // GetTimer returns an editable timer stat for a given path.
 func (n *Namespaced) GetTimer(path string) StatTimer {
-	path, labelKeys, labelValues := n.getPathAndLabels(path)
+	path, labelKeys, labelValues, _ := n.getPathAndLabels(path)
 	if path == "" {
 		return DudStat{}
 	}
@@ -185,27 +229,57 @@ func (n *Namespaced) GetTimer(path string) StatTimer {
 // these labels must be consistent with any other metrics registered on the same
 // path.
 func (n *Namespaced) GetTimerVec(path string, labelNames ...string) StatTimerVec {
-	path, staticKeys, staticValues := n.getPathAndLabels(path)
+	path, staticKeys, staticValues, dynLabels := n.getPathAndLabels(path, labelNames...)
 	if path == "" {
 		return FakeTimerVec(func(...string) StatTimer {
 			return DudStat{}
 		})
 	}
-	if len(staticKeys) > 0 {
-		newNames := make([]string, 0, len(staticKeys)+len(labelNames))
-		newNames = append(newNames, staticKeys...)
-		newNames = append(newNames, labelNames...)
+	newNames, labels := newVecLabels(labelNames, staticKeys, staticValues, dynLabels)
+	if labels != nil {
 		return &timerVecWithStatic{
-			staticValues: staticValues,
-			child:        n.child.GetTimerVec(path, newNames...),
+			labels: labels,
+			child:  n.child.GetTimerVec(path, newNames...),
 		}
 	}
 	return n.child.GetTimerVec(path, labelNames...)
 }
 
+// GetHistogram returns an editable histogram stat for a given path.
+func (n *Namespaced) GetHistogram(path string) StatHistogram {
+	path, labelKeys, labelValues, _ := n.getPathAndLabels(path)
+	if path == "" {
+		return DudStat{}
+	}
+	if len(labelKeys) > 0 {
+		return n.child.GetHistogramVec(path, labelKeys...).With(labelValues...)
+	}
+	return n.child.GetHistogram(path)
+}
+
+// GetHistogramVec returns an editable histogram stat for a given path with
+// labels, these labels must be consistent with any other metrics registered on
+// the same path.
+func (n *Namespaced) GetHistogramVec(path string, labelNames ...string) StatHistogramVec {
+	path, staticKeys, staticValues, dynLabels := n.getPathAndLabels(path, labelNames...)
+	if path == "" {
+		return FakeHistogramVec(func(...string) StatHistogram {
+			return DudStat{}
+		})
+	}
+	newNames, labels := newVecLabels(labelNames, staticKeys, staticValues, dynLabels)
+	if labels != nil {
+		return &histogramVecWithStatic{
+			labels: labels,
+			child:  n.child.GetHistogramVec(path, newNames...),
+		}
+	}
+	return n.child.GetHistogramVec(path, labelNames...)
+}
+
 // GetGauge returns an editable gauge stat for a given path.
 func (n *Namespaced) GetGauge(path string) StatGauge {
-	path, labelKeys, labelValues := n.getPathAndLabels(path)
+	path, labelKeys, labelValues, _ := n.getPathAndLabels(path)
 	if path == "" {
 		return DudStat{}
 	}
@@ -219,19 +293,17 @@ func (n *Namespaced) GetGauge(path string) StatGauge {
 // these labels must be consistent with any other metrics registered on the same
 // path.
 func (n *Namespaced) GetGaugeVec(path string, labelNames ...string) StatGaugeVec {
-	path, staticKeys, staticValues := n.getPathAndLabels(path)
+	path, staticKeys, staticValues, dynLabels := n.getPathAndLabels(path, labelNames...)
 	if path == "" {
 		return FakeGaugeVec(func(...string) StatGauge {
 			return DudStat{}
 		})
 	}
-	if len(staticKeys) > 0 {
-		newNames := make([]string, 0, len(staticKeys)+len(labelNames))
-		newNames = append(newNames, staticKeys...)
-		newNames = append(newNames, labelNames...)
+	newNames, labels := newVecLabels(labelNames, staticKeys, staticValues, dynLabels)
+	if labels != nil {
 		return &gaugeVecWithStatic{
-			staticValues: staticValues,
-			child:        n.child.GetGaugeVec(path, newNames...),
+			labels: labels,
+			child:  n.child.GetGaugeVec(path, newNames...),
 		}
 	}
 	return n.child.GetGaugeVec(path, labelNames...)
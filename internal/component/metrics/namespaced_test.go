@@ -252,3 +252,59 @@ root = this.replace_all("bar","baz")`, log.Noop())
 	assert.Contains(t, body, "\ngaugetwo{extra1=\"extravalue1\",extra2=\"extravalue2\",label2=\"value3\",static1=\"sbaz1\"} 12")
 	assert.Contains(t, body, "\ntimertwo_sum{extra1=\"extravalue1\",extra2=\"extravalue2\",label3=\"value4\",label4=\"value5\",static1=\"sbaz1\"} 1.3e-08")
 }
+
+func TestNamespacedDynamicLabelsWithMappings(t *testing.T) {
+	prom, handler := getTestProm(t)
+
+	mapping, err := metrics.NewMapping(`meta renamed = meta("label1") | deleted()
+meta label1 = deleted()
+meta label3 = deleted()`, log.Noop())
+	require.NoError(t, err)
+
+	nm := metrics.NewNamespaced(prom).WithLabels("static1", "svalue1")
+	nm = nm.WithMapping(mapping)
+
+	ctr := nm.GetCounterVec("counter", "label1", "label2")
+	ctr.With("value1", "value2").Incr(10)
+	ctr.With("value3", "value4").Incr(11)
+
+	gge := nm.GetGaugeVec("gauge", "label3")
+	gge.With("value5").Set(12)
+	gge.With("value6").Set(13)
+
+	tmr := nm.GetTimerVec("timer", "label3", "label4")
+	tmr.With("value7", "value8").Timing(13)
+	tmr.With("value9", "value8").Timing(13)
+
+	body := getPage(t, handler)
+
+	assert.Contains(t, body, "\ncounter{label2=\"value2\",renamed=\"value1\",static1=\"svalue1\"} 10")
+	assert.Contains(t, body, "\ncounter{label2=\"value4\",renamed=\"value3\",static1=\"svalue1\"} 11")
+	assert.Contains(t, body, "\ngauge{static1=\"svalue1\"} 13")
+	assert.Contains(t, body, "\ntimer_sum{label4=\"value8\",static1=\"svalue1\"} 2.6e-08")
+}
+
+func TestNamespacedDynamicLabelsDerivedValues(t *testing.T) {
+	for _, mapping := range []string{
+		`meta topic = "kafka_" + meta("label1")`,
+		`meta label1 = meta("label1").uppercase()`,
+		`root = this + "_" + meta("label2")`,
+	} {
+		prom, handler := getTestProm(t)
+
+		m, err := metrics.NewMapping(mapping, log.Noop())
+		require.NoError(t, err)
+
+		nm := metrics.NewNamespaced(prom).WithLabels("static1", "svalue1")
+		nm = nm.WithMapping(m)
+
+		ctr := nm.GetCounterVec("counter", "label1", "label2")
+		ctr.With("value1", "value2").Incr(10)
+
+		body := getPage(t, handler)
+
+		assert.Contains(t, body, "\ncounter{label1=\"value1\",label2=\"value2\",static1=\"svalue1\"} 10", mapping)
+		assert.NotContains(t, body, "benthos_dynamic_label", mapping)
+		assert.NotContains(t, body, "BENTHOS_DYNAMIC_LABEL", mapping)
+	}
+}
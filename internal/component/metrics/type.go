@@ -19,6 +19,15 @@ type StatTimer interface {
 	Timing(delta int64)
 }
 
+// StatHistogram is a representation of a single histogram metric stat, which
+// records the distribution of unitless values such as the sizes of batches.
+// Unlike timers the values are never scaled by exporters. Interactions with
+// this stat are thread safe.
+type StatHistogram interface {
+	// Observe records a value.
+	Observe(value int64)
+}
+
 // StatGauge is a representation of a single gauge metric stat. Interactions
 // with this stat are thread safe.
 type StatGauge interface {
@@ -46,6 +55,12 @@ type StatTimerVec interface {
 	With(labelValues ...string) StatTimer
 }
 
+// StatHistogramVec creates StatHistograms with dynamic labels.
+type StatHistogramVec interface {
+	// With returns a StatHistogram with a set of label values.
+	With(labelValues ...string) StatHistogram
+}
+
 // StatGaugeVec creates StatGauges with dynamic labels.
 type StatGaugeVec interface {
 	// With returns a StatGauge with a set of label values.
@@ -72,6 +87,14 @@ type Type interface {
 	// same path.
 	GetTimerVec(path string, labelNames ...string) StatTimerVec
 
+	// GetHistogram returns an editable histogram stat for a given path.
+	GetHistogram(path string) StatHistogram
+
+	// GetHistogramVec returns an editable histogram stat for a given path with
+	// labels, these labels must be consistent with any other metrics
+	// registered on the same path.
+	GetHistogramVec(path string, labelNames ...string) StatHistogramVec
+
 	// GetGauge returns an editable gauge stat for a given path.
 	GetGauge(path string) StatGauge
 
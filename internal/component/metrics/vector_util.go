@@ -34,6 +34,23 @@ func FakeTimerVec(f func(...string) StatTimer) StatTimerVec {
 
 //------------------------------------------------------------------------------
 
+type fHistogramVec struct {
+	f func(...string) StatHistogram
+}
+
+func (f *fHistogramVec) With(labels ...string) StatHistogram {
+	return f.f(labels...)
+}
+
+// FakeHistogramVec returns a histogram vec implementation that ignores labels.
+func FakeHistogramVec(f func(...string) StatHistogram) StatHistogramVec {
+	return &fHistogramVec{
+		f: f,
+	}
+}
+
+//------------------------------------------------------------------------------
+
 type fGaugeVec struct {
 	f func(...string) StatGauge
 }
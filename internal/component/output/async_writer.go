@@ -44,6 +44,7 @@ type AsyncSink interface {
 // AsyncWriter is an output type that writes messages to a writer.Type.
 type AsyncWriter struct {
 	isConnected int32
+	connErr     component.LastError
 
 	typeStr     string
 	maxInflight int
@@ -150,6 +151,8 @@ func (w *AsyncWriter) loop() {
 		mConn       = w.stats.GetCounter("output_connection_up")
 		mFailedConn = w.stats.GetCounter("output_connection_failed")
 		mLostConn   = w.stats.GetCounter("output_connection_lost")
+		mInFlight   = w.stats.GetGauge("output_in_flight")
+		mBatchSize  = w.stats.GetHistogram("output_batch_size")
 	)
 
 	defer func() {
@@ -178,6 +181,7 @@ func (w *AsyncWriter) loop() {
 				}
 				w.log.Errorf("Failed to connect to %v: %v\n", w.typeStr, err)
 				mFailedConn.Incr(1)
+				w.connErr.Set(err)
 				select {
 				case <-time.After(connBackoff.NextBackOff()):
 				case <-initConnCtx.Done():
@@ -185,6 +189,7 @@ func (w *AsyncWriter) loop() {
 				}
 			} else {
 				connBackoff.Reset()
+				w.connErr.Set(nil)
 				return true
 			}
 		}
@@ -251,6 +256,9 @@ func (w *AsyncWriter) loop() {
 			spans := tracing.CreateChildSpans("output_"+w.typeStr, ts.Payload)
 			ts.Payload = w.injectSpans(ts.Payload, spans)
 
+			inFlight := int64(ts.Payload.Len())
+			mBatchSize.Observe(inFlight)
+			mInFlight.Incr(inFlight)
 			latency, err := w.latencyMeasuringWrite(ts.Payload)
 
 			// If our writer says it is not connected.
@@ -259,6 +267,7 @@ func (w *AsyncWriter) loop() {
 			} else if err != nil {
 				mError.Incr(1)
 			}
+			mInFlight.Decr(inFlight)
 
 			// Close immediately if our writer is closed.
 			if err == component.ErrTypeClosed {
@@ -310,6 +319,12 @@ func (w *AsyncWriter) Connected() bool {
 	return atomic.LoadInt32(&w.isConnected) == 1
 }
 
+// ConnectionError returns the most recent error encountered whilst connecting
+// to the target, or nil if the last attempt was successful.
+func (w *AsyncWriter) ConnectionError() error {
+	return w.connErr.Get()
+}
+
 // CloseAsync shuts down the File output and stops processing messages.
 func (w *AsyncWriter) CloseAsync() {
 	w.shutSig.CloseAtLeisure()
@@ -170,6 +170,12 @@ func (m *Impl) Connected() bool {
 	return m.child.Connected()
 }
 
+// ConnectionError returns the most recent connection error of the wrapped
+// component.
+func (m *Impl) ConnectionError() error {
+	return component.ConnectionErrorOf(m.child)
+}
+
 // Consume assigns a messages channel for the output to read.
 func (m *Impl) Consume(msgs <-chan message.Transaction) error {
 	if m.messagesIn != nil {
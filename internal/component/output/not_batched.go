@@ -152,6 +152,12 @@ func (n *notBatchedOutput) Connected() bool {
 	return n.out.Connected()
 }
 
+// ConnectionError returns the most recent connection error of the wrapped
+// component.
+func (n *notBatchedOutput) ConnectionError() error {
+	return component.ConnectionErrorOf(n.out)
+}
+
 func (n *notBatchedOutput) CloseAsync() {
 	n.shutSig.CloseAtLeisure()
 }
@@ -3,6 +3,7 @@ package output
 import (
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
@@ -58,6 +59,12 @@ func (i *WithPipeline) Connected() bool {
 	return i.out.Connected()
 }
 
+// ConnectionError returns the most recent connection error of the wrapped
+// component.
+func (i *WithPipeline) ConnectionError() error {
+	return component.ConnectionErrorOf(i.out)
+}
+
 //------------------------------------------------------------------------------
 
 // CloseAsync triggers a closure of this object but does not block.
@@ -5,6 +5,8 @@ type BranchConfig struct {
 	RequestMap string   `json:"request_map" yaml:"request_map"`
 	Processors []Config `json:"processors" yaml:"processors"`
 	ResultMap  string   `json:"result_map" yaml:"result_map"`
+
+	StatementMetrics bool `json:"statement_metrics" yaml:"statement_metrics"`
 }
 
 // NewBranchConfig returns a BranchConfig with default values.
@@ -13,5 +15,7 @@ func NewBranchConfig() BranchConfig {
 		RequestMap: "",
 		Processors: []Config{},
 		ResultMap:  "",
+
+		StatementMetrics: false,
 	}
 }
@@ -2,9 +2,10 @@ package processor
 
 // DedupeConfig contains configuration fields for the Dedupe processor.
 type DedupeConfig struct {
-	Cache          string `json:"cache" yaml:"cache"`
-	Key            string `json:"key" yaml:"key"`
-	DropOnCacheErr bool   `json:"drop_on_err" yaml:"drop_on_err"`
+	Cache          string             `json:"cache" yaml:"cache"`
+	Key            string             `json:"key" yaml:"key"`
+	DropOnCacheErr bool               `json:"drop_on_err" yaml:"drop_on_err"`
+	Filter         DedupeFilterConfig `json:"filter" yaml:"filter"`
 }
 
 // NewDedupeConfig returns a DedupeConfig with default values.
@@ -13,5 +14,31 @@ func NewDedupeConfig() DedupeConfig {
 		Cache:          "",
 		Key:            "",
 		DropOnCacheErr: true,
+		Filter:         NewDedupeFilterConfig(),
+	}
+}
+
+// DedupeFilterConfig contains configuration fields for the probabilistic
+// filter of the Dedupe processor.
+type DedupeFilterConfig struct {
+	Type              string  `json:"type" yaml:"type"`
+	Capacity          int     `json:"capacity" yaml:"capacity"`
+	FalsePositiveRate float64 `json:"false_positive_rate" yaml:"false_positive_rate"`
+	StatePath         string  `json:"state_path" yaml:"state_path"`
+	StateCache        string  `json:"state_cache" yaml:"state_cache"`
+	StateKey          string  `json:"state_key" yaml:"state_key"`
+	StateInterval     string  `json:"state_interval" yaml:"state_interval"`
+}
+
+// NewDedupeFilterConfig returns a DedupeFilterConfig with default values.
+func NewDedupeFilterConfig() DedupeFilterConfig {
+	return DedupeFilterConfig{
+		Type:              "none",
+		Capacity:          1000000,
+		FalsePositiveRate: 0.0001,
+		StatePath:         "",
+		StateCache:        "",
+		StateKey:          "benthos_dedupe_filter",
+		StateInterval:     "30s",
 	}
 }
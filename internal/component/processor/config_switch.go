@@ -10,6 +10,8 @@ type SwitchCaseConfig struct {
 	Check       string   `json:"check" yaml:"check"`
 	Processors  []Config `json:"processors" yaml:"processors"`
 	Fallthrough bool     `json:"fallthrough" yaml:"fallthrough"`
+
+	StatementMetrics bool `json:"statement_metrics" yaml:"statement_metrics"`
 }
 
 // NewSwitchCaseConfig returns a new SwitchCaseConfig with default values.
@@ -18,6 +20,8 @@ func NewSwitchCaseConfig() SwitchCaseConfig {
 		Check:       "",
 		Processors:  []Config{},
 		Fallthrough: false,
+
+		StatementMetrics: false,
 	}
 }
 
@@ -7,6 +7,8 @@ type WhileConfig struct {
 	MaxLoops    int      `json:"max_loops" yaml:"max_loops"`
 	Check       string   `json:"check" yaml:"check"`
 	Processors  []Config `json:"processors" yaml:"processors"`
+
+	StatementMetrics bool `json:"statement_metrics" yaml:"statement_metrics"`
 }
 
 // NewWhileConfig returns a default WhileConfig.
@@ -16,5 +18,7 @@ func NewWhileConfig() WhileConfig {
 		MaxLoops:    0,
 		Check:       "",
 		Processors:  []Config{},
+
+		StatementMetrics: false,
 	}
 }
@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+// WithStatementMetrics returns a copy of a mapping executor that records the
+// execution count, error count and latency of each of its statements, labelled
+// by the name of the mapping within the processor config and the line number
+// of the statement. Instrumentation adds overhead to every statement executed
+// and should therefore only be applied when explicitly enabled.
+func WithStatementMetrics(exec *mapping.Executor, name string, stats metrics.Type) *mapping.Executor {
+	s := &statementMetrics{
+		name:     name,
+		executed: stats.GetCounterVec("mapping_statement_executed", "mapping", "line"),
+		errored:  stats.GetCounterVec("mapping_statement_error", "mapping", "line"),
+		latency:  stats.GetTimerVec("mapping_statement_latency_ns", "mapping", "line"),
+		byLine:   map[int]*lineMetrics{},
+	}
+	return exec.WithTrace(s.record)
+}
+
+type lineMetrics struct {
+	executed metrics.StatCounter
+	errored  metrics.StatCounter
+	latency  metrics.StatTimer
+}
+
+type statementMetrics struct {
+	name     string
+	executed metrics.StatCounterVec
+	errored  metrics.StatCounterVec
+	latency  metrics.StatTimerVec
+
+	mut    sync.RWMutex
+	byLine map[int]*lineMetrics
+}
+
+func (s *statementMetrics) forLine(line int) *lineMetrics {
+	s.mut.RLock()
+	m, exists := s.byLine[line]
+	s.mut.RUnlock()
+	if exists {
+		return m
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if m, exists = s.byLine[line]; exists {
+		return m
+	}
+	lineStr := strconv.Itoa(line)
+	m = &lineMetrics{
+		executed: s.executed.With(s.name, lineStr),
+		errored:  s.errored.With(s.name, lineStr),
+		latency:  s.latency.With(s.name, lineStr),
+	}
+	s.byLine[line] = m
+	return m
+}
+
+func (s *statementMetrics) record(entry mapping.TraceEntry) {
+	m := s.forLine(entry.Line)
+	m.executed.Incr(1)
+	if entry.Err != nil {
+		m.errored.Incr(1)
+	}
+	m.latency.Timing(entry.Elapsed.Nanoseconds())
+}
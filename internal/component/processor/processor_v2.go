@@ -52,6 +52,8 @@ type v2ToV1Processor struct {
 	mBatchSent     metrics.StatCounter
 	mError         metrics.StatCounter
 	mLatency       metrics.StatTimer
+	mInFlight      metrics.StatGauge
+	mBatchSize     metrics.StatHistogram
 }
 
 // NewV2ToV1Processor wraps a processor.V2 with a struct that implements V1.
@@ -65,12 +67,18 @@ func NewV2ToV1Processor(typeStr string, p V2, stats metrics.Type) V1 {
 		mBatchSent:     stats.GetCounter("processor_batch_sent"),
 		mError:         stats.GetCounter("processor_error"),
 		mLatency:       stats.GetTimer("processor_latency_ns"),
+		mInFlight:      stats.GetGauge("processor_in_flight"),
+		mBatchSize:     stats.GetHistogram("processor_batch_size"),
 	}
 }
 
 func (a *v2ToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
-	a.mReceived.Incr(int64(msg.Len()))
+	inFlight := int64(msg.Len())
+	a.mReceived.Incr(inFlight)
 	a.mBatchReceived.Incr(1)
+	a.mBatchSize.Observe(inFlight)
+	a.mInFlight.Incr(inFlight)
+	defer a.mInFlight.Decr(inFlight)
 
 	tStarted := time.Now()
 
@@ -138,6 +146,8 @@ type v2BatchedToV1Processor struct {
 	mBatchSent     metrics.StatCounter
 	mError         metrics.StatCounter
 	mLatency       metrics.StatTimer
+	mInFlight      metrics.StatGauge
+	mBatchSize     metrics.StatHistogram
 }
 
 // NewV2BatchedToV1Processor wraps a processor.V2Batched with a struct that
@@ -152,12 +162,18 @@ func NewV2BatchedToV1Processor(typeStr string, p V2Batched, stats metrics.Type)
 		mBatchSent:     stats.GetCounter("processor_batch_sent"),
 		mError:         stats.GetCounter("processor_error"),
 		mLatency:       stats.GetTimer("processor_latency_ns"),
+		mInFlight:      stats.GetGauge("processor_in_flight"),
+		mBatchSize:     stats.GetHistogram("processor_batch_size"),
 	}
 }
 
 func (a *v2BatchedToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
-	a.mReceived.Incr(int64(msg.Len()))
+	inFlight := int64(msg.Len())
+	a.mReceived.Incr(inFlight)
 	a.mBatchReceived.Incr(1)
+	a.mBatchSize.Observe(inFlight)
+	a.mInFlight.Incr(inFlight)
+	defer a.mInFlight.Decr(inFlight)
 
 	tStarted := time.Now()
 	spans := tracing.CreateChildSpans(a.typeStr, msg)
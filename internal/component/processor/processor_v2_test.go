@@ -72,6 +72,32 @@ func TestProcessorAirGapOneToOne(t *testing.T) {
 	assert.Equal(t, "unchanged", string(msg.Get(0).Get()))
 }
 
+func TestProcessorAirGapMetrics(t *testing.T) {
+	stats := metrics.NewLocal()
+
+	var inFlight int64
+	agrp := NewV2ToV1Processor("foo", &fnProcessor{
+		fn: func(c context.Context, m *message.Part) ([]*message.Part, error) {
+			inFlight = stats.GetCounters()["processor_in_flight"]
+			return []*message.Part{m}, nil
+		},
+	}, metrics.NewNamespaced(stats))
+
+	msgs, res := agrp.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, int64(3), inFlight)
+	assert.Equal(t, int64(0), stats.GetCounters()["processor_in_flight"])
+
+	batchSize := stats.GetTimings()["processor_batch_size"]
+	require.NotNil(t, batchSize)
+	assert.Equal(t, int64(1), batchSize.Count())
+	assert.Equal(t, int64(3), batchSize.Max())
+}
+
 func TestProcessorAirGapOneToError(t *testing.T) {
 	agrp := NewV2ToV1Processor("foo", &fnProcessor{
 		fn: func(c context.Context, m *message.Part) ([]*message.Part, error) {
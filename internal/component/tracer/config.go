@@ -19,6 +19,7 @@ type Type interface {
 // Config is the all encompassing configuration struct for all tracer types.
 type Config struct {
 	Type       string           `json:"type" yaml:"type"`
+	Datadog    DatadogConfig    `json:"datadog" yaml:"datadog"`
 	Jaeger     JaegerConfig     `json:"jaeger" yaml:"jaeger"`
 	CloudTrace CloudTraceConfig `json:"gcp_cloudtrace" yaml:"gcp_cloudtrace"`
 	None       struct{}         `json:"none" yaml:"none"`
@@ -28,6 +29,7 @@ type Config struct {
 func NewConfig() Config {
 	return Config{
 		Type:       "none",
+		Datadog:    NewDatadogConfig(),
 		Jaeger:     NewJaegerConfig(),
 		CloudTrace: NewCloudTraceConfig(),
 		None:       struct{}{},
@@ -0,0 +1,25 @@
+package tracer
+
+// DatadogConfig is config for the Datadog tracer.
+type DatadogConfig struct {
+	AgentAddress  string            `json:"agent_address" yaml:"agent_address"`
+	Service       string            `json:"service" yaml:"service"`
+	Env           string            `json:"env" yaml:"env"`
+	Version       string            `json:"version" yaml:"version"`
+	SamplingRatio float64           `json:"sampling_ratio" yaml:"sampling_ratio"`
+	Tags          map[string]string `json:"tags" yaml:"tags"`
+	FlushInterval string            `json:"flush_interval" yaml:"flush_interval"`
+}
+
+// NewDatadogConfig creates an DatadogConfig struct with default values.
+func NewDatadogConfig() DatadogConfig {
+	return DatadogConfig{
+		AgentAddress:  "localhost:4318",
+		Service:       "benthos",
+		Env:           "",
+		Version:       "",
+		SamplingRatio: 1.0,
+		Tags:          map[string]string{},
+		FlushInterval: "",
+	}
+}
@@ -101,6 +101,44 @@ input:
 	assert.Equal(t, "foobar", conf.Output.Kafka.Topic)
 }
 
+func TestSetOverridesFlowValues(t *testing.T) {
+	dir := t.TempDir()
+
+	fullPath := filepath.Join(dir, "main.yaml")
+	require.NoError(t, os.WriteFile(fullPath, []byte(`
+input:
+  kafka:
+    addresses: [ foobar.com ]
+    topics: [ meow1 ]
+pipeline:
+  processors:
+    - bloblang: 'root = this'
+`), 0o644))
+
+	conf := config.New()
+	rdr := config.NewReader(fullPath, nil, config.OptAddOverrides(
+		"input.kafka.addresses=[ nope1.com, nope2.com ]",
+		"input.kafka.topics.-=meow2",
+		"input.kafka.tls={ enabled: true, skip_cert_verify: true }",
+		"pipeline.processors.0.bloblang=root = this.without(\"foo\")",
+		"input.kafka.client_id={ not valid flow",
+	))
+
+	lints, err := rdr.Read(&conf)
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+
+	assert.Equal(t, []string{"nope1.com", "nope2.com"}, conf.Input.Kafka.Addresses)
+	assert.Equal(t, []string{"meow1", "meow2"}, conf.Input.Kafka.Topics)
+	assert.True(t, conf.Input.Kafka.TLS.Enabled)
+	assert.True(t, conf.Input.Kafka.TLS.InsecureSkipVerify)
+
+	assert.Equal(t, `{ not valid flow`, conf.Input.Kafka.ClientID)
+
+	require.Len(t, conf.Pipeline.Processors, 1)
+	assert.Equal(t, `root = this.without("foo")`, string(conf.Pipeline.Processors[0].Bloblang))
+}
+
 func TestResources(t *testing.T) {
 	dir := t.TempDir()
 
@@ -2,9 +2,17 @@ package config
 
 import (
 	"bytes"
+	"context"
+	"fmt"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 )
 
 var (
@@ -21,27 +29,129 @@ var (
 // respective environment variable will be read and will replace the pattern. If
 // the environment variable is empty or does not exist then either the default
 // value is used or the field will be left empty.
-func ReplaceEnvVariables(inBytes []byte) []byte {
+//
+// If the default value begins with a question mark, as in `${FOO:?msg}`, then
+// the variable is required and an error containing the remaining message is
+// returned when it is empty or does not exist.
+//
+// The special patterns `${file:/path/to/file}` and `${secret:provider:path#key}`
+// are instead replaced with the contents of a file and the value of a secret
+// obtained from a secrets provider respectively, and an error is returned if
+// either cannot be read.
+func ReplaceEnvVariables(inBytes []byte) ([]byte, error) {
+	return replaceEnvVariables(inBytes, true)
+}
+
+// ReplaceEnvVariablesWithoutSecrets performs the same replacements as
+// ReplaceEnvVariables, except that an error is returned if the blob contains a
+// reference to a secret or a file. This is used for configs from sources that
+// should not be able to obtain secrets or read files on behalf of Benthos, such
+// as the streams API.
+func ReplaceEnvVariablesWithoutSecrets(inBytes []byte) ([]byte, error) {
+	return replaceEnvVariables(inBytes, false)
+}
+
+func replaceEnvVariables(inBytes []byte, allowSecrets bool) ([]byte, error) {
+	var replaceErr error
 	replaced := envRegex.ReplaceAllFunc(inBytes, func(content []byte) []byte {
 		var value string
 		if len(content) > 3 {
+			var err error
 			if colonIndex := bytes.IndexByte(content, ':'); colonIndex == -1 {
 				value = os.Getenv(string(content[2 : len(content)-1]))
 			} else {
-				targetVar := content[2:colonIndex]
-				defaultVal := content[colonIndex+1 : len(content)-1]
+				targetVar := string(content[2:colonIndex])
+				defaultVal := string(content[colonIndex+1 : len(content)-1])
 
-				value = os.Getenv(string(targetVar))
-				if value == "" {
-					value = string(defaultVal)
+				switch {
+				case targetVar == "secret" && !allowSecrets:
+					err = fmt.Errorf("secret reference '%v' is not permitted", defaultVal)
+				case targetVar == "file" && !allowSecrets:
+					err = fmt.Errorf("file reference '%v' is not permitted", defaultVal)
+				case targetVar == "secret":
+					value, err = secrets.Resolve(context.Background(), defaultVal)
+				case targetVar == "file":
+					value, err = readFileVariable(defaultVal)
+				case strings.HasPrefix(defaultVal, "?"):
+					if value = os.Getenv(targetVar); value == "" {
+						err = requiredVariableErr(targetVar, defaultVal[1:])
+					}
+				default:
+					if value = os.Getenv(targetVar); value == "" {
+						value = defaultVal
+					}
 				}
 			}
+			if err != nil && replaceErr == nil {
+				replaceErr = err
+			}
 			// Escape newlines, otherwise there's no way that they would work
 			// within a config.
 			value = strings.ReplaceAll(value, "\n", "\\n")
 		}
 		return []byte(value)
 	})
+	if replaceErr != nil {
+		return nil, replaceErr
+	}
 	replaced = escapedEnvRegex.ReplaceAll(replaced, []byte("$$$1"))
-	return replaced
+	return replaced, nil
+}
+
+func readFileVariable(path string) (string, error) {
+	fileBytes, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file variable: %w", err)
+	}
+	// Files such as mounted secrets commonly end with a newline that isn't
+	// intended to be part of the value.
+	return strings.TrimRight(string(fileBytes), "\r\n"), nil
+}
+
+func requiredVariableErr(name, msg string) error {
+	if msg = strings.TrimSpace(msg); msg == "" {
+		return fmt.Errorf("required environment variable %v is not set", name)
+	}
+	return fmt.Errorf("required environment variable %v is not set: %v", name, msg)
+}
+
+// RedactEnvVariables walks a raw config, prior to environment variables being
+// replaced, and for each scalar value containing an interpolation pattern the
+// equivalent value within the provided node is reset to the raw value. This
+// allows a resolved config to be printed without exposing the values of
+// environment variables, files or secrets.
+func RedactEnvVariables(rawBytes []byte, node *yaml.Node) error {
+	var rawNode yaml.Node
+	if err := yaml.Unmarshal(rawBytes, &rawNode); err != nil {
+		return err
+	}
+
+	var walk func(n *yaml.Node, path []string)
+	walk = func(n *yaml.Node, path []string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i < len(n.Content)-1; i += 2 {
+				walk(n.Content[i+1], append(path[:len(path):len(path)], n.Content[i].Value))
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				walk(c, append(path[:len(path):len(path)], strconv.Itoa(i)))
+			}
+		case yaml.ScalarNode:
+			if !envRegex.MatchString(n.Value) {
+				return
+			}
+			if target, err := docs.GetYAMLPath(node, path...); err == nil && target.Kind == yaml.ScalarNode {
+				target.Value = n.Value
+				target.Tag = "!!str"
+				target.Style = 0
+			}
+		}
+	}
+	walk(&rawNode, nil)
+	return nil
 }
@@ -1,8 +1,17 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 )
 
 func TestEnvSwapping(t *testing.T) {
@@ -36,9 +45,138 @@ func TestEnvSwapping(t *testing.T) {
 	}
 
 	for in, exp := range tests {
-		out := ReplaceEnvVariables([]byte(in))
+		out, err := ReplaceEnvVariables([]byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
 		if act := string(out); act != exp {
 			t.Errorf("Wrong result: %v != %v", act, exp)
 		}
 	}
 }
+
+func TestEnvSwappingSecrets(t *testing.T) {
+	secrets.ResetCache()
+	t.Cleanup(secrets.ResetCache)
+
+	secrets.RegisterProvider("envtest", secrets.ProviderFunc(func(ctx context.Context, path string) (string, error) {
+		if path == "foo/bar" {
+			return `{"user":"fooer","pass":"foo\nbar"}`, nil
+		}
+		return "", errors.New("nope")
+	}))
+
+	out, err := ReplaceEnvVariables([]byte(`user: ${secret:envtest:foo/bar#user}, pass: ${secret:envtest:foo/bar#pass}`))
+	require.NoError(t, err)
+	assert.Equal(t, `user: fooer, pass: foo\nbar`, string(out))
+
+	_, err = ReplaceEnvVariables([]byte(`user: ${secret:envtest:baz#user}`))
+	require.EqualError(t, err, "failed to obtain secret 'baz' from envtest: nope")
+}
+
+func TestEnvSwappingWithoutSecrets(t *testing.T) {
+	require.NoError(t, os.Setenv("BENTHOS_TEST_NO_SECRETS", "foo"))
+	t.Cleanup(func() {
+		_ = os.Unsetenv("BENTHOS_TEST_NO_SECRETS")
+	})
+
+	out, err := ReplaceEnvVariablesWithoutSecrets([]byte(`foo: ${BENTHOS_TEST_NO_SECRETS}`))
+	require.NoError(t, err)
+	assert.Equal(t, `foo: foo`, string(out))
+
+	_, err = ReplaceEnvVariablesWithoutSecrets([]byte(`foo: ${BENTHOS_TEST_NO_SECRETS}, pass: ${secret:envtest:foo/bar#pass}`))
+	require.EqualError(t, err, "secret reference 'envtest:foo/bar#pass' is not permitted")
+
+	_, err = ReplaceEnvVariablesWithoutSecrets([]byte(`foo: ${file:/etc/hostname}`))
+	require.EqualError(t, err, "file reference '/etc/hostname' is not permitted")
+}
+
+func TestEnvSwappingRequired(t *testing.T) {
+	require.NoError(t, os.Setenv("BENTHOS_TEST_REQUIRED", "foo"))
+	require.NoError(t, os.Unsetenv("BENTHOS_TEST_MISSING"))
+	t.Cleanup(func() {
+		_ = os.Unsetenv("BENTHOS_TEST_REQUIRED")
+	})
+
+	out, err := ReplaceEnvVariables([]byte(`foo ${BENTHOS_TEST_REQUIRED:?must be set} baz`))
+	require.NoError(t, err)
+	assert.Equal(t, "foo foo baz", string(out))
+
+	_, err = ReplaceEnvVariables([]byte(`foo ${BENTHOS_TEST_MISSING:?the kafka address must be set} baz`))
+	require.EqualError(t, err, "required environment variable BENTHOS_TEST_MISSING is not set: the kafka address must be set")
+
+	_, err = ReplaceEnvVariables([]byte(`foo ${BENTHOS_TEST_MISSING:?} baz`))
+	require.EqualError(t, err, "required environment variable BENTHOS_TEST_MISSING is not set")
+}
+
+func TestEnvSwappingFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	fooPath := filepath.Join(tmpDir, "foo")
+	require.NoError(t, os.WriteFile(fooPath, []byte("foo value\n"), 0o644))
+
+	barPath := filepath.Join(tmpDir, "bar")
+	require.NoError(t, os.WriteFile(barPath, []byte("bar\nvalue"), 0o644))
+
+	out, err := ReplaceEnvVariables([]byte(`foo: ${file:` + fooPath + `}, bar: ${file:` + barPath + `}`))
+	require.NoError(t, err)
+	assert.Equal(t, `foo: foo value, bar: bar\nvalue`, string(out))
+
+	_, err = ReplaceEnvVariables([]byte(`foo: ${file:` + filepath.Join(tmpDir, "nope") + `}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read file variable")
+}
+
+func TestRedactEnvVariables(t *testing.T) {
+	rawBytes := []byte(`
+input:
+  kafka:
+    addresses: [ "${BROKER}", localhost:9092 ]
+    topics: [ foo ]
+output:
+  http_client:
+    url: http://example.com/${PATH_SUFFIX:foo}
+    headers:
+      Authorization: "${secret:vault:secret/api#token}"
+      Content-Type: application/json
+    verb: ${{NOT_REDACTED}}
+`)
+
+	var resolved yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+input:
+  kafka:
+    addresses:
+      - 10.0.0.1:9092
+      - localhost:9092
+    topics: [ foo ]
+output:
+  http_client:
+    url: http://example.com/foo
+    headers:
+      Authorization: hunter2
+      Content-Type: application/json
+    verb: POST
+    max_in_flight: 64
+`), &resolved))
+
+	require.NoError(t, RedactEnvVariables(rawBytes, &resolved))
+
+	resBytes, err := yaml.Marshal(&resolved)
+	require.NoError(t, err)
+	assert.Equal(t, `input:
+    kafka:
+        addresses:
+            - ${BROKER}
+            - localhost:9092
+        topics: [foo]
+output:
+    http_client:
+        url: http://example.com/${PATH_SUFFIX:foo}
+        headers:
+            Authorization: ${secret:vault:secret/api#token}
+            Content-Type: application/json
+        verb: POST
+        max_in_flight: 64
+`, string(resBytes))
+}
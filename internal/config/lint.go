@@ -68,6 +68,8 @@ func ReadFileEnvSwap(path string) (configBytes []byte, lints []string, err error
 		lints = append(lints, "Detected invalid utf-8 encoding in config, this may result in interpolation functions not working as expected")
 	}
 
-	configBytes = ReplaceEnvVariables(configBytes)
+	if configBytes, err = ReplaceEnvVariables(configBytes); err != nil {
+		return nil, nil, err
+	}
 	return configBytes, lints, nil
 }
@@ -0,0 +1,266 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// overlayLineStride is added to the line numbers of the nodes of each config
+// file merged over the main config, multiplied by the index of the file. This
+// allows linting errors found within a merged config to be attributed to the
+// file that the offending field originated from.
+const overlayLineStride = 1 << 24
+
+// expandConfigPaths returns the files of a list of config paths, where
+// directories are expanded into the YAML files they contain (excluding unit
+// test definitions) sorted by name.
+func expandConfigPaths(paths []string, testSuffix string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil || !info.IsDir() {
+			// Non-existent files are left for the reader to report.
+			files = append(files, p)
+			continue
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		var dirFiles []string
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			ext := filepath.Ext(e.Name())
+			if ext != ".yaml" && ext != ".yml" {
+				continue
+			}
+			if testSuffix != "" && strings.HasSuffix(strings.TrimSuffix(e.Name(), ext), testSuffix) {
+				continue
+			}
+			dirFiles = append(dirFiles, filepath.Join(p, e.Name()))
+		}
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+func offsetYAMLLines(node *yaml.Node, offset int) {
+	node.Line += offset
+	for _, c := range node.Content {
+		offsetYAMLLines(c, offset)
+	}
+}
+
+func resetYAMLLines(node *yaml.Node) {
+	node.Line %= overlayLineStride
+	for _, c := range node.Content {
+		resetYAMLLines(c)
+	}
+}
+
+// mergeYAML deep merges an overlay node into a base node and returns the
+// result. Mappings are merged key by key, where a null value removes the key
+// from the base, and all other values of the overlay replace those of the
+// base. When a field of the spec is a component and the overlay specifies a
+// different component type than the base then the component is replaced rather
+// than merged.
+func mergeYAML(spec docs.FieldSpecs, base, overlay *yaml.Node) *yaml.Node {
+	if base == nil || base.Kind == 0 {
+		return overlay
+	}
+	if overlay.Kind == 0 {
+		// An empty overlay document leaves the base untouched.
+		return base
+	}
+	if base.Kind == yaml.DocumentNode && overlay.Kind == yaml.DocumentNode {
+		if len(base.Content) == 0 {
+			return overlay
+		}
+		if len(overlay.Content) > 0 {
+			base.Content[0] = mergeYAML(spec, base.Content[0], overlay.Content[0])
+		}
+		return base
+	}
+	if base.Kind != yaml.MappingNode || overlay.Kind != yaml.MappingNode {
+		return overlay
+	}
+
+	for i := 0; i < len(overlay.Content)-1; i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+
+		baseIndex := -1
+		for j := 0; j < len(base.Content)-1; j += 2 {
+			if base.Content[j].Value == key.Value {
+				baseIndex = j
+				break
+			}
+		}
+
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			if baseIndex >= 0 {
+				base.Content = append(base.Content[:baseIndex], base.Content[baseIndex+2:]...)
+			}
+			continue
+		}
+		if baseIndex < 0 {
+			base.Content = append(base.Content, key, value)
+			continue
+		}
+
+		var childSpec docs.FieldSpecs
+		replace := false
+		for _, f := range spec {
+			if f.Name != key.Value {
+				continue
+			}
+			if coreType, isCore := f.Type.IsCoreComponent(); isCore && f.Kind == docs.KindScalar {
+				replace = !sameComponentType(coreType, base.Content[baseIndex+1], value)
+			} else {
+				childSpec = f.Children
+			}
+			break
+		}
+		if replace {
+			base.Content[baseIndex+1] = value
+		} else {
+			base.Content[baseIndex+1] = mergeYAML(childSpec, base.Content[baseIndex+1], value)
+		}
+	}
+	return base
+}
+
+func sameComponentType(t docs.Type, a, b *yaml.Node) bool {
+	aName, _, aErr := docs.GetInferenceCandidateFromYAML(docs.DeprecatedProvider, t, a)
+	bName, _, bErr := docs.GetInferenceCandidateFromYAML(docs.DeprecatedProvider, t, b)
+	if aErr != nil || bErr != nil {
+		// Without a clear type for either we fall back to merging.
+		return true
+	}
+	return aName == bName
+}
+
+// readMergedYAML reads a list of config files, replacing environment variable
+// references, and deep merges them in order into a single node. The line
+// numbers of nodes originating from overlays are offset so that lints can be
+// attributed with attributeLintLine.
+func readMergedYAML(spec docs.FieldSpecs, paths []string) (root *yaml.Node, lints []string, lintDisabled []bool, err error) {
+	for i, p := range paths {
+		var confBytes []byte
+		var fileLints []string
+		if confBytes, fileLints, err = ReadFileEnvSwap(p); err != nil {
+			err = fmt.Errorf("%v: %w", p, err)
+			return
+		}
+		for _, l := range fileLints {
+			lints = append(lints, fmt.Sprintf("%v: %v", p, l))
+		}
+		lintDisabled = append(lintDisabled, bytes.HasPrefix(confBytes, []byte("# BENTHOS LINT DISABLE")))
+
+		var node yaml.Node
+		if err = yaml.Unmarshal(confBytes, &node); err != nil {
+			err = fmt.Errorf("%v: %w", p, err)
+			return
+		}
+		offsetYAMLLines(&node, i*overlayLineStride)
+		root = mergeYAML(spec, root, &node)
+	}
+	return
+}
+
+// attributeLintLine returns the index of the file a merged line number
+// originates from along with the line number within that file.
+func attributeLintLine(line int) (fileIndex, fileLine int) {
+	return line / overlayLineStride, line % overlayLineStride
+}
+
+// ReadFilesLinted reads a main config file followed by any number of overlay
+// files (or directories of them) that are deep merged over it in order, and
+// returns any linting errors found within the merged config, prefixed with the
+// path of the file that each error originates from.
+func ReadFilesLinted(paths []string, testSuffix string, rejectDeprecated bool, conf *Type) ([]string, error) {
+	files, err := expandConfigPaths(paths, testSuffix)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	spec := Spec()
+	root, lints, lintDisabled, err := readMergedYAML(spec, files)
+	if err != nil {
+		return nil, err
+	}
+
+	lintCtx := docs.NewLintContext()
+	lintCtx.RejectDeprecated = rejectDeprecated
+	for _, lint := range spec.LintYAML(lintCtx, root) {
+		fileIndex, line := attributeLintLine(lint.Line)
+		if !lintDisabled[fileIndex] {
+			lints = append(lints, fmt.Sprintf("%v: line %v: %v", files[fileIndex], line, lint.What))
+		}
+	}
+
+	resetYAMLLines(root)
+	if err := root.Decode(conf); err != nil {
+		return nil, err
+	}
+	return lints, nil
+}
+
+// RedactEnvVariablesFiles is equivalent to RedactEnvVariables for a main config
+// file followed by any number of overlay files (or directories of them), where
+// the raw files are deep merged in order before being walked.
+func RedactEnvVariablesFiles(paths []string, testSuffix string, node *yaml.Node) error {
+	files, err := expandConfigPaths(paths, testSuffix)
+	if err != nil {
+		return err
+	}
+
+	root, err := readMergedRawYAML(Spec(), files)
+	if err != nil || root == nil {
+		return err
+	}
+
+	rawBytes, err := yaml.Marshal(root)
+	if err != nil {
+		return err
+	}
+	return RedactEnvVariables(rawBytes, node)
+}
+
+// readMergedRawYAML reads a list of config files without replacing environment
+// variable references, and deep merges them in order into a single node. Nil is
+// returned if the files are empty.
+func readMergedRawYAML(spec docs.FieldSpecs, paths []string) (*yaml.Node, error) {
+	var root *yaml.Node
+	for _, p := range paths {
+		rawBytes, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		var fileNode yaml.Node
+		if err := yaml.Unmarshal(rawBytes, &fileNode); err != nil {
+			return nil, fmt.Errorf("%v: %w", p, err)
+		}
+		root = mergeYAML(spec, root, &fileNode)
+	}
+	if root == nil || root.Kind == 0 {
+		return nil, nil
+	}
+	return root, nil
+}
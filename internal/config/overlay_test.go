@@ -0,0 +1,172 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestReaderOverlays(t *testing.T) {
+	dir := t.TempDir()
+
+	basePath := filepath.Join(dir, "base.yaml")
+	require.NoError(t, os.WriteFile(basePath, []byte(`
+input:
+  label: foo
+  generate:
+    mapping: 'root = "base"'
+    interval: 5s
+pipeline:
+  threads: 4
+  processors:
+    - bloblang: 'root = this'
+output:
+  stdout: {}
+logger:
+  level: DEBUG
+`), 0o644))
+
+	overlayPath := filepath.Join(dir, "prod.yaml")
+	require.NoError(t, os.WriteFile(overlayPath, []byte(`
+input:
+  generate:
+    mapping: 'root = "prod"'
+pipeline:
+  processors: []
+output:
+  drop: {}
+logger: ~
+`), 0o644))
+
+	rdr := NewReader(basePath, nil, OptAddOverlayPaths(overlayPath))
+
+	conf := New()
+	lints, err := rdr.Read(&conf)
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+
+	assert.Equal(t, "foo", conf.Input.Label)
+	assert.Equal(t, "generate", conf.Input.Type)
+	assert.Equal(t, `root = "prod"`, conf.Input.Generate.Mapping)
+	assert.Equal(t, "5s", conf.Input.Generate.Interval)
+	assert.Equal(t, 4, conf.Pipeline.Threads)
+	assert.Empty(t, conf.Pipeline.Processors)
+	assert.Equal(t, "drop", conf.Output.Type)
+	assert.Equal(t, "INFO", conf.Logger.LogLevel)
+}
+
+func TestReaderOverlaysDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"01_base.yaml": `
+pipeline:
+  threads: 1
+logger:
+  level: WARN
+`,
+		"02_env.yml": `
+pipeline:
+  threads: 2
+`,
+		"03_ignored.txt": `
+pipeline:
+  threads: 3
+`,
+		"04_foo_benthos_test.yaml": `
+pipeline:
+  threads: 4
+`,
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	rdr := NewReader(dir, nil)
+
+	conf := New()
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, conf.Pipeline.Threads)
+	assert.Equal(t, "WARN", conf.Logger.LogLevel)
+}
+
+func TestReaderOverlaysLintAttribution(t *testing.T) {
+	dir := t.TempDir()
+
+	basePath := filepath.Join(dir, "base.yaml")
+	require.NoError(t, os.WriteFile(basePath, []byte(`
+pipeline:
+  threads: 1
+  nope: true
+`), 0o644))
+
+	overlayPath := filepath.Join(dir, "overlay.yaml")
+	require.NoError(t, os.WriteFile(overlayPath, []byte(`
+pipeline:
+  threads: 2
+
+logger:
+  also_nope: true
+`), 0o644))
+
+	rdr := NewReader(basePath, nil, OptAddOverlayPaths(overlayPath))
+
+	conf := New()
+	lints, err := rdr.Read(&conf)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		basePath + ": line 4: field nope not recognised",
+		overlayPath + ": line 6: field also_nope not recognised",
+	}, lints)
+	assert.Equal(t, 2, conf.Pipeline.Threads)
+
+	conf = New()
+	lints, err = ReadFilesLinted([]string{basePath, overlayPath}, "", false, &conf)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		basePath + ": line 4: field nope not recognised",
+		overlayPath + ": line 6: field also_nope not recognised",
+	}, lints)
+	assert.Equal(t, 2, conf.Pipeline.Threads)
+}
+
+func TestRedactEnvVariablesFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	basePath := filepath.Join(dir, "base.yaml")
+	require.NoError(t, os.WriteFile(basePath, []byte(`
+http:
+  address: ${ADDRESS}
+logger:
+  level: ${LEVEL}
+`), 0o644))
+
+	overlayPath := filepath.Join(dir, "overlay.yaml")
+	require.NoError(t, os.WriteFile(overlayPath, []byte(`
+logger:
+  level: DEBUG
+`), 0o644))
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+http:
+  address: 0.0.0.0:4195
+logger:
+  level: DEBUG
+`), &node))
+
+	require.NoError(t, RedactEnvVariablesFiles([]string{basePath, overlayPath}, "", &node))
+
+	resBytes, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.Equal(t, `http:
+    address: ${ADDRESS}
+logger:
+    level: DEBUG
+`, string(resBytes))
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
 
@@ -43,6 +45,7 @@ type Reader struct {
 	testSuffix string
 
 	mainPath      string
+	overlayPaths  []string
 	resourcePaths []string
 	streamsPaths  []string
 	overrides     []string
@@ -53,6 +56,10 @@ type Reader struct {
 	// Tracks the details of the config file when we last read it.
 	configFileInfo configFileInfo
 
+	// Tracks the resources defined within the main config file when we last
+	// read it.
+	mainResourceInfo resourceFileInfo
+
 	// Tracks the details of stream config files when we last read them.
 	streamFileInfo map[string]streamFileInfo
 
@@ -65,9 +72,15 @@ type Reader struct {
 	mainUpdateFn   MainUpdateFunc
 	streamUpdateFn StreamUpdateFunc
 	watcher        *fsnotify.Watcher
+	reloading      bool
+	reloadChan     chan struct{}
+	closeChan      chan struct{}
+	closeOnce      sync.Once
 
 	changeFlushPeriod time.Duration
 	changeDelayPeriod time.Duration
+
+	secretsRefreshPeriod time.Duration
 }
 
 // NewReader creates a new config reader.
@@ -80,6 +93,8 @@ func NewReader(mainPath string, resourcePaths []string, opts ...OptFunc) *Reader
 		resourceFileInfo:  map[string]resourceFileInfo{},
 		changeFlushPeriod: defaultChangeFlushPeriod,
 		changeDelayPeriod: defaultChangeDelayPeriod,
+		reloadChan:        make(chan struct{}, 1),
+		closeChan:         make(chan struct{}),
 	}
 	for _, opt := range opts {
 		opt(r)
@@ -109,6 +124,14 @@ func OptAddOverrides(overrides ...string) OptFunc {
 	}
 }
 
+// OptAddOverlayPaths adds one or more config files, or directories of config
+// files, that are deep merged over the main config file in the order given.
+func OptAddOverlayPaths(paths ...string) OptFunc {
+	return func(r *Reader) {
+		r.overlayPaths = append(r.overlayPaths, paths...)
+	}
+}
+
 // OptSetStreamPaths marks this config reader as operating in streams mode, and
 // adds a list of paths to obtain individual stream configs from.
 func OptSetStreamPaths(streamsPaths ...string) OptFunc {
@@ -118,6 +141,16 @@ func OptSetStreamPaths(streamsPaths ...string) OptFunc {
 	}
 }
 
+// OptSecretsRefreshPeriod configures the reader to periodically refresh the
+// values of secrets referenced by config files, and when a secret value changes
+// the files that reference it are reloaded. Refreshing is started by either
+// BeginFileWatching or BeginSecretsRefresh.
+func OptSecretsRefreshPeriod(period time.Duration) OptFunc {
+	return func(r *Reader) {
+		r.secretsRefreshPeriod = period
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // Read a Benthos config from the files and options specified.
@@ -125,6 +158,7 @@ func (r *Reader) Read(conf *Type) (lints []string, err error) {
 	if lints, err = r.readMain(conf); err != nil {
 		return
 	}
+	r.mainResourceInfo = resInfoFromConfig(&conf.ResourceConfig)
 	var rLints []string
 	if rLints, err = r.readResources(&conf.ResourceConfig); err != nil {
 		return
@@ -133,6 +167,44 @@ func (r *Reader) Read(conf *Type) (lints []string, err error) {
 	return
 }
 
+// RedactEnvVariables resets the values of a config node, as read by this
+// reader, that were obtained from environment variables, files or secrets to
+// the expressions that produced them. This is equivalent to RedactEnvVariables
+// where the raw config is combined from the main config file, overlays,
+// override expressions and resource files in the same way as Read.
+func (r *Reader) RedactEnvVariables(node *yaml.Node) error {
+	confSpec := Spec()
+	if r.streamsMode {
+		confSpec = SpecWithoutStream()
+	}
+
+	mainPaths, err := r.mainPathsExpanded()
+	if err != nil {
+		return err
+	}
+
+	var rawNode yaml.Node
+	root, err := readMergedRawYAML(confSpec, mainPaths)
+	if err != nil {
+		return err
+	}
+	if root != nil {
+		rawNode = *root
+	}
+	if err := applyOverrides(confSpec, &rawNode, r.overrides...); err != nil {
+		return err
+	}
+	if err := r.appendRawResources(&rawNode); err != nil {
+		return err
+	}
+
+	rawBytes, err := yaml.Marshal(&rawNode)
+	if err != nil {
+		return err
+	}
+	return RedactEnvVariables(rawBytes, node)
+}
+
 // ReadStreams attempts to read Benthos stream configs from one or more paths.
 // Stream configs are extracted and added to a provided map, where the id is
 // derived from the path of the stream config file.
@@ -152,8 +224,8 @@ type MainUpdateFunc func(conf stream.Config) bool
 // The provided closure should return true if the stream was successfully
 // replaced.
 func (r *Reader) SubscribeConfigChanges(fn MainUpdateFunc) error {
-	if r.watcher != nil {
-		return errors.New("a file watcher has already been started")
+	if r.reloading {
+		return errors.New("config reloading has already been started")
 	}
 
 	r.mainUpdateFn = fn
@@ -172,8 +244,8 @@ type StreamUpdateFunc func(id string, conf stream.Config) bool
 // The provided closure should return true if the stream was successfully
 // replaced.
 func (r *Reader) SubscribeStreamChanges(fn StreamUpdateFunc) error {
-	if r.watcher != nil {
-		return errors.New("a file watcher has already been started")
+	if r.reloading {
+		return errors.New("config reloading has already been started")
 	}
 
 	r.streamUpdateFn = fn
@@ -193,6 +265,9 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 	if r.watcher != nil {
 		return errors.New("a file watcher has already been started")
 	}
+	if r.reloading {
+		return errors.New("config reloading has already been started")
+	}
 	if r.mainUpdateFn == nil && r.streamUpdateFn == nil {
 		return errors.New("a file watcher cannot be started without a subscription function registered")
 	}
@@ -202,16 +277,100 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 		return err
 	}
 	r.watcher = watcher
+	r.beginReloading(mgr, strict, watcher)
+
+	if !r.streamsMode {
+		mainPaths, err := r.mainPathsExpanded()
+		if err != nil {
+			_ = watcher.Close()
+			return err
+		}
+		for _, p := range mainPaths {
+			if err := watcher.Add(p); err != nil {
+				_ = watcher.Close()
+				return err
+			}
+		}
+	}
+
+	// TODO: Refresh this occasionally?
+	streamsPaths, err := r.streamPathsExpanded()
+	if err != nil {
+		return err
+	}
+	for _, p := range streamsPaths {
+		if err := watcher.Add(p[1]); err != nil {
+			_ = watcher.Close()
+			return err
+		}
+	}
+
+	// TODO: Refresh this occasionally?
+	resourcePaths, err := r.resourcePathsExpanded()
+	if err != nil {
+		return err
+	}
+	for _, p := range resourcePaths {
+		if err := watcher.Add(p); err != nil {
+			_ = watcher.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// BeginSecretsRefresh creates a goroutine that periodically refreshes the
+// values of secrets referenced by config files, and reloads the files that
+// reference any secret that has changed, without otherwise watching the files
+// for changes. This has no effect if a secrets refresh period has not been
+// configured, or if file watching has already started, as the file watcher
+// also refreshes secrets.
+//
+// WARNING: Either SubscribeConfigChanges or SubscribeStreamChanges must be
+// called before this, as otherwise it is unsafe to register them during
+// refreshing.
+func (r *Reader) BeginSecretsRefresh(mgr bundle.NewManagement, strict bool) error {
+	if r.secretsRefreshPeriod <= 0 || r.watcher != nil {
+		return nil
+	}
+	if r.reloading {
+		return errors.New("config reloading has already been started")
+	}
+	if r.mainUpdateFn == nil && r.streamUpdateFn == nil {
+		return errors.New("secrets refreshing cannot be started without a subscription function registered")
+	}
+	r.beginReloading(mgr, strict, nil)
+	return nil
+}
+
+// beginReloading creates a goroutine that applies changes to config files,
+// which are detected by a file watcher when provided, and by refreshing secrets
+// when a refresh period is configured.
+func (r *Reader) beginReloading(mgr bundle.NewManagement, strict bool, watcher *fsnotify.Watcher) {
+	r.reloading = true
+
+	var watcherEvents <-chan fsnotify.Event
+	var watcherErrors <-chan error
+	if watcher != nil {
+		watcherEvents, watcherErrors = watcher.Events, watcher.Errors
+	}
 
 	go func() {
 		ticker := time.NewTicker(r.changeFlushPeriod)
 		defer ticker.Stop()
 
+		var secretsTickerChan <-chan time.Time
+		if r.secretsRefreshPeriod > 0 {
+			secretsTicker := time.NewTicker(r.secretsRefreshPeriod)
+			defer secretsTicker.Stop()
+			secretsTickerChan = secretsTicker.C
+		}
+
 		collapsedChanges := map[string]time.Time{}
 		lostNames := map[string]struct{}{}
 		for {
 			select {
-			case event, ok := <-watcher.Events:
+			case event, ok := <-watcherEvents:
 				if !ok {
 					return
 				}
@@ -230,7 +389,7 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 						continue
 					}
 					var succeeded bool
-					if nameClean == filepath.Clean(r.mainPath) {
+					if r.isMainPath(nameClean) {
 						succeeded = r.reactMainUpdate(mgr, strict)
 					} else if _, exists := r.streamFileInfo[nameClean]; exists {
 						succeeded = r.reactStreamUpdate(mgr, strict, nameClean)
@@ -249,7 +408,28 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 						delete(lostNames, lostName)
 					}
 				}
-			case err, ok := <-watcher.Errors:
+			case <-r.closeChan:
+				return
+			case <-r.reloadChan:
+				mgr.Logger().Infoln("Reloading all config files.")
+				for _, path := range r.allPaths() {
+					// Backdate the change so that it is applied immediately.
+					collapsedChanges[filepath.Clean(path)] = time.Now().Add(-r.changeDelayPeriod)
+				}
+			case <-secretsTickerChan:
+				changed, err := secrets.Refresh(context.Background())
+				if err != nil {
+					mgr.Logger().Errorf("Failed to refresh secrets: %v", err)
+				}
+				if len(changed) == 0 {
+					break
+				}
+				mgr.Logger().Infof("Secrets updated: %v", strings.Join(changed, ", "))
+				for _, path := range r.pathsReferencingSecrets(changed) {
+					// Backdate the change so that it is applied immediately.
+					collapsedChanges[path] = time.Now().Add(-r.changeDelayPeriod)
+				}
+			case err, ok := <-watcherErrors:
 				if !ok {
 					return
 				}
@@ -257,42 +437,80 @@ func (r *Reader) BeginFileWatching(mgr bundle.NewManagement, strict bool) error
 			}
 		}
 	}()
+}
 
-	if !r.streamsMode && r.mainPath != "" {
-		if err := watcher.Add(r.mainPath); err != nil {
-			_ = watcher.Close()
-			return err
-		}
+// TriggerReload schedules all config files to be read again and any changes to
+// be applied, as if each file had been modified. This has no effect unless
+// reloading has been started with BeginFileWatching or BeginSecretsRefresh.
+func (r *Reader) TriggerReload() {
+	select {
+	case r.reloadChan <- struct{}{}:
+	default:
 	}
+}
 
-	// TODO: Refresh this occasionally?
-	streamsPaths, err := r.streamPathsExpanded()
-	if err != nil {
-		return err
+// allPaths returns the paths of all config files read by the reader.
+func (r *Reader) allPaths() []string {
+	var paths []string
+	if !r.streamsMode {
+		if mainPaths, err := r.mainPathsExpanded(); err == nil {
+			paths = append(paths, mainPaths...)
+		}
 	}
-	for _, p := range streamsPaths {
-		if err := watcher.Add(p[1]); err != nil {
-			_ = watcher.Close()
-			return err
+	if streamsPaths, err := r.streamPathsExpanded(); err == nil {
+		for _, p := range streamsPaths {
+			paths = append(paths, p[1])
 		}
 	}
+	if resourcePaths, err := r.resourcePathsExpanded(); err == nil {
+		paths = append(paths, resourcePaths...)
+	}
+	return paths
+}
 
-	// TODO: Refresh this occasionally?
-	resourcePaths, err := r.resourcePathsExpanded()
+// mainPathsExpanded returns the main config file followed by any overlay files,
+// where directories are expanded into the files they contain.
+func (r *Reader) mainPathsExpanded() ([]string, error) {
+	return expandConfigPaths(append([]string{r.mainPath}, r.overlayPaths...), r.testSuffix)
+}
+
+func (r *Reader) isMainPath(nameClean string) bool {
+	mainPaths, err := r.mainPathsExpanded()
 	if err != nil {
-		return err
+		return false
 	}
-	for _, p := range resourcePaths {
-		if err := watcher.Add(p); err != nil {
-			_ = watcher.Close()
-			return err
+	for _, p := range mainPaths {
+		if filepath.Clean(p) == nameClean {
+			return true
 		}
 	}
-	return nil
+	return false
+}
+
+// pathsReferencingSecrets returns the cleaned paths of all config files that
+// contain a reference to any of the provided secrets.
+func (r *Reader) pathsReferencingSecrets(refs []string) []string {
+	var matched []string
+	for _, p := range r.allPaths() {
+		fileBytes, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		for _, ref := range refs {
+			if bytes.Contains(fileBytes, []byte("${secret:"+ref+"}")) {
+				matched = append(matched, filepath.Clean(p))
+				break
+			}
+		}
+	}
+	return matched
 }
 
 // Close the reader, when this method exits all reloading will be stopped.
 func (r *Reader) Close(ctx context.Context) error {
+	r.closeOnce.Do(func() {
+		close(r.closeChan)
+	})
 	if r.watcher != nil {
 		return r.watcher.Close()
 	}
@@ -318,6 +536,14 @@ func applyOverrides(specs docs.FieldSpecs, root *yaml.Node, overrides ...string)
 			Kind:  yaml.ScalarNode,
 			Value: value,
 		}
+		if strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{") {
+			// Values written in YAML flow syntax are parsed as arrays or
+			// objects, otherwise they're treated as a string.
+			var flowNode yaml.Node
+			if err := yaml.Unmarshal([]byte(value), &flowNode); err == nil && len(flowNode.Content) > 0 {
+				valNode = *flowNode.Content[0]
+			}
+		}
 		if err := specs.SetYAMLPath(docs.DeprecatedProvider, root, &valNode, gabs.DotPathToSlice(path)...); err != nil {
 			return fmt.Errorf("failed to set config field override: %w", err)
 		}
@@ -326,25 +552,45 @@ func applyOverrides(specs docs.FieldSpecs, root *yaml.Node, overrides ...string)
 }
 
 func (r *Reader) readMain(conf *Type) (lints []string, err error) {
+	mainPaths, err := r.mainPathsExpanded()
+	if err != nil {
+		return nil, err
+	}
+
 	defer func() {
-		if err != nil && r.mainPath != "" {
-			err = fmt.Errorf("%v: %w", r.mainPath, err)
+		if err != nil && len(mainPaths) == 1 {
+			err = fmt.Errorf("%v: %w", mainPaths[0], err)
 		}
 	}()
 
-	if r.mainPath == "" && len(r.overrides) == 0 {
+	if len(mainPaths) == 0 && len(r.overrides) == 0 {
 		return
 	}
 
+	confSpec := Spec()
+	if r.streamsMode {
+		// Spec is limited to just non-stream fields when in streams mode (no
+		// input, output, etc)
+		confSpec = SpecWithoutStream()
+	}
+
 	var rawNode yaml.Node
-	var confBytes []byte
-	if r.mainPath != "" {
-		if confBytes, lints, err = ReadFileEnvSwap(r.mainPath); err != nil {
+	var lintDisabled []bool
+	if len(mainPaths) == 1 {
+		var confBytes []byte
+		if confBytes, lints, err = ReadFileEnvSwap(mainPaths[0]); err != nil {
 			return
 		}
 		if err = yaml.Unmarshal(confBytes, &rawNode); err != nil {
 			return
 		}
+		lintDisabled = []bool{bytes.HasPrefix(confBytes, []byte("# BENTHOS LINT DISABLE"))}
+	} else if len(mainPaths) > 1 {
+		var merged *yaml.Node
+		if merged, lints, lintDisabled, err = readMergedYAML(confSpec, mainPaths); err != nil {
+			return
+		}
+		rawNode = *merged
 	}
 
 	// This is an unlikely race condition as the file could've been updated
@@ -354,26 +600,23 @@ func (r *Reader) readMain(conf *Type) (lints []string, err error) {
 	// now (ignoring the issue).
 	r.configFileInfo.updatedAt = time.Now()
 
-	confSpec := Spec()
-	if r.streamsMode {
-		// Spec is limited to just non-stream fields when in streams mode (no
-		// input, output, etc)
-		confSpec = SpecWithoutStream()
-	}
 	if err = applyOverrides(confSpec, &rawNode, r.overrides...); err != nil {
 		return
 	}
 
-	if !bytes.HasPrefix(confBytes, []byte("# BENTHOS LINT DISABLE")) {
-		lintFilePrefix := ""
-		if r.mainPath != "" {
-			lintFilePrefix = fmt.Sprintf("%v: ", r.mainPath)
+	for _, lint := range confSpec.LintYAML(docs.NewLintContext(), &rawNode) {
+		fileIndex, line := attributeLintLine(lint.Line)
+		if fileIndex >= len(mainPaths) {
+			// Lints from overrides without a main config.
+			lints = append(lints, fmt.Sprintf("line %v: %v", line, lint.What))
+			continue
 		}
-		for _, lint := range confSpec.LintYAML(docs.NewLintContext(), &rawNode) {
-			lints = append(lints, fmt.Sprintf("%vline %v: %v", lintFilePrefix, lint.Line, lint.What))
+		if !lintDisabled[fileIndex] {
+			lints = append(lints, fmt.Sprintf("%v: line %v: %v", mainPaths[fileIndex], line, lint.What))
 		}
 	}
 
+	resetYAMLLines(&rawNode)
 	err = rawNode.Decode(conf)
 	return
 }
@@ -406,9 +649,11 @@ func (r *Reader) reactMainUpdate(mgr bundle.NewManagement, strict bool) bool {
 	}
 
 	// Update any resources within the file.
-	if newInfo := resInfoFromConfig(&conf.ResourceConfig); !newInfo.applyChanges(mgr) {
+	newInfo := resInfoFromConfig(&conf.ResourceConfig)
+	if !newInfo.applyChanges(mgr, &r.mainResourceInfo) {
 		return false
 	}
+	r.mainResourceInfo = newInfo
 
 	return r.mainUpdateFn(conf.Config)
 }
@@ -1,17 +1,24 @@
 package config
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
 
@@ -127,3 +134,256 @@ output:
 	assert.Equal(t, "kafka", updatedConf.Input.Type)
 	assert.Equal(t, "aws_s3", updatedConf.Output.Type)
 }
+
+func TestReaderSecretsRefresh(t *testing.T) {
+	secrets.ResetCache()
+	t.Cleanup(secrets.ResetCache)
+
+	var secretMut sync.Mutex
+	secretValue := "foo"
+	secrets.RegisterProvider("readertest", secrets.ProviderFunc(func(ctx context.Context, path string) (string, error) {
+		secretMut.Lock()
+		defer secretMut.Unlock()
+		return secretValue, nil
+	}))
+
+	confDir := t.TempDir()
+
+	confFilePath := filepath.Join(confDir, "main.yaml")
+	require.NoError(t, os.WriteFile(confFilePath, []byte(`
+input:
+  generate:
+    mapping: 'root = "${secret:readertest:foo}"'
+output:
+  drop: {}
+`), 0o644))
+
+	rdr := newDummyReader(confFilePath)
+	rdr.secretsRefreshPeriod = 5 * time.Millisecond
+
+	conf := New()
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+	assert.Equal(t, `root = "foo"`, conf.Input.Generate.Mapping)
+
+	changeChan := make(chan struct{})
+	var updatedConf stream.Config
+	require.NoError(t, rdr.SubscribeConfigChanges(func(conf stream.Config) bool {
+		updatedConf = conf
+		close(changeChan)
+		return true
+	}))
+
+	testMgr, err := manager.New(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginFileWatching(testMgr, true))
+	t.Cleanup(func() {
+		_ = rdr.Close(context.Background())
+	})
+
+	secretMut.Lock()
+	secretValue = "bar"
+	secretMut.Unlock()
+
+	select {
+	case <-changeChan:
+	case <-time.After(time.Second):
+		require.FailNow(t, "Expected a config change to be triggered")
+	}
+
+	assert.Equal(t, `root = "bar"`, updatedConf.Input.Generate.Mapping)
+}
+
+func TestReaderSecretsRefreshWithoutWatcher(t *testing.T) {
+	secrets.ResetCache()
+	t.Cleanup(secrets.ResetCache)
+
+	var secretMut sync.Mutex
+	secretValue := "foo"
+	secrets.RegisterProvider("readertestnowatch", secrets.ProviderFunc(func(ctx context.Context, path string) (string, error) {
+		secretMut.Lock()
+		defer secretMut.Unlock()
+		return secretValue, nil
+	}))
+
+	confDir := t.TempDir()
+
+	confFilePath := filepath.Join(confDir, "main.yaml")
+	require.NoError(t, os.WriteFile(confFilePath, []byte(`
+input:
+  generate:
+    mapping: 'root = "${secret:readertestnowatch:foo}"'
+output:
+  drop: {}
+`), 0o644))
+
+	rdr := newDummyReader(confFilePath)
+	rdr.secretsRefreshPeriod = 5 * time.Millisecond
+
+	conf := New()
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+
+	changeChan := make(chan struct{})
+	var updatedConf stream.Config
+	require.NoError(t, rdr.SubscribeConfigChanges(func(conf stream.Config) bool {
+		updatedConf = conf
+		close(changeChan)
+		return true
+	}))
+
+	testMgr, err := manager.New(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginSecretsRefresh(testMgr, true))
+	t.Cleanup(func() {
+		_ = rdr.Close(context.Background())
+	})
+
+	// Refreshing secrets does not watch files
+	assert.Nil(t, rdr.watcher)
+	require.Error(t, rdr.BeginFileWatching(testMgr, true))
+
+	secretMut.Lock()
+	secretValue = "bar"
+	secretMut.Unlock()
+
+	select {
+	case <-changeChan:
+	case <-time.After(time.Second):
+		require.FailNow(t, "Expected a config change to be triggered")
+	}
+
+	assert.Equal(t, `root = "bar"`, updatedConf.Input.Generate.Mapping)
+}
+
+func TestReaderTriggerReload(t *testing.T) {
+	confDir := t.TempDir()
+
+	confFilePath := filepath.Join(confDir, "main.yaml")
+	require.NoError(t, os.WriteFile(confFilePath, []byte(`
+input:
+  kafka: {}
+output:
+  aws_s3: {}
+`), 0o644))
+
+	rdr := newDummyReader(confFilePath)
+
+	conf := New()
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+
+	changeChan := make(chan struct{})
+	var updatedConf stream.Config
+	require.NoError(t, rdr.SubscribeConfigChanges(func(conf stream.Config) bool {
+		updatedConf = conf
+		close(changeChan)
+		return true
+	}))
+
+	testMgr, err := manager.New(manager.NewResourceConfig(), nil, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+	require.NoError(t, rdr.BeginFileWatching(testMgr, true))
+	t.Cleanup(func() {
+		_ = rdr.Close(context.Background())
+	})
+
+	rdr.TriggerReload()
+
+	select {
+	case <-changeChan:
+	case <-time.After(time.Second):
+		require.FailNow(t, "Expected a config change to be triggered")
+	}
+
+	assert.Equal(t, "kafka", updatedConf.Input.Type)
+	assert.Equal(t, "aws_s3", updatedConf.Output.Type)
+}
+
+func TestResInfoFromConfigMultiple(t *testing.T) {
+	resConf := manager.NewResourceConfig()
+
+	fooCache := cache.NewConfig()
+	fooCache.Label = "foo"
+	fooCache.Type = "memory"
+
+	barCache := cache.NewConfig()
+	barCache.Label = "bar"
+	barCache.Type = "file"
+
+	resConf.ResourceCaches = append(resConf.ResourceCaches, fooCache, barCache)
+
+	info := resInfoFromConfig(&resConf)
+	require.Len(t, info.caches, 2)
+	assert.Equal(t, "memory", info.caches["foo"].Type)
+	assert.Equal(t, "file", info.caches["bar"].Type)
+}
+
+func TestReaderRedactEnvVariables(t *testing.T) {
+	for k, v := range map[string]string{
+		"BENTHOS_TEST_REDACT_ADDR":  "0.0.0.0:4196",
+		"BENTHOS_TEST_REDACT_TTL":   "10s",
+		"BENTHOS_TEST_REDACT_PASS":  "hunter2",
+		"BENTHOS_TEST_REDACT_LEVEL": "TRACE",
+	} {
+		require.NoError(t, os.Setenv(k, v))
+		k := k
+		t.Cleanup(func() {
+			_ = os.Unsetenv(k)
+		})
+	}
+
+	confDir := t.TempDir()
+
+	confFilePath := filepath.Join(confDir, "main.yaml")
+	require.NoError(t, os.WriteFile(confFilePath, []byte(`
+http:
+  address: ${BENTHOS_TEST_REDACT_ADDR}
+logger:
+  level: ${BENTHOS_TEST_REDACT_LEVEL}
+cache_resources:
+  - label: foo
+    memory:
+      default_ttl: ${BENTHOS_TEST_REDACT_TTL}
+`), 0o644))
+
+	resFilePath := filepath.Join(confDir, "resources.yaml")
+	require.NoError(t, os.WriteFile(resFilePath, []byte(`
+cache_resources:
+  - label: bar
+    redis:
+      url: redis://:${BENTHOS_TEST_REDACT_PASS}@localhost:6379
+`), 0o644))
+
+	rdr := NewReader(confFilePath, []string{resFilePath}, OptAddOverrides("logger.level=DEBUG"))
+
+	conf := New()
+	_, err := rdr.Read(&conf)
+	require.NoError(t, err)
+	require.Len(t, conf.ResourceCaches, 2)
+	assert.Equal(t, "bar", conf.ResourceCaches[1].Label)
+
+	var node yaml.Node
+	require.NoError(t, node.Encode(conf))
+
+	sanitConf := docs.NewSanitiseConfig()
+	sanitConf.RemoveTypeField = true
+	require.NoError(t, Spec().SanitiseYAML(&node, sanitConf))
+
+	require.NoError(t, rdr.RedactEnvVariables(&node))
+
+	redacted, err := yaml.Marshal(&node)
+	require.NoError(t, err)
+	assert.NotContains(t, string(redacted), "hunter2")
+
+	for path, exp := range map[string]string{
+		"http.address":                         "${BENTHOS_TEST_REDACT_ADDR}",
+		"logger.level":                         "DEBUG",
+		"cache_resources.0.memory.default_ttl": "${BENTHOS_TEST_REDACT_TTL}",
+		"cache_resources.1.redis.url":          "redis://:${BENTHOS_TEST_REDACT_PASS}@localhost:6379",
+	} {
+		target, err := docs.GetYAMLPath(&node, strings.Split(path, ".")...)
+		require.NoError(t, err, path)
+		assert.Equal(t, exp, target.Value, path)
+	}
+}
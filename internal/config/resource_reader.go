@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"reflect"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -47,18 +49,23 @@ func resInfoFromConfig(conf *manager.ResourceConfig) resourceFileInfo {
 
 	// New style
 	for _, c := range conf.ResourceInputs {
+		c := c
 		resInfo.inputs[c.Label] = &c
 	}
 	for _, c := range conf.ResourceProcessors {
+		c := c
 		resInfo.processors[c.Label] = &c
 	}
 	for _, c := range conf.ResourceOutputs {
+		c := c
 		resInfo.outputs[c.Label] = &c
 	}
 	for _, c := range conf.ResourceCaches {
+		c := c
 		resInfo.caches[c.Label] = &c
 	}
 	for _, c := range conf.ResourceRateLimits {
+		c := c
 		resInfo.rateLimits[c.Label] = &c
 	}
 
@@ -95,6 +102,74 @@ func (r *Reader) readResources(conf *manager.ResourceConfig) (lints []string, er
 	return
 }
 
+// appendRawResources appends the resources of each resource file, without
+// replacing environment variable references, to the resource lists of a raw
+// config node in the same order as readResources.
+func (r *Reader) appendRawResources(root *yaml.Node) error {
+	resourcesPaths, err := r.resourcePathsExpanded()
+	if err != nil {
+		return err
+	}
+
+	if root.Kind == 0 {
+		root.Kind = yaml.MappingNode
+	}
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for _, path := range resourcesPaths {
+		rawBytes, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var fileNode yaml.Node
+		if err := yaml.Unmarshal(rawBytes, &fileNode); err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+		if len(fileNode.Content) == 0 || fileNode.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+
+		resNode := fileNode.Content[0]
+		for i := 0; i < len(resNode.Content)-1; i += 2 {
+			key, value := resNode.Content[i], resNode.Content[i+1]
+			if value.Kind != yaml.SequenceNode {
+				continue
+			}
+			if !isResourceField(key.Value) {
+				continue
+			}
+
+			var target *yaml.Node
+			for j := 0; j < len(root.Content)-1; j += 2 {
+				if root.Content[j].Value == key.Value {
+					target = root.Content[j+1]
+					break
+				}
+			}
+			if target == nil || target.Kind != yaml.SequenceNode {
+				target = &yaml.Node{Kind: yaml.SequenceNode}
+				root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key.Value}, target)
+			}
+			target.Content = append(target.Content, value.Content...)
+		}
+	}
+	return nil
+}
+
+func isResourceField(name string) bool {
+	for _, f := range manager.Spec() {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func readResource(path string, conf *manager.ResourceConfig) (lints []string, err error) {
 	defer func() {
 		if err != nil {
@@ -152,11 +227,11 @@ func (r *Reader) reactResourceUpdate(mgr bundle.NewManagement, strict bool, path
 	}
 
 	// TODO: Should we error out if the new config is missing some resources?
-	// (as they will continue to exist). Also, we could avoid restarting
-	// resources where the config hasn't changed.
+	// (as they will continue to exist).
 
+	prevInfo := r.resourceFileInfo[path]
 	newInfo := resInfoFromConfig(&newResConf)
-	if !newInfo.applyChanges(mgr) {
+	if !newInfo.applyChanges(mgr, &prevInfo) {
 		return false
 	}
 
@@ -164,7 +239,10 @@ func (r *Reader) reactResourceUpdate(mgr bundle.NewManagement, strict bool, path
 	return true
 }
 
-func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
+// applyChanges stores each resource of the file info within a manager, where
+// resources with a config identical to that of the previous file info (when
+// provided) are left untouched.
+func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement, prev *resourceFileInfo) bool {
 	// Kind of arbitrary, but I feel better about having some sort of timeout.
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
 	defer cancel()
@@ -173,6 +251,9 @@ func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
 	// with components that could be dependencies of other components. This is
 	// a "best attempt", so not all edge cases need to be accounted for.
 	for k, v := range i.rateLimits {
+		if prev != nil && reflect.DeepEqual(prev.rateLimits[k], v) {
+			continue
+		}
 		if err := mgr.StoreRateLimit(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return false
@@ -180,6 +261,9 @@ func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k, v := range i.caches {
+		if prev != nil && reflect.DeepEqual(prev.caches[k], v) {
+			continue
+		}
 		if err := mgr.StoreCache(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return false
@@ -187,6 +271,9 @@ func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k, v := range i.processors {
+		if prev != nil && reflect.DeepEqual(prev.processors[k], v) {
+			continue
+		}
 		if err := mgr.StoreProcessor(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return false
@@ -194,6 +281,9 @@ func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k, v := range i.inputs {
+		if prev != nil && reflect.DeepEqual(prev.inputs[k], v) {
+			continue
+		}
 		if err := mgr.StoreInput(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return false
@@ -201,6 +291,9 @@ func (i *resourceFileInfo) applyChanges(mgr bundle.NewManagement) bool {
 		mgr.Logger().Infof("Updated resource %v config from file.", k)
 	}
 	for k, v := range i.outputs {
+		if prev != nil && reflect.DeepEqual(prev.outputs[k], v) {
+			continue
+		}
 		if err := mgr.StoreOutput(ctx, k, *v); err != nil {
 			mgr.Logger().Errorf("Failed to update resource %v: %v", k, err)
 			return false
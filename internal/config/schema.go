@@ -2,13 +2,17 @@ package config
 
 import (
 	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/bundle/tracing"
 	tdocs "github.com/benthosdev/benthos/v4/internal/cli/test/docs"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/tracer"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/flowcontrol"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/stream"
+	"github.com/benthosdev/benthos/v4/internal/stream/audit"
+	"github.com/benthosdev/benthos/v4/internal/stream/store"
 )
 
 // Type is the Benthos service configuration struct.
@@ -16,24 +20,36 @@ type Type struct {
 	HTTP                   api.Config `json:"http" yaml:"http"`
 	stream.Config          `json:",inline" yaml:",inline"`
 	manager.ResourceConfig `json:",inline" yaml:",inline"`
-	Logger                 log.Config     `json:"logger" yaml:"logger"`
-	Metrics                metrics.Config `json:"metrics" yaml:"metrics"`
-	Tracer                 tracer.Config  `json:"tracer" yaml:"tracer"`
-	SystemCloseTimeout     string         `json:"shutdown_timeout" yaml:"shutdown_timeout"`
-	Tests                  []interface{}  `json:"tests,omitempty" yaml:"tests,omitempty"`
+	Logger                 log.Config                   `json:"logger" yaml:"logger"`
+	Metrics                metrics.Config               `json:"metrics" yaml:"metrics"`
+	Tracer                 tracer.Config                `json:"tracer" yaml:"tracer"`
+	MessageTracing         tracing.MessageTracingConfig `json:"message_tracing" yaml:"message_tracing"`
+	StreamsAuditLog        audit.Config                 `json:"streams_audit_log" yaml:"streams_audit_log"`
+	StreamsStore           store.Config                 `json:"streams_store" yaml:"streams_store"`
+	FlowControl            flowcontrol.Config           `json:"flow_control" yaml:"flow_control"`
+	SystemCloseTimeout     string                       `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	ShutdownDrainTimeout   string                       `json:"shutdown_drain_timeout" yaml:"shutdown_drain_timeout"`
+	ShutdownFlushTimeout   string                       `json:"shutdown_flush_timeout" yaml:"shutdown_flush_timeout"`
+	Tests                  []interface{}                `json:"tests,omitempty" yaml:"tests,omitempty"`
 }
 
 // New returns a new configuration with default values.
 func New() Type {
 	return Type{
-		HTTP:               api.NewConfig(),
-		Config:             stream.NewConfig(),
-		ResourceConfig:     manager.NewResourceConfig(),
-		Logger:             log.NewConfig(),
-		Metrics:            metrics.NewConfig(),
-		Tracer:             tracer.NewConfig(),
-		SystemCloseTimeout: "20s",
-		Tests:              nil,
+		HTTP:                 api.NewConfig(),
+		Config:               stream.NewConfig(),
+		ResourceConfig:       manager.NewResourceConfig(),
+		Logger:               log.NewConfig(),
+		Metrics:              metrics.NewConfig(),
+		Tracer:               tracer.NewConfig(),
+		MessageTracing:       tracing.NewMessageTracingConfig(),
+		StreamsAuditLog:      audit.NewConfig(),
+		StreamsStore:         store.NewConfig(),
+		FlowControl:          flowcontrol.NewConfig(),
+		SystemCloseTimeout:   "20s",
+		ShutdownDrainTimeout: "",
+		ShutdownFlushTimeout: "",
+		Tests:                nil,
 	}
 }
 
@@ -43,7 +59,13 @@ var observabilityFields = docs.FieldSpecs{
 	docs.FieldObject("logger", "Describes how operational logs should be emitted.").WithChildren(log.Spec()...),
 	docs.FieldMetrics("metrics", "A mechanism for exporting metrics.").Optional(),
 	docs.FieldTracer("tracer", "A mechanism for exporting traces.").Optional(),
+	tracing.MessageTracingSpec(),
+	audit.Spec(),
+	store.Spec(),
+	flowcontrol.Spec(),
 	docs.FieldString("shutdown_timeout", "The maximum period of time to wait for a clean shutdown. If this time is exceeded Benthos will forcefully close.").HasDefault("20s"),
+	docs.FieldString("shutdown_drain_timeout", "The maximum period of time to wait during a shutdown for in-flight and buffered messages to be delivered, after which the remaining components are closed without waiting for their messages to be delivered. When empty three quarters of the `shutdown_timeout` is used.", "10s").HasDefault("").Advanced(),
+	docs.FieldString("shutdown_flush_timeout", "The maximum period of time to wait during a shutdown for outputs to flush pending messages once all upstream components have closed. When empty outputs are given whatever remains of the drain period.", "5s").HasDefault("").Advanced(),
 }
 
 // Spec returns a docs.FieldSpec for an entire Benthos configuration.
@@ -1,6 +1,11 @@
 package schema
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/config"
@@ -147,3 +152,69 @@ func scrubComponentSpecs(cs []docs.ComponentSpec) {
 		scrubFieldSpec(&cs[i].Config)
 	}
 }
+
+// HandlerFunc returns an HTTP handler that responds with the schema of all
+// registered components as JSON. The query parameter `format` can be set to
+// `json` (the default) for a flattened list of component names, `json-full`
+// for the full schema, or `json-full-scrubbed` for the full schema without
+// descriptions. The query parameter `types` can be set to a comma separated
+// list of component types in order to filter the flattened list.
+func HandlerFunc(version, date string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s := New(version, date)
+
+		var res interface{}
+		switch format := r.URL.Query().Get("format"); format {
+		case "", "json":
+			flat := s.Flattened()
+			if typesStr := r.URL.Query().Get("types"); typesStr != "" {
+				ofTypes := map[string]struct{}{}
+				for _, t := range strings.Split(typesStr, ",") {
+					ofTypes[strings.TrimSpace(t)] = struct{}{}
+				}
+				for k := range flat {
+					if _, exists := ofTypes[k]; !exists {
+						delete(flat, k)
+					}
+				}
+			}
+			res = flat
+		case "json-full":
+			res = s
+		case "json-full-scrubbed":
+			s.Scrub()
+			res = s
+		case "jsonschema":
+			res = s.JSONSchema()
+		default:
+			http.Error(w, fmt.Sprintf("Format not recognised: %v", format), http.StatusBadRequest)
+			return
+		}
+
+		resBytes, err := json.Marshal(res)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
+
+// JSONSchema returns a JSON schema describing the entire config file format,
+// including all registered plugins.
+func (f *Full) JSONSchema() map[string]interface{} {
+	root, _ := docs.FieldObject("", "").WithChildren(f.Config...).JSONSchema().(map[string]interface{})
+	root["$schema"] = "https://json-schema.org/draft/2019-09/schema"
+	root["$defs"] = map[string]interface{}{
+		"buffer":     docs.ComponentsJSONSchema(docs.TypeBuffer, f.Buffers),
+		"cache":      docs.ComponentsJSONSchema(docs.TypeCache, f.Caches),
+		"input":      docs.ComponentsJSONSchema(docs.TypeInput, f.Inputs),
+		"output":     docs.ComponentsJSONSchema(docs.TypeOutput, f.Outputs),
+		"processor":  docs.ComponentsJSONSchema(docs.TypeProcessor, f.Processors),
+		"rate_limit": docs.ComponentsJSONSchema(docs.TypeRateLimit, f.RateLimits),
+		"metrics":    docs.ComponentsJSONSchema(docs.TypeMetrics, f.Metrics),
+		"tracer":     docs.ComponentsJSONSchema(docs.TypeTracer, f.Tracers),
+	}
+	return root
+}
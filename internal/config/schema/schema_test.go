@@ -0,0 +1,160 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config/schema"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
+)
+
+func TestSchemaHandlerFunc(t *testing.T) {
+	handler := schema.HandlerFunc("1.2.3", "today")
+
+	request := func(target string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	res := request("/schema")
+	require.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+
+	var flat map[string][]string
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &flat))
+	assert.Contains(t, flat["processors"], "bloblang")
+	assert.Contains(t, flat["inputs"], "generate")
+
+	res = request("/schema?types=inputs,outputs")
+	require.Equal(t, http.StatusOK, res.Code)
+
+	flat = nil
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &flat))
+	assert.Len(t, flat, 2)
+	assert.Contains(t, flat["inputs"], "generate")
+	assert.Contains(t, flat["outputs"], "drop")
+
+	res = request("/schema?format=json-full")
+	require.Equal(t, http.StatusOK, res.Code)
+
+	var full struct {
+		Version    string `json:"version"`
+		Processors []struct {
+			Name    string `json:"name"`
+			Summary string `json:"summary"`
+		} `json:"processors"`
+	}
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &full))
+	assert.Equal(t, "1.2.3", full.Version)
+
+	var summary string
+	for _, p := range full.Processors {
+		if p.Name == "bloblang" {
+			summary = p.Summary
+		}
+	}
+	assert.NotEmpty(t, summary)
+
+	res = request("/schema?format=json-full-scrubbed")
+	require.Equal(t, http.StatusOK, res.Code)
+
+	full.Processors = nil
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &full))
+	assert.NotEmpty(t, full.Processors)
+	for _, p := range full.Processors {
+		assert.Empty(t, p.Summary, p.Name)
+	}
+
+	res = request("/schema?format=nope")
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+}
+
+func TestSchemaJSONSchema(t *testing.T) {
+	sch := schema.New("1.2.3", "today")
+
+	jsonSchema := sch.JSONSchema()
+	// Swap the draft for one supported by the validator, the structure of the
+	// schema is compatible with both.
+	jsonSchema["$schema"] = "http://json-schema.org/draft-07/schema#"
+
+	validator, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(jsonSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		config string
+		valid  bool
+	}{
+		{
+			name: "valid config",
+			config: `
+input:
+  label: foo
+  generate:
+    mapping: 'root = "hello world"'
+  processors:
+    - bloblang: 'root = content().uppercase()'
+pipeline:
+  threads: 2
+  processors:
+    - label: bar
+      bloblang: 'root = this'
+output:
+  type: drop
+`,
+			valid: true,
+		},
+		{
+			name: "unknown field",
+			config: `
+input:
+  generate:
+    mapping: 'root = "hello world"'
+    nope: true
+`,
+		},
+		{
+			name: "unknown component",
+			config: `
+pipeline:
+  processors:
+    - nope: {}
+`,
+		},
+		{
+			name: "unknown root field",
+			config: `
+nope: {}
+`,
+		},
+		{
+			name: "wrong field type",
+			config: `
+pipeline:
+  threads: nope
+`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var v interface{}
+			require.NoError(t, yaml.Unmarshal([]byte(test.config), &v))
+
+			res, err := validator.Validate(gojsonschema.NewGoLoader(v))
+			require.NoError(t, err)
+			assert.Equal(t, test.valid, res.Valid(), res.Errors())
+		})
+	}
+}
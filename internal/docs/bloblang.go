@@ -365,6 +365,8 @@ func BloblangMethodsMarkdown() ([]byte, error) {
 		query.MethodCategoryObjectAndArray,
 		query.MethodCategoryParsing,
 		query.MethodCategoryEncoding,
+		query.MethodCategoryNetwork,
+		query.MethodCategoryGeo,
 		query.MethodCategoryGeoIP,
 		query.MethodCategoryDeprecated,
 	} {
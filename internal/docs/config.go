@@ -197,11 +197,50 @@ type SanitiseConfig struct {
 	ForExample       bool
 	Filter           FieldFilter
 	DocsProvider     Provider
+
+	// DocsComments adds the summary of each component and the description of
+	// each field to the sanitised config as YAML comments.
+	DocsComments bool
+
+	// RedactSecrets replaces the non-empty values of fields marked as secret
+	// with RedactedValue.
+	RedactSecrets bool
 }
 
+// RedactedValue replaces the values of secret fields when a config is sanitised
+// with RedactSecrets enabled.
+const RedactedValue = "!!!REDACTED!!!"
+
 // NewSanitiseConfig creates a new sanitise config.
 func NewSanitiseConfig() SanitiseConfig {
 	return SanitiseConfig{
 		DocsProvider: DeprecatedProvider,
 	}
 }
+
+// docsComment converts the first paragraph of a markdown description into a
+// YAML comment wrapped at a reasonable line width.
+func docsComment(description string) string {
+	paragraph := strings.TrimSpace(description)
+	if i := strings.Index(paragraph, "\n\n"); i >= 0 {
+		paragraph = paragraph[:i]
+	}
+
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(paragraph) {
+		if line != "" && len(line)+len(word) >= 78 {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line == "" {
+			line = "# " + word
+		} else {
+			line += " " + word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -95,6 +95,11 @@ type FieldSpec struct {
 	// is missing.
 	IsOptional bool `json:"is_optional,omitempty"`
 
+	// IsSecret is true for fields that contain sensitive information such as
+	// passwords or tokens, the values of which are redacted when configs are
+	// recorded.
+	IsSecret bool `json:"is_secret,omitempty"`
+
 	// Default value of the field.
 	Default *interface{} `json:"default,omitempty"`
 
@@ -153,6 +158,13 @@ func (f FieldSpec) Optional() FieldSpec {
 	return f
 }
 
+// Secret marks this field as containing sensitive information, such as a
+// password or token.
+func (f FieldSpec) Secret() FieldSpec {
+	f.IsSecret = true
+	return f
+}
+
 // Advanced marks this field as being advanced, and therefore not commonly used.
 func (f FieldSpec) Advanced() FieldSpec {
 	f.IsAdvanced = true
@@ -617,6 +629,17 @@ func (f FieldSpec) needsDefault() bool {
 	return true
 }
 
+// lintRequired returns true if the absence of this field within a config
+// should be reported as a linting error.
+func (f FieldSpec) lintRequired() bool {
+	_, isCore := f.Type.IsCoreComponent()
+	return f.needsDefault() &&
+		f.Default == nil &&
+		!isCore &&
+		f.Kind == KindScalar &&
+		len(f.Children) == 0
+}
+
 func getDefault(pathName string, field FieldSpec) (interface{}, error) {
 	if field.Default != nil {
 		// TODO: Should be deep copy here?
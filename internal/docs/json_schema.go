@@ -3,6 +3,9 @@ package docs
 // JSONSchema serializes a field spec into a JSON schema structure.
 func (f FieldSpec) JSONSchema() interface{} {
 	spec := map[string]interface{}{}
+	if f.Description != "" {
+		spec["description"] = f.Description
+	}
 	switch f.Kind {
 	case Kind2DArray:
 		innerField := f
@@ -36,7 +39,7 @@ func (f FieldSpec) JSONSchema() interface{} {
 			spec["properties"] = f.Children.JSONSchema()
 			var required []string
 			for _, child := range f.Children {
-				if !child.IsOptional && child.Default == nil {
+				if child.lintRequired() {
 					required = append(required, child.Name)
 				}
 			}
@@ -73,3 +76,48 @@ func (f FieldSpecs) JSONSchema() map[string]interface{} {
 	}
 	return spec
 }
+
+// ComponentsJSONSchema serializes a list of component specs of a given type
+// into a JSON schema structure that matches a config of any one of them.
+func ComponentsJSONSchema(t Type, specs []ComponentSpec) map[string]interface{} {
+	reserved := map[string]interface{}{}
+	for name, field := range ReservedFieldsByType(t) {
+		// The plugin field is a legacy alternative to the named field and is
+		// not documented.
+		if name == "plugin" || name == "type" {
+			continue
+		}
+		reserved[name] = field.JSONSchema()
+	}
+
+	options := make([]interface{}, 0, len(specs))
+	for _, spec := range specs {
+		properties := map[string]interface{}{
+			"type": map[string]interface{}{
+				"const": spec.Name,
+			},
+			spec.Name: spec.Config.JSONSchema(),
+		}
+		for k, v := range reserved {
+			properties[k] = v
+		}
+		option := map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+			// A component can be identified either by its named field or by
+			// the type field.
+			"anyOf": []interface{}{
+				map[string]interface{}{"required": []string{spec.Name}},
+				map[string]interface{}{"required": []string{"type"}},
+			},
+		}
+		if spec.Summary != "" {
+			option["description"] = spec.Summary
+		}
+		options = append(options, option)
+	}
+	return map[string]interface{}{
+		"anyOf": options,
+	}
+}
@@ -0,0 +1,52 @@
+package docs
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j] + 1
+			if v := curr[j-1] + 1; v < curr[j] {
+				curr[j] = v
+			}
+			if v := prev[j-1] + cost; v < curr[j] {
+				curr[j] = v
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
+
+// closestMatch returns the option most similar to a target string, provided it
+// is close enough to plausibly be the result of a typo.
+func closestMatch(target string, options []string) (string, bool) {
+	maxDist := len(target) / 3
+	if maxDist < 2 {
+		maxDist = 2
+	}
+	if maxDist > 3 {
+		maxDist = 3
+	}
+	if len(target) <= 3 {
+		maxDist = 1
+	}
+
+	best, bestDist := "", maxDist+1
+	for _, opt := range options {
+		if d := levenshtein(target, opt); d < bestDist || (d == bestDist && opt < best) {
+			best, bestDist = opt, d
+		}
+	}
+	return best, bestDist <= maxDist
+}
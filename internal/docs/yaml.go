@@ -238,6 +238,9 @@ func SanitiseYAML(cType Type, node *yaml.Node, conf SanitiseConfig) error {
 		}
 
 		nameFound = true
+		if conf.DocsComments {
+			node.Content[i].HeadComment = docsComment(cSpec.Summary)
+		}
 		if err := cSpec.Config.SanitiseYAML(node.Content[i+1], conf); err != nil {
 			return err
 		}
@@ -252,6 +255,9 @@ func SanitiseYAML(cType Type, node *yaml.Node, conf SanitiseConfig) error {
 		if err := keyNode.Encode(name); err != nil {
 			return err
 		}
+		if conf.DocsComments {
+			keyNode.HeadComment = docsComment(cSpec.Summary)
+		}
 		bodyNode, err := cSpec.Config.ToYAML(conf.ForExample)
 		if err != nil {
 			return err
@@ -376,16 +382,47 @@ func (f FieldSpecs) SanitiseYAML(node *yaml.Node, conf SanitiseConfig) error {
 		if err := field.SanitiseYAML(value, conf); err != nil {
 			return err
 		}
+		if field.IsSecret && conf.RedactSecrets {
+			redactYAML(value)
+		}
 		var keyNode yaml.Node
 		if err := keyNode.Encode(field.Name); err != nil {
 			return err
 		}
+		if conf.DocsComments {
+			keyNode.HeadComment = docsComment(field.Description)
+		}
 		newNodes = append(newNodes, &keyNode, value)
 	}
 	node.Content = newNodes
 	return nil
 }
 
+// redactYAML replaces all non-empty scalar values within a node with
+// RedactedValue.
+func redactYAML(node *yaml.Node) {
+	node = unwrapDocumentNode(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			redactYAML(node.Content[i])
+		}
+	case yaml.SequenceNode:
+		for _, c := range node.Content {
+			redactYAML(c)
+		}
+	case yaml.ScalarNode:
+		if node.Value == "" {
+			return
+		}
+		node.Value = RedactedValue
+		node.Tag = "!!str"
+		node.Style = 0
+	case yaml.AliasNode:
+		*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: RedactedValue}
+	}
+}
+
 //------------------------------------------------------------------------------
 
 func lintYAMLFromOmit(parentSpec FieldSpecs, lintTargetSpec FieldSpec, parent, node *yaml.Node) []Lint {
@@ -489,13 +526,21 @@ func LintYAML(ctx LintContext, cType Type, node *yaml.Node) []Lint {
 		}
 		spec, exists := reservedFields[node.Content[i].Value]
 		if exists {
+			if ctx.RejectDeprecated && spec.IsDeprecated {
+				lints = append(lints, NewLintError(node.Content[i].Line, fmt.Sprintf("field %v is deprecated", spec.Name)))
+			}
 			lints = append(lints, lintYAMLFromOmit(cSpec.Config.Children, spec, node, node.Content[i+1])...)
 			lints = append(lints, spec.LintYAML(ctx, node.Content[i+1])...)
 		} else {
-			lints = append(lints, NewLintError(
-				node.Content[i].Line,
-				fmt.Sprintf("field %v is invalid when the component type is %v (%v)", node.Content[i].Value, name, cType),
-			))
+			msg := fmt.Sprintf("field %v is invalid when the component type is %v (%v)", node.Content[i].Value, name, cType)
+			reservedNames := make([]string, 0, len(reservedFields))
+			for k := range reservedFields {
+				reservedNames = append(reservedNames, k)
+			}
+			if match, ok := closestMatch(node.Content[i].Value, reservedNames); ok {
+				msg += fmt.Sprintf(", did you mean %v?", match)
+			}
+			lints = append(lints, NewLintError(node.Content[i].Line, msg))
 		}
 	}
 
@@ -509,10 +554,6 @@ func (f FieldSpec) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 
 	var lints []Lint
 
-	if ctx.RejectDeprecated && f.IsDeprecated {
-		lints = append(lints, NewLintError(node.Line, fmt.Sprintf("field %v is deprecated", f.Name)))
-	}
-
 	// Execute custom linters, if the kind is non-scalar this means we execute
 	// the linter from the perspective of both the scalar and higher level types
 	// and it's up to the linting implementation to distinguish between them.
@@ -561,10 +602,11 @@ func (f FieldSpec) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 
 	// Otherwise we're a leaf node, so do basic type checking
 	switch f.Type {
-	// TODO: Do proper checking for bool and number types.
 	case FieldTypeBool, FieldTypeString, FieldTypeInt, FieldTypeFloat:
 		if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
 			lints = append(lints, NewLintError(node.Line, fmt.Sprintf("expected %v value", f.Type)))
+		} else if !scalarMatchesType(f.Type, node) {
+			lints = append(lints, NewLintError(node.Line, fmt.Sprintf("expected %v value, got %v", f.Type, node.Value)))
 		}
 	case FieldTypeObject:
 		if node.Kind != yaml.MappingNode && node.Kind != yaml.AliasNode {
@@ -574,6 +616,38 @@ func (f FieldSpec) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 	return lints
 }
 
+// scalarMatchesType returns false if a scalar node holds a value that cannot be
+// decoded into the given bool or number field type.
+func scalarMatchesType(t FieldType, node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+		return true
+	}
+	var err error
+	switch t {
+	case FieldTypeBool:
+		var b bool
+		err = node.Decode(&b)
+	case FieldTypeInt:
+		var i int64
+		err = node.Decode(&i)
+	case FieldTypeFloat:
+		var f float64
+		err = node.Decode(&f)
+	}
+	return err == nil
+}
+
+func unrecognisedFieldMsg(f FieldSpecs, name string) string {
+	names := make([]string, 0, len(f))
+	for _, field := range f {
+		names = append(names, field.Name)
+	}
+	if match, ok := closestMatch(name, names); ok {
+		return fmt.Sprintf("field %v not recognised, did you mean %v?", name, match)
+	}
+	return fmt.Sprintf("field %v not recognised", name)
+}
+
 // LintYAML walks a yaml node and returns a list of linting errors found.
 func (f FieldSpecs) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 	node = unwrapDocumentNode(node)
@@ -597,22 +671,20 @@ func (f FieldSpecs) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 		spec, exists := specNames[node.Content[i].Value]
 		if !exists {
 			if node.Content[i+1].Kind != yaml.AliasNode {
-				lints = append(lints, NewLintError(node.Content[i].Line, fmt.Sprintf("field %v not recognised", node.Content[i].Value)))
+				lints = append(lints, NewLintError(node.Content[i].Line, unrecognisedFieldMsg(f, node.Content[i].Value)))
 			}
 			continue
 		}
+		if ctx.RejectDeprecated && spec.IsDeprecated {
+			lints = append(lints, NewLintError(node.Content[i].Line, fmt.Sprintf("field %v is deprecated", spec.Name)))
+		}
 		lints = append(lints, lintYAMLFromOmit(f, spec, node, node.Content[i+1])...)
 		lints = append(lints, spec.LintYAML(ctx, node.Content[i+1])...)
 		delete(specNames, node.Content[i].Value)
 	}
 
 	for name, remaining := range specNames {
-		_, isCore := remaining.Type.IsCoreComponent()
-		if remaining.needsDefault() &&
-			remaining.Default == nil &&
-			!isCore &&
-			remaining.Kind == KindScalar &&
-			len(remaining.Children) == 0 {
+		if remaining.lintRequired() {
 			lints = append(lints, NewLintError(node.Line, fmt.Sprintf("field %v is required", name)))
 		}
 	}
@@ -814,6 +814,30 @@ testlintfooinput:
 				docs.NewLintError(5, "expected string value"),
 			},
 		},
+		{
+			name:      "misspelled field",
+			inputType: docs.TypeInput,
+			inputConf: `
+testlintfooinput:
+  fooo1: hello world`,
+			res: []docs.Lint{
+				docs.NewLintError(3, "field fooo1 not recognised, did you mean foo1?"),
+			},
+		},
+		{
+			name:      "string for int",
+			inputType: docs.TypeInput,
+			inputConf: `
+testlintfooinput:
+  foo8:
+    key1:
+      foochild1: not a number
+    key2:
+      foochild1: 10`,
+			res: []docs.Lint{
+				docs.NewLintError(5, "expected int value, got not a number"),
+			},
+		},
 		{
 			name:      "nested map fields",
 			inputType: docs.TypeInput,
@@ -1245,3 +1269,45 @@ processors:
 		})
 	}
 }
+
+func TestYAMLSanitationDocsComments(t *testing.T) {
+	prov := docs.NewMappedDocsProvider()
+	prov.RegisterDocs(docs.ComponentSpec{
+		Name:    "testyamlsanitcomments",
+		Type:    docs.TypeInput,
+		Summary: "A test input.",
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("foo", "A short description."),
+			docs.FieldString("bar", `
+A long description that goes on and on and on for much longer than a single line of a config would allow.
+
+Only the first paragraph is used.`),
+			docs.FieldString("baz", ""),
+		),
+	})
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+testyamlsanitcomments:
+  baz: c
+  bar: b
+  foo: a
+`), &node))
+
+	sanitConf := docs.NewSanitiseConfig()
+	sanitConf.DocsProvider = prov
+	sanitConf.DocsComments = true
+	require.NoError(t, docs.SanitiseYAML(docs.TypeInput, &node, sanitConf))
+
+	resBytes, err := yaml.Marshal(node.Content[0])
+	require.NoError(t, err)
+	assert.Equal(t, `# A test input.
+testyamlsanitcomments:
+    # A short description.
+    foo: a
+    # A long description that goes on and on and on for much longer than a single
+    # line of a config would allow.
+    bar: b
+    baz: c
+`, string(resBytes))
+}
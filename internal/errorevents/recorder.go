@@ -0,0 +1,313 @@
+// Package errorevents provides a central place for recording structured events
+// describing failures that occur within a Benthos pipeline.
+package errorevents
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// Classes of failure events.
+const (
+	ClassProcessing = "processing"
+	ClassDelivery   = "delivery"
+)
+
+// Event describes a single failure observed within a pipeline.
+type Event struct {
+	Time   time.Time `json:"time"`
+	Stream string    `json:"stream,omitempty"`
+	Path   string    `json:"path"`
+	Label  string    `json:"label,omitempty"`
+	Class  string    `json:"class"`
+	Error  string    `json:"error"`
+	Sample string    `json:"sample,omitempty"`
+}
+
+// Summary aggregates the failures of a given class emitted by a component.
+type Summary struct {
+	Stream    string    `json:"stream,omitempty"`
+	Path      string    `json:"path"`
+	Class     string    `json:"class"`
+	Count     int64     `json:"count"`
+	LastError string    `json:"last_error"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+type summaryKey struct {
+	stream, path, class string
+}
+
+// SampleSize is the maximum number of bytes of a message payload captured by
+// an event.
+const SampleSize = 128
+
+const defaultCapacity = 100
+
+// Recorder stores the most recent failure events of a service along with a
+// summary of all failures observed, and exposes them as metrics and via an
+// HTTP endpoint.
+type Recorder struct {
+	stream string
+	*state
+}
+
+type state struct {
+	mut       sync.Mutex
+	recent    []Event
+	next      int
+	summaries map[summaryKey]*Summary
+	deadLet   map[string]int64
+	samples   bool
+
+	mEvents  metrics.StatCounterVec
+	mDeadLet metrics.StatCounterVec
+
+	nowFn func() time.Time
+}
+
+// NewRecorder creates a new error event recorder that emits metrics to the
+// provided exporter.
+func NewRecorder(stats metrics.Type) *Recorder {
+	return &Recorder{state: &state{
+		recent:    make([]Event, 0, defaultCapacity),
+		summaries: map[summaryKey]*Summary{},
+		deadLet:   map[string]int64{},
+		mEvents:   stats.GetCounterVec("error_events", "error_class", "error_path"),
+		mDeadLet:  stats.GetCounterVec("error_dead_lettered", "error_stream"),
+		nowFn:     time.Now,
+	}}
+}
+
+// ForStream returns a variant of this recorder to be used by a particular
+// stream, where events recorded are attributed to that stream.
+func (r *Recorder) ForStream(id string) *Recorder {
+	return &Recorder{stream: id, state: r.state}
+}
+
+// IncludeSamples sets whether events should include a sample of the payload
+// of the message that failed. Payloads may contain sensitive data and are
+// therefore omitted unless enabled. This must be called before any events are
+// recorded.
+func (r *Recorder) IncludeSamples(enabled bool) {
+	r.samples = enabled
+}
+
+// Sample returns a truncated copy of a payload suitable for adding to an
+// event, or an empty string if samples are not enabled.
+func (r *Recorder) Sample(b []byte) string {
+	if !r.samples {
+		return ""
+	}
+	if len(b) > SampleSize {
+		b = b[:SampleSize]
+	}
+	return string(b)
+}
+
+type recordedKey struct{}
+
+// recorded tracks the paths of the components that have recorded a failure of
+// a message. It is shared by all copies of the message as they're made from
+// within nested components.
+type recorded struct {
+	mut   sync.Mutex
+	paths map[string]struct{}
+}
+
+// Track prepares the messages of a batch about to be processed by the
+// component at a given path, so that failures recorded by components nested
+// within it can be identified with RecordPartErr. Failures previously recorded
+// by the component, or those nested within it, are forgotten as the messages
+// are being processed by it again.
+func (r *Recorder) Track(msg *message.Batch, path string) {
+	parts := make([]*message.Part, 0, msg.Len())
+	changed := false
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		if rec, ok := p.GetContext().Value(recordedKey{}).(*recorded); ok {
+			rec.mut.Lock()
+			for k := range rec.paths {
+				if isWithinPath(k, path) {
+					delete(rec.paths, k)
+				}
+			}
+			rec.mut.Unlock()
+			parts = append(parts, p)
+			return nil
+		}
+		parts = append(parts, p.WithContext(context.WithValue(p.GetContext(), recordedKey{}, &recorded{})))
+		changed = true
+		return nil
+	})
+	if changed {
+		msg.SetAll(parts)
+	}
+}
+
+// RecordPartErr adds an event for a message that failed with a given error,
+// unless the failure of that message has already been recorded by a component
+// nested within the path of the event. This prevents errors bubbling up
+// through nested components from being recorded more than once.
+func (r *Recorder) RecordPartErr(p *message.Part, err error, e Event) {
+	if err == nil {
+		return
+	}
+	if rec, ok := p.GetContext().Value(recordedKey{}).(*recorded); ok {
+		rec.mut.Lock()
+		for k := range rec.paths {
+			if isWithinPath(k, e.Path) {
+				rec.mut.Unlock()
+				return
+			}
+		}
+		if rec.paths == nil {
+			rec.paths = map[string]struct{}{}
+		}
+		rec.paths[e.Path] = struct{}{}
+		rec.mut.Unlock()
+	}
+	e.Error = err.Error()
+	e.Sample = r.Sample(p.Get())
+	r.Record(e)
+}
+
+func isWithinPath(path, parent string) bool {
+	return path == parent || strings.HasPrefix(path, parent+".")
+}
+
+// Record adds a failure event.
+func (r *Recorder) Record(e Event) {
+	if e.Stream == "" {
+		e.Stream = r.stream
+	}
+	if e.Time.IsZero() {
+		e.Time = r.nowFn()
+	}
+	r.mEvents.With(e.Class, e.Path).Incr(1)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if len(r.recent) < cap(r.recent) {
+		r.recent = append(r.recent, e)
+	} else {
+		r.recent[r.next] = e
+	}
+	r.next = (r.next + 1) % cap(r.recent)
+
+	key := summaryKey{stream: e.Stream, path: e.Path, class: e.Class}
+	s, exists := r.summaries[key]
+	if !exists {
+		s = &Summary{Stream: e.Stream, Path: e.Path, Class: e.Class}
+		r.summaries[key] = s
+	}
+	s.Count++
+	s.LastError = e.Error
+	s.LastSeen = e.Time
+}
+
+// RecordDeadLettered counts messages that were delivered successfully by the
+// output of a stream whilst flagged as having failed processing, which is how
+// messages are typically routed to a dead-letter queue.
+func (r *Recorder) RecordDeadLettered(n int) {
+	if n <= 0 {
+		return
+	}
+	r.mDeadLet.With(r.stream).Incr(int64(n))
+
+	r.mut.Lock()
+	r.deadLet[r.stream] += int64(n)
+	r.mut.Unlock()
+}
+
+// Recent returns the most recent events, ordered from oldest to newest.
+func (r *Recorder) Recent() []Event {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	events := make([]Event, 0, len(r.recent))
+	if len(r.recent) < cap(r.recent) {
+		return append(events, r.recent...)
+	}
+	events = append(events, r.recent[r.next:]...)
+	return append(events, r.recent[:r.next]...)
+}
+
+// Summaries returns a summary of failures for each component and class,
+// ordered by stream and path.
+func (r *Recorder) Summaries() []Summary {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	summaries := make([]Summary, 0, len(r.summaries))
+	for _, s := range r.summaries {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Stream != summaries[j].Stream {
+			return summaries[i].Stream < summaries[j].Stream
+		}
+		if summaries[i].Path != summaries[j].Path {
+			return summaries[i].Path < summaries[j].Path
+		}
+		return summaries[i].Class < summaries[j].Class
+	})
+	return summaries
+}
+
+// Reset removes all recorded events and summaries.
+func (r *Recorder) Reset() {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.recent = r.recent[:0]
+	r.next = 0
+	r.summaries = map[summaryKey]*Summary{}
+	r.deadLet = map[string]int64{}
+}
+
+// HandlerFunc returns an HTTP handler where a GET request returns the recorded
+// events as JSON and a DELETE request resets them.
+func (r *Recorder) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodDelete:
+			r.Reset()
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		r.mut.Lock()
+		deadLet := make(map[string]int64, len(r.deadLet))
+		for k, v := range r.deadLet {
+			deadLet[k] = v
+		}
+		r.mut.Unlock()
+
+		resBytes, err := json.Marshal(struct {
+			Summaries    []Summary        `json:"summaries"`
+			Recent       []Event          `json:"recent"`
+			DeadLettered map[string]int64 `json:"dead_lettered"`
+		}{
+			Summaries:    r.Summaries(),
+			Recent:       r.Recent(),
+			DeadLettered: deadLet,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
@@ -0,0 +1,133 @@
+package errorevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestRecorderSummaries(t *testing.T) {
+	stats := metrics.NewLocal()
+	r := NewRecorder(stats)
+	r.nowFn = func() time.Time {
+		return time.Unix(10, 0).UTC()
+	}
+
+	r.Record(Event{Path: "root.pipeline.processors.0", Class: ClassProcessing, Error: "a failed"})
+	r.Record(Event{Path: "root.pipeline.processors.0", Class: ClassProcessing, Error: "b failed"})
+	r.ForStream("foo").Record(Event{Path: "root.output", Class: ClassDelivery, Error: "c failed"})
+	r.ForStream("foo").RecordDeadLettered(3)
+
+	assert.Equal(t, []Summary{
+		{Path: "root.pipeline.processors.0", Class: ClassProcessing, Count: 2, LastError: "b failed", LastSeen: time.Unix(10, 0).UTC()},
+		{Stream: "foo", Path: "root.output", Class: ClassDelivery, Count: 1, LastError: "c failed", LastSeen: time.Unix(10, 0).UTC()},
+	}, r.Summaries())
+
+	assert.Equal(t, map[string]int64{
+		`error_events{error_class="processing",error_path="root.pipeline.processors.0"}`: 2,
+		`error_events{error_class="delivery",error_path="root.output"}`:                  1,
+		`error_dead_lettered{error_stream="foo"}`:                                        3,
+	}, stats.GetCounters())
+}
+
+func TestRecorderNestedFailures(t *testing.T) {
+	r := NewRecorder(metrics.Noop())
+
+	outer := Event{Path: "root.pipeline.processors.0", Class: ClassProcessing}
+	inner := Event{Path: "root.pipeline.processors.0.branch.processors.0", Class: ClassProcessing}
+	sibling := Event{Path: "root.pipeline.processors.01", Class: ClassProcessing}
+
+	errShared := errors.New("shared failure")
+
+	msg := message.QuickBatch([][]byte{[]byte("foo"), []byte("bar")})
+	r.Track(msg, outer.Path)
+
+	// Copies made within nested components are the same message.
+	nested := msg.Copy()
+	r.Track(nested, inner.Path)
+	r.RecordPartErr(nested.Get(0), errShared, inner)
+
+	// The failure bubbles up with a different error value for the first
+	// message, and the same error value is shared by both messages.
+	r.RecordPartErr(msg.Get(0), fmt.Errorf("processors failed: %w", errShared), outer)
+	r.RecordPartErr(msg.Get(1), errShared, outer)
+
+	// A component outside of the nested path records its own failure.
+	r.Track(msg, sibling.Path)
+	r.RecordPartErr(msg.Get(0), errShared, sibling)
+
+	recent := r.Recent()
+	require.Len(t, recent, 3)
+	assert.Equal(t, inner.Path, recent[0].Path)
+	assert.Equal(t, outer.Path, recent[1].Path)
+	assert.Equal(t, sibling.Path, recent[2].Path)
+	for _, e := range recent {
+		assert.Equal(t, "shared failure", e.Error)
+	}
+
+	// Processing the messages again forgets the failures previously recorded
+	// within the path.
+	r.Track(msg, outer.Path)
+	r.RecordPartErr(msg.Get(0), errShared, outer)
+	assert.Len(t, r.Recent(), 4)
+}
+
+func TestRecorderSamples(t *testing.T) {
+	r := NewRecorder(metrics.Noop())
+
+	part := message.NewPart(bytes.Repeat([]byte("a"), SampleSize+10))
+	r.RecordPartErr(part, errors.New("nope"), Event{Path: "root.output", Class: ClassDelivery})
+	assert.Equal(t, "", r.Recent()[0].Sample)
+
+	r.IncludeSamples(true)
+	r.RecordPartErr(part, errors.New("nope"), Event{Path: "root.output", Class: ClassDelivery})
+	assert.Equal(t, strings.Repeat("a", SampleSize), r.Recent()[1].Sample)
+}
+
+func TestRecorderRecent(t *testing.T) {
+	r := NewRecorder(metrics.Noop())
+	for i := 0; i < defaultCapacity+5; i++ {
+		r.Record(Event{Path: "root.output", Class: ClassDelivery, Error: string(rune('a' + i%26))})
+	}
+
+	recent := r.Recent()
+	require.Len(t, recent, defaultCapacity)
+	assert.Equal(t, "f", recent[0].Error)
+	assert.Equal(t, string(rune('a'+(defaultCapacity+4)%26)), recent[defaultCapacity-1].Error)
+}
+
+func TestRecorderHandler(t *testing.T) {
+	r := NewRecorder(metrics.Noop())
+	r.Record(Event{Path: "root.output", Class: ClassDelivery, Error: "nope", Sample: "hello"})
+
+	rec := httptest.NewRecorder()
+	r.HandlerFunc()(rec, httptest.NewRequest(http.MethodGet, "/errors", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res struct {
+		Summaries []Summary `json:"summaries"`
+		Recent    []Event   `json:"recent"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Len(t, res.Summaries, 1)
+	require.Len(t, res.Recent, 1)
+	assert.Equal(t, "hello", res.Recent[0].Sample)
+
+	rec = httptest.NewRecorder()
+	r.HandlerFunc()(rec, httptest.NewRequest(http.MethodDelete, "/errors", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, r.Recent())
+	assert.Empty(t, r.Summaries())
+}
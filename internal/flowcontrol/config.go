@@ -0,0 +1,65 @@
+package flowcontrol
+
+import "github.com/benthosdev/benthos/v4/internal/docs"
+
+// Config contains configuration fields for flow control.
+type Config struct {
+	Enabled        bool                 `json:"enabled" yaml:"enabled"`
+	MaxInFlight    int                  `json:"max_in_flight" yaml:"max_in_flight"`
+	PauseThreshold float64              `json:"pause_threshold" yaml:"pause_threshold"`
+	Shed           ShedConfig           `json:"shed" yaml:"shed"`
+	CircuitBreaker CircuitBreakerConfig `json:"circuit_breaker" yaml:"circuit_breaker"`
+}
+
+// ShedConfig contains configuration fields for pausing selected streams when
+// any stream is under pressure.
+type ShedConfig struct {
+	Streams   []string `json:"streams" yaml:"streams"`
+	Threshold float64  `json:"threshold" yaml:"threshold"`
+}
+
+// CircuitBreakerConfig contains configuration fields for pausing streams with
+// outputs that are consistently failing.
+type CircuitBreakerConfig struct {
+	Enabled          bool   `json:"enabled" yaml:"enabled"`
+	FailureThreshold int    `json:"failure_threshold" yaml:"failure_threshold"`
+	OpenPeriod       string `json:"open_period" yaml:"open_period"`
+}
+
+// NewConfig creates a new flow control config with default values.
+func NewConfig() Config {
+	return Config{
+		Enabled:        false,
+		MaxInFlight:    1024,
+		PauseThreshold: 1,
+		Shed: ShedConfig{
+			Streams:   []string{},
+			Threshold: 0.8,
+		},
+		CircuitBreaker: CircuitBreakerConfig{
+			Enabled:          false,
+			FailureThreshold: 5,
+			OpenPeriod:       "10s",
+		},
+	}
+}
+
+// Spec returns a field spec for the flow control configuration fields.
+func Spec() docs.FieldSpec {
+	return docs.FieldObject(
+		"flow_control", "Explicit backpressure policies that pause consuming from the inputs of streams based on their pressure, which is the number of message batches consumed by a stream that are yet to be acknowledged relative to `max_in_flight`. The current state of each stream is available via the HTTP endpoint `/flow_control`.",
+	).WithChildren(
+		docs.FieldBool("enabled", "Whether flow control is enabled.").HasDefault(false),
+		docs.FieldInt("max_in_flight", "The number of unacknowledged message batches at which a stream has a pressure of `1`.").HasDefault(1024),
+		docs.FieldFloat("pause_threshold", "The pressure at which a stream stops consuming from its input until messages are acknowledged. Set to zero in order to disable pausing by pressure.").HasDefault(1),
+		docs.FieldObject("shed", "Shed load by pausing a selection of streams whilst any stream is under pressure, allowing the remaining streams to take priority. This is mostly useful in streams mode.").WithChildren(
+			docs.FieldString("streams", "A list of stream identifiers to pause. In streams mode these are the stream IDs, otherwise the single stream has an empty identifier.").Array().HasDefault([]interface{}{}),
+			docs.FieldFloat("threshold", "The pressure of the busiest stream at which the selected streams are paused.").HasDefault(0.8),
+		),
+		docs.FieldObject("circuit_breaker", "Open a circuit and pause consuming from the input of a stream once its output fails to deliver a number of consecutive message batches. After a period a single message batch is let through, and if it succeeds the circuit is closed again. When a buffer is configured the deliveries observed are to the buffer rather than the output.").WithChildren(
+			docs.FieldBool("enabled", "Whether the circuit breaker is enabled.").HasDefault(false),
+			docs.FieldInt("failure_threshold", "The number of consecutive delivery failures after which the circuit is opened.").HasDefault(5),
+			docs.FieldString("open_period", "The period of time a circuit remains open before a message batch is let through in order to test the output.").HasDefault("10s"),
+		),
+	).Advanced()
+}
@@ -0,0 +1,432 @@
+// Package flowcontrol provides explicit backpressure policies that pause
+// consuming from the inputs of streams based on how saturated they are and
+// whether their outputs are failing.
+package flowcontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// States of a circuit.
+const (
+	CircuitClosed   = "closed"
+	CircuitHalfOpen = "half_open"
+	CircuitOpen     = "open"
+)
+
+// Reasons for a stream being paused.
+const (
+	PausedPressure = "pressure"
+	PausedShed     = "shed"
+	PausedCircuit  = "circuit"
+)
+
+// pollPeriod is how often a paused stream checks whether it should resume
+// regardless of the acknowledgements it receives, as the pressure of other
+// streams and circuit timers can also lift a pause.
+const pollPeriod = time.Millisecond * 50
+
+// StreamStatus describes the current flow control state of a stream.
+type StreamStatus struct {
+	Stream              string  `json:"stream,omitempty"`
+	InFlight            int64   `json:"in_flight"`
+	Pressure            float64 `json:"pressure"`
+	Paused              bool    `json:"paused"`
+	PausedReason        string  `json:"paused_reason,omitempty"`
+	Circuit             string  `json:"circuit"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+}
+
+// Status describes the current flow control state of all streams.
+type Status struct {
+	Pressure float64        `json:"pressure"`
+	Streams  []StreamStatus `json:"streams"`
+}
+
+// Controller applies flow control policies to the streams of a service.
+type Controller struct {
+	stream string
+	*state
+}
+
+type state struct {
+	conf       Config
+	openPeriod time.Duration
+	shed       map[string]struct{}
+
+	mut    sync.Mutex
+	valves map[*Valve]struct{}
+
+	nowFn func() time.Time
+}
+
+// New creates a flow control controller from a config.
+func New(conf Config) (*Controller, error) {
+	if conf.MaxInFlight <= 0 {
+		return nil, errors.New("max_in_flight must be greater than zero")
+	}
+	s := &state{
+		conf:   conf,
+		shed:   map[string]struct{}{},
+		valves: map[*Valve]struct{}{},
+		nowFn:  time.Now,
+	}
+	for _, id := range conf.Shed.Streams {
+		s.shed[id] = struct{}{}
+	}
+	if conf.CircuitBreaker.Enabled {
+		var err error
+		if s.openPeriod, err = time.ParseDuration(conf.CircuitBreaker.OpenPeriod); err != nil {
+			return nil, fmt.Errorf("failed to parse circuit_breaker.open_period: %w", err)
+		}
+		if conf.CircuitBreaker.FailureThreshold <= 0 {
+			return nil, errors.New("circuit_breaker.failure_threshold must be greater than zero")
+		}
+	}
+	return &Controller{state: s}, nil
+}
+
+// ForStream returns a variant of this controller to be used by a particular
+// stream, where the valves created are attributed to that stream.
+func (c *Controller) ForStream(id string) *Controller {
+	return &Controller{stream: id, state: c.state}
+}
+
+// Pressure returns the pressure of the busiest stream.
+func (c *Controller) Pressure() float64 {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var max float64
+	for v := range c.valves {
+		if p := v.pressure(); p > max {
+			max = p
+		}
+	}
+	return max
+}
+
+// Status returns the current flow control state of all streams.
+func (c *Controller) Status() Status {
+	c.mut.Lock()
+	valves := make([]*Valve, 0, len(c.valves))
+	for v := range c.valves {
+		valves = append(valves, v)
+	}
+	c.mut.Unlock()
+
+	status := Status{Streams: make([]StreamStatus, 0, len(valves))}
+	for _, v := range valves {
+		s := v.Status()
+		if s.Pressure > status.Pressure {
+			status.Pressure = s.Pressure
+		}
+		status.Streams = append(status.Streams, s)
+	}
+	sort.Slice(status.Streams, func(i, j int) bool {
+		return status.Streams[i].Stream < status.Streams[j].Stream
+	})
+	return status
+}
+
+// HandlerFunc returns an HTTP handler that returns the current flow control
+// state of all streams as JSON.
+func (c *Controller) HandlerFunc() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resBytes, err := json.Marshal(c.Status())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Valve controls the flow of messages from the input of a stream.
+type Valve struct {
+	stream string
+	state  *state
+	log    log.Modular
+
+	inFlight int64
+
+	mut          sync.Mutex
+	circuit      string
+	failures     int
+	openedAt     time.Time
+	probing      bool
+	pausedReason string
+
+	wake      chan struct{}
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	mPressure     metrics.StatGauge
+	mPaused       metrics.StatGauge
+	mCircuitState metrics.StatGauge
+	mCircuitOpen  metrics.StatCounter
+}
+
+// NewValve creates a valve for the stream of this controller, which applies
+// flow control to transactions passed through Gate until it is closed.
+func (c *Controller) NewValve(log log.Modular, stats metrics.Type) *Valve {
+	v := &Valve{
+		stream:        c.stream,
+		state:         c.state,
+		log:           log,
+		circuit:       CircuitClosed,
+		wake:          make(chan struct{}, 1),
+		closeChan:     make(chan struct{}),
+		mPressure:     stats.GetGauge("flow_control_pressure"),
+		mPaused:       stats.GetGauge("flow_control_paused"),
+		mCircuitState: stats.GetGauge("flow_control_circuit_state"),
+		mCircuitOpen:  stats.GetCounter("flow_control_circuit_opened"),
+	}
+	c.mut.Lock()
+	c.valves[v] = struct{}{}
+	c.mut.Unlock()
+	return v
+}
+
+func (v *Valve) pressure() float64 {
+	return float64(atomic.LoadInt64(&v.inFlight)) / float64(v.state.conf.MaxInFlight)
+}
+
+func circuitStateValue(circuit string) int64 {
+	switch circuit {
+	case CircuitHalfOpen:
+		return 1
+	case CircuitOpen:
+		return 2
+	}
+	return 0
+}
+
+// setCircuit must be called with the valve mutex held.
+func (v *Valve) setCircuit(circuit string) {
+	if v.circuit == circuit {
+		return
+	}
+	v.circuit = circuit
+	v.mCircuitState.Set(circuitStateValue(circuit))
+	switch circuit {
+	case CircuitOpen:
+		v.openedAt = v.state.nowFn()
+		v.mCircuitOpen.Incr(1)
+		v.log.Warnf("Opening circuit after %v consecutive delivery failures, consuming from the input is paused for %v\n", v.failures, v.state.openPeriod)
+	case CircuitHalfOpen:
+		v.log.Infoln("Circuit is half open, testing the output with a single message batch")
+	case CircuitClosed:
+		v.log.Infoln("Closing circuit after a successful delivery")
+	}
+}
+
+// pauseReason returns the reason that the stream should be paused at a given
+// time, where an empty string indicates that it should not be paused, along
+// with the state of the circuit once any transitions due by that time have
+// been applied. The valve itself is not modified, and this must be called with
+// the valve mutex held.
+func (v *Valve) pauseReason(now time.Time) (reason, circuit string) {
+	circuit, probing := v.circuit, v.probing
+	if circuit == CircuitOpen && now.Sub(v.openedAt) >= v.state.openPeriod {
+		circuit, probing = CircuitHalfOpen, false
+	}
+	if circuit == CircuitOpen || (circuit == CircuitHalfOpen && probing) {
+		return PausedCircuit, circuit
+	}
+
+	conf := v.state.conf
+	if conf.PauseThreshold > 0 && v.pressure() >= conf.PauseThreshold {
+		return PausedPressure, circuit
+	}
+	if _, exists := v.state.shed[v.stream]; exists {
+		if (&Controller{state: v.state}).Pressure() >= conf.Shed.Threshold {
+			return PausedShed, circuit
+		}
+	}
+	return "", circuit
+}
+
+// evaluate applies any due transitions of the circuit and determines whether
+// the stream should be paused and the reason why, where an empty string
+// indicates that it should not be paused.
+func (v *Valve) evaluate() string {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	now := v.state.nowFn()
+	if v.circuit == CircuitOpen && now.Sub(v.openedAt) >= v.state.openPeriod {
+		v.setCircuit(CircuitHalfOpen)
+		v.probing = false
+	}
+
+	reason, _ := v.pauseReason(now)
+	if reason != v.pausedReason {
+		if reason != "" {
+			v.log.Debugf("Pausing consumption from input due to %v\n", reason)
+			v.mPaused.Set(1)
+		} else {
+			v.log.Debugln("Resuming consumption from input")
+			v.mPaused.Set(0)
+		}
+		v.pausedReason = reason
+	}
+	return reason
+}
+
+// forwarded registers a transaction being let through the valve, and returns
+// true if the transaction is testing a half open circuit.
+func (v *Valve) forwarded() (probe bool) {
+	v.mPressure.Set(int64(float64(atomic.AddInt64(&v.inFlight, 1)) * 100 / float64(v.state.conf.MaxInFlight)))
+
+	v.mut.Lock()
+	if v.circuit == CircuitHalfOpen && !v.probing {
+		v.probing = true
+		probe = true
+	}
+	v.mut.Unlock()
+	return
+}
+
+// resolved registers the outcome of a transaction let through the valve.
+func (v *Valve) resolved(err error, probe bool) {
+	v.mPressure.Set(int64(float64(atomic.AddInt64(&v.inFlight, -1)) * 100 / float64(v.state.conf.MaxInFlight)))
+
+	v.mut.Lock()
+	if err == nil {
+		v.failures = 0
+		if v.circuit == CircuitHalfOpen {
+			v.setCircuit(CircuitClosed)
+		}
+	} else {
+		v.failures++
+		if v.state.conf.CircuitBreaker.Enabled {
+			if v.circuit == CircuitHalfOpen || (v.circuit == CircuitClosed && v.failures >= v.state.conf.CircuitBreaker.FailureThreshold) {
+				v.setCircuit(CircuitOpen)
+			}
+		}
+	}
+	if probe {
+		v.probing = false
+	}
+	v.mut.Unlock()
+
+	select {
+	case v.wake <- struct{}{}:
+	default:
+	}
+}
+
+// waitUntilOpen blocks until the valve allows a transaction through, returning
+// false if the valve was closed whilst waiting.
+func (v *Valve) waitUntilOpen() bool {
+	var ticker *time.Ticker
+	for v.evaluate() != "" {
+		if ticker == nil {
+			ticker = time.NewTicker(pollPeriod)
+			defer ticker.Stop()
+		}
+		select {
+		case <-v.wake:
+		case <-ticker.C:
+		case <-v.closeChan:
+			return false
+		}
+	}
+	return true
+}
+
+// Gate returns a channel of transactions consumed from the provided channel
+// only whilst the valve allows it. The returned channel is closed once the
+// provided channel is closed or the valve is closed.
+func (v *Valve) Gate(in <-chan message.Transaction) <-chan message.Transaction {
+	out := make(chan message.Transaction)
+	go func() {
+		defer func() {
+			close(out)
+			v.Close()
+		}()
+		for {
+			var tran message.Transaction
+			var open bool
+			select {
+			case tran, open = <-in:
+				if !open {
+					return
+				}
+			case <-v.closeChan:
+				return
+			}
+
+			// The transaction is held until the valve allows it through,
+			// which prevents the input from producing more.
+			if !v.waitUntilOpen() {
+				_ = tran.Ack(context.Background(), component.ErrTypeClosed)
+				return
+			}
+
+			probe := v.forwarded()
+			var once sync.Once
+			select {
+			case out <- message.NewTransactionFunc(tran.Payload, func(ctx context.Context, err error) error {
+				once.Do(func() {
+					v.resolved(err, probe)
+				})
+				return tran.Ack(ctx, err)
+			}):
+			case <-v.closeChan:
+				v.resolved(component.ErrTypeClosed, probe)
+				_ = tran.Ack(context.Background(), component.ErrTypeClosed)
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Status returns a snapshot of the current flow control state of the stream of
+// the valve. Obtaining the status has no effect on the valve, and transitions
+// of the circuit that are due are reported without being applied, as they are
+// only applied when the valve next lets a transaction through.
+func (v *Valve) Status() StreamStatus {
+	v.mut.Lock()
+	defer v.mut.Unlock()
+
+	reason, circuit := v.pauseReason(v.state.nowFn())
+	return StreamStatus{
+		Stream:              v.stream,
+		InFlight:            atomic.LoadInt64(&v.inFlight),
+		Pressure:            v.pressure(),
+		Paused:              reason != "",
+		PausedReason:        reason,
+		Circuit:             circuit,
+		ConsecutiveFailures: v.failures,
+	}
+}
+
+// Close stops the valve from letting transactions through and removes it from
+// the controller.
+func (v *Valve) Close() {
+	v.closeOnce.Do(func() {
+		close(v.closeChan)
+		v.state.mut.Lock()
+		delete(v.state.valves, v)
+		v.state.mut.Unlock()
+	})
+}
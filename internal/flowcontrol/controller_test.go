@@ -0,0 +1,254 @@
+package flowcontrol_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/flowcontrol"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func sendTran(t *testing.T, in chan<- message.Transaction) {
+	t.Helper()
+	select {
+	case in <- message.NewTransactionFunc(message.QuickBatch([][]byte{[]byte("hello")}), func(context.Context, error) error {
+		return nil
+	}):
+	case <-time.After(time.Second * 5):
+		t.Error("timed out")
+	}
+}
+
+func recvTran(t *testing.T, out <-chan message.Transaction) message.Transaction {
+	t.Helper()
+	select {
+	case tran := <-out:
+		return tran
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return message.Transaction{}
+}
+
+func assertBlocked(t *testing.T, out <-chan message.Transaction) {
+	t.Helper()
+	select {
+	case <-out:
+		t.Fatal("expected transaction to be held")
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+func TestValvePausedByPressure(t *testing.T) {
+	conf := flowcontrol.NewConfig()
+	conf.Enabled = true
+	conf.MaxInFlight = 2
+
+	ctrl, err := flowcontrol.New(conf)
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	valve := ctrl.NewValve(log.Noop(), stats)
+
+	in := make(chan message.Transaction)
+	out := valve.Gate(in)
+
+	sendTran(t, in)
+	first := recvTran(t, out)
+	sendTran(t, in)
+	_ = recvTran(t, out)
+
+	go sendTran(t, in)
+	assertBlocked(t, out)
+
+	status := valve.Status()
+	assert.Equal(t, int64(2), status.InFlight)
+	assert.Equal(t, 1.0, status.Pressure)
+	assert.True(t, status.Paused)
+	assert.Equal(t, flowcontrol.PausedPressure, status.PausedReason)
+	assert.Equal(t, int64(1), stats.GetCounters()["flow_control_paused"])
+	assert.Equal(t, int64(100), stats.GetCounters()["flow_control_pressure"])
+
+	require.NoError(t, first.Ack(context.Background(), nil))
+	third := recvTran(t, out)
+	require.NoError(t, third.Ack(context.Background(), nil))
+
+	close(in)
+	select {
+	case _, open := <-out:
+		assert.False(t, open)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	assert.Empty(t, ctrl.Status().Streams)
+}
+
+func TestValveCircuitBreaker(t *testing.T) {
+	conf := flowcontrol.NewConfig()
+	conf.Enabled = true
+	conf.CircuitBreaker.Enabled = true
+	conf.CircuitBreaker.FailureThreshold = 2
+	conf.CircuitBreaker.OpenPeriod = "200ms"
+
+	ctrl, err := flowcontrol.New(conf)
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	valve := ctrl.NewValve(log.Noop(), stats)
+	defer valve.Close()
+
+	in := make(chan message.Transaction)
+	out := valve.Gate(in)
+
+	sendTran(t, in)
+	tran := recvTran(t, out)
+	require.NoError(t, tran.Ack(context.Background(), errors.New("nope")))
+	assert.Equal(t, flowcontrol.CircuitClosed, valve.Status().Circuit)
+
+	sendTran(t, in)
+	tran = recvTran(t, out)
+	require.NoError(t, tran.Ack(context.Background(), errors.New("nope")))
+	status := valve.Status()
+	assert.Equal(t, flowcontrol.CircuitOpen, status.Circuit)
+	assert.Equal(t, 2, status.ConsecutiveFailures)
+	assert.Equal(t, flowcontrol.PausedCircuit, status.PausedReason)
+	assert.Equal(t, int64(1), stats.GetCounters()["flow_control_circuit_opened"])
+
+	// After the open period a single probe is let through, and its failure
+	// opens the circuit again.
+	sendTran(t, in)
+	probe := recvTran(t, out)
+	assert.Equal(t, flowcontrol.CircuitHalfOpen, valve.Status().Circuit)
+
+	go sendTran(t, in)
+	assertBlocked(t, out)
+	require.NoError(t, probe.Ack(context.Background(), errors.New("nope")))
+	assert.Equal(t, flowcontrol.CircuitOpen, valve.Status().Circuit)
+	assert.Equal(t, int64(2), stats.GetCounters()["flow_control_circuit_opened"])
+
+	// A successful probe closes the circuit.
+	probe = recvTran(t, out)
+	require.NoError(t, probe.Ack(context.Background(), nil))
+	status = valve.Status()
+	assert.Equal(t, flowcontrol.CircuitClosed, status.Circuit)
+	assert.False(t, status.Paused)
+}
+
+func TestValveStatusSnapshot(t *testing.T) {
+	conf := flowcontrol.NewConfig()
+	conf.Enabled = true
+	conf.CircuitBreaker.Enabled = true
+	conf.CircuitBreaker.FailureThreshold = 1
+	conf.CircuitBreaker.OpenPeriod = "100ms"
+
+	ctrl, err := flowcontrol.New(conf)
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	valve := ctrl.NewValve(log.Noop(), stats)
+	defer valve.Close()
+
+	in := make(chan message.Transaction)
+	out := valve.Gate(in)
+
+	sendTran(t, in)
+	tran := recvTran(t, out)
+	require.NoError(t, tran.Ack(context.Background(), errors.New("nope")))
+	assert.Equal(t, flowcontrol.CircuitOpen, valve.Status().Circuit)
+	assert.Equal(t, int64(2), stats.GetCounters()["flow_control_circuit_state"])
+
+	<-time.After(time.Millisecond * 150)
+
+	// The due transition is reported by the status without being applied.
+	for i := 0; i < 3; i++ {
+		status := valve.Status()
+		assert.Equal(t, flowcontrol.CircuitHalfOpen, status.Circuit)
+		assert.False(t, status.Paused)
+		assert.Equal(t, int64(2), stats.GetCounters()["flow_control_circuit_state"])
+	}
+	assert.Equal(t, flowcontrol.CircuitHalfOpen, ctrl.Status().Streams[0].Circuit)
+	assert.Equal(t, int64(2), stats.GetCounters()["flow_control_circuit_state"])
+
+	// And is applied once the valve next lets a transaction through.
+	sendTran(t, in)
+	probe := recvTran(t, out)
+	assert.Equal(t, int64(1), stats.GetCounters()["flow_control_circuit_state"])
+	require.NoError(t, probe.Ack(context.Background(), nil))
+	assert.Equal(t, flowcontrol.CircuitClosed, valve.Status().Circuit)
+}
+
+func TestValveShedStreams(t *testing.T) {
+	conf := flowcontrol.NewConfig()
+	conf.Enabled = true
+	conf.MaxInFlight = 4
+	conf.Shed.Streams = []string{"low"}
+	conf.Shed.Threshold = 0.5
+
+	ctrl, err := flowcontrol.New(conf)
+	require.NoError(t, err)
+
+	high := ctrl.ForStream("high").NewValve(log.Noop(), metrics.Noop())
+	defer high.Close()
+	low := ctrl.ForStream("low").NewValve(log.Noop(), metrics.Noop())
+	defer low.Close()
+
+	highIn, lowIn := make(chan message.Transaction), make(chan message.Transaction)
+	highOut, lowOut := high.Gate(highIn), low.Gate(lowIn)
+
+	var highTrans []message.Transaction
+	for i := 0; i < 2; i++ {
+		sendTran(t, highIn)
+		highTrans = append(highTrans, recvTran(t, highOut))
+	}
+
+	go sendTran(t, lowIn)
+	assertBlocked(t, lowOut)
+	assert.Equal(t, flowcontrol.PausedShed, low.Status().PausedReason)
+
+	// The high priority stream is unaffected.
+	sendTran(t, highIn)
+	_ = recvTran(t, highOut)
+	assert.InDelta(t, 0.75, ctrl.Pressure(), 0.001)
+
+	for _, tran := range highTrans {
+		require.NoError(t, tran.Ack(context.Background(), nil))
+	}
+	_ = recvTran(t, lowOut)
+
+	req := httptest.NewRequest(http.MethodGet, "/flow_control", nil)
+	w := httptest.NewRecorder()
+	ctrl.HandlerFunc()(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var status flowcontrol.Status
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	require.Len(t, status.Streams, 2)
+	assert.Equal(t, "high", status.Streams[0].Stream)
+	assert.Equal(t, int64(1), status.Streams[0].InFlight)
+	assert.Equal(t, "low", status.Streams[1].Stream)
+	assert.Equal(t, int64(1), status.Streams[1].InFlight)
+	assert.InDelta(t, 0.25, status.Pressure, 0.001)
+}
+
+func TestControllerConfigErrors(t *testing.T) {
+	conf := flowcontrol.NewConfig()
+	conf.MaxInFlight = 0
+	_, err := flowcontrol.New(conf)
+	require.EqualError(t, err, "max_in_flight must be greater than zero")
+
+	conf = flowcontrol.NewConfig()
+	conf.CircuitBreaker.Enabled = true
+	conf.CircuitBreaker.OpenPeriod = "nope"
+	_, err = flowcontrol.New(conf)
+	require.Error(t, err)
+}
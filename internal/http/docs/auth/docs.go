@@ -9,7 +9,7 @@ func BasicAuthFieldSpec() docs.FieldSpec {
 	).WithChildren(
 		docs.FieldBool("enabled", "Whether to use basic authentication in requests.").HasDefault(false),
 		docs.FieldString("username", "A username to authenticate as.").HasDefault(""),
-		docs.FieldString("password", "A password to authenticate with.").HasDefault(""),
+		docs.FieldString("password", "A password to authenticate with.").Secret().HasDefault(""),
 	).Advanced()
 }
 
@@ -27,15 +27,15 @@ func oAuthFieldSpec() docs.FieldSpec {
 
 		docs.FieldString(
 			"consumer_secret", "A secret used to establish ownership of the consumer key.",
-		).HasDefault(""),
+		).Secret().HasDefault(""),
 
 		docs.FieldString(
 			"access_token", "A value used to gain access to the protected resources on behalf of the user.",
-		).HasDefault(""),
+		).Secret().HasDefault(""),
 
 		docs.FieldString(
 			"access_token_secret", "A secret provided in order to establish ownership of a given access token.",
-		).HasDefault(""),
+		).Secret().HasDefault(""),
 	)
 }
 
@@ -53,7 +53,7 @@ func oAuth2FieldSpec() docs.FieldSpec {
 
 		docs.FieldString(
 			"client_secret", "A secret used to establish ownership of the client key.",
-		).HasDefault(""),
+		).Secret().HasDefault(""),
 
 		docs.FieldString(
 			"token_url", "The URL of the token provider.",
@@ -0,0 +1,258 @@
+package amqp09
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
+)
+
+// fakeAMQP09Server implements just enough of the AMQP 0.9.1 protocol in order
+// to deliver a fixed set of messages to a single consumer and record which of
+// them are acked and which are nacked.
+type fakeAMQP09Server struct {
+	ln       net.Listener
+	messages []string
+
+	mut    sync.Mutex
+	acked  []string
+	nacked []string
+}
+
+func newFakeAMQP09Server(t *testing.T, messages ...string) *fakeAMQP09Server {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	f := &fakeAMQP09Server{ln: ln, messages: messages}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeAMQP09Server) url() string {
+	return fmt.Sprintf("amqp://guest:guest@%v/", f.ln.Addr())
+}
+
+func (f *fakeAMQP09Server) results() (acked, nacked []string) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	acked = append(acked, f.acked...)
+	nacked = append(nacked, f.nacked...)
+	sort.Strings(acked)
+	sort.Strings(nacked)
+	return
+}
+
+type amqpFrameWriter struct {
+	w io.Writer
+}
+
+func (a amqpFrameWriter) frame(frameType uint8, channel uint16, payload []byte) error {
+	var buf bytes.Buffer
+	buf.WriteByte(frameType)
+	_ = binary.Write(&buf, binary.BigEndian, channel)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(len(payload)))
+	buf.Write(payload)
+	buf.WriteByte(0xCE)
+	_, err := a.w.Write(buf.Bytes())
+	return err
+}
+
+func (a amqpFrameWriter) method(channel, class, method uint16, args ...interface{}) error {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.BigEndian, class)
+	_ = binary.Write(&buf, binary.BigEndian, method)
+	for _, arg := range args {
+		if s, ok := arg.(string); ok {
+			buf.WriteByte(uint8(len(s)))
+			buf.WriteString(s)
+			continue
+		}
+		_ = binary.Write(&buf, binary.BigEndian, arg)
+	}
+	return a.frame(1, channel, buf.Bytes())
+}
+
+func (f *fakeAMQP09Server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+
+	w := amqpFrameWriter{w: conn}
+	// connection.start with an empty table of server properties, followed by
+	// the long strings of mechanisms and locales.
+	if err := w.method(0, 10, 10, uint8(0), uint8(9), uint32(0),
+		uint32(5), []byte("PLAIN"), uint32(5), []byte("en_US")); err != nil {
+		return
+	}
+
+	deliveryTags := map[uint64]string{}
+	for {
+		frameHeader := make([]byte, 7)
+		if _, err := io.ReadFull(conn, frameHeader); err != nil {
+			return
+		}
+		channel := binary.BigEndian.Uint16(frameHeader[1:3])
+		payload := make([]byte, binary.BigEndian.Uint32(frameHeader[3:7])+1)
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+		if frameHeader[0] != 1 {
+			continue
+		}
+
+		var err error
+		switch class, method := binary.BigEndian.Uint16(payload[0:2]), binary.BigEndian.Uint16(payload[2:4]); {
+		case class == 10 && method == 11: // connection.start-ok
+			err = w.method(0, 10, 30, uint16(0), uint32(131072), uint16(0))
+		case class == 10 && method == 40: // connection.open
+			err = w.method(0, 10, 41, "")
+		case class == 10 && method == 50: // connection.close
+			_ = w.method(0, 10, 51)
+			return
+		case class == 20 && method == 10: // channel.open
+			err = w.method(channel, 20, 11, uint32(0))
+		case class == 20 && method == 40: // channel.close
+			err = w.method(channel, 20, 41)
+		case class == 60 && method == 10: // basic.qos
+			err = w.method(channel, 60, 11)
+		case class == 60 && method == 20: // basic.consume
+			args := payload[6:]
+			args = args[1+args[0]:]
+			tag := string(args[1 : 1+args[0]])
+			if err = w.method(channel, 60, 21, tag); err != nil {
+				return
+			}
+			for i, content := range f.messages {
+				deliveryTag := uint64(i + 1)
+				deliveryTags[deliveryTag] = content
+				if err = w.method(channel, 60, 60, tag, deliveryTag, uint8(0), "", "foo"); err != nil {
+					return
+				}
+				var contentHeader bytes.Buffer
+				_ = binary.Write(&contentHeader, binary.BigEndian, uint16(60))
+				_ = binary.Write(&contentHeader, binary.BigEndian, uint16(0))
+				_ = binary.Write(&contentHeader, binary.BigEndian, uint64(len(content)))
+				_ = binary.Write(&contentHeader, binary.BigEndian, uint16(0))
+				if err = w.frame(2, channel, contentHeader.Bytes()); err != nil {
+					return
+				}
+				if err = w.frame(3, channel, []byte(content)); err != nil {
+					return
+				}
+			}
+		case class == 60 && method == 30: // basic.cancel
+			args := payload[4:]
+			if args[1+args[0]]&1 == 0 {
+				err = w.method(channel, 60, 31, string(args[1:1+args[0]]))
+			}
+		case class == 60 && method == 80: // basic.ack
+			f.mut.Lock()
+			f.acked = append(f.acked, deliveryTags[binary.BigEndian.Uint64(payload[4:12])])
+			f.mut.Unlock()
+		case class == 60 && (method == 90 || method == 120): // basic.reject, basic.nack
+			f.mut.Lock()
+			f.nacked = append(f.nacked, deliveryTags[binary.BigEndian.Uint64(payload[4:12])])
+			f.mut.Unlock()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+type amqp09TestBatchOutput struct {
+	fn func(service.MessageBatch) error
+}
+
+func (a *amqp09TestBatchOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (a *amqp09TestBatchOutput) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	return a.fn(b)
+}
+
+func (a *amqp09TestBatchOutput) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestAMQP09InputBatchErrorNacksFailed(t *testing.T) {
+	fake := newFakeAMQP09Server(t, "first", "second", "third")
+
+	env := service.NewEnvironment()
+	require.NoError(t, env.RegisterBatchOutput("amqp09_test_output", service.NewConfigSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchOutput, service.BatchPolicy, int, error) {
+			return &amqp09TestBatchOutput{fn: func(b service.MessageBatch) error {
+				berr := service.NewBatchError(b, errors.New("failed to write"))
+				for i, m := range b {
+					if content, _ := m.AsBytes(); string(content) == "second" {
+						berr.Failed(i, errors.New("second is not allowed"))
+					}
+				}
+				return berr
+			}}, service.BatchPolicy{}, 1, nil
+		}))
+
+	builder := env.NewStreamBuilder()
+	require.NoError(t, builder.SetLoggerYAML(`level: none`))
+	require.NoError(t, builder.AddInputYAML(fmt.Sprintf(`
+broker:
+  inputs:
+    - amqp_0_9:
+        urls: [ %v ]
+        queue: foo
+  batching:
+    count: 3
+`, fake.url())))
+	require.NoError(t, builder.AddOutputYAML(`amqp09_test_output: {}`))
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	go func() {
+		_ = strm.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		acked, nacked := fake.results()
+		return len(acked) == 2 && len(nacked) == 1
+	}, time.Second*10, time.Millisecond*50)
+	require.NoError(t, strm.StopWithin(time.Second*10))
+
+	acked, nacked := fake.results()
+	assert.Equal(t, []string{"first", "third"}, acked)
+	assert.Equal(t, []string{"second"}, nacked)
+}
@@ -0,0 +1,160 @@
+package aws
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// fakeSQSServer serves a fixed set of messages via the SQS query API and
+// records the messages that are deleted and those that have their visibility
+// reset.
+type fakeSQSServer struct {
+	mut      sync.Mutex
+	pending  []string
+	deleted  []string
+	resetVis []string
+}
+
+func (f *fakeSQSServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	var body strings.Builder
+	switch action := r.PostForm.Get("Action"); action {
+	case "ReceiveMessage":
+		body.WriteString("<ReceiveMessageResponse><ReceiveMessageResult>")
+		for _, content := range f.pending {
+			sum := md5.Sum([]byte(content))
+			fmt.Fprintf(&body,
+				"<Message><MessageId>%v</MessageId><ReceiptHandle>%v</ReceiptHandle><MD5OfBody>%v</MD5OfBody><Body>%v</Body></Message>",
+				content, content, hex.EncodeToString(sum[:]), content,
+			)
+		}
+		f.pending = nil
+		body.WriteString("</ReceiveMessageResult></ReceiveMessageResponse>")
+	case "DeleteMessageBatch", "ChangeMessageVisibilityBatch":
+		fmt.Fprintf(&body, "<%vResponse><%vResult>", action, action)
+		for i := 1; ; i++ {
+			id := r.PostForm.Get(fmt.Sprintf("%vRequestEntry.%v.Id", action, i))
+			if id == "" {
+				break
+			}
+			if action == "DeleteMessageBatch" {
+				f.deleted = append(f.deleted, id)
+			} else {
+				f.resetVis = append(f.resetVis, id)
+			}
+			fmt.Fprintf(&body, "<%vResultEntry><Id>%v</Id></%vResultEntry>", action, id, action)
+		}
+		fmt.Fprintf(&body, "</%vResult></%vResponse>", action, action)
+	default:
+		http.Error(w, "action not supported: "+action, http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	_, _ = w.Write([]byte(body.String()))
+}
+
+func (f *fakeSQSServer) results() (deleted, resetVis []string) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	deleted = append(deleted, f.deleted...)
+	resetVis = append(resetVis, f.resetVis...)
+	sort.Strings(deleted)
+	sort.Strings(resetVis)
+	return
+}
+
+func TestSQSInputBatchErrorNacksFailed(t *testing.T) {
+	fake := &fakeSQSServer{pending: []string{"foo", "bar", "baz"}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	env := service.NewEnvironment()
+
+	require.NoError(t, env.RegisterBatchOutput("sqs_test_output", service.NewConfigSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchOutput, service.BatchPolicy, int, error) {
+			return &sqsTestBatchOutput{fn: func(b service.MessageBatch) error {
+				berr := service.NewBatchError(b, errors.New("failed to write"))
+				for i, m := range b {
+					if content, _ := m.AsBytes(); string(content) == "bar" {
+						berr.Failed(i, errors.New("bar is not allowed"))
+					}
+				}
+				return berr
+			}}, service.BatchPolicy{}, 1, nil
+		}))
+
+	builder := env.NewStreamBuilder()
+	require.NoError(t, builder.SetLoggerYAML(`level: none`))
+	require.NoError(t, builder.AddInputYAML(fmt.Sprintf(`
+broker:
+  inputs:
+    - aws_sqs:
+        url: %v/queue
+        endpoint: %v
+        region: eu-west-1
+        credentials:
+          id: xxxxx
+          secret: xxxxx
+  batching:
+    count: 3
+`, server.URL, server.URL)))
+	require.NoError(t, builder.AddOutputYAML(`sqs_test_output: {}`))
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	go func() {
+		_ = strm.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		deleted, resetVis := fake.results()
+		return len(deleted) == 2 && len(resetVis) == 1
+	}, time.Second*10, time.Millisecond*50)
+	require.NoError(t, strm.StopWithin(time.Second*10))
+
+	deleted, resetVis := fake.results()
+	assert.Equal(t, []string{"baz", "foo"}, deleted)
+	assert.Equal(t, []string{"bar"}, resetVis)
+}
+
+type sqsTestBatchOutput struct {
+	fn func(service.MessageBatch) error
+}
+
+func (s *sqsTestBatchOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (s *sqsTestBatchOutput) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	return s.fn(b)
+}
+
+func (s *sqsTestBatchOutput) Close(ctx context.Context) error {
+	return nil
+}
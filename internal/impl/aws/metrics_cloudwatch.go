@@ -162,6 +162,11 @@ func (c *cloudWatchStat) Timing(delta int64) {
 	c.appendValue(delta / 1000)
 }
 
+// Observe records a histogram value.
+func (c *cloudWatchStat) Observe(value int64) {
+	c.appendValue(value)
+}
+
 // Set sets a gauge metric.
 func (c *cloudWatchStat) Set(value int64) {
 	c.appendValue(value)
@@ -214,6 +219,14 @@ func (c *cloudWatchTimerVec) With(labelValues ...string) metrics.StatTimer {
 	return c.with(labelValues...)
 }
 
+type cloudWatchHistogramVec struct {
+	cloudWatchStatVec
+}
+
+func (c *cloudWatchHistogramVec) With(labelValues ...string) metrics.StatHistogram {
+	return c.with(labelValues...)
+}
+
 type cloudWatchGaugeVec struct {
 	cloudWatchStatVec
 }
@@ -305,6 +318,26 @@ func (c *cwMetrics) GetTimerVec(path string, n ...string) metrics.StatTimerVec {
 	}
 }
 
+func (c *cwMetrics) GetHistogram(path string) metrics.StatHistogram {
+	return &cloudWatchStat{
+		root: c,
+		id:   path,
+		name: path,
+		unit: cloudwatch.StandardUnitCount,
+	}
+}
+
+func (c *cwMetrics) GetHistogramVec(path string, n ...string) metrics.StatHistogramVec {
+	return &cloudWatchHistogramVec{
+		cloudWatchStatVec: cloudWatchStatVec{
+			root:       c,
+			name:       path,
+			unit:       cloudwatch.StandardUnitCount,
+			labelNames: n,
+		},
+	}
+}
+
 func (c *cwMetrics) GetGauge(path string) metrics.StatGauge {
 	return &cloudWatchStat{
 		root: c,
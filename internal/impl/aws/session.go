@@ -29,9 +29,11 @@ func sessionFields() []*service.ConfigField {
 				Description("The ID of credentials to use.").
 				Default("").Advanced(),
 			service.NewStringField("secret").
+				Secret().
 				Description("The secret for the credentials being used.").
 				Default("").Advanced(),
 			service.NewStringField("token").
+				Secret().
 				Description("The token for the credentials being used, required when using short term credentials.").
 				Default("").Advanced(),
 			service.NewBoolField("from_ec2_role").
@@ -0,0 +1,120 @@
+package cbor
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+var (
+	decMode cbor.DecMode
+	encMode cbor.EncMode
+)
+
+func init() {
+	var err error
+	if decMode, err = (cbor.DecOptions{
+		IntDec:         cbor.IntDecConvertSigned,
+		DefaultMapType: reflect.TypeOf(map[string]interface{}{}),
+	}).DecMode(); err != nil {
+		panic(err)
+	}
+	if encMode, err = cbor.CoreDetEncOptions().EncMode(); err != nil {
+		panic(err)
+	}
+
+	// Note: The examples are run and tested from within
+	// ./internal/bloblang/query/parsed_test.go
+
+	cborParseSpec := bloblang.NewPluginSpec().
+		Category(query.MethodCategoryParsing).
+		Version("4.2.0").
+		Description("Parses a [CBOR](https://cbor.io/) message into a structured document. Objects must only contain string keys.").
+		Example("",
+			`root = content().decode("hex").parse_cbor()`,
+			[2]string{
+				`a163666f6f63626172`,
+				`{"foo":"bar"}`,
+			}).
+		Example("",
+			`root = this.encoded.decode("base64").parse_cbor()`,
+			[2]string{
+				`{"encoded":"oWNmb29jYmFy"}`,
+				`{"foo":"bar"}`,
+			})
+
+	if err := bloblang.RegisterMethodV2(
+		"parse_cbor", cborParseSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			return bloblang.BytesMethod(func(b []byte) (interface{}, error) {
+				var jObj interface{}
+				if err := decMode.Unmarshal(b, &jObj); err != nil {
+					return nil, err
+				}
+				return jObj, nil
+			}), nil
+		},
+	); err != nil {
+		panic(err)
+	}
+
+	cborFormatSpec := bloblang.NewPluginSpec().
+		Category(query.MethodCategoryParsing).
+		Version("4.2.0").
+		Description("Formats data as a [CBOR](https://cbor.io/) message in bytes format. Objects are written with their keys in a deterministic order.").
+		Example("",
+			`root = this.format_cbor().encode("hex")`,
+			[2]string{
+				`{"foo":"bar"}`,
+				`a163666f6f63626172`,
+			}).
+		Example("",
+			`root.encoded = this.format_cbor().encode("base64")`,
+			[2]string{
+				`{"foo":"bar"}`,
+				`{"encoded":"oWNmb29jYmFy"}`,
+			})
+
+	if err := bloblang.RegisterMethodV2(
+		"format_cbor", cborFormatSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			return func(v interface{}) (interface{}, error) {
+				return encMode.Marshal(resolveNumbers(v))
+			}, nil
+		},
+	); err != nil {
+		panic(err)
+	}
+}
+
+// resolveNumbers returns a copy of a structured value where json.Number values
+// are replaced with the integer or float value they represent, as otherwise
+// they'd be encoded as strings.
+func resolveNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		newObj := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			newObj[k] = resolveNumbers(e)
+		}
+		return newObj
+	case []interface{}:
+		newArr := make([]interface{}, len(t))
+		for i, e := range t {
+			newArr[i] = resolveNumbers(e)
+		}
+		return newArr
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
@@ -0,0 +1,75 @@
+package cbor_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/cbor"
+)
+
+func execMethod(t *testing.T, method string, target interface{}) (interface{}, error) {
+	t.Helper()
+
+	fn, err := query.InitMethodHelper(method, query.NewLiteralFunction("", target))
+	require.NoError(t, err)
+
+	return fn.Exec(query.FunctionContext{
+		Maps: map[string]query.Function{},
+	})
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	input := map[string]interface{}{
+		"str":    "foo",
+		"int":    int64(-5),
+		"float":  1.5,
+		"number": json.Number("10"),
+		"bool":   true,
+		"null":   nil,
+		"arr":    []interface{}{"a", int64(1)},
+		"obj":    map[string]interface{}{"bar": "baz"},
+	}
+
+	encoded, err := execMethod(t, "format_cbor", input)
+	require.NoError(t, err)
+
+	decoded, err := execMethod(t, "parse_cbor", encoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"str":    "foo",
+		"int":    int64(-5),
+		"float":  1.5,
+		"number": int64(10),
+		"bool":   true,
+		"null":   nil,
+		"arr":    []interface{}{"a", int64(1)},
+		"obj":    map[string]interface{}{"bar": "baz"},
+	}, decoded)
+}
+
+func TestCBORFormatDeterministic(t *testing.T) {
+	encoded, err := execMethod(t, "format_cbor", map[string]interface{}{
+		"b": int64(1),
+		"a": int64(2),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "a2616102616201", hex.EncodeToString(encoded.([]byte)))
+}
+
+func TestCBORParseErrors(t *testing.T) {
+	// A map with an integer key.
+	nonStringKey, err := hex.DecodeString("a10102")
+	require.NoError(t, err)
+
+	_, err = execMethod(t, "parse_cbor", nonStringKey)
+	require.Error(t, err)
+
+	_, err = execMethod(t, "parse_cbor", []byte{0xff})
+	require.Error(t, err)
+}
@@ -0,0 +1,46 @@
+package confluent
+
+import (
+	"net/http"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func basicAuthField() *service.ConfigField {
+	return service.NewObjectField("basic_auth",
+		service.NewBoolField("enabled").
+			Description("Whether to use basic authentication in requests.").
+			Default(false),
+		service.NewStringField("username").
+			Description("A username to authenticate as.").
+			Default(""),
+		service.NewStringField("password").
+			Secret().
+			Description("A password to authenticate with.").
+			Default(""),
+	).Description("Allows you to specify basic authentication for requests to the schema registry.").
+		Version("4.2.0").
+		Advanced()
+}
+
+// requestSigner adds authentication to a request made to the schema registry.
+type requestSigner func(req *http.Request) error
+
+func basicAuthSignerFromParsed(conf *service.ParsedConfig) (requestSigner, error) {
+	enabled, err := conf.FieldBool("basic_auth", "enabled")
+	if err != nil || !enabled {
+		return nil, err
+	}
+	username, err := conf.FieldString("basic_auth", "username")
+	if err != nil {
+		return nil, err
+	}
+	password, err := conf.FieldString("basic_auth", "password")
+	if err != nil {
+		return nil, err
+	}
+	return func(req *http.Request) error {
+		req.SetBasicAuth(username, password)
+		return nil
+	}, nil
+}
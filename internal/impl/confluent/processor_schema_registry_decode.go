@@ -47,6 +47,7 @@ For example, the union schema ` + "`[\"null\",\"string\",\"Foo\"]`, where `Foo`"
 		// 	Description("Whether Avro messages should be decoded into raw JSON documents rather than [Avro JSON](https://avro.apache.org/docs/current/spec.html#json_encoding). Avro JSON contains namespaced objects for any typed or non-nil union values, e.g. a union `[\"null\",\"string\"]` field with a string value would be represented as `{\"string\":\"foo\"}`.").
 		// 	Advanced().Default(false)).
 		Field(service.NewStringField("url").Description("The base URL of the schema registry service.")).
+		Field(basicAuthField()).
 		Field(service.NewTLSField("tls"))
 }
 
@@ -66,6 +67,7 @@ func init() {
 
 type schemaRegistryDecoder struct {
 	client      *http.Client
+	reqSigner   requestSigner
 	avroRawJSON bool
 
 	schemaRegistryBaseURL *url.URL
@@ -83,20 +85,25 @@ func newSchemaRegistryDecoderFromConfig(conf *service.ParsedConfig, logger *serv
 	if err != nil {
 		return nil, err
 	}
+	reqSigner, err := basicAuthSignerFromParsed(conf)
+	if err != nil {
+		return nil, err
+	}
 	tlsConf, err := conf.FieldTLS("tls")
 	if err != nil {
 		return nil, err
 	}
-	return newSchemaRegistryDecoder(urlStr, tlsConf, true, logger)
+	return newSchemaRegistryDecoder(urlStr, reqSigner, tlsConf, true, logger)
 }
 
-func newSchemaRegistryDecoder(urlStr string, tlsConf *tls.Config, avroRawJSON bool, logger *service.Logger) (*schemaRegistryDecoder, error) {
+func newSchemaRegistryDecoder(urlStr string, reqSigner requestSigner, tlsConf *tls.Config, avroRawJSON bool, logger *service.Logger) (*schemaRegistryDecoder, error) {
 	u, err := url.Parse(urlStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse url: %w", err)
 	}
 
 	s := &schemaRegistryDecoder{
+		reqSigner:             reqSigner,
 		avroRawJSON:           avroRawJSON,
 		schemaRegistryBaseURL: u,
 		schemas:               map[int]*cachedSchemaDecoder{},
@@ -187,6 +194,10 @@ func extractID(b []byte) (id int, remaining []byte, err error) {
 		err = fmt.Errorf("serialization format version number %v not supported", b[0])
 		return
 	}
+	if len(b) < 5 {
+		err = errors.New("message is too short to contain a schema ID")
+		return
+	}
 	id = int(binary.BigEndian.Uint32(b[1:5]))
 	remaining = b[5:]
 	return
@@ -254,6 +265,11 @@ func (s *schemaRegistryDecoder) getDecoder(id int) (schemaDecoder, error) {
 		return nil, err
 	}
 	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
+	if s.reqSigner != nil {
+		if err := s.reqSigner(req); err != nil {
+			return nil, err
+		}
+	}
 
 	var resBytes []byte
 	for i := 0; i < 3; i++ {
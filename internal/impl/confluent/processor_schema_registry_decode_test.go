@@ -187,7 +187,7 @@ func TestSchemaRegistryDecodeAvro(t *testing.T) {
 		return nil, nil
 	})
 
-	decoder, err := newSchemaRegistryDecoder(urlStr, nil, true, nil)
+	decoder, err := newSchemaRegistryDecoder(urlStr, nil, nil, true, nil)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -216,6 +216,11 @@ func TestSchemaRegistryDecodeAvro(t *testing.T) {
 			input:       "\x06\x00\x00\x00\x03\x06foo\x02\x06foo\x06bar",
 			errContains: "version number 6 not supported",
 		},
+		{
+			name:        "message too short",
+			input:       "\x00\x00\x03",
+			errContains: "too short to contain a schema ID",
+		},
 		{
 			name:        "non-existing schema",
 			input:       "\x00\x00\x00\x00\x06\x06foo\x02\x06foo\x06bar",
@@ -255,12 +260,63 @@ func TestSchemaRegistryDecodeAvro(t *testing.T) {
 	decoder.cacheMut.Unlock()
 }
 
+func TestSchemaRegistryDecodeBasicAuth(t *testing.T) {
+	payload, err := json.Marshal(struct {
+		Schema string `json:"schema"`
+	}{
+		Schema: testSchema,
+	})
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "foo" || pass != "bar" {
+			http.Error(w, "nope", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(payload)
+	}))
+	t.Cleanup(ts.Close)
+
+	for _, test := range []struct {
+		name        string
+		password    string
+		errContains string
+	}{
+		{name: "correct credentials", password: "bar"},
+		{name: "wrong credentials", password: "baz", errContains: "request failed for schema '3'"},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := schemaRegistryDecoderConfig().ParseYAML(fmt.Sprintf(`
+url: %v
+basic_auth:
+  enabled: true
+  username: foo
+  password: %v
+`, ts.URL, test.password), nil)
+			require.NoError(t, err)
+
+			decoder, err := newSchemaRegistryDecoderFromConfig(conf, service.MockResources().Logger())
+			require.NoError(t, err)
+
+			_, err = decoder.Process(context.Background(), service.NewMessage([]byte("\x00\x00\x00\x00\x03\x06foo\x00\x00")))
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NoError(t, decoder.Close(context.Background()))
+		})
+	}
+}
+
 func TestSchemaRegistryDecodeClearExpired(t *testing.T) {
 	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
 		return nil, fmt.Errorf("nope")
 	})
 
-	decoder, err := newSchemaRegistryDecoder(urlStr, nil, true, nil)
+	decoder, err := newSchemaRegistryDecoder(urlStr, nil, nil, true, nil)
 	require.NoError(t, err)
 	require.NoError(t, decoder.Close(context.Background()))
 
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,7 +28,7 @@ func schemaRegistryEncoderConfig() *service.ConfigSpec {
 		Categories("Parsing", "Integration").
 		Summary("Automatically encodes and validates messages with schemas from a Confluent Schema Registry service.").
 		Description(`
-Encodes messages automatically from schemas obtains from a [Confluent Schema Registry service](https://docs.confluent.io/platform/current/schema-registry/index.html) by polling the service for the latest schema version for target subjects.
+Encodes messages automatically from schemas obtains from a [Confluent Schema Registry service](https://docs.confluent.io/platform/current/schema-registry/index.html) by polling the service for the latest schema version for target subjects. A specific schema version can instead be pinned with the field ` + "[`version`](#version)" + `, in which case the schema is never refreshed.
 
 If a message fails to encode under the schema then it will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).
 
@@ -51,6 +52,11 @@ However, it is possible to instead consume documents in raw JSON format (that ma
 		Field(service.NewInterpolatedStringField("subject").Description("The schema subject to derive schemas from.").
 			Example("foo").
 			Example(`${! meta("kafka_topic") }`)).
+		Field(service.NewStringField("version").
+			Description("The version of the subject schema to encode with, either `latest` or a specific version number.").
+			Default("latest").
+			Example("3").
+			Version("4.2.0")).
 		Field(service.NewStringField("refresh_period").
 			Description("The period after which a schema is refreshed for each subject, this is done by polling the schema registry service.").
 			Default("10m").
@@ -59,6 +65,7 @@ However, it is possible to instead consume documents in raw JSON format (that ma
 		Field(service.NewBoolField("avro_raw_json").
 			Description("Whether messages encoded in Avro format should be parsed as raw JSON documents rather than [Avro JSON](https://avro.apache.org/docs/current/spec.html#json_encoding).").
 			Advanced().Default(false).Version("3.59.0")).
+		Field(basicAuthField()).
 		Field(service.NewTLSField("tls")).
 		Version("3.58.0")
 }
@@ -79,7 +86,9 @@ func init() {
 
 type schemaRegistryEncoder struct {
 	client             *http.Client
+	reqSigner          requestSigner
 	subject            *service.InterpolatedString
+	version            string
 	avroRawJSON        bool
 	schemaRefreshAfter time.Duration
 
@@ -103,6 +112,10 @@ func newSchemaRegistryEncoderFromConfig(conf *service.ParsedConfig, logger *serv
 	if err != nil {
 		return nil, err
 	}
+	version, err := conf.FieldString("version")
+	if err != nil {
+		return nil, err
+	}
 	avroRawJSON, err := conf.FieldBool("avro_raw_json")
 	if err != nil {
 		return nil, err
@@ -119,17 +132,23 @@ func newSchemaRegistryEncoderFromConfig(conf *service.ParsedConfig, logger *serv
 	if refreshTicker < time.Second {
 		refreshTicker = time.Second
 	}
+	reqSigner, err := basicAuthSignerFromParsed(conf)
+	if err != nil {
+		return nil, err
+	}
 	tlsConf, err := conf.FieldTLS("tls")
 	if err != nil {
 		return nil, err
 	}
-	return newSchemaRegistryEncoder(urlStr, tlsConf, subject, avroRawJSON, refreshPeriod, refreshTicker, logger)
+	return newSchemaRegistryEncoder(urlStr, reqSigner, tlsConf, subject, version, avroRawJSON, refreshPeriod, refreshTicker, logger)
 }
 
 func newSchemaRegistryEncoder(
 	urlStr string,
+	reqSigner requestSigner,
 	tlsConf *tls.Config,
 	subject *service.InterpolatedString,
+	version string,
 	avroRawJSON bool,
 	schemaRefreshAfter, schemaRefreshTicker time.Duration,
 	logger *service.Logger,
@@ -138,10 +157,17 @@ func newSchemaRegistryEncoder(
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse url: %w", err)
 	}
+	if version != "latest" {
+		if v, err := strconv.Atoi(version); err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid schema version '%v', expected either 'latest' or a positive integer", version)
+		}
+	}
 
 	s := &schemaRegistryEncoder{
+		reqSigner:             reqSigner,
 		schemaRegistryBaseURL: u,
 		subject:               subject,
+		version:               version,
 		avroRawJSON:           avroRawJSON,
 		schemaRefreshAfter:    schemaRefreshAfter,
 		schemas:               map[string]*cachedSchemaEncoder{},
@@ -249,7 +275,7 @@ func (s *schemaRegistryEncoder) refreshEncoders() {
 	for k, v := range s.schemas {
 		if atomic.LoadInt64(&v.lastUsedUnixSeconds) < purgeTargetTime {
 			purgeTargets = append(purgeTargets, k)
-		} else if atomic.LoadInt64(&v.lastUpdatedUnixSeconds) < updateTargetTime {
+		} else if s.version == "latest" && atomic.LoadInt64(&v.lastUpdatedUnixSeconds) < updateTargetTime {
 			refreshTargets = append(refreshTargets, k)
 		}
 	}
@@ -270,7 +296,7 @@ func (s *schemaRegistryEncoder) refreshEncoders() {
 	if len(refreshTargets) > 0 {
 		s.requestMut.Lock()
 		for _, k := range refreshTargets {
-			encoder, id, err := s.getLatestEncoder(k)
+			encoder, id, err := s.getSubjectEncoder(k)
 			if err != nil {
 				s.logger.Errorf("Failed to refresh schema subject '%v': %v", k, err)
 			} else {
@@ -285,18 +311,23 @@ func (s *schemaRegistryEncoder) refreshEncoders() {
 	}
 }
 
-func (s *schemaRegistryEncoder) getLatestEncoder(subject string) (schemaEncoder, int, error) {
+func (s *schemaRegistryEncoder) getSubjectEncoder(subject string) (schemaEncoder, int, error) {
 	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
 	defer done()
 
 	reqURL := *s.schemaRegistryBaseURL
-	reqURL.Path = path.Join(reqURL.Path, fmt.Sprintf("/subjects/%s/versions/latest", subject))
+	reqURL.Path = path.Join(reqURL.Path, fmt.Sprintf("/subjects/%s/versions/%s", subject, s.version))
 
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), http.NoBody)
 	if err != nil {
 		return nil, 0, err
 	}
 	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
+	if s.reqSigner != nil {
+		if err := s.reqSigner(req); err != nil {
+			return nil, 0, err
+		}
+	}
 
 	var resBytes []byte
 	for i := 0; i < 3; i++ {
@@ -320,7 +351,7 @@ func (s *schemaRegistryEncoder) getLatestEncoder(subject string) (schemaEncoder,
 		}
 
 		if res.Body == nil {
-			s.logger.Errorf("request for schema subject latest '%v' returned an empty body", subject)
+			s.logger.Errorf("request for schema subject '%v' returned an empty body", subject)
 			err = errors.New("schema request returned an empty body")
 			continue
 		}
@@ -400,7 +431,7 @@ func (s *schemaRegistryEncoder) getEncoder(subject string) (schemaEncoder, int,
 		return c.encoder, c.id, nil
 	}
 
-	encoder, id, err := s.getLatestEncoder(subject)
+	encoder, id, err := s.getSubjectEncoder(subject)
 	if err != nil {
 		return nil, 0, err
 	}
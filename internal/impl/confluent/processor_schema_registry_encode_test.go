@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -63,6 +65,24 @@ subject: foo
 `,
 			expectedBaseURL: "http://example.com/v1",
 		},
+		{
+			name: "pinned version",
+			config: `
+url: http://example.com
+subject: foo
+version: 3
+`,
+			expectedBaseURL: "http://example.com",
+		},
+		{
+			name: "bad version",
+			config: `
+url: http://example.com
+subject: foo
+version: nope
+`,
+			errContains: "invalid schema version",
+		},
 	}
 
 	spec := schemaRegistryEncoderConfig()
@@ -91,6 +111,58 @@ subject: foo
 	}
 }
 
+func TestSchemaRegistryEncodePinnedVersionBasicAuth(t *testing.T) {
+	fooThird, err := json.Marshal(struct {
+		Schema string `json:"schema"`
+		ID     int    `json:"id"`
+	}{
+		Schema: testSchema,
+		ID:     7,
+	})
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "foo" || pass != "bar" {
+			http.Error(w, "nope", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/subjects/foo/versions/3" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(fooThird)
+	}))
+	t.Cleanup(ts.Close)
+
+	conf, err := schemaRegistryEncoderConfig().ParseYAML(fmt.Sprintf(`
+url: %v
+subject: foo
+version: 3
+basic_auth:
+  enabled: true
+  username: foo
+  password: bar
+`, ts.URL), nil)
+	require.NoError(t, err)
+
+	encoder, err := newSchemaRegistryEncoderFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	outBatches, err := encoder.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"Address":null,"Name":"foo","MaybeHobby":null}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, outBatches, 1)
+	require.Len(t, outBatches[0], 1)
+	require.NoError(t, outBatches[0][0].GetError())
+
+	b, err := outBatches[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "\x00\x00\x00\x00\x07\x06foo\x00\x00", string(b))
+
+	require.NoError(t, encoder.Close(context.Background()))
+}
+
 func TestSchemaRegistryEncodeAvroRawJSON(t *testing.T) {
 	fooFirst, err := json.Marshal(struct {
 		Schema string `json:"schema"`
@@ -111,7 +183,7 @@ func TestSchemaRegistryEncodeAvroRawJSON(t *testing.T) {
 	subj, err := service.NewInterpolatedString("foo")
 	require.NoError(t, err)
 
-	encoder, err := newSchemaRegistryEncoder(urlStr, nil, subj, true, time.Minute*10, time.Minute, nil)
+	encoder, err := newSchemaRegistryEncoder(urlStr, nil, nil, subj, "latest", true, time.Minute*10, time.Minute, nil)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -188,7 +260,7 @@ func TestSchemaRegistryEncodeAvro(t *testing.T) {
 	subj, err := service.NewInterpolatedString("foo")
 	require.NoError(t, err)
 
-	encoder, err := newSchemaRegistryEncoder(urlStr, nil, subj, false, time.Minute*10, time.Minute, nil)
+	encoder, err := newSchemaRegistryEncoder(urlStr, nil, nil, subj, "latest", false, time.Minute*10, time.Minute, nil)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -253,7 +325,7 @@ func TestSchemaRegistryEncodeClearExpired(t *testing.T) {
 	subj, err := service.NewInterpolatedString("foo")
 	require.NoError(t, err)
 
-	encoder, err := newSchemaRegistryEncoder(urlStr, nil, subj, false, time.Minute*10, time.Minute, nil)
+	encoder, err := newSchemaRegistryEncoder(urlStr, nil, nil, subj, "latest", false, time.Minute*10, time.Minute, nil)
 	require.NoError(t, err)
 	require.NoError(t, encoder.Close(context.Background()))
 
@@ -314,7 +386,7 @@ func TestSchemaRegistryEncodeRefresh(t *testing.T) {
 	subj, err := service.NewInterpolatedString("foo")
 	require.NoError(t, err)
 
-	encoder, err := newSchemaRegistryEncoder(urlStr, nil, subj, false, time.Minute*10, time.Minute, nil)
+	encoder, err := newSchemaRegistryEncoder(urlStr, nil, nil, subj, "latest", false, time.Minute*10, time.Minute, nil)
 	require.NoError(t, err)
 	require.NoError(t, encoder.Close(context.Background()))
 
@@ -0,0 +1,290 @@
+package datadog
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func init() {
+	_ = bundle.AllMetrics.Add(newDatadog, docs.ComponentSpec{
+		Name:    "datadog",
+		Type:    docs.TypeMetrics,
+		Status:  docs.StatusExperimental,
+		Version: "4.2.0",
+		Summary: `Pushes metrics to a [Datadog agent](https://docs.datadoghq.com/developers/dogstatsd/) using the DogStatsD protocol.`,
+		Description: `
+Metric labels are sent as DogStatsD tags, and timing metrics are sent as
+[distributions](https://docs.datadoghq.com/metrics/types/?tab=distribution#metric-types)
+by default so that percentiles can be aggregated globally across instances.
+Timing values are converted into the units set by ` + "`timing_units`" + `,
+which default to milliseconds. Classic DogStatsD timers are always in
+milliseconds and therefore the timing type ` + "`timing`" + ` can only be used with
+the units ` + "`ms`" + `.
+
+When ` + "`origin_detection`" + ` is enabled the environment variable
+` + "`DD_ENTITY_ID`" + ` (usually set via the Kubernetes downward API) is used in
+order to enrich metrics with container and pod tags by the agent.`,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("address", "The address of the DogStatsD server to send metrics to, unix domain sockets are supported with the prefix `unix://`.", "localhost:8125", "unix:///var/run/datadog/dsd.socket").HasDefault("localhost:8125"),
+			docs.FieldString("namespace", "An optional prefix to add to all metric names.", "benthos.").HasDefault(""),
+			docs.FieldString("tags", "A map of global tags to add to all metrics.").Map().HasDefault(map[string]interface{}{}),
+			docs.FieldString("timing_type", "The DogStatsD metric type to use for timing metrics.").HasAnnotatedOptions(
+				"distribution", "Send timings as distributions, which are aggregated server side and support global percentiles.",
+				"histogram", "Send timings as histograms, which are aggregated by the agent.",
+				"timing", "Send timings using the classic StatsD timer type.",
+			).HasDefault("distribution"),
+			docs.FieldString("timing_units", "The units to convert timing values into before they are sent. The timing type `timing` only supports `ms`.").HasOptions(
+				"ns", "us", "ms", "s",
+			).Advanced().HasDefault("ms"),
+			docs.FieldBool("origin_detection", "Whether to send origin detection tags, allowing the agent to enrich metrics with container tags.").Advanced().HasDefault(true),
+			docs.FieldString("flush_period", "The time interval between metrics flushes.").Advanced().HasDefault("100ms"),
+		),
+	})
+}
+
+//------------------------------------------------------------------------------
+
+type ddCounter struct {
+	path string
+	c    *statsd.Client
+	tags []string
+}
+
+func (d *ddCounter) Incr(count int64) {
+	_ = d.c.Count(d.path, count, d.tags, 1)
+}
+
+type ddTimer struct {
+	path       string
+	c          *statsd.Client
+	tags       []string
+	timingType string
+	unit       time.Duration
+}
+
+// Timing values are always in nanoseconds and are converted into the
+// configured units, the DogStatsD client would otherwise send everything other
+// than classic timers as raw nanoseconds. Classic timers are only permitted
+// with millisecond units.
+func (d *ddTimer) Timing(delta int64) {
+	v := float64(delta) / float64(d.unit)
+	switch d.timingType {
+	case "histogram":
+		_ = d.c.Histogram(d.path, v, d.tags, 1)
+	case "timing":
+		_ = d.c.TimeInMilliseconds(d.path, v, d.tags, 1)
+	default:
+		_ = d.c.Distribution(d.path, v, d.tags, 1)
+	}
+}
+
+// Histogram values are unitless and are sent as distributions unless the
+// timing type is configured otherwise, in which case they are sent as
+// histograms as DogStatsD timers are always in milliseconds.
+type ddHistogram struct {
+	path         string
+	c            *statsd.Client
+	tags         []string
+	distribution bool
+}
+
+func (d *ddHistogram) Observe(value int64) {
+	if d.distribution {
+		_ = d.c.Distribution(d.path, float64(value), d.tags, 1)
+		return
+	}
+	_ = d.c.Histogram(d.path, float64(value), d.tags, 1)
+}
+
+// DogStatsD gauges are absolute values and therefore we track the current
+// value locally in order to support relative changes. Gauges are shared across
+// calls to With for the same label values.
+type ddGauge struct {
+	path  string
+	c     *statsd.Client
+	tags  []string
+	value int64
+}
+
+func (d *ddGauge) Set(value int64) {
+	atomic.StoreInt64(&d.value, value)
+	_ = d.c.Gauge(d.path, float64(value), d.tags, 1)
+}
+
+func (d *ddGauge) Incr(count int64) {
+	_ = d.c.Gauge(d.path, float64(atomic.AddInt64(&d.value, count)), d.tags, 1)
+}
+
+func (d *ddGauge) Decr(count int64) {
+	_ = d.c.Gauge(d.path, float64(atomic.AddInt64(&d.value, -count)), d.tags, 1)
+}
+
+//------------------------------------------------------------------------------
+
+type ddMetrics struct {
+	c          *statsd.Client
+	timingType string
+	timingUnit time.Duration
+	gauges     sync.Map
+	log        log.Modular
+}
+
+func newDatadog(config metrics.Config, log log.Modular) (metrics.Type, error) {
+	conf := config.Datadog
+	switch conf.TimingType {
+	case "distribution", "histogram", "timing":
+	default:
+		return nil, fmt.Errorf("timing type '%v' was not recognised", conf.TimingType)
+	}
+
+	var timingUnit time.Duration
+	switch conf.TimingUnits {
+	case "ns":
+		timingUnit = time.Nanosecond
+	case "us":
+		timingUnit = time.Microsecond
+	case "ms":
+		timingUnit = time.Millisecond
+	case "s":
+		timingUnit = time.Second
+	default:
+		return nil, fmt.Errorf("timing units '%v' were not recognised", conf.TimingUnits)
+	}
+	if conf.TimingType == "timing" && timingUnit != time.Millisecond {
+		return nil, fmt.Errorf("timing units '%v' are not supported by the timing type 'timing', which is always in milliseconds", conf.TimingUnits)
+	}
+
+	flushPeriod, err := time.ParseDuration(conf.FlushPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flush period: %s", err)
+	}
+
+	opts := []statsd.Option{
+		statsd.WithTags(globalTags(conf.Tags)),
+		statsd.WithBufferFlushInterval(flushPeriod),
+		statsd.WithoutTelemetry(),
+	}
+	if conf.Namespace != "" {
+		// The client appends a dot to any namespace, including an empty one.
+		opts = append(opts, statsd.WithNamespace(conf.Namespace))
+	}
+	if conf.OriginDetection {
+		opts = append(opts, statsd.WithOriginDetection())
+	} else {
+		opts = append(opts, statsd.WithoutOriginDetection())
+	}
+
+	client, err := statsd.New(conf.Address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dogstatsd client: %w", err)
+	}
+	return &ddMetrics{
+		c:          client,
+		timingType: conf.TimingType,
+		timingUnit: timingUnit,
+		log:        log,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *ddMetrics) GetCounter(path string) metrics.StatCounter {
+	return d.GetCounterVec(path).With()
+}
+
+func (d *ddMetrics) GetCounterVec(path string, n ...string) metrics.StatCounterVec {
+	return metrics.FakeCounterVec(func(l ...string) metrics.StatCounter {
+		return &ddCounter{
+			path: path,
+			c:    d.c,
+			tags: tags(n, l),
+		}
+	})
+}
+
+func (d *ddMetrics) GetTimer(path string) metrics.StatTimer {
+	return d.GetTimerVec(path).With()
+}
+
+func (d *ddMetrics) GetTimerVec(path string, n ...string) metrics.StatTimerVec {
+	return metrics.FakeTimerVec(func(l ...string) metrics.StatTimer {
+		return &ddTimer{
+			path:       path,
+			c:          d.c,
+			tags:       tags(n, l),
+			timingType: d.timingType,
+			unit:       d.timingUnit,
+		}
+	})
+}
+
+func (d *ddMetrics) GetHistogram(path string) metrics.StatHistogram {
+	return d.GetHistogramVec(path).With()
+}
+
+func (d *ddMetrics) GetHistogramVec(path string, n ...string) metrics.StatHistogramVec {
+	return metrics.FakeHistogramVec(func(l ...string) metrics.StatHistogram {
+		return &ddHistogram{
+			path:         path,
+			c:            d.c,
+			tags:         tags(n, l),
+			distribution: d.timingType == "distribution",
+		}
+	})
+}
+
+func (d *ddMetrics) GetGauge(path string) metrics.StatGauge {
+	return d.GetGaugeVec(path).With()
+}
+
+func (d *ddMetrics) GetGaugeVec(path string, n ...string) metrics.StatGaugeVec {
+	return metrics.FakeGaugeVec(func(l ...string) metrics.StatGauge {
+		t := tags(n, l)
+		g, _ := d.gauges.LoadOrStore(path+"|"+strings.Join(t, ","), &ddGauge{
+			path: path,
+			c:    d.c,
+			tags: t,
+		})
+		return g.(*ddGauge)
+	})
+}
+
+func (d *ddMetrics) HandlerFunc() http.HandlerFunc {
+	return nil
+}
+
+func (d *ddMetrics) Close() error {
+	return d.c.Close()
+}
+
+func globalTags(m map[string]string) []string {
+	tags := make([]string, 0, len(m))
+	for k, v := range m {
+		tags = append(tags, k+":"+v)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func tags(labels, values []string) []string {
+	if len(labels) != len(values) {
+		return nil
+	}
+	tags := make([]string, len(labels))
+	for i := range labels {
+		tags[i] = labels[i] + ":" + values[i]
+	}
+	return tags
+}
@@ -0,0 +1,127 @@
+package datadog
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func listenDatadog(t *testing.T) net.PacketConn {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return conn
+}
+
+func readDatadogLines(t *testing.T, conn net.PacketConn, count int) []string {
+	t.Helper()
+
+	var lines []string
+	buf := make([]byte, 1024)
+	for len(lines) < count {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second*5)))
+		n, _, err := conn.ReadFrom(buf)
+		require.NoError(t, err)
+		for _, l := range strings.Split(string(buf[:n]), "\n") {
+			if l != "" {
+				lines = append(lines, l)
+			}
+		}
+	}
+	return lines
+}
+
+func TestDatadogMetrics(t *testing.T) {
+	conn := listenDatadog(t)
+
+	conf := metrics.NewConfig()
+	conf.Datadog.Address = conn.LocalAddr().String()
+	conf.Datadog.Namespace = "benthos."
+	conf.Datadog.Tags = map[string]string{"service": "foo"}
+	conf.Datadog.OriginDetection = false
+
+	m, err := newDatadog(conf, log.Noop())
+	require.NoError(t, err)
+
+	m.GetCounterVec("counter", "label").With("a").Incr(3)
+	m.GetTimer("timer").Timing(int64(250 * time.Millisecond))
+	m.GetHistogramVec("hist", "label").With("c").Observe(20)
+
+	gauge := m.GetGaugeVec("gauge", "label").With("b")
+	gauge.Set(5)
+	m.GetGaugeVec("gauge", "label").With("b").Incr(2)
+
+	require.NoError(t, m.Close())
+
+	assert.ElementsMatch(t, []string{
+		"benthos.counter:3|c|#service:foo,label:a",
+		"benthos.timer:250|d|#service:foo",
+		"benthos.hist:20|d|#service:foo,label:c",
+		"benthos.gauge:7|g|#service:foo,label:b",
+	}, readDatadogLines(t, conn, 4))
+}
+
+func TestDatadogTimingUnits(t *testing.T) {
+	tests := []struct {
+		timingType  string
+		timingUnits string
+		expected    string
+	}{
+		{timingType: "distribution", timingUnits: "ms", expected: "timer:1500|d"},
+		{timingType: "histogram", timingUnits: "ms", expected: "timer:1500|h"},
+		{timingType: "timing", timingUnits: "ms", expected: "timer:1500.000000|ms"},
+		{timingType: "distribution", timingUnits: "s", expected: "timer:1.5|d"},
+		{timingType: "histogram", timingUnits: "us", expected: "timer:1500000|h"},
+		{timingType: "histogram", timingUnits: "ns", expected: "timer:1500000000|h"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.timingType+"_"+test.timingUnits, func(t *testing.T) {
+			conn := listenDatadog(t)
+
+			conf := metrics.NewConfig()
+			conf.Datadog.Address = conn.LocalAddr().String()
+			conf.Datadog.TimingType = test.timingType
+			conf.Datadog.TimingUnits = test.timingUnits
+			conf.Datadog.OriginDetection = false
+
+			m, err := newDatadog(conf, log.Noop())
+			require.NoError(t, err)
+
+			m.GetTimer("timer").Timing(int64(1500 * time.Millisecond))
+			require.NoError(t, m.Close())
+
+			assert.Equal(t, []string{test.expected}, readDatadogLines(t, conn, 1))
+		})
+	}
+}
+
+func TestDatadogBadConfig(t *testing.T) {
+	conf := metrics.NewConfig()
+	conf.Datadog.TimingUnits = "minutes"
+	_, err := newDatadog(conf, log.Noop())
+	require.Error(t, err)
+
+	conf = metrics.NewConfig()
+	conf.Datadog.TimingType = "nope"
+	_, err = newDatadog(conf, log.Noop())
+	require.Error(t, err)
+
+	conf = metrics.NewConfig()
+	conf.Datadog.TimingType = "timing"
+	conf.Datadog.TimingUnits = "ns"
+	_, err = newDatadog(conf, log.Noop())
+	require.EqualError(t, err, "timing units 'ns' are not supported by the timing type 'timing', which is always in milliseconds")
+}
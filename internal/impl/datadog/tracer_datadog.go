@@ -0,0 +1,114 @@
+package datadog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/tracer"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+func init() {
+	_ = bundle.AllTracers.Add(newDatadogTracer, docs.ComponentSpec{
+		Name:    "datadog",
+		Type:    docs.TypeTracer,
+		Status:  docs.StatusExperimental,
+		Version: "4.2.0",
+		Summary: `Send tracing events to a [Datadog agent](https://docs.datadoghq.com/tracing/) as APM traces.`,
+		Description: `
+Spans are delivered to the agent using its OTLP HTTP receiver, which must be
+enabled with the agent setting ` + "`otlp_config.receiver.protocols.http.endpoint`" + `.
+The fields ` + "`service`, `env` and `version`" + ` map onto Datadog
+[unified service tagging](https://docs.datadoghq.com/getting_started/tagging/unified_service_tagging/).`,
+		Config: docs.FieldObject("", "").WithChildren(
+			docs.FieldString("agent_address", "The address of the Datadog agent OTLP HTTP receiver.").HasDefault("localhost:4318"),
+			docs.FieldString("service", "The service name to attach to spans.").HasDefault("benthos"),
+			docs.FieldString("env", "An optional environment name to attach to spans.", "production").HasDefault(""),
+			docs.FieldString("version", "An optional version to attach to spans.").HasDefault(""),
+			docs.FieldFloat("sampling_ratio", "Sets the ratio of traces to sample. Tuning the sampling ratio is recommended for high-volume production workloads.", 1.0).HasDefault(1.0),
+			docs.FieldString("tags", "A map of tags to add to tracing spans.").Map().Advanced().HasDefault(map[string]interface{}{}),
+			docs.FieldString("flush_interval", "The period of time between each flush of tracing spans.").HasDefault(""),
+		),
+	})
+}
+
+//------------------------------------------------------------------------------
+
+type ddTracer struct {
+	prov     *tracesdk.TracerProvider
+	resource *resource.Resource
+}
+
+func newDatadogTracer(config tracer.Config) (tracer.Type, error) {
+	conf := config.Datadog
+
+	exp, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpoint(conf.AgentAddress),
+		otlptracehttp.WithInsecure(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create datadog exporter: %w", err)
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.ServiceNameKey.String(conf.Service),
+	}
+	if conf.Env != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironmentKey.String(conf.Env))
+	}
+	if conf.Version != "" {
+		attrs = append(attrs, semconv.ServiceVersionKey.String(conf.Version))
+	}
+	for k, v := range conf.Tags {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	var batchOpts []tracesdk.BatchSpanProcessorOption
+	if i := conf.FlushInterval; len(i) > 0 {
+		flushInterval, err := time.ParseDuration(i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse flush interval '%s': %v", i, err)
+		}
+		batchOpts = append(batchOpts, tracesdk.WithBatchTimeout(flushInterval))
+	}
+
+	res := resource.NewWithAttributes(semconv.SchemaURL, attrs...)
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithBatcher(exp, batchOpts...),
+		tracesdk.WithResource(res),
+		tracesdk.WithSampler(tracesdk.ParentBased(tracesdk.TraceIDRatioBased(conf.SamplingRatio))),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return &ddTracer{prov: tp, resource: res}, nil
+}
+
+// Close stops the tracer.
+func (d *ddTracer) Close() error {
+	if d.prov == nil {
+		return nil
+	}
+
+	sig := shutdown.NewSignaller()
+	ctx, cancel := sig.CloseAtLeisureCtx(context.Background())
+	defer cancel()
+
+	if err := d.prov.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shutdown trace provider: %w", err)
+	}
+	d.prov = nil
+	return nil
+}
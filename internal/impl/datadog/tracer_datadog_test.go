@@ -0,0 +1,76 @@
+package datadog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component/tracer"
+)
+
+func TestDatadogTracerConfig(t *testing.T) {
+	conf := tracer.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+datadog:
+  agent_address: localhost:1234
+  service: foo
+  env: production
+  version: 1.2.3
+  sampling_ratio: 0.5
+  tags:
+    team: data
+  flush_interval: 5s
+`), &conf))
+
+	assert.Equal(t, tracer.DatadogConfig{
+		AgentAddress:  "localhost:1234",
+		Service:       "foo",
+		Env:           "production",
+		Version:       "1.2.3",
+		SamplingRatio: 0.5,
+		Tags:          map[string]string{"team": "data"},
+		FlushInterval: "5s",
+	}, conf.Datadog)
+}
+
+func TestDatadogTracerConstructor(t *testing.T) {
+	conf := tracer.NewConfig()
+	conf.Datadog.Env = "production"
+	conf.Datadog.Version = "1.2.3"
+	conf.Datadog.Tags = map[string]string{"team": "data"}
+	conf.Datadog.FlushInterval = "5s"
+
+	tr, err := newDatadogTracer(conf)
+	require.NoError(t, err)
+
+	ddTr, ok := tr.(*ddTracer)
+	require.True(t, ok)
+	require.NotNil(t, ddTr.prov)
+
+	res := ddTr.resource
+	for _, kv := range []attribute.KeyValue{
+		semconv.ServiceNameKey.String("benthos"),
+		semconv.DeploymentEnvironmentKey.String("production"),
+		semconv.ServiceVersionKey.String("1.2.3"),
+		attribute.String("team", "data"),
+	} {
+		v, exists := res.Set().Value(kv.Key)
+		require.True(t, exists, string(kv.Key))
+		assert.Equal(t, kv.Value, v, string(kv.Key))
+	}
+
+	require.NoError(t, tr.Close())
+	require.NoError(t, tr.Close())
+}
+
+func TestDatadogTracerBadFlushInterval(t *testing.T) {
+	conf := tracer.NewConfig()
+	conf.Datadog.FlushInterval = "not a duration"
+
+	_, err := newDatadogTracer(conf)
+	require.Error(t, err)
+}
@@ -31,7 +31,7 @@ func init() {
 			docs.FieldString("db", "The name of the database to use.").HasDefault(""),
 			btls.FieldSpec(),
 			docs.FieldString("username", "A username (when applicable).").Advanced().HasDefault(""),
-			docs.FieldString("password", "A password (when applicable).").Advanced().HasDefault(""),
+			docs.FieldString("password", "A password (when applicable).").Secret().Advanced().HasDefault(""),
 			docs.FieldObject("include", "Optional additional metrics to collect, enabling these metrics may have some performance implications as it acquires a global semaphore and does `stoptheworld()`.").WithChildren(
 				docs.FieldString("runtime", "A duration string indicating how often to poll and collect runtime metrics. Leave empty to disable this metric", "1m").HasDefault(""),
 				docs.FieldString("debug_gc", "A duration string indicating how often to poll and collect GC metrics. Leave empty to disable this metric.", "1m").HasDefault(""),
@@ -322,6 +322,21 @@ func (i *influxDBMetrics) GetTimerVec(path string, n ...string) imetrics.StatTim
 	})
 }
 
+func (i *influxDBMetrics) GetHistogram(path string) imetrics.StatHistogram {
+	return i.GetHistogramVec(path).With()
+}
+
+func (i *influxDBMetrics) GetHistogramVec(path string, n ...string) imetrics.StatHistogramVec {
+	return imetrics.FakeHistogramVec(func(l ...string) imetrics.StatHistogram {
+		encodedName := encodeInfluxDBName(path, n, l)
+		return i.registry.GetOrRegister(encodedName, func() metrics.Histogram {
+			return influxDBHistogram{
+				metrics.NewHistogram(metrics.NewExpDecaySample(1028, 0.015)),
+			}
+		}).(influxDBHistogram)
+	})
+}
+
 func (i *influxDBMetrics) GetGauge(path string) imetrics.StatGauge {
 	encodedName := encodeInfluxDBName(path, nil, nil)
 	var result = i.registry.GetOrRegister(encodedName, func() metrics.Gauge {
@@ -49,6 +49,15 @@ func (i influxDBTimer) Timing(delta int64) {
 	i.Update(time.Duration(delta))
 }
 
+type influxDBHistogram struct {
+	metrics.Histogram
+}
+
+// Observe records a histogram value.
+func (i influxDBHistogram) Observe(value int64) {
+	i.Update(value)
+}
+
 // encodeInfluxDBName accepts a measurement name and a map of tag values and
 // returns influx line protocol-formatted string.
 func encodeInfluxDBName(name string, tagNames, tagValues []string) string {
@@ -2,6 +2,9 @@ package io
 
 import (
 	"os"
+	"sync"
+
+	"github.com/gofrs/uuid"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
@@ -27,24 +30,75 @@ func init() {
 		panic(err)
 	}
 
+	if err := bloblang.RegisterFunctionV2("pid",
+		bloblang.NewPluginSpec().
+			Impure().
+			Category(query.FunctionCategoryEnvironment).
+			Version("4.2.0").
+			Description(`Returns the process ID of the Benthos instance running the mapping.`).
+			Example("", `meta processed_by = "%v:%v".format(hostname(), pid())`),
+		func(_ *bloblang.ParsedParams) (bloblang.Function, error) {
+			pid := int64(os.Getpid())
+			return func() (interface{}, error) {
+				return pid, nil
+			}, nil
+		},
+	); err != nil {
+		panic(err)
+	}
+
+	if err := bloblang.RegisterFunctionV2("instance_id",
+		bloblang.NewPluginSpec().
+			Impure().
+			Category(query.FunctionCategoryEnvironment).
+			Version("4.2.0").
+			Description(`Returns a unique identifier of the Benthos instance running the mapping, which is generated once when the process starts. This is useful for telling apart the messages processed by replicas that share a hostname.`).
+			Example("", `meta processed_by = instance_id()`),
+		func(_ *bloblang.ParsedParams) (bloblang.Function, error) {
+			id, err := getInstanceID()
+			if err != nil {
+				return nil, err
+			}
+			return func() (interface{}, error) {
+				return id, nil
+			}, nil
+		},
+	); err != nil {
+		panic(err)
+	}
+
 	if err := bloblang.RegisterFunctionV2("env",
 		bloblang.NewPluginSpec().
 			Impure().
 			Category(query.FunctionCategoryEnvironment).
 			Description("Returns the value of an environment variable, or `null` if the environment variable does not exist.").
 			Param(bloblang.NewStringParam("name").Description("The name of an environment variable.")).
-			Example("", `root.thing.key = env("key").or("default value")`),
+			Param(bloblang.NewBoolParam("no_cache").Description("Force the variable lookup to occur for each mapping invocation, otherwise it occurs once when the mapping is parsed.").Default(false)).
+			Example("", `root.thing.key = env("key").or("default value")`).
+			Example("When the environment variable is changed during the lifetime of the process the lookup can be forced to occur for each invocation.", `root.thing.key = env(name: "key", no_cache: true)`),
 		func(args *bloblang.ParsedParams) (bloblang.Function, error) {
 			name, err := args.GetString("name")
 			if err != nil {
 				return nil, err
 			}
+			noCache, err := args.GetBool("no_cache")
+			if err != nil {
+				return nil, err
+			}
 
-			var value interface{}
-			if valueStr, exists := os.LookupEnv(name); exists {
-				value = valueStr
+			lookup := func() interface{} {
+				if valueStr, exists := os.LookupEnv(name); exists {
+					return valueStr
+				}
+				return nil
+			}
+			if noCache {
+				return func() (interface{}, error) {
+					return lookup(), nil
+				}, nil
 			}
 
+			value := lookup()
 			return func() (interface{}, error) {
 				return value, nil
 			}, nil
@@ -59,15 +113,30 @@ func init() {
 			Category(query.FunctionCategoryEnvironment).
 			Description("Reads a file and returns its contents. Relative paths are resolved from the directory of the process executing the mapping.").
 			Param(bloblang.NewStringParam("path").Description("The path of the target file.")).
+			Param(bloblang.NewBoolParam("no_cache").Description("Force the file to be read for each mapping invocation, otherwise it is read once when the mapping is parsed.").Default(false)).
 			Example("", `root.doc = file(env("BENTHOS_TEST_BLOBLANG_FILE")).parse_json()`, [2]string{
 				`{}`,
 				`{"doc":{"foo":"bar"}}`,
+			}).
+			Example("When the file is changed during the lifetime of the process it can be forced to be read for each invocation.", `root.doc = file(path: env("BENTHOS_TEST_BLOBLANG_FILE"), no_cache: true).parse_json()`, [2]string{
+				`{}`,
+				`{"doc":{"foo":"bar"}}`,
 			}),
 		func(args *bloblang.ParsedParams) (bloblang.Function, error) {
 			path, err := args.GetString("path")
 			if err != nil {
 				return nil, err
 			}
+			noCache, err := args.GetBool("no_cache")
+			if err != nil {
+				return nil, err
+			}
+
+			if noCache {
+				return func() (interface{}, error) {
+					return os.ReadFile(path)
+				}, nil
+			}
 
 			pathBytes, err := os.ReadFile(path)
 			if err != nil {
@@ -82,3 +151,19 @@ func init() {
 		panic(err)
 	}
 }
+
+var (
+	instanceID     string
+	instanceIDErr  error
+	instanceIDOnce sync.Once
+)
+
+func getInstanceID() (string, error) {
+	instanceIDOnce.Do(func() {
+		var u4 uuid.UUID
+		if u4, instanceIDErr = uuid.NewV4(); instanceIDErr == nil {
+			instanceID = u4.String()
+		}
+	})
+	return instanceID, instanceIDErr
+}
@@ -2,6 +2,7 @@ package io_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -25,6 +26,69 @@ func TestEnvFunction(t *testing.T) {
 	assert.Equal(t, "foobar", res)
 }
 
+func TestEnvFunctionNoCache(t *testing.T) {
+	key := "BENTHOS_TEST_BLOBLANG_FUNCTION_NO_CACHE"
+	os.Setenv(key, "foo")
+	t.Cleanup(func() {
+		os.Unsetenv(key)
+	})
+
+	cached, err := query.InitFunctionHelper("env", key)
+	require.NoError(t, err)
+
+	uncached, err := query.InitFunctionHelper("env", key, true)
+	require.NoError(t, err)
+
+	os.Setenv(key, "bar")
+
+	res, err := cached.Exec(query.FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, "foo", res)
+
+	res, err = uncached.Exec(query.FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, "bar", res)
+
+	os.Unsetenv(key)
+
+	res, err = uncached.Exec(query.FunctionContext{})
+	require.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestFileFunction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.txt")
+	require.NoError(t, os.WriteFile(path, []byte("foo"), 0o644))
+
+	cached, err := query.InitFunctionHelper("file", path)
+	require.NoError(t, err)
+
+	uncached, err := query.InitFunctionHelper("file", path, true)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("bar"), 0o644))
+
+	res, err := cached.Exec(query.FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("foo"), res)
+
+	res, err = uncached.Exec(query.FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("bar"), res)
+
+	require.NoError(t, os.Remove(path))
+
+	res, err = cached.Exec(query.FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("foo"), res)
+
+	_, err = uncached.Exec(query.FunctionContext{})
+	require.Error(t, err)
+
+	_, err = query.InitFunctionHelper("file", path)
+	require.Error(t, err)
+}
+
 func TestHostname(t *testing.T) {
 	hostname, _ := os.Hostname()
 
@@ -35,3 +99,29 @@ func TestHostname(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, hostname, res)
 }
+
+func TestPid(t *testing.T) {
+	e, err := query.InitFunctionHelper("pid")
+	require.NoError(t, err)
+
+	res, err := e.Exec(query.FunctionContext{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(os.Getpid()), res)
+}
+
+func TestInstanceID(t *testing.T) {
+	e1, err := query.InitFunctionHelper("instance_id")
+	require.NoError(t, err)
+
+	e2, err := query.InitFunctionHelper("instance_id")
+	require.NoError(t, err)
+
+	res1, err := e1.Exec(query.FunctionContext{})
+	require.NoError(t, err)
+
+	res2, err := e2.Exec(query.FunctionContext{})
+	require.NoError(t, err)
+
+	assert.Len(t, res1, 36)
+	assert.Equal(t, res1, res2)
+}
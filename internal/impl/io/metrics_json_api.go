@@ -88,6 +88,14 @@ func (h *jsonAPIMetrics) GetTimerVec(path string, n ...string) metrics.StatTimer
 	return h.local.GetTimerVec(path, n...)
 }
 
+func (h *jsonAPIMetrics) GetHistogram(path string) metrics.StatHistogram {
+	return h.local.GetHistogram(path)
+}
+
+func (h *jsonAPIMetrics) GetHistogramVec(path string, n ...string) metrics.StatHistogramVec {
+	return h.local.GetHistogramVec(path, n...)
+}
+
 func (h *jsonAPIMetrics) GetGauge(path string) metrics.StatGauge {
 	return h.local.GetGauge(path)
 }
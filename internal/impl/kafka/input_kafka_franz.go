@@ -430,7 +430,7 @@ func recordToMessage(record *kgo.Record) *service.Message {
 	for _, hdr := range record.Headers {
 		msg.MetaSet(hdr.Key, string(hdr.Value))
 	}
-	return msg
+	return msg.WithTxnOffset(kafkaTxnSource, kafkaTxnPartition(record.Topic, record.Partition), record.Offset)
 }
 
 func (f *franzKafkaReader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
@@ -402,7 +402,20 @@ func dataToPart(highestOffset int64, data *sarama.ConsumerMessage) *message.Part
 	part.MetaSet("kafka_lag", strconv.FormatInt(lag, 10))
 	part.MetaSet("kafka_timestamp_unix", strconv.FormatInt(data.Timestamp.Unix(), 10))
 
-	return part
+	return message.WithTxnOffset(part, message.TxnOffset{
+		Source:    kafkaTxnSource,
+		Partition: kafkaTxnPartition(data.Topic, data.Partition),
+		Offset:    data.Offset,
+	})
+}
+
+// kafkaTxnSource is the source of the transaction offsets attached to
+// messages consumed from Kafka, which is shared by all Kafka inputs so that
+// outputs recording offsets are unaffected by switching between them.
+const kafkaTxnSource = "kafka"
+
+func kafkaTxnPartition(topic string, partition int32) string {
+	return topic + "/" + strconv.Itoa(int(partition))
 }
 
 //------------------------------------------------------------------------------
@@ -62,6 +62,11 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 			Description("Optionally set an explicit compression type. The default preference is to use snappy when the broker supports it, and fall back to none if not.").
 			Optional().
 			Advanced()).
+		Field(service.NewStringField("transactional_id").
+			Description("An optional transactional ID, when set each batch of messages is written within a Kafka transaction, and therefore becomes visible to consumers with the `read_committed` isolation level atomically, and only once the transaction commits is the batch acknowledged. When set `max_in_flight` is ignored and batches are written one at a time. The ID should be unique to each instance of this output and stable across restarts. The offsets of consumed messages are not committed within the transaction, and therefore messages redelivered by an input, such as after a restart before a `kafka` input commits its offsets, may still be written more than once.").
+			Advanced().
+			Optional().
+			Version("4.2.0")).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField)
 }
@@ -80,7 +85,15 @@ func init() {
 			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
 				return
 			}
-			output, err = newFranzKafkaWriterFromConfig(conf, mgr.Logger())
+			var w *franzKafkaWriter
+			if w, err = newFranzKafkaWriterFromConfig(conf, mgr.Logger()); err != nil {
+				return
+			}
+			if w.transactionalID != "" {
+				// Transactions of a client are sequential.
+				maxInFlight = 1
+			}
+			output = w
 			return
 		})
 
@@ -102,6 +115,7 @@ type franzKafkaWriter struct {
 	partitioner      kgo.Partitioner
 	produceMaxBytes  int32
 	compressionPrefs []kgo.CompressionCodec
+	transactionalID  string
 
 	client *kgo.Client
 
@@ -187,6 +201,12 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 		}
 	}
 
+	if conf.Contains("transactional_id") {
+		if f.transactionalID, err = conf.FieldString("transactional_id"); err != nil {
+			return nil, err
+		}
+	}
+
 	if conf.Contains("metadata") {
 		if f.metaFilter, err = conf.FieldMetadataFilter("metadata"); err != nil {
 			return nil, err
@@ -230,6 +250,9 @@ func (f *franzKafkaWriter) Connect(ctx context.Context) error {
 	if len(f.compressionPrefs) > 0 {
 		clientOpts = append(clientOpts, kgo.ProducerBatchCompression(f.compressionPrefs...))
 	}
+	if f.transactionalID != "" {
+		clientOpts = append(clientOpts, kgo.TransactionalID(f.transactionalID))
+	}
 
 	cl, err := kgo.NewClient(clientOpts...)
 	if err != nil {
@@ -265,12 +288,43 @@ func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatc
 		records = append(records, record)
 	}
 
+	if f.transactionalID != "" {
+		return f.writeTransaction(ctx, records)
+	}
+
 	// TODO: This is very cool and allows us to easily return granular errors,
 	// so we should honor travis by doing it.
 	err = f.client.ProduceSync(ctx, records...).FirstErr()
 	return
 }
 
+func (f *franzKafkaWriter) writeTransaction(ctx context.Context, records []*kgo.Record) error {
+	if err := f.client.BeginTransaction(); err != nil {
+		// The client is in a fatal state and must be recreated.
+		f.disconnect()
+		return err
+	}
+	err := f.client.ProduceSync(ctx, records...).FirstErr()
+	if err == nil {
+		if err = f.client.EndTransaction(ctx, kgo.TryCommit); err == nil {
+			return nil
+		}
+	}
+
+	// Ending the transaction is attempted without the write context as a
+	// cancelled abort leaves the client in an unknown state.
+	abortCtx := context.Background()
+	abortErr := f.client.AbortBufferedRecords(abortCtx)
+	if abortErr == nil {
+		abortErr = f.client.EndTransaction(abortCtx, kgo.TryAbort)
+	}
+	if abortErr != nil {
+		f.log.Errorf("Failed to abort transaction, reconnecting: %v", abortErr)
+		f.disconnect()
+	}
+	return err
+}
+
 func (f *franzKafkaWriter) disconnect() {
 	if f.client == nil {
 		return
@@ -0,0 +1,43 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFranzKafkaOutputTransactionalID(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		conf  string
+		txnID string
+	}{
+		{
+			name: "without transactions",
+			conf: `
+seed_brokers: [ localhost:9092 ]
+topic: foo
+`,
+		},
+		{
+			name: "with transactions",
+			conf: `
+seed_brokers: [ localhost:9092 ]
+topic: foo
+transactional_id: bar
+`,
+			txnID: "bar",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := franzKafkaOutputConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			w, err := newFranzKafkaWriterFromConfig(conf, nil)
+			require.NoError(t, err)
+			assert.Equal(t, test.txnID, w.transactionalID)
+		})
+	}
+}
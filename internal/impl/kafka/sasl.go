@@ -30,9 +30,11 @@ var saslField = service.NewObjectListField("sasl",
 		Description("A username to provide for PLAIN or SCRAM-* authentication.").
 		Default(""),
 	service.NewStringField("password").
+		Secret().
 		Description("A password to provide for PLAIN or SCRAM-* authentication.").
 		Default(""),
 	service.NewStringField("token").
+		Secret().
 		Description("The token to use for a single session's OAUTHBEARER authentication.").
 		Default(""),
 	service.NewStringMapField("extensions").
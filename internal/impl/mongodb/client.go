@@ -16,7 +16,7 @@ func clientFields() []*service.ConfigField {
 			Description("The URL of the target MongoDB server.").
 			Example("mongodb://localhost:27017"),
 		service.NewStringField("username").Description("The username to connect to the database.").Default(""),
-		service.NewStringField("password").Description("The password to connect to the database.").Default(""),
+		service.NewStringField("password").Secret().Description("The password to connect to the database.").Default(""),
 	}
 }
 
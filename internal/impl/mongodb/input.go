@@ -27,7 +27,7 @@ func mongoConfigSpec() *service.ConfigSpec {
 		Field(service.NewStringField("database").Description("The name of the target MongoDB database.")).
 		Field(service.NewStringField("collection").Description("The collection to select from.")).
 		Field(service.NewStringField("username").Description("The username to connect to the database.").Default("")).
-		Field(service.NewStringField("password").Description("The password to connect to the database.").Default("")).
+		Field(service.NewStringField("password").Secret().Description("The password to connect to the database.").Default("")).
 		Field(service.NewStringEnumField("operation", FindInputOperation, AggregateInputOperation).
 			Description("The mongodb operation to perform.").
 			Default(FindInputOperation).Advanced().
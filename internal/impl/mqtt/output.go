@@ -56,7 +56,7 @@ messages these interpolations are performed per message part.`),
 			docs.FieldString("retained_interpolated", "Override the value of `retained` with an interpolable value, this allows it to be dynamically set based on message contents. The value must resolve to either `true` or `false`.").IsInterpolated().Advanced().AtVersion("3.59.0"),
 			mqttconf.WillFieldSpec(),
 			docs.FieldString("user", "A username to connect with.").Advanced(),
-			docs.FieldString("password", "A password to connect with.").Advanced(),
+			docs.FieldString("password", "A password to connect with.").Secret().Advanced(),
 			docs.FieldInt("keepalive", "Max seconds of inactivity before a keepalive message is sent.").Advanced(),
 			tls.FieldSpec().AtVersion("3.45.0"),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
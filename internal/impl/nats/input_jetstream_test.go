@@ -1,12 +1,21 @@
 package nats
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
 )
 
 func TestInputJetStreamConfigParse(t *testing.T) {
@@ -32,3 +41,126 @@ auth:
 	assert.Equal(t, "test auth n key file", e.authConf.NKeyFile)
 	assert.Equal(t, "test auth user creds file", e.authConf.UserCredentialsFile)
 }
+
+func runJetStreamServer(t *testing.T) *server.Server {
+	t.Helper()
+
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+
+	go s.Start()
+	require.True(t, s.ReadyForConnections(time.Second*10))
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+type jetStreamTestBatchOutput struct {
+	fn func(service.MessageBatch) error
+}
+
+func (j *jetStreamTestBatchOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (j *jetStreamTestBatchOutput) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	return j.fn(b)
+}
+
+func (j *jetStreamTestBatchOutput) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestJetStreamInputBatchErrorNacksFailed(t *testing.T) {
+	s := runJetStreamServer(t)
+
+	nc, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:     "foo",
+		Subjects: []string{"foo.>"},
+	})
+	require.NoError(t, err)
+
+	for _, content := range []string{"first", "second", "third"} {
+		_, err = js.Publish("foo.bar", []byte(content))
+		require.NoError(t, err)
+	}
+
+	var receivedMut sync.Mutex
+	var received []string
+	failed := map[string]bool{}
+
+	env := service.NewEnvironment()
+	require.NoError(t, env.RegisterBatchOutput("jetstream_test_output", service.NewConfigSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchOutput, service.BatchPolicy, int, error) {
+			return &jetStreamTestBatchOutput{fn: func(b service.MessageBatch) error {
+				receivedMut.Lock()
+				defer receivedMut.Unlock()
+
+				// Fail the second message the first time it's seen only.
+				berr := service.NewBatchError(b, errors.New("failed to write"))
+				for i, m := range b {
+					content, _ := m.AsBytes()
+					received = append(received, string(content))
+					if string(content) == "second" && !failed[string(content)] {
+						failed[string(content)] = true
+						berr.Failed(i, errors.New("second is not allowed"))
+					}
+				}
+				if berr.IndexedErrors() > 0 {
+					return berr
+				}
+				return nil
+			}}, service.BatchPolicy{}, 1, nil
+		}))
+
+	builder := env.NewStreamBuilder()
+	require.NoError(t, builder.SetLoggerYAML(`level: none`))
+	require.NoError(t, builder.AddInputYAML(fmt.Sprintf(`
+broker:
+  inputs:
+    - nats_jetstream:
+        urls: [ %v ]
+        subject: foo.bar
+        durable: benthos
+  batching:
+    count: 3
+    period: 100ms
+`, s.ClientURL())))
+	require.NoError(t, builder.AddOutputYAML(`jetstream_test_output: {}`))
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	go func() {
+		_ = strm.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		info, err := js.ConsumerInfo("foo", "benthos")
+		if err != nil {
+			return false
+		}
+		return info.AckFloor.Stream == 3 && info.NumAckPending == 0
+	}, time.Second*10, time.Millisecond*50)
+	require.NoError(t, strm.StopWithin(time.Second*10))
+
+	receivedMut.Lock()
+	assert.Equal(t, []string{"first", "second", "third", "second"}, received)
+	receivedMut.Unlock()
+}
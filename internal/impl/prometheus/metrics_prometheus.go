@@ -49,7 +49,7 @@ If the Push Gateway requires HTTP Basic Authentication it can be configured with
 			docs.FieldString("push_job_name", "An identifier for push jobs.").Advanced().HasDefault("benthos_push"),
 			docs.FieldObject("push_basic_auth", "The Basic Authentication credentials.").WithChildren(
 				docs.FieldString("username", "The Basic Authentication username.").HasDefault(""),
-				docs.FieldString("password", "The Basic Authentication password.").HasDefault(""),
+				docs.FieldString("password", "The Basic Authentication password.").Secret().HasDefault(""),
 			).Advanced(),
 			docs.FieldString("file_output_path", "An optional file path to write all prometheus metrics on service shutdown.").Advanced().HasDefault(""),
 		),
@@ -131,6 +131,25 @@ func (p *promTimingHistVec) With(labelValues ...string) metrics.StatTimer {
 	}
 }
 
+type promHistogram struct {
+	hist prometheus.Observer
+}
+
+func (p *promHistogram) Observe(val int64) {
+	p.hist.Observe(float64(val))
+}
+
+type promHistogramVec struct {
+	hist  *prometheus.HistogramVec
+	count int
+}
+
+func (p *promHistogramVec) With(labelValues ...string) metrics.StatHistogram {
+	return &promHistogram{
+		hist: p.hist.WithLabelValues(labelValues...),
+	}
+}
+
 type promGaugeVec struct {
 	ctr   *prometheus.GaugeVec
 	count int
@@ -161,6 +180,7 @@ type prometheusMetrics struct {
 	gauges     map[string]*promGaugeVec
 	timers     map[string]*promTimingVec
 	timersHist map[string]*promTimingHistVec
+	histograms map[string]*promHistogramVec
 
 	mut sync.Mutex
 }
@@ -178,6 +198,7 @@ func newPrometheus(config metrics.Config, log log.Modular) (metrics.Type, error)
 		gauges:             map[string]*promGaugeVec{},
 		timers:             map[string]*promTimingVec{},
 		timersHist:         map[string]*promTimingHistVec{},
+		histograms:         map[string]*promHistogramVec{},
 	}
 
 	if len(p.histogramBuckets) == 0 {
@@ -346,6 +367,49 @@ func (p *prometheusMetrics) getTimerHistVec(path string, labelNames ...string) m
 	return pv
 }
 
+func (p *prometheusMetrics) GetHistogram(path string) metrics.StatHistogram {
+	return p.GetHistogramVec(path).With()
+}
+
+// Histogram values are unitless, such as batch sizes, and therefore the
+// configured histogram_buckets, which are in seconds, do not apply.
+var histogramBuckets = prometheus.ExponentialBuckets(1, 2, 16)
+
+func (p *prometheusMetrics) GetHistogramVec(path string, labelNames ...string) metrics.StatHistogramVec {
+	if !model.IsValidMetricName(model.LabelValue(path)) {
+		p.log.Errorf("Ignoring metric '%v' due to invalid name", path)
+		return metrics.FakeHistogramVec(func(l ...string) metrics.StatHistogram {
+			return &metrics.DudStat{}
+		})
+	}
+
+	var pv *promHistogramVec
+
+	p.mut.Lock()
+	var exists bool
+	if pv, exists = p.histograms[path]; !exists {
+		hist := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    path,
+			Help:    "Benthos Histogram metric",
+			Buckets: histogramBuckets,
+		}, labelNames)
+		p.reg.MustRegister(hist)
+
+		pv = &promHistogramVec{
+			hist:  hist,
+			count: len(labelNames),
+		}
+		p.histograms[path] = pv
+	}
+	p.mut.Unlock()
+
+	if pv.count != len(labelNames) {
+		p.log.Errorf("Metrics label mismatch %v versus %v %v for name '%v', skipping metric", pv.count, len(labelNames), labelNames, path)
+		return metrics.Noop().GetHistogramVec(path, labelNames...)
+	}
+	return pv
+}
+
 func (p *prometheusMetrics) GetGauge(path string) metrics.StatGauge {
 	return p.GetGaugeVec(path).With()
 }
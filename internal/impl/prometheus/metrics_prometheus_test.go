@@ -167,6 +167,28 @@ func TestPrometheusHistMetrics(t *testing.T) {
 	assert.Contains(t, body, "\ntimertwo_sum{label3=\"value4\",label4=\"value5\"} 1.4e-08")
 }
 
+func TestPrometheusHistogramMetrics(t *testing.T) {
+	conf := metrics.NewConfig()
+	conf.Prometheus.UseHistogramTiming = true
+
+	nm, err := newPrometheus(conf, log.Noop())
+	require.NoError(t, err)
+
+	nm.GetHistogram("histone").Observe(3)
+	histTwo := nm.GetHistogramVec("histtwo", "label1")
+	histTwo.With("value1").Observe(20)
+	histTwo.With("value1").Observe(40)
+
+	body := getPage(t, nm.HandlerFunc())
+
+	assert.Contains(t, body, "\nhistone_sum 3")
+	assert.Contains(t, body, "\nhistone_bucket{le=\"2\"} 0")
+	assert.Contains(t, body, "\nhistone_bucket{le=\"4\"} 1")
+	assert.Contains(t, body, "\nhisttwo_sum{label1=\"value1\"} 60")
+	assert.Contains(t, body, "\nhisttwo_bucket{label1=\"value1\",le=\"32\"} 1")
+	assert.Contains(t, body, "\nhisttwo_bucket{label1=\"value1\",le=\"64\"} 2")
+}
+
 func TestPrometheusWithFileOutputPath(t *testing.T) {
 	config := metrics.NewConfig()
 	config.Prometheus.FileOutputPath = os.TempDir() + "/benthos_metrics.prom"
@@ -28,6 +28,7 @@ func authField() *service.ConfigField {
 				Description("Whether Token Auth is enabled.").
 				Default(false),
 			service.NewStringField("token").
+				Secret().
 				Description("Actual base64 encoded token.").
 				Default(""),
 		).Description("Parameters for Pulsar Token authentication.").
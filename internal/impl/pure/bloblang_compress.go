@@ -0,0 +1,83 @@
+package pure
+
+import (
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func init() {
+	// Note: The examples are run and tested from within
+	// ./internal/bloblang/query/parsed_test.go
+
+	compressSpec := bloblang.NewPluginSpec().
+		Category("Encoding and Encryption").
+		Description("Compresses a string or byte array value according to a specified algorithm and returns the result as a byte array. When mapping the result to a JSON field the value should be encoded using the method [`encode`](#encode), otherwise it will be base64 encoded by default.").
+		Version("4.2.0").
+		Param(bloblang.NewStringParam("algorithm").Description("One of `gzip`, `zlib`, `flate`, `snappy`, `lz4`, `zstd`.")).
+		Param(bloblang.NewInt64Param("level").Description("The level of compression to use. May not be applicable to all algorithms.").Default(-1)).
+		Example("",
+			`root.compressed = this.payload.compress("snappy").encode("base64")`,
+			[2]string{
+				`{"payload":"hello world hello world hello world"}`,
+				`{"compressed":"IyxoZWxsbyB3b3JsZCBaDAA="}`,
+			})
+
+	if err := bloblang.RegisterMethodV2(
+		"compress", compressSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			algStr, err := args.GetString("algorithm")
+			if err != nil {
+				return nil, err
+			}
+			level, err := args.GetInt64("level")
+			if err != nil {
+				return nil, err
+			}
+			comp, err := strToCompressor(algStr)
+			if err != nil {
+				return nil, err
+			}
+			return bloblang.BytesMethod(func(b []byte) (interface{}, error) {
+				return comp(int(level), b)
+			}), nil
+		},
+	); err != nil {
+		panic(err)
+	}
+
+	decompressSpec := bloblang.NewPluginSpec().
+		Category("Encoding and Encryption").
+		Description("Decompresses a string or byte array value according to a specified algorithm and returns the result as a byte array. The result should be converted with the method [`string`](#string) or parsed before it is mapped to a JSON field, otherwise it will be base64 encoded by default.").
+		Version("4.2.0").
+		Param(bloblang.NewStringParam("algorithm").Description("One of `gzip`, `zlib`, `bzip2`, `flate`, `snappy`, `lz4`, `zstd`.")).
+		Example("",
+			`root = this.payload.decode("base64").decompress("gzip").parse_json()`,
+			[2]string{
+				`{"payload":"H4sIAAAAAAAA/wANAPL/eyJmb28iOiJiYXIifQMA7/Ur/g0AAAA="}`,
+				`{"foo":"bar"}`,
+			}).
+		Example("",
+			`root.payload = this.payload.decode("base64").decompress("snappy").string()`,
+			[2]string{
+				`{"payload":"IyxoZWxsbyB3b3JsZCBaDAA="}`,
+				`{"payload":"hello world hello world hello world"}`,
+			})
+
+	if err := bloblang.RegisterMethodV2(
+		"decompress", decompressSpec,
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			algStr, err := args.GetString("algorithm")
+			if err != nil {
+				return nil, err
+			}
+			decomp, err := strToDecompressor(algStr)
+			if err != nil {
+				return nil, err
+			}
+			return bloblang.BytesMethod(func(b []byte) (interface{}, error) {
+				return decomp(b)
+			}), nil
+		},
+	); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,57 @@
+package pure_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestBloblangCompressRoundTrip(t *testing.T) {
+	input := "hello world, hello world, hello world, hello world"
+
+	for _, algo := range []string{"gzip", "zlib", "flate", "snappy", "lz4", "zstd"} {
+		algo := algo
+		t.Run(algo, func(t *testing.T) {
+			exec, err := bloblang.Parse(`
+root.compressed = this.value.compress("` + algo + `")
+root.decompressed = root.compressed.decompress("` + algo + `").string()
+`)
+			require.NoError(t, err)
+
+			res, err := exec.Query(map[string]interface{}{"value": input})
+			require.NoError(t, err)
+
+			resObj := res.(map[string]interface{})
+			assert.Equal(t, input, resObj["decompressed"])
+			assert.NotEqual(t, []byte(input), resObj["compressed"])
+		})
+	}
+}
+
+func TestBloblangCompressLevel(t *testing.T) {
+	exec, err := bloblang.Parse(`root = this.value.compress(algorithm: "zstd", level: 19).decompress("zstd").string()`)
+	require.NoError(t, err)
+
+	res, err := exec.Query(map[string]interface{}{"value": "hello world"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", res)
+}
+
+func TestBloblangCompressErrors(t *testing.T) {
+	_, err := bloblang.Parse(`root = this.value.compress("nope")`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "compression type not recognised: nope")
+
+	_, err = bloblang.Parse(`root = this.value.decompress("nope")`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "decompression type not recognised: nope")
+
+	exec, err := bloblang.Parse(`root = this.value.decompress("gzip")`)
+	require.NoError(t, err)
+
+	_, err = exec.Query(map[string]interface{}{"value": "not gzipped"})
+	require.Error(t, err)
+}
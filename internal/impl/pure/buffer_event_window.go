@@ -0,0 +1,496 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func eventWindowBufferConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.2.0").
+		Categories("Windowing").
+		Summary("Groups messages by a key into tumbling, sliding or session windows following the event time of the messages, where each window is flushed as a batch once a watermark passes its end.").
+		Description(`
+A window is a grouping of messages that share a key and fit within a span of time, where the time of each message is its event time as provided by the `+"[`timestamp_mapping` field](#timestamp_mapping)"+`, and the key is provided by the `+"[`key_mapping` field](#key_mapping)"+`. Each window is flushed as a single batch, and therefore messages of a window can be aggregated with a `+"[`bloblang` processor](/docs/components/processors/bloblang)"+` that reduces the batch.
+
+## Window Types
+
+In `+"`tumbling`"+` mode windows of a fixed size immediately follow one another and therefore each message belongs to exactly one window. Windows are aligned to the unix epoch, meaning windows of 1 hour duration will match the turn of each hour in the day.
+
+In `+"`sliding`"+` mode windows of a fixed size begin from an offset of the prior window's beginning rather than its end, and therefore messages may belong to multiple windows. The offset is specified with the `+"[`slide` field](#slide)"+`.
+
+In `+"`session`"+` mode a window is extended by each message that arrives within a `+"[`gap`](#gap)"+` of the earliest or latest message of the window, and ends once no message has been seen for that length of time. Sessions that become connected by a message are merged.
+
+## Watermarks
+
+Rather than following the system clock, windows are flushed according to a watermark, which is the latest event time seen so far minus the `+"[`allowed_lateness`](#allowed_lateness)"+`. A window is flushed once the watermark passes its end, which for session windows is the time of the latest message plus the gap.
+
+Messages that arrive after the windows they belong to have been flushed are dropped. The watermark only advances as messages are consumed, and therefore when the stream of messages pauses the windows that remain open are not flushed until more messages arrive.
+
+When a window is flushed each message has the metadata fields `+"`window_start_timestamp`"+` and `+"`window_end_timestamp`"+` added to it containing the bounds of the window as RFC3339 strings, and `+"`window_key`"+` containing the key of the window. The messages of a window are ordered by their event time.
+
+## Delivery Guarantees
+
+This buffer honours the transaction model within Benthos in order to ensure that messages are not acknowledged until they are either intentionally dropped or successfully delivered to outputs. However, since late messages are intentionally dropped there are circumstances where not all messages entering the system will be delivered.
+
+When this buffer is configured with sliding windows it is possible for messages to belong to multiple windows, and therefore be delivered multiple times. In this case the first time the message is delivered it will be acked (or nacked) and subsequent deliveries of the same message will be a "best attempt".
+
+During graceful termination messages of windows that have not yet been flushed are nacked such that they are re-consumed the next time the service starts.
+`).
+		Field(service.NewBloblangField("timestamp_mapping").
+			Description(`
+A [Bloblang mapping](/docs/guides/bloblang/about) applied to each message during ingestion that provides the event time of the message. The timestamp value assigned to `+"`root`"+` must either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format. If the mapping fails or provides an invalid result the batch of messages is rejected.
+`).
+			Example("root = this.created_at").Example(`root = meta("kafka_timestamp_unix").number()`)).
+		Field(service.NewBloblangField("key_mapping").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) applied to each message during ingestion that provides a key, where messages are only grouped into windows with other messages of the same key. The result is converted into a string. By default all messages share the same key.").
+			Default(`root = ""`).
+			Example("root = this.user_id")).
+		Field(service.NewStringAnnotatedEnumField("type", map[string]string{
+			"tumbling": "Windows of a fixed size that follow one another.",
+			"sliding":  "Windows of a fixed size that begin at a fixed interval from the beginning of the prior window.",
+			"session":  "Windows that extend as long as messages continue to arrive within a gap of one another.",
+		}).
+			Description("The type of windows to create.").
+			Default("tumbling")).
+		Field(service.NewStringField("size").
+			Description("A duration string describing the size of each window, which is required for tumbling and sliding windows.").
+			Default("").
+			Example("30s").Example("10m")).
+		Field(service.NewStringField("slide").
+			Description("A duration string describing by how much time the beginning of each sliding window is offset from the beginning of the previous, which is required for sliding windows and must be smaller than the `size` of the window.").
+			Default("").
+			Example("30s").Example("10m")).
+		Field(service.NewStringField("gap").
+			Description("A duration string describing the length of time without messages after which a session window ends, which is required for session windows.").
+			Default("").
+			Example("30s").Example("10m")).
+		Field(service.NewStringField("allowed_lateness").
+			Description("An optional duration string describing how far behind the latest event time seen so far the watermark is, allowing messages that arrive out of order to be included in their windows.").
+			Default("").
+			Example("10s").Example("1m")).
+		Example("User Sessions", `Given a stream of click events of the form:
+
+`+"```json"+`
+{
+  "user_id": "ash",
+  "page": "/basket",
+  "created_at": "2021-08-07T09:49:35Z"
+}
+`+"```"+`
+
+We can use an event window buffer in order to summarise the pages visited by each user within a session, where a session ends once the user hasn't clicked for ten minutes:
+
+`+"```json"+`
+{
+  "user_id": "ash",
+  "started_at": "2021-08-07T09:49:35Z",
+  "ended_at": "2021-08-07T10:04:12Z",
+  "pages": [ "/", "/shoes", "/basket" ]
+}
+`+"```"+`
+
+With the following config:`,
+			`
+buffer:
+  event_window:
+    timestamp_mapping: root = this.created_at
+    key_mapping: root = this.user_id
+    type: session
+    gap: 10m
+    allowed_lateness: 1m
+
+pipeline:
+  processors:
+    # Reduce each window to a single message by deleting indexes > 0, and
+    # aggregate the pages visited.
+    - bloblang: |
+        root = if batch_index() == 0 {
+          {
+            "user_id": meta("window_key"),
+            "started_at": meta("window_start_timestamp"),
+            "ended_at": json("created_at").from(batch_size() - 1),
+            "pages": json("page").from_all(),
+          }
+        } else { deleted() }
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchBuffer(
+		"event_window", eventWindowBufferConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchBuffer, error) {
+			return eventWindowBufferFromConfig(conf, mgr.Logger())
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func eventWindowBufferFromConfig(conf *service.ParsedConfig, logger *service.Logger) (*eventWindowBuffer, error) {
+	tsMapping, err := conf.FieldBloblang("timestamp_mapping")
+	if err != nil {
+		return nil, err
+	}
+	keyMapping, err := conf.FieldBloblang("key_mapping")
+	if err != nil {
+		return nil, err
+	}
+	mode, err := conf.FieldString("type")
+	if err != nil {
+		return nil, err
+	}
+
+	var size, slide, gap time.Duration
+	switch mode {
+	case "tumbling", "sliding":
+		if size, err = getDuration(conf, true, "size"); err != nil {
+			return nil, err
+		}
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid window size '%v' must be greater than zero", size)
+		}
+		if mode == "sliding" {
+			if slide, err = getDuration(conf, true, "slide"); err != nil {
+				return nil, err
+			}
+			if slide <= 0 || slide >= size {
+				return nil, fmt.Errorf("invalid window slide '%v' must be greater than zero and lower than the size '%v'", slide, size)
+			}
+		}
+	case "session":
+		if gap, err = getDuration(conf, true, "gap"); err != nil {
+			return nil, err
+		}
+		if gap <= 0 {
+			return nil, fmt.Errorf("invalid session gap '%v' must be greater than zero", gap)
+		}
+	default:
+		return nil, fmt.Errorf("unrecognised window type: %v", mode)
+	}
+
+	allowedLateness, err := getDuration(conf, false, "allowed_lateness")
+	if err != nil {
+		return nil, err
+	}
+	if allowedLateness < 0 {
+		return nil, fmt.Errorf("invalid allowed_lateness '%v' must not be negative", allowedLateness)
+	}
+	return newEventWindowBuffer(tsMapping, keyMapping, mode, size, slide, gap, allowedLateness, logger), nil
+}
+
+//------------------------------------------------------------------------------
+
+type eventWindow struct {
+	key        string
+	start, end time.Time
+	msgs       []*tsMessage
+}
+
+type eventWindowBuffer struct {
+	logger *service.Logger
+
+	tsMapping, keyMapping             *bloblang.Executor
+	mode                              string
+	size, slide, gap, allowedLateness time.Duration
+
+	mut      sync.Mutex
+	latestTS time.Time
+	open     map[string][]*eventWindow
+	ready    []*eventWindow
+
+	readyChan           chan struct{}
+	endOfInputChan      chan struct{}
+	closeEndOfInputOnce sync.Once
+}
+
+func newEventWindowBuffer(
+	tsMapping, keyMapping *bloblang.Executor,
+	mode string,
+	size, slide, gap, allowedLateness time.Duration,
+	logger *service.Logger,
+) *eventWindowBuffer {
+	return &eventWindowBuffer{
+		logger:          logger,
+		tsMapping:       tsMapping,
+		keyMapping:      keyMapping,
+		mode:            mode,
+		size:            size,
+		slide:           slide,
+		gap:             gap,
+		allowedLateness: allowedLateness,
+		open:            map[string][]*eventWindow{},
+		readyChan:       make(chan struct{}, 1),
+		endOfInputChan:  make(chan struct{}),
+	}
+}
+
+// watermark returns the time before which all windows are considered
+// complete.
+func (w *eventWindowBuffer) watermark() time.Time {
+	return w.latestTS.Add(-w.allowedLateness)
+}
+
+// floorTime returns the start of the epoch aligned period that contains t.
+func floorTime(t time.Time, period time.Duration) time.Time {
+	n := t.UnixNano()
+	rem := n % int64(period)
+	if rem < 0 {
+		rem += int64(period)
+	}
+	return time.Unix(0, n-rem).UTC()
+}
+
+// addFixed adds a message to each open tumbling or sliding window that it
+// belongs to, and returns false if all of those windows have already been
+// flushed.
+func (w *eventWindowBuffer) addFixed(key string, msg *tsMessage) bool {
+	epoch := w.size
+	if w.slide > 0 {
+		epoch = w.slide
+	}
+
+	added := false
+	for start := floorTime(msg.ts, epoch); start.Add(w.size).After(msg.ts); start = start.Add(-epoch) {
+		end := start.Add(w.size)
+		if !end.After(w.watermark()) {
+			continue
+		}
+		added = true
+
+		var win *eventWindow
+		for _, existing := range w.open[key] {
+			if existing.start.Equal(start) {
+				win = existing
+				break
+			}
+		}
+		if win == nil {
+			win = &eventWindow{key: key, start: start, end: end}
+			w.open[key] = append(w.open[key], win)
+		}
+		win.msgs = append(win.msgs, msg)
+	}
+	return added
+}
+
+// addSession adds a message to the open session window that it belongs to,
+// merging any sessions that the message connects, and returns false if the
+// session of the message has already been flushed.
+func (w *eventWindowBuffer) addSession(key string, msg *tsMessage) bool {
+	var win *eventWindow
+	remaining := w.open[key][:0]
+	for _, existing := range w.open[key] {
+		// The end of a session is the time of its latest message plus the gap.
+		if msg.ts.Before(existing.start.Add(-w.gap)) || !msg.ts.Before(existing.end) {
+			remaining = append(remaining, existing)
+			continue
+		}
+		if win == nil {
+			win = existing
+			remaining = append(remaining, existing)
+			continue
+		}
+		win.msgs = append(win.msgs, existing.msgs...)
+		if existing.start.Before(win.start) {
+			win.start = existing.start
+		}
+		if existing.end.After(win.end) {
+			win.end = existing.end
+		}
+	}
+	w.open[key] = remaining
+
+	if win == nil {
+		if !msg.ts.Add(w.gap).After(w.watermark()) {
+			return false
+		}
+		win = &eventWindow{key: key, start: msg.ts, end: msg.ts.Add(w.gap)}
+		w.open[key] = append(w.open[key], win)
+	}
+	if msg.ts.Before(win.start) {
+		win.start = msg.ts
+	}
+	if end := msg.ts.Add(w.gap); end.After(win.end) {
+		win.end = end
+	}
+	win.msgs = append(win.msgs, msg)
+	return true
+}
+
+// closeWindows moves all open windows that end before the watermark to the
+// ready queue.
+func (w *eventWindowBuffer) closeWindows() {
+	watermark := w.watermark()
+
+	var closed []*eventWindow
+	for key, wins := range w.open {
+		remaining := wins[:0]
+		for _, win := range wins {
+			if !win.end.After(watermark) {
+				closed = append(closed, win)
+			} else {
+				remaining = append(remaining, win)
+			}
+		}
+		if len(remaining) == 0 {
+			delete(w.open, key)
+		} else {
+			w.open[key] = remaining
+		}
+	}
+	if len(closed) == 0 {
+		return
+	}
+
+	sort.Slice(closed, func(i, j int) bool {
+		if !closed[i].end.Equal(closed[j].end) {
+			return closed[i].end.Before(closed[j].end)
+		}
+		if closed[i].key != closed[j].key {
+			return closed[i].key < closed[j].key
+		}
+		return closed[i].start.Before(closed[j].start)
+	})
+	w.ready = append(w.ready, closed...)
+
+	select {
+	case w.readyChan <- struct{}{}:
+	default:
+	}
+}
+
+func (w *eventWindowBuffer) WriteBatch(ctx context.Context, msgBatch service.MessageBatch, aFn service.AckFunc) error {
+	if len(msgBatch) == 0 {
+		return aFn(ctx, nil)
+	}
+
+	timestamps := make([]time.Time, len(msgBatch))
+	keys := make([]string, len(msgBatch))
+	for i := range msgBatch {
+		var err error
+		if timestamps[i], err = windowTimestamp(w.logger, w.tsMapping, i, msgBatch); err != nil {
+			return err
+		}
+		if keys[i], err = batchQueryString(msgBatch, i, w.keyMapping); err != nil {
+			w.logger.Errorf("Key mapping failed for message: %v", err)
+			return fmt.Errorf("key mapping failed: %w", err)
+		}
+	}
+
+	// All ack funcs must be derived before any of them are called.
+	aggregatedAck := batch.NewCombinedAcker(batch.AckFunc(aFn))
+	ackFns := make([]service.AckFunc, len(msgBatch))
+	for i := range ackFns {
+		ackFns[i] = service.AckFunc(aggregatedAck.Derive())
+	}
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	for i, msg := range msgBatch {
+		tsMsg := &tsMessage{ts: timestamps[i], m: msg, ackFn: ackFns[i]}
+
+		var added bool
+		if w.mode == "session" {
+			added = w.addSession(keys[i], tsMsg)
+		} else {
+			added = w.addFixed(keys[i], tsMsg)
+		}
+		if !added {
+			// Reject messages that are too late to fit into a window by
+			// acknowledging them.
+			_ = tsMsg.ackFn(ctx, nil)
+		}
+	}
+
+	for _, ts := range timestamps {
+		if ts.After(w.latestTS) {
+			w.latestTS = ts
+		}
+	}
+	w.closeWindows()
+	return nil
+}
+
+func (w *eventWindowBuffer) flushWindow(win *eventWindow) (service.MessageBatch, service.AckFunc) {
+	sort.SliceStable(win.msgs, func(i, j int) bool {
+		return win.msgs[i].ts.Before(win.msgs[j].ts)
+	})
+
+	flushBatch := make(service.MessageBatch, 0, len(win.msgs))
+	for _, pending := range win.msgs {
+		tmpMsg := pending.m.Copy()
+		tmpMsg.MetaSet("window_start_timestamp", win.start.UTC().Format(time.RFC3339Nano))
+		tmpMsg.MetaSet("window_end_timestamp", win.end.UTC().Format(time.RFC3339Nano))
+		tmpMsg.MetaSet("window_key", win.key)
+		flushBatch = append(flushBatch, tmpMsg)
+	}
+	return flushBatch, func(ctx context.Context, err error) error {
+		for _, pending := range win.msgs {
+			_ = pending.ackFn(ctx, err)
+		}
+		return nil
+	}
+}
+
+var errEventWindowClosed = errors.New("message rejected as window was not flushed")
+
+func (w *eventWindowBuffer) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for {
+		w.mut.Lock()
+		if len(w.ready) > 0 {
+			win := w.ready[0]
+			w.ready = w.ready[1:]
+			w.mut.Unlock()
+
+			msgBatch, aFn := w.flushWindow(win)
+			return msgBatch, aFn, nil
+		}
+		w.mut.Unlock()
+
+		select {
+		case <-w.readyChan:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-w.endOfInputChan:
+			w.mut.Lock()
+			if len(w.ready) > 0 {
+				w.mut.Unlock()
+				continue
+			}
+			// Nack all messages of open windows so that we re-consume them on
+			// the next start up.
+			for _, wins := range w.open {
+				for _, win := range wins {
+					for _, pending := range win.msgs {
+						_ = pending.ackFn(ctx, errEventWindowClosed)
+					}
+				}
+			}
+			w.open = map[string][]*eventWindow{}
+			w.mut.Unlock()
+			return nil, nil, service.ErrEndOfBuffer
+		}
+	}
+}
+
+func (w *eventWindowBuffer) EndOfInput() {
+	w.closeEndOfInputOnce.Do(func() {
+		close(w.endOfInputChan)
+	})
+}
+
+func (w *eventWindowBuffer) Close(ctx context.Context) error {
+	return nil
+}
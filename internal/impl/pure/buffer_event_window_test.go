@@ -0,0 +1,321 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestEventWindowBufferConfigs(t *testing.T) {
+	tests := []struct {
+		config           string
+		lintErrContains  string
+		buildErrContains string
+	}{
+		{
+			config: `
+event_window:
+  timestamp_mapping: root = this.ts
+  size: 60m
+`,
+		},
+		{
+			config: `
+event_window:
+  size: 60m
+`,
+			lintErrContains: "field timestamp_mapping is required",
+		},
+		{
+			config: `
+event_window:
+  timestamp_mapping: root = this.ts
+`,
+			buildErrContains: "failed to parse field 'size' as duration",
+		},
+		{
+			config: `
+event_window:
+  timestamp_mapping: root = this.ts
+  type: sliding
+  size: 60m
+  slide: 60m
+`,
+			buildErrContains: "invalid window slide",
+		},
+		{
+			config: `
+event_window:
+  timestamp_mapping: root = this.ts
+  key_mapping: root = this.user
+  type: session
+  gap: 5m
+  allowed_lateness: 1m
+`,
+		},
+		{
+			config: `
+event_window:
+  timestamp_mapping: root = this.ts
+  type: session
+  gap: -5m
+`,
+			buildErrContains: "invalid session gap",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			env := service.NewStreamBuilder()
+			require.NoError(t, env.SetLoggerYAML(`level: OFF`))
+			err := env.AddConsumerFunc(func(context.Context, *service.Message) error {
+				return nil
+			})
+			require.NoError(t, err)
+			_, err = env.AddProducerFunc()
+			require.NoError(t, err)
+
+			err = env.SetBufferYAML(test.config)
+			if test.lintErrContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.lintErrContains)
+				return
+			}
+			require.NoError(t, err)
+
+			strm, err := env.Build()
+			require.NoError(t, err)
+
+			cancelledCtx, done := context.WithCancel(context.Background())
+			done()
+			err = strm.Run(cancelledCtx)
+			if test.buildErrContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.buildErrContains)
+				return
+			}
+			require.EqualError(t, err, "context canceled")
+			require.NoError(t, strm.StopWithin(time.Second))
+		})
+	}
+}
+
+func newTestEventWindowBuffer(t *testing.T, mode string, size, slide, gap, allowedLateness time.Duration) *eventWindowBuffer {
+	t.Helper()
+
+	tsMapping, err := bloblang.Parse(`root = this.ts`)
+	require.NoError(t, err)
+	keyMapping, err := bloblang.Parse(`root = this.key`)
+	require.NoError(t, err)
+	return newEventWindowBuffer(tsMapping, keyMapping, mode, size, slide, gap, allowedLateness, nil)
+}
+
+type eventWindowResult struct {
+	start, end, key string
+	ids             []string
+}
+
+func readEventWindow(t *testing.T, w *eventWindowBuffer) eventWindowResult {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	msgBatch, aFn, err := w.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, aFn(ctx, nil))
+
+	var res eventWindowResult
+	for i, msg := range msgBatch {
+		v, err := msg.AsStructured()
+		require.NoError(t, err)
+		res.ids = append(res.ids, v.(map[string]interface{})["id"].(string))
+
+		start, _ := msg.MetaGet("window_start_timestamp")
+		end, _ := msg.MetaGet("window_end_timestamp")
+		key, _ := msg.MetaGet("window_key")
+		if i == 0 {
+			res.start, res.end, res.key = start, end, key
+		} else {
+			assert.Equal(t, res.start, start)
+			assert.Equal(t, res.end, end)
+			assert.Equal(t, res.key, key)
+		}
+	}
+	return res
+}
+
+func assertNoEventWindow(t *testing.T, w *eventWindowBuffer) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer done()
+
+	_, _, err := w.ReadBatch(ctx)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func writeEventWindow(t *testing.T, w *eventWindowBuffer, msgs ...string) {
+	t.Helper()
+
+	var msgBatch service.MessageBatch
+	for _, m := range msgs {
+		msgBatch = append(msgBatch, service.NewMessage([]byte(m)))
+	}
+	require.NoError(t, w.WriteBatch(context.Background(), msgBatch, noopAck))
+}
+
+func TestEventWindowTumbling(t *testing.T) {
+	w := newTestEventWindowBuffer(t, "tumbling", time.Second, 0, 0, 0)
+
+	writeEventWindow(t, w,
+		`{"id":"1","key":"a","ts":10.1}`,
+		`{"id":"2","key":"b","ts":10.2}`,
+		`{"id":"3","key":"a","ts":10.9}`,
+		`{"id":"4","key":"a","ts":11.5}`,
+	)
+	assert.Equal(t, eventWindowResult{
+		start: "1970-01-01T00:00:10Z", end: "1970-01-01T00:00:11Z", key: "a",
+		ids: []string{"1", "3"},
+	}, readEventWindow(t, w))
+	assert.Equal(t, eventWindowResult{
+		start: "1970-01-01T00:00:10Z", end: "1970-01-01T00:00:11Z", key: "b",
+		ids: []string{"2"},
+	}, readEventWindow(t, w))
+	assertNoEventWindow(t, w)
+
+	// Late messages are dropped.
+	writeEventWindow(t, w,
+		`{"id":"5","key":"a","ts":10.5}`,
+		`{"id":"6","key":"a","ts":11.7}`,
+		`{"id":"7","key":"a","ts":13.1}`,
+	)
+	assert.Equal(t, eventWindowResult{
+		start: "1970-01-01T00:00:11Z", end: "1970-01-01T00:00:12Z", key: "a",
+		ids: []string{"4", "6"},
+	}, readEventWindow(t, w))
+	assertNoEventWindow(t, w)
+}
+
+func TestEventWindowAllowedLateness(t *testing.T) {
+	w := newTestEventWindowBuffer(t, "tumbling", time.Second, 0, 0, time.Second)
+
+	writeEventWindow(t, w,
+		`{"id":"1","key":"a","ts":10.1}`,
+		`{"id":"2","key":"a","ts":11.5}`,
+	)
+	assertNoEventWindow(t, w)
+
+	writeEventWindow(t, w,
+		`{"id":"3","key":"a","ts":10.5}`,
+		`{"id":"4","key":"a","ts":12.0}`,
+	)
+	assert.Equal(t, eventWindowResult{
+		start: "1970-01-01T00:00:10Z", end: "1970-01-01T00:00:11Z", key: "a",
+		ids: []string{"1", "3"},
+	}, readEventWindow(t, w))
+	assertNoEventWindow(t, w)
+}
+
+func TestEventWindowSliding(t *testing.T) {
+	w := newTestEventWindowBuffer(t, "sliding", time.Second*2, time.Second, 0, 0)
+
+	writeEventWindow(t, w,
+		`{"id":"1","key":"a","ts":10.5}`,
+		`{"id":"2","key":"a","ts":11.5}`,
+		`{"id":"3","key":"a","ts":12.5}`,
+		`{"id":"4","key":"a","ts":13.5}`,
+	)
+	assert.Equal(t, eventWindowResult{
+		start: "1970-01-01T00:00:09Z", end: "1970-01-01T00:00:11Z", key: "a",
+		ids: []string{"1"},
+	}, readEventWindow(t, w))
+	assert.Equal(t, eventWindowResult{
+		start: "1970-01-01T00:00:10Z", end: "1970-01-01T00:00:12Z", key: "a",
+		ids: []string{"1", "2"},
+	}, readEventWindow(t, w))
+	assert.Equal(t, eventWindowResult{
+		start: "1970-01-01T00:00:11Z", end: "1970-01-01T00:00:13Z", key: "a",
+		ids: []string{"2", "3"},
+	}, readEventWindow(t, w))
+	assertNoEventWindow(t, w)
+}
+
+func TestEventWindowSession(t *testing.T) {
+	w := newTestEventWindowBuffer(t, "session", 0, 0, time.Second*5, 0)
+
+	writeEventWindow(t, w,
+		`{"id":"1","key":"a","ts":10}`,
+		`{"id":"2","key":"a","ts":22}`,
+		`{"id":"3","key":"b","ts":12}`,
+		`{"id":"4","key":"a","ts":13}`,
+	)
+	assert.Equal(t, eventWindowResult{
+		start: "1970-01-01T00:00:12Z", end: "1970-01-01T00:00:17Z", key: "b",
+		ids: []string{"3"},
+	}, readEventWindow(t, w))
+	assert.Equal(t, eventWindowResult{
+		start: "1970-01-01T00:00:10Z", end: "1970-01-01T00:00:18Z", key: "a",
+		ids: []string{"1", "4"},
+	}, readEventWindow(t, w))
+	assertNoEventWindow(t, w)
+
+	// A message that connects two sessions merges them, and a message for a
+	// session that has been flushed is dropped.
+	writeEventWindow(t, w,
+		`{"id":"5","key":"a","ts":30}`,
+		`{"id":"6","key":"a","ts":26}`,
+		`{"id":"7","key":"a","ts":14}`,
+	)
+	assertNoEventWindow(t, w)
+
+	writeEventWindow(t, w, `{"id":"8","key":"a","ts":40}`)
+	assert.Equal(t, eventWindowResult{
+		start: "1970-01-01T00:00:22Z", end: "1970-01-01T00:00:35Z", key: "a",
+		ids: []string{"2", "6", "5"},
+	}, readEventWindow(t, w))
+	assertNoEventWindow(t, w)
+}
+
+func TestEventWindowAcks(t *testing.T) {
+	w := newTestEventWindowBuffer(t, "tumbling", time.Second, 0, 0, 0)
+
+	var firstAckErr, secondAckErr error
+	firstAcked, secondAcked := false, false
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"1","key":"a","ts":10.1}`)),
+		service.NewMessage([]byte(`{"id":"2","key":"a","ts":11.1}`)),
+	}, func(ctx context.Context, err error) error {
+		firstAcked, firstAckErr = true, err
+		return nil
+	}))
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"3","key":"a","ts":9.5}`)),
+	}, func(ctx context.Context, err error) error {
+		secondAcked, secondAckErr = true, err
+		return nil
+	}))
+
+	// Late messages are acknowledged immediately.
+	assert.True(t, secondAcked)
+	assert.NoError(t, secondAckErr)
+
+	assert.Equal(t, []string{"1"}, readEventWindow(t, w).ids)
+	assert.False(t, firstAcked)
+
+	// Messages of windows that were not flushed are nacked at end of input.
+	w.EndOfInput()
+	_, _, err := w.ReadBatch(context.Background())
+	require.Equal(t, service.ErrEndOfBuffer, err)
+	assert.True(t, firstAcked)
+	assert.True(t, errors.Is(firstAckErr, errEventWindowClosed))
+}
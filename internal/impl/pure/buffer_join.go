@@ -0,0 +1,415 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func joinBufferConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Version("4.2.0").
+		Categories("Utility").
+		Summary("Joins pairs of messages from two logical streams that share a key and arrive within a period of time of one another.").
+		Description(`
+Each message consumed by this buffer is given a key by the `+"[`key_mapping`](#key_mapping)"+` and a source by the `+"[`source_mapping`](#source_mapping)"+`, where the source must be one of the two names listed in the field `+"[`sources`](#sources)"+`. Messages are held until a message with the same key from the other source arrives, at which point both are merged into a single message of the form:
+
+`+"```json"+`
+{
+  "<first source>": <first document>,
+  "<second source>": <second document>
+}
+`+"```"+`
+
+The metadata of the merged message is the union of the metadata of both messages, where the metadata of the latest message takes precedence. When multiple messages of one source are waiting for the same key they are matched with messages of the other source in the order in which they arrived.
+
+Messages that are not matched within the `+"[`ttl`](#ttl)"+` are either dropped or emitted on their own depending on the field `+"[`unmatched`](#unmatched)"+`. Unmatched messages that are emitted are of the same form as merged messages but only contain the document of their own source.
+
+Each message emitted has the metadata field `+"`join_key`"+` containing its key, and `+"`join_status`"+` containing either `+"`matched`"+` or `+"`unmatched`"+`.
+
+## Delivery Guarantees
+
+This buffer honours the transaction model within Benthos in order to ensure that messages are not acknowledged until they are either intentionally dropped or successfully delivered to outputs. A merged message is only acknowledged once delivered, at which point both of the messages it was merged from are acknowledged.
+
+During graceful termination any messages waiting to be matched are nacked such that they are re-consumed the next time the service starts.
+`).
+		Field(service.NewBloblangField("key_mapping").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) applied to each message during ingestion that provides the key to join on. The result is converted into a string.").
+			Example("root = this.order_id")).
+		Field(service.NewBloblangField("source_mapping").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) applied to each message during ingestion that provides the name of the source of the message, which must be one of the names listed in `sources`. If the mapping fails or provides an unknown source the batch of messages is rejected.").
+			Example(`root = meta("kafka_topic")`)).
+		Field(service.NewStringListField("sources").
+			Description("The names of the two sources to join, which are also the fields of merged messages.").
+			Example([]string{"orders", "payments"})).
+		Field(service.NewDurationField("ttl").
+			Description("The maximum period of time that a message waits for a match, following the system clock.").
+			Default("5m")).
+		Field(service.NewStringAnnotatedEnumField("unmatched", map[string]string{
+			"drop": "Messages that are not matched are acknowledged and dropped.",
+			"emit": "Messages that are not matched are emitted on their own.",
+		}).
+			Description("What to do with messages that are not matched within the `ttl`.").
+			Default("drop")).
+		Example("Joining Orders and Payments", `Given a stream of orders and a stream of payments consumed from separate Kafka topics that both contain an order ID, we can join each order with its payment and produce a combined document:`,
+			`
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ orders, payments ]
+    consumer_group: benthos_join
+
+buffer:
+  join:
+    key_mapping: root = this.order_id
+    source_mapping: root = meta("kafka_topic")
+    sources: [ orders, payments ]
+    ttl: 10m
+    unmatched: emit
+
+pipeline:
+  processors:
+    - bloblang: |
+        root = this.orders
+        root.paid = this.payments != null
+        root.amount_paid = this.payments.amount | 0
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchBuffer(
+		"join", joinBufferConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchBuffer, error) {
+			return joinBufferFromConfig(conf, mgr.Logger())
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func joinBufferFromConfig(conf *service.ParsedConfig, logger *service.Logger) (*joinBuffer, error) {
+	keyMapping, err := conf.FieldBloblang("key_mapping")
+	if err != nil {
+		return nil, err
+	}
+	sourceMapping, err := conf.FieldBloblang("source_mapping")
+	if err != nil {
+		return nil, err
+	}
+	sources, err := conf.FieldStringList("sources")
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) != 2 || sources[0] == sources[1] {
+		return nil, fmt.Errorf("exactly two different sources must be specified, got: %v", sources)
+	}
+	ttl, err := conf.FieldDuration("ttl")
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("invalid ttl '%v' must be greater than zero", ttl)
+	}
+	unmatched, err := conf.FieldString("unmatched")
+	if err != nil {
+		return nil, err
+	}
+	return newJoinBuffer(keyMapping, sourceMapping, [2]string{sources[0], sources[1]}, ttl, unmatched == "emit", func() time.Time {
+		return time.Now()
+	}, logger), nil
+}
+
+//------------------------------------------------------------------------------
+
+type joinEntry struct {
+	key, source string
+	m           *service.Message
+	ackFn       service.AckFunc
+	expiresAt   time.Time
+	matched     bool
+}
+
+type joinResult struct {
+	m     *service.Message
+	ackFn service.AckFunc
+}
+
+type joinBuffer struct {
+	logger *service.Logger
+
+	keyMapping, sourceMapping *bloblang.Executor
+	sources                   [2]string
+	ttl                       time.Duration
+	emitUnmatched             bool
+	clock                     func() time.Time
+
+	mut     sync.Mutex
+	pending map[string][]*joinEntry
+	expiry  []*joinEntry
+	ready   []joinResult
+
+	readyChan           chan struct{}
+	endOfInputChan      chan struct{}
+	closeEndOfInputOnce sync.Once
+}
+
+func newJoinBuffer(
+	keyMapping, sourceMapping *bloblang.Executor,
+	sources [2]string,
+	ttl time.Duration,
+	emitUnmatched bool,
+	clock func() time.Time,
+	logger *service.Logger,
+) *joinBuffer {
+	return &joinBuffer{
+		logger:         logger,
+		keyMapping:     keyMapping,
+		sourceMapping:  sourceMapping,
+		sources:        sources,
+		ttl:            ttl,
+		emitUnmatched:  emitUnmatched,
+		clock:          clock,
+		pending:        map[string][]*joinEntry{},
+		readyChan:      make(chan struct{}, 1),
+		endOfInputChan: make(chan struct{}),
+	}
+}
+
+// batchQueryString executes a mapping against a message of a batch and returns
+// the result as a string.
+func batchQueryString(msgBatch service.MessageBatch, i int, mapping *bloblang.Executor) (string, error) {
+	res, err := msgBatch.BloblangQuery(i, mapping)
+	if err != nil {
+		return "", err
+	}
+	if res == nil {
+		return "", nil
+	}
+	b, err := res.AsBytes()
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (j *joinBuffer) WriteBatch(ctx context.Context, msgBatch service.MessageBatch, aFn service.AckFunc) error {
+	if len(msgBatch) == 0 {
+		return aFn(ctx, nil)
+	}
+
+	keys := make([]string, len(msgBatch))
+	sources := make([]string, len(msgBatch))
+	for i := range msgBatch {
+		var err error
+		if keys[i], err = batchQueryString(msgBatch, i, j.keyMapping); err != nil {
+			j.logger.Errorf("Key mapping failed for message: %v", err)
+			return fmt.Errorf("key mapping failed: %w", err)
+		}
+		if sources[i], err = batchQueryString(msgBatch, i, j.sourceMapping); err != nil {
+			j.logger.Errorf("Source mapping failed for message: %v", err)
+			return fmt.Errorf("source mapping failed: %w", err)
+		}
+		if sources[i] != j.sources[0] && sources[i] != j.sources[1] {
+			j.logger.Errorf("Source mapping failed for message: unrecognised source '%v'", sources[i])
+			return fmt.Errorf("source mapping failed: unrecognised source '%v', expected one of: %v", sources[i], j.sources)
+		}
+	}
+
+	// All ack funcs must be derived before any of them are called.
+	aggregatedAck := batch.NewCombinedAcker(batch.AckFunc(aFn))
+	ackFns := make([]service.AckFunc, len(msgBatch))
+	for i := range ackFns {
+		ackFns[i] = service.AckFunc(aggregatedAck.Derive())
+	}
+
+	j.mut.Lock()
+	defer j.mut.Unlock()
+
+	now := j.clock()
+	matched := false
+	for i, msg := range msgBatch {
+		entry := &joinEntry{
+			key:       keys[i],
+			source:    sources[i],
+			m:         msg,
+			ackFn:     ackFns[i],
+			expiresAt: now.Add(j.ttl),
+		}
+
+		// Messages waiting for a key are all of the same source, as otherwise
+		// they would have been matched with one another.
+		if waiting := j.pending[entry.key]; len(waiting) > 0 && waiting[0].source != entry.source {
+			other := waiting[0]
+			if len(waiting) == 1 {
+				delete(j.pending, entry.key)
+			} else {
+				j.pending[entry.key] = waiting[1:]
+			}
+			other.matched = true
+			j.ready = append(j.ready, j.merge(other, entry))
+			matched = true
+			continue
+		}
+		j.pending[entry.key] = append(j.pending[entry.key], entry)
+		j.expiry = append(j.expiry, entry)
+	}
+
+	if matched {
+		select {
+		case j.readyChan <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// merge creates a message from a pair of entries, or from a single entry when
+// the second is nil.
+func (j *joinBuffer) merge(first, second *joinEntry) joinResult {
+	entries := []*joinEntry{first}
+	if second != nil {
+		entries = append(entries, second)
+	}
+
+	doc := make(map[string]interface{}, len(entries))
+	msg := first.m.Copy()
+	for _, e := range entries {
+		v, err := e.m.AsStructured()
+		if err != nil {
+			b, _ := e.m.AsBytes()
+			v = string(b)
+		}
+		doc[e.source] = v
+		if e != first {
+			_ = e.m.MetaWalk(func(k, v string) error {
+				msg.MetaSet(k, v)
+				return nil
+			})
+		}
+	}
+	msg.SetStructured(doc)
+	msg.MetaSet("join_key", first.key)
+	if second != nil {
+		msg.MetaSet("join_status", "matched")
+	} else {
+		msg.MetaSet("join_status", "unmatched")
+	}
+
+	return joinResult{
+		m: msg,
+		ackFn: func(ctx context.Context, err error) error {
+			for _, e := range entries {
+				_ = e.ackFn(ctx, err)
+			}
+			return nil
+		},
+	}
+}
+
+// expire removes all entries that have passed their ttl, and returns the time
+// at which the next entry expires.
+func (j *joinBuffer) expire(ctx context.Context) (next time.Time, exists bool) {
+	now := j.clock()
+	for len(j.expiry) > 0 {
+		entry := j.expiry[0]
+		if entry.matched {
+			j.expiry = j.expiry[1:]
+			continue
+		}
+		if entry.expiresAt.After(now) {
+			return entry.expiresAt, true
+		}
+		j.expiry = j.expiry[1:]
+
+		// Entries expire in the order that they arrived, and therefore the
+		// entry is the oldest waiting for its key.
+		if waiting := j.pending[entry.key]; len(waiting) > 1 {
+			j.pending[entry.key] = waiting[1:]
+		} else {
+			delete(j.pending, entry.key)
+		}
+
+		if j.emitUnmatched {
+			j.ready = append(j.ready, j.merge(entry, nil))
+		} else {
+			_ = entry.ackFn(ctx, nil)
+		}
+	}
+	return time.Time{}, false
+}
+
+var errJoinClosed = errors.New("message rejected as it was not joined")
+
+func (j *joinBuffer) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for {
+		j.mut.Lock()
+		nextExpiry, expiryExists := j.expire(ctx)
+		if len(j.ready) > 0 {
+			res := j.ready[0]
+			j.ready = j.ready[1:]
+			j.mut.Unlock()
+			return service.MessageBatch{res.m}, res.ackFn, nil
+		}
+		j.mut.Unlock()
+
+		var expiryChan <-chan time.Time
+		var expiryTimer *time.Timer
+		if expiryExists {
+			expiryTimer = time.NewTimer(nextExpiry.Sub(j.clock()))
+			expiryChan = expiryTimer.C
+		}
+
+		var endOfInput bool
+		select {
+		case <-j.readyChan:
+		case <-expiryChan:
+		case <-ctx.Done():
+		case <-j.endOfInputChan:
+			endOfInput = true
+		}
+		if expiryTimer != nil {
+			expiryTimer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if !endOfInput {
+			continue
+		}
+
+		j.mut.Lock()
+		if len(j.ready) > 0 {
+			j.mut.Unlock()
+			continue
+		}
+		// Nack all messages waiting to be matched so that we re-consume them
+		// on the next start up.
+		for _, entry := range j.expiry {
+			if !entry.matched {
+				_ = entry.ackFn(ctx, errJoinClosed)
+			}
+		}
+		j.expiry = nil
+		j.pending = map[string][]*joinEntry{}
+		j.mut.Unlock()
+		return nil, nil, service.ErrEndOfBuffer
+	}
+}
+
+func (j *joinBuffer) EndOfInput() {
+	j.closeEndOfInputOnce.Do(func() {
+		close(j.endOfInputChan)
+	})
+}
+
+func (j *joinBuffer) Close(ctx context.Context) error {
+	return nil
+}
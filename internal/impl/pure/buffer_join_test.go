@@ -0,0 +1,275 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestJoinBufferConfigs(t *testing.T) {
+	tests := []struct {
+		config           string
+		lintErrContains  string
+		buildErrContains string
+	}{
+		{
+			config: `
+join:
+  key_mapping: root = this.id
+  source_mapping: root = meta("topic")
+  sources: [ foo, bar ]
+`,
+		},
+		{
+			config: `
+join:
+  key_mapping: root = this.id
+  sources: [ foo, bar ]
+`,
+			lintErrContains: "field source_mapping is required",
+		},
+		{
+			config: `
+join:
+  key_mapping: root = this.id
+  source_mapping: root = meta("topic")
+  sources: [ foo, foo ]
+`,
+			buildErrContains: "exactly two different sources must be specified",
+		},
+		{
+			config: `
+join:
+  key_mapping: root = this.id
+  source_mapping: root = meta("topic")
+  sources: [ foo, bar ]
+  ttl: 0s
+`,
+			buildErrContains: "invalid ttl",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			env := service.NewStreamBuilder()
+			require.NoError(t, env.SetLoggerYAML(`level: OFF`))
+			err := env.AddConsumerFunc(func(context.Context, *service.Message) error {
+				return nil
+			})
+			require.NoError(t, err)
+			_, err = env.AddProducerFunc()
+			require.NoError(t, err)
+
+			err = env.SetBufferYAML(test.config)
+			if test.lintErrContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.lintErrContains)
+				return
+			}
+			require.NoError(t, err)
+
+			strm, err := env.Build()
+			require.NoError(t, err)
+
+			cancelledCtx, done := context.WithCancel(context.Background())
+			done()
+			err = strm.Run(cancelledCtx)
+			if test.buildErrContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.buildErrContains)
+				return
+			}
+			require.EqualError(t, err, "context canceled")
+			require.NoError(t, strm.StopWithin(time.Second))
+		})
+	}
+}
+
+type testJoinClock struct {
+	mut sync.Mutex
+	now time.Time
+}
+
+func (c *testJoinClock) Now() time.Time {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.now
+}
+
+func (c *testJoinClock) Add(d time.Duration) {
+	c.mut.Lock()
+	c.now = c.now.Add(d)
+	c.mut.Unlock()
+}
+
+func newTestJoinBuffer(t *testing.T, emitUnmatched bool) (*joinBuffer, *testJoinClock) {
+	t.Helper()
+
+	keyMapping, err := bloblang.Parse(`root = this.id`)
+	require.NoError(t, err)
+	sourceMapping, err := bloblang.Parse(`root = meta("topic")`)
+	require.NoError(t, err)
+
+	clock := &testJoinClock{now: time.Unix(100, 0)}
+	return newJoinBuffer(keyMapping, sourceMapping, [2]string{"orders", "payments"}, time.Minute, emitUnmatched, clock.Now, nil), clock
+}
+
+func joinMsg(topic, content string) *service.Message {
+	msg := service.NewMessage([]byte(content))
+	msg.MetaSet("topic", topic)
+	return msg
+}
+
+func readJoined(t *testing.T, j *joinBuffer, timeout time.Duration) (string, map[string]string, service.AckFunc, error) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), timeout)
+	defer done()
+
+	msgBatch, aFn, err := j.ReadBatch(ctx)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	require.Len(t, msgBatch, 1)
+
+	b, err := msgBatch[0].AsBytes()
+	require.NoError(t, err)
+
+	meta := map[string]string{}
+	_ = msgBatch[0].MetaWalk(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+	return string(b), meta, aFn, nil
+}
+
+func TestJoinBufferMatching(t *testing.T) {
+	j, _ := newTestJoinBuffer(t, false)
+
+	var acked []string
+	ackFor := func(name string) service.AckFunc {
+		return func(ctx context.Context, err error) error {
+			require.NoError(t, err)
+			acked = append(acked, name)
+			return nil
+		}
+	}
+
+	require.NoError(t, j.WriteBatch(context.Background(), service.MessageBatch{
+		joinMsg("orders", `{"id":"a","item":"shoes"}`),
+		joinMsg("orders", `{"id":"b","item":"socks"}`),
+		joinMsg("orders", `{"id":"a","item":"hat"}`),
+	}, ackFor("first")))
+
+	_, _, _, err := readJoined(t, j, time.Millisecond*50)
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	require.NoError(t, j.WriteBatch(context.Background(), service.MessageBatch{
+		joinMsg("payments", `{"id":"a","amount":10}`),
+		joinMsg("payments", `{"id":"c","amount":5}`),
+	}, ackFor("second")))
+	require.NoError(t, j.WriteBatch(context.Background(), service.MessageBatch{
+		joinMsg("payments", `{"id":"a","amount":20}`),
+		joinMsg("payments", `{"id":"b","amount":30}`),
+	}, ackFor("third")))
+
+	for _, exp := range []struct {
+		content, key string
+	}{
+		{content: `{"orders":{"id":"a","item":"shoes"},"payments":{"amount":10,"id":"a"}}`, key: "a"},
+		{content: `{"orders":{"id":"a","item":"hat"},"payments":{"amount":20,"id":"a"}}`, key: "a"},
+		{content: `{"orders":{"id":"b","item":"socks"},"payments":{"amount":30,"id":"b"}}`, key: "b"},
+	} {
+		content, meta, aFn, err := readJoined(t, j, time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, exp.content, content)
+		assert.Equal(t, map[string]string{
+			"topic":       "payments",
+			"join_key":    exp.key,
+			"join_status": "matched",
+		}, meta)
+		require.NoError(t, aFn(context.Background(), nil))
+	}
+
+	// The payment for c is still waiting.
+	assert.Equal(t, []string{"first", "third"}, acked)
+}
+
+func TestJoinBufferUnmatched(t *testing.T) {
+	for _, emit := range []bool{false, true} {
+		t.Run(strconv.FormatBool(emit), func(t *testing.T) {
+			j, clock := newTestJoinBuffer(t, emit)
+
+			var ackErr error
+			acked := false
+			require.NoError(t, j.WriteBatch(context.Background(), service.MessageBatch{
+				joinMsg("orders", `{"id":"a","item":"shoes"}`),
+			}, func(ctx context.Context, err error) error {
+				acked, ackErr = true, err
+				return nil
+			}))
+
+			clock.Add(time.Second * 30)
+			require.NoError(t, j.WriteBatch(context.Background(), service.MessageBatch{
+				joinMsg("payments", `{"id":"b","amount":10}`),
+			}, noopAck))
+
+			clock.Add(time.Second * 31)
+			if !emit {
+				_, _, _, err := readJoined(t, j, time.Millisecond*50)
+				require.Equal(t, context.DeadlineExceeded, err)
+				assert.True(t, acked)
+				assert.NoError(t, ackErr)
+				return
+			}
+
+			content, meta, aFn, err := readJoined(t, j, time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, `{"orders":{"id":"a","item":"shoes"}}`, content)
+			assert.Equal(t, map[string]string{
+				"topic":       "orders",
+				"join_key":    "a",
+				"join_status": "unmatched",
+			}, meta)
+			assert.False(t, acked)
+			require.NoError(t, aFn(context.Background(), nil))
+			assert.True(t, acked)
+
+			// The payment expires later.
+			_, _, _, err = readJoined(t, j, time.Millisecond*50)
+			require.Equal(t, context.DeadlineExceeded, err)
+		})
+	}
+}
+
+func TestJoinBufferErrors(t *testing.T) {
+	j, _ := newTestJoinBuffer(t, false)
+
+	err := j.WriteBatch(context.Background(), service.MessageBatch{
+		joinMsg("refunds", `{"id":"a"}`),
+	}, noopAck)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unrecognised source 'refunds'")
+
+	var ackErr error
+	require.NoError(t, j.WriteBatch(context.Background(), service.MessageBatch{
+		joinMsg("orders", `{"id":"a"}`),
+	}, func(ctx context.Context, err error) error {
+		ackErr = err
+		return nil
+	}))
+
+	j.EndOfInput()
+	_, _, err = j.ReadBatch(context.Background())
+	require.Equal(t, service.ErrEndOfBuffer, err)
+	assert.True(t, errors.Is(ackErr, errJoinClosed))
+}
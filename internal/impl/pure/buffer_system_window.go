@@ -268,9 +268,15 @@ func (w *systemWindowBuffer) nextSystemWindow() (prevStart, prevEnd, start, end
 }
 
 func (w *systemWindowBuffer) getTimestamp(i int, batch service.MessageBatch) (ts time.Time, err error) {
+	return windowTimestamp(w.logger, w.tsMapping, i, batch)
+}
+
+// windowTimestamp executes a timestamp mapping against a message of a batch
+// and parses the result as a timestamp.
+func windowTimestamp(logger *service.Logger, tsMapping *bloblang.Executor, i int, batch service.MessageBatch) (ts time.Time, err error) {
 	var tsValueMsg *service.Message
-	if tsValueMsg, err = batch.BloblangQuery(i, w.tsMapping); err != nil {
-		w.logger.Errorf("Timestamp mapping failed for message: %v", err)
+	if tsValueMsg, err = batch.BloblangQuery(i, tsMapping); err != nil {
+		logger.Errorf("Timestamp mapping failed for message: %v", err)
 		err = fmt.Errorf("timestamp mapping failed: %w", err)
 		return
 	}
@@ -283,13 +289,13 @@ func (w *systemWindowBuffer) getTimestamp(i int, batch service.MessageBatch) (ts
 		}
 	}
 	if err != nil {
-		w.logger.Errorf("Timestamp mapping failed for message: unable to parse result as structured value: %v", err)
+		logger.Errorf("Timestamp mapping failed for message: unable to parse result as structured value: %v", err)
 		err = fmt.Errorf("unable to parse result of timestamp mapping as structured value: %w", err)
 		return
 	}
 
 	if ts, err = query.IGetTimestamp(tsValue); err != nil {
-		w.logger.Errorf("Timestamp mapping failed for message: %v", err)
+		logger.Errorf("Timestamp mapping failed for message: %v", err)
 		err = fmt.Errorf("unable to parse result of timestamp mapping as timestamp: %w", err)
 	}
 	return
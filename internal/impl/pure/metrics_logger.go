@@ -127,6 +127,14 @@ func (s *loggerMetrics) GetTimerVec(path string, n ...string) metrics.StatTimerV
 	return s.local.GetTimerVec(path, n...)
 }
 
+func (s *loggerMetrics) GetHistogram(path string) metrics.StatHistogram {
+	return s.GetHistogramVec(path).With()
+}
+
+func (s *loggerMetrics) GetHistogramVec(path string, n ...string) metrics.StatHistogramVec {
+	return s.local.GetHistogramVec(path, n...)
+}
+
 func (s *loggerMetrics) GetGauge(path string) metrics.StatGauge {
 	return s.GetGaugeVec(path).With()
 }
@@ -3,6 +3,10 @@ package pure
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
@@ -33,7 +37,9 @@ Executes a [Bloblang](/docs/guides/bloblang/about) mapping on messages.`,
 		Description: `
 Bloblang is a powerful language that enables a wide range of mapping, transformation and filtering tasks. For more information [check out the docs](/docs/guides/bloblang/about).
 
-If your mapping is large and you'd prefer for it to live in a separate file then you can execute a mapping directly from a file with the expression ` + "`from \"<path>\"`" + `, where the path must be absolute, or relative from the location that Benthos is executed from.`,
+If your mapping is large and you'd prefer for it to live in a separate file then you can execute a mapping directly from a file with the expression ` + "`from \"<path>\"`" + `, where the path must be absolute, or relative from the location that Benthos is executed from.
+
+Mappings executed from a file in this way are reloaded each time the file is modified, allowing you to make changes to a mapping without restarting Benthos. If the modified mapping fails to parse then the error is logged and the previous mapping continues to be executed. Files imported by the mapping itself are not watched.`,
 		Footnotes: `
 ## Error Handling
 
@@ -43,7 +49,27 @@ are logged, and the message is flagged as having failed, allowing you to use
 
 However, Bloblang itself also provides powerful ways of ensuring your mappings
 do not fail by specifying desired fallback behaviour, which you can read about
-[in this section](/docs/guides/bloblang/about#error-handling).`,
+[in this section](/docs/guides/bloblang/about#error-handling).
+
+## Debugging
+
+When the log level of this processor is set to ` + "`TRACE`" + ` the result of each
+statement of the mapping is logged along with its line number, assignment target
+and the time taken to execute it, which is useful for inspecting the
+intermediate values of a mapping. The log level of a single processor can be
+changed at runtime with the ` + "`/log/level`" + ` endpoint when
+` + "`http.debug_endpoints`" + ` is enabled, and therefore the mapping can be inspected
+without raising the log level of the whole pipeline.
+
+## Metrics
+
+In addition to the standard processor metrics, the following metrics are emitted
+for each statement of the mapping with a label ` + "`mapping`" + ` of ` + "`mapping`" + ` and a
+label ` + "`line`" + ` identifying the line number of the statement:
+
+- ` + "`mapping_statement_executed`" + `: A count of executions of the statement.
+- ` + "`mapping_statement_error`" + `: A count of executions of the statement that failed.
+- ` + "`mapping_statement_latency_ns`" + `: The time taken to execute the statement.`,
 		Examples: []docs.AnnotatedExample{
 			{
 				Title: "Mapping",
@@ -126,11 +152,32 @@ pipeline:
 }
 
 type bloblangProc struct {
-	exec *mapping.Executor
-	log  log.Modular
+	log log.Modular
+
+	execMut sync.RWMutex
+	exec    *mapping.Executor
+
+	watcher *fsnotify.Watcher
 }
 
 func newBloblang(conf string, mgr bundle.NewManagement) (processor.V2Batched, error) {
+	exec, err := parseBloblangProc(conf, mgr)
+	if err != nil {
+		return nil, err
+	}
+	b := &bloblangProc{
+		exec: exec,
+		log:  mgr.Logger(),
+	}
+	if path, ok := mgr.BloblEnvironment().MappingFilePath(conf); ok {
+		if err := b.watchFile(path, conf, mgr); err != nil {
+			b.log.Warnf("Unable to watch mapping file %v for changes: %v\n", path, err)
+		}
+	}
+	return b, nil
+}
+
+func parseBloblangProc(conf string, mgr bundle.NewManagement) (*mapping.Executor, error) {
 	exec, err := mgr.BloblEnvironment().NewMapping(conf)
 	if err != nil {
 		if perr, ok := err.(*parser.Error); ok {
@@ -138,16 +185,68 @@ func newBloblang(conf string, mgr bundle.NewManagement) (processor.V2Batched, er
 		}
 		return nil, err
 	}
-	return &bloblangProc{
-		exec: exec,
-		log:  mgr.Logger(),
-	}, nil
+	return processor.WithStatementMetrics(exec, "mapping", mgr.Metrics()), nil
+}
+
+// watchFile reloads the mapping each time the file it is read from is
+// modified. Editors commonly replace files rather than writing to them,
+// therefore we watch the parent directory and filter for events on the file.
+func (b *bloblangProc) watchFile(path, conf string, mgr bundle.NewManagement) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	path = filepath.Clean(path)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return err
+	}
+	b.watcher = watcher
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path ||
+					event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				exec, err := parseBloblangProc(conf, mgr)
+				if err != nil {
+					b.log.Errorf("Failed to reload mapping file %v: %v\n", path, err)
+					continue
+				}
+				b.execMut.Lock()
+				b.exec = exec
+				b.execMut.Unlock()
+				b.log.Infof("Reloaded mapping file %v\n", path)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				b.log.Errorf("Failed to watch mapping file %v: %v\n", path, err)
+			}
+		}
+	}()
+	return nil
 }
 
 func (b *bloblangProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg *message.Batch) ([]*message.Batch, error) {
+	b.execMut.RLock()
+	exec := b.exec
+	b.execMut.RUnlock()
+	if lc, ok := b.log.(log.LevelChecker); ok && lc.LevelEnabled("TRACE") {
+		exec = exec.WithTrace(func(entry mapping.TraceEntry) {
+			b.log.Tracef("Mapping statement %v\n", entry)
+		})
+	}
+
 	newParts := make([]*message.Part, 0, msg.Len())
 	_ = msg.Iter(func(i int, part *message.Part) error {
-		p, err := b.exec.MapPart(i, msg)
+		p, err := exec.MapPart(i, msg)
 		if err != nil {
 			p = part.Copy()
 			b.log.Errorf("%v\n", err)
@@ -168,5 +267,8 @@ func (b *bloblangProc) ProcessBatch(ctx context.Context, spans []*tracing.Span,
 }
 
 func (b *bloblangProc) Close(context.Context) error {
+	if b.watcher != nil {
+		return b.watcher.Close()
+	}
 	return nil
 }
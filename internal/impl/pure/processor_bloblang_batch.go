@@ -0,0 +1,86 @@
+package pure
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func bloblangBatchProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Mapping", "Utility").
+		Summary("Executes a [Bloblang](/docs/guides/bloblang/about) mapping once on an entire batch of messages in order to aggregate them into a single message.").
+		Description(`
+The context of the mapping (` + "`this`" + `) is an array containing each message of the batch parsed as a JSON document. Functions that reference the message being mapped, such as ` + "`meta`" + ` and ` + "`content`" + `, do so from the perspective of the first message of the batch, and functions such as ` + "`json_from`" + ` can be used in order to reference the other messages.
+
+The resulting message adopts the metadata of the _first_ message of the batch. If the mapping deletes the root then the entire batch is dropped.
+
+The functionality of this processor depends on being applied across messages that are batched. You can find out more about batching [in this doc](/docs/configuration/batching).`).
+		Field(service.NewBloblangField("mapping").
+			Description("The mapping to execute on the batch.")).
+		Version("4.2.0").
+		Example("Summarise a Batch", `
+Given batches of JSON documents each of the form `+"`"+`{"id":"foo","price":12.5}`+"`"+`, we can aggregate each batch into a single summary document with the following config:`, `
+pipeline:
+  processors:
+    - bloblang_batch:
+        mapping: |
+          root.ids = this.map_each(doc -> doc.id)
+          root.total = this.map_each(doc -> doc.price).sum()
+          root.count = this.length()
+`)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"bloblang_batch", bloblangBatchProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newBloblangBatchFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type bloblangBatchProc struct {
+	mapping *bloblang.Executor
+	log     *service.Logger
+}
+
+func newBloblangBatchFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*bloblangBatchProc, error) {
+	mapping, err := conf.FieldBloblang("mapping")
+	if err != nil {
+		return nil, err
+	}
+	return &bloblangBatchProc{
+		mapping: mapping,
+		log:     mgr.Logger(),
+	}, nil
+}
+
+func (b *bloblangBatchProc) ProcessBatch(ctx context.Context, msg service.MessageBatch) ([]service.MessageBatch, error) {
+	if len(msg) == 0 {
+		return nil, nil
+	}
+
+	newPart, err := msg.BloblangQueryAll(b.mapping)
+	if err != nil {
+		b.log.Debugf("Failed to map batch: %v\n", err)
+		return nil, err
+	}
+	if newPart == nil {
+		return nil, nil
+	}
+
+	newPart = newPart.WithContext(batch.CtxWithCollapsedCount(newPart.Context(), len(msg)))
+	return []service.MessageBatch{{newPart}}, nil
+}
+
+func (b *bloblangBatchProc) Close(context.Context) error {
+	return nil
+}
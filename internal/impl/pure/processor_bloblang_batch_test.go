@@ -0,0 +1,86 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestBloblangBatchAggregate(t *testing.T) {
+	conf, err := bloblangBatchProcConfig().ParseYAML(`
+mapping: |
+  root.ids = this.map_each(doc -> doc.id)
+  root.total = this.map_each(doc -> doc.price).sum()
+  root.last_topic = meta_from(-1, "topic")
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newBloblangBatchFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	var msg service.MessageBatch
+	for _, c := range []struct {
+		content string
+		topic   string
+	}{
+		{content: `{"id":"foo","price":10}`, topic: "a"},
+		{content: `{"id":"bar","price":2.5}`, topic: "b"},
+		{content: `{"id":"baz","price":1}`, topic: "c"},
+	} {
+		p := service.NewMessage([]byte(c.content))
+		p.MetaSet("topic", c.topic)
+		msg = append(msg, p)
+	}
+
+	batches, err := proc.ProcessBatch(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 1)
+
+	bBytes, err := batches[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"ids":["foo","bar","baz"],"last_topic":"c","total":13.5}`, string(bBytes))
+
+	topic, _ := batches[0][0].MetaGet("topic")
+	assert.Equal(t, "a", topic)
+	assert.Equal(t, 3, batch.CtxCollapsedCount(batches[0][0].Context()))
+}
+
+func TestBloblangBatchDeleted(t *testing.T) {
+	conf, err := bloblangBatchProcConfig().ParseYAML(`
+mapping: 'root = if this.length() < 3 { deleted() } else { this }'
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newBloblangBatchFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	batches, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"foo"}`)),
+		service.NewMessage([]byte(`{"id":"bar"}`)),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, batches)
+}
+
+func TestBloblangBatchError(t *testing.T) {
+	conf, err := bloblangBatchProcConfig().ParseYAML(`
+mapping: 'root.ids = this.map_each(doc -> doc.id)'
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newBloblangBatchFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	_, err = proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"foo"}`)),
+		service.NewMessage([]byte(`not json`)),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parse message 1 as json")
+}
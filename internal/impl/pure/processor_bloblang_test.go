@@ -1,14 +1,22 @@
 package pure_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/gabs/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
 
@@ -218,3 +226,133 @@ func TestBloblangJSONError(t *testing.T) {
 	require.Error(t, resPart.ErrorGet())
 	assert.Equal(t, `failed assignment (line 2): invalid character 'h' in literal true (expecting 'r')`, resPart.ErrorGet().Error())
 }
+
+func TestBloblangDecimalPrecision(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = "bloblang"
+	conf.Bloblang = `root.total = this.price.decimal() * 3
+root.id = this.id.uint64()`
+	proc, err := mock.NewManager().NewProcessor(conf)
+	require.NoError(t, err)
+
+	outMsgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"id":18446744073709551615,"price":"0.1"}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, outMsgs, 1)
+
+	resPart := outMsgs[0].Get(0)
+	require.NoError(t, resPart.ErrorGet())
+	assert.Equal(t, `{"id":18446744073709551615,"total":0.3}`, string(resPart.Get()))
+
+	// Deep copies of the structured result keep the exact decimal.
+	v, err := resPart.DeepCopy().JSON()
+	require.NoError(t, err)
+	assert.Equal(t, json.Number("0.3"), v.(map[string]interface{})["total"])
+}
+
+func TestBloblangTraceLogging(t *testing.T) {
+	logConf := log.NewConfig()
+	logConf.LogLevel = "TRACE"
+	logConf.Format = "logfmt"
+
+	var buf bytes.Buffer
+	logger, err := log.NewV2(&buf, logConf)
+	require.NoError(t, err)
+
+	mgr := mock.NewManager()
+	mgr.L = logger
+
+	conf := processor.NewConfig()
+	conf.Type = "bloblang"
+	conf.Bloblang = `root.foo = this.foo.uppercase()
+root.bar = this.bar`
+
+	proc, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+
+	outMsgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(`{"foo":"hello","bar":5}`)}))
+	require.Nil(t, res)
+	require.Len(t, outMsgs, 1)
+	assert.Equal(t, `{"bar":5,"foo":"HELLO"}`, string(outMsgs[0].Get(0).Get()))
+
+	assert.Contains(t, buf.String(), `line 1: root.foo = \"HELLO\"`)
+	assert.Contains(t, buf.String(), `line 2: root.bar = 5`)
+
+	// No statements are logged at less verbose levels.
+	require.NoError(t, logger.(log.LevelSetter).SetLevel("DEBUG", ""))
+	buf.Reset()
+
+	_, res = proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(`{"foo":"hello","bar":5}`)}))
+	require.Nil(t, res)
+	assert.Empty(t, buf.String())
+}
+
+func TestBloblangStatementMetrics(t *testing.T) {
+	mockMetrics := metrics.NewLocal()
+
+	mgr := mock.NewManager()
+	mgr.M = mockMetrics
+
+	conf := processor.NewConfig()
+	conf.Type = "bloblang"
+	conf.Bloblang = `root.foo = this.foo.uppercase()
+root.bar = this.bar.number()`
+
+	proc, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+
+	for _, input := range []string{
+		`{"foo":"a","bar":"1"}`,
+		`{"foo":"b","bar":"nope"}`,
+		`{"foo":"c","bar":"3"}`,
+	} {
+		outMsgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(input)}))
+		require.Nil(t, res)
+		require.Len(t, outMsgs, 1)
+	}
+
+	counters := mockMetrics.GetCounters()
+	assert.Equal(t, int64(3), counters[`mapping_statement_executed{line="1",mapping="mapping"}`])
+	assert.Equal(t, int64(3), counters[`mapping_statement_executed{line="2",mapping="mapping"}`])
+	assert.Equal(t, int64(0), counters[`mapping_statement_error{line="1",mapping="mapping"}`])
+	assert.Equal(t, int64(1), counters[`mapping_statement_error{line="2",mapping="mapping"}`])
+
+	timings := mockMetrics.GetTimings()
+	assert.Contains(t, timings, `mapping_statement_latency_ns{line="1",mapping="mapping"}`)
+	assert.Contains(t, timings, `mapping_statement_latency_ns{line="2",mapping="mapping"}`)
+}
+
+func TestBloblangFileReload(t *testing.T) {
+	mappingPath := filepath.Join(t.TempDir(), "mapping.blobl")
+	require.NoError(t, os.WriteFile(mappingPath, []byte(`root = content().uppercase()`), 0o644))
+
+	conf := processor.NewConfig()
+	conf.Type = "bloblang"
+	conf.Bloblang = fmt.Sprintf(`from %q`, mappingPath)
+
+	proc, err := mock.NewManager().NewProcessor(conf)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		proc.CloseAsync()
+		require.NoError(t, proc.WaitForClose(time.Second*5))
+	})
+
+	process := func() string {
+		outMsgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(`hello world`)}))
+		require.Nil(t, res)
+		require.Len(t, outMsgs, 1)
+		return string(outMsgs[0].Get(0).Get())
+	}
+	assert.Equal(t, "HELLO WORLD", process())
+
+	// A mapping that fails to parse is ignored.
+	require.NoError(t, os.WriteFile(mappingPath, []byte(`root = content().not_a_method()`), 0o644))
+	time.Sleep(time.Millisecond * 100)
+	assert.Equal(t, "HELLO WORLD", process())
+
+	require.NoError(t, os.WriteFile(mappingPath, []byte(`root = content().capitalize()`), 0o644))
+	assert.Eventually(t, func() bool {
+		return process() == "Hello World"
+	}, time.Second*5, time.Millisecond*10)
+}
@@ -51,6 +51,10 @@ root.bar.id = this.user.id`,
 	this
 }`,
 	).HasDefault(""),
+	docs.FieldBool(
+		"statement_metrics",
+		"Whether to emit the metrics `mapping_statement_executed`, `mapping_statement_error` and `mapping_statement_latency_ns` for each statement of the `request_map` and `result_map` mappings, labelled by the name of the mapping field and the line number of the statement. Measuring each statement adds overhead to the execution of mappings.",
+	).HasDefault(false).Advanced(),
 }
 
 func init() {
@@ -203,6 +207,8 @@ type Branch struct {
 	mBatchSent     metrics.StatCounter
 	mError         metrics.StatCounter
 	mLatency       metrics.StatTimer
+	mInFlight      metrics.StatGauge
+	mBatchSize     metrics.StatHistogram
 }
 
 func newBranch(conf processor.BranchConfig, mgr bundle.NewManagement) (*Branch, error) {
@@ -230,6 +236,8 @@ func newBranch(conf processor.BranchConfig, mgr bundle.NewManagement) (*Branch,
 		mBatchSent:     stats.GetCounter("processor_batch_sent"),
 		mError:         stats.GetCounter("processor_error"),
 		mLatency:       stats.GetTimer("processor_latency_ns"),
+		mInFlight:      stats.GetGauge("processor_in_flight"),
+		mBatchSize:     stats.GetHistogram("processor_batch_size"),
 	}
 
 	var err error
@@ -237,11 +245,17 @@ func newBranch(conf processor.BranchConfig, mgr bundle.NewManagement) (*Branch,
 		if b.requestMap, err = mgr.BloblEnvironment().NewMapping(conf.RequestMap); err != nil {
 			return nil, fmt.Errorf("failed to parse request mapping: %w", err)
 		}
+		if conf.StatementMetrics {
+			b.requestMap = processor.WithStatementMetrics(b.requestMap, "request_map", stats)
+		}
 	}
 	if len(conf.ResultMap) > 0 {
 		if b.resultMap, err = mgr.BloblEnvironment().NewMapping(conf.ResultMap); err != nil {
 			return nil, fmt.Errorf("failed to parse result mapping: %w", err)
 		}
+		if conf.StatementMetrics {
+			b.resultMap = processor.WithStatementMetrics(b.resultMap, "result_map", stats)
+		}
 	}
 
 	return b, nil
@@ -306,8 +320,12 @@ pathLoop:
 // ProcessMessage applies the processor to a message, either creating >0
 // resulting messages or a response to be sent back to the message source.
 func (b *Branch) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
-	b.mReceived.Incr(int64(msg.Len()))
+	inFlight := int64(msg.Len())
+	b.mReceived.Incr(inFlight)
 	b.mBatchReceived.Incr(1)
+	b.mBatchSize.Observe(inFlight)
+	b.mInFlight.Incr(inFlight)
+	defer b.mInFlight.Decr(inFlight)
 	startedAt := time.Now()
 
 	branchMsg, propSpans := tracing.WithChildSpans("branch", msg.Copy())
@@ -341,7 +359,8 @@ func (b *Branch) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 		return msgs[:], nil
 	}
 
-	result := msg.DeepCopy()
+	// Structured contents are cloned lazily by result mappings.
+	result := msg.Copy()
 	for _, e := range mapErrs {
 		result.Get(e.index).ErrorSet(e.err)
 		b.log.Errorf("Branch error: %v", e.err)
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -270,3 +271,72 @@ func TestBranchBasic(t *testing.T) {
 		})
 	}
 }
+
+func TestBranchStatementMetrics(t *testing.T) {
+	mockMetrics := metrics.NewLocal()
+
+	mgr := mock.NewManager()
+	mgr.M = mockMetrics
+
+	conf := processor.NewConfig()
+	conf.Type = "branch"
+	conf.Branch.RequestMap = "root.value = this.value.uppercase()"
+	conf.Branch.ResultMap = "root.result = this.value"
+	conf.Branch.StatementMetrics = true
+
+	procConf := processor.NewConfig()
+	procConf.Type = "noop"
+	conf.Branch.Processors = append(conf.Branch.Processors, procConf)
+
+	proc, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+
+	outMsgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{[]byte(`{"value":"foo"}`)}))
+	require.Nil(t, res)
+	require.Len(t, outMsgs, 1)
+	assert.NoError(t, outMsgs[0].Get(0).ErrorGet())
+	assert.Equal(t, `{"result":"FOO","value":"foo"}`, string(outMsgs[0].Get(0).Get()))
+
+	counters := mockMetrics.GetCounters()
+	assert.Equal(t, int64(1), counters[`mapping_statement_executed{line="1",mapping="request_map"}`])
+	assert.Equal(t, int64(1), counters[`mapping_statement_executed{line="1",mapping="result_map"}`])
+
+	proc.CloseAsync()
+	assert.NoError(t, proc.WaitForClose(time.Second))
+}
+
+func TestBranchInFlightMetrics(t *testing.T) {
+	mockMetrics := metrics.NewLocal()
+
+	mgr := mock.NewManager()
+	mgr.M = mockMetrics
+
+	conf := processor.NewConfig()
+	conf.Type = "branch"
+	conf.Branch.ResultMap = "root.result = this.value"
+
+	procConf := processor.NewConfig()
+	procConf.Type = "noop"
+	conf.Branch.Processors = append(conf.Branch.Processors, procConf)
+
+	proc, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+
+	outMsgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"value":"foo"}`),
+		[]byte(`{"value":"bar"}`),
+		[]byte(`{"value":"baz"}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, outMsgs, 1)
+
+	assert.Equal(t, int64(0), mockMetrics.GetCounters()["processor_in_flight"])
+
+	batchSize := mockMetrics.GetTimings()["processor_batch_size"]
+	require.NotNil(t, batchSize)
+	assert.Equal(t, int64(1), batchSize.Count())
+	assert.Equal(t, int64(3), batchSize.Max())
+
+	proc.CloseAsync()
+	assert.NoError(t, proc.WaitForClose(time.Second))
+}
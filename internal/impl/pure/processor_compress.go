@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4/v4"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
@@ -32,11 +33,11 @@ func init() {
 		},
 		Summary: `
 Compresses messages according to the selected algorithm. Supported compression
-algorithms are: gzip, zlib, flate, snappy, lz4.`,
+algorithms are: gzip, zlib, flate, snappy, lz4, zstd.`,
 		Description: `
 The 'level' field might not apply to all algorithms.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("algorithm", "The compression algorithm to use.").HasOptions("gzip", "zlib", "flate", "snappy", "lz4"),
+			docs.FieldString("algorithm", "The compression algorithm to use.").HasOptions("gzip", "zlib", "flate", "snappy", "lz4", "zstd"),
 			docs.FieldInt("level", "The level of compression to use. May not be applicable to all algorithms."),
 		).ChildDefaultAndTypesFromStruct(processor.NewCompressConfig()),
 	})
@@ -121,6 +122,19 @@ func lz4Compress(level int, b []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+func zstdCompress(level int, b []byte) ([]byte, error) {
+	var opts []zstd.EOption
+	if level > 0 {
+		opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	}
+	w, err := zstd.NewWriter(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+	return w.EncodeAll(b, nil), nil
+}
+
 func strToCompressor(str string) (compressFunc, error) {
 	switch str {
 	case "gzip":
@@ -133,6 +147,8 @@ func strToCompressor(str string) (compressFunc, error) {
 		return snappyCompress, nil
 	case "lz4":
 		return lz4Compress, nil
+	case "zstd":
+		return zstdCompress, nil
 	}
 	return nil, fmt.Errorf("compression type not recognised: %v", str)
 }
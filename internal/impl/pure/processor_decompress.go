@@ -11,6 +11,7 @@ import (
 	"io"
 
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4/v4"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
@@ -34,9 +35,9 @@ func init() {
 		},
 		Summary: `
 Decompresses messages according to the selected algorithm. Supported
-decompression types are: gzip, zlib, bzip2, flate, snappy, lz4.`,
+decompression types are: gzip, zlib, bzip2, flate, snappy, lz4, zstd.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("algorithm", "The decompression algorithm to use.").HasOptions("gzip", "zlib", "bzip2", "flate", "snappy", "lz4"),
+			docs.FieldString("algorithm", "The decompression algorithm to use.").HasOptions("gzip", "zlib", "bzip2", "flate", "snappy", "lz4", "zstd"),
 		).ChildDefaultAndTypesFromStruct(processor.NewDecompressConfig()),
 	})
 	if err != nil {
@@ -114,6 +115,15 @@ func lz4Decompress(b []byte) ([]byte, error) {
 	return outBuf.Bytes(), nil
 }
 
+func zstdDecompress(b []byte) ([]byte, error) {
+	r, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return r.DecodeAll(b, nil)
+}
+
 func strToDecompressor(str string) (decompressFunc, error) {
 	switch str {
 	case "gzip":
@@ -128,6 +138,8 @@ func strToDecompressor(str string) (decompressFunc, error) {
 		return snappyDecompress, nil
 	case "lz4":
 		return lz4Decompress, nil
+	case "zstd":
+		return zstdDecompress, nil
 	}
 	return nil, fmt.Errorf("decompression type not recognised: %v", str)
 }
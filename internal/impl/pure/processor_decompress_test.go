@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4/v4"
 
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -259,3 +260,48 @@ func TestDecompressLZ4(t *testing.T) {
 		t.Errorf("Unexpected output: %s != %s", act, exp)
 	}
 }
+
+func TestDecompressZSTD(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = "decompress"
+	conf.Decompress.Algorithm = "zstd"
+
+	input := [][]byte{
+		[]byte("hello world first part"),
+		[]byte("hello world second part"),
+		[]byte("third part"),
+		[]byte("fourth"),
+		[]byte("5"),
+	}
+
+	exp := [][]byte{}
+
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range input {
+		exp = append(exp, input[i])
+		input[i] = enc.EncodeAll(input[i], nil)
+	}
+	enc.Close()
+
+	if reflect.DeepEqual(input, exp) {
+		t.Fatal("Input and exp output are the same")
+	}
+
+	proc, err := mock.NewManager().NewProcessor(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := proc.ProcessMessage(message.QuickBatch(input))
+	if len(msgs) != 1 {
+		t.Error("Decompress failed")
+	} else if res != nil {
+		t.Errorf("Expected nil response: %v", res)
+	}
+	if act := message.GetAllBytes(msgs[0]); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Unexpected output: %s != %s", act, exp)
+	}
+}
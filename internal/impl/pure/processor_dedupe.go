@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
@@ -13,6 +17,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
@@ -42,11 +47,30 @@ This processor enacts on individual messages only, in order to perform a dedupli
 
 Performing deduplication on a stream using a distributed cache voids any at-least-once guarantees that it previously had. This is because the cache will preserve message signatures even if the message fails to leave the Benthos pipeline, which would cause message loss in the event of an outage at the output sink followed by a restart of the Benthos instance (or a server crash, etc).
 
-This problem can be mitigated by using an in-memory cache and distributing messages to horizontally scaled Benthos pipelines partitioned by the deduplication key. However, in situations where at-least-once delivery guarantees are important it is worth avoiding deduplication in favour of implement idempotent behaviour at the edge of your stream pipelines.`,
+This problem can be mitigated by using an in-memory cache and distributing messages to horizontally scaled Benthos pipelines partitioned by the deduplication key. However, in situations where at-least-once delivery guarantees are important it is worth avoiding deduplication in favour of implement idempotent behaviour at the edge of your stream pipelines.
+
+## Probabilistic Filters
+
+Storing every key within a cache can become expensive when deduplicating streams with a very large number of distinct keys. Setting ` + "`filter.type`" + ` to either ` + "`bloom`" + ` or ` + "`cuckoo`" + ` instead tracks keys within a probabilistic filter held in memory, in which case the ` + "`cache`" + ` field is not required. Filters use a fixed amount of memory that is determined by the ` + "`filter.capacity`" + ` and ` + "`filter.false_positive_rate`" + ` fields, at the cost of dropping a small proportion of unique messages that are mistaken for duplicates. Keys are never removed from a filter, and once a ` + "`cuckoo`" + ` filter reaches its capacity any new keys result in an error that is handled according to ` + "`drop_on_err`" + `, whereas a ` + "`bloom`" + ` filter continues to accept keys with a steadily increasing false positive rate.
+
+The state of a filter can be persisted to a file with ` + "`filter.state_path`" + ` or to a cache resource with ` + "`filter.state_cache`" + `, in which case it is saved periodically and during shutdown, and loaded when the processor is created. This allows deduplication to continue across restarts. A persisted state that was produced with a different filter type, capacity or false positive rate is ignored.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("cache", "The [`cache` resource](/docs/components/caches/about) to target with this processor."),
 			docs.FieldString("key", "An interpolated string yielding the key to deduplicate by for each message.", `${! meta("kafka_key") }`, `${! content().hash("xxhash64") }`).IsInterpolated(),
 			docs.FieldBool("drop_on_err", "Whether messages should be dropped when the cache returns a general error such as a network issue."),
+			docs.FieldObject("filter", "Optionally deduplicate keys with a [probabilistic filter](#probabilistic-filters) instead of a cache.").WithChildren(
+				docs.FieldString("type", "The type of filter to use.").HasAnnotatedOptions(
+					"none", "Deduplicate with the `cache` resource.",
+					"bloom", "A bloom filter, which is the most compact for a given false positive rate.",
+					"cuckoo", "A cuckoo filter, which is faster than a bloom filter for low false positive rates but has a hard capacity limit.",
+				),
+				docs.FieldInt("capacity", "The number of distinct keys that the filter is sized to hold."),
+				docs.FieldFloat("false_positive_rate", "The probability, once the filter holds `capacity` keys, of a unique key being mistaken for a duplicate."),
+				docs.FieldString("state_path", "An optional file path to persist the state of the filter to."),
+				docs.FieldString("state_cache", "An optional [`cache` resource](/docs/components/caches/about) to persist the state of the filter to."),
+				docs.FieldString("state_key", "The key under which the state of the filter is stored within the `state_cache`.").Advanced(),
+				docs.FieldString("state_interval", "The period at which the state of the filter is persisted, it is also persisted during shutdown.").Advanced(),
+			).AtVersion("4.2.0"),
 		).ChildDefaultAndTypesFromStruct(processor.NewDedupeConfig()),
 		Examples: []docs.AnnotatedExample{
 			{
@@ -63,6 +87,21 @@ cache_resources:
   - label: keycache
     memory:
       default_ttl: 60s
+`,
+			},
+			{
+				Title:   "Deduplicate with a persisted bloom filter",
+				Summary: "The following configuration deduplicates messages by their ID within a bloom filter sized for one hundred million keys, which is persisted to disk in order to survive restarts.",
+				Config: `
+pipeline:
+  processors:
+    - dedupe:
+        key: ${! json("id") }
+        filter:
+          type: bloom
+          capacity: 100000000
+          false_positive_rate: 0.0001
+          state_path: /var/lib/benthos/dedupe.filter
 `,
 			},
 		},
@@ -79,6 +118,15 @@ type dedupeProc struct {
 	key       *field.Expression
 	mgr       bundle.NewManagement
 	cacheName string
+
+	filter      dedupeFilter
+	filterMut   sync.Mutex
+	filterDirty bool
+	statePath   string
+	stateCache  string
+	stateKey    string
+
+	shutSig *shutdown.Signaller
 }
 
 func newDedupe(conf processor.DedupeConfig, mgr bundle.NewManagement) (*dedupeProc, error) {
@@ -90,33 +138,178 @@ func newDedupe(conf processor.DedupeConfig, mgr bundle.NewManagement) (*dedupePr
 		return nil, fmt.Errorf("failed to parse key expression: %v", err)
 	}
 
-	if !mgr.ProbeCache(conf.Cache) {
-		return nil, fmt.Errorf("cache resource '%v' was not found", conf.Cache)
+	d := &dedupeProc{
+		log:        mgr.Logger(),
+		dropOnErr:  conf.DropOnCacheErr,
+		key:        key,
+		mgr:        mgr,
+		cacheName:  conf.Cache,
+		statePath:  conf.Filter.StatePath,
+		stateCache: conf.Filter.StateCache,
+		stateKey:   conf.Filter.StateKey,
+		shutSig:    shutdown.NewSignaller(),
+	}
+
+	if conf.Filter.Type == "" || conf.Filter.Type == "none" {
+		if !mgr.ProbeCache(conf.Cache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", conf.Cache)
+		}
+		return d, nil
 	}
 
-	return &dedupeProc{
-		log:       mgr.Logger(),
-		dropOnErr: conf.DropOnCacheErr,
-		key:       key,
-		mgr:       mgr,
-		cacheName: conf.Cache,
-	}, nil
+	if d.filter, err = newDedupeFilter(conf.Filter.Type, conf.Filter.Capacity, conf.Filter.FalsePositiveRate); err != nil {
+		return nil, err
+	}
+	if d.stateCache != "" && !mgr.ProbeCache(d.stateCache) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", d.stateCache)
+	}
+	if d.statePath == "" && d.stateCache == "" {
+		return d, nil
+	}
+
+	interval, err := time.ParseDuration(conf.Filter.StateInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse filter state interval: %v", err)
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid filter state interval: %v", interval)
+	}
+	if err := d.loadFilterState(); err != nil {
+		return nil, err
+	}
+	go d.persistLoop(interval)
+	return d, nil
 }
 
 //------------------------------------------------------------------------------
 
-func (d *dedupeProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, batch *message.Batch) ([]*message.Batch, error) {
-	newBatch := message.QuickBatch(nil)
-	_ = batch.Iter(func(i int, p *message.Part) error {
-		key := d.key.String(i, batch)
+func (d *dedupeProc) loadFilterState() error {
+	var state []byte
+	if d.statePath != "" {
+		var err error
+		if state, err = os.ReadFile(d.statePath); err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to read filter state: %w", err)
+		}
+	} else {
+		var err error
+		if cerr := d.mgr.AccessCache(context.Background(), d.stateCache, func(c cache.V1) {
+			state, err = c.Get(context.Background(), d.stateKey)
+		}); cerr != nil {
+			err = cerr
+		}
+		if err != nil {
+			if err == component.ErrKeyNotFound {
+				return nil
+			}
+			return fmt.Errorf("failed to read filter state: %w", err)
+		}
+	}
+
+	if err := d.filter.UnmarshalBinary(state); err != nil {
+		d.log.Warnf("Ignoring persisted filter state: %v\n", err)
+	}
+	return nil
+}
 
+func (d *dedupeProc) saveFilterState(ctx context.Context) error {
+	d.filterMut.Lock()
+	if !d.filterDirty {
+		d.filterMut.Unlock()
+		return nil
+	}
+	state, err := d.filter.MarshalBinary()
+	d.filterDirty = false
+	d.filterMut.Unlock()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			d.filterMut.Lock()
+			d.filterDirty = true
+			d.filterMut.Unlock()
+		}
+	}()
+
+	if d.statePath != "" {
+		// Write to a temporary file first so that a crash mid-write never
+		// leaves a corrupted state behind.
+		tmpPath := filepath.Join(filepath.Dir(d.statePath), "."+filepath.Base(d.statePath)+".tmp")
+		if err = os.WriteFile(tmpPath, state, 0o644); err != nil {
+			return err
+		}
+		err = os.Rename(tmpPath, d.statePath)
+		return err
+	}
+
+	if cerr := d.mgr.AccessCache(ctx, d.stateCache, func(c cache.V1) {
+		err = c.Set(ctx, d.stateKey, state, nil)
+	}); cerr != nil {
+		err = cerr
+	}
+	return err
+}
+
+func (d *dedupeProc) persistLoop(interval time.Duration) {
+	defer d.shutSig.ShutdownComplete()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-d.shutSig.CloseAtLeisureChan():
+			ctx, done := d.shutSig.CloseNowCtx(context.Background())
+			if err := d.saveFilterState(ctx); err != nil {
+				d.log.Errorf("Failed to persist filter state: %v\n", err)
+			}
+			done()
+			return
+		}
+		if err := d.saveFilterState(context.Background()); err != nil {
+			d.log.Errorf("Failed to persist filter state: %v\n", err)
+		}
+	}
+}
+
+func (d *dedupeProc) addKey(key string) error {
+	if d.filter == nil {
 		var err error
 		if cerr := d.mgr.AccessCache(context.Background(), d.cacheName, func(cache cache.V1) {
 			err = cache.Add(context.Background(), key, []byte{'t'}, nil)
 		}); cerr != nil {
 			err = cerr
 		}
-		if err != nil {
+		return err
+	}
+
+	d.filterMut.Lock()
+	defer d.filterMut.Unlock()
+
+	exists, err := d.filter.TestAndAdd([]byte(key))
+	if err != nil {
+		return err
+	}
+	if exists {
+		return component.ErrKeyAlreadyExists
+	}
+	d.filterDirty = true
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *dedupeProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, batch *message.Batch) ([]*message.Batch, error) {
+	newBatch := message.QuickBatch(nil)
+	_ = batch.Iter(func(i int, p *message.Part) error {
+		key := d.key.String(i, batch)
+
+		if err := d.addKey(key); err != nil {
 			if err == component.ErrKeyAlreadyExists {
 				spans[i].LogKV(
 					"event", "dropped",
@@ -125,7 +318,11 @@ func (d *dedupeProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, ba
 				return nil
 			}
 
-			d.log.Errorf("Cache error: %v\n", err)
+			if d.filter != nil {
+				d.log.Errorf("Filter error: %v\n", err)
+			} else {
+				d.log.Errorf("Cache error: %v\n", err)
+			}
 			if d.dropOnErr {
 				spans[i].LogKV(
 					"event", "dropped",
@@ -148,6 +345,16 @@ func (d *dedupeProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, ba
 	return []*message.Batch{newBatch}, nil
 }
 
-func (d *dedupeProc) Close(context.Context) error {
+func (d *dedupeProc) Close(ctx context.Context) error {
+	if d.filter == nil || (d.statePath == "" && d.stateCache == "") {
+		return nil
+	}
+	d.shutSig.CloseAtLeisure()
+	select {
+	case <-d.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		d.shutSig.CloseNow()
+		return ctx.Err()
+	}
 	return nil
 }
@@ -0,0 +1,269 @@
+package pure
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/OneOfOne/xxhash"
+)
+
+var errDedupeFilterFull = errors.New("filter has reached its capacity")
+
+// dedupeFilter is a probabilistic set of keys that is used for deduplicating
+// messages with a bounded amount of memory. Filters may report false
+// positives, but never false negatives.
+type dedupeFilter interface {
+	// TestAndAdd adds a key to the filter and returns true if the key was
+	// already present.
+	TestAndAdd(key []byte) (bool, error)
+
+	// MarshalBinary encodes the state of the filter.
+	MarshalBinary() ([]byte, error)
+
+	// UnmarshalBinary replaces the state of the filter with a previously
+	// encoded state. An error is returned if the encoded state was produced by
+	// a filter of a different type or size, in which case the filter is left
+	// unchanged.
+	UnmarshalBinary(data []byte) error
+}
+
+func newDedupeFilter(kind string, capacity int, fpRate float64) (dedupeFilter, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("invalid filter capacity: %v", capacity)
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, fmt.Errorf("invalid filter false positive rate: %v", fpRate)
+	}
+	switch kind {
+	case "bloom":
+		return newBloomFilter(capacity, fpRate), nil
+	case "cuckoo":
+		return newCuckooFilter(capacity, fpRate), nil
+	}
+	return nil, fmt.Errorf("filter type not recognised: %v", kind)
+}
+
+// Each encoded filter state begins with a byte identifying the filter type
+// followed by the parameters of the filter, which must match those of the
+// filter that is decoding it.
+const (
+	dedupeFilterBloom  byte = 'b'
+	dedupeFilterCuckoo byte = 'c'
+)
+
+func decodeFilterHeader(data []byte, kind byte, params ...uint64) (*bytes.Reader, error) {
+	if len(data) == 0 || data[0] != kind {
+		return nil, errors.New("filter state was produced by a different filter type")
+	}
+	r := bytes.NewReader(data[1:])
+	for _, exp := range params {
+		var v uint64
+		if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+			return nil, fmt.Errorf("failed to read filter state: %w", err)
+		}
+		if v != exp {
+			return nil, errors.New("filter state was produced by a filter with a different capacity or false positive rate")
+		}
+	}
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+
+type bloomFilter struct {
+	hashes uint64
+	bits   []uint64
+}
+
+func newBloomFilter(capacity int, fpRate float64) *bloomFilter {
+	m := math.Ceil(-float64(capacity) * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := math.Round(m / float64(capacity) * math.Ln2)
+	if k < 1 {
+		k = 1
+	}
+	return &bloomFilter{
+		hashes: uint64(k),
+		bits:   make([]uint64, (uint64(m)+63)/64),
+	}
+}
+
+func (b *bloomFilter) TestAndAdd(key []byte) (bool, error) {
+	// Positions are derived from two hashes as described in "Less Hashing,
+	// Same Performance: Building a Better Bloom Filter" by Kirsch and
+	// Mitzenmacher.
+	h1 := xxhash.Checksum64(key)
+	h2 := xxhash.Checksum64S(key, h1) | 1
+
+	size := uint64(len(b.bits)) * 64
+	present := true
+	for i := uint64(0); i < b.hashes; i++ {
+		pos := (h1 + i*h2) % size
+		word, mask := pos/64, uint64(1)<<(pos%64)
+		if b.bits[word]&mask == 0 {
+			present = false
+			b.bits[word] |= mask
+		}
+	}
+	return present, nil
+}
+
+func (b *bloomFilter) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(dedupeFilterBloom)
+	for _, v := range [][]uint64{{b.hashes, uint64(len(b.bits))}, b.bits} {
+		if err := binary.Write(&buf, binary.BigEndian, v); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (b *bloomFilter) UnmarshalBinary(data []byte) error {
+	r, err := decodeFilterHeader(data, dedupeFilterBloom, b.hashes, uint64(len(b.bits)))
+	if err != nil {
+		return err
+	}
+	bits := make([]uint64, len(b.bits))
+	if err := binary.Read(r, binary.BigEndian, bits); err != nil {
+		return fmt.Errorf("failed to read filter state: %w", err)
+	}
+	b.bits = bits
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	cuckooBucketSize = 4
+	cuckooMaxKicks   = 500
+)
+
+// cuckooFilter is an implementation of the filter described in "Cuckoo
+// Filter: Practically Better Than Bloom" by Fan et al. Each bucket holds up to
+// four fingerprints, where an empty slot is represented by a zero.
+type cuckooFilter struct {
+	fpBits     uint64
+	bucketMask uint64
+	slots      []uint32
+
+	// When an insert runs out of kicks the evicted fingerprint is kept here,
+	// after which the filter is considered full.
+	victim      uint32
+	victimIndex uint64
+}
+
+func newCuckooFilter(capacity int, fpRate float64) *cuckooFilter {
+	fpBits := math.Ceil(math.Log2(2 * cuckooBucketSize / fpRate))
+	if fpBits < 4 {
+		fpBits = 4
+	} else if fpBits > 32 {
+		fpBits = 32
+	}
+
+	// Inserts begin to fail as the load of a filter approaches 95%, so we
+	// leave some headroom.
+	buckets := uint64(1)
+	for target := uint64(math.Ceil(float64(capacity) / cuckooBucketSize / 0.9)); buckets < target; {
+		buckets <<= 1
+	}
+	return &cuckooFilter{
+		fpBits:     uint64(fpBits),
+		bucketMask: buckets - 1,
+		slots:      make([]uint32, buckets*cuckooBucketSize),
+	}
+}
+
+func (c *cuckooFilter) fingerprint(key []byte) (fp uint32, index uint64) {
+	h := xxhash.Checksum64(key)
+	if fp = uint32((h >> 32) & (1<<c.fpBits - 1)); fp == 0 {
+		fp = 1
+	}
+	return fp, h & c.bucketMask
+}
+
+func (c *cuckooFilter) altIndex(index uint64, fp uint32) uint64 {
+	return (index ^ (uint64(fp) * 0x5bd1e995)) & c.bucketMask
+}
+
+func (c *cuckooFilter) bucket(index uint64) []uint32 {
+	return c.slots[index*cuckooBucketSize : (index+1)*cuckooBucketSize]
+}
+
+func (c *cuckooFilter) contains(index uint64, fp uint32) bool {
+	for _, v := range c.bucket(index) {
+		if v == fp {
+			return true
+		}
+	}
+	return c.victim == fp && c.victimIndex == index
+}
+
+func (c *cuckooFilter) insert(index uint64, fp uint32) bool {
+	b := c.bucket(index)
+	for i, v := range b {
+		if v == 0 {
+			b[i] = fp
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cuckooFilter) TestAndAdd(key []byte) (bool, error) {
+	fp, i1 := c.fingerprint(key)
+	i2 := c.altIndex(i1, fp)
+	if c.contains(i1, fp) || c.contains(i2, fp) {
+		return true, nil
+	}
+	if c.victim != 0 {
+		return false, errDedupeFilterFull
+	}
+	if c.insert(i1, fp) || c.insert(i2, fp) {
+		return false, nil
+	}
+
+	index := i2
+	for n := 0; n < cuckooMaxKicks; n++ {
+		slot := index*cuckooBucketSize + uint64(n%cuckooBucketSize)
+		fp, c.slots[slot] = c.slots[slot], fp
+		if index = c.altIndex(index, fp); c.insert(index, fp) {
+			return false, nil
+		}
+	}
+	c.victim, c.victimIndex = fp, index
+	return false, nil
+}
+
+func (c *cuckooFilter) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(dedupeFilterCuckoo)
+	for _, v := range []interface{}{
+		[]uint64{c.fpBits, c.bucketMask + 1},
+		c.victim, c.victimIndex, c.slots,
+	} {
+		if err := binary.Write(&buf, binary.BigEndian, v); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *cuckooFilter) UnmarshalBinary(data []byte) error {
+	r, err := decodeFilterHeader(data, dedupeFilterCuckoo, c.fpBits, c.bucketMask+1)
+	if err != nil {
+		return err
+	}
+	var victim uint32
+	var victimIndex uint64
+	slots := make([]uint32, len(c.slots))
+	for _, v := range []interface{}{&victim, &victimIndex, slots} {
+		if err := binary.Read(r, binary.BigEndian, v); err != nil {
+			return fmt.Errorf("failed to read filter state: %w", err)
+		}
+	}
+	c.victim, c.victimIndex, c.slots = victim, victimIndex, slots
+	return nil
+}
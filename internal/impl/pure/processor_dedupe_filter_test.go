@@ -0,0 +1,113 @@
+package pure
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupeFilters(t *testing.T) {
+	for _, kind := range []string{"bloom", "cuckoo"} {
+		t.Run(kind, func(t *testing.T) {
+			f, err := newDedupeFilter(kind, 10000, 0.001)
+			require.NoError(t, err)
+
+			for i := 0; i < 10000; i++ {
+				_, err := f.TestAndAdd([]byte("key" + strconv.Itoa(i)))
+				require.NoError(t, err)
+			}
+			for i := 0; i < 10000; i++ {
+				exists, err := f.TestAndAdd([]byte("key" + strconv.Itoa(i)))
+				require.NoError(t, err)
+				require.True(t, exists, i)
+			}
+
+			// Testing keys also adds them, so we only test a small number in
+			// order to avoid overloading the filter.
+			falsePositives := 0
+			for i := 0; i < 1000; i++ {
+				exists, err := f.TestAndAdd([]byte("other" + strconv.Itoa(i)))
+				require.NoError(t, err)
+				if exists {
+					falsePositives++
+				}
+			}
+			assert.Less(t, falsePositives, 10)
+		})
+	}
+}
+
+func TestDedupeFilterState(t *testing.T) {
+	for _, kind := range []string{"bloom", "cuckoo"} {
+		t.Run(kind, func(t *testing.T) {
+			f, err := newDedupeFilter(kind, 1000, 0.001)
+			require.NoError(t, err)
+
+			for _, k := range []string{"foo", "bar"} {
+				_, err = f.TestAndAdd([]byte(k))
+				require.NoError(t, err)
+			}
+
+			state, err := f.MarshalBinary()
+			require.NoError(t, err)
+
+			g, err := newDedupeFilter(kind, 1000, 0.001)
+			require.NoError(t, err)
+			require.NoError(t, g.UnmarshalBinary(state))
+
+			for k, exp := range map[string]bool{"foo": true, "bar": true, "baz": false} {
+				exists, err := g.TestAndAdd([]byte(k))
+				require.NoError(t, err)
+				assert.Equal(t, exp, exists, k)
+			}
+
+			h, err := newDedupeFilter(kind, 2000, 0.001)
+			require.NoError(t, err)
+			require.Error(t, h.UnmarshalBinary(state))
+
+			require.Error(t, g.UnmarshalBinary(state[:len(state)-1]))
+			require.Error(t, g.UnmarshalBinary(nil))
+		})
+	}
+
+	b, err := newDedupeFilter("bloom", 1000, 0.001)
+	require.NoError(t, err)
+	c, err := newDedupeFilter("cuckoo", 1000, 0.001)
+	require.NoError(t, err)
+
+	state, err := b.MarshalBinary()
+	require.NoError(t, err)
+	require.Error(t, c.UnmarshalBinary(state))
+}
+
+func TestDedupeCuckooFilterFull(t *testing.T) {
+	f, err := newDedupeFilter("cuckoo", 100, 0.001)
+	require.NoError(t, err)
+
+	var i int
+	for i = 0; i < 10000; i++ {
+		if _, err = f.TestAndAdd([]byte("key" + strconv.Itoa(i))); err != nil {
+			break
+		}
+	}
+	require.Equal(t, errDedupeFilterFull, err)
+	assert.Greater(t, i, 100)
+
+	// Keys that were added before the filter filled up are still found.
+	exists, err := f.TestAndAdd([]byte("key0"))
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestDedupeFilterBadConfig(t *testing.T) {
+	_, err := newDedupeFilter("nope", 100, 0.001)
+	require.Error(t, err)
+
+	_, err = newDedupeFilter("bloom", 0, 0.001)
+	require.Error(t, err)
+
+	_, err = newDedupeFilter("bloom", 100, 1)
+	require.Error(t, err)
+}
@@ -1,7 +1,10 @@
 package pure_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -94,3 +97,119 @@ func TestDedupeCacheErrors(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, msgs, 1)
 }
+
+func dedupeFilterTestContents(t *testing.T, proc interface {
+	ProcessMessage(*message.Batch) ([]*message.Batch, error)
+}, docs ...string) []string {
+	t.Helper()
+
+	var parts [][]byte
+	for _, d := range docs {
+		parts = append(parts, []byte(d))
+	}
+	msgs, err := proc.ProcessMessage(message.QuickBatch(parts))
+	require.NoError(t, err)
+
+	var res []string
+	for _, m := range msgs {
+		_ = m.Iter(func(i int, p *message.Part) error {
+			res = append(res, string(p.Get()))
+			return nil
+		})
+	}
+	return res
+}
+
+func TestDedupeFilter(t *testing.T) {
+	for _, kind := range []string{"bloom", "cuckoo"} {
+		t.Run(kind, func(t *testing.T) {
+			conf := processor.NewConfig()
+			conf.Type = "dedupe"
+			conf.Dedupe.Key = "${! content() }"
+			conf.Dedupe.Filter.Type = kind
+			conf.Dedupe.Filter.Capacity = 100
+
+			proc, err := mock.NewManager().NewProcessor(conf)
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"foo", "bar"}, dedupeFilterTestContents(t, proc, "foo", "bar", "foo"))
+			assert.Equal(t, []string{"baz"}, dedupeFilterTestContents(t, proc, "bar", "baz"))
+
+			proc.CloseAsync()
+			require.NoError(t, proc.WaitForClose(time.Second))
+		})
+	}
+}
+
+func TestDedupeFilterStatePath(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = "dedupe"
+	conf.Dedupe.Key = "${! content() }"
+	conf.Dedupe.Filter.Type = "bloom"
+	conf.Dedupe.Filter.Capacity = 100
+	conf.Dedupe.Filter.StatePath = filepath.Join(t.TempDir(), "dedupe.filter")
+	conf.Dedupe.Filter.StateInterval = "10ms"
+
+	mgr := mock.NewManager()
+
+	proc, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, dedupeFilterTestContents(t, proc, "foo", "bar"))
+
+	// The state is persisted periodically.
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(conf.Dedupe.Filter.StatePath)
+		return err == nil
+	}, time.Second, time.Millisecond*10)
+
+	proc.CloseAsync()
+	require.NoError(t, proc.WaitForClose(time.Second))
+
+	proc, err = mgr.NewProcessor(conf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"baz"}, dedupeFilterTestContents(t, proc, "foo", "bar", "baz"))
+
+	proc.CloseAsync()
+	require.NoError(t, proc.WaitForClose(time.Second))
+
+	// A state from a differently sized filter is ignored.
+	conf.Dedupe.Filter.Capacity = 200
+	proc, err = mgr.NewProcessor(conf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, dedupeFilterTestContents(t, proc, "foo"))
+
+	proc.CloseAsync()
+	require.NoError(t, proc.WaitForClose(time.Second))
+}
+
+func TestDedupeFilterStateCache(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = "dedupe"
+	conf.Dedupe.Key = "${! content() }"
+	conf.Dedupe.Filter.Type = "cuckoo"
+	conf.Dedupe.Filter.Capacity = 100
+	conf.Dedupe.Filter.StateCache = "foocache"
+
+	mgr := mock.NewManager()
+
+	_, err := mgr.NewProcessor(conf)
+	require.Error(t, err)
+
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	proc, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, dedupeFilterTestContents(t, proc, "foo", "bar"))
+
+	proc.CloseAsync()
+	require.NoError(t, proc.WaitForClose(time.Second))
+	_, exists := mgr.Caches["foocache"]["benthos_dedupe_filter"]
+	require.True(t, exists)
+
+	proc, err = mgr.NewProcessor(conf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"baz"}, dedupeFilterTestContents(t, proc, "foo", "bar", "baz"))
+
+	proc.CloseAsync()
+	require.NoError(t, proc.WaitForClose(time.Second))
+}
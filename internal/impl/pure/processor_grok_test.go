@@ -81,6 +81,12 @@ func TestGrok(t *testing.T) {
 			pattern: "%{WORD:nested.name} %{INT:nested.value:int} bazes from %{IPV4:nested.ipv4}",
 			output:  `{"nested":{"ipv4":"192.0.1.11","name":"foo","value":5}}`,
 		},
+		{
+			name:    "Test int and float type hints",
+			input:   `GET /foo 200 0.25`,
+			pattern: "%{WORD:verb} %{URIPATH:path} %{INT:status:int} %{NUMBER:duration:float}",
+			output:  `{"duration":0.25,"path":"/foo","status":200,"verb":"GET"}`,
+		},
 	}
 
 	for _, test := range tests {
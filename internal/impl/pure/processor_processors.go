@@ -0,0 +1,102 @@
+package pure
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func processorsProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Stable().
+		Categories("Composition", "Utility").
+		Summary("A processor grouping several sub-processors.").
+		Description(`
+This processor is useful in situations where you want to collect several processors under a single resource identifier, whether it is for making your configuration easier to read and navigate, or for improving the testability of your configuration. The behaviour of child processors will match exactly the behaviour they would have under any other processors block.
+
+Since only one instance of each processor resource is created, referencing the same chain from several places (including multiple streams in [streams mode](/docs/guides/streams_mode/about)) avoids duplicating both the configuration and the memory used by its processors.`).
+		Field(service.NewProcessorListField("").Default([]interface{}{})).
+		Example("Grouped Processing", `
+Imagine we have a collection of processors who cover a specific functionality. We could use this processor to group them together and make it easier to read and mock during testing by giving the whole block a label:`, `
+pipeline:
+  processors:
+    - processors:
+        - label: my_super_feature
+          log:
+            message: "Let's do something cool"
+        - archive:
+            format: json_array
+        - bloblang: root.items = this
+`).
+		Example("Shared Resources", `
+A chain of processors that is needed in several places can be defined once as a resource and then referenced by name with the `+"[`resource` processor](/docs/components/processors/resource)"+`:`, `
+pipeline:
+  processors:
+    - resource: normalise
+    - switch:
+        - check: this.type == "legacy"
+          processors:
+            - bloblang: root = this.payload.parse_json()
+            - resource: normalise
+
+processor_resources:
+  - label: normalise
+    processors:
+      - bloblang: |
+          root = this
+          root.name = this.name.lowercase()
+      - log:
+          message: 'Normalised ${! json("name") }'
+`).
+		Version("4.2.0")
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"processors", processorsProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newProcessorsFromParsed(conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type processorsProc struct {
+	children []*service.OwnedProcessor
+}
+
+func newProcessorsFromParsed(conf *service.ParsedConfig) (*processorsProc, error) {
+	children, err := conf.FieldProcessorList()
+	if err != nil {
+		return nil, err
+	}
+	return &processorsProc{children: children}, nil
+}
+
+func (p *processorsProc) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	batches := []service.MessageBatch{batch}
+	for i := 0; len(batches) > 0 && i < len(p.children); i++ {
+		var nextBatches []service.MessageBatch
+		for _, b := range batches {
+			res, err := p.children[i].ProcessBatch(ctx, b)
+			if err != nil {
+				return nil, err
+			}
+			nextBatches = append(nextBatches, res...)
+		}
+		batches = nextBatches
+	}
+	return batches, nil
+}
+
+func (p *processorsProc) Close(ctx context.Context) error {
+	for _, c := range p.children {
+		if err := c.Close(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestProcessorsChain(t *testing.T) {
+	conf, err := processorsProcConfig().ParseYAML(`
+- bloblang: 'root = content().uppercase()'
+- bloblang: 'root = content() + " WORLD"'
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newProcessorsFromParsed(conf)
+	require.NoError(t, err)
+
+	res, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("hello")),
+		service.NewMessage([]byte("goodbye")),
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 2)
+
+	for i, exp := range []string{"HELLO WORLD", "GOODBYE WORLD"} {
+		b, err := res[0][i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(b))
+	}
+
+	require.NoError(t, proc.Close(context.Background()))
+}
+
+func TestProcessorsDropped(t *testing.T) {
+	conf, err := processorsProcConfig().ParseYAML(`
+- bloblang: 'root = deleted()'
+- bloblang: 'root = "should not run"'
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newProcessorsFromParsed(conf)
+	require.NoError(t, err)
+
+	res, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("hello")),
+	})
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	require.NoError(t, proc.Close(context.Background()))
+}
+
+func TestProcessorsEmpty(t *testing.T) {
+	conf, err := processorsProcConfig().ParseYAML(`[]`, nil)
+	require.NoError(t, err)
+
+	proc, err := newProcessorsFromParsed(conf)
+	require.NoError(t, err)
+
+	res, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte("hello")),
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 1)
+
+	b, err := res[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+}
@@ -1,11 +1,15 @@
 package pure
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -17,7 +21,9 @@ import (
 	"github.com/golang/protobuf/jsonpb"
 	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
 	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 
+	"github.com/jhump/protoreflect/codec"
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/desc/protoparse"
 	"github.com/jhump/protoreflect/dynamic"
@@ -60,11 +66,24 @@ to manipulate the contents of the document within Benthos.
 
 ### ` + "`from_json`" + `
 
-Attempts to create a target protobuf message from a generic JSON structure.`,
+Attempts to create a target protobuf message from a generic JSON structure.
+
+## Descriptors
+
+Message definitions are loaded from the .proto files found within ` + "`import_paths`" + `, and well-known types such as ` + "`google.protobuf.Timestamp`" + ` and ` + "`google.protobuf.Any`" + ` can be imported without being present in those directories. Alternatively, definitions can be loaded from compiled descriptor sets listed in ` + "`descriptor_sets`" + `, which can be generated with ` + "`protoc --include_imports --descriptor_set_out=<path>`" + `. When descriptor sets are specified and ` + "`import_paths`" + ` is empty no .proto files are loaded.
+
+## Unknown Fields
+
+The JSON mapping of protobuf has no representation for fields of a protobuf message that are not present within its definition. In order to preserve them the ` + "`to_json`" + ` operator encodes the unknown fields of each message, including nested messages, in protobuf wire format as a base64 string under the key ` + "`unknown_fields_key`" + ` of the corresponding JSON object. The ` + "`from_json`" + ` operator decodes this key and re-emits the fields, and therefore a message converted to JSON and back again retains its unknown fields. Unknown fields of messages packed within ` + "`google.protobuf.Any`" + ` fields are not preserved. Setting ` + "`unknown_fields_key`" + ` to an empty string disables this behaviour, in which case unknown fields are dropped by ` + "`to_json`" + `.
+
+When converting from JSON the processor fails on any other fields that are not present within the definition unless ` + "`discard_unknown`" + ` is set to ` + "`true`" + `, in which case they are ignored.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json"),
 			docs.FieldString("message", "The fully qualified name of the protobuf message to convert to/from."),
 			docs.FieldString("import_paths", "A list of directories containing .proto files, including all definitions required for parsing the target message. If left empty the current directory is used. Each directory listed will be walked with all found .proto files imported.").Array(),
+			docs.FieldString("descriptor_sets", "A list of paths to compiled protobuf descriptor set files to load message definitions from. Descriptor sets must include the definitions of all imports, including well-known types.", []string{"./schema/person.desc"}).Array().AtVersion("4.2.0"),
+			docs.FieldBool("discard_unknown", "Whether fields of JSON documents that are not present within the message definition should be ignored by the `from_json` operator rather than resulting in an error.").Advanced().AtVersion("4.2.0"),
+			docs.FieldString("unknown_fields_key", "The key of JSON objects under which [unknown fields](#unknown-fields) of protobuf messages are stored by the `to_json` operator and read by the `from_json` operator. Set to an empty string in order to drop unknown fields.").Advanced().AtVersion("4.2.0"),
 		).ChildDefaultAndTypesFromStruct(processor.NewProtobufConfig()),
 		Examples: []docs.AnnotatedExample{
 			{
@@ -162,21 +181,7 @@ pipeline:
 
 type protobufOperator func(part *message.Part) error
 
-func newProtobufToJSONOperator(msg string, importPaths []string) (protobufOperator, error) {
-	if msg == "" {
-		return nil, errors.New("message field must not be empty")
-	}
-
-	descriptors, err := loadDescriptors(importPaths)
-	if err != nil {
-		return nil, err
-	}
-
-	m := getMessageFromDescriptors(msg, descriptors)
-	if m == nil {
-		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", msg, importPaths)
-	}
-
+func newProtobufToJSONOperator(m *desc.MessageDescriptor, descriptors []*desc.FileDescriptor, unknownKey string) protobufOperator {
 	marshaller := &jsonpb.Marshaler{
 		AnyResolver: dynamic.AnyResolver(dynamic.NewMessageFactoryWithDefaults(), descriptors...),
 	}
@@ -192,36 +197,57 @@ func newProtobufToJSONOperator(msg string, importPaths []string) (protobufOperat
 			return fmt.Errorf("failed to marshal protobuf message: %w", err)
 		}
 
+		if unknownKey != "" && hasUnknownFields(msg) {
+			if data, err = addUnknownFieldsToJSON(msg, data, unknownKey); err != nil {
+				return fmt.Errorf("failed to encode unknown fields: %w", err)
+			}
+		}
+
 		part.Set(data)
 		return nil
-	}, nil
-}
-
-func newProtobufFromJSONOperator(msg string, importPaths []string) (protobufOperator, error) {
-	if msg == "" {
-		return nil, errors.New("message field must not be empty")
-	}
-
-	descriptors, err := loadDescriptors(importPaths)
-	if err != nil {
-		return nil, err
 	}
+}
 
-	m := getMessageFromDescriptors(msg, descriptors)
-	if m == nil {
-		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", msg, importPaths)
+func newProtobufFromJSONOperator(m *desc.MessageDescriptor, descriptors []*desc.FileDescriptor, discardUnknown bool, unknownKey string) protobufOperator {
+	unmarshaler := &jsonpb.Unmarshaler{
+		AllowUnknownFields: discardUnknown,
+		AnyResolver:        dynamic.AnyResolver(dynamic.NewMessageFactoryWithDefaults(), descriptors...),
 	}
 
-	unmarshaler := &jsonpb.Unmarshaler{
-		AnyResolver: dynamic.AnyResolver(dynamic.NewMessageFactoryWithDefaults(), descriptors...),
+	var quotedKey []byte
+	if unknownKey != "" {
+		quotedKey, _ = json.Marshal(unknownKey)
 	}
 
 	return func(part *message.Part) error {
+		data := part.Get()
+
+		// Only documents that mention the key are walked for unknown fields,
+		// which avoids parsing all other documents twice.
+		var doc interface{}
+		if len(quotedKey) > 0 && bytes.Contains(data, quotedKey) {
+			var err error
+			if doc, err = decodeJSONNumbers(data); err != nil {
+				return fmt.Errorf("failed to unmarshal JSON message: %w", err)
+			}
+			if data, err = removeUnknownFieldsKey(data, unknownKey); err != nil {
+				return fmt.Errorf("failed to unmarshal JSON message: %w", err)
+			}
+		}
+
 		msg := dynamic.NewMessage(m)
-		if err := msg.UnmarshalJSONPB(unmarshaler, part.Get()); err != nil {
+		if err := msg.UnmarshalJSONPB(unmarshaler, data); err != nil {
 			return fmt.Errorf("failed to unmarshal JSON message: %w", err)
 		}
 
+		if obj, ok := doc.(map[string]interface{}); ok {
+			if err := walkMessageJSON(msg, obj, func(msg *dynamic.Message, obj map[string]interface{}) error {
+				return restoreUnknownFields(msg, obj, unknownKey)
+			}); err != nil {
+				return fmt.Errorf("failed to decode unknown fields: %w", err)
+			}
+		}
+
 		data, err := msg.Marshal()
 		if err != nil {
 			return fmt.Errorf("failed to marshal protobuf message: %v", err)
@@ -229,17 +255,260 @@ func newProtobufFromJSONOperator(msg string, importPaths []string) (protobufOper
 
 		part.Set(data)
 		return nil
-	}, nil
+	}
+}
+
+func newProtobufOperator(conf processor.ProtobufConfig) (protobufOperator, error) {
+	if conf.Operator != "to_json" && conf.Operator != "from_json" {
+		return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
+	}
+	if conf.Message == "" {
+		return nil, errors.New("message field must not be empty")
+	}
+
+	var descriptors []*desc.FileDescriptor
+	if len(conf.ImportPaths) > 0 || len(conf.DescriptorSets) == 0 {
+		fds, err := loadDescriptors(conf.ImportPaths)
+		if err != nil {
+			return nil, err
+		}
+		descriptors = append(descriptors, fds...)
+	}
+	for _, path := range conf.DescriptorSets {
+		fds, err := loadDescriptorSet(path)
+		if err != nil {
+			return nil, err
+		}
+		descriptors = append(descriptors, fds...)
+	}
+
+	m := getMessageFromDescriptors(conf.Message, descriptors)
+	if m == nil {
+		sources := append(append([]string{}, conf.ImportPaths...), conf.DescriptorSets...)
+		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", conf.Message, sources)
+	}
+
+	if conf.Operator == "to_json" {
+		return newProtobufToJSONOperator(m, descriptors, conf.UnknownFieldsKey), nil
+	}
+	return newProtobufFromJSONOperator(m, descriptors, conf.DiscardUnknown, conf.UnknownFieldsKey), nil
+}
+
+//------------------------------------------------------------------------------
+
+// walkMessageJSON calls fn for a message and its JSON representation, followed
+// by each nested message, including those within repeated and map fields,
+// along with the corresponding JSON object.
+func walkMessageJSON(msg *dynamic.Message, obj map[string]interface{}, fn func(*dynamic.Message, map[string]interface{}) error) error {
+	if err := fn(msg, obj); err != nil {
+		return err
+	}
+
+	walkValue := func(v interface{}, jv interface{}) error {
+		nestedMsg, ok := v.(*dynamic.Message)
+		if !ok {
+			return nil
+		}
+		nestedObj, ok := jv.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		return walkMessageJSON(nestedMsg, nestedObj, fn)
+	}
+
+	for _, fd := range msg.GetMessageDescriptor().GetFields() {
+		jv, exists := obj[fd.GetJSONName()]
+		if !exists {
+			if jv, exists = obj[fd.GetName()]; !exists {
+				continue
+			}
+		}
+
+		switch {
+		case fd.IsMap():
+			if fd.GetMapValueType().GetMessageType() == nil {
+				continue
+			}
+			jMap, _ := jv.(map[string]interface{})
+			var err error
+			msg.ForEachMapFieldEntry(fd, func(k, v interface{}) bool {
+				err = walkValue(v, jMap[fmt.Sprintf("%v", k)])
+				return err == nil
+			})
+			if err != nil {
+				return err
+			}
+		case fd.GetMessageType() == nil:
+			continue
+		case fd.IsRepeated():
+			jArr, _ := jv.([]interface{})
+			for i := 0; i < msg.FieldLength(fd) && i < len(jArr); i++ {
+				if err := walkValue(msg.GetRepeatedField(fd, i), jArr[i]); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := walkValue(msg.GetField(fd), jv); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasUnknownFields returns whether a message or any of its nested messages
+// contain unknown fields.
+func hasUnknownFields(msg *dynamic.Message) bool {
+	if len(msg.GetUnknownFields()) > 0 {
+		return true
+	}
+
+	isUnknown := func(v interface{}) bool {
+		nested, ok := v.(*dynamic.Message)
+		return ok && hasUnknownFields(nested)
+	}
+
+	var found bool
+	for _, fd := range msg.GetMessageDescriptor().GetFields() {
+		if !msg.HasField(fd) {
+			continue
+		}
+		switch {
+		case fd.IsMap():
+			if fd.GetMapValueType().GetMessageType() == nil {
+				continue
+			}
+			msg.ForEachMapFieldEntry(fd, func(_, v interface{}) bool {
+				found = isUnknown(v)
+				return !found
+			})
+		case fd.GetMessageType() == nil:
+			continue
+		case fd.IsRepeated():
+			for i := 0; i < msg.FieldLength(fd) && !found; i++ {
+				found = isUnknown(msg.GetRepeatedField(fd, i))
+			}
+		default:
+			found = isUnknown(msg.GetField(fd))
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeUnknownFields returns the unknown fields of a message in protobuf wire
+// format.
+func encodeUnknownFields(msg *dynamic.Message) ([]byte, error) {
+	tags := msg.GetUnknownFields()
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+
+	buf := codec.NewBuffer(nil)
+	for _, tag := range tags {
+		for _, u := range msg.GetUnknownField(tag) {
+			if err := buf.EncodeTagAndWireType(tag, u.Encoding); err != nil {
+				return nil, err
+			}
+			var err error
+			switch u.Encoding {
+			case proto.WireBytes:
+				err = buf.EncodeRawBytes(u.Contents)
+			case proto.WireStartGroup:
+				_, _ = buf.Write(u.Contents)
+				err = buf.EncodeTagAndWireType(tag, proto.WireEndGroup)
+			case proto.WireFixed32:
+				err = buf.EncodeFixed32(u.Value)
+			case proto.WireFixed64:
+				err = buf.EncodeFixed64(u.Value)
+			case proto.WireVarint:
+				err = buf.EncodeVarint(u.Value)
+			default:
+				err = fmt.Errorf("unrecognised wire type: %v", u.Encoding)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func addUnknownFieldsToJSON(msg *dynamic.Message, data []byte, key string) ([]byte, error) {
+	doc, err := decodeJSONNumbers(data)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return data, nil
+	}
+	if err := walkMessageJSON(msg, obj, func(msg *dynamic.Message, obj map[string]interface{}) error {
+		if len(msg.GetUnknownFields()) == 0 {
+			return nil
+		}
+		raw, err := encodeUnknownFields(msg)
+		if err != nil {
+			return err
+		}
+		obj[key] = base64.StdEncoding.EncodeToString(raw)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return json.Marshal(obj)
+}
+
+func restoreUnknownFields(msg *dynamic.Message, obj map[string]interface{}, key string) error {
+	v, exists := obj[key]
+	if !exists {
+		return nil
+	}
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("expected string value for key %v, got %T", key, v)
+	}
+	raw, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		return err
+	}
+	return msg.UnmarshalMerge(raw)
 }
 
-func strToProtobufOperator(opStr, message string, importPaths []string) (protobufOperator, error) {
-	switch opStr {
-	case "to_json":
-		return newProtobufToJSONOperator(message, importPaths)
-	case "from_json":
-		return newProtobufFromJSONOperator(message, importPaths)
+// removeUnknownFieldsKey returns a JSON document with the unknown fields key
+// removed from all objects.
+func removeUnknownFieldsKey(data []byte, key string) ([]byte, error) {
+	doc, err := decodeJSONNumbers(data)
+	if err != nil {
+		return nil, err
+	}
+	var strip func(v interface{})
+	strip = func(v interface{}) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			delete(t, key)
+			for _, child := range t {
+				strip(child)
+			}
+		case []interface{}:
+			for _, child := range t {
+				strip(child)
+			}
+		}
 	}
-	return nil, fmt.Errorf("operator not recognised: %v", opStr)
+	strip(doc)
+	return json.Marshal(doc)
+}
+
+func decodeJSONNumbers(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
 }
 
 func loadDescriptors(importPaths []string) ([]*desc.FileDescriptor, error) {
@@ -280,6 +549,33 @@ func loadDescriptors(importPaths []string) ([]*desc.FileDescriptor, error) {
 	return fds, err
 }
 
+func loadDescriptorSet(path string) ([]*desc.FileDescriptor, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set '%v': %w", path, err)
+	}
+
+	fdMap, err := desc.CreateFileDescriptorsFromSet(&set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load descriptor set '%v': %w", path, err)
+	}
+
+	// Files are ordered as they appear within the set so that message lookups
+	// are deterministic.
+	fds := make([]*desc.FileDescriptor, 0, len(fdMap))
+	for _, fdp := range set.File {
+		if fd, exists := fdMap[fdp.GetName()]; exists {
+			fds = append(fds, fd)
+		}
+	}
+	return fds, nil
+}
+
 func getMessageFromDescriptors(message string, fds []*desc.FileDescriptor) *desc.MessageDescriptor {
 	var msg *desc.MessageDescriptor
 	for _, fd := range fds {
@@ -303,7 +599,7 @@ func newProtobuf(conf processor.ProtobufConfig, mgr bundle.NewManagement) (*prot
 		log: mgr.Logger(),
 	}
 	var err error
-	if p.operator, err = strToProtobufOperator(conf.Operator, conf.Message, conf.ImportPaths); err != nil {
+	if p.operator, err = newProtobufOperator(conf); err != nil {
 		return nil, err
 	}
 	return p, nil
@@ -1,8 +1,14 @@
 package pure_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -198,3 +204,133 @@ func TestProtobufErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestProtobufDescriptorSet(t *testing.T) {
+	fds, err := (&protoparse.Parser{
+		ImportPaths: []string{"../../../config/test/protobuf/schema"},
+	}).ParseFiles("envelope.proto", "house.proto")
+	require.NoError(t, err)
+
+	setBytes, err := proto.Marshal(desc.ToFileDescriptorSet(fds...))
+	require.NoError(t, err)
+
+	setPath := filepath.Join(t.TempDir(), "schema.desc")
+	require.NoError(t, os.WriteFile(setPath, setBytes, 0o644))
+
+	jsonDoc := `{"id":747,"content":{"@type":"type.googleapis.com/testing.House","address":"123"}}`
+	protoDoc := []byte{
+		0x8, 0xeb, 0x5, 0x12, 0x2a, 0xa, 0x21, 0x74, 0x79, 0x70, 0x65, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+		0x65, 0x61, 0x70, 0x69, 0x73, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x69, 0x6e,
+		0x67, 0x2e, 0x48, 0x6f, 0x75, 0x73, 0x65, 0x12, 0x5, 0x12, 0x3, 0x31, 0x32, 0x33,
+	}
+
+	for _, test := range []struct {
+		operator string
+		input    []byte
+		output   []byte
+	}{
+		{operator: "from_json", input: []byte(jsonDoc), output: protoDoc},
+		{operator: "to_json", input: protoDoc, output: []byte(jsonDoc)},
+	} {
+		t.Run(test.operator, func(t *testing.T) {
+			conf := processor.NewConfig()
+			conf.Type = "protobuf"
+			conf.Protobuf.Operator = test.operator
+			conf.Protobuf.Message = "testing.Envelope"
+			conf.Protobuf.DescriptorSets = []string{setPath}
+
+			proc, err := mock.NewManager().NewProcessor(conf)
+			require.NoError(t, err)
+
+			msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{test.input}))
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+			require.NoError(t, msgs[0].Get(0).ErrorGet())
+			assert.Equal(t, test.output, msgs[0].Get(0).Get())
+		})
+	}
+
+	conf := processor.NewConfig()
+	conf.Type = "protobuf"
+	conf.Protobuf.Operator = "to_json"
+	conf.Protobuf.Message = "testing.Nope"
+	conf.Protobuf.DescriptorSets = []string{setPath}
+
+	_, err = mock.NewManager().NewProcessor(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to find message 'testing.Nope'")
+
+	conf.Protobuf.Message = "testing.Envelope"
+	conf.Protobuf.DescriptorSets = []string{"../../../config/test/protobuf/schema/person.proto"}
+
+	_, err = mock.NewManager().NewProcessor(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse descriptor set")
+}
+
+func TestProtobufDiscardUnknown(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = "protobuf"
+	conf.Protobuf.Operator = "from_json"
+	conf.Protobuf.Message = "testing.Person"
+	conf.Protobuf.ImportPaths = []string{"../../../config/test/protobuf/schema"}
+	conf.Protobuf.DiscardUnknown = true
+
+	proc, err := mock.NewManager().NewProcessor(conf)
+	require.NoError(t, err)
+
+	msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"firstName":"john","lastName":"oates","ageFoo":10}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.NoError(t, msgs[0].Get(0).ErrorGet())
+	assert.Equal(t, []byte{0x0a, 0x04, 0x6a, 0x6f, 0x68, 0x6e, 0x12, 0x05, 0x6f, 0x61, 0x74, 0x65, 0x73}, msgs[0].Get(0).Get())
+}
+
+func TestProtobufUnknownFields(t *testing.T) {
+	newProc := func(operator, unknownKey string) processor.V1 {
+		t.Helper()
+
+		conf := processor.NewConfig()
+		conf.Type = "protobuf"
+		conf.Protobuf.Operator = operator
+		conf.Protobuf.Message = "testing.House"
+		conf.Protobuf.ImportPaths = []string{"../../../config/test/protobuf/schema"}
+		conf.Protobuf.UnknownFieldsKey = unknownKey
+
+		proc, err := mock.NewManager().NewProcessor(conf)
+		require.NoError(t, err)
+		return proc
+	}
+
+	process := func(proc processor.V1, input []byte) []byte {
+		t.Helper()
+
+		msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{input}))
+		require.Nil(t, res)
+		require.Len(t, msgs, 1)
+		require.NoError(t, msgs[0].Get(0).ErrorGet())
+		return msgs[0].Get(0).Get()
+	}
+
+	input := []byte{
+		// people: [{first_name: "bob", 20: 5}]
+		0x0a, 0x08, 0x0a, 0x03, 0x62, 0x6f, 0x62, 0xa0, 0x01, 0x05,
+		// address: "123"
+		0x12, 0x03, 0x31, 0x32, 0x33,
+		// 30: "hi"
+		0xf2, 0x01, 0x02, 0x68, 0x69,
+	}
+
+	jsonBytes := process(newProc("to_json", "$unknown"), input)
+	assert.JSONEq(t, `{
+  "$unknown": "8gECaGk=",
+  "address": "123",
+  "people": [{"$unknown": "oAEF", "firstName": "bob"}]
+}`, string(jsonBytes))
+
+	assert.Equal(t, input, process(newProc("from_json", "$unknown"), jsonBytes))
+
+	assert.Equal(t, `{"people":[{"firstName":"bob"}],"address":"123"}`, string(process(newProc("to_json", ""), input)))
+}
@@ -53,6 +53,10 @@ At the end of switch processing the resulting batch will follow the same orderin
 				"fallthrough",
 				"Indicates whether, if this case passes for a message, the next case should also be executed.",
 			).HasDefault(false).Advanced(),
+			docs.FieldBool(
+				"statement_metrics",
+				"Whether to emit the metrics `mapping_statement_executed`, `mapping_statement_error` and `mapping_statement_latency_ns` for each statement of the `check` mapping of this case, labelled by the name of the mapping field (`<case index>.check`) and the line number of the statement. Measuring each statement adds overhead to the execution of mappings.",
+			).HasDefault(false).Advanced(),
 		),
 		Examples: []docs.AnnotatedExample{
 			{
@@ -110,6 +114,9 @@ func newSwitchProc(conf processor.SwitchConfig, mgr bundle.NewManagement) (*swit
 			if check, err = mgr.BloblEnvironment().NewMapping(caseConf.Check); err != nil {
 				return nil, fmt.Errorf("failed to parse case %v check: %w", i, err)
 			}
+			if caseConf.StatementMetrics {
+				check = processor.WithStatementMetrics(check, strconv.Itoa(i)+".check", mgr.Metrics())
+			}
 		}
 
 		if len(caseConf.Processors) == 0 {
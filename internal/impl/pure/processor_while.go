@@ -50,6 +50,7 @@ The conditions of this processor are applied across entire message batches. You
 				`this.urls.unprocessed.length() > 0`,
 			).HasDefault(""),
 			docs.FieldProcessor("processors", "A list of child processors to execute on each loop.").Array(),
+			docs.FieldBool("statement_metrics", "Whether to emit the metrics `mapping_statement_executed`, `mapping_statement_error` and `mapping_statement_latency_ns` for each statement of the `check` mapping, labelled by the name of the mapping field and the line number of the statement. Measuring each statement adds overhead to the execution of mappings.").Advanced(),
 		).ChildDefaultAndTypesFromStruct(processor.NewWhileConfig()),
 	})
 	if err != nil {
@@ -75,6 +76,9 @@ func newWhile(conf processor.WhileConfig, mgr bundle.NewManagement) (*whileProc,
 		if check, err = mgr.BloblEnvironment().NewMapping(conf.Check); err != nil {
 			return nil, fmt.Errorf("failed to parse check query: %w", err)
 		}
+		if conf.StatementMetrics {
+			check = processor.WithStatementMetrics(check, "check", mgr.Metrics())
+		}
 	} else {
 		return nil, errors.New("a check query is required")
 	}
@@ -281,6 +281,8 @@ type Workflow struct {
 	mBatchSent     metrics.StatCounter
 	mError         metrics.StatCounter
 	mLatency       metrics.StatTimer
+	mInFlight      metrics.StatGauge
+	mBatchSize     metrics.StatHistogram
 }
 
 // NewWorkflow instanciates a new workflow processor.
@@ -297,6 +299,8 @@ func NewWorkflow(conf processor.WorkflowConfig, mgr bundle.NewManagement) (*Work
 		mBatchSent:     stats.GetCounter("processor_batch_sent"),
 		mError:         stats.GetCounter("processor_error"),
 		mLatency:       stats.GetTimer("processor_latency_ns"),
+		mInFlight:      stats.GetGauge("processor_in_flight"),
+		mBatchSize:     stats.GetHistogram("processor_batch_size"),
 	}
 	if len(conf.MetaPath) > 0 {
 		w.metaPath = gabs.DotPathToSlice(conf.MetaPath)
@@ -443,11 +447,16 @@ func (w *Workflow) skipFromMeta(root interface{}) map[string]struct{} {
 
 // ProcessMessage applies workflow stages to each part of a message type.
 func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
-	w.mReceived.Incr(int64(msg.Len()))
+	inFlight := int64(msg.Len())
+	w.mReceived.Incr(inFlight)
 	w.mBatchReceived.Incr(1)
+	w.mBatchSize.Observe(inFlight)
+	w.mInFlight.Incr(inFlight)
+	defer w.mInFlight.Decr(inFlight)
 	startedAt := time.Now()
 
-	payload := msg.DeepCopy()
+	// Structured contents are cloned lazily by result mappings.
+	payload := msg.Copy()
 
 	// Prevent resourced branches from being updated mid-flow.
 	dag, children, unlock, err := w.children.Lock()
@@ -546,7 +555,7 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 	// Finally, set the meta records of each document.
 	if len(w.metaPath) > 0 {
 		_ = payload.Iter(func(i int, p *message.Part) error {
-			pJSON, err := p.JSON()
+			pJSON, err := p.JSONMut()
 			if err != nil {
 				w.mError.Incr(1)
 				w.log.Errorf("Failed to parse message for meta update: %v\n", err)
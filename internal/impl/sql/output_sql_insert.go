@@ -42,7 +42,13 @@ func sqlInsertOutputConfig() *service.ConfigSpec {
 			Example("ON CONFLICT (name) DO NOTHING")).
 		Field(service.NewIntField("max_in_flight").
 			Description("The maximum number of inserts to run in parallel.").
-			Default(64))
+			Default(64)).
+		Field(service.NewStringField("offsets_table").
+			Description("An optional table in which to record the upstream offsets of inserted messages, such as the partition offsets of messages consumed with a `kafka` input. When set each batch is inserted within a transaction along with a row for the offset of each inserted message, and messages with an offset that has already been recorded are skipped. This prevents duplicate rows when messages are redelivered after a failure between the insert and the input acknowledging them. Offsets are recorded individually, and therefore messages are not required to arrive in order. The table must have the columns `source_id` and `partition_id` of a string type and `committed_offset` of a 64-bit integer type, and should have a primary key on all three columns. A row is added for every inserted message, and rows of offsets that have since been committed by the input can be deleted periodically in order to limit the size of the table. When set `max_in_flight` is ignored and batches are inserted one at a time, and this is not supported by the `clickhouse` driver.").
+			Example("benthos_offsets").
+			Optional().
+			Advanced().
+			Version("4.2.0"))
 
 	for _, f := range connFields() {
 		spec = spec.Field(f)
@@ -81,7 +87,15 @@ func init() {
 			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
 				return
 			}
-			out, err = newSQLInsertOutputFromConfig(conf, mgr.Logger())
+			var s *sqlInsertOutput
+			if s, err = newSQLInsertOutputFromConfig(conf, mgr.Logger()); err != nil {
+				return
+			}
+			if s.offsetsTable != "" {
+				// Offsets must be recorded in the order that batches arrive.
+				maxInFlight = 1
+			}
+			out = s
 			return
 		})
 
@@ -99,8 +113,10 @@ type sqlInsertOutput struct {
 	builder squirrel.InsertBuilder
 	dbMut   sync.RWMutex
 
-	useTxStmt   bool
-	argsMapping *bloblang.Executor
+	useTxStmt    bool
+	argsMapping  *bloblang.Executor
+	offsetsTable string
+	placeholder  squirrel.PlaceholderFormat
 
 	connSettings connSettings
 
@@ -145,9 +161,19 @@ func newSQLInsertOutputFromConfig(conf *service.ParsedConfig, logger *service.Lo
 		}
 	}
 
-	s.builder = squirrel.Insert(tableStr).Columns(columns...)
+	s.placeholder = squirrel.Question
 	if s.driver == "postgres" || s.driver == "clickhouse" {
-		s.builder = s.builder.PlaceholderFormat(squirrel.Dollar)
+		s.placeholder = squirrel.Dollar
+	}
+	s.builder = squirrel.Insert(tableStr).Columns(columns...).PlaceholderFormat(s.placeholder)
+
+	if conf.Contains("offsets_table") {
+		if s.offsetsTable, err = conf.FieldString("offsets_table"); err != nil {
+			return nil, err
+		}
+		if s.useTxStmt {
+			return nil, fmt.Errorf("field offsets_table is not supported by the %v driver", s.driver)
+		}
 	}
 
 	if conf.Contains("prefix") {
@@ -203,6 +229,10 @@ func (s *sqlInsertOutput) WriteBatch(ctx context.Context, batch service.MessageB
 	s.dbMut.RLock()
 	defer s.dbMut.RUnlock()
 
+	if s.offsetsTable != "" {
+		return s.writeBatchWithOffsets(ctx, batch)
+	}
+
 	insertBuilder := s.builder
 
 	var tx *sql.Tx
@@ -223,22 +253,11 @@ func (s *sqlInsertOutput) WriteBatch(ctx context.Context, batch service.MessageB
 	}
 
 	for i := range batch {
-		var args []interface{}
-		resMsg, err := batch.BloblangQuery(i, s.argsMapping)
+		args, err := s.batchArgs(batch, i)
 		if err != nil {
 			return err
 		}
 
-		iargs, err := resMsg.AsStructured()
-		if err != nil {
-			return err
-		}
-
-		var ok bool
-		if args, ok = iargs.([]interface{}); !ok {
-			return fmt.Errorf("mapping returned non-array result: %T", iargs)
-		}
-
 		if tx == nil {
 			insertBuilder = insertBuilder.Values(args...)
 		} else if _, err = stmt.Exec(args...); err != nil {
@@ -255,6 +274,128 @@ func (s *sqlInsertOutput) WriteBatch(ctx context.Context, batch service.MessageB
 	return err
 }
 
+func (s *sqlInsertOutput) batchArgs(batch service.MessageBatch, i int) ([]interface{}, error) {
+	resMsg, err := batch.BloblangQuery(i, s.argsMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	iargs, err := resMsg.AsStructured()
+	if err != nil {
+		return nil, err
+	}
+
+	args, ok := iargs.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("mapping returned non-array result: %T", iargs)
+	}
+	return args, nil
+}
+
+type sqlTxnPartition struct {
+	source    string
+	partition string
+}
+
+// writeBatchWithOffsets inserts the rows of a batch and records the upstream
+// offset of each inserted message within a single transaction, skipping any
+// messages with an offset that was recorded by a prior transaction. Offsets are
+// recorded individually rather than as a high water mark of each partition as
+// messages are not guaranteed to arrive in order, e.g. when an input has many
+// messages in flight or when pipeline threads reorder batches.
+func (s *sqlInsertOutput) writeBatchWithOffsets(ctx context.Context, batch service.MessageBatch) (err error) {
+	var tx *sql.Tx
+	if tx, err = s.db.BeginTx(ctx, nil); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	pending := map[sqlTxnPartition][]int64{}
+	for _, msg := range batch {
+		if source, partition, offset, ok := msg.TxnOffset(); ok {
+			key := sqlTxnPartition{source: source, partition: partition}
+			pending[key] = append(pending[key], offset)
+		}
+	}
+
+	committed := map[sqlTxnPartition]map[int64]struct{}{}
+	for key, offsets := range pending {
+		if committed[key], err = s.committedOffsets(ctx, tx, key, offsets); err != nil {
+			return err
+		}
+	}
+
+	insertBuilder := s.builder
+	offsetsBuilder := squirrel.Insert(s.offsetsTable).
+		Columns("source_id", "partition_id", "committed_offset").
+		PlaceholderFormat(s.placeholder)
+
+	var rows, offsetRows int
+	for i, msg := range batch {
+		if source, partition, offset, ok := msg.TxnOffset(); ok {
+			key := sqlTxnPartition{source: source, partition: partition}
+			if _, exists := committed[key][offset]; exists {
+				continue
+			}
+			// Marking the offset as committed also skips duplicates within
+			// the batch itself.
+			committed[key][offset] = struct{}{}
+			offsetsBuilder = offsetsBuilder.Values(key.source, key.partition, offset)
+			offsetRows++
+		}
+
+		var args []interface{}
+		if args, err = s.batchArgs(batch, i); err != nil {
+			return err
+		}
+		insertBuilder = insertBuilder.Values(args...)
+		rows++
+	}
+
+	if rows > 0 {
+		if _, err = insertBuilder.RunWith(tx).ExecContext(ctx); err != nil {
+			return err
+		}
+	}
+	if offsetRows > 0 {
+		if _, err = offsetsBuilder.RunWith(tx).ExecContext(ctx); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// committedOffsets returns which of the offsets of a partition have already
+// been recorded.
+func (s *sqlInsertOutput) committedOffsets(ctx context.Context, tx *sql.Tx, key sqlTxnPartition, offsets []int64) (map[int64]struct{}, error) {
+	rows, err := squirrel.Select("committed_offset").
+		From(s.offsetsTable).
+		Where(squirrel.Eq{"source_id": key.source}).
+		Where(squirrel.Eq{"partition_id": key.partition}).
+		Where(squirrel.Eq{"committed_offset": offsets}).
+		PlaceholderFormat(s.placeholder).
+		RunWith(tx).
+		QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	committed := map[int64]struct{}{}
+	for rows.Next() {
+		var offset int64
+		if err := rows.Scan(&offset); err != nil {
+			return nil, err
+		}
+		committed[offset] = struct{}{}
+	}
+	return committed, rows.Err()
+}
+
 func (s *sqlInsertOutput) Close(ctx context.Context) error {
 	s.shutSig.CloseNow()
 	s.dbMut.RLock()
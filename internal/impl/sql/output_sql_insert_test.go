@@ -2,8 +2,15 @@ package sql
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/public/service"
@@ -28,3 +35,212 @@ args_mapping: 'root = [ this.id ]'
 	require.NoError(t, err)
 	require.NoError(t, insertOutput.Close(context.Background()))
 }
+
+func TestSQLInsertOutputOffsetsTable(t *testing.T) {
+	spec := sqlInsertOutputConfig()
+	env := service.NewEnvironment()
+
+	insertConfig, err := spec.ParseYAML(`
+driver: postgres
+dsn: woof
+table: quack
+columns: [ foo ]
+args_mapping: 'root = [ this.id ]'
+offsets_table: offsets
+`, env)
+	require.NoError(t, err)
+
+	insertOutput, err := newSQLInsertOutputFromConfig(insertConfig, nil)
+	require.NoError(t, err)
+	require.Equal(t, "offsets", insertOutput.offsetsTable)
+	require.NoError(t, insertOutput.Close(context.Background()))
+
+	insertConfig, err = spec.ParseYAML(`
+driver: clickhouse
+dsn: woof
+table: quack
+columns: [ foo ]
+args_mapping: 'root = [ this.id ]'
+offsets_table: offsets
+`, env)
+	require.NoError(t, err)
+
+	_, err = newSQLInsertOutputFromConfig(insertConfig, nil)
+	require.EqualError(t, err, "field offsets_table is not supported by the clickhouse driver")
+}
+
+// fakeOffsetsDB is a minimal database/sql driver that understands the queries
+// issued by the sql_insert output when an offsets table is configured, where
+// rows are inserted into the table quack and offsets into the table offsets.
+type fakeOffsetsDB struct {
+	mut     sync.Mutex
+	rows    []string
+	offsets map[string]struct{}
+}
+
+var fakeOffsetsDBs sync.Map
+
+func init() {
+	sql.Register("sqlinsert_offsets_test", fakeOffsetsDriver{})
+}
+
+type fakeOffsetsDriver struct{}
+
+func (fakeOffsetsDriver) Open(name string) (driver.Conn, error) {
+	db, ok := fakeOffsetsDBs.Load(name)
+	if !ok {
+		return nil, fmt.Errorf("database %v not found", name)
+	}
+	return &fakeOffsetsConn{db: db.(*fakeOffsetsDB)}, nil
+}
+
+type fakeOffsetsConn struct {
+	db *fakeOffsetsDB
+
+	txRows    []string
+	txOffsets []string
+}
+
+func (c *fakeOffsetsConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeOffsetsStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeOffsetsConn) Close() error {
+	return nil
+}
+
+func (c *fakeOffsetsConn) Begin() (driver.Tx, error) {
+	c.txRows, c.txOffsets = nil, nil
+	return c, nil
+}
+
+func (c *fakeOffsetsConn) Commit() error {
+	c.db.mut.Lock()
+	defer c.db.mut.Unlock()
+	for _, o := range c.txOffsets {
+		if _, exists := c.db.offsets[o]; exists {
+			return fmt.Errorf("duplicate offset %v", o)
+		}
+		c.db.offsets[o] = struct{}{}
+	}
+	c.db.rows = append(c.db.rows, c.txRows...)
+	return nil
+}
+
+func (c *fakeOffsetsConn) Rollback() error {
+	c.txRows, c.txOffsets = nil, nil
+	return nil
+}
+
+type fakeOffsetsStmt struct {
+	conn  *fakeOffsetsConn
+	query string
+}
+
+func (s *fakeOffsetsStmt) Close() error {
+	return nil
+}
+
+func (s *fakeOffsetsStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeOffsetsStmt) Exec(args []driver.Value) (driver.Result, error) {
+	switch {
+	case strings.HasPrefix(s.query, "INSERT INTO quack "):
+		for _, a := range args {
+			s.conn.txRows = append(s.conn.txRows, fmt.Sprint(a))
+		}
+	case strings.HasPrefix(s.query, "INSERT INTO offsets "):
+		for i := 0; i+2 < len(args); i += 3 {
+			s.conn.txOffsets = append(s.conn.txOffsets, fmt.Sprintf("%v/%v/%v", args[i], args[i+1], args[i+2]))
+		}
+	default:
+		return nil, fmt.Errorf("unexpected exec: %v", s.query)
+	}
+	return driver.RowsAffected(len(args)), nil
+}
+
+func (s *fakeOffsetsStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if !strings.HasPrefix(s.query, "SELECT committed_offset FROM offsets ") || len(args) < 3 {
+		return nil, fmt.Errorf("unexpected query: %v", s.query)
+	}
+
+	s.conn.db.mut.Lock()
+	defer s.conn.db.mut.Unlock()
+
+	rows := &fakeOffsetsRows{}
+	for _, offset := range args[2:] {
+		if _, exists := s.conn.db.offsets[fmt.Sprintf("%v/%v/%v", args[0], args[1], offset)]; exists {
+			rows.offsets = append(rows.offsets, offset.(int64))
+		}
+	}
+	return rows, nil
+}
+
+type fakeOffsetsRows struct {
+	offsets []int64
+}
+
+func (r *fakeOffsetsRows) Columns() []string {
+	return []string{"committed_offset"}
+}
+
+func (r *fakeOffsetsRows) Close() error {
+	return nil
+}
+
+func (r *fakeOffsetsRows) Next(dest []driver.Value) error {
+	if len(r.offsets) == 0 {
+		return io.EOF
+	}
+	dest[0], r.offsets = r.offsets[0], r.offsets[1:]
+	return nil
+}
+
+func TestSQLInsertOutputOffsetsOutOfOrder(t *testing.T) {
+	fakeDB := &fakeOffsetsDB{offsets: map[string]struct{}{}}
+	fakeOffsetsDBs.Store(t.Name(), fakeDB)
+
+	insertConfig, err := sqlInsertOutputConfig().ParseYAML(`
+driver: mysql
+dsn: woof
+table: quack
+columns: [ foo ]
+args_mapping: 'root = [ this.id ]'
+offsets_table: offsets
+`, service.NewEnvironment())
+	require.NoError(t, err)
+
+	insertOutput, err := newSQLInsertOutputFromConfig(insertConfig, nil)
+	require.NoError(t, err)
+
+	insertOutput.db, err = sql.Open("sqlinsert_offsets_test", t.Name())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = insertOutput.db.Close()
+	})
+
+	batchOf := func(offsets ...int64) service.MessageBatch {
+		var batch service.MessageBatch
+		for _, o := range offsets {
+			msg := service.NewMessage([]byte(fmt.Sprintf(`{"id":"%v"}`, o)))
+			batch = append(batch, msg.WithTxnOffset("kafka", "foo/0", o))
+		}
+		return batch
+	}
+
+	ctx := context.Background()
+	require.NoError(t, insertOutput.WriteBatch(ctx, batchOf(5, 6)))
+
+	// A batch with lower offsets that arrives late is still inserted.
+	require.NoError(t, insertOutput.WriteBatch(ctx, batchOf(3, 4)))
+
+	// Redelivered messages are skipped, including duplicates within a batch.
+	require.NoError(t, insertOutput.WriteBatch(ctx, batchOf(4, 7, 5, 3, 7)))
+
+	fakeDB.mut.Lock()
+	defer fakeDB.mut.Unlock()
+	assert.Equal(t, []string{"5", "6", "3", "4", "7"}, fakeDB.rows)
+	assert.Len(t, fakeDB.offsets, 5)
+}
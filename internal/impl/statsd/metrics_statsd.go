@@ -73,6 +73,12 @@ func (s *statsdStat) Timing(delta int64) {
 	s.s.Timing(s.path, delta, s.tags...)
 }
 
+// Timers are the only distribution type of the StatsD protocol and so
+// histograms are sent as timing values.
+func (s *statsdStat) Observe(value int64) {
+	s.s.Timing(s.path, value, s.tags...)
+}
+
 func (s *statsdStat) Set(value int64) {
 	s.s.Gauge(s.path, value, s.tags...)
 }
@@ -147,6 +153,20 @@ func (h *statsdMetrics) GetTimerVec(path string, n ...string) metrics.StatTimerV
 	})
 }
 
+func (h *statsdMetrics) GetHistogram(path string) metrics.StatHistogram {
+	return h.GetHistogramVec(path).With()
+}
+
+func (h *statsdMetrics) GetHistogramVec(path string, n ...string) metrics.StatHistogramVec {
+	return metrics.FakeHistogramVec(func(l ...string) metrics.StatHistogram {
+		return &statsdStat{
+			path: path,
+			s:    h.s,
+			tags: tags(n, l),
+		}
+	})
+}
+
 func (h *statsdMetrics) GetGauge(path string) metrics.StatGauge {
 	return h.GetGaugeVec(path).With()
 }
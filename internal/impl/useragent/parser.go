@@ -0,0 +1,245 @@
+package useragent
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed regexes.yaml
+var regexesYAML []byte
+
+type regexesFile struct {
+	UserAgentParsers []struct {
+		Regex             string `yaml:"regex"`
+		FamilyReplacement string `yaml:"family_replacement"`
+		V1Replacement     string `yaml:"v1_replacement"`
+		V2Replacement     string `yaml:"v2_replacement"`
+		V3Replacement     string `yaml:"v3_replacement"`
+	} `yaml:"user_agent_parsers"`
+	OSParsers []struct {
+		Regex             string `yaml:"regex"`
+		FamilyReplacement string `yaml:"os_replacement"`
+		V1Replacement     string `yaml:"os_v1_replacement"`
+		V2Replacement     string `yaml:"os_v2_replacement"`
+		V3Replacement     string `yaml:"os_v3_replacement"`
+	} `yaml:"os_parsers"`
+	DeviceParsers []struct {
+		Regex             string `yaml:"regex"`
+		DeviceReplacement string `yaml:"device_replacement"`
+		BrandReplacement  string `yaml:"brand_replacement"`
+		ModelReplacement  string `yaml:"model_replacement"`
+	} `yaml:"device_parsers"`
+}
+
+// A versionedMatcher extracts a family and up to three version components,
+// which is used for both browsers and operating systems.
+type versionedMatcher struct {
+	re           *regexp.Regexp
+	replacements [4]string
+}
+
+type deviceMatcher struct {
+	re           *regexp.Regexp
+	replacements [3]string
+}
+
+type parser struct {
+	agents  []versionedMatcher
+	oses    []versionedMatcher
+	devices []deviceMatcher
+}
+
+func newParser(regexes []byte) (*parser, error) {
+	var file regexesFile
+	if err := yaml.Unmarshal(regexes, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse regexes: %w", err)
+	}
+
+	p := &parser{}
+	for _, a := range file.UserAgentParsers {
+		re, err := regexp.Compile(a.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile user agent regex '%v': %w", a.Regex, err)
+		}
+		p.agents = append(p.agents, versionedMatcher{
+			re:           re,
+			replacements: [4]string{a.FamilyReplacement, a.V1Replacement, a.V2Replacement, a.V3Replacement},
+		})
+	}
+	for _, o := range file.OSParsers {
+		re, err := regexp.Compile(o.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile os regex '%v': %w", o.Regex, err)
+		}
+		p.oses = append(p.oses, versionedMatcher{
+			re:           re,
+			replacements: [4]string{o.FamilyReplacement, o.V1Replacement, o.V2Replacement, o.V3Replacement},
+		})
+	}
+	for _, d := range file.DeviceParsers {
+		re, err := regexp.Compile(d.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile device regex '%v': %w", d.Regex, err)
+		}
+		p.devices = append(p.devices, deviceMatcher{
+			re:           re,
+			replacements: [3]string{d.DeviceReplacement, d.BrandReplacement, d.ModelReplacement},
+		})
+	}
+	return p, nil
+}
+
+var defaultParser *parser
+
+func init() {
+	var err error
+	if defaultParser, err = newParser(regexesYAML); err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Replaces references to capture groups ($1 to $9) within a replacement.
+func expandReplacement(replacement string, groups []string) string {
+	if !strings.Contains(replacement, "$") {
+		return replacement
+	}
+	var b strings.Builder
+	for i := 0; i < len(replacement); i++ {
+		if replacement[i] == '$' && i+1 < len(replacement) && replacement[i+1] >= '1' && replacement[i+1] <= '9' {
+			if n := int(replacement[i+1] - '0'); n < len(groups) {
+				b.WriteString(groups[n])
+			}
+			i++
+			continue
+		}
+		b.WriteByte(replacement[i])
+	}
+	return strings.TrimSpace(b.String())
+}
+
+type versioned struct {
+	Family string
+	Major  string
+	Minor  string
+	Patch  string
+}
+
+func (v versioned) version() string {
+	parts := []string{}
+	for _, p := range []string{v.Major, v.Minor, v.Patch} {
+		if p == "" {
+			break
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, ".")
+}
+
+func (v versioned) asMap() map[string]interface{} {
+	return map[string]interface{}{
+		"family":  v.Family,
+		"major":   v.Major,
+		"minor":   v.Minor,
+		"patch":   v.Patch,
+		"version": v.version(),
+	}
+}
+
+func matchVersioned(matchers []versionedMatcher, ua string) versioned {
+	for _, m := range matchers {
+		groups := m.re.FindStringSubmatch(ua)
+		if groups == nil {
+			continue
+		}
+
+		var fields [4]string
+		for i, r := range m.replacements {
+			if r != "" {
+				fields[i] = expandReplacement(r, groups)
+			} else if i+1 < len(groups) {
+				fields[i] = groups[i+1]
+			}
+		}
+		return versioned{Family: fields[0], Major: fields[1], Minor: fields[2], Patch: fields[3]}
+	}
+	return versioned{Family: "Other"}
+}
+
+type device struct {
+	Family string
+	Brand  string
+	Model  string
+}
+
+func matchDevice(matchers []deviceMatcher, ua string) device {
+	for _, m := range matchers {
+		groups := m.re.FindStringSubmatch(ua)
+		if groups == nil {
+			continue
+		}
+
+		var d device
+		if d.Family = "$1"; m.replacements[0] != "" {
+			d.Family = m.replacements[0]
+		}
+		d.Family = expandReplacement(d.Family, groups)
+		d.Brand = expandReplacement(m.replacements[1], groups)
+		if d.Model = "$1"; m.replacements[2] != "" {
+			d.Model = m.replacements[2]
+		}
+		d.Model = expandReplacement(d.Model, groups)
+		return d
+	}
+	return device{Family: "Other"}
+}
+
+var (
+	tabletRegexp = regexp.MustCompile(`iPad|Tablet|Kindle|Silk|KF[A-Z]{2,4}(?: Build|[;)])`)
+	mobileRegexp = regexp.MustCompile(`Mobi|iPhone|iPod|Windows Phone|KAIOS|Opera Mini`)
+)
+
+// Determines a coarse type of the device, which is one of bot, tablet, mobile,
+// desktop or other.
+func deviceType(ua string, os versioned, d device) string {
+	switch {
+	case d.Family == "Spider":
+		return "bot"
+	case tabletRegexp.MatchString(ua):
+		return "tablet"
+	case mobileRegexp.MatchString(ua):
+		return "mobile"
+	case os.Family == "Android":
+		// Android tablets omit the Mobile token.
+		return "tablet"
+	}
+	switch os.Family {
+	case "Windows", "Mac OS X", "Linux", "Ubuntu", "Fedora", "Chrome OS", "FreeBSD", "OpenBSD", "NetBSD":
+		return "desktop"
+	}
+	return "other"
+}
+
+// Parse a user agent string into a structured result containing the browser,
+// operating system and device. Values that are not recognised have the family
+// Other.
+func (p *parser) Parse(ua string) map[string]interface{} {
+	browser := matchVersioned(p.agents, ua)
+	os := matchVersioned(p.oses, ua)
+	d := matchDevice(p.devices, ua)
+	return map[string]interface{}{
+		"browser": browser.asMap(),
+		"os":      os.asMap(),
+		"device": map[string]interface{}{
+			"family": d.Family,
+			"brand":  d.Brand,
+			"model":  d.Model,
+			"type":   deviceType(ua, os, d),
+		},
+	}
+}
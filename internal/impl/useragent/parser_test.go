@@ -0,0 +1,185 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParser(t *testing.T) {
+	tests := []struct {
+		ua      string
+		browser string
+		version string
+		os      string
+		osVer   string
+		device  string
+		brand   string
+		model   string
+		devType string
+	}{
+		{
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.51 Safari/537.36",
+			browser: "Chrome", version: "99.0.4844",
+			os: "Windows", osVer: "10",
+			device: "Other", devType: "desktop",
+		},
+		{
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.51 Safari/537.36 Edg/99.0.1150.39",
+			browser: "Edge", version: "99.0.1150",
+			os: "Windows", osVer: "10",
+			device: "Other", devType: "desktop",
+		},
+		{
+			ua:      "Mozilla/5.0 (Windows NT 6.1; WOW64; Trident/7.0; rv:11.0) like Gecko",
+			browser: "IE", version: "11.0",
+			os: "Windows", osVer: "7",
+			device: "Other", devType: "desktop",
+		},
+		{
+			ua:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.3 Safari/605.1.15",
+			browser: "Safari", version: "15.3",
+			os: "Mac OS X", osVer: "10.15.7",
+			device: "Mac", brand: "Apple", model: "Mac", devType: "desktop",
+		},
+		{
+			ua:      "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:98.0) Gecko/20100101 Firefox/98.0",
+			browser: "Firefox", version: "98.0",
+			os: "Ubuntu", osVer: "",
+			device: "Other", devType: "desktop",
+		},
+		{
+			ua:      "Mozilla/5.0 (iPhone; CPU iPhone OS 15_3_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.3 Mobile/15E148 Safari/604.1",
+			browser: "Mobile Safari", version: "15.3",
+			os: "iOS", osVer: "15.3.1",
+			device: "iPhone", brand: "Apple", model: "iPhone", devType: "mobile",
+		},
+		{
+			ua:      "Mozilla/5.0 (iPad; CPU OS 14_7_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/99.0.4844.59 Mobile/15E148 Safari/604.1",
+			browser: "Chrome Mobile iOS", version: "99.0.4844",
+			os: "iOS", osVer: "14.7.1",
+			device: "iPad", brand: "Apple", model: "iPad", devType: "tablet",
+		},
+		{
+			ua:      "Mozilla/5.0 (iPhone; CPU iPhone OS 15_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148",
+			browser: "Mobile Safari UI/WKWebView", version: "15.2",
+			os: "iOS", osVer: "15.2",
+			device: "iPhone", brand: "Apple", model: "iPhone", devType: "mobile",
+		},
+		{
+			ua:      "Mozilla/5.0 (Linux; Android 12; SM-G991B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/16.2 Chrome/92.0.4515.166 Mobile Safari/537.36",
+			browser: "Samsung Internet", version: "16.2",
+			os: "Android", osVer: "12",
+			device: "Samsung SM-G991B", brand: "Samsung", model: "SM-G991B", devType: "mobile",
+		},
+		{
+			ua:      "Mozilla/5.0 (Linux; Android 12; Pixel 6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.58 Mobile Safari/537.36",
+			browser: "Chrome Mobile", version: "99.0.4844",
+			os: "Android", osVer: "12",
+			device: "Pixel 6", brand: "Google", model: "Pixel 6", devType: "mobile",
+		},
+		{
+			ua:      "Mozilla/5.0 (Linux; Android 11; Lenovo TB-X606F) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.58 Safari/537.36",
+			browser: "Chrome", version: "99.0.4844",
+			os: "Android", osVer: "11",
+			device: "Lenovo TB-X606F", brand: "Generic_Android", model: "Lenovo TB-X606F", devType: "tablet",
+		},
+		{
+			ua:      "Mozilla/5.0 (Linux; Android 10; K; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/99.0.4844.58 Mobile Safari/537.36",
+			browser: "Chrome Mobile WebView", version: "99.0.4844",
+			os: "Android", osVer: "10",
+			device: "K", brand: "Generic_Android", model: "K", devType: "mobile",
+		},
+		{
+			ua:      "Mozilla/5.0 (Android 12; Mobile; rv:98.0) Gecko/98.0 Firefox/98.0",
+			browser: "Firefox Mobile", version: "98.0",
+			os: "Android", osVer: "12",
+			device: "Other", devType: "mobile",
+		},
+		{
+			ua:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.51 Safari/537.36 OPR/85.0.4341.18",
+			browser: "Opera", version: "85.0.4341",
+			os: "Windows", osVer: "10",
+			device: "Other", devType: "desktop",
+		},
+		{
+			ua:      "Mozilla/5.0 (X11; CrOS x86_64 14388.61.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/98.0.4758.107 Safari/537.36",
+			browser: "Chrome", version: "98.0.4758",
+			os: "Chrome OS", osVer: "14388.61.0",
+			device: "Other", devType: "desktop",
+		},
+		{
+			ua:      "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			browser: "Googlebot", version: "2.1",
+			os: "Other", osVer: "",
+			device: "Spider", brand: "Spider", model: "Desktop", devType: "bot",
+		},
+		{
+			ua:      "Mozilla/5.0 (compatible; MyCustomCrawler/1.2; +https://example.com)",
+			browser: "MyCustomCrawler", version: "1.2",
+			os: "Other", osVer: "",
+			device: "Spider", brand: "Spider", model: "Desktop", devType: "bot",
+		},
+		{
+			ua:      "curl/7.79.1",
+			browser: "curl", version: "7.79.1",
+			os: "Other", osVer: "",
+			device: "Other", devType: "other",
+		},
+		{
+			ua:      "",
+			browser: "Other", version: "",
+			os: "Other", osVer: "",
+			device: "Other", devType: "other",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.ua, func(t *testing.T) {
+			res := defaultParser.Parse(test.ua)
+
+			browser := res["browser"].(map[string]interface{})
+			assert.Equal(t, test.browser, browser["family"], "browser family")
+			assert.Equal(t, test.version, browser["version"], "browser version")
+
+			os := res["os"].(map[string]interface{})
+			assert.Equal(t, test.os, os["family"], "os family")
+			assert.Equal(t, test.osVer, os["version"], "os version")
+
+			device := res["device"].(map[string]interface{})
+			assert.Equal(t, test.device, device["family"], "device family")
+			assert.Equal(t, test.brand, device["brand"], "device brand")
+			assert.Equal(t, test.model, device["model"], "device model")
+			assert.Equal(t, test.devType, device["type"], "device type")
+		})
+	}
+}
+
+func TestParserVersionFields(t *testing.T) {
+	res := defaultParser.Parse("Mozilla/5.0 (Windows NT 6.3; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/99.0.4844.51 Safari/537.36")
+	assert.Equal(t, map[string]interface{}{
+		"family":  "Chrome",
+		"major":   "99",
+		"minor":   "0",
+		"patch":   "4844",
+		"version": "99.0.4844",
+	}, res["browser"])
+	assert.Equal(t, map[string]interface{}{
+		"family":  "Windows",
+		"major":   "8",
+		"minor":   "1",
+		"patch":   "",
+		"version": "8.1",
+	}, res["os"])
+}
+
+func TestParserBadRegexes(t *testing.T) {
+	_, err := newParser([]byte(`user_agent_parsers: [ { regex: "(" } ]`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compile user agent regex")
+
+	_, err = newParser([]byte(`not: [ valid`))
+	require.Error(t, err)
+}
@@ -0,0 +1,139 @@
+package useragent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/gabs/v2"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func processorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Parsing").
+		Summary("Parses user agent strings into the browser, operating system and device that they describe.").
+		Description(`
+User agents are parsed with an embedded database of regular expressions, which follows the layout of the [ua-parser](https://github.com/ua-parser/uap-core) project and covers the most common browsers, operating systems, devices and crawlers. The parsed result is a structure of the form:
+
+`+"```json"+`
+{
+  "browser": { "family": "Chrome", "major": "99", "minor": "0", "patch": "4844", "version": "99.0.4844" },
+  "os": { "family": "Android", "major": "12", "minor": "", "patch": "", "version": "12" },
+  "device": { "family": "Samsung SM-G991B", "brand": "Samsung", "model": "SM-G991B", "type": "mobile" }
+}
+`+"```"+`
+
+Any browser, operating system or device that is not recognised has the family `+"`Other`"+`. The field `+"`device.type`"+` is one of `+"`bot`, `tablet`, `mobile`, `desktop` or `other`"+`.
+
+If the user agent field is missing or is not a string the message remains unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).`).
+		Field(service.NewStringField("field").
+			Description("A [dot path](/docs/configuration/field_paths) of the user agent string within structured messages. If empty the raw contents of each message are parsed as the user agent.").
+			Example("headers.user_agent").
+			Default("")).
+		Field(service.NewStringField("target").
+			Description("A [dot path](/docs/configuration/field_paths) at which the parsed result is set within structured messages. If empty the contents of each message are replaced with the parsed result.").
+			Example("user_agent_parsed").
+			Default("")).
+		Example("Enrich Clickstream Events", `
+Given a stream of clickstream events that contain the user agent of each visitor we can add the parsed browser, operating system and device of each one to the events:`, `
+pipeline:
+  processors:
+    - user_agent:
+        field: context.user_agent
+        target: context.client
+`).
+		Version("4.2.0")
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"user_agent", processorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newProcessorFromConfig(conf)
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type processor struct {
+	field  string
+	target string
+	parser *parser
+}
+
+func newProcessorFromConfig(conf *service.ParsedConfig) (*processor, error) {
+	field, err := conf.FieldString("field")
+	if err != nil {
+		return nil, err
+	}
+	target, err := conf.FieldString("target")
+	if err != nil {
+		return nil, err
+	}
+	return newProcessor(field, target)
+}
+
+func newProcessor(field, target string) (*processor, error) {
+	if field == "" && target != "" {
+		return nil, errors.New("a target cannot be set when parsing the raw contents of messages, a field must also be set")
+	}
+	return &processor{
+		field:  field,
+		target: target,
+		parser: defaultParser,
+	}, nil
+}
+
+func (p *processor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	if p.field == "" {
+		b, err := msg.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		resMsg := msg.Copy()
+		resMsg.SetStructured(p.parser.Parse(string(b)))
+		return service.MessageBatch{resMsg}, nil
+	}
+
+	v, err := msg.AsStructured()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message as JSON: %w", err)
+	}
+
+	uaV := gabs.Wrap(v).Path(p.field).Data()
+	if uaV == nil {
+		return nil, fmt.Errorf("field '%v' was not found", p.field)
+	}
+	ua, ok := uaV.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected field '%v' to be a string, got %T", p.field, uaV)
+	}
+	parsed := p.parser.Parse(ua)
+
+	resMsg := msg.Copy()
+	if p.target == "" {
+		resMsg.SetStructured(parsed)
+		return service.MessageBatch{resMsg}, nil
+	}
+
+	if v, err = resMsg.AsStructuredMut(); err != nil {
+		return nil, err
+	}
+	gObj := gabs.Wrap(v)
+	if _, err := gObj.SetP(parsed, p.target); err != nil {
+		return nil, fmt.Errorf("failed to set target '%v': %w", p.target, err)
+	}
+	resMsg.SetStructured(gObj.Data())
+	return service.MessageBatch{resMsg}, nil
+}
+
+func (p *processor) Close(ctx context.Context) error {
+	return nil
+}
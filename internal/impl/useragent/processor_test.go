@@ -0,0 +1,116 @@
+package useragent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const testUA = "Mozilla/5.0 (iPhone; CPU iPhone OS 15_3_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.3 Mobile/15E148 Safari/604.1"
+
+func TestProcessorRaw(t *testing.T) {
+	proc, err := newProcessor("", "")
+	require.NoError(t, err)
+
+	msgs, err := proc.Process(context.Background(), service.NewMessage([]byte(testUA)))
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	act, err := msgs[0].AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"browser": map[string]interface{}{
+			"family": "Mobile Safari", "major": "15", "minor": "3", "patch": "", "version": "15.3",
+		},
+		"os": map[string]interface{}{
+			"family": "iOS", "major": "15", "minor": "3", "patch": "1", "version": "15.3.1",
+		},
+		"device": map[string]interface{}{
+			"family": "iPhone", "brand": "Apple", "model": "iPhone", "type": "mobile",
+		},
+	}, act)
+}
+
+func TestProcessorFieldTarget(t *testing.T) {
+	proc, err := newProcessor("context.user_agent", "context.client")
+	require.NoError(t, err)
+
+	input := service.NewMessage(nil)
+	input.SetStructured(map[string]interface{}{
+		"id": "foo",
+		"context": map[string]interface{}{
+			"user_agent": testUA,
+		},
+	})
+
+	msgs, err := proc.Process(context.Background(), input)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	actBytes, err := msgs[0].AsBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "id": "foo",
+  "context": {
+    "user_agent": "`+testUA+`",
+    "client": {
+      "browser": {"family":"Mobile Safari","major":"15","minor":"3","patch":"","version":"15.3"},
+      "os": {"family":"iOS","major":"15","minor":"3","patch":"1","version":"15.3.1"},
+      "device": {"family":"iPhone","brand":"Apple","model":"iPhone","type":"mobile"}
+    }
+  }
+}`, string(actBytes))
+
+	// The original message must not be modified.
+	inBytes, err := input.AsBytes()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"foo","context":{"user_agent":"`+testUA+`"}}`, string(inBytes))
+}
+
+func TestProcessorFieldNoTarget(t *testing.T) {
+	proc, err := newProcessor("ua", "")
+	require.NoError(t, err)
+
+	msgs, err := proc.Process(context.Background(), service.NewMessage([]byte(`{"ua":"curl/7.79.1"}`)))
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	act, err := msgs[0].AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, "curl", act.(map[string]interface{})["browser"].(map[string]interface{})["family"])
+	assert.Equal(t, "other", act.(map[string]interface{})["device"].(map[string]interface{})["type"])
+}
+
+func TestProcessorErrors(t *testing.T) {
+	proc, err := newProcessor("ua", "parsed")
+	require.NoError(t, err)
+
+	for _, input := range []string{
+		`not json`,
+		`{"nope":"curl/7.79.1"}`,
+		`{"ua":10}`,
+	} {
+		_, err = proc.Process(context.Background(), service.NewMessage([]byte(input)))
+		assert.Error(t, err, input)
+	}
+
+	_, err = newProcessor("", "parsed")
+	require.Error(t, err)
+}
+
+func TestProcessorConfig(t *testing.T) {
+	conf, err := processorConfig().ParseYAML(`
+field: ua
+target: parsed
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newProcessorFromConfig(conf)
+	require.NoError(t, err)
+	assert.Equal(t, "ua", proc.field)
+	assert.Equal(t, "parsed", proc.target)
+}
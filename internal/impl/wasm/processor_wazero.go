@@ -0,0 +1,328 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/benthosdev/benthos/v4/internal/wasmpool"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	wazeroHostModule = "benthos_wasm"
+
+	wzFieldModulePath     = "module_path"
+	wzFieldFunction       = "function"
+	wzFieldTimeout        = "timeout"
+	wzFieldMaxMemoryPages = "max_memory_pages"
+)
+
+func wazeroProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Summary("Executes a function exported by a WASM module for each message.").
+		Description(`
+This processor uses [Wazero](https://github.com/tetratelabs/wazero) to execute a WASM module (with support for WASI), calling a specific function for each message being processed. This makes it possible to distribute processing logic as ` + "`.wasm`" + ` artifacts that can be loaded without recompiling Benthos.
+
+The target function is called without arguments, and from within the WASM module it is possible to query and mutate the message being processed via the following functions imported from the module ` + "`benthos_wasm`" + `:
+
+- ` + "`v0_msg_as_bytes() i64`" + ` returns the raw contents of the message, where the upper 32 bits of the result are a pointer and the lower 32 bits are the length.
+- ` + "`v0_msg_set_bytes(ptr i32, len i32)`" + ` sets the raw contents of the message.
+- ` + "`v0_msg_get_meta(key_ptr i32, key_len i32) i64`" + ` returns the value of a metadata key in the same form as ` + "`v0_msg_as_bytes`" + `, where a length of zero indicates an empty or missing value.
+- ` + "`v0_msg_set_meta(key_ptr i32, key_len i32, value_ptr i32, value_len i32)`" + ` sets the value of a metadata key.
+
+In order to return data to the module the functions ` + "`v0_msg_as_bytes`" + ` and ` + "`v0_msg_get_meta`" + ` call a function ` + "`allocate(size i32) i32`" + ` that must be exported by the module. If the module also exports a function ` + "`deallocate(ptr i32)`" + ` then it is called for each allocation once the processed message has been returned.
+
+Instances of the module are not shared between concurrent executions of the processor, and therefore state held by a module should not be relied upon to persist across messages. An instance that fails or exceeds the ` + "`timeout`" + ` while processing a message is discarded.
+
+Anything written by the module to stdout is logged at the ` + "`INFO`" + ` level and anything written to stderr is logged at the ` + "`WARN`" + ` level.`).
+		Field(service.NewStringField(wzFieldModulePath).
+			Description("The path of the target WASM module to execute.")).
+		Field(service.NewStringField(wzFieldFunction).
+			Description("The name of the function exported by the target WASM module to run for each message.").
+			Default("process")).
+		Field(service.NewDurationField(wzFieldTimeout).
+			Description("The maximum period of time to wait for the function to process a message before it is aborted.").
+			Default("5s").
+			Advanced()).
+		Field(service.NewIntField(wzFieldMaxMemoryPages).
+			Description("The maximum number of 64KiB pages of memory that each instance of the module can use.").
+			Default(1024).
+			Advanced()).
+		Version("4.2.0")
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"wasm", wazeroProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newWazeroProcessorFromConfig(conf, mgr.Logger())
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type wazeroProcessor struct {
+	runtime  wazero.Runtime
+	pool     *wasmpool.Pool
+	function string
+	timeout  time.Duration
+}
+
+func newWazeroProcessorFromConfig(conf *service.ParsedConfig, logger *service.Logger) (*wazeroProcessor, error) {
+	modulePath, err := conf.FieldString(wzFieldModulePath)
+	if err != nil {
+		return nil, err
+	}
+	function, err := conf.FieldString(wzFieldFunction)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := conf.FieldDuration(wzFieldTimeout)
+	if err != nil {
+		return nil, err
+	}
+	maxMemoryPages, err := conf.FieldInt(wzFieldMaxMemoryPages)
+	if err != nil {
+		return nil, err
+	}
+	if maxMemoryPages <= 0 || maxMemoryPages > 65536 {
+		return nil, fmt.Errorf("field %v must be between 1 and 65536, got %v", wzFieldMaxMemoryPages, maxMemoryPages)
+	}
+	moduleBytes, err := os.ReadFile(modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module: %w", err)
+	}
+	return newWazeroProcessor(moduleBytes, function, timeout, uint32(maxMemoryPages),
+		&logWriter{log: logger.Infof}, &logWriter{log: logger.Warnf})
+}
+
+func newWazeroProcessor(moduleBytes []byte, function string, timeout time.Duration, maxMemoryPages uint32, stdout, stderr io.Writer) (*wazeroProcessor, error) {
+	ctx := context.Background()
+
+	r, err := wasmpool.NewRuntime(ctx, maxMemoryPages)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := r.NewHostModuleBuilder(wazeroHostModule)
+	for name, fn := range hostFunctions {
+		builder.NewFunctionBuilder().
+			WithGoModuleFunction(fn.fn, fn.params, fn.results).
+			Export(name)
+	}
+	if _, err := builder.Instantiate(ctx); err != nil {
+		_ = r.Close(ctx)
+		return nil, err
+	}
+
+	compiled, err := r.CompileModule(ctx, moduleBytes)
+	if err != nil {
+		_ = r.Close(ctx)
+		return nil, fmt.Errorf("failed to compile module: %w", err)
+	}
+
+	modConfig := wazero.NewModuleConfig().
+		WithName("").
+		WithStdout(stdout).
+		WithStderr(stderr)
+
+	p := &wazeroProcessor{
+		runtime:  r,
+		function: function,
+		timeout:  timeout,
+	}
+	p.pool = wasmpool.New(r, compiled, modConfig, func(inst *wasmpool.Instance) error {
+		if inst.Function(function) == nil {
+			return fmt.Errorf("does not export function '%v'", function)
+		}
+		return nil
+	})
+
+	// Instantiate a first instance in order to surface problems with the
+	// module at construction rather than when the first message arrives.
+	inst, err := p.pool.Get(ctx)
+	if err != nil {
+		_ = r.Close(ctx)
+		return nil, fmt.Errorf("module %w", err)
+	}
+	p.pool.Put(inst)
+	return p, nil
+}
+
+func (p *wazeroProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	ctx, done := context.WithTimeout(ctx, p.timeout)
+	defer done()
+
+	inst, err := p.pool.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("module %w", err)
+	}
+
+	resMsg := msg.Copy()
+	runner := &moduleRunner{inst: inst, msg: resMsg}
+	if err := runner.run(ctx, p.function); err != nil {
+		// The state of an instance that failed mid-execution can't be trusted
+		// and so it is discarded.
+		p.pool.Discard(inst)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("execution exceeded the time limit of %v", p.timeout)
+		}
+		return nil, err
+	}
+	p.pool.Put(inst)
+	return service.MessageBatch{resMsg}, nil
+}
+
+func (p *wazeroProcessor) Close(ctx context.Context) error {
+	p.pool.Close()
+	return p.runtime.Close(ctx)
+}
+
+//------------------------------------------------------------------------------
+
+// logWriter logs each write made by a module to stdout or stderr, with
+// trailing line breaks removed.
+type logWriter struct {
+	log func(format string, args ...interface{})
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	if line := strings.TrimRight(string(p), "\r\n"); line != "" {
+		w.log("%s", line)
+	}
+	return len(p), nil
+}
+
+//------------------------------------------------------------------------------
+
+type moduleRunner struct {
+	inst *wasmpool.Instance
+	msg  *service.Message
+
+	pending []uint64
+}
+
+type runnerCtxKey struct{}
+
+func (r *moduleRunner) run(ctx context.Context, function string) error {
+	if _, err := r.inst.Function(function).Call(context.WithValue(ctx, runnerCtxKey{}, r)); err != nil {
+		return err
+	}
+	if deallocate := r.inst.Function("deallocate"); deallocate != nil {
+		for _, ptr := range r.pending {
+			if _, err := deallocate.Call(ctx, ptr); err != nil {
+				return fmt.Errorf("failed to deallocate module memory: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *moduleRunner) read(ptr, size uint32) []byte {
+	b, ok := r.inst.Module.Memory().Read(ptr, size)
+	if !ok {
+		panic(fmt.Errorf("memory read out of range: %v bytes at %v", size, ptr))
+	}
+	// The returned slice is a view of the module memory, which could be
+	// modified by the module at any point after we return.
+	cpy := make([]byte, len(b))
+	copy(cpy, b)
+	return cpy
+}
+
+// write copies data into memory allocated by the module and returns the
+// pointer and length packed into a single value.
+func (r *moduleRunner) write(ctx context.Context, data []byte) uint64 {
+	if len(data) == 0 {
+		return 0
+	}
+	allocate := r.inst.Function("allocate")
+	if allocate == nil {
+		panic(errors.New("module does not export function 'allocate'"))
+	}
+	res, err := allocate.Call(ctx, uint64(len(data)))
+	if err != nil {
+		panic(fmt.Errorf("failed to allocate module memory: %w", err))
+	}
+	ptr := uint32(res[0])
+	if !r.inst.Module.Memory().Write(ptr, data) {
+		panic(fmt.Errorf("memory write out of range: %v bytes at %v", len(data), ptr))
+	}
+	r.pending = append(r.pending, uint64(ptr))
+	return uint64(ptr)<<32 | uint64(len(data))
+}
+
+//------------------------------------------------------------------------------
+
+type hostFunction struct {
+	fn      api.GoModuleFunc
+	params  []api.ValueType
+	results []api.ValueType
+}
+
+func runnerFromCtx(ctx context.Context) *moduleRunner {
+	r, _ := ctx.Value(runnerCtxKey{}).(*moduleRunner)
+	if r == nil {
+		panic(errors.New("function called outside of message processing"))
+	}
+	return r
+}
+
+var (
+	i32 = api.ValueTypeI32
+	i64 = api.ValueTypeI64
+)
+
+var hostFunctions = map[string]hostFunction{
+	"v0_msg_as_bytes": {
+		fn: func(ctx context.Context, mod api.Module, stack []uint64) {
+			r := runnerFromCtx(ctx)
+			b, err := r.msg.AsBytes()
+			if err != nil {
+				panic(err)
+			}
+			stack[0] = r.write(ctx, b)
+		},
+		results: []api.ValueType{i64},
+	},
+	"v0_msg_set_bytes": {
+		fn: func(ctx context.Context, mod api.Module, stack []uint64) {
+			r := runnerFromCtx(ctx)
+			r.msg.SetBytes(r.read(api.DecodeU32(stack[0]), api.DecodeU32(stack[1])))
+		},
+		params: []api.ValueType{i32, i32},
+	},
+	"v0_msg_get_meta": {
+		fn: func(ctx context.Context, mod api.Module, stack []uint64) {
+			r := runnerFromCtx(ctx)
+			key := r.read(api.DecodeU32(stack[0]), api.DecodeU32(stack[1]))
+			v, _ := r.msg.MetaGet(string(key))
+			stack[0] = r.write(ctx, []byte(v))
+		},
+		params:  []api.ValueType{i32, i32},
+		results: []api.ValueType{i64},
+	},
+	"v0_msg_set_meta": {
+		fn: func(ctx context.Context, mod api.Module, stack []uint64) {
+			r := runnerFromCtx(ctx)
+			key := r.read(api.DecodeU32(stack[0]), api.DecodeU32(stack[1]))
+			value := r.read(api.DecodeU32(stack[2]), api.DecodeU32(stack[3]))
+			r.msg.MetaSet(string(key), string(value))
+		},
+		params: []api.ValueType{i32, i32, i32, i32},
+	},
+}
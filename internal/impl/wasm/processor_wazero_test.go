@@ -0,0 +1,318 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func leb128(v uint32) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		if v >>= 7; v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+func wasmStr(s string) []byte {
+	return append(leb128(uint32(len(s))), s...)
+}
+
+func wasmVec(items ...[]byte) []byte {
+	b := leb128(uint32(len(items)))
+	for _, item := range items {
+		b = append(b, item...)
+	}
+	return b
+}
+
+func wasmSection(id byte, contents []byte) []byte {
+	return append(append([]byte{id}, leb128(uint32(len(contents)))...), contents...)
+}
+
+func wasmBody(locals []byte, code ...byte) []byte {
+	body := append(locals, code...)
+	return append(leb128(uint32(len(body))), body...)
+}
+
+// testModule assembles a module that, when its function `process` is called,
+// sets the metadata key `seen` to the original message contents and then sets
+// the message contents to `hello`. Memory is allocated with a bump allocator
+// that counts calls to `deallocate` in the first four bytes of memory.
+func testModule() []byte {
+	const (
+		i32, i64 = 0x7f, 0x7e
+	)
+
+	funcType := func(params, results []byte) []byte {
+		b := append([]byte{0x60}, leb128(uint32(len(params)))...)
+		b = append(b, params...)
+		b = append(b, leb128(uint32(len(results)))...)
+		return append(b, results...)
+	}
+	importFunc := func(name string, typeIdx byte) []byte {
+		b := append(wasmStr("benthos_wasm"), wasmStr(name)...)
+		return append(b, 0x00, typeIdx)
+	}
+	export := func(name string, kind, idx byte) []byte {
+		return append(wasmStr(name), kind, idx)
+	}
+
+	var m []byte
+	m = append(m, 0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00)
+	m = append(m, wasmSection(1, wasmVec(
+		funcType(nil, []byte{i64}),                // 0: as_bytes
+		funcType([]byte{i32, i32}, nil),           // 1: set_bytes
+		funcType([]byte{i32, i32, i32, i32}, nil), // 2: set_meta
+		funcType(nil, nil),                        // 3: process
+		funcType([]byte{i32}, []byte{i32}),        // 4: allocate
+		funcType([]byte{i32}, nil),                // 5: deallocate
+	))...)
+	m = append(m, wasmSection(2, wasmVec(
+		importFunc("v0_msg_as_bytes", 0),
+		importFunc("v0_msg_set_bytes", 1),
+		importFunc("v0_msg_set_meta", 2),
+	))...)
+	m = append(m, wasmSection(3, wasmVec([]byte{3}, []byte{4}, []byte{5}))...)
+	m = append(m, wasmSection(5, wasmVec([]byte{0x00, 0x01}))...)
+	m = append(m, wasmSection(6, wasmVec([]byte{i32, 0x01, 0x41, 0x80, 0x08, 0x0b}))...)
+	m = append(m, wasmSection(7, wasmVec(
+		export("memory", 0x02, 0),
+		export("process", 0x00, 3),
+		export("allocate", 0x00, 4),
+		export("deallocate", 0x00, 5),
+	))...)
+	m = append(m, wasmSection(10, wasmVec(
+		// process
+		wasmBody([]byte{0x01, 0x01, i64},
+			0x10, 0x00, 0x21, 0x00, // call as_bytes, local.set 0
+			0x41, 0x04, 0x41, 0x04, // key ptr 4, key len 4
+			0x20, 0x00, 0x42, 0x20, 0x88, 0xa7, // value ptr
+			0x20, 0x00, 0xa7, // value len
+			0x10, 0x02, // call set_meta
+			0x41, 0x08, 0x41, 0x05, // ptr 8, len 5
+			0x10, 0x01, // call set_bytes
+			0x0b,
+		),
+		// allocate
+		wasmBody([]byte{0x00},
+			0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00,
+			0x0b,
+		),
+		// deallocate
+		wasmBody([]byte{0x00},
+			0x41, 0x00, 0x41, 0x00, 0x28, 0x02, 0x00, 0x41, 0x01, 0x6a, 0x36, 0x02, 0x00,
+			0x0b,
+		),
+	))...)
+	m = append(m, wasmSection(11, wasmVec(
+		append([]byte{0x00, 0x41, 0x04, 0x0b}, wasmStr("seenhello")...),
+	))...)
+	return m
+}
+
+func TestWazeroProcessor(t *testing.T) {
+	proc, err := newWazeroProcessor(testModule(), "process", time.Second, 1024, io.Discard, io.Discard)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, proc.Close(context.Background()))
+	})
+
+	inMsg := service.NewMessage([]byte("first message"))
+	inMsg.MetaSet("foo", "bar")
+
+	res, err := proc.Process(context.Background(), inMsg)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	mBytes, err := res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(mBytes))
+
+	v, _ := res[0].MetaGet("seen")
+	assert.Equal(t, "first message", v)
+
+	v, _ = res[0].MetaGet("foo")
+	assert.Equal(t, "bar", v)
+
+	inBytes, err := inMsg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "first message", string(inBytes))
+
+	// Each call to as_bytes results in one allocation that must be returned to
+	// the module, which our module counts at the start of its memory.
+	inst, err := proc.pool.Get(context.Background())
+	require.NoError(t, err)
+	counter, ok := inst.Module.Memory().ReadUint32Le(0)
+	require.True(t, ok)
+	assert.Equal(t, uint32(1), counter)
+	proc.pool.Put(inst)
+}
+
+func TestWazeroProcessorParallel(t *testing.T) {
+	proc, err := newWazeroProcessor(testModule(), "process", time.Second, 1024, io.Discard, io.Discard)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, proc.Close(context.Background()))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				res, err := proc.Process(context.Background(), service.NewMessage([]byte("meow")))
+				require.NoError(t, err)
+				require.Len(t, res, 1)
+
+				v, _ := res[0].MetaGet("seen")
+				assert.Equal(t, "meow", v)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWazeroProcessorConfig(t *testing.T) {
+	modPath := filepath.Join(t.TempDir(), "test.wasm")
+	require.NoError(t, os.WriteFile(modPath, testModule(), 0o644))
+
+	conf, err := wazeroProcessorConfig().ParseYAML(`
+module_path: `+modPath+`
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newWazeroProcessorFromConfig(conf, nil)
+	require.NoError(t, err)
+	require.NoError(t, proc.Close(context.Background()))
+
+	conf, err = wazeroProcessorConfig().ParseYAML(`
+module_path: `+modPath+`
+function: nope
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newWazeroProcessorFromConfig(conf, nil)
+	require.EqualError(t, err, "module does not export function 'nope'")
+
+	conf, err = wazeroProcessorConfig().ParseYAML(`
+module_path: `+modPath+`
+max_memory_pages: 0
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newWazeroProcessorFromConfig(conf, nil)
+	require.EqualError(t, err, "field max_memory_pages must be between 1 and 65536, got 0")
+}
+
+// spinModule assembles a module with a function `process` that never returns.
+func spinModule() []byte {
+	var m []byte
+	m = append(m, 0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00)
+	m = append(m, wasmSection(1, wasmVec([]byte{0x60, 0x00, 0x00}))...)
+	m = append(m, wasmSection(3, wasmVec([]byte{0}))...)
+	m = append(m, wasmSection(7, wasmVec(append(wasmStr("process"), 0x00, 0)))...)
+	m = append(m, wasmSection(10, wasmVec(
+		wasmBody([]byte{0x00}, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b),
+	))...)
+	return m
+}
+
+// printModule assembles a module with a function `process` that writes
+// `hello` to stdout and `oh no` to stderr via WASI.
+func printModule() []byte {
+	const i32 = 0x7f
+
+	segment := func(offset byte, data ...byte) []byte {
+		return append([]byte{0x00, 0x41, offset, 0x0b}, wasmStr(string(data))...)
+	}
+
+	var m []byte
+	m = append(m, 0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00)
+	m = append(m, wasmSection(1, wasmVec(
+		[]byte{0x60, 0x04, i32, i32, i32, i32, 0x01, i32}, // 0: fd_write
+		[]byte{0x60, 0x00, 0x00},                          // 1: process
+	))...)
+	m = append(m, wasmSection(2, wasmVec(
+		append(append(wasmStr("wasi_snapshot_preview1"), wasmStr("fd_write")...), 0x00, 0),
+	))...)
+	m = append(m, wasmSection(3, wasmVec([]byte{1}))...)
+	m = append(m, wasmSection(5, wasmVec([]byte{0x00, 0x01}))...)
+	m = append(m, wasmSection(7, wasmVec(
+		append(wasmStr("memory"), 0x02, 0),
+		append(wasmStr("process"), 0x00, 1),
+	))...)
+	m = append(m, wasmSection(10, wasmVec(
+		wasmBody([]byte{0x00},
+			0x41, 0x01, 0x41, 0x10, 0x41, 0x01, 0x41, 0x08, 0x10, 0x00, 0x1a, // fd_write(1, 16, 1, 8)
+			0x41, 0x02, 0x41, 0x18, 0x41, 0x01, 0x41, 0x08, 0x10, 0x00, 0x1a, // fd_write(2, 24, 1, 8)
+			0x0b,
+		),
+	))...)
+	m = append(m, wasmSection(11, wasmVec(
+		segment(0x10, 0x20, 0, 0, 0, 0x06, 0, 0, 0), // iovec at 16: {32, 6}
+		segment(0x18, 0x30, 0, 0, 0, 0x06, 0, 0, 0), // iovec at 24: {48, 6}
+		segment(0x20, []byte("hello\n")...),
+		segment(0x30, []byte("oh no\n")...),
+	))...)
+	return m
+}
+
+func TestWazeroProcessorTimeout(t *testing.T) {
+	proc, err := newWazeroProcessor(spinModule(), "process", time.Millisecond*100, 1024, io.Discard, io.Discard)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, proc.Close(context.Background()))
+	})
+
+	_, err = proc.Process(context.Background(), service.NewMessage([]byte("meow")))
+	require.EqualError(t, err, "execution exceeded the time limit of 100ms")
+}
+
+func TestWazeroProcessorOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	proc, err := newWazeroProcessor(printModule(), "process", time.Second, 1024, &stdout, &stderr)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, proc.Close(context.Background()))
+	})
+
+	res, err := proc.Process(context.Background(), service.NewMessage([]byte("meow")))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	assert.Equal(t, "hello\n", stdout.String())
+	assert.Equal(t, "oh no\n", stderr.String())
+}
+
+func TestWazeroLogWriter(t *testing.T) {
+	var lines []string
+	w := &logWriter{log: func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}}
+
+	for _, s := range []string{"hello\n", "\n", "world\r\n", "100%"} {
+		n, err := w.Write([]byte(s))
+		require.NoError(t, err)
+		assert.Equal(t, len(s), n)
+	}
+	assert.Equal(t, []string{"hello", "world", "100%"}, lines)
+}
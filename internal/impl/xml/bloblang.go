@@ -1,7 +1,9 @@
 package xml
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
@@ -14,10 +16,10 @@ func init() {
 			Description(`
 Attempts to parse a string as an XML document and returns a structured result, where elements appear as keys of an object according to the following rules:
 
-- If an element contains attributes they are parsed by prefixing a hyphen, `+"`-`"+`, to the attribute label.
+- If an element contains attributes they are parsed by prefixing a hyphen, `+"`-`"+`, to the attribute label. A different prefix can be set with the parameter `+"`attribute_prefix`"+`.
 - If the element is a simple element and has attributes, the element value is given the key `+"`#text`"+`.
 - XML comments, directives, and process instructions are ignored.
-- When elements are repeated the resulting JSON value is an array.
+- When elements are repeated the resulting JSON value is an array. Elements listed in the parameter `+"`force_arrays`"+` always result in an array, even when they appear once.
 - If cast is true, try to cast values to numbers and booleans instead of returning strings.
 `).
 			Example("", `root.doc = this.doc.parse_xml()`, [2]string{
@@ -32,9 +34,19 @@ Attempts to parse a string as an XML document and returns a structured result, w
 				`{"doc":"<root><title>This is a title</title><number id=99>123</number><bool>True</bool></root>"}`,
 				`{"doc":{"root":{"bool":true,"number":{"#text":123,"-id":99},"title":"This is a title"}}}`,
 			}).
+			Example("", `root.doc = this.doc.parse_xml(attribute_prefix: "@", force_arrays: ["item"])`, [2]string{
+				`{"doc":"<order id=\"1\"><item sku=\"a\">Shoes</item></order>"}`,
+				`{"doc":{"order":{"@id":"1","item":[{"#text":"Shoes","@sku":"a"}]}}}`,
+			}).
 			Param(bloblang.NewBoolParam("cast").
 				Description("whether to try to cast values that are numbers and booleans to the right type.").
-				Optional().Default(false)),
+				Optional().Default(false)).
+			Param(bloblang.NewStringParam("attribute_prefix").
+				Description("A prefix added to the labels of attributes.").
+				Default("-")).
+			Param(bloblang.NewAnyParam("force_arrays").
+				Description("An array of element names that should always be parsed as arrays.").
+				Default([]interface{}{})),
 		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
 			castOpt, err := args.GetOptionalBool("cast")
 			if err != nil {
@@ -44,14 +56,166 @@ Attempts to parse a string as an XML document and returns a structured result, w
 			if castOpt != nil {
 				cast = *castOpt
 			}
+			attrPrefix, err := attributePrefixParam(args)
+			if err != nil {
+				return nil, err
+			}
+			forceArrays, err := forceArraysParam(args)
+			if err != nil {
+				return nil, err
+			}
 			return bloblang.BytesMethod(func(xmlBytes []byte) (interface{}, error) {
 				xmlObj, err := ToMap(xmlBytes, cast)
 				if err != nil {
 					return nil, fmt.Errorf("failed to parse value as XML: %w", err)
 				}
+				if attrPrefix != defaultAttrPrefix || len(forceArrays) > 0 {
+					xmlObj = reshapeParsed(xmlObj, attrPrefix, forceArrays)
+				}
 				return xmlObj, nil
 			}), nil
 		}); err != nil {
 		panic(err)
 	}
+
+	if err := bloblang.RegisterMethodV2("format_xml",
+		bloblang.NewPluginSpec().
+			Category(query.MethodCategoryParsing).
+			Version("4.2.0").
+			Description(`
+Serializes an object into an XML document following the same rules as the method `+"[`parse_xml`](#parse_xml)"+`, where keys prefixed with the `+"`attribute_prefix`"+` are written as attributes and the key `+"`#text`"+` is written as the value of an element with attributes. When the object contains exactly one key it is used as the root element, otherwise the keys are wrapped in a root element `+"`doc`"+`.
+
+The result is a byte array, use the method `+"[`string`](#string)"+` in order to coerce it into a string.`).
+			Example("", `root = this.format_xml()`, [2]string{
+				`{"order":{"-id":"1","item":[{"#text":"Shoes","-sku":"a"},"Socks"]}}`,
+				`<order id="1"><item sku="a">Shoes</item><item>Socks</item></order>`,
+			}).
+			Example("", `root.doc = this.doc.format_xml(attribute_prefix: "@", indent: "  ").string()`, [2]string{
+				`{"doc":{"order":{"@id":"1","item":"Shoes"}}}`,
+				`{"doc":"<order id=\"1\">\n  <item>Shoes</item>\n</order>"}`,
+			}).
+			Param(bloblang.NewStringParam("attribute_prefix").
+				Description("The prefix of keys that are written as attributes.").
+				Default("-")).
+			Param(bloblang.NewStringParam("indent").
+				Description("An indentation string, when empty the document is written on a single line.").
+				Default("")),
+		func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+			attrPrefix, err := attributePrefixParam(args)
+			if err != nil {
+				return nil, err
+			}
+			indent, err := args.GetString("indent")
+			if err != nil {
+				return nil, err
+			}
+			return bloblang.ObjectMethod(func(obj map[string]interface{}) (interface{}, error) {
+				if attrPrefix != defaultAttrPrefix {
+					obj = reshapeFormatted(obj, attrPrefix)
+				}
+				xmlBytes, err := FromMap(obj, indent)
+				if err != nil {
+					return nil, fmt.Errorf("failed to format value as XML: %w", err)
+				}
+				return xmlBytes, nil
+			}), nil
+		}); err != nil {
+		panic(err)
+	}
+}
+
+const defaultAttrPrefix = "-"
+
+func attributePrefixParam(args *bloblang.ParsedParams) (string, error) {
+	attrPrefix, err := args.GetString("attribute_prefix")
+	if err != nil {
+		return "", err
+	}
+	if attrPrefix == "" {
+		return "", errors.New("attribute_prefix must not be empty")
+	}
+	return attrPrefix, nil
+}
+
+func forceArraysParam(args *bloblang.ParsedParams) (map[string]struct{}, error) {
+	v, err := args.Get("force_arrays")
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("force_arrays must be an array of strings, got %T", v)
+	}
+	names := make(map[string]struct{}, len(arr))
+	for _, e := range arr {
+		name, ok := e.(string)
+		if !ok {
+			return nil, fmt.Errorf("force_arrays must be an array of strings, got element %T", e)
+		}
+		names[name] = struct{}{}
+	}
+	return names, nil
+}
+
+// reshapeParsed replaces the default attribute prefix of a parsed document and
+// wraps the values of elements that must always be arrays.
+func reshapeParsed(obj map[string]interface{}, attrPrefix string, forceArrays map[string]struct{}) map[string]interface{} {
+	var reshapeValue func(v interface{}) interface{}
+	reshapeValue = func(v interface{}) interface{} {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			return reshapeParsed(t, attrPrefix, forceArrays)
+		case []interface{}:
+			for i, e := range t {
+				t[i] = reshapeValue(e)
+			}
+			return t
+		}
+		return v
+	}
+
+	newObj := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		if strings.HasPrefix(k, defaultAttrPrefix) {
+			newObj[attrPrefix+strings.TrimPrefix(k, defaultAttrPrefix)] = v
+			continue
+		}
+		v = reshapeValue(v)
+		if _, force := forceArrays[k]; force {
+			if _, isArr := v.([]interface{}); !isArr {
+				v = []interface{}{v}
+			}
+		}
+		newObj[k] = v
+	}
+	return newObj
+}
+
+// reshapeFormatted replaces a custom attribute prefix of a document with the
+// default prefix expected by the encoder.
+func reshapeFormatted(obj map[string]interface{}, attrPrefix string) map[string]interface{} {
+	var reshapeValue func(v interface{}) interface{}
+	reshapeValue = func(v interface{}) interface{} {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			return reshapeFormatted(t, attrPrefix)
+		case []interface{}:
+			newArr := make([]interface{}, len(t))
+			for i, e := range t {
+				newArr[i] = reshapeValue(e)
+			}
+			return newArr
+		}
+		return v
+	}
+
+	newObj := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		if strings.HasPrefix(k, attrPrefix) {
+			newObj[defaultAttrPrefix+strings.TrimPrefix(k, attrPrefix)] = v
+			continue
+		}
+		newObj[k] = reshapeValue(v)
+	}
+	return newObj
 }
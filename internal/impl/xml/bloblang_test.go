@@ -39,6 +39,24 @@ func TestParseXML(t *testing.T) {
 			args:   []interface{}{true},
 			exp:    map[string]interface{}{"root": map[string]interface{}{"bool": true, "number": map[string]interface{}{"#text": float64(123), "-id": float64(99)}, "title": "This is a title"}},
 		},
+		{
+			name:   "parsing with attribute prefix and forced arrays",
+			method: "parse_xml",
+			target: `<root id="1"><item sku="a">foo</item><other>bar</other></root>`,
+			args:   []interface{}{false, "@", []interface{}{"item"}},
+			exp: map[string]interface{}{"root": map[string]interface{}{
+				"@id":   "1",
+				"item":  []interface{}{map[string]interface{}{"#text": "foo", "@sku": "a"}},
+				"other": "bar",
+			}},
+		},
+		{
+			name:   "parsing forced arrays that are already arrays",
+			method: "parse_xml",
+			target: `<root><item>foo</item><item>bar</item></root>`,
+			args:   []interface{}{false, "-", []interface{}{"item"}},
+			exp:    map[string]interface{}{"root": map[string]interface{}{"item": []interface{}{"foo", "bar"}}},
+		},
 	}
 
 	for _, test := range testCases {
@@ -63,3 +81,66 @@ func TestParseXML(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatXML(t *testing.T) {
+	testCases := []struct {
+		name   string
+		target interface{}
+		args   []interface{}
+		exp    string
+	}{
+		{
+			name: "simple formatting",
+			target: map[string]interface{}{"root": map[string]interface{}{
+				"-id":   "1",
+				"title": "foo & bar",
+				"item":  []interface{}{map[string]interface{}{"#text": "a", "-n": "1"}, "b"},
+			}},
+			args: []interface{}{},
+			exp:  `<root id="1"><item n="1">a</item><item>b</item><title>foo &amp; bar</title></root>`,
+		},
+		{
+			name: "attribute prefix and indent",
+			target: map[string]interface{}{"root": map[string]interface{}{
+				"@id":   "1",
+				"title": "foo",
+			}},
+			args: []interface{}{"@", "  "},
+			exp:  "<root id=\"1\">\n  <title>foo</title>\n</root>",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			targetClone := query.IClone(test.target)
+
+			fn, err := query.InitMethodHelper("format_xml", query.NewLiteralFunction("", targetClone), test.args...)
+			require.NoError(t, err)
+
+			res, err := fn.Exec(query.FunctionContext{
+				Maps:     map[string]query.Function{},
+				Index:    0,
+				MsgBatch: nil,
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.exp, string(res.([]byte)))
+			assert.Equal(t, test.target, targetClone)
+		})
+	}
+}
+
+func TestXMLMethodErrors(t *testing.T) {
+	_, err := query.InitMethodHelper("parse_xml", query.NewLiteralFunction("", "<root/>"), false, "")
+	require.EqualError(t, err, "attribute_prefix must not be empty")
+
+	_, err = query.InitMethodHelper("parse_xml", query.NewLiteralFunction("", "<root/>"), false, "-", "item")
+	require.EqualError(t, err, "force_arrays must be an array of strings, got string")
+
+	fn, err := query.InitMethodHelper("format_xml", query.NewLiteralFunction("", "<root/>"))
+	require.NoError(t, err)
+
+	_, err = fn.Exec(query.FunctionContext{Maps: map[string]query.Function{}})
+	require.Error(t, err)
+}
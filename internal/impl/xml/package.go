@@ -16,6 +16,7 @@ func init() {
 	dec.Strict = false
 	dec.CharsetReader = charset.NewReaderLabel
 	mxj.CustomDecoder = dec
+	mxj.XMLEscapeChars(true)
 }
 
 // ToMap parses a byte slice as XML and returns a generic structure that can be
@@ -27,3 +28,13 @@ func ToMap(xmlBytes []byte, cast bool) (map[string]interface{}, error) {
 	}
 	return map[string]interface{}(root), nil
 }
+
+// FromMap serializes a generic structure as XML, where the structure follows
+// the same format as the result of ToMap. When indent is non-empty the document
+// is written with each element on a new line.
+func FromMap(root map[string]interface{}, indent string) ([]byte, error) {
+	if indent != "" {
+		return mxj.Map(root).XmlIndent("", indent)
+	}
+	return mxj.Map(root).Xml()
+}
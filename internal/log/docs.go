@@ -10,8 +10,15 @@ func Spec() docs.FieldSpecs {
 		).HasDefault("INFO").LinterFunc(nil),
 		docs.FieldString("format", "Set the format of emitted logs.").HasOptions("json", "logfmt").HasDefault("logfmt"),
 		docs.FieldBool("add_timestamp", "Whether to include timestamps in logs.").HasDefault(false),
-		docs.FieldString("static_fields", "A map of key/value pairs to add to each structured log.").Map().HasDefault(map[string]string{
+		docs.FieldInterpolatedString("static_fields", "A map of key/value pairs to add to each structured log. Values support [interpolation functions](/docs/configuration/interpolation#bloblang-queries), which are resolved once when the logger is created.").Map().HasDefault(map[string]string{
 			"@service": "benthos",
 		}),
+		docs.FieldObject("sampling", "Sample logs in order to reduce the volume of identical logs emitted within a period. Logs are counted per level and message, where the first N of each are emitted and then one in every M thereafter.").WithChildren(
+			docs.FieldBool("enabled", "Whether logs should be sampled.").HasDefault(false),
+			docs.FieldString("period", "The period over which logs are counted, after which the counts are reset.").HasDefault("1s"),
+			docs.FieldInt("first", "The number of identical logs emitted within each period before sampling begins.").HasDefault(100),
+			docs.FieldInt("thereafter", "Once sampling has begun, emit one in every this number of identical logs. Set to zero in order to drop all subsequent logs within the period.").HasDefault(100),
+		).Advanced(),
+		docs.FieldInt("rate_limit", "The maximum number of logs emitted per second across all levels, where any logs beyond this limit are dropped. Set to zero in order to disable the limit.").HasDefault(0).Advanced(),
 	}
 }
@@ -5,8 +5,13 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
 // Config holds configuration options for a logger object.
@@ -15,6 +20,8 @@ type Config struct {
 	Format       string            `json:"format" yaml:"format"`
 	AddTimeStamp bool              `json:"add_timestamp" yaml:"add_timestamp"`
 	StaticFields map[string]string `json:"static_fields" yaml:"static_fields"`
+	Sampling     SamplingConfig    `json:"sampling" yaml:"sampling"`
+	RateLimit    int               `json:"rate_limit" yaml:"rate_limit"`
 }
 
 // NewConfig returns a config struct with the default values for each field.
@@ -26,6 +33,8 @@ func NewConfig() Config {
 		StaticFields: map[string]string{
 			"@service": "benthos",
 		},
+		Sampling:  NewSamplingConfig(),
+		RateLimit: 0,
 	}
 }
 
@@ -74,9 +83,104 @@ func (conf *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 //------------------------------------------------------------------------------
 
+// LevelSetter is implemented by loggers that support modifying their log level
+// at runtime.
+type LevelSetter interface {
+	// SetLevel changes the log level of a logger and all loggers derived from
+	// it. When a path is provided the level only applies to loggers of
+	// components at or within that path.
+	SetLevel(level, path string) error
+
+	// ResetLevel removes a log level previously set for a path. When the path
+	// is empty the log level is reset to the configured level and all path
+	// levels are removed.
+	ResetLevel(path string)
+
+	// Levels returns the current log level and any levels set for specific
+	// component paths.
+	Levels() (level string, paths map[string]string)
+}
+
+// LevelChecker is implemented by loggers that are able to report whether
+// messages of a given level are currently being logged, which is useful for
+// avoiding expensive work that would otherwise be discarded.
+type LevelChecker interface {
+	LevelEnabled(level string) bool
+}
+
+var levelNames = map[logrus.Level]string{
+	logrus.PanicLevel: "NONE",
+	logrus.FatalLevel: "FATAL",
+	logrus.ErrorLevel: "ERROR",
+	logrus.WarnLevel:  "WARN",
+	logrus.InfoLevel:  "INFO",
+	logrus.DebugLevel: "DEBUG",
+	logrus.TraceLevel: "TRACE",
+}
+
+func parseLevel(level string) (logrus.Level, error) {
+	switch strings.ToUpper(level) {
+	case "OFF", "NONE":
+		return logrus.PanicLevel, nil
+	case "FATAL":
+		return logrus.FatalLevel, nil
+	case "ERROR":
+		return logrus.ErrorLevel, nil
+	case "WARN":
+		return logrus.WarnLevel, nil
+	case "INFO":
+		return logrus.InfoLevel, nil
+	case "DEBUG":
+		return logrus.DebugLevel, nil
+	case "TRACE", "ALL":
+		return logrus.TraceLevel, nil
+	}
+	return logrus.InfoLevel, fmt.Errorf("log level '%v' not recognized", level)
+}
+
+// levelState is shared by all loggers derived from the same root and tracks
+// the active log level along with any path specific levels.
+type levelState struct {
+	configured logrus.Level
+	global     uint32
+	hasPaths   int32
+
+	pathsMut sync.RWMutex
+	paths    map[string]logrus.Level
+}
+
+func newLevelState(level logrus.Level) *levelState {
+	return &levelState{
+		configured: level,
+		global:     uint32(level),
+		paths:      map[string]logrus.Level{},
+	}
+}
+
+func (s *levelState) enabled(path string, level logrus.Level) bool {
+	active := logrus.Level(atomic.LoadUint32(&s.global))
+	if path != "" && atomic.LoadInt32(&s.hasPaths) == 1 {
+		s.pathsMut.RLock()
+		longest := -1
+		for p, l := range s.paths {
+			if len(p) > longest && (path == p || strings.HasPrefix(path, p+".")) {
+				longest = len(p)
+				active = l
+			}
+		}
+		s.pathsMut.RUnlock()
+	}
+	return active >= level
+}
+
+//------------------------------------------------------------------------------
+
 // Logger is an object with support for levelled logging and modular components.
 type Logger struct {
-	entry *logrus.Entry
+	entry   *logrus.Entry
+	levels  *levelState
+	sampler *logSampler
+	path    string
 }
 
 // NewV2 returns a new logger from a config, or returns an error if the config
@@ -99,31 +203,101 @@ func NewV2(stream io.Writer, config Config) (Modular, error) {
 		return nil, fmt.Errorf("log format '%v' not recognized", config.Format)
 	}
 
-	switch strings.ToUpper(config.LogLevel) {
-	case "OFF", "NONE":
-		logger.Level = logrus.PanicLevel
-	case "FATAL":
-		logger.Level = logrus.FatalLevel
-	case "ERROR":
-		logger.Level = logrus.ErrorLevel
-	case "WARN":
-		logger.Level = logrus.WarnLevel
-	case "INFO":
-		logger.Level = logrus.InfoLevel
-	case "DEBUG":
-		logger.Level = logrus.DebugLevel
-	case "TRACE", "ALL":
-		logger.Level = logrus.TraceLevel
-		logger.Level = logrus.TraceLevel
-	}
+	// Levels are filtered by the logger itself in order to support changing
+	// them at runtime for specific component paths.
+	level, _ := parseLevel(config.LogLevel)
+	logger.Level = logrus.TraceLevel
 
 	sFields := logrus.Fields{}
 	for k, v := range config.StaticFields {
-		sFields[k] = v
+		e, err := bloblang.GlobalEnvironment().NewField(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse static field '%v': %v", k, err)
+		}
+		sFields[k] = e.String(0, message.QuickBatch(nil))
 	}
 	logEntry := logger.WithFields(sFields)
 
-	return &Logger{entry: logEntry}, nil
+	sampler, err := newLogSampler(config.Sampling, config.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Logger{entry: logEntry, levels: newLevelState(level), sampler: sampler}, nil
+}
+
+// SetLevel changes the log level of a logger and all loggers derived from it.
+// When a path is provided the level only applies to loggers of components at or
+// within that path.
+func (l *Logger) SetLevel(level, path string) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		atomic.StoreUint32(&l.levels.global, uint32(lvl))
+		return nil
+	}
+	l.levels.pathsMut.Lock()
+	l.levels.paths[path] = lvl
+	atomic.StoreInt32(&l.levels.hasPaths, 1)
+	l.levels.pathsMut.Unlock()
+	return nil
+}
+
+// ResetLevel removes a log level previously set for a path. When the path is
+// empty the log level is reset to the configured level and all path levels are
+// removed.
+func (l *Logger) ResetLevel(path string) {
+	l.levels.pathsMut.Lock()
+	defer l.levels.pathsMut.Unlock()
+
+	if path == "" {
+		atomic.StoreUint32(&l.levels.global, uint32(l.levels.configured))
+		l.levels.paths = map[string]logrus.Level{}
+	} else {
+		delete(l.levels.paths, path)
+	}
+	if len(l.levels.paths) == 0 {
+		atomic.StoreInt32(&l.levels.hasPaths, 0)
+	}
+}
+
+// LevelEnabled returns whether messages of a given level are currently logged
+// by this logger.
+func (l *Logger) LevelEnabled(level string) bool {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return false
+	}
+	return l.levels.enabled(l.path, lvl)
+}
+
+// Levels returns the current log level and any levels set for specific
+// component paths.
+func (l *Logger) Levels() (level string, paths map[string]string) {
+	level = levelNames[logrus.Level(atomic.LoadUint32(&l.levels.global))]
+
+	l.levels.pathsMut.RLock()
+	defer l.levels.pathsMut.RUnlock()
+
+	paths = make(map[string]string, len(l.levels.paths))
+	for p, lvl := range l.levels.paths {
+		paths[p] = levelNames[lvl]
+	}
+	return
+}
+
+// IncreaseLevel raises the verbosity of a logger by one level, up to TRACE,
+// and returns the new level.
+func IncreaseLevel(l LevelSetter) string {
+	level, _ := l.Levels()
+	lvl, _ := parseLevel(level)
+	if lvl < logrus.TraceLevel {
+		lvl++
+	}
+	_ = l.SetLevel(levelNames[lvl], "")
+	return levelNames[lvl]
 }
 
 //------------------------------------------------------------------------------
@@ -132,7 +306,7 @@ func NewV2(stream io.Writer, config Config) (Modular, error) {
 func Noop() Modular {
 	logger := logrus.New()
 	logger.Out = io.Discard
-	return &Logger{entry: logger.WithFields(logrus.Fields{})}
+	return &Logger{entry: logger.WithFields(logrus.Fields{}), levels: newLevelState(logrus.InfoLevel)}
 }
 
 // WithFields returns a logger with new fields added to the JSON formatted
@@ -145,6 +319,9 @@ func (l *Logger) WithFields(inboundFields map[string]string) Modular {
 
 	newLogger := *l
 	newLogger.entry = l.entry.WithFields(newFields)
+	if p, exists := inboundFields["path"]; exists {
+		newLogger.path = p
+	}
 	return &newLogger
 }
 
@@ -162,38 +339,65 @@ func (l *Logger) With(keyValues ...interface{}) Modular {
 
 	newLogger := *l
 	newLogger.entry = newEntry
+	for i := 0; i < (len(keyValues) - 1); i += 2 {
+		if key, _ := keyValues[i].(string); key == "path" {
+			newLogger.path = fmt.Sprintf("%v", keyValues[i+1])
+		}
+	}
 	return &newLogger
 }
 
 //------------------------------------------------------------------------------
 
+func (l *Logger) shouldLog(level logrus.Level, message string) bool {
+	return l.levels.enabled(l.path, level) && l.sampler.allow(level, message)
+}
+
 // Fatalf prints a fatal message to the console. Does NOT cause panic.
 func (l *Logger) Fatalf(format string, v ...interface{}) {
+	if !l.shouldLog(logrus.FatalLevel, format) {
+		return
+	}
 	l.entry.Fatalf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Errorf prints an error message to the console.
 func (l *Logger) Errorf(format string, v ...interface{}) {
+	if !l.shouldLog(logrus.ErrorLevel, format) {
+		return
+	}
 	l.entry.Errorf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Warnf prints a warning message to the console.
 func (l *Logger) Warnf(format string, v ...interface{}) {
+	if !l.shouldLog(logrus.WarnLevel, format) {
+		return
+	}
 	l.entry.Warnf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Infof prints an information message to the console.
 func (l *Logger) Infof(format string, v ...interface{}) {
+	if !l.shouldLog(logrus.InfoLevel, format) {
+		return
+	}
 	l.entry.Infof(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Debugf prints a debug message to the console.
 func (l *Logger) Debugf(format string, v ...interface{}) {
+	if !l.shouldLog(logrus.DebugLevel, format) {
+		return
+	}
 	l.entry.Debugf(strings.TrimSuffix(format, "\n"), v...)
 }
 
 // Tracef prints a trace message to the console.
 func (l *Logger) Tracef(format string, v ...interface{}) {
+	if !l.shouldLog(logrus.TraceLevel, format) {
+		return
+	}
 	l.entry.Tracef(strings.TrimSuffix(format, "\n"), v...)
 }
 
@@ -201,30 +405,48 @@ func (l *Logger) Tracef(format string, v ...interface{}) {
 
 // Fatalln prints a fatal message to the console. Does NOT cause panic.
 func (l *Logger) Fatalln(message string) {
+	if !l.shouldLog(logrus.FatalLevel, message) {
+		return
+	}
 	l.entry.Fatalln(message)
 }
 
 // Errorln prints an error message to the console.
 func (l *Logger) Errorln(message string) {
+	if !l.shouldLog(logrus.ErrorLevel, message) {
+		return
+	}
 	l.entry.Errorln(message)
 }
 
 // Warnln prints a warning message to the console.
 func (l *Logger) Warnln(message string) {
+	if !l.shouldLog(logrus.WarnLevel, message) {
+		return
+	}
 	l.entry.Warnln(message)
 }
 
 // Infoln prints an information message to the console.
 func (l *Logger) Infoln(message string) {
+	if !l.shouldLog(logrus.InfoLevel, message) {
+		return
+	}
 	l.entry.Infoln(message)
 }
 
 // Debugln prints a debug message to the console.
 func (l *Logger) Debugln(message string) {
+	if !l.shouldLog(logrus.DebugLevel, message) {
+		return
+	}
 	l.entry.Debugln(message)
 }
 
 // Traceln prints a trace message to the console.
 func (l *Logger) Traceln(message string) {
+	if !l.shouldLog(logrus.TraceLevel, message) {
+		return
+	}
 	l.entry.Traceln(message)
 }
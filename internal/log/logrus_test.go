@@ -3,6 +3,7 @@ package log
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,6 +42,34 @@ level=warning msg="Warning message root module" @service=benthos_service @system
 	assert.Equal(t, expected, buf.String())
 }
 
+func TestLoggerStaticFieldsInterpolated(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.Format = "logfmt"
+	loggerConfig.LogLevel = "WARN"
+	loggerConfig.StaticFields = map[string]string{
+		"@service": "benthos_service",
+		"@system":  `${! "foo".uppercase() }`,
+	}
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	logger.Warnln("Warning message root module")
+
+	expected := `level=warning msg="Warning message root module" @service=benthos_service @system=FOO
+`
+	assert.Equal(t, expected, buf.String())
+
+	loggerConfig.StaticFields = map[string]string{
+		"@system": `${! "foo".uppercase( }`,
+	}
+	_, err = NewV2(&buf, loggerConfig)
+	require.Error(t, err)
+}
+
 func TestLoggerWithOddArgs(t *testing.T) {
 	loggerConfig := NewConfig()
 	loggerConfig.AddTimeStamp = false
@@ -136,3 +165,131 @@ func TestLogLevels(t *testing.T) {
 		}
 	}
 }
+
+func TestLoggerSetLevel(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.LogLevel = "WARN"
+	loggerConfig.StaticFields = map[string]string{}
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	inputLogger := logger.WithFields(map[string]string{"path": "root.input"})
+	procLogger := logger.WithFields(map[string]string{"path": "root.pipeline.processors.0"})
+
+	ls, ok := logger.(LevelSetter)
+	require.True(t, ok)
+
+	require.NoError(t, ls.SetLevel("DEBUG", "root.pipeline"))
+	require.Error(t, ls.SetLevel("NOPE", ""))
+
+	inputLogger.Debugln("input debug")
+	procLogger.Debugln("processor debug")
+	procLogger.Traceln("processor trace")
+
+	level, paths := ls.Levels()
+	assert.Equal(t, "WARN", level)
+	assert.Equal(t, map[string]string{"root.pipeline": "DEBUG"}, paths)
+
+	require.NoError(t, ls.SetLevel("INFO", ""))
+	inputLogger.Infoln("input info")
+
+	ls.ResetLevel("")
+	inputLogger.Infoln("input info again")
+	procLogger.Debugln("processor debug again")
+
+	assert.Equal(t, "INFO", IncreaseLevel(ls))
+	assert.Equal(t, "DEBUG", IncreaseLevel(ls))
+	assert.Equal(t, "TRACE", IncreaseLevel(ls))
+	assert.Equal(t, "TRACE", IncreaseLevel(ls))
+
+	expected := `level=debug msg="processor debug" path=root.pipeline.processors.0
+level=info msg="input info" path=root.input
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestLoggerLevelEnabled(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.LogLevel = "INFO"
+
+	logger, err := NewV2(&bytes.Buffer{}, loggerConfig)
+	require.NoError(t, err)
+
+	procLogger := logger.WithFields(map[string]string{"path": "root.pipeline.processors.0"})
+
+	lc, ok := procLogger.(LevelChecker)
+	require.True(t, ok)
+
+	assert.True(t, lc.LevelEnabled("INFO"))
+	assert.False(t, lc.LevelEnabled("TRACE"))
+	assert.False(t, lc.LevelEnabled("NOPE"))
+
+	require.NoError(t, logger.(LevelSetter).SetLevel("TRACE", "root.pipeline"))
+	assert.True(t, lc.LevelEnabled("TRACE"))
+	assert.False(t, logger.(LevelChecker).LevelEnabled("TRACE"))
+}
+
+func TestLoggerSampling(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.Format = "logfmt"
+	loggerConfig.LogLevel = "INFO"
+	loggerConfig.StaticFields = map[string]string{}
+	loggerConfig.Sampling.Enabled = true
+	loggerConfig.Sampling.Period = "1h"
+	loggerConfig.Sampling.First = 2
+	loggerConfig.Sampling.Thereafter = 3
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	for i := 0; i < 8; i++ {
+		logger.Errorf("failed to send: %v", i)
+	}
+	logger.Warnln("different message")
+
+	expected := `level=error msg="failed to send: 0"
+level=error msg="failed to send: 1"
+level=error msg="failed to send: 4"
+level=error msg="failed to send: 7"
+level=warning msg="different message"
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestLoggerRateLimit(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.Format = "logfmt"
+	loggerConfig.LogLevel = "INFO"
+	loggerConfig.StaticFields = map[string]string{}
+	loggerConfig.RateLimit = 2
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	now := time.Unix(1000, 0)
+	logger.(*Logger).sampler.nowFn = func() time.Time {
+		return now
+	}
+
+	logger.Infoln("first")
+	logger.Infoln("second")
+	logger.Infoln("third")
+
+	now = now.Add(time.Second)
+	logger.Infoln("fourth")
+
+	expected := `level=info msg=first
+level=info msg=second
+level=info msg=fourth
+`
+	assert.Equal(t, expected, buf.String())
+}
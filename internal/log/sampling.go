@@ -0,0 +1,117 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SamplingConfig holds configuration options for sampling logs.
+type SamplingConfig struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	Period     string `json:"period" yaml:"period"`
+	First      int    `json:"first" yaml:"first"`
+	Thereafter int    `json:"thereafter" yaml:"thereafter"`
+}
+
+// NewSamplingConfig returns a sampling config struct with default values.
+func NewSamplingConfig() SamplingConfig {
+	return SamplingConfig{
+		Enabled:    false,
+		Period:     "1s",
+		First:      100,
+		Thereafter: 100,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type samplerKey struct {
+	level   logrus.Level
+	message string
+}
+
+// logSampler decides whether a log should be emitted based on sampling and
+// rate limiting rules. A single sampler is shared by all loggers derived from
+// the same root.
+type logSampler struct {
+	period     time.Duration
+	first      uint64
+	thereafter uint64
+	rateLimit  int
+
+	mut         sync.Mutex
+	windowStart time.Time
+	counts      map[samplerKey]uint64
+	rateStart   time.Time
+	rateCount   int
+
+	nowFn func() time.Time
+}
+
+func newLogSampler(conf SamplingConfig, rateLimit int) (*logSampler, error) {
+	if !conf.Enabled && rateLimit <= 0 {
+		return nil, nil
+	}
+
+	s := &logSampler{
+		rateLimit: rateLimit,
+		counts:    map[samplerKey]uint64{},
+		nowFn:     time.Now,
+	}
+	if conf.Enabled {
+		var err error
+		if s.period, err = time.ParseDuration(conf.Period); err != nil {
+			return nil, fmt.Errorf("failed to parse sampling period: %w", err)
+		}
+		if conf.First < 0 || conf.Thereafter < 0 {
+			return nil, fmt.Errorf("sampling fields first and thereafter must not be negative")
+		}
+		s.first = uint64(conf.First)
+		s.thereafter = uint64(conf.Thereafter)
+	}
+	return s, nil
+}
+
+// allow returns true if a log of the given level and message template should
+// be emitted. Logs are sampled per level and message, where within each period
+// the first N are emitted and then every Mth thereafter. Logs that pass
+// sampling are then subject to a global limit per second.
+func (s *logSampler) allow(level logrus.Level, message string) bool {
+	if s == nil {
+		return true
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	now := s.nowFn()
+	if s.period > 0 {
+		if now.Sub(s.windowStart) >= s.period {
+			s.windowStart = now
+			s.counts = map[samplerKey]uint64{}
+		}
+
+		key := samplerKey{level: level, message: message}
+		n := s.counts[key] + 1
+		s.counts[key] = n
+
+		if n > s.first && (s.thereafter == 0 || (n-s.first)%s.thereafter != 0) {
+			return false
+		}
+	}
+
+	if s.rateLimit > 0 {
+		if now.Sub(s.rateStart) >= time.Second {
+			s.rateStart = now
+			s.rateCount = 0
+		}
+		if s.rateCount >= s.rateLimit {
+			return false
+		}
+		s.rateCount++
+	}
+	return true
+}
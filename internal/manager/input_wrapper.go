@@ -89,6 +89,15 @@ func (w *inputWrapper) Connected() bool {
 	return con
 }
 
+func (w *inputWrapper) ConnectionError() error {
+	w.inputLock.Lock()
+	defer w.inputLock.Unlock()
+	if w.ctrl.input == nil {
+		return nil
+	}
+	return component.ConnectionErrorOf(w.ctrl.input)
+}
+
 func (w *inputWrapper) loop() {
 	defer func() {
 		w.inputLock.Lock()
@@ -45,6 +45,12 @@ func (w *outputWrapper) Connected() bool {
 	return w.output.Connected()
 }
 
+// ConnectionError returns the most recent connection error of the wrapped
+// component.
+func (w *outputWrapper) ConnectionError() error {
+	return component.ConnectionErrorOf(w.output)
+}
+
 func (w *outputWrapper) CloseAsync() {
 	w.closeOnce.Do(func() {
 		close(w.tranChan)
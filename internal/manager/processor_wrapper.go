@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/errorevents"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+var _ processor.V1 = &processorWrapper{}
+
+// processorWrapper records an error event for each message that a processor
+// flags as having failed.
+type processorWrapper struct {
+	processor processor.V1
+	events    *errorevents.Recorder
+	event     errorevents.Event
+}
+
+func wrapProcessor(p processor.V1, events *errorevents.Recorder, event errorevents.Event) processor.V1 {
+	if events == nil {
+		return p
+	}
+	return &processorWrapper{
+		processor: p,
+		events:    events,
+		event:     event,
+	}
+}
+
+// Unwrap returns the underlying processor.
+func (w *processorWrapper) Unwrap() processor.V1 {
+	return w.processor
+}
+
+func (w *processorWrapper) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
+	w.events.Track(msg, w.event.Path)
+
+	// Errors flagged before the processor was executed were set by a prior
+	// processor and have therefore already been recorded.
+	var prior []error
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		if err := p.ErrorGet(); err != nil {
+			prior = append(prior, err)
+		}
+		return nil
+	})
+
+	msgs, err := w.processor.ProcessMessage(msg)
+	for _, m := range msgs {
+		_ = m.Iter(func(i int, p *message.Part) error {
+			if pErr := p.ErrorGet(); pErr != nil && !containsErr(prior, pErr) {
+				w.events.RecordPartErr(p, pErr, w.event)
+			}
+			return nil
+		})
+	}
+	return msgs, err
+}
+
+func containsErr(errs []error, err error) bool {
+	if !reflect.TypeOf(err).Comparable() {
+		return false
+	}
+	for _, e := range errs {
+		if reflect.TypeOf(e) == reflect.TypeOf(err) && e == err {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *processorWrapper) CloseAsync() {
+	w.processor.CloseAsync()
+}
+
+func (w *processorWrapper) WaitForClose(timeout time.Duration) error {
+	return w.processor.WaitForClose(timeout)
+}
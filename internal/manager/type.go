@@ -20,6 +20,8 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/errorevents"
+	"github.com/benthosdev/benthos/v4/internal/flowcontrol"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -73,8 +75,11 @@ type Type struct {
 	env      *bundle.Environment
 	bloblEnv *bloblang.Environment
 
-	logger log.Modular
-	stats  *metrics.Namespaced
+	logger      log.Modular
+	stats       *metrics.Namespaced
+	errEvents   *errorevents.Recorder
+	errEventsOn bool
+	flowControl *flowcontrol.Controller
 
 	pipes    map[string]<-chan message.Transaction
 	pipeLock *sync.RWMutex
@@ -99,6 +104,23 @@ func OptSetBloblangEnvironment(env *bloblang.Environment) OptFunc {
 	}
 }
 
+// OptSetFlowControl sets a flow control controller to be applied to the streams
+// created with the manager. This option is for internal use only.
+func OptSetFlowControl(c *flowcontrol.Controller) OptFunc {
+	return func(t *Type) {
+		t.flowControl = c
+	}
+}
+
+// OptSetErrorEvents determines whether the manager records the failures
+// observed by the processors it creates, which are then accessible via
+// ErrorEvents. This option is for internal use only.
+func OptSetErrorEvents(b bool) OptFunc {
+	return func(t *Type) {
+		t.errEventsOn = b
+	}
+}
+
 // OptSetStreamsMode marks the manager as being created for running streams mode
 // resources. This ensures that a label "stream" is added to metrics.
 func OptSetStreamsMode(b bool) OptFunc {
@@ -137,6 +159,10 @@ func New(conf ResourceConfig, apiReg APIReg, log log.Modular, stats *metrics.Nam
 		opt(t)
 	}
 
+	if t.errEventsOn {
+		t.errEvents = errorevents.NewRecorder(t.stats)
+	}
+
 	seen := map[string]struct{}{}
 
 	checkLabel := func(typeStr, label string) error {
@@ -236,6 +262,12 @@ func (t *Type) forStream(id string) *Type {
 		"stream": id,
 	})
 	newT.stats = t.stats.WithLabels("stream", id)
+	if t.errEvents != nil {
+		newT.errEvents = t.errEvents.ForStream(id)
+	}
+	if t.flowControl != nil {
+		newT.flowControl = t.flowControl.ForStream(id)
+	}
 	return &newT
 }
 
@@ -354,6 +386,20 @@ func (t *Type) Environment() *bundle.Environment {
 	return t.env
 }
 
+// ErrorEvents returns a recorder of failure events shared by all components of
+// the manager, which is nil when error events are disabled. This is for
+// internal use only.
+func (t *Type) ErrorEvents() *errorevents.Recorder {
+	return t.errEvents
+}
+
+// FlowControl returns the flow control controller applied to streams created
+// with the manager, which is nil when flow control is disabled. This is for
+// internal use only.
+func (t *Type) FlowControl() *flowcontrol.Controller {
+	return t.flowControl
+}
+
 // BloblEnvironment returns a Bloblang environment used by the manager. This is
 // for internal use only.
 func (t *Type) BloblEnvironment() *bloblang.Environment {
@@ -553,7 +599,15 @@ func (t *Type) AccessProcessor(ctx context.Context, name string, fn func(process
 
 // NewProcessor attempts to create a new processor component from a config.
 func (t *Type) NewProcessor(conf processor.Config) (processor.V1, error) {
-	return t.env.ProcessorInit(conf, t.forLabel(conf.Label))
+	p, err := t.env.ProcessorInit(conf, t.forLabel(conf.Label))
+	if err != nil {
+		return nil, err
+	}
+	return wrapProcessor(p, t.errEvents, errorevents.Event{
+		Path:  "root." + query.SliceToDotPath(t.componentPath...),
+		Label: conf.Label,
+		Class: errorevents.ClassProcessing,
+	}), nil
 }
 
 // StoreProcessor attempts to store a new processor resource. If an existing
@@ -2,6 +2,7 @@ package manager_test
 
 import (
 	"context"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -254,6 +255,129 @@ func TestManagerProcessor(t *testing.T) {
 	require.False(t, mgr.ProbeProcessor("baz"))
 }
 
+func TestManagerProcessorErrorEvents(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = "bloblang"
+	conf.Bloblang = `root = this.foo.number()`
+
+	mgr, err := manager.New(manager.NewResourceConfig(), nil, log.Noop(), noopStats(), manager.OptSetErrorEvents(true))
+	require.NoError(t, err)
+	mgr.ErrorEvents().IncludeSamples(true)
+
+	p, err := mgr.IntoPath("pipeline", "processors", "0").NewProcessor(conf)
+	require.NoError(t, err)
+
+	msgs, res := p.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"foo":"10"}`),
+		[]byte(`{"foo":"nah"}`),
+	}))
+	require.NoError(t, res)
+	require.Len(t, msgs, 1)
+
+	summaries := mgr.ErrorEvents().Summaries()
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "root.pipeline.processors.0", summaries[0].Path)
+	assert.Equal(t, "processing", summaries[0].Class)
+	assert.Equal(t, int64(1), summaries[0].Count)
+
+	recent := mgr.ErrorEvents().Recent()
+	require.Len(t, recent, 1)
+	assert.Equal(t, `{"foo":"nah"}`, recent[0].Sample)
+	assert.Contains(t, recent[0].Error, "nah")
+}
+
+func TestManagerProcessorErrorEventsNested(t *testing.T) {
+	childConf := processor.NewConfig()
+	childConf.Type = "bloblang"
+	childConf.Bloblang = `root = this.foo.number()`
+
+	conf := processor.NewConfig()
+	conf.Type = "branch"
+	conf.Branch.ResultMap = `root.bar = this`
+	conf.Branch.Processors = append(conf.Branch.Processors, childConf)
+
+	mgr, err := manager.New(manager.NewResourceConfig(), nil, log.Noop(), noopStats(), manager.OptSetErrorEvents(true))
+	require.NoError(t, err)
+
+	p, err := mgr.IntoPath("pipeline", "processors", "0").NewProcessor(conf)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		msgs, res := p.ProcessMessage(message.QuickBatch([][]byte{
+			[]byte(`{"foo":"nah"}`),
+			[]byte(`{"foo":"nope"}`),
+		}))
+		require.NoError(t, res)
+		require.Len(t, msgs, 1)
+		require.Error(t, msgs[0].Get(0).ErrorGet())
+		require.Error(t, msgs[0].Get(1).ErrorGet())
+	}
+
+	summaries := mgr.ErrorEvents().Summaries()
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "root.pipeline.processors.0.branch.processors.0", summaries[0].Path)
+	assert.Equal(t, int64(4), summaries[0].Count)
+
+	for _, e := range mgr.ErrorEvents().Recent() {
+		assert.Empty(t, e.Sample)
+	}
+}
+
+func TestManagerProcessorErrorEventsSequential(t *testing.T) {
+	mgr, err := manager.New(manager.NewResourceConfig(), nil, log.Noop(), noopStats(), manager.OptSetErrorEvents(true))
+	require.NoError(t, err)
+
+	mappings := []string{
+		`root = this.foo.number()`,
+		`root = this`,
+		`root.bar = "baz"`,
+	}
+
+	var procs []processor.V1
+	for i, m := range mappings {
+		conf := processor.NewConfig()
+		conf.Type = "bloblang"
+		conf.Bloblang = m
+
+		p, err := mgr.IntoPath("pipeline", "processors", strconv.Itoa(i)).NewProcessor(conf)
+		require.NoError(t, err)
+		procs = append(procs, p)
+	}
+
+	msgs, res := processor.ExecuteAll(procs, message.QuickBatch([][]byte{
+		[]byte(`{"foo":"nah"}`),
+	}))
+	require.NoError(t, res)
+	require.Len(t, msgs, 1)
+	require.Error(t, msgs[0].Get(0).ErrorGet())
+
+	summaries := mgr.ErrorEvents().Summaries()
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "root.pipeline.processors.0", summaries[0].Path)
+	assert.Equal(t, int64(1), summaries[0].Count)
+	assert.Len(t, mgr.ErrorEvents().Recent(), 1)
+}
+
+func TestManagerProcessorErrorEventsDisabled(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = "bloblang"
+	conf.Bloblang = `root = this.foo.number()`
+
+	mgr, err := manager.New(manager.NewResourceConfig(), nil, log.Noop(), noopStats())
+	require.NoError(t, err)
+	assert.Nil(t, mgr.ErrorEvents())
+
+	p, err := mgr.IntoPath("pipeline", "processors", "0").NewProcessor(conf)
+	require.NoError(t, err)
+
+	msgs, res := p.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"foo":"nah"}`),
+	}))
+	require.NoError(t, res)
+	require.Len(t, msgs, 1)
+	require.Error(t, msgs[0].Get(0).ErrorGet())
+}
+
 func TestManagerProcessorList(t *testing.T) {
 	cFoo := processor.NewConfig()
 	cFoo.Label = "foo"
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"sync/atomic"
 )
 
 var useNumber = true
@@ -24,6 +25,12 @@ type rwData struct {
 	jsonCache interface{}
 	metadata  map[string]string
 	err       error
+
+	// jsonShared is non-zero when the structured contents might be referenced
+	// by other message parts, and must therefore be cloned before they are
+	// mutated. This is set atomically as a part may be copied from multiple
+	// goroutines at once.
+	jsonShared int32
 }
 
 // Part represents a single Benthos message.
@@ -44,8 +51,15 @@ func NewPart(data []byte) *Part {
 
 //------------------------------------------------------------------------------
 
-// Copy creates a shallow copy of the message part.
+// Copy creates a shallow copy of the message part. The structured contents of
+// the part, if already parsed, are shared with the copy and are only cloned
+// when either part obtains them with JSONMut.
 func (p *Part) Copy() *Part {
+	var jsonShared int32
+	if p.data.jsonCache != nil {
+		atomic.StoreInt32(&p.data.jsonShared, 1)
+		jsonShared = 1
+	}
 	var clonedMeta map[string]string
 	if p.data.metadata != nil {
 		clonedMeta = make(map[string]string, len(p.data.metadata))
@@ -55,10 +69,11 @@ func (p *Part) Copy() *Part {
 	}
 	return &Part{
 		data: &rwData{
-			rawBytes:  p.data.rawBytes,
-			metadata:  clonedMeta,
-			jsonCache: p.data.jsonCache,
-			err:       p.data.err,
+			rawBytes:   p.data.rawBytes,
+			metadata:   clonedMeta,
+			jsonCache:  p.data.jsonCache,
+			err:        p.data.err,
+			jsonShared: jsonShared,
 		},
 		ctx: p.ctx,
 	}
@@ -186,21 +201,49 @@ func (p *Part) JSON() (interface{}, error) {
 	return nil, err
 }
 
+// JSONMut attempts to parse the message part as a JSON document and returns a
+// result that is safe to mutate in place. The structured contents are only
+// cloned when they might be referenced by other message parts, and the raw
+// bytes of the part are cleared so that they are serialised from the mutated
+// result the next time they are read.
+func (p *Part) JSONMut() (interface{}, error) {
+	jObj, err := p.JSON()
+	if err != nil {
+		return nil, err
+	}
+	if atomic.LoadInt32(&p.data.jsonShared) != 0 {
+		if jObj, err = cloneGeneric(jObj); err != nil {
+			return nil, err
+		}
+		p.data.jsonCache = jObj
+		atomic.StoreInt32(&p.data.jsonShared, 0)
+	}
+	p.data.rawBytes = nil
+	if jObj == nil {
+		p.data.rawBytes = []byte(`null`)
+	}
+	return jObj, nil
+}
+
 // Set the value of the message part.
 func (p *Part) Set(data []byte) *Part {
 	p.data.rawBytes = data
 	p.data.jsonCache = nil
+	atomic.StoreInt32(&p.data.jsonShared, 0)
 	return p
 }
 
 // SetJSON attempts to marshal a JSON document into a byte slice and stores the
-// result as the contents of the message part.
+// result as the contents of the message part. The provided document might be
+// referenced elsewhere and is therefore cloned before being mutated with
+// JSONMut.
 func (p *Part) SetJSON(jObj interface{}) {
 	p.data.rawBytes = nil
 	if jObj == nil {
 		p.data.rawBytes = []byte(`null`)
 	}
 	p.data.jsonCache = jObj
+	atomic.StoreInt32(&p.data.jsonShared, 1)
 }
 
 //------------------------------------------------------------------------------
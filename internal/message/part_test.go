@@ -124,6 +124,56 @@ func TestPartCopyDirtyJSON(t *testing.T) {
 	}
 }
 
+func TestPartJSONMutCopyOnWrite(t *testing.T) {
+	p := NewPart([]byte(`{"foo":"bar"}`))
+
+	pJSON, err := p.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p2 := p.Copy()
+
+	p2JSON, err := p2.JSONMut()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2JSON.(map[string]interface{})["foo"] = "baz"
+
+	if exp, act := `{"foo":"bar"}`, string(p.Get()); exp != act {
+		t.Errorf("Wrong original contents: %v != %v", act, exp)
+	}
+	if exp, act := `{"foo":"baz"}`, string(p2.Get()); exp != act {
+		t.Errorf("Wrong copied contents: %v != %v", act, exp)
+	}
+
+	// The original is also shared and must therefore be cloned.
+	pJSONMut, err := p.JSONMut()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pJSONMut.(map[string]interface{})["foo"] = "qux"
+	if exp, act := "bar", pJSON.(map[string]interface{})["foo"]; exp != act {
+		t.Errorf("Wrong shared contents: %v != %v", act, exp)
+	}
+	if exp, act := `{"foo":"qux"}`, string(p.Get()); exp != act {
+		t.Errorf("Wrong original contents: %v != %v", act, exp)
+	}
+
+	// Once owned the contents are no longer cloned.
+	pJSONMutAgain, err := p.JSONMut()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pJSONMutAgain.(map[string]interface{})["foo"] = "quz"
+	if exp, act := "quz", pJSONMut.(map[string]interface{})["foo"]; exp != act {
+		t.Errorf("Wrong owned contents: %v != %v", act, exp)
+	}
+	if exp, act := `{"foo":"quz"}`, string(p.Get()); exp != act {
+		t.Errorf("Wrong original contents: %v != %v", act, exp)
+	}
+}
+
 func TestPartJSONMarshal(t *testing.T) {
 	p := NewPart(nil)
 	p.SetJSON(map[string]interface{}{
@@ -0,0 +1,47 @@
+package message
+
+import "context"
+
+// TxnOffset describes the position of a message within an ordered partition of
+// an upstream source, such as the offset of a record within a Kafka topic
+// partition.
+//
+// Inputs that consume from ordered partitions attach an offset to each message
+// they create. Outputs that support transactions are then able to coordinate
+// delivery with upstream acknowledgement by recording the offsets of the
+// messages they write within the same transaction as the data itself, and by
+// skipping messages with an offset that was already recorded. This means a
+// redelivery caused by a failure after an output commits but before the input
+// acknowledges does not result in duplicates.
+//
+// Messages are not guaranteed to reach an output in the order of their
+// offsets, as inputs may have many messages in flight and processing threads
+// may reorder batches, and therefore outputs must not assume that an offset
+// below the highest recorded one has already been written.
+type TxnOffset struct {
+	// Source identifies the type of the upstream source, e.g. kafka.
+	Source string
+
+	// Partition identifies the ordered partition of the source that the
+	// message was consumed from, e.g. a topic and partition number.
+	Partition string
+
+	// Offset is the position of the message within the partition, where
+	// offsets are expected to increase monotonically.
+	Offset int64
+}
+
+type txnOffsetKey struct{}
+
+// WithTxnOffset returns the message part with a transaction offset attached to
+// its context, which can subsequently be received with GetTxnOffset.
+func WithTxnOffset(p *Part, offset TxnOffset) *Part {
+	return p.WithContext(context.WithValue(p.GetContext(), txnOffsetKey{}, offset))
+}
+
+// GetTxnOffset returns the transaction offset attached to a message part, and
+// a boolean indicating whether one was present.
+func GetTxnOffset(p *Part) (TxnOffset, bool) {
+	offset, ok := p.GetContext().Value(txnOffsetKey{}).(TxnOffset)
+	return offset, ok
+}
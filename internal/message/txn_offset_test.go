@@ -0,0 +1,19 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxnOffsets(t *testing.T) {
+	p := NewPart([]byte("foo"))
+	_, ok := GetTxnOffset(p)
+	assert.False(t, ok)
+
+	p = WithTxnOffset(p, TxnOffset{Source: "kafka", Partition: "foo/0", Offset: 5})
+
+	o, ok := GetTxnOffset(p.Copy())
+	assert.True(t, ok)
+	assert.Equal(t, TxnOffset{Source: "kafka", Partition: "foo/0", Offset: 5}, o)
+}
@@ -96,6 +96,11 @@ func cloneGeneric(root interface{}) (interface{}, error) {
 		// Oops, this means we have 'dirty' types within the JSON object. Our
 		// only way to fallback is to marshal/unmarshal the structure, gross!
 		if b, err := json.Marshal(root); err == nil {
+			// Types that marshal to a single number, such as exact decimals,
+			// are kept as a json.Number in order to preserve their precision.
+			if _, isMarshaler := root.(json.Marshaler); isMarshaler && isJSONNumber(b) {
+				return json.Number(b), nil
+			}
 			var rootCopy interface{}
 			if err = json.Unmarshal(b, &rootCopy); err == nil {
 				return rootCopy, nil
@@ -105,6 +110,13 @@ func cloneGeneric(root interface{}) (interface{}, error) {
 	}
 }
 
+func isJSONNumber(b []byte) bool {
+	if len(b) == 0 || (b[0] != '-' && (b[0] < '0' || b[0] > '9')) {
+		return false
+	}
+	return json.Valid(b)
+}
+
 // CopyJSON recursively creates a deep copy of a JSON structure extracted from a
 // message part.
 func CopyJSON(root interface{}) (interface{}, error) {
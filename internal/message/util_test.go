@@ -123,6 +123,32 @@ func TestCloneGenericYAML(t *testing.T) {
 	}
 }
 
+type exactNumber string
+
+func (e exactNumber) MarshalJSON() ([]byte, error) {
+	return []byte(e), nil
+}
+
+func TestCloneGenericNumberMarshaler(t *testing.T) {
+	original := map[string]interface{}{
+		"exact": exactNumber("12345678901234567890.123456789"),
+		"dirty": map[string]int{"foo": 1},
+	}
+
+	cloned, err := cloneGeneric(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]interface{}{
+		"exact": json.Number("12345678901234567890.123456789"),
+		"dirty": map[string]interface{}{"foo": float64(1)},
+	}
+	if act := cloned; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong cloned contents: %v != %v", act, exp)
+	}
+}
+
 //------------------------------------------------------------------------------
 
 var benchResult float64
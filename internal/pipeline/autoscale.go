@@ -0,0 +1,336 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// AutoscaleConfig contains configuration fields for automatically scaling the
+// number of processing threads of a pipeline.
+type AutoscaleConfig struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	MinThreads int    `json:"min_threads" yaml:"min_threads"`
+	MaxThreads int    `json:"max_threads" yaml:"max_threads"`
+	Period     string `json:"period" yaml:"period"`
+}
+
+// NewAutoscaleConfig returns an AutoscaleConfig with default values.
+func NewAutoscaleConfig() AutoscaleConfig {
+	return AutoscaleConfig{
+		Enabled:    false,
+		MinThreads: 1,
+		MaxThreads: -1,
+		Period:     "1s",
+	}
+}
+
+const (
+	// When workers spend less than this proportion of their time waiting for
+	// messages and more than the busy proportion processing them then messages
+	// are queuing up and a thread is added.
+	autoscaleUpIdleRatio = 0.1
+	autoscaleUpBusyRatio = 0.75
+
+	// When workers spend less than this proportion of their time processing
+	// messages then a thread is removed.
+	autoscaleDownBusyRatio = 0.25
+)
+
+//------------------------------------------------------------------------------
+
+// workerStats accumulates the time that the workers of a pool have spent
+// waiting for and processing messages.
+type workerStats struct {
+	idleNanos int64
+	busyNanos int64
+}
+
+func (s *workerStats) record(idle, busy time.Duration) {
+	atomic.AddInt64(&s.idleNanos, int64(idle))
+	atomic.AddInt64(&s.busyNanos, int64(busy))
+}
+
+func (s *workerStats) reset() (idle, busy time.Duration) {
+	idle = time.Duration(atomic.SwapInt64(&s.idleNanos, 0))
+	busy = time.Duration(atomic.SwapInt64(&s.busyNanos, 0))
+	return
+}
+
+// scaledWorker contains the state shared between an autoscaling pool and one
+// of its workers.
+type scaledWorker struct {
+	stopChan    chan struct{}
+	stats       *workerStats
+	inputClosed func()
+}
+
+//------------------------------------------------------------------------------
+
+// AutoscalePool is a pool of pipelines where the number of pipelines is
+// adjusted periodically within bounds based on how much time they spend
+// waiting for and processing messages.
+type AutoscalePool struct {
+	running uint32
+	threads int64
+
+	msgProcessors []iprocessor.V1
+	minThreads    int
+	maxThreads    int
+	period        time.Duration
+
+	workers []*Processor
+	stopped []*Processor
+	stats   workerStats
+
+	log      log.Modular
+	mThreads metrics.StatGauge
+
+	messagesIn  <-chan message.Transaction
+	messagesOut chan message.Transaction
+
+	inputClosedOnce sync.Once
+	inputClosedChan chan struct{}
+
+	closeChan chan struct{}
+	closed    chan struct{}
+}
+
+// NewAutoscalePool creates a new processing pool that scales its number of
+// threads automatically, starting with the provided number of threads.
+func NewAutoscalePool(threads int, conf AutoscaleConfig, log log.Modular, stats metrics.Type, msgProcessors ...iprocessor.V1) (*AutoscalePool, error) {
+	period, err := time.ParseDuration(conf.Period)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse autoscale period: %w", err)
+	}
+	if period <= 0 {
+		return nil, errors.New("autoscale period must be greater than zero")
+	}
+
+	minThreads, maxThreads := conf.MinThreads, conf.MaxThreads
+	if minThreads <= 0 {
+		minThreads = 1
+	}
+	if maxThreads <= 0 {
+		maxThreads = runtime.NumCPU() * 4
+	}
+	if maxThreads < minThreads {
+		return nil, fmt.Errorf("autoscale max_threads (%v) must not be less than min_threads (%v)", maxThreads, minThreads)
+	}
+
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	if threads < minThreads {
+		threads = minThreads
+	}
+	if threads > maxThreads {
+		threads = maxThreads
+	}
+
+	p := &AutoscalePool{
+		running:         1,
+		msgProcessors:   msgProcessors,
+		minThreads:      minThreads,
+		maxThreads:      maxThreads,
+		period:          period,
+		log:             log,
+		mThreads:        stats.GetGauge("pipeline_threads"),
+		messagesOut:     make(chan message.Transaction),
+		inputClosedChan: make(chan struct{}),
+		closeChan:       make(chan struct{}),
+		closed:          make(chan struct{}),
+	}
+	p.workers = make([]*Processor, 0, threads)
+	for i := 0; i < threads; i++ {
+		p.workers = append(p.workers, p.newWorker())
+	}
+	return p, nil
+}
+
+func (p *AutoscalePool) newWorker() *Processor {
+	w := NewProcessor(p.msgProcessors...)
+	w.messagesOut = p.messagesOut
+	w.scaled = &scaledWorker{
+		stopChan: make(chan struct{}),
+		stats:    &p.stats,
+		inputClosed: func() {
+			p.inputClosedOnce.Do(func() {
+				close(p.inputClosedChan)
+			})
+		},
+	}
+	return w
+}
+
+// Threads returns the current number of processing threads of the pool.
+func (p *AutoscalePool) Threads() int {
+	return int(atomic.LoadInt64(&p.threads))
+}
+
+func (p *AutoscalePool) setThreads() {
+	atomic.StoreInt64(&p.threads, int64(len(p.workers)))
+	p.mThreads.Set(int64(len(p.workers)))
+}
+
+//------------------------------------------------------------------------------
+
+// scale adjusts the number of workers based on the stats accumulated since
+// the last adjustment.
+func (p *AutoscalePool) scale(elapsed time.Duration) {
+	idle, busy := p.stats.reset()
+
+	running := p.stopped[:0]
+	for _, w := range p.stopped {
+		select {
+		case <-w.shutSig.HasClosedChan():
+		default:
+			running = append(running, w)
+		}
+	}
+	p.stopped = running
+
+	if len(p.workers) == 0 {
+		return
+	}
+
+	capacity := float64(elapsed) * float64(len(p.workers))
+	idleRatio, busyRatio := float64(idle)/capacity, float64(busy)/capacity
+
+	switch {
+	case idleRatio < autoscaleUpIdleRatio && busyRatio > autoscaleUpBusyRatio && len(p.workers) < p.maxThreads:
+		// Grow by a quarter so that large pools converge quickly.
+		add := len(p.workers) / 4
+		if add < 1 {
+			add = 1
+		}
+		if len(p.workers)+add > p.maxThreads {
+			add = p.maxThreads - len(p.workers)
+		}
+		for i := 0; i < add; i++ {
+			w := p.newWorker()
+			if err := w.Consume(p.messagesIn); err != nil {
+				p.log.Errorf("Failed to start pipeline worker: %v\n", err)
+				break
+			}
+			p.workers = append(p.workers, w)
+		}
+		p.log.Debugf("Scaled pipeline up to %v threads\n", len(p.workers))
+	case busyRatio < autoscaleDownBusyRatio && len(p.workers) > p.minThreads:
+		w := p.workers[len(p.workers)-1]
+		p.workers = p.workers[:len(p.workers)-1]
+
+		// The worker stops once it has finished with its current message.
+		close(w.scaled.stopChan)
+		p.stopped = append(p.stopped, w)
+		p.log.Debugf("Scaled pipeline down to %v threads\n", len(p.workers))
+	}
+	p.setThreads()
+}
+
+// loop is the processing loop of this pipeline.
+func (p *AutoscalePool) loop() {
+	defer func() {
+		atomic.StoreUint32(&p.running, 0)
+
+		// Signal all workers to close, and wait for them to do so before
+		// closing the processors and our messages channel as the workers may
+		// still have access to them.
+		all := append(p.stopped, p.workers...)
+		for _, w := range all {
+			w.CloseAsync()
+		}
+		for _, w := range all {
+			_ = w.WaitForClose(shutdown.MaximumShutdownWait())
+		}
+		for _, c := range p.msgProcessors {
+			c.CloseAsync()
+		}
+		for _, c := range p.msgProcessors {
+			_ = c.WaitForClose(shutdown.MaximumShutdownWait())
+		}
+
+		close(p.messagesOut)
+		close(p.closed)
+	}()
+
+	started := p.workers[:0]
+	for _, w := range p.workers {
+		if err := w.Consume(p.messagesIn); err != nil {
+			p.log.Errorf("Failed to start pipeline worker: %v\n", err)
+			continue
+		}
+		started = append(started, w)
+	}
+	p.workers = started
+	p.setThreads()
+
+	ticker := time.NewTicker(p.period)
+	defer ticker.Stop()
+
+	lastScaled := time.Now()
+	for atomic.LoadUint32(&p.running) == 1 {
+		select {
+		case <-ticker.C:
+			p.scale(time.Since(lastScaled))
+			lastScaled = time.Now()
+		case <-p.inputClosedChan:
+			// Wait for the remaining workers to finish with their messages.
+			for _, w := range append(p.stopped, p.workers...) {
+				select {
+				case <-w.shutSig.HasClosedChan():
+				case <-p.closeChan:
+					return
+				}
+			}
+			return
+		case <-p.closeChan:
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Consume assigns a messages channel for the pipeline to read.
+func (p *AutoscalePool) Consume(msgs <-chan message.Transaction) error {
+	if p.messagesIn != nil {
+		return component.ErrAlreadyStarted
+	}
+	p.messagesIn = msgs
+	go p.loop()
+	return nil
+}
+
+// TransactionChan returns the channel used for consuming messages from this
+// pipeline.
+func (p *AutoscalePool) TransactionChan() <-chan message.Transaction {
+	return p.messagesOut
+}
+
+// CloseAsync shuts down the pipeline and stops processing messages.
+func (p *AutoscalePool) CloseAsync() {
+	if atomic.CompareAndSwapUint32(&p.running, 1, 0) {
+		close(p.closeChan)
+	}
+}
+
+// WaitForClose blocks until the pipeline has closed down.
+func (p *AutoscalePool) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-p.closed:
+	case <-time.After(timeout):
+		return component.ErrTimeout
+	}
+	return nil
+}
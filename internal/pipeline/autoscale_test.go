@@ -0,0 +1,133 @@
+package pipeline_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/pipeline"
+)
+
+type sleepProcessor struct {
+	sleep  time.Duration
+	closed int32
+}
+
+func (s *sleepProcessor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
+	time.Sleep(s.sleep)
+	return []*message.Batch{msg}, nil
+}
+
+func (s *sleepProcessor) CloseAsync() {
+	atomic.StoreInt32(&s.closed, 1)
+}
+
+func (s *sleepProcessor) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+func TestAutoscalePoolScaling(t *testing.T) {
+	conf := pipeline.NewAutoscaleConfig()
+	conf.Enabled = true
+	conf.MinThreads = 1
+	conf.MaxThreads = 4
+	conf.Period = "50ms"
+
+	proc := &sleepProcessor{sleep: time.Millisecond * 5}
+	stats := metrics.NewLocal()
+
+	pool, err := pipeline.NewAutoscalePool(1, conf, log.Noop(), stats, proc)
+	require.NoError(t, err)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, pool.Consume(tChan))
+	assert.Error(t, pool.Consume(tChan))
+
+	// Drain the pool as fast as possible so that the output is never the
+	// bottleneck.
+	var received int64
+	go func() {
+		for tran := range pool.TransactionChan() {
+			atomic.AddInt64(&received, 1)
+			assert.NoError(t, tran.Ack(context.Background(), nil))
+		}
+	}()
+
+	// Saturate the pool with many concurrent producers.
+	ctx, done := context.WithCancel(context.Background())
+	var producersWG sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		producersWG.Add(1)
+		go func() {
+			defer producersWG.Done()
+			for {
+				resChan := make(chan error, 1)
+				select {
+				case tChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello")}), resChan):
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case <-resChan:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		return pool.Threads() == 4
+	}, time.Second*5, time.Millisecond*10)
+	assert.Equal(t, int64(4), stats.GetCounters()["pipeline_threads"])
+
+	// Stop producing and the pool should shrink back down.
+	done()
+	producersWG.Wait()
+
+	assert.Eventually(t, func() bool {
+		return pool.Threads() == 1
+	}, time.Second*5, time.Millisecond*10)
+	assert.Greater(t, atomic.LoadInt64(&received), int64(0))
+
+	close(tChan)
+	require.NoError(t, pool.WaitForClose(time.Second*5))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&proc.closed))
+}
+
+func TestAutoscalePoolBounds(t *testing.T) {
+	conf := pipeline.NewAutoscaleConfig()
+	conf.Enabled = true
+	conf.MinThreads = 2
+	conf.MaxThreads = 3
+
+	pool, err := pipeline.NewAutoscalePool(10, conf, log.Noop(), metrics.Noop(), &sleepProcessor{})
+	require.NoError(t, err)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, pool.Consume(tChan))
+
+	assert.Eventually(t, func() bool {
+		return pool.Threads() == 3
+	}, time.Second*5, time.Millisecond*10)
+
+	pool.CloseAsync()
+	require.NoError(t, pool.WaitForClose(time.Second*5))
+
+	conf.MinThreads = 4
+	_, err = pipeline.NewAutoscalePool(1, conf, log.Noop(), metrics.Noop(), &sleepProcessor{})
+	require.EqualError(t, err, "autoscale max_threads (3) must not be less than min_threads (4)")
+
+	conf.MinThreads = 1
+	conf.Period = "nope"
+	_, err = pipeline.NewAutoscalePool(1, conf, log.Noop(), metrics.Noop(), &sleepProcessor{})
+	require.Error(t, err)
+}
@@ -18,6 +18,7 @@ import (
 type Config struct {
 	Threads    int                `json:"threads" yaml:"threads"`
 	Processors []processor.Config `json:"processors" yaml:"processors"`
+	Autoscale  AutoscaleConfig    `json:"autoscale" yaml:"autoscale"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
@@ -25,6 +26,7 @@ func NewConfig() Config {
 	return Config{
 		Threads:    -1,
 		Processors: []processor.Config{},
+		Autoscale:  NewAutoscaleConfig(),
 	}
 }
 
@@ -41,6 +43,9 @@ func New(conf Config, mgr bundle.NewManagement) (Type, error) {
 			return nil, err
 		}
 	}
+	if conf.Autoscale.Enabled {
+		return NewAutoscalePool(conf.Threads, conf.Autoscale, mgr.Logger(), mgr.Metrics(), processors...)
+	}
 	if conf.Threads == 1 {
 		return NewProcessor(processors...), nil
 	}
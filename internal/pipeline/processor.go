@@ -25,6 +25,10 @@ type Processor struct {
 
 	messagesIn <-chan message.Transaction
 
+	// scaled is set when the processor is a worker of an autoscaling pool, in
+	// which case its processors and output channel are owned by the pool.
+	scaled *scaledWorker
+
 	shutSig *shutdown.Signaller
 }
 
@@ -43,31 +47,54 @@ func NewProcessor(msgProcessors ...iprocessor.V1) *Processor {
 // loop is the processing loop of this pipeline.
 func (p *Processor) loop() {
 	defer func() {
-		// Signal all children to close.
-		for _, c := range p.msgProcessors {
-			c.CloseAsync()
+		if p.scaled == nil {
+			// Signal all children to close.
+			for _, c := range p.msgProcessors {
+				c.CloseAsync()
+			}
+			close(p.messagesOut)
 		}
-
-		close(p.messagesOut)
 		p.shutSig.ShutdownComplete()
 	}()
 
 	closeCtx, done := p.shutSig.CloseAtLeisureCtx(context.Background())
 	defer done()
 
+	// A nil channel is never selected.
+	var stopChan <-chan struct{}
+	if p.scaled != nil {
+		stopChan = p.scaled.stopChan
+	}
+
+	var waitStarted, procStarted time.Time
 	var open bool
 	for !p.shutSig.ShouldCloseAtLeisure() {
+		if p.scaled != nil {
+			waitStarted = time.Now()
+		}
+
 		var tran message.Transaction
 		select {
 		case tran, open = <-p.messagesIn:
 			if !open {
+				if p.scaled != nil {
+					p.scaled.inputClosed()
+				}
 				return
 			}
+		case <-stopChan:
+			return
 		case <-p.shutSig.CloseAtLeisureChan():
 			return
 		}
 
+		if p.scaled != nil {
+			procStarted = time.Now()
+		}
 		resultMsgs, resultRes := iprocessor.ExecuteAll(p.msgProcessors, tran.Payload)
+		if p.scaled != nil {
+			p.scaled.stats.record(procStarted.Sub(waitStarted), time.Since(procStarted))
+		}
 		if len(resultMsgs) == 0 {
 			if err := tran.Ack(closeCtx, resultRes); err != nil && closeCtx.Err() != nil {
 				return
@@ -150,6 +177,9 @@ func (p *Processor) TransactionChan() <-chan message.Transaction {
 // CloseAsync shuts down the pipeline and stops processing messages.
 func (p *Processor) CloseAsync() {
 	p.shutSig.CloseAtLeisure()
+	if p.scaled != nil {
+		return
+	}
 
 	// Signal all children to close.
 	for _, c := range p.msgProcessors {
@@ -165,6 +195,9 @@ func (p *Processor) WaitForClose(timeout time.Duration) error {
 	case <-time.After(time.Until(stopBy)):
 		return component.ErrTimeout
 	}
+	if p.scaled != nil {
+		return nil
+	}
 
 	// Wait for all processors to close.
 	for _, c := range p.msgProcessors {
@@ -0,0 +1,36 @@
+package secrets
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+)
+
+func init() {
+	RegisterProvider("aws", ProviderFunc(getAWSSecret))
+}
+
+// getAWSSecret reads a secret from AWS Secrets Manager, where the path is the
+// name or ARN of the secret. Credentials and the region are obtained from the
+// default AWS credentials chain.
+func getAWSSecret(ctx context.Context, path string) (string, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	out, err := secretsmanager.New(sess).GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(path),
+	})
+	if err != nil {
+		return "", err
+	}
+	if out.SecretString != nil {
+		return *out.SecretString, nil
+	}
+	return string(out.SecretBinary), nil
+}
@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+func init() {
+	RegisterProvider("gcp", ProviderFunc(getGCPSecret))
+}
+
+// getGCPSecret reads a secret version from GCP Secret Manager, where the path
+// is the resource name of the secret (e.g. `projects/foo/secrets/bar`),
+// optionally including a version, otherwise the latest version is used.
+// Credentials are obtained from the application default credentials.
+func getGCPSecret(ctx context.Context, path string) (string, error) {
+	path = strings.TrimPrefix(path, "/")
+	if !strings.Contains(path, "/versions/") {
+		path += "/versions/latest"
+	}
+
+	client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://secretmanager.googleapis.com/v1/"+path+":access", nil)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %v: %s", res.StatusCode, resBytes)
+	}
+
+	var body struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(resBytes, &body); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(body.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,4 @@
+// Package secrets resolves references to secrets held in external secret
+// managers, such as Vault, AWS Secrets Manager and GCP Secret Manager, which
+// can be used within configs in place of plaintext credentials.
+package secrets
@@ -0,0 +1,217 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider obtains the value of a secret from an external secret manager.
+type Provider interface {
+	// Get the value of a secret by its path, the format of which is specific
+	// to the provider.
+	Get(ctx context.Context, path string) (string, error)
+}
+
+// ProviderFunc is a closure that implements Provider.
+type ProviderFunc func(ctx context.Context, path string) (string, error)
+
+// Get the value of a secret by its path.
+func (p ProviderFunc) Get(ctx context.Context, path string) (string, error) {
+	return p(ctx, path)
+}
+
+var (
+	providersMut sync.RWMutex
+	providers    = map[string]Provider{}
+
+	cacheMut       sync.Mutex
+	cache          = map[string]cachedSecret{}
+	cacheTTL       = time.Minute * 10
+	resolveTimeout = time.Second * 30
+)
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// SetTimeout sets the maximum period to wait for a provider to return the
+// value of a secret, after which resolving the secret fails. A zero period
+// disables the timeout.
+func SetTimeout(d time.Duration) {
+	cacheMut.Lock()
+	resolveTimeout = d
+	cacheMut.Unlock()
+}
+
+// SetCacheTTL sets the period after which a cached secret value expires, and is
+// therefore fetched again the next time it is resolved. A zero period means
+// cached values never expire.
+func SetCacheTTL(d time.Duration) {
+	cacheMut.Lock()
+	cacheTTL = d
+	cacheMut.Unlock()
+}
+
+func expiresAt(now time.Time) time.Time {
+	if cacheTTL <= 0 {
+		return time.Time{}
+	}
+	return now.Add(cacheTTL)
+}
+
+// RegisterProvider adds a secrets provider under a name, which is used as the
+// prefix of references targeting it. Registering a provider with a name that
+// already exists replaces it.
+func RegisterProvider(name string, p Provider) {
+	providersMut.Lock()
+	providers[name] = p
+	providersMut.Unlock()
+}
+
+// Reference is a parsed reference to a secret of the form
+// `<provider>:<path>#<key>`, where the key is optional and selects a field
+// from a secret holding a JSON object.
+type Reference struct {
+	Provider string
+	Path     string
+	Key      string
+}
+
+// ParseReference parses a reference of the form `<provider>:<path>#<key>`.
+func ParseReference(ref string) (Reference, error) {
+	i := strings.Index(ref, ":")
+	if i <= 0 || i == len(ref)-1 {
+		return Reference{}, fmt.Errorf("secret reference '%v' must be of the form <provider>:<path>", ref)
+	}
+	r := Reference{
+		Provider: ref[:i],
+		Path:     ref[i+1:],
+	}
+	if j := strings.LastIndex(r.Path, "#"); j != -1 {
+		r.Key = r.Path[j+1:]
+		r.Path = r.Path[:j]
+	}
+	if r.Path == "" {
+		return Reference{}, fmt.Errorf("secret reference '%v' has an empty path", ref)
+	}
+	return r, nil
+}
+
+func (r Reference) fetch(ctx context.Context) (string, error) {
+	providersMut.RLock()
+	p, exists := providers[r.Provider]
+	providersMut.RUnlock()
+	if !exists {
+		return "", fmt.Errorf("secrets provider '%v' not recognised", r.Provider)
+	}
+
+	cacheMut.Lock()
+	timeout := resolveTimeout
+	cacheMut.Unlock()
+	if timeout > 0 {
+		var done func()
+		ctx, done = context.WithTimeout(ctx, timeout)
+		defer done()
+	}
+
+	value, err := p.Get(ctx, r.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain secret '%v' from %v: %w", r.Path, r.Provider, err)
+	}
+	if r.Key == "" {
+		return value, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("failed to select key '%v' from secret '%v': secret is not a JSON object", r.Key, r.Path)
+	}
+	field, exists := fields[r.Key]
+	if !exists {
+		return "", fmt.Errorf("key '%v' was not found in secret '%v'", r.Key, r.Path)
+	}
+	if s, ok := field.(string); ok {
+		return s, nil
+	}
+	fieldBytes, err := json.Marshal(field)
+	if err != nil {
+		return "", err
+	}
+	return string(fieldBytes), nil
+}
+
+// Resolve obtains the value of a secret by its reference. Values are cached
+// after they are first obtained, and are fetched again either by Refresh or
+// when resolved after the cache TTL has passed.
+func Resolve(ctx context.Context, ref string) (string, error) {
+	cacheMut.Lock()
+	cached, exists := cache[ref]
+	cacheMut.Unlock()
+	if exists && (cached.expires.IsZero() || time.Now().Before(cached.expires)) {
+		return cached.value, nil
+	}
+
+	r, err := ParseReference(ref)
+	if err != nil {
+		return "", err
+	}
+	value, err := r.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	cacheMut.Lock()
+	cache[ref] = cachedSecret{value: value, expires: expiresAt(time.Now())}
+	cacheMut.Unlock()
+	return value, nil
+}
+
+// Refresh fetches the latest value of every secret that has been resolved so
+// far, and returns a list of the references where the value has changed.
+func Refresh(ctx context.Context) (changed []string, err error) {
+	cacheMut.Lock()
+	refs := make([]string, 0, len(cache))
+	for ref := range cache {
+		refs = append(refs, ref)
+	}
+	cacheMut.Unlock()
+	sort.Strings(refs)
+
+	var errs []string
+	for _, ref := range refs {
+		r, pErr := ParseReference(ref)
+		if pErr != nil {
+			errs = append(errs, pErr.Error())
+			continue
+		}
+		value, fErr := r.fetch(ctx)
+		if fErr != nil {
+			errs = append(errs, fErr.Error())
+			continue
+		}
+		cacheMut.Lock()
+		if cache[ref].value != value {
+			changed = append(changed, ref)
+		}
+		cache[ref] = cachedSecret{value: value, expires: expiresAt(time.Now())}
+		cacheMut.Unlock()
+	}
+	if len(errs) > 0 {
+		err = errors.New(strings.Join(errs, ", "))
+	}
+	return
+}
+
+// ResetCache removes all cached secret values.
+func ResetCache() {
+	cacheMut.Lock()
+	cache = map[string]cachedSecret{}
+	cacheMut.Unlock()
+}
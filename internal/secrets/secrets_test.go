@@ -0,0 +1,218 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		output Reference
+		err    string
+	}{
+		"path only": {
+			input:  "vault:secret/data/foo",
+			output: Reference{Provider: "vault", Path: "secret/data/foo"},
+		},
+		"path and key": {
+			input:  "vault:secret/data/foo#password",
+			output: Reference{Provider: "vault", Path: "secret/data/foo", Key: "password"},
+		},
+		"path with colons": {
+			input:  "aws:arn:aws:secretsmanager:eu-west-1:123:secret:foo#bar",
+			output: Reference{Provider: "aws", Path: "arn:aws:secretsmanager:eu-west-1:123:secret:foo", Key: "bar"},
+		},
+		"no provider": {
+			input: "secret/data/foo",
+			err:   "secret reference 'secret/data/foo' must be of the form <provider>:<path>",
+		},
+		"empty path": {
+			input: "vault:#foo",
+			err:   "secret reference 'vault:#foo' has an empty path",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			ref, err := ParseReference(test.input)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.output, ref)
+		})
+	}
+}
+
+func TestResolveAndRefresh(t *testing.T) {
+	ResetCache()
+	t.Cleanup(ResetCache)
+
+	var mut sync.Mutex
+	values := map[string]string{
+		"foo": `{"user":"foouser","pass":"foopass","port":1234}`,
+		"bar": "barvalue",
+	}
+	RegisterProvider("test", ProviderFunc(func(ctx context.Context, path string) (string, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		v, exists := values[path]
+		if !exists {
+			return "", errors.New("not found")
+		}
+		return v, nil
+	}))
+
+	ctx := context.Background()
+
+	v, err := Resolve(ctx, "test:foo#pass")
+	require.NoError(t, err)
+	assert.Equal(t, "foopass", v)
+
+	v, err = Resolve(ctx, "test:foo#port")
+	require.NoError(t, err)
+	assert.Equal(t, "1234", v)
+
+	v, err = Resolve(ctx, "test:bar")
+	require.NoError(t, err)
+	assert.Equal(t, "barvalue", v)
+
+	_, err = Resolve(ctx, "test:foo#nope")
+	require.EqualError(t, err, "key 'nope' was not found in secret 'foo'")
+
+	_, err = Resolve(ctx, "test:bar#nope")
+	require.EqualError(t, err, "failed to select key 'nope' from secret 'bar': secret is not a JSON object")
+
+	_, err = Resolve(ctx, "test:baz")
+	require.EqualError(t, err, "failed to obtain secret 'baz' from test: not found")
+
+	_, err = Resolve(ctx, "nope:baz")
+	require.EqualError(t, err, "secrets provider 'nope' not recognised")
+
+	mut.Lock()
+	values["foo"] = `{"user":"foouser","pass":"newpass","port":1234}`
+	mut.Unlock()
+
+	// Cached values are returned until refreshed
+	v, err = Resolve(ctx, "test:foo#pass")
+	require.NoError(t, err)
+	assert.Equal(t, "foopass", v)
+
+	changed, err := Refresh(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test:foo#pass"}, changed)
+
+	v, err = Resolve(ctx, "test:foo#pass")
+	require.NoError(t, err)
+	assert.Equal(t, "newpass", v)
+}
+
+func TestResolveCacheTTL(t *testing.T) {
+	ResetCache()
+	SetCacheTTL(time.Millisecond * 50)
+	t.Cleanup(func() {
+		ResetCache()
+		SetCacheTTL(time.Minute * 10)
+	})
+
+	var mut sync.Mutex
+	value := "first"
+	RegisterProvider("testttl", ProviderFunc(func(ctx context.Context, path string) (string, error) {
+		mut.Lock()
+		defer mut.Unlock()
+		return value, nil
+	}))
+
+	ctx := context.Background()
+
+	v, err := Resolve(ctx, "testttl:foo")
+	require.NoError(t, err)
+	assert.Equal(t, "first", v)
+
+	mut.Lock()
+	value = "second"
+	mut.Unlock()
+
+	v, err = Resolve(ctx, "testttl:foo")
+	require.NoError(t, err)
+	assert.Equal(t, "first", v)
+
+	// Expired values are fetched again
+	<-time.After(time.Millisecond * 100)
+
+	v, err = Resolve(ctx, "testttl:foo")
+	require.NoError(t, err)
+	assert.Equal(t, "second", v)
+}
+
+func TestResolveTimeout(t *testing.T) {
+	ResetCache()
+	SetTimeout(time.Millisecond * 50)
+	t.Cleanup(func() {
+		ResetCache()
+		SetTimeout(time.Second * 30)
+	})
+
+	RegisterProvider("testslow", ProviderFunc(func(ctx context.Context, path string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}))
+
+	_, err := Resolve(context.Background(), "testslow:foo")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
+
+func TestVaultProvider(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "footoken" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/foo":
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"foopass"},"metadata":{"version":2}}}`))
+		case "/v1/kv/bar":
+			_, _ = w.Write([]byte(`{"data":{"password":"barpass"}}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	require.NoError(t, os.Setenv("VAULT_ADDR", ts.URL))
+	require.NoError(t, os.Setenv("VAULT_TOKEN", "footoken"))
+	t.Cleanup(func() {
+		_ = os.Unsetenv("VAULT_ADDR")
+		_ = os.Unsetenv("VAULT_TOKEN")
+	})
+
+	ctx := context.Background()
+
+	v, err := getVaultSecret(ctx, "secret/data/foo")
+	require.NoError(t, err)
+	assert.Equal(t, `{"password":"foopass"}`, v)
+
+	v, err = getVaultSecret(ctx, "kv/bar")
+	require.NoError(t, err)
+	assert.Equal(t, `{"password":"barpass"}`, v)
+
+	_, err = getVaultSecret(ctx, "kv/baz")
+	require.Error(t, err)
+
+	require.NoError(t, os.Setenv("VAULT_TOKEN", "badtoken"))
+	_, err = getVaultSecret(ctx, "kv/bar")
+	require.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+func init() {
+	RegisterProvider("vault", ProviderFunc(getVaultSecret))
+}
+
+// getVaultSecret reads a secret from the Vault HTTP API, where the path is the
+// API path of the secret (e.g. `secret/data/foo` for a KV version 2 engine
+// mounted at `secret`). The address, token and namespace are obtained from the
+// standard environment variables VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE.
+func getVaultSecret(ctx context.Context, path string) (string, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		addr = "https://127.0.0.1:8200"
+	}
+	url := strings.TrimSuffix(addr, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if ns := os.Getenv("VAULT_NAMESPACE"); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %v: %s", res.StatusCode, resBytes)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(resBytes, &body); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// KV version 2 engines nest the secret within a data field alongside its
+	// metadata.
+	if inner, exists := body.Data["data"]; exists {
+		if _, hasMeta := body.Data["metadata"]; hasMeta {
+			return string(inner), nil
+		}
+	}
+
+	dataBytes, err := json.Marshal(body.Data)
+	if err != nil {
+		return "", err
+	}
+	return string(dataBytes), nil
+}
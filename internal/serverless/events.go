@@ -0,0 +1,141 @@
+package serverless
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// batchEvent is an invocation payload containing a batch of records delivered
+// by an event source mapping, where each record is converted into a message
+// and the identifiers are used for reporting partial batch failures.
+type batchEvent struct {
+	parts       []*message.Part
+	identifiers []string
+}
+
+type recordUnwrapper func(record map[string]interface{}) (part *message.Part, identifier string, err error)
+
+var recordUnwrappers = map[string]recordUnwrapper{
+	"aws:sqs":      unwrapSQSRecord,
+	"aws:kinesis":  unwrapKinesisRecord,
+	"aws:dynamodb": unwrapDynamoDBRecord,
+}
+
+// unwrapBatchEvent attempts to parse an invocation payload as a batch of
+// records from a supported event source (SQS, Kinesis or DynamoDB streams).
+// Returns false if the payload is not a batch event of a supported source, in
+// which case it should be processed as a single message.
+func unwrapBatchEvent(obj interface{}) (*batchEvent, bool, error) {
+	root, ok := obj.(map[string]interface{})
+	if !ok {
+		return nil, false, nil
+	}
+	records, ok := root["Records"].([]interface{})
+	if !ok || len(records) == 0 {
+		return nil, false, nil
+	}
+
+	first, _ := records[0].(map[string]interface{})
+	source, _ := first["eventSource"].(string)
+	unwrap, exists := recordUnwrappers[source]
+	if !exists {
+		return nil, false, nil
+	}
+
+	event := &batchEvent{}
+	for i, r := range records {
+		record, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, true, fmt.Errorf("record %v: expected object, got %T", i, r)
+		}
+		if s, _ := record["eventSource"].(string); s != source {
+			return nil, true, fmt.Errorf("record %v: event source '%v' does not match '%v'", i, s, source)
+		}
+		part, id, err := unwrap(record)
+		if err != nil {
+			return nil, true, fmt.Errorf("record %v: %w", i, err)
+		}
+		event.parts = append(event.parts, part)
+		event.identifiers = append(event.identifiers, id)
+	}
+	return event, true, nil
+}
+
+func unwrapSQSRecord(record map[string]interface{}) (*message.Part, string, error) {
+	id, _ := record["messageId"].(string)
+	if id == "" {
+		return nil, "", errors.New("missing field messageId")
+	}
+	body, _ := record["body"].(string)
+
+	part := message.NewPart([]byte(body))
+	part.MetaSet("sqs_message_id", id)
+	if arn, _ := record["eventSourceARN"].(string); arn != "" {
+		part.MetaSet("sqs_event_source_arn", arn)
+	}
+	if attrs, ok := record["attributes"].(map[string]interface{}); ok {
+		if count, _ := attrs["ApproximateReceiveCount"].(string); count != "" {
+			part.MetaSet("sqs_approximate_receive_count", count)
+		}
+	}
+	if attrs, ok := record["messageAttributes"].(map[string]interface{}); ok {
+		for k, v := range attrs {
+			attr, _ := v.(map[string]interface{})
+			if str, ok := attr["stringValue"].(string); ok {
+				part.MetaSet(k, str)
+			}
+		}
+	}
+	return part, id, nil
+}
+
+func unwrapKinesisRecord(record map[string]interface{}) (*message.Part, string, error) {
+	kin, ok := record["kinesis"].(map[string]interface{})
+	if !ok {
+		return nil, "", errors.New("missing field kinesis")
+	}
+	seq, _ := kin["sequenceNumber"].(string)
+	if seq == "" {
+		return nil, "", errors.New("missing field kinesis.sequenceNumber")
+	}
+	dataStr, _ := kin["data"].(string)
+	data, err := base64.StdEncoding.DecodeString(dataStr)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode kinesis.data: %w", err)
+	}
+
+	part := message.NewPart(data)
+	part.MetaSet("kinesis_sequence_number", seq)
+	if key, _ := kin["partitionKey"].(string); key != "" {
+		part.MetaSet("kinesis_partition_key", key)
+	}
+	if arn, _ := record["eventSourceARN"].(string); arn != "" {
+		part.MetaSet("kinesis_event_source_arn", arn)
+	}
+	return part, seq, nil
+}
+
+func unwrapDynamoDBRecord(record map[string]interface{}) (*message.Part, string, error) {
+	ddb, ok := record["dynamodb"].(map[string]interface{})
+	if !ok {
+		return nil, "", errors.New("missing field dynamodb")
+	}
+	seq, _ := ddb["SequenceNumber"].(string)
+	if seq == "" {
+		return nil, "", errors.New("missing field dynamodb.SequenceNumber")
+	}
+
+	part := message.NewPart(nil)
+	part.SetJSON(ddb)
+	part.MetaSet("dynamodb_sequence_number", seq)
+	if name, _ := record["eventName"].(string); name != "" {
+		part.MetaSet("dynamodb_event_name", name)
+	}
+	if arn, _ := record["eventSourceARN"].(string); arn != "" {
+		part.MetaSet("dynamodb_event_source_arn", arn)
+	}
+	return part, seq, nil
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
@@ -28,8 +30,29 @@ const ServerlessResponseType = "sync_response"
 // Handler contains a live Benthos pipeline and wraps it within an invoke
 // handler.
 type Handler struct {
-	transactionChan chan message.Transaction
-	done            func(exitTimeout time.Duration) error
+	transactionChan   chan message.Transaction
+	responseMapping   *mapping.Executor
+	unwrapBatchEvents bool
+	done              func(exitTimeout time.Duration) error
+}
+
+// OptResponseMapping sets a Bloblang mapping to be executed on the response of
+// each invocation before it is returned to the caller, which allows the shape
+// of the response to be customised.
+func OptResponseMapping(exec *mapping.Executor) func(*Handler) {
+	return func(h *Handler) {
+		h.responseMapping = exec
+	}
+}
+
+// OptUnwrapBatchEvents sets whether payloads containing a batch of records from
+// an SQS, Kinesis or DynamoDB stream event source are unwrapped into a batch of
+// messages, with the response reporting the records that failed to be
+// processed as partial batch failures.
+func OptUnwrapBatchEvents(b bool) func(*Handler) {
+	return func(h *Handler) {
+		h.unwrapBatchEvents = b
+	}
 }
 
 // Close shuts down the underlying pipeline. If the shut down takes longer than
@@ -40,30 +63,107 @@ func (h *Handler) Close(tout time.Duration) error {
 
 // Handle is a request/response func that injects a payload into the underlying
 // Benthos pipeline and returns a result.
+//
+// When enabled with OptUnwrapBatchEvents, payloads containing a batch of
+// records from an SQS, Kinesis or DynamoDB stream event source are unwrapped
+// into a batch of messages, and the response reports the records that failed
+// to be processed as partial batch failures.
 func (h *Handler) Handle(ctx context.Context, obj interface{}) (interface{}, error) {
-	msg := message.QuickBatch(nil)
-	part := message.NewPart(nil)
-	part.SetJSON(obj)
-	msg.Append(part)
+	var (
+		event   *batchEvent
+		isBatch bool
+		err     error
+	)
+	if h.unwrapBatchEvents {
+		if event, isBatch, err = unwrapBatchEvent(obj); err != nil {
+			return nil, fmt.Errorf("failed to unwrap batch event: %w", err)
+		}
+	}
 
-	store := transaction.NewResultStore()
-	transaction.AddResultStore(msg, store)
+	var res interface{}
+	if isBatch {
+		res, err = h.handleBatch(ctx, event)
+	} else {
+		res, err = h.handleSingle(ctx, obj)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h.mapResponse(res)
+}
 
+func (h *Handler) send(ctx context.Context, msg *message.Batch) error {
 	resChan := make(chan error, 1)
 
 	select {
 	case h.transactionChan <- message.NewTransaction(msg, resChan):
 	case <-ctx.Done():
-		return nil, errors.New("request cancelled")
+		return errors.New("request cancelled")
 	}
 
 	select {
 	case res := <-resChan:
-		if res != nil {
-			return nil, res
-		}
+		return res
 	case <-ctx.Done():
-		return nil, errors.New("request cancelled")
+		return errors.New("request cancelled")
+	}
+}
+
+func (h *Handler) handleBatch(ctx context.Context, event *batchEvent) (interface{}, error) {
+	group, parts := message.NewSortGroupParts(event.parts)
+	msg := message.QuickBatch(nil)
+	msg.SetAll(parts)
+
+	// Results are not returned for batch events, but a store is still required
+	// for any sync_response outputs to write to.
+	transaction.AddResultStore(msg, transaction.NewResultStore())
+
+	failures := []interface{}{}
+	err := h.send(ctx, msg)
+	if err != nil {
+		// Errors that aren't specific to individual messages result in the
+		// entire batch being retried.
+		var bErr *batch.Error
+		if !errors.As(err, &bErr) || bErr.IndexedErrors() == 0 {
+			return nil, err
+		}
+
+		failed := map[int]struct{}{}
+		bErr.WalkParts(func(_ int, p *message.Part, pErr error) bool {
+			if pErr == nil {
+				return true
+			}
+			index := group.GetIndex(p)
+			if index == -1 {
+				return false
+			}
+			failed[index] = struct{}{}
+			return true
+		})
+
+		for i, id := range event.identifiers {
+			if _, exists := failed[i]; exists {
+				failures = append(failures, map[string]interface{}{"itemIdentifier": id})
+			}
+		}
+		if len(failures) == 0 {
+			return nil, err
+		}
+	}
+	return map[string]interface{}{"batchItemFailures": failures}, nil
+}
+
+func (h *Handler) handleSingle(ctx context.Context, obj interface{}) (interface{}, error) {
+	msg := message.QuickBatch(nil)
+	part := message.NewPart(nil)
+	part.SetJSON(obj)
+	msg.Append(part)
+
+	store := transaction.NewResultStore()
+	transaction.AddResultStore(msg, store)
+
+	if err := h.send(ctx, msg); err != nil {
+		return nil, err
 	}
 
 	resultBatches := store.Get()
@@ -100,8 +200,28 @@ func (h *Handler) Handle(ctx context.Context, obj interface{}) (interface{}, err
 	return genBatchOfBatches, nil
 }
 
+func (h *Handler) mapResponse(res interface{}) (interface{}, error) {
+	if h.responseMapping == nil {
+		return res, nil
+	}
+
+	part := message.NewPart(nil)
+	part.SetJSON(res)
+	msg := message.QuickBatch(nil)
+	msg.Append(part)
+
+	mapped, err := h.responseMapping.MapPart(0, msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute response mapping: %w", err)
+	}
+	if mapped == nil {
+		return nil, nil
+	}
+	return mapped.JSON()
+}
+
 // NewHandler returns a Handler by creating a Benthos pipeline.
-func NewHandler(conf config.Type) (*Handler, error) {
+func NewHandler(conf config.Type, opts ...func(*Handler)) (*Handler, error) {
 	// Logging and stats aggregation.
 	logger, err := log.NewV2(os.Stdout, conf.Logger)
 	if err != nil {
@@ -152,7 +272,7 @@ func NewHandler(conf config.Type) (*Handler, error) {
 		return nil, fmt.Errorf("failed to create resource: %v", err)
 	}
 
-	return &Handler{
+	h := &Handler{
 		transactionChan: transactionChan,
 		done: func(exitTimeout time.Duration) error {
 			timesOut := time.Now().Add(exitTimeout)
@@ -178,7 +298,11 @@ func NewHandler(conf config.Type) (*Handler, error) {
 			}
 			return nil
 		},
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h, nil
 }
 
 //------------------------------------------------------------------------------
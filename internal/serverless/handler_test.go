@@ -10,6 +10,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/config"
@@ -201,3 +205,222 @@ func TestHandlerCombined(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func lambdaOutputConf(conf *config.Type) {
+	conf.Output.Type = "switch"
+	conf.Output.Switch.RetryUntilSuccess = false
+
+	errorCase := output.NewSwitchConfigCase()
+	errorCase.Check = "errored()"
+	errorCase.Output.Type = "reject"
+	errorCase.Output.Reject = "processing failed due to: ${! error() }"
+
+	responseCase := output.NewSwitchConfigCase()
+	responseCase.Output.Type = ServerlessResponseType
+
+	conf.Output.Switch.Cases = append(conf.Output.Switch.Cases, errorCase, responseCase)
+}
+
+func TestHandlerSQSBatchFailures(t *testing.T) {
+	conf := config.New()
+	lambdaOutputConf(&conf)
+
+	pConf := processor.NewConfig()
+	pConf.Type = "bloblang"
+	pConf.Bloblang = `root = if content() == "bad" { throw("nope") } else { content().uppercase() }`
+	conf.Pipeline.Processors = append(conf.Pipeline.Processors, pConf)
+
+	h, err := NewHandler(conf, OptUnwrapBatchEvents(true))
+	require.NoError(t, err)
+
+	sqsRecord := func(id, body string) interface{} {
+		return map[string]interface{}{
+			"messageId":   id,
+			"body":        body,
+			"eventSource": "aws:sqs",
+		}
+	}
+
+	res, err := h.Handle(context.Background(), map[string]interface{}{
+		"Records": []interface{}{
+			sqsRecord("a", "good"),
+			sqsRecord("b", "bad"),
+			sqsRecord("c", "good"),
+			sqsRecord("d", "bad"),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"batchItemFailures": []interface{}{
+			map[string]interface{}{"itemIdentifier": "b"},
+			map[string]interface{}{"itemIdentifier": "d"},
+		},
+	}, res)
+
+	res, err = h.Handle(context.Background(), map[string]interface{}{
+		"Records": []interface{}{
+			sqsRecord("e", "good"),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"batchItemFailures": []interface{}{},
+	}, res)
+
+	require.NoError(t, h.Close(time.Second*10))
+}
+
+func TestHandlerBatchEventsNotUnwrappedByDefault(t *testing.T) {
+	conf := config.New()
+	lambdaOutputConf(&conf)
+
+	pConf := processor.NewConfig()
+	pConf.Type = "bloblang"
+	pConf.Bloblang = `root.bodies = this.Records.map_each(r -> r.body)`
+	conf.Pipeline.Processors = append(conf.Pipeline.Processors, pConf)
+
+	h, err := NewHandler(conf)
+	require.NoError(t, err)
+
+	res, err := h.Handle(context.Background(), map[string]interface{}{
+		"Records": []interface{}{
+			map[string]interface{}{"messageId": "a", "body": "foo", "eventSource": "aws:sqs"},
+			map[string]interface{}{"messageId": "b", "body": "bar", "eventSource": "aws:sqs"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"bodies": []interface{}{"foo", "bar"},
+	}, res)
+
+	require.NoError(t, h.Close(time.Second*10))
+}
+
+func TestHandlerUnwrapBatchEvents(t *testing.T) {
+	tests := map[string]struct {
+		event   interface{}
+		content []string
+		meta    []map[string]string
+		ids     []string
+	}{
+		"sqs": {
+			event: map[string]interface{}{
+				"Records": []interface{}{
+					map[string]interface{}{
+						"messageId":      "foo",
+						"body":           "hello world",
+						"eventSource":    "aws:sqs",
+						"eventSourceARN": "arn:aws:sqs:us-east-1:123456789012:queue",
+						"attributes": map[string]interface{}{
+							"ApproximateReceiveCount": "2",
+						},
+						"messageAttributes": map[string]interface{}{
+							"bar": map[string]interface{}{"stringValue": "baz", "dataType": "String"},
+						},
+					},
+				},
+			},
+			content: []string{"hello world"},
+			meta: []map[string]string{{
+				"sqs_message_id":                "foo",
+				"sqs_event_source_arn":          "arn:aws:sqs:us-east-1:123456789012:queue",
+				"sqs_approximate_receive_count": "2",
+				"bar":                           "baz",
+			}},
+			ids: []string{"foo"},
+		},
+		"kinesis": {
+			event: map[string]interface{}{
+				"Records": []interface{}{
+					map[string]interface{}{
+						"eventSource": "aws:kinesis",
+						"kinesis": map[string]interface{}{
+							"partitionKey":   "key1",
+							"sequenceNumber": "100",
+							"data":           "aGVsbG8gd29ybGQ=",
+						},
+					},
+				},
+			},
+			content: []string{"hello world"},
+			meta: []map[string]string{{
+				"kinesis_partition_key":   "key1",
+				"kinesis_sequence_number": "100",
+			}},
+			ids: []string{"100"},
+		},
+		"dynamodb": {
+			event: map[string]interface{}{
+				"Records": []interface{}{
+					map[string]interface{}{
+						"eventSource": "aws:dynamodb",
+						"eventName":   "INSERT",
+						"dynamodb": map[string]interface{}{
+							"SequenceNumber": "200",
+							"Keys": map[string]interface{}{
+								"id": map[string]interface{}{"S": "foo"},
+							},
+						},
+					},
+				},
+			},
+			content: []string{`{"Keys":{"id":{"S":"foo"}},"SequenceNumber":"200"}`},
+			meta: []map[string]string{{
+				"dynamodb_sequence_number": "200",
+				"dynamodb_event_name":      "INSERT",
+			}},
+			ids: []string{"200"},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			event, isBatch, err := unwrapBatchEvent(test.event)
+			require.NoError(t, err)
+			require.True(t, isBatch)
+
+			assert.Equal(t, test.ids, event.identifiers)
+			require.Len(t, event.parts, len(test.content))
+			for i, p := range event.parts {
+				assert.Equal(t, test.content[i], string(p.Get()))
+
+				meta := map[string]string{}
+				_ = p.MetaIter(func(k, v string) error {
+					meta[k] = v
+					return nil
+				})
+				assert.Equal(t, test.meta[i], meta)
+			}
+		})
+	}
+
+	_, isBatch, err := unwrapBatchEvent(map[string]interface{}{
+		"Records": []interface{}{
+			map[string]interface{}{"eventSource": "aws:s3"},
+		},
+	})
+	require.NoError(t, err)
+	assert.False(t, isBatch)
+}
+
+func TestHandlerResponseMapping(t *testing.T) {
+	conf := config.New()
+	conf.Output.Type = ServerlessResponseType
+
+	exec, err := bloblang.GlobalEnvironment().NewMapping(`root.body = this.foo.uppercase()
+root.statusCode = 200`)
+	require.NoError(t, err)
+
+	h, err := NewHandler(conf, OptResponseMapping(exec))
+	require.NoError(t, err)
+
+	res, err := h.Handle(context.Background(), map[string]interface{}{"foo": "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"body":       "BAR",
+		"statusCode": int64(200),
+	}, res)
+
+	require.NoError(t, h.Close(time.Second*10))
+}
@@ -3,11 +3,13 @@ package lambda
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/serverless"
@@ -16,7 +18,10 @@ import (
 var handler *serverless.Handler
 
 // Run executes Benthos as an AWS Lambda function. Configuration can be stored
-// within the environment variable BENTHOS_CONFIG.
+// within the environment variable BENTHOS_CONFIG, a Bloblang mapping for
+// shaping the response of each invocation within BENTHOS_RESPONSE_MAPPING, and
+// the unwrapping of batch events from SQS, Kinesis and DynamoDB streams can be
+// enabled by setting BENTHOS_UNWRAP_BATCH_EVENTS to true.
 func Run() {
 	// A list of default config paths to check for if not explicitly defined
 	defaultPaths := []string{
@@ -48,8 +53,11 @@ func Run() {
 	conf.Output.Switch.Cases = append(conf.Output.Switch.Cases, errorCase, responseCase)
 
 	if confStr := os.Getenv("BENTHOS_CONFIG"); len(confStr) > 0 {
-		confBytes := config.ReplaceEnvVariables([]byte(confStr))
-		if err := yaml.Unmarshal(confBytes, &conf); err != nil {
+		confBytes, err := config.ReplaceEnvVariables([]byte(confStr))
+		if err == nil {
+			err = yaml.Unmarshal(confBytes, &conf)
+		}
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
 			os.Exit(1)
 		}
@@ -66,8 +74,27 @@ func Run() {
 		}
 	}
 
+	var opts []func(*serverless.Handler)
+	if mappingStr := os.Getenv("BENTHOS_RESPONSE_MAPPING"); len(mappingStr) > 0 {
+		exec, err := bloblang.GlobalEnvironment().NewMapping(mappingStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Response mapping error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, serverless.OptResponseMapping(exec))
+	}
+
+	if unwrapStr := os.Getenv("BENTHOS_UNWRAP_BATCH_EVENTS"); len(unwrapStr) > 0 {
+		unwrap, err := strconv.ParseBool(unwrapStr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unwrap batch events error: %v\n", err)
+			os.Exit(1)
+		}
+		opts = append(opts, serverless.OptUnwrapBatchEvents(unwrap))
+	}
+
 	var err error
-	if handler, err = serverless.NewHandler(conf); err != nil {
+	if handler, err = serverless.NewHandler(conf, opts...); err != nil {
 		fmt.Fprintf(os.Stderr, "Initialisation error: %v\n", err)
 		os.Exit(1)
 	}
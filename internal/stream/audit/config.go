@@ -0,0 +1,27 @@
+package audit
+
+import "github.com/benthosdev/benthos/v4/internal/docs"
+
+// Config contains configuration fields for the streams mode audit log.
+type Config struct {
+	File   string `json:"file" yaml:"file"`
+	Output string `json:"output" yaml:"output"`
+}
+
+// NewConfig creates a new audit log config with default values.
+func NewConfig() Config {
+	return Config{
+		File:   "",
+		Output: "",
+	}
+}
+
+// Spec returns a field spec for the audit log configuration fields.
+func Spec() docs.FieldSpec {
+	return docs.FieldObject(
+		"streams_audit_log", "Record every create, update and delete of a stream in streams mode as a JSON document, including the principal that submitted the change (the common name of a verified client certificate when `http.client_ca_file` is set, otherwise the remote address of the request) and a diff of the stream config. This has no effect outside of streams mode.",
+	).WithChildren(
+		docs.FieldString("file", "An optional path to a file that audit events are appended to as lines of JSON.").HasDefault(""),
+		docs.FieldString("output", "An optional label of an [output resource](/docs/configuration/resources) to send audit events to. Events are sent in the background, and are dropped when too many are pending delivery.").HasDefault(""),
+	).Advanced()
+}
@@ -0,0 +1,288 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// Actions that can be recorded by an audit log.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Change describes a single field of a stream config that was added, removed
+// or modified, where the path is a dot separated path to the field.
+type Change struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// Event describes a change made to a stream.
+type Event struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	Stream    string    `json:"stream"`
+	Principal string    `json:"principal,omitempty"`
+	Changes   []Change  `json:"changes,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// PrincipalFromRequest returns the identity of the submitter of an HTTP
+// request. Only identities verified by the server are trusted, which is the
+// common name of a client certificate verified against the client_ca_file of
+// the HTTP server. Otherwise the request is recorded as anonymous along with
+// its remote address.
+func PrincipalFromRequest(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		if cn := r.TLS.VerifiedChains[0][0].Subject.CommonName; cn != "" {
+			return cn
+		}
+	}
+	return "anonymous (" + r.RemoteAddr + ")"
+}
+
+// Diff returns the changes required to get from one sanitised config to
+// another, sorted by path. Either config may be nil, in which case all fields
+// of the other are reported.
+func Diff(before, after interface{}) []Change {
+	return DiffRedacted(before, after, before, after)
+}
+
+// DiffRedacted returns the changes required to get from one sanitised config
+// to another in the same way as Diff, but the values of each change are taken
+// from redacted copies of the configs, which must share the same structure.
+// This allows changes to secret values to be detected without recording the
+// values themselves.
+func DiffRedacted(before, after, redactedBefore, redactedAfter interface{}) []Change {
+	var changes []Change
+	diffValues("", before, after, redactedBefore, redactedAfter, &changes)
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func diffValues(path string, before, after, rBefore, rAfter interface{}, changes *[]Change) {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		if a, ok := after.(map[string]interface{}); ok {
+			rb, _ := rBefore.(map[string]interface{})
+			ra, _ := rAfter.(map[string]interface{})
+			for k, bv := range b {
+				diffValues(joinPath(path, k), bv, a[k], rb[k], ra[k], changes)
+			}
+			for k, av := range a {
+				if _, exists := b[k]; !exists {
+					diffValues(joinPath(path, k), nil, av, nil, ra[k], changes)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if a, ok := after.([]interface{}); ok {
+			rb, _ := rBefore.([]interface{})
+			ra, _ := rAfter.([]interface{})
+			for i := 0; i < len(b) || i < len(a); i++ {
+				var bv, av, rbv, rav interface{}
+				if i < len(b) {
+					bv = b[i]
+				}
+				if i < len(rb) {
+					rbv = rb[i]
+				}
+				if i < len(a) {
+					av = a[i]
+				}
+				if i < len(ra) {
+					rav = ra[i]
+				}
+				diffValues(joinPath(path, strconv.Itoa(i)), bv, av, rbv, rav, changes)
+			}
+			return
+		}
+	}
+	if before == nil && after == nil {
+		return
+	}
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, Change{Path: path, Before: rBefore, After: rAfter})
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// outputBufferSize is the number of events that can be pending delivery to an
+// output resource before further events are dropped.
+const outputBufferSize = 100
+
+// outputTimeout is the maximum time spent delivering an event to an output
+// resource, and also the time given to pending events when the log is closed.
+const outputTimeout = time.Second * 5
+
+// Log writes audit events to a file and/or an output resource. A nil *Log is
+// valid and discards all events.
+type Log struct {
+	conf Config
+	mgr  bundle.NewManagement
+
+	mut    sync.Mutex
+	file   *os.File
+	closed bool
+
+	outChan chan []byte
+	outDone chan struct{}
+	outCtx  context.Context
+	outStop func()
+}
+
+// NewLog creates an audit log from a config, returns nil if neither a file nor
+// an output are configured.
+func NewLog(conf Config, mgr bundle.NewManagement) (*Log, error) {
+	if conf.File == "" && conf.Output == "" {
+		return nil, nil
+	}
+	l := &Log{conf: conf, mgr: mgr}
+	if conf.File != "" {
+		var err error
+		if l.file, err = os.OpenFile(conf.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640); err != nil {
+			return nil, fmt.Errorf("failed to open audit log file: %w", err)
+		}
+	}
+	if conf.Output != "" {
+		if !mgr.ProbeOutput(conf.Output) {
+			if l.file != nil {
+				_ = l.file.Close()
+			}
+			return nil, fmt.Errorf("output resource '%v' was not found", conf.Output)
+		}
+		l.outChan = make(chan []byte, outputBufferSize)
+		l.outDone = make(chan struct{})
+		l.outCtx, l.outStop = context.WithCancel(context.Background())
+		go l.outputLoop()
+	}
+	return l, nil
+}
+
+// Record an audit event, events are written in the order that they are
+// recorded. Events are delivered to an output resource asynchronously in order
+// to prevent a slow or unavailable output from blocking callers, and events
+// are dropped when too many are pending delivery.
+func (l *Log) Record(e Event) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	eventBytes, err := json.Marshal(e)
+	if err != nil {
+		l.mgr.Logger().Errorf("Failed to marshal audit event: %v\n", err)
+		return
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if l.closed {
+		l.mgr.Logger().Errorf("Failed to record audit event: audit log is closed\n")
+		return
+	}
+	if l.file != nil {
+		if _, err := l.file.Write(append(eventBytes, '\n')); err != nil {
+			l.mgr.Logger().Errorf("Failed to write audit event to file: %v\n", err)
+		}
+	}
+	if l.outChan != nil {
+		select {
+		case l.outChan <- eventBytes:
+		default:
+			l.mgr.Logger().Errorf("Failed to send audit event to output resource '%v': too many events pending delivery\n", l.conf.Output)
+		}
+	}
+}
+
+func (l *Log) outputLoop() {
+	defer close(l.outDone)
+	for eventBytes := range l.outChan {
+		l.writeOutput(eventBytes)
+	}
+}
+
+func (l *Log) writeOutput(eventBytes []byte) {
+	ctx, done := context.WithTimeout(l.outCtx, outputTimeout)
+	defer done()
+
+	var writeErr error
+	if err := l.mgr.AccessOutput(ctx, l.conf.Output, func(o output.Sync) {
+		writeErr = o.WriteTransaction(ctx, message.NewTransactionFunc(
+			message.QuickBatch([][]byte{eventBytes}),
+			func(context.Context, error) error { return nil },
+		))
+	}); err != nil {
+		writeErr = err
+	}
+	if writeErr != nil {
+		l.mgr.Logger().Errorf("Failed to send audit event to output resource '%v': %v\n", l.conf.Output, writeErr)
+	}
+}
+
+// Close the audit log file, if any, and wait for pending events to be
+// delivered to the output resource, if any.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mut.Lock()
+	if l.closed {
+		l.mut.Unlock()
+		return nil
+	}
+	l.closed = true
+	if l.outChan != nil {
+		close(l.outChan)
+	}
+	var err error
+	if l.file != nil {
+		err = l.file.Close()
+		l.file = nil
+	}
+	l.mut.Unlock()
+
+	if l.outDone != nil {
+		select {
+		case <-l.outDone:
+		case <-time.After(outputTimeout):
+			l.outStop()
+			<-l.outDone
+		}
+		l.outStop()
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package audit_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/stream/audit"
+)
+
+func TestDiff(t *testing.T) {
+	before := map[string]interface{}{
+		"input": map[string]interface{}{
+			"generate": map[string]interface{}{
+				"mapping":  "root = {}",
+				"interval": "1s",
+			},
+		},
+		"pipeline": map[string]interface{}{
+			"processors": []interface{}{"a", "b"},
+		},
+	}
+	after := map[string]interface{}{
+		"input": map[string]interface{}{
+			"generate": map[string]interface{}{
+				"mapping":  "root = {}",
+				"interval": "5s",
+			},
+		},
+		"pipeline": map[string]interface{}{
+			"processors": []interface{}{"a"},
+		},
+		"output": map[string]interface{}{
+			"drop": map[string]interface{}{},
+		},
+	}
+
+	assert.Equal(t, []audit.Change{
+		{Path: "input.generate.interval", Before: "1s", After: "5s"},
+		{Path: "output", After: map[string]interface{}{"drop": map[string]interface{}{}}},
+		{Path: "pipeline.processors.1", Before: "b"},
+	}, audit.Diff(before, after))
+
+	assert.Equal(t, []audit.Change{
+		{Path: "", Before: before},
+	}, audit.Diff(before, nil))
+
+	assert.Empty(t, audit.Diff(before, before))
+}
+
+func TestDiffRedacted(t *testing.T) {
+	conf := func(user, pass string) map[string]interface{} {
+		return map[string]interface{}{
+			"basic_auth": map[string]interface{}{
+				"username": user,
+				"password": pass,
+			},
+		}
+	}
+
+	assert.Equal(t, []audit.Change{
+		{Path: "basic_auth.password", Before: "!!!REDACTED!!!", After: "!!!REDACTED!!!"},
+		{Path: "basic_auth.username", Before: "foo", After: "bar"},
+	}, audit.DiffRedacted(
+		conf("foo", "first"), conf("bar", "second"),
+		conf("foo", "!!!REDACTED!!!"), conf("bar", "!!!REDACTED!!!"),
+	))
+
+	assert.Empty(t, audit.DiffRedacted(
+		conf("foo", "first"), conf("foo", "first"),
+		conf("foo", "!!!REDACTED!!!"), conf("foo", "!!!REDACTED!!!"),
+	))
+}
+
+func TestPrincipalFromRequest(t *testing.T) {
+	req := httptest.NewRequest("POST", "/streams/foo", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal(t, "anonymous (10.0.0.1:1234)", audit.PrincipalFromRequest(req))
+
+	// Basic auth credentials are not verified and therefore not trusted.
+	req.SetBasicAuth("alice", "hunter2")
+	assert.Equal(t, "anonymous (10.0.0.1:1234)", audit.PrincipalFromRequest(req))
+
+	// Neither are certificates that were not verified.
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "deployer"}}
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	assert.Equal(t, "anonymous (10.0.0.1:1234)", audit.PrincipalFromRequest(req))
+
+	req.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+	assert.Equal(t, "deployer", audit.PrincipalFromRequest(req))
+}
+
+func TestLogOutputDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+
+	var mut sync.Mutex
+	var streams []string
+
+	mgr := mock.NewManager()
+	mgr.Outputs["foo"] = func(ctx context.Context, tran message.Transaction) error {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		var e audit.Event
+		if err := json.Unmarshal(tran.Payload.Get(0).Get(), &e); err != nil {
+			return err
+		}
+		mut.Lock()
+		streams = append(streams, e.Stream)
+		mut.Unlock()
+		return nil
+	}
+
+	conf := audit.NewConfig()
+	conf.Output = "foo"
+
+	l, err := audit.NewLog(conf, mgr)
+	require.NoError(t, err)
+
+	recorded := make(chan struct{})
+	go func() {
+		for _, id := range []string{"a", "b", "c"} {
+			l.Record(audit.Event{Action: audit.ActionCreate, Stream: id})
+		}
+		close(recorded)
+	}()
+
+	select {
+	case <-recorded:
+	case <-time.After(time.Second):
+		t.Fatal("recording events blocked on the output")
+	}
+
+	close(release)
+	require.NoError(t, l.Close())
+
+	mut.Lock()
+	assert.Equal(t, []string{"a", "b", "c"}, streams)
+	mut.Unlock()
+}
@@ -0,0 +1,3 @@
+// Package audit provides an append-only log of changes made to the streams of
+// a stream manager.
+package audit
@@ -0,0 +1,26 @@
+package stream
+
+import (
+	"regexp"
+)
+
+// connErrRedactions match the parts of connection errors that might reveal
+// credentials or the addresses of the targets of components.
+var connErrRedactions = []*regexp.Regexp{
+	regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`),
+	regexp.MustCompile(`(?i)\b(password|passwd|pwd|secret|token|key)=[^\s;&]+`),
+	regexp.MustCompile(`[^\s:/@"']+:[^\s@"']+@[^\s/"']*`),
+	regexp.MustCompile(`\[[0-9a-fA-F:.%]+\](:\d+)?`),
+	regexp.MustCompile(`\b[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)*:\d+\b`),
+	regexp.MustCompile(`\b[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)+\b`),
+}
+
+// sanitiseConnectionError returns the message of a connection error with any
+// URLs, credentials, host names and IP addresses redacted.
+func sanitiseConnectionError(err error) string {
+	msg := err.Error()
+	for _, r := range connErrRedactions {
+		msg = r.ReplaceAllString(msg, "[redacted]")
+	}
+	return msg
+}
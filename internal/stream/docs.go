@@ -12,6 +12,12 @@ func Spec() docs.FieldSpecs {
 		docs.FieldObject("pipeline", "Describes optional processing pipelines used for mutating messages.").WithChildren(
 			docs.FieldInt("threads", "The number of threads to execute processing pipelines across.").HasDefault(-1),
 			docs.FieldProcessor("processors", "A list of processors to apply to messages.").Array().HasDefault([]interface{}{}),
+			docs.FieldObject("autoscale", "Adjust the number of processing threads automatically within bounds, starting from `threads`. A thread is added when messages are queuing up for busy threads, and a thread is removed when threads spend most of their time idle or blocked by the output. The current number of threads is exposed by the metric `pipeline_threads`.").WithChildren(
+				docs.FieldBool("enabled", "Whether the number of threads should be adjusted automatically.").HasDefault(false),
+				docs.FieldInt("min_threads", "The minimum number of threads.").HasDefault(1),
+				docs.FieldInt("max_threads", "The maximum number of threads, a value of zero or less defaults to four times the number of logical CPUs.").HasDefault(-1),
+				docs.FieldString("period", "The period of time over which thread activity is measured before each adjustment.").HasDefault("1s"),
+			).Advanced().AtVersion("4.2.0"),
 		),
 		docs.FieldOutput("output", "An output to sink messages to.").Optional(),
 	}
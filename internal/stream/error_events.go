@@ -0,0 +1,49 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/errorevents"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type errorEventsProvider interface {
+	ErrorEvents() *errorevents.Recorder
+}
+
+// observeDelivery forwards transactions to the output layer and records an
+// error event for each transaction that the output fails to deliver, as well
+// as counting messages delivered whilst flagged as having failed processing.
+func observeDelivery(in <-chan message.Transaction, events *errorevents.Recorder) <-chan message.Transaction {
+	out := make(chan message.Transaction)
+	go func() {
+		defer close(out)
+		for tran := range in {
+			tran := tran
+			out <- message.NewTransactionFunc(tran.Payload, func(ctx context.Context, err error) error {
+				if err != nil {
+					e := errorevents.Event{
+						Path:  "root.output",
+						Class: errorevents.ClassDelivery,
+						Error: err.Error(),
+					}
+					if tran.Payload.Len() > 0 {
+						e.Sample = events.Sample(tran.Payload.Get(0).Get())
+					}
+					events.Record(e)
+				} else {
+					failed := 0
+					_ = tran.Payload.Iter(func(i int, p *message.Part) error {
+						if p.ErrorGet() != nil {
+							failed++
+						}
+						return nil
+					})
+					events.RecordDeadLettered(failed)
+				}
+				return tran.Ack(ctx, err)
+			})
+		}
+	}()
+	return out
+}
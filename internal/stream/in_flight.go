@@ -0,0 +1,46 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// inFlightTracker counts the batches and messages that have been consumed from
+// an input but not yet acknowledged, which are reported when a stream is shut
+// down in order to expose the potential for lost or redelivered data.
+type inFlightTracker struct {
+	batches  int64
+	messages int64
+}
+
+func (i *inFlightTracker) track(in <-chan message.Transaction) <-chan message.Transaction {
+	out := make(chan message.Transaction)
+	go func() {
+		defer close(out)
+		for tran := range in {
+			tran := tran
+			parts := int64(tran.Payload.Len())
+
+			atomic.AddInt64(&i.batches, 1)
+			atomic.AddInt64(&i.messages, parts)
+
+			var once sync.Once
+			out <- message.NewTransactionFunc(tran.Payload, func(ctx context.Context, err error) error {
+				once.Do(func() {
+					atomic.AddInt64(&i.batches, -1)
+					atomic.AddInt64(&i.messages, -parts)
+				})
+				return tran.Ack(ctx, err)
+			})
+		}
+	}()
+	return out
+}
+
+// Count returns the number of batches and messages currently in flight.
+func (i *inFlightTracker) Count() (batches, messages int) {
+	return int(atomic.LoadInt64(&i.batches)), int(atomic.LoadInt64(&i.messages))
+}
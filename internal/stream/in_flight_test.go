@@ -0,0 +1,74 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestInFlightTracker(t *testing.T) {
+	var tracker inFlightTracker
+
+	in := make(chan message.Transaction)
+	out := tracker.track(in)
+
+	resChan := make(chan error, 2)
+	sendAndReceive := func(parts ...string) message.Transaction {
+		t.Helper()
+
+		batch := message.QuickBatch(nil)
+		for _, p := range parts {
+			batch.Append(message.NewPart([]byte(p)))
+		}
+		select {
+		case in <- message.NewTransaction(batch, resChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+
+		select {
+		case tran := <-out:
+			return tran
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		return message.Transaction{}
+	}
+
+	tranOne := sendAndReceive("foo", "bar")
+	tranTwo := sendAndReceive("baz")
+
+	batches, messages := tracker.Count()
+	assert.Equal(t, 2, batches)
+	assert.Equal(t, 3, messages)
+
+	require.NoError(t, tranOne.Ack(context.Background(), nil))
+	require.NoError(t, <-resChan)
+
+	batches, messages = tracker.Count()
+	assert.Equal(t, 1, batches)
+	assert.Equal(t, 1, messages)
+
+	// Acknowledging the same transaction again must not affect the counts.
+	require.NoError(t, tranOne.Ack(context.Background(), nil))
+	require.NoError(t, <-resChan)
+
+	batches, messages = tracker.Count()
+	assert.Equal(t, 1, batches)
+	assert.Equal(t, 1, messages)
+
+	require.NoError(t, tranTwo.Ack(context.Background(), nil))
+
+	batches, messages = tracker.Count()
+	assert.Equal(t, 0, batches)
+	assert.Equal(t, 0, messages)
+
+	close(in)
+	_, open := <-out
+	assert.False(t, open)
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,16 +15,15 @@ import (
 	"github.com/gorilla/mux"
 	"gopkg.in/yaml.v3"
 
-	"github.com/benthosdev/benthos/v4/internal/component/buffer"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
-	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/docs"
-	"github.com/benthosdev/benthos/v4/internal/pipeline"
 	"github.com/benthosdev/benthos/v4/internal/stream"
+	"github.com/benthosdev/benthos/v4/internal/stream/audit"
 )
 
 //------------------------------------------------------------------------------
@@ -56,6 +56,21 @@ func (m *Type) registerEndpoints(enableCrud bool) {
 		"GET a structured JSON object containing metrics for the stream.",
 		m.HandleStreamStats,
 	)
+	m.manager.RegisterEndpoint(
+		"/streams/{id}/revisions",
+		"GET a list of the revisions of a stream config, from oldest to newest.",
+		m.HandleStreamRevisions,
+	)
+	m.manager.RegisterEndpoint(
+		"/streams/{id}/revisions/{revision}/diff",
+		"GET the changes to a stream config between a revision and the current revision, or the revision set by the URL param `to`.",
+		m.HandleStreamRevisionDiff,
+	)
+	m.manager.RegisterEndpoint(
+		"/streams/{id}/revisions/{revision}/rollback",
+		"POST: Replace a stream config with that of a previous revision.",
+		m.HandleStreamRollback,
+	)
 	m.manager.RegisterEndpoint(
 		"/resources/{type}/{id}",
 		"POST: Create or replace a given resource configuration of a specified type. Types supported are `cache`, `input`, `output`, `processor` and `rate_limit`.",
@@ -84,8 +99,41 @@ func (c ConfigSet) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
-func lintStreamConfigNode(node *yaml.Node) (lints []string) {
-	for _, dLint := range stream.Spec().LintYAML(docs.NewLintContext(), node) {
+// mergePatch applies a patch to a structured config following the semantics of
+// a JSON merge patch (RFC 7396), where objects are merged recursively, null
+// values remove fields, and all other values replace the target.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj := map[string]interface{}{}
+	if tObj, ok := target.(map[string]interface{}); ok {
+		for k, v := range tObj {
+			targetObj[k] = v
+		}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+		targetObj[k] = mergePatch(targetObj[k], v)
+	}
+	return targetObj
+}
+
+// lintContextFromRequest returns a lint context for a request, where the URL
+// param `deprecated` set to `true` rejects deprecated components and fields.
+func lintContextFromRequest(r *http.Request) docs.LintContext {
+	lintCtx := docs.NewLintContext()
+	lintCtx.RejectDeprecated = r.URL.Query().Get("deprecated") == "true"
+	return lintCtx
+}
+
+func lintStreamConfigNode(lintCtx docs.LintContext, node *yaml.Node) (lints []string) {
+	for _, dLint := range stream.Spec().LintYAML(lintCtx, node) {
 		lints = append(lints, fmt.Sprintf("line %v: %v", dLint.Line, dLint.What))
 	}
 	return
@@ -148,14 +196,15 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	nodeSet := map[string]yaml.Node{}
+	if requestErr = yaml.Unmarshal(setBytes, &nodeSet); requestErr != nil {
+		return
+	}
+
 	if r.URL.Query().Get("chilled") != "true" {
-		nodeSet := map[string]yaml.Node{}
-		if requestErr = yaml.Unmarshal(setBytes, &nodeSet); requestErr != nil {
-			return
-		}
 		var lints []string
 		for k, n := range nodeSet {
-			for _, l := range lintStreamConfigNode(&n) {
+			for _, l := range lintStreamConfigNode(lintContextFromRequest(r), &n) {
 				keyLint := fmt.Sprintf("stream '%v': %v", k, l)
 				lints = append(lints, keyLint)
 				m.manager.Logger().Debugf("Streams request linting error: %v\n", keyLint)
@@ -174,9 +223,19 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Each stream config is kept in its raw form, prior to interpolations, so
+	// that it can be persisted without exposing interpolated values.
 	newSet := ConfigSet{}
-	if requestErr = yaml.Unmarshal(setBytes, &newSet); requestErr != nil {
-		return
+	rawSet := map[string][]byte{}
+	for id, n := range nodeSet {
+		n := n
+		if rawSet[id], requestErr = yaml.Marshal(&n); requestErr != nil {
+			return
+		}
+		if newSet[id], requestErr = m.parseConfig(rawSet[id]); requestErr != nil {
+			requestErr = fmt.Errorf("stream '%v': %w", id, requestErr)
+			return
+		}
 	}
 
 	toDelete := []string{}
@@ -208,9 +267,10 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	// TODO: Replace with context
 	tmpTimeout := time.Second * 5
 
+	principal := audit.PrincipalFromRequest(r)
 	for i, id := range toDelete {
 		go func(sid string, j int) {
-			errDelete[j] = m.Delete(sid, tmpTimeout)
+			errDelete[j] = m.delete(principal, true, sid, tmpTimeout)
 			wg.Done()
 		}(id, i)
 	}
@@ -218,7 +278,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	for id, conf := range toUpdate {
 		newConf := conf
 		go func(sid string, sconf *stream.Config, j int) {
-			errUpdate[j] = m.Update(sid, *sconf, tmpTimeout)
+			errUpdate[j] = m.update(principal, true, sid, *sconf, rawSet[sid], tmpTimeout)
 			wg.Done()
 		}(id, &newConf, i)
 		i++
@@ -227,7 +287,7 @@ func (m *Type) HandleStreamsCRUD(w http.ResponseWriter, r *http.Request) {
 	for id, conf := range toCreate {
 		newConf := conf
 		go func(sid string, sconf *stream.Config, j int) {
-			errCreate[j] = m.Create(sid, *sconf)
+			errCreate[j] = m.create(principal, true, sid, *sconf, rawSet[sid])
 			wg.Done()
 		}(id, &newConf, i)
 		i++
@@ -283,19 +343,21 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	readConfig := func() (confOut stream.Config, lints []string, err error) {
+	readConfig := func() (confOut stream.Config, raw []byte, lints []string, err error) {
+		if raw, err = io.ReadAll(r.Body); err != nil {
+			return
+		}
 		var confBytes []byte
-		if confBytes, err = io.ReadAll(r.Body); err != nil {
+		if confBytes, err = m.interpolateConfig(raw); err != nil {
 			return
 		}
-		confBytes = config.ReplaceEnvVariables(confBytes)
 
 		if r.URL.Query().Get("chilled") != "true" {
 			var node yaml.Node
 			if err = yaml.Unmarshal(confBytes, &node); err != nil {
 				return
 			}
-			lints = lintStreamConfigNode(&node)
+			lints = lintStreamConfigNode(lintContextFromRequest(r), &node)
 			for _, l := range lints {
 				m.manager.Logger().Infof("Stream '%v' config: %v\n", id, l)
 			}
@@ -305,48 +367,46 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 		err = yaml.Unmarshal(confBytes, &confOut)
 		return
 	}
-	patchConfig := func(confIn stream.Config) (confOut stream.Config, err error) {
+	patchConfig := func(info *StreamStatus) (confOut stream.Config, raw []byte, err error) {
 		var patchBytes []byte
 		if patchBytes, err = io.ReadAll(r.Body); err != nil {
 			return
 		}
 
-		type aliasedIn input.Config
-		type aliasedBuf buffer.Config
-		type aliasedPipe pipeline.Config
-		type aliasedOut output.Config
+		var patch interface{}
+		if err = yaml.Unmarshal(patchBytes, &patch); err != nil {
+			return
+		}
 
-		aliasedConf := struct {
-			Input    aliasedIn   `json:"input"`
-			Buffer   aliasedBuf  `json:"buffer"`
-			Pipeline aliasedPipe `json:"pipeline"`
-			Output   aliasedOut  `json:"output"`
-		}{
-			Input:    aliasedIn(confIn.Input),
-			Buffer:   aliasedBuf(confIn.Buffer),
-			Pipeline: aliasedPipe(confIn.Pipeline),
-			Output:   aliasedOut(confIn.Output),
+		// The patch is applied to the config as it was submitted when known,
+		// so that interpolations are preserved.
+		var currentBytes []byte
+		if currentBytes, err = rawOrSanitised(info.raw, info.Config()); err != nil {
+			return
 		}
-		if err = yaml.Unmarshal(patchBytes, &aliasedConf); err != nil {
+		var current interface{}
+		if err = yaml.Unmarshal(currentBytes, &current); err != nil {
 			return
 		}
-		confOut = stream.Config{
-			Input:    input.Config(aliasedConf.Input),
-			Buffer:   buffer.Config(aliasedConf.Buffer),
-			Pipeline: pipeline.Config(aliasedConf.Pipeline),
-			Output:   output.Config(aliasedConf.Output),
+
+		if raw, err = yaml.Marshal(mergePatch(current, patch)); err != nil {
+			return
 		}
+		confOut, err = m.parseConfig(raw)
 		return
 	}
 
 	// TODO: Replace with context
 	tmpTimeout := time.Second * 5
 
+	principal := audit.PrincipalFromRequest(r)
+
 	var conf stream.Config
+	var raw []byte
 	var lints []string
 	switch r.Method {
 	case "POST":
-		if conf, lints, requestErr = readConfig(); requestErr != nil {
+		if conf, raw, lints, requestErr = readConfig(); requestErr != nil {
 			return
 		}
 		if len(lints) > 0 {
@@ -359,7 +419,7 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			_, _ = w.Write(errBytes)
 			return
 		}
-		serverErr = m.Create(id, conf)
+		serverErr = m.create(principal, true, id, conf, raw)
 	case "GET":
 		var info *StreamStatus
 		if info, serverErr = m.Read(id); serverErr == nil {
@@ -384,7 +444,7 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			_, _ = w.Write(bodyBytes)
 		}
 	case "PUT":
-		if conf, lints, requestErr = readConfig(); requestErr != nil {
+		if conf, raw, lints, requestErr = readConfig(); requestErr != nil {
 			return
 		}
 		if len(lints) > 0 {
@@ -397,16 +457,16 @@ func (m *Type) HandleStreamCRUD(w http.ResponseWriter, r *http.Request) {
 			_, _ = w.Write(errBytes)
 			return
 		}
-		serverErr = m.Update(id, conf, tmpTimeout)
+		serverErr = m.update(principal, true, id, conf, raw, tmpTimeout)
 	case "DELETE":
-		serverErr = m.Delete(id, tmpTimeout)
+		serverErr = m.delete(principal, true, id, tmpTimeout)
 	case "PATCH":
 		var info *StreamStatus
 		if info, serverErr = m.Read(id); serverErr == nil {
-			if conf, requestErr = patchConfig(info.Config()); requestErr != nil {
+			if conf, raw, requestErr = patchConfig(info); requestErr != nil {
 				return
 			}
-			serverErr = m.Update(id, conf, tmpTimeout)
+			serverErr = m.update(principal, true, id, conf, raw, tmpTimeout)
 		}
 	default:
 		requestErr = fmt.Errorf("verb not supported: %v", r.Method)
@@ -513,7 +573,9 @@ func (m *Type) HandleResourceCRUD(w http.ResponseWriter, r *http.Request) {
 		if confBytes, requestErr = io.ReadAll(r.Body); requestErr != nil {
 			return
 		}
-		confBytes = config.ReplaceEnvVariables(confBytes)
+		if confBytes, requestErr = m.interpolateConfig(confBytes); requestErr != nil {
+			return
+		}
 
 		var node yaml.Node
 		if requestErr = yaml.Unmarshal(confBytes, &node); requestErr != nil {
@@ -522,7 +584,7 @@ func (m *Type) HandleResourceCRUD(w http.ResponseWriter, r *http.Request) {
 		confNode = &node
 
 		if r.URL.Query().Get("chilled") != "true" {
-			for _, l := range docs.LintYAML(docs.NewLintContext(), docType, &node) {
+			for _, l := range docs.LintYAML(lintContextFromRequest(r), docType, &node) {
 				lints = append(lints, fmt.Sprintf("line %v: %v", l.Line, l.What))
 				m.manager.Logger().Infof("Resource '%v' config: %v\n", id, l)
 			}
@@ -572,7 +634,8 @@ func (m *Type) HandleStreamStats(w http.ResponseWriter, r *http.Request) {
 		var info *StreamStatus
 		if info, serverErr = m.Read(id); serverErr == nil {
 			values := map[string]interface{}{}
-			for k, v := range info.metrics.GetCounters() {
+			counters := info.metrics.GetCounters()
+			for k, v := range counters {
 				values[k] = v
 			}
 			for k, v := range info.metrics.GetTimings() {
@@ -588,6 +651,8 @@ func (m *Type) HandleStreamStats(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 			values["uptime_ns"] = info.Uptime().Nanoseconds()
+			values["summary"] = summariseStreamStats(counters, info.Uptime())
+			values["status"] = info.ReadyStatus()
 
 			jBytes, err := json.Marshal(values)
 			if err != nil {
@@ -608,24 +673,231 @@ func (m *Type) HandleStreamStats(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// streamStatsSummary is a digest of the metrics of a stream that is included in
+// the stats endpoint so that clients needn't aggregate labelled counters
+// themselves.
+type streamStatsSummary struct {
+	Received        int64   `json:"received"`
+	Sent            int64   `json:"sent"`
+	InputErrors     int64   `json:"input_errors"`
+	ProcessorErrors int64   `json:"processor_errors"`
+	OutputErrors    int64   `json:"output_errors"`
+	ReceivedPerSec  float64 `json:"received_per_sec"`
+	SentPerSec      float64 `json:"sent_per_sec"`
+	UptimeSeconds   float64 `json:"uptime"`
+	UptimeStr       string  `json:"uptime_str"`
+}
+
+func summariseStreamStats(counters map[string]int64, uptime time.Duration) streamStatsSummary {
+	var sum streamStatsSummary
+	for k, v := range counters {
+		name, _, _ := metrics.ReverseLabelledPath(k)
+		switch name {
+		case "input_received":
+			sum.Received += v
+		case "output_sent":
+			sum.Sent += v
+		case "input_connection_failed", "input_connection_lost":
+			sum.InputErrors += v
+		case "processor_error":
+			sum.ProcessorErrors += v
+		case "output_error", "output_connection_failed", "output_connection_lost":
+			sum.OutputErrors += v
+		}
+	}
+	if secs := uptime.Seconds(); secs > 0 {
+		sum.ReceivedPerSec = float64(sum.Received) / secs
+		sum.SentPerSec = float64(sum.Sent) / secs
+	}
+	sum.UptimeSeconds = uptime.Seconds()
+	sum.UptimeStr = uptime.String()
+	return sum
+}
+
+// handleRevisionRequest wraps the common error handling of stream revision
+// endpoints, where the provided closure is called with the stream id and, if
+// present in the path, the target revision.
+func (m *Type) handleRevisionRequest(w http.ResponseWriter, r *http.Request, method string, fn func(id string, revision int) (serverErr, requestErr error)) {
+	var serverErr, requestErr error
+	defer func() {
+		if r.Body != nil {
+			r.Body.Close()
+		}
+		if serverErr == ErrStreamDoesNotExist {
+			http.Error(w, "Stream not found", http.StatusNotFound)
+			return
+		}
+		if serverErr == ErrRevisionDoesNotExist {
+			http.Error(w, "Revision not found", http.StatusNotFound)
+			return
+		}
+		if serverErr != nil {
+			m.manager.Logger().Errorf("Stream revisions Error: %v\n", serverErr)
+			http.Error(w, fmt.Sprintf("Error: %v", serverErr), http.StatusBadGateway)
+			return
+		}
+		if requestErr != nil {
+			m.manager.Logger().Debugf("Stream request revisions Error: %v\n", requestErr)
+			http.Error(w, fmt.Sprintf("Error: %v", requestErr), http.StatusBadRequest)
+			return
+		}
+	}()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	if id == "" {
+		http.Error(w, "Var `id` must be set", http.StatusBadRequest)
+		return
+	}
+	if r.Method != method {
+		requestErr = fmt.Errorf("verb not supported: %v", r.Method)
+		return
+	}
+
+	var revision int
+	if revStr, exists := vars["revision"]; exists {
+		if revision, requestErr = strconv.Atoi(revStr); requestErr != nil {
+			requestErr = fmt.Errorf("failed to parse revision: %w", requestErr)
+			return
+		}
+	}
+	serverErr, requestErr = fn(id, revision)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) error {
+	jBytes, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(jBytes)
+	return nil
+}
+
+// HandleStreamRevisions is an http.HandleFunc for listing the revisions of a
+// stream config.
+func (m *Type) HandleStreamRevisions(w http.ResponseWriter, r *http.Request) {
+	m.handleRevisionRequest(w, r, "GET", func(id string, _ int) (error, error) {
+		revs, err := m.Revisions(id)
+		if err != nil {
+			return err, nil
+		}
+
+		type revisionInfo struct {
+			Revision  int         `json:"revision"`
+			CreatedAt time.Time   `json:"created_at"`
+			Principal string      `json:"principal,omitempty"`
+			Config    interface{} `json:"config"`
+		}
+		infos := make([]revisionInfo, 0, len(revs))
+		for _, rev := range revs {
+			// Interpolated values are shown in their raw form and secret
+			// fields are redacted in order to avoid leaking resolved values.
+			sanit, err := redactedView(&configWithRaw{rev.Config, rev.raw}, true)
+			if err != nil {
+				return err, nil
+			}
+			infos = append(infos, revisionInfo{
+				Revision:  rev.Revision,
+				CreatedAt: rev.CreatedAt,
+				Principal: rev.Principal,
+				Config:    sanit,
+			})
+		}
+		return writeJSON(w, infos), nil
+	})
+}
+
+// HandleStreamRevisionDiff is an http.HandleFunc for obtaining the changes to
+// a stream config between two revisions.
+func (m *Type) HandleStreamRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	m.handleRevisionRequest(w, r, "GET", func(id string, from int) (error, error) {
+		revs, err := m.Revisions(id)
+		if err != nil {
+			return err, nil
+		}
+
+		to := revs[len(revs)-1].Revision
+		if toStr := r.URL.Query().Get("to"); toStr != "" {
+			if to, err = strconv.Atoi(toStr); err != nil {
+				return nil, fmt.Errorf("failed to parse param to: %w", err)
+			}
+		}
+
+		changes, err := m.RevisionDiff(id, from, to)
+		if err != nil {
+			return err, nil
+		}
+		if changes == nil {
+			changes = []audit.Change{}
+		}
+		return writeJSON(w, struct {
+			From    int            `json:"from"`
+			To      int            `json:"to"`
+			Changes []audit.Change `json:"changes"`
+		}{
+			From:    from,
+			To:      to,
+			Changes: changes,
+		}), nil
+	})
+}
+
+// HandleStreamRollback is an http.HandleFunc for replacing a stream config with
+// that of a previous revision.
+func (m *Type) HandleStreamRollback(w http.ResponseWriter, r *http.Request) {
+	m.handleRevisionRequest(w, r, "POST", func(id string, revision int) (error, error) {
+		// TODO: Replace with context
+		tmpTimeout := time.Second * 5
+		return m.rollback(audit.PrincipalFromRequest(r), true, id, revision, tmpTimeout), nil
+	})
+}
+
 // HandleStreamReady is an http.HandleFunc for providing a ready check across
-// all streams.
+// all streams. When the query parameter `format` is set to `json` the response
+// body is a JSON object describing the connection state of the components of
+// each stream.
 func (m *Type) HandleStreamReady(w http.ResponseWriter, r *http.Request) {
+	res := struct {
+		Ready   bool                          `json:"ready"`
+		Streams map[string]stream.ReadyStatus `json:"streams"`
+	}{
+		Ready:   true,
+		Streams: map[string]stream.ReadyStatus{},
+	}
 	var notReady []string
 
 	m.lock.Lock()
 	for k, v := range m.streams {
-		if !v.IsReady() {
+		status := v.ReadyStatus()
+		if !status.Ready {
+			res.Ready = false
 			notReady = append(notReady, k)
 		}
+		res.Streams[k] = status
 	}
 	m.lock.Unlock()
 
+	if r.URL.Query().Get("format") == "json" {
+		resBytes, err := json.Marshal(res)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !res.Ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = w.Write(resBytes)
+		return
+	}
+
 	if len(notReady) == 0 {
 		_, _ = w.Write([]byte("OK"))
 		return
 	}
 
+	sort.Strings(notReady)
 	w.WriteHeader(http.StatusServiceUnavailable)
 	fmt.Fprintf(w, "streams %v are not connected\n", strings.Join(notReady, ", "))
 }
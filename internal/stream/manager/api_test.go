@@ -2,6 +2,10 @@ package manager_test
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -20,11 +25,14 @@ import (
 	yaml "gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	bmanager "github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/secrets"
 	"github.com/benthosdev/benthos/v4/internal/stream"
+	"github.com/benthosdev/benthos/v4/internal/stream/audit"
 	"github.com/benthosdev/benthos/v4/internal/stream/manager"
 
 	_ "github.com/benthosdev/benthos/v4/public/components/all"
@@ -35,10 +43,23 @@ func router(m *manager.Type) *mux.Router {
 	router.HandleFunc("/streams", m.HandleStreamsCRUD)
 	router.HandleFunc("/streams/{id}", m.HandleStreamCRUD)
 	router.HandleFunc("/streams/{id}/stats", m.HandleStreamStats)
+	router.HandleFunc("/streams/{id}/revisions", m.HandleStreamRevisions)
+	router.HandleFunc("/streams/{id}/revisions/{revision}/diff", m.HandleStreamRevisionDiff)
+	router.HandleFunc("/streams/{id}/revisions/{revision}/rollback", m.HandleStreamRollback)
 	router.HandleFunc("/resources/{type}/{id}", m.HandleResourceCRUD)
 	return router
 }
 
+// withClientCert sets the connection state of a request to that of a client
+// that presented a verified certificate with the given common name.
+func withClientCert(req *http.Request, commonName string) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{cert},
+		VerifiedChains:   [][]*x509.Certificate{{cert}},
+	}
+}
+
 func genRequest(verb, url string, payload interface{}) *http.Request {
 	var body io.Reader
 
@@ -142,6 +163,41 @@ func TestTypeAPIDisabled(t *testing.T) {
 	assert.Contains(t, r.endpoints, "/ready")
 }
 
+func TestTypeAPIReady(t *testing.T) {
+	res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := manager.New(res)
+	defer func() {
+		require.NoError(t, mgr.Stop(time.Second*5))
+	}()
+
+	response := httptest.NewRecorder()
+	mgr.HandleStreamReady(response, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "OK", response.Body.String())
+
+	conf := harmlessConf()
+	conf.Output.Type = "socket"
+	conf.Output.Socket.Network = "tcp"
+	conf.Output.Socket.Address = "127.0.0.1:1"
+	require.NoError(t, mgr.Create("foo", conf))
+
+	assert.Eventually(t, func() bool {
+		response = httptest.NewRecorder()
+		mgr.HandleStreamReady(response, httptest.NewRequest("GET", "/ready?format=json", nil))
+		return strings.Contains(response.Body.String(), "connection refused")
+	}, time.Second*5, time.Millisecond*50)
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Contains(t, response.Body.String(), `"ready":false`)
+	assert.NotContains(t, response.Body.String(), "127.0.0.1")
+
+	response = httptest.NewRecorder()
+	mgr.HandleStreamReady(response, httptest.NewRequest("GET", "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.Equal(t, "streams foo are not connected\n", response.Body.String())
+}
+
 func TestTypeAPIBadMethods(t *testing.T) {
 	mgr := manager.New(mock.NewManager())
 
@@ -666,6 +722,44 @@ func TestTypeAPILinting(t *testing.T) {
 	assert.Equal(t, http.StatusOK, response.Code)
 }
 
+func TestTypeAPILintingDeprecated(t *testing.T) {
+	res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := manager.New(res)
+
+	r := router(mgr)
+
+	body := []byte(`input:
+  generate:
+    mapping: 'root = "hello world"'
+pipeline:
+  processors:
+    - log:
+        message: foo
+        fields:
+          bar: baz
+output:
+  drop: {}
+`)
+
+	request, err := http.NewRequest("POST", "/streams/foo?deprecated=true", bytes.NewReader(body))
+	require.NoError(t, err)
+
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Equal(t, `{"lint_errors":["line 8: field fields is deprecated"]}`, response.Body.String())
+
+	request, err = http.NewRequest("POST", "/streams/foo", bytes.NewReader(body))
+	require.NoError(t, err)
+
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	assert.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	require.NoError(t, mgr.Stop(time.Second*5))
+}
+
 func TestResourceAPILinting(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -796,6 +890,13 @@ func TestTypeAPIGetStats(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Greater(t, len(stats.ChildrenMap()), 0, response.Body.String())
+
+	assert.True(t, stats.Exists(`output_sent{endpoint="get",label="",path="root.output",stream="foo"}`), response.Body.String())
+	assert.Equal(t, 0.0, stats.S("summary", "received").Data(), response.Body.String())
+	assert.Equal(t, 0.0, stats.S("summary", "sent").Data(), response.Body.String())
+	assert.Greater(t, stats.S("summary", "uptime").Data(), 0.0, response.Body.String())
+	assert.Equal(t, true, stats.S("status", "ready").Data(), response.Body.String())
+	assert.Equal(t, true, stats.S("status", "components", "input", "connected").Data(), response.Body.String())
 }
 
 func TestTypeAPISetResources(t *testing.T) {
@@ -884,3 +985,394 @@ file:
 	require.NoError(t, err)
 	assert.Equal(t, `{"id":"second","content":"hello world 2"}`, string(file2Bytes))
 }
+
+func TestTypeAPIAuditLog(t *testing.T) {
+	res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditConf := audit.NewConfig()
+	auditConf.File = auditPath
+
+	auditLog, err := audit.NewLog(auditConf, res)
+	require.NoError(t, err)
+
+	mgr := manager.New(res, manager.OptAuditLog(auditLog))
+
+	r := router(mgr)
+	conf, err := harmlessConf().Sanitised()
+	require.NoError(t, err)
+
+	request := genRequest("POST", "/streams/foo", conf)
+	withClientCert(request, "alice")
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	// Rejected requests are not recorded
+	request = genRequest("POST", "/streams/foo", conf)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusBadRequest, response.Code, response.Body.String())
+
+	newConf := harmlessConf()
+	newConf.Buffer.Type = "memory"
+	newConfSanit, err := newConf.Sanitised()
+	require.NoError(t, err)
+
+	request = genRequest("PUT", "/streams/foo", newConfSanit)
+	withClientCert(request, "bob")
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	require.NoError(t, mgr.Delete("foo", time.Second*5))
+	require.NoError(t, mgr.Stop(time.Second*5))
+
+	auditBytes, err := os.ReadFile(auditPath)
+	require.NoError(t, err)
+
+	var events []audit.Event
+	for _, line := range bytes.Split(bytes.TrimSpace(auditBytes), []byte("\n")) {
+		var e audit.Event
+		require.NoError(t, json.Unmarshal(line, &e))
+		events = append(events, e)
+	}
+	require.Len(t, events, 3)
+
+	assert.Equal(t, audit.ActionCreate, events[0].Action)
+	assert.Equal(t, "foo", events[0].Stream)
+	assert.Equal(t, "alice", events[0].Principal)
+	assert.NotEmpty(t, events[0].Changes)
+
+	assert.Equal(t, audit.ActionUpdate, events[1].Action)
+	assert.Equal(t, "bob", events[1].Principal)
+	assert.Equal(t, []audit.Change{
+		{Path: "buffer.memory", After: map[string]interface{}{}},
+		{Path: "buffer.none", Before: map[string]interface{}{}},
+	}, events[1].Changes)
+
+	assert.Equal(t, audit.ActionDelete, events[2].Action)
+	assert.Equal(t, "", events[2].Principal)
+	assert.Empty(t, events[2].Error)
+}
+
+func TestTypeAPIRevisions(t *testing.T) {
+	res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := manager.New(res)
+	t.Cleanup(func() {
+		_ = mgr.Stop(time.Second * 5)
+	})
+
+	r := router(mgr)
+	conf, err := harmlessConf().Sanitised()
+	require.NoError(t, err)
+
+	request := genRequest("GET", "/streams/foo/revisions", nil)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusNotFound, response.Code, response.Body.String())
+
+	request = genRequest("POST", "/streams/foo", conf)
+	withClientCert(request, "alice")
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	request = genYAMLRequest("PATCH", "/streams/foo", `
+buffer:
+  none: null
+  memory: {}
+`)
+	withClientCert(request, "bob")
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	request = genRequest("GET", "/streams/foo/revisions", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	revs, err := gabs.ParseJSON(response.Body.Bytes())
+	require.NoError(t, err)
+	require.Len(t, revs.Children(), 2)
+	assert.Equal(t, 1.0, revs.S("0", "revision").Data())
+	assert.Equal(t, "alice", revs.S("0", "principal").Data())
+	assert.Equal(t, map[string]interface{}{}, revs.S("0", "config", "buffer", "none").Data())
+	assert.Equal(t, 2.0, revs.S("1", "revision").Data())
+	assert.Equal(t, "bob", revs.S("1", "principal").Data())
+	assert.Equal(t, map[string]interface{}{}, revs.S("1", "config", "buffer", "memory").Data())
+
+	request = genRequest("GET", "/streams/foo/revisions/1/diff", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.JSONEq(t, `{
+  "from": 1,
+  "to": 2,
+  "changes": [
+    { "path": "buffer.memory", "after": {} },
+    { "path": "buffer.none", "before": {} }
+  ]
+}`, response.Body.String())
+
+	request = genRequest("GET", "/streams/foo/revisions/1/diff?to=1", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	assert.JSONEq(t, `{"from":1,"to":1,"changes":[]}`, response.Body.String())
+
+	request = genRequest("GET", "/streams/foo/revisions/5/diff", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusNotFound, response.Code, response.Body.String())
+
+	request = genRequest("GET", "/streams/foo/revisions/1/rollback", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusBadRequest, response.Code, response.Body.String())
+
+	request = genRequest("POST", "/streams/foo/revisions/1/rollback", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	strm, err := mgr.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "none", strm.Config().Buffer.Type)
+
+	revisions, err := mgr.Revisions("foo")
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	assert.Equal(t, 3, revisions[2].Revision)
+	assert.Equal(t, "none", revisions[2].Config.Buffer.Type)
+
+	require.NoError(t, mgr.Delete("foo", time.Second*5))
+
+	_, err = mgr.Revisions("foo")
+	require.Equal(t, manager.ErrStreamDoesNotExist, err)
+}
+
+func TestTypeAPIRevisionsRedaction(t *testing.T) {
+	secrets.ResetCache()
+	t.Cleanup(secrets.ResetCache)
+
+	secrets.RegisterProvider("revtest", secrets.ProviderFunc(func(ctx context.Context, path string) (string, error) {
+		return "resolvedsecret", nil
+	}))
+
+	testVar := "BENTHOS_TEST_REVISIONS_REDACT_PATH"
+	_ = os.Setenv(testVar, "/resolved/path")
+	t.Cleanup(func() {
+		_ = os.Unsetenv(testVar)
+	})
+
+	res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := manager.New(res, manager.OptAPISecrets(true))
+	t.Cleanup(func() {
+		_ = mgr.Stop(time.Second * 5)
+	})
+	r := router(mgr)
+
+	request, err := http.NewRequest("POST", "/streams/foo", bytes.NewReader([]byte(`
+input:
+  http_server:
+    path: ${`+testVar+`}
+output:
+  http_client:
+    url: http://localhost:4195/nope
+    basic_auth:
+      enabled: true
+      username: foo
+      password: ${secret:revtest:foo}
+`)))
+	require.NoError(t, err)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	request = genRequest("GET", "/streams/foo/revisions", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	assert.NotContains(t, response.Body.String(), "/resolved/path")
+	assert.NotContains(t, response.Body.String(), "resolvedsecret")
+
+	revs, err := gabs.ParseJSON(response.Body.Bytes())
+	require.NoError(t, err)
+	require.Len(t, revs.Children(), 1)
+	assert.Equal(t, "${"+testVar+"}", revs.S("0", "config", "input", "http_server", "path").Data())
+	assert.Equal(t, docs.RedactedValue, revs.S("0", "config", "output", "http_client", "basic_auth", "password").Data())
+}
+
+func TestTypeAPIAuditLogRedaction(t *testing.T) {
+	res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditConf := audit.NewConfig()
+	auditConf.File = auditPath
+
+	auditLog, err := audit.NewLog(auditConf, res)
+	require.NoError(t, err)
+
+	mgr := manager.New(res, manager.OptAuditLog(auditLog))
+	r := router(mgr)
+
+	testVar := "BENTHOS_TEST_AUDIT_REDACT_PATH"
+	_ = os.Setenv(testVar, "/resolved/path")
+	t.Cleanup(func() {
+		_ = os.Unsetenv(testVar)
+	})
+
+	confTemplate := `
+input:
+  http_server:
+    path: %v
+output:
+  http_client:
+    url: http://localhost:4195/nope
+    basic_auth:
+      enabled: true
+      username: foo
+      password: %v
+`
+
+	request, err := http.NewRequest("POST", "/streams/foo", bytes.NewReader(
+		[]byte(fmt.Sprintf(confTemplate, "/static", "firstsecret")),
+	))
+	require.NoError(t, err)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	request, err = http.NewRequest("PUT", "/streams/foo", bytes.NewReader(
+		[]byte(fmt.Sprintf(confTemplate, "${"+testVar+"}", "secondsecret")),
+	))
+	require.NoError(t, err)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	require.NoError(t, mgr.Stop(time.Second*5))
+
+	auditBytes, err := os.ReadFile(auditPath)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(auditBytes), "firstsecret")
+	assert.NotContains(t, string(auditBytes), "secondsecret")
+	assert.NotContains(t, string(auditBytes), "/resolved/path")
+
+	var events []audit.Event
+	for _, line := range bytes.Split(bytes.TrimSpace(auditBytes), []byte("\n")) {
+		var e audit.Event
+		require.NoError(t, json.Unmarshal(line, &e))
+		events = append(events, e)
+	}
+	require.Len(t, events, 2)
+
+	assert.Equal(t, audit.ActionUpdate, events[1].Action)
+	assert.Equal(t, []audit.Change{
+		{Path: "input.http_server.path", Before: "/static", After: "${" + testVar + "}"},
+		{Path: "output.http_client.basic_auth.password", Before: docs.RedactedValue, After: docs.RedactedValue},
+	}, events[1].Changes)
+}
+
+func TestTypeAPISecrets(t *testing.T) {
+	secrets.ResetCache()
+	t.Cleanup(secrets.ResetCache)
+
+	secrets.RegisterProvider("apitest", secrets.ProviderFunc(func(ctx context.Context, path string) (string, error) {
+		return "/secret/path", nil
+	}))
+
+	rawConf := []byte(`
+input:
+  http_server:
+    path: ${secret:apitest:foo}
+output:
+  http_server: {}
+`)
+
+	for _, allowed := range []bool{false, true} {
+		res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		mgr := manager.New(res, manager.OptAPISecrets(allowed))
+		t.Cleanup(func() {
+			_ = mgr.Stop(time.Second * 5)
+		})
+
+		request, err := http.NewRequest("POST", "/streams/foo", bytes.NewReader(rawConf))
+		require.NoError(t, err)
+		response := httptest.NewRecorder()
+		router(mgr).ServeHTTP(response, request)
+
+		if !allowed {
+			// Secrets are rejected unless explicitly allowed
+			require.Equal(t, http.StatusBadRequest, response.Code, response.Body.String())
+			assert.Contains(t, response.Body.String(), "secret reference 'apitest:foo' is not permitted")
+
+			_, err = mgr.Read("foo")
+			require.Equal(t, manager.ErrStreamDoesNotExist, err)
+			continue
+		}
+
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+		strm, err := mgr.Read("foo")
+		require.NoError(t, err)
+		assert.Equal(t, "/secret/path", strm.Config().Input.HTTPServer.Path)
+	}
+}
+
+func TestTypeAPIFileReferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "path")
+	require.NoError(t, os.WriteFile(path, []byte("/file/path\n"), 0o644))
+
+	rawConf := []byte(`
+input:
+  http_server:
+    path: ${file:` + path + `}
+output:
+  http_server: {}
+`)
+
+	for _, allowed := range []bool{false, true} {
+		res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		mgr := manager.New(res, manager.OptAPISecrets(allowed))
+		t.Cleanup(func() {
+			_ = mgr.Stop(time.Second * 5)
+		})
+
+		request, err := http.NewRequest("POST", "/streams/foo", bytes.NewReader(rawConf))
+		require.NoError(t, err)
+		response := httptest.NewRecorder()
+		router(mgr).ServeHTTP(response, request)
+
+		if !allowed {
+			// Files are rejected unless explicitly allowed
+			require.Equal(t, http.StatusBadRequest, response.Code, response.Body.String())
+			assert.Contains(t, response.Body.String(), "file reference '"+path+"' is not permitted")
+			assert.NotContains(t, response.Body.String(), "/file/path")
+
+			_, err = mgr.Read("foo")
+			require.Equal(t, manager.ErrStreamDoesNotExist, err)
+			continue
+		}
+
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+		strm, err := mgr.Read("foo")
+		require.NoError(t, err)
+		assert.Equal(t, "/file/path", strm.Config().Input.HTTPServer.Path)
+	}
+}
@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+	"github.com/benthosdev/benthos/v4/internal/stream/audit"
+)
+
+// configWithRaw is a stream config along with the raw config it was parsed
+// from prior to any interpolations, which is nil for configs that were
+// provided programmatically.
+type configWithRaw struct {
+	conf stream.Config
+	raw  []byte
+}
+
+// diffView returns a sanitised stream config for reporting changes, where
+// interpolated values are reset to their raw form and, when redact is true,
+// the values of secret fields are redacted.
+func diffView(c *configWithRaw, redact bool) interface{} {
+	v, _ := redactedView(c, redact)
+	return v
+}
+
+// redactedView returns a sanitised stream config where interpolated values are
+// reset to their raw form and, when redact is true, the values of secret
+// fields are redacted.
+func redactedView(c *configWithRaw, redact bool) (interface{}, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	var node yaml.Node
+	if err := node.Encode(c.conf); err != nil {
+		return nil, err
+	}
+	if c.raw != nil {
+		if err := config.RedactEnvVariables(c.raw, &node); err != nil {
+			return nil, err
+		}
+	}
+
+	sanitConf := docs.NewSanitiseConfig()
+	sanitConf.RemoveTypeField = true
+	sanitConf.RedactSecrets = redact
+	if err := stream.Spec().SanitiseYAML(&node, sanitConf); err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// diffConfigs returns the changes between two stream configs without exposing
+// the values of secret fields or interpolations, changes to these values are
+// still reported but with their values redacted.
+func diffConfigs(before, after *configWithRaw) []audit.Change {
+	return audit.DiffRedacted(
+		diffView(before, false), diffView(after, false),
+		diffView(before, true), diffView(after, true),
+	)
+}
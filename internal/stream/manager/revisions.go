@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"errors"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/stream"
+	"github.com/benthosdev/benthos/v4/internal/stream/audit"
+)
+
+// maxStreamRevisions is the number of revisions of each stream that are kept,
+// after which the oldest revisions are discarded.
+const maxStreamRevisions = 20
+
+// ErrRevisionDoesNotExist is returned when a stream revision is not found.
+var ErrRevisionDoesNotExist = errors.New("stream revision does not exist")
+
+// Revision is a version of the config of a stream.
+type Revision struct {
+	Revision  int
+	CreatedAt time.Time
+	Principal string
+	Config    stream.Config
+
+	// raw is the config as it was submitted prior to any interpolations, or
+	// nil if the config was provided programmatically.
+	raw []byte
+}
+
+type streamRevisions struct {
+	next int
+	list []Revision
+}
+
+func (m *Type) addRevision(principal, id string, conf stream.Config, raw []byte) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	revs, exists := m.revisions[id]
+	if !exists {
+		revs = &streamRevisions{next: 1}
+		m.revisions[id] = revs
+	}
+	revs.list = append(revs.list, Revision{
+		Revision:  revs.next,
+		CreatedAt: time.Now(),
+		Principal: principal,
+		Config:    conf,
+		raw:       raw,
+	})
+	revs.next++
+	if len(revs.list) > maxStreamRevisions {
+		revs.list = revs.list[len(revs.list)-maxStreamRevisions:]
+	}
+}
+
+func (m *Type) dropRevisions(id string) {
+	m.lock.Lock()
+	delete(m.revisions, id)
+	m.lock.Unlock()
+}
+
+// Revisions returns the revisions of the config of a stream that have been
+// kept, ordered from oldest to newest, where the last is the current config.
+// Returns an error if the stream does not exist.
+func (m *Type) Revisions(id string) ([]Revision, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	revs, exists := m.revisions[id]
+	if !exists {
+		return nil, ErrStreamDoesNotExist
+	}
+	return append([]Revision(nil), revs.list...), nil
+}
+
+// Revision returns a specific revision of the config of a stream.
+func (m *Type) Revision(id string, revision int) (Revision, error) {
+	revs, err := m.Revisions(id)
+	if err != nil {
+		return Revision{}, err
+	}
+	for _, r := range revs {
+		if r.Revision == revision {
+			return r, nil
+		}
+	}
+	return Revision{}, ErrRevisionDoesNotExist
+}
+
+// RevisionDiff returns the changes made to the config of a stream between two
+// revisions.
+func (m *Type) RevisionDiff(id string, from, to int) ([]audit.Change, error) {
+	fromRev, err := m.Revision(id, from)
+	if err != nil {
+		return nil, err
+	}
+	toRev, err := m.Revision(id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffConfigs(
+		&configWithRaw{fromRev.Config, fromRev.raw},
+		&configWithRaw{toRev.Config, toRev.raw},
+	), nil
+}
+
+// Rollback replaces the config of a stream with that of a previous revision,
+// which results in a new revision.
+func (m *Type) Rollback(id string, revision int, timeout time.Duration) error {
+	return m.rollback("", false, id, revision, timeout)
+}
+
+func (m *Type) rollback(principal string, persist bool, id string, revision int, timeout time.Duration) error {
+	rev, err := m.Revision(id, revision)
+	if err != nil {
+		return err
+	}
+	return m.update(principal, persist, id, rev.Config, rev.raw, timeout)
+}
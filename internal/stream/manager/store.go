@@ -0,0 +1,299 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+const storeStreamTimeout = 30 * time.Second
+
+// storedConfig is a stream config as it was last read from or written to a
+// store, where raw is the config exactly as it was submitted, prior to any
+// interpolations, and conf is the result of interpolating it.
+type storedConfig struct {
+	raw  []byte
+	conf stream.Config
+}
+
+// rawOrSanitised returns the raw config of a stream as it was submitted, or a
+// marshalled sanitised form of the config when the stream was created
+// programmatically and therefore never had a raw form.
+func rawOrSanitised(raw []byte, conf stream.Config) ([]byte, error) {
+	if raw != nil {
+		return raw, nil
+	}
+	sanit, err := conf.Sanitised()
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(sanit)
+}
+
+// interpolateConfig replaces the interpolations of a raw stream config, as
+// submitted via the API or read from a store. References to secrets and files
+// are rejected unless explicitly allowed.
+func (m *Type) interpolateConfig(raw []byte) ([]byte, error) {
+	if m.apiSecrets {
+		return config.ReplaceEnvVariables(raw)
+	}
+	return config.ReplaceEnvVariablesWithoutSecrets(raw)
+}
+
+// parseConfig interpolates and then parses a raw stream config.
+func (m *Type) parseConfig(raw []byte) (stream.Config, error) {
+	conf := stream.NewConfig()
+	confBytes, err := m.interpolateConfig(raw)
+	if err != nil {
+		return conf, err
+	}
+	err = yaml.Unmarshal(confBytes, &conf)
+	return conf, err
+}
+
+// reconcile ensures that the running state of a stream matches the desired
+// config, where a nil config means the stream should not be running. Streams
+// that are singletons are not run unless this instance is the leader. Must be
+// called with syncMut held.
+func (m *Type) reconcile(id string, stored *storedConfig, timeout time.Duration) error {
+	m.lock.Lock()
+	wrapper, running := m.streams[id]
+	m.lock.Unlock()
+
+	run := stored != nil && m.store.ShouldRun(id)
+	if running && (!run || !reflect.DeepEqual(wrapper.config, stored.conf)) {
+		if err := m.deleteStream(id, timeout); err != nil {
+			return err
+		}
+		running = false
+	}
+	if run && !running {
+		return m.createStream(id, stored.conf, stored.raw)
+	}
+	return nil
+}
+
+func (m *Type) putStored(id string, stored storedConfig) error {
+	ctx, done := context.WithTimeout(context.Background(), storeStreamTimeout)
+	defer done()
+	if err := m.store.Store.Put(ctx, id, stored.raw); err != nil {
+		return fmt.Errorf("failed to persist stream config: %w", err)
+	}
+	m.storedConfs[id] = stored
+	return nil
+}
+
+func (m *Type) deleteStoredConf(id string) error {
+	ctx, done := context.WithTimeout(context.Background(), storeStreamTimeout)
+	defer done()
+	if err := m.store.Store.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to remove persisted stream config: %w", err)
+	}
+	delete(m.storedConfs, id)
+	return nil
+}
+
+func (m *Type) streamExists(id string) bool {
+	m.lock.Lock()
+	_, running := m.streams[id]
+	m.lock.Unlock()
+	_, stored := m.storedConfs[id]
+	return running || stored
+}
+
+// createStored persists the config of a new stream before running it. The
+// config is always persisted before the running stream is changed, and is
+// reverted when the running stream cannot be changed, which ensures that this
+// instance never runs a stream that the store disagrees with.
+func (m *Type) createStored(id string, conf stream.Config, raw []byte) error {
+	m.syncMut.Lock()
+	defer m.syncMut.Unlock()
+
+	if m.streamExists(id) {
+		return ErrStreamExists
+	}
+
+	var err error
+	if raw, err = rawOrSanitised(raw, conf); err != nil {
+		return err
+	}
+	stored := storedConfig{raw: raw, conf: conf}
+	if err := m.putStored(id, stored); err != nil {
+		return err
+	}
+	if err := m.reconcile(id, &stored, storeStreamTimeout); err != nil {
+		if rErr := m.deleteStoredConf(id); rErr != nil {
+			m.manager.Logger().Errorf("Failed to revert persisted stream %v config: %v\n", id, rErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (m *Type) updateStored(id string, conf stream.Config, raw []byte, timeout time.Duration) error {
+	m.syncMut.Lock()
+	defer m.syncMut.Unlock()
+
+	if !m.streamExists(id) {
+		return ErrStreamDoesNotExist
+	}
+	prev, hadPrev := m.storedConfs[id]
+
+	var err error
+	if raw, err = rawOrSanitised(raw, conf); err != nil {
+		return err
+	}
+	stored := storedConfig{raw: raw, conf: conf}
+	if err := m.putStored(id, stored); err != nil {
+		return err
+	}
+	if err := m.reconcile(id, &stored, timeout); err != nil {
+		if !hadPrev {
+			return err
+		}
+		if rErr := m.putStored(id, prev); rErr != nil {
+			m.manager.Logger().Errorf("Failed to revert persisted stream %v config: %v\n", id, rErr)
+		} else if rErr = m.reconcile(id, &prev, timeout); rErr != nil {
+			m.manager.Logger().Errorf("Failed to restore previous stream %v config: %v\n", id, rErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (m *Type) deleteStored(id string, timeout time.Duration) error {
+	m.syncMut.Lock()
+	defer m.syncMut.Unlock()
+
+	if !m.streamExists(id) {
+		return ErrStreamDoesNotExist
+	}
+	if err := m.deleteStoredConf(id); err != nil {
+		return err
+	}
+	return m.reconcile(id, nil, timeout)
+}
+
+// SyncStore reads all stream configs from the store of the stream manager and
+// creates, updates or deletes streams in order to match them. Streams that
+// were not created via the API or from the store are left untouched. This is a
+// no-op when the stream manager has no store.
+func (m *Type) SyncStore(ctx context.Context) error {
+	if m.store == nil {
+		return nil
+	}
+
+	confs, err := m.store.Store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.syncMut.Lock()
+	defer m.syncMut.Unlock()
+
+	m.lock.Lock()
+	closed := m.closed
+	m.lock.Unlock()
+	if closed {
+		return component.ErrTypeClosed
+	}
+
+	for id, raw := range confs {
+		stored, exists := m.storedConfs[id]
+		if !exists || !bytes.Equal(stored.raw, raw) {
+			conf, err := m.parseConfig(raw)
+			if err != nil {
+				m.manager.Logger().Errorf("Failed to parse stored stream %v config: %v\n", id, err)
+				continue
+			}
+			stored = storedConfig{raw: raw, conf: conf}
+		}
+		// Reconcile regardless of changes to the config as the leadership of
+		// this instance may have changed.
+		if err := m.reconcile(id, &stored, storeStreamTimeout); err != nil {
+			m.manager.Logger().Errorf("Failed to apply stored stream %v config: %v\n", id, err)
+			continue
+		}
+		m.storedConfs[id] = stored
+	}
+
+	for id := range m.storedConfs {
+		if _, exists := confs[id]; exists {
+			continue
+		}
+		if err := m.reconcile(id, nil, storeStreamTimeout); err != nil {
+			m.manager.Logger().Errorf("Failed to remove stored stream %v: %v\n", id, err)
+			continue
+		}
+		delete(m.storedConfs, id)
+	}
+	return nil
+}
+
+func (m *Type) syncLoop() {
+	defer m.syncWG.Done()
+
+	ticker := time.NewTicker(m.store.SyncPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, done := context.WithTimeout(context.Background(), m.store.SyncPeriod)
+			if err := m.SyncStore(ctx); err != nil {
+				m.manager.Logger().Errorf("Failed to synchronise streams with store: %v\n", err)
+			}
+			done()
+		case <-m.syncCloseChan:
+			return
+		}
+	}
+}
+
+// reconcileSingletons starts or stops the stored singleton streams in order to
+// match the current leadership of this instance.
+func (m *Type) reconcileSingletons() {
+	m.syncMut.Lock()
+	defer m.syncMut.Unlock()
+
+	m.lock.Lock()
+	closed := m.closed
+	m.lock.Unlock()
+	if closed {
+		return
+	}
+
+	for id, stored := range m.storedConfs {
+		if !m.store.IsSingleton(id) {
+			continue
+		}
+		stored := stored
+		if err := m.reconcile(id, &stored, storeStreamTimeout); err != nil {
+			m.manager.Logger().Errorf("Failed to apply leadership change to stream %v: %v\n", id, err)
+		}
+	}
+}
+
+// leadershipLoop applies leadership changes as soon as they occur rather than
+// at the next synchronisation, which means singleton streams are stopped when
+// leadership is lost even when the store is never synchronised.
+func (m *Type) leadershipLoop() {
+	defer m.syncWG.Done()
+
+	for {
+		select {
+		case <-m.store.Elector.LeadershipChanged():
+			m.reconcileSingletons()
+		case <-m.syncCloseChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package manager_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	bmanager "github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/stream/manager"
+	"github.com/benthosdev/benthos/v4/internal/stream/store"
+)
+
+type fakeElector struct {
+	leader  int32
+	changed chan struct{}
+}
+
+func newFakeElector(leader bool) *fakeElector {
+	f := &fakeElector{changed: make(chan struct{}, 1)}
+	if leader {
+		f.leader = 1
+	}
+	return f
+}
+
+func (f *fakeElector) setLeader(leader bool) {
+	var v int32
+	if leader {
+		v = 1
+	}
+	if atomic.SwapInt32(&f.leader, v) != v {
+		select {
+		case f.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (f *fakeElector) IsLeader() bool {
+	return atomic.LoadInt32(&f.leader) == 1
+}
+
+func (f *fakeElector) LeadershipChanged() <-chan struct{} {
+	return f.changed
+}
+
+func (f *fakeElector) Close(ctx context.Context) error {
+	return nil
+}
+
+func TestTypeAPIStore(t *testing.T) {
+	storeConf := store.NewConfig()
+	storeConf.Type = "directory"
+	storeConf.Directory.Path = t.TempDir()
+	storeConf.SyncPeriod = ""
+
+	newMgr := func(elector store.Elector) *manager.Type {
+		t.Helper()
+
+		res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		s, err := store.New(storeConf)
+		require.NoError(t, err)
+		s.Elector = elector
+		s.Singletons = []string{"singleton_*"}
+
+		mgr := manager.New(res, manager.OptStore(s))
+		t.Cleanup(func() {
+			_ = mgr.Stop(time.Second * 5)
+		})
+		return mgr
+	}
+
+	elector := newFakeElector(false)
+	mgrOne, mgrTwo := newMgr(nil), newMgr(elector)
+	ctx := context.Background()
+
+	conf, err := harmlessConf().Sanitised()
+	require.NoError(t, err)
+
+	r := router(mgrOne)
+	for _, id := range []string{"foo", "singleton_bar"} {
+		request := genRequest("POST", "/streams/"+id, conf)
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+		require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+	}
+
+	// Streams created from elsewhere are not persisted
+	require.NoError(t, mgrOne.Create("baz", harmlessConf()))
+
+	require.NoError(t, mgrTwo.SyncStore(ctx))
+
+	_, err = mgrTwo.Read("foo")
+	require.NoError(t, err)
+
+	_, err = mgrTwo.Read("baz")
+	require.Equal(t, manager.ErrStreamDoesNotExist, err)
+
+	// Singletons only run on the leader
+	_, err = mgrTwo.Read("singleton_bar")
+	require.Equal(t, manager.ErrStreamDoesNotExist, err)
+
+	atomic.StoreInt32(&elector.leader, 1)
+	require.NoError(t, mgrTwo.SyncStore(ctx))
+
+	_, err = mgrTwo.Read("singleton_bar")
+	require.NoError(t, err)
+
+	// Updates are applied by other instances
+	newConf := harmlessConf()
+	newConf.Buffer.Type = "memory"
+	newConfSanit, err := newConf.Sanitised()
+	require.NoError(t, err)
+
+	request := genRequest("PUT", "/streams/foo", newConfSanit)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	require.NoError(t, mgrTwo.SyncStore(ctx))
+
+	strm, err := mgrTwo.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "memory", strm.Config().Buffer.Type)
+
+	// Deletes are applied by other instances
+	request = genRequest("DELETE", "/streams/foo", nil)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	require.NoError(t, mgrTwo.SyncStore(ctx))
+
+	_, err = mgrTwo.Read("foo")
+	require.Equal(t, manager.ErrStreamDoesNotExist, err)
+
+	// A fresh instance restores the persisted streams
+	mgrThree := newMgr(nil)
+	require.NoError(t, mgrThree.SyncStore(ctx))
+
+	_, err = mgrThree.Read("singleton_bar")
+	require.NoError(t, err)
+
+	_, err = mgrThree.Read("foo")
+	require.Equal(t, manager.ErrStreamDoesNotExist, err)
+}
+
+func TestTypeAPIStoreLeaderFailover(t *testing.T) {
+	storeConf := store.NewConfig()
+	storeConf.Type = "directory"
+	storeConf.Directory.Path = t.TempDir()
+	storeConf.SyncPeriod = ""
+
+	newMgr := func(elector store.Elector) *manager.Type {
+		t.Helper()
+
+		res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		s, err := store.New(storeConf)
+		require.NoError(t, err)
+		s.Elector = elector
+		s.Singletons = []string{"singleton_*"}
+
+		mgr := manager.New(res, manager.OptStore(s))
+		t.Cleanup(func() {
+			_ = mgr.Stop(time.Second * 5)
+		})
+		return mgr
+	}
+
+	isRunning := func(mgr *manager.Type, id string) bool {
+		_, err := mgr.Read(id)
+		return err == nil
+	}
+
+	electorOne, electorTwo := newFakeElector(true), newFakeElector(false)
+	mgrOne, mgrTwo := newMgr(electorOne), newMgr(electorTwo)
+
+	conf, err := harmlessConf().Sanitised()
+	require.NoError(t, err)
+
+	request := genRequest("POST", "/streams/singleton_foo", conf)
+	response := httptest.NewRecorder()
+	router(mgrOne).ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	// The store is only read at startup without a sync period.
+	require.NoError(t, mgrTwo.SyncStore(context.Background()))
+	assert.True(t, isRunning(mgrOne, "singleton_foo"))
+	assert.False(t, isRunning(mgrTwo, "singleton_foo"))
+
+	// Leadership changes are applied without waiting for a synchronisation.
+	electorOne.setLeader(false)
+	assert.Eventually(t, func() bool {
+		return !isRunning(mgrOne, "singleton_foo")
+	}, time.Second*5, time.Millisecond*10)
+
+	electorTwo.setLeader(true)
+	assert.Eventually(t, func() bool {
+		return isRunning(mgrTwo, "singleton_foo")
+	}, time.Second*5, time.Millisecond*10)
+
+	electorTwo.setLeader(false)
+	electorOne.setLeader(true)
+	assert.Eventually(t, func() bool {
+		return isRunning(mgrOne, "singleton_foo") && !isRunning(mgrTwo, "singleton_foo")
+	}, time.Second*5, time.Millisecond*10)
+}
+
+type flakyStore struct {
+	store.Store
+	failPuts int32
+}
+
+func (f *flakyStore) Put(ctx context.Context, id string, conf []byte) error {
+	if atomic.LoadInt32(&f.failPuts) == 1 {
+		return errors.New("nope")
+	}
+	return f.Store.Put(ctx, id, conf)
+}
+
+func TestTypeAPIStorePutFailure(t *testing.T) {
+	storeConf := store.NewConfig()
+	storeConf.Type = "directory"
+	storeConf.Directory.Path = t.TempDir()
+
+	s, err := store.New(storeConf)
+	require.NoError(t, err)
+
+	flaky := &flakyStore{Store: s.Store}
+	s.Store = flaky
+
+	res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	mgr := manager.New(res, manager.OptStore(s))
+	t.Cleanup(func() {
+		_ = mgr.Stop(time.Second * 5)
+	})
+
+	conf, err := harmlessConf().Sanitised()
+	require.NoError(t, err)
+
+	r := router(mgr)
+
+	// A stream that cannot be persisted is not run
+	atomic.StoreInt32(&flaky.failPuts, 1)
+
+	request := genRequest("POST", "/streams/foo", conf)
+	response := httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusBadGateway, response.Code, response.Body.String())
+
+	_, err = mgr.Read("foo")
+	require.Equal(t, manager.ErrStreamDoesNotExist, err)
+
+	atomic.StoreInt32(&flaky.failPuts, 0)
+
+	request = genRequest("POST", "/streams/foo", conf)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	// An update that cannot be persisted leaves the stream unchanged
+	atomic.StoreInt32(&flaky.failPuts, 1)
+
+	newConf := harmlessConf()
+	newConf.Buffer.Type = "memory"
+	newConfSanit, err := newConf.Sanitised()
+	require.NoError(t, err)
+
+	request = genRequest("PUT", "/streams/foo", newConfSanit)
+	response = httptest.NewRecorder()
+	r.ServeHTTP(response, request)
+	require.Equal(t, http.StatusBadGateway, response.Code, response.Body.String())
+
+	strm, err := mgr.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "none", strm.Config().Buffer.Type)
+
+	// The store agrees with the running streams
+	atomic.StoreInt32(&flaky.failPuts, 0)
+	require.NoError(t, mgr.SyncStore(context.Background()))
+
+	strm, err = mgr.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "none", strm.Config().Buffer.Type)
+}
+
+func TestTypeAPIStoreRawConfig(t *testing.T) {
+	storeDir := t.TempDir()
+
+	storeConf := store.NewConfig()
+	storeConf.Type = "directory"
+	storeConf.Directory.Path = storeDir
+
+	newMgr := func() *manager.Type {
+		t.Helper()
+
+		res, err := bmanager.New(bmanager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+		require.NoError(t, err)
+
+		s, err := store.New(storeConf)
+		require.NoError(t, err)
+
+		mgr := manager.New(res, manager.OptStore(s))
+		t.Cleanup(func() {
+			_ = mgr.Stop(time.Second * 5)
+		})
+		return mgr
+	}
+
+	testVar := "BENTHOS_TEST_STORE_RAW_PATH"
+	_ = os.Setenv(testVar, "/resolved/path")
+	t.Cleanup(func() {
+		_ = os.Unsetenv(testVar)
+	})
+
+	rawConf := []byte(`
+input:
+  http_server:
+    path: ${` + testVar + `}
+output:
+  http_server: {}
+`)
+
+	mgrOne := newMgr()
+
+	request, err := http.NewRequest("POST", "/streams/foo", bytes.NewReader(rawConf))
+	require.NoError(t, err)
+	response := httptest.NewRecorder()
+	router(mgrOne).ServeHTTP(response, request)
+	require.Equal(t, http.StatusOK, response.Code, response.Body.String())
+
+	strm, err := mgrOne.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "/resolved/path", strm.Config().Input.HTTPServer.Path)
+
+	// The config is persisted exactly as it was submitted
+	storedBytes, err := os.ReadFile(filepath.Join(storeDir, "foo.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, string(rawConf), string(storedBytes))
+
+	// And is interpolated when loaded by other instances
+	_ = os.Setenv(testVar, "/other/path")
+
+	mgrTwo := newMgr()
+	require.NoError(t, mgrTwo.SyncStore(context.Background()))
+
+	strm, err = mgrTwo.Read("foo")
+	require.NoError(t, err)
+	assert.Equal(t, "/other/path", strm.Config().Input.HTTPServer.Path)
+}
@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -14,12 +15,15 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/stream"
+	"github.com/benthosdev/benthos/v4/internal/stream/audit"
+	"github.com/benthosdev/benthos/v4/internal/stream/store"
 )
 
 // StreamStatus tracks a stream along with information regarding its internals.
 type StreamStatus struct {
 	stoppedAfter int64
 	config       stream.Config
+	raw          []byte
 	strm         *stream.Type
 	logger       log.Modular
 	metrics      *metrics.Local
@@ -54,6 +58,11 @@ func (s *StreamStatus) IsReady() bool {
 	return s.strm.IsReady()
 }
 
+// ReadyStatus returns the connection state of each component of the stream.
+func (s *StreamStatus) ReadyStatus() stream.ReadyStatus {
+	return s.strm.ReadyStatus()
+}
+
 // Uptime returns a time.Duration indicating the current uptime of the stream.
 func (s *StreamStatus) Uptime() time.Duration {
 	if stoppedAfter := atomic.LoadInt64(&s.stoppedAfter); stoppedAfter > 0 {
@@ -88,11 +97,23 @@ type StreamProcConstructorFunc func(streamID string) (processor.V1, error)
 // Type manages a collection of streams, providing APIs for CRUD operations on
 // the streams.
 type Type struct {
-	closed  bool
-	streams map[string]*StreamStatus
-
-	manager    bundle.NewManagement
-	apiEnabled bool
+	closed    bool
+	streams   map[string]*StreamStatus
+	revisions map[string]*streamRevisions
+
+	manager         bundle.NewManagement
+	apiEnabled      bool
+	apiSecrets      bool
+	readyComponents []string
+	audit           *audit.Log
+	drainTimeout    time.Duration
+	flushTimeout    time.Duration
+
+	store         *store.Type
+	storedConfs   map[string]storedConfig
+	syncMut       sync.Mutex
+	syncCloseChan chan struct{}
+	syncWG        sync.WaitGroup
 
 	lock sync.Mutex
 }
@@ -100,19 +121,64 @@ type Type struct {
 // New creates a new stream manager.Type.
 func New(mgr bundle.NewManagement, opts ...func(*Type)) *Type {
 	t := &Type{
-		streams:    map[string]*StreamStatus{},
-		apiEnabled: true,
-		manager:    mgr,
+		streams:       map[string]*StreamStatus{},
+		revisions:     map[string]*streamRevisions{},
+		apiEnabled:    true,
+		manager:       mgr,
+		storedConfs:   map[string]storedConfig{},
+		syncCloseChan: make(chan struct{}),
 	}
 	for _, opt := range opts {
 		opt(t)
 	}
 	t.registerEndpoints(t.apiEnabled)
+	if t.store != nil && t.store.SyncPeriod > 0 {
+		t.syncWG.Add(1)
+		go t.syncLoop()
+	}
+	if t.store != nil && t.store.Elector != nil {
+		t.syncWG.Add(1)
+		go t.leadershipLoop()
+	}
 	return t
 }
 
 //------------------------------------------------------------------------------
 
+// OptReadyComponents sets the components of each stream that must be connected
+// in order for the stream to be considered ready.
+func OptReadyComponents(components ...string) func(*Type) {
+	return func(t *Type) {
+		t.readyComponents = components
+	}
+}
+
+// OptAuditLog sets an audit log that records every create, update and delete
+// of a stream. The audit log is closed when the stream manager is stopped.
+func OptAuditLog(l *audit.Log) func(*Type) {
+	return func(t *Type) {
+		t.audit = l
+	}
+}
+
+// OptStore sets a store that streams created, updated and deleted via the API
+// are persisted to, and which is periodically synchronised with the running
+// streams. The store is closed when the stream manager is stopped.
+func OptStore(s *store.Type) func(*Type) {
+	return func(t *Type) {
+		t.store = s
+	}
+}
+
+// OptShutdownTimeouts sets the drain and flush timeouts applied to each stream
+// when it is stopped, see stream.OptShutdownTimeouts for more details.
+func OptShutdownTimeouts(drain, flush time.Duration) func(*Type) {
+	return func(t *Type) {
+		t.drainTimeout = drain
+		t.flushTimeout = flush
+	}
+}
+
 // OptAPIEnabled sets whether the stream manager registers API endpoints for
 // CRUD operations on streams. This is enabled by default.
 func OptAPIEnabled(b bool) func(*Type) {
@@ -121,6 +187,15 @@ func OptAPIEnabled(b bool) func(*Type) {
 	}
 }
 
+// OptAPISecrets sets whether stream configs submitted via the API, or read from
+// a store, are permitted to reference secrets, which are otherwise rejected.
+// This is disabled by default.
+func OptAPISecrets(b bool) func(*Type) {
+	return func(t *Type) {
+		t.apiSecrets = b
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // Errors specifically returned by a stream manager.
@@ -131,9 +206,51 @@ var (
 
 //------------------------------------------------------------------------------
 
+// recordAudit adds an event to the audit log describing a change to a stream,
+// attempts that were rejected before any change was made are not recorded.
+func (m *Type) recordAudit(action, principal, id string, before, after *configWithRaw, err error) {
+	if m.audit == nil {
+		return
+	}
+	if err == ErrStreamExists || err == ErrStreamDoesNotExist || err == component.ErrTypeClosed {
+		return
+	}
+
+	event := audit.Event{
+		Action:    action,
+		Stream:    id,
+		Principal: principal,
+		Changes:   diffConfigs(before, after),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	m.audit.Record(event)
+}
+
 // Create attempts to construct and run a new stream under a unique ID. If the
 // ID already exists an error is returned.
 func (m *Type) Create(id string, conf stream.Config) error {
+	return m.create("", false, id, conf, nil)
+}
+
+// create a stream, where raw is the config as it was submitted prior to any
+// interpolations, or nil if the config was provided programmatically.
+func (m *Type) create(principal string, persist bool, id string, conf stream.Config, raw []byte) error {
+	var err error
+	if persist && m.store != nil {
+		err = m.createStored(id, conf, raw)
+	} else {
+		err = m.createStream(id, conf, raw)
+	}
+	m.recordAudit(audit.ActionCreate, principal, id, nil, &configWithRaw{conf, raw}, err)
+	if err == nil {
+		m.addRevision(principal, id, conf, raw)
+	}
+	return err
+}
+
+func (m *Type) createStream(id string, conf stream.Config, raw []byte) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
@@ -149,14 +266,24 @@ func (m *Type) Create(id string, conf stream.Config) error {
 	sMgr := m.manager.ForStream(id).WithAddedMetrics(strmFlatMetrics)
 
 	var wrapper *StreamStatus
-	strm, err := stream.New(conf, sMgr, stream.OptOnClose(func() {
-		wrapper.setClosed()
-	}))
+	strmOpts := []func(*stream.Type){
+		stream.OptOnClose(func() {
+			wrapper.setClosed()
+		}),
+	}
+	if m.readyComponents != nil {
+		strmOpts = append(strmOpts, stream.OptReadyComponents(m.readyComponents...))
+	}
+	if m.drainTimeout > 0 || m.flushTimeout > 0 {
+		strmOpts = append(strmOpts, stream.OptShutdownTimeouts(m.drainTimeout, m.flushTimeout))
+	}
+	strm, err := stream.New(conf, sMgr, strmOpts...)
 	if err != nil {
 		return err
 	}
 
 	wrapper = NewStreamStatus(conf, strm, sMgr.Logger(), strmFlatMetrics)
+	wrapper.raw = raw
 	m.streams[id] = wrapper
 	return nil
 }
@@ -182,6 +309,10 @@ func (m *Type) Read(id string) (*StreamStatus, error) {
 // Update attempts to stop an existing stream and replace it with a new version
 // of the same stream.
 func (m *Type) Update(id string, conf stream.Config, timeout time.Duration) error {
+	return m.update("", false, id, conf, nil, timeout)
+}
+
+func (m *Type) update(principal string, persist bool, id string, conf stream.Config, raw []byte, timeout time.Duration) error {
 	m.lock.Lock()
 	wrapper, exists := m.streams[id]
 	closed := m.closed
@@ -190,6 +321,23 @@ func (m *Type) Update(id string, conf stream.Config, timeout time.Duration) erro
 	if closed {
 		return component.ErrTypeClosed
 	}
+
+	if persist && m.store != nil {
+		var before *configWithRaw
+		if exists {
+			if reflect.DeepEqual(wrapper.config, conf) {
+				return nil
+			}
+			before = &configWithRaw{wrapper.config, wrapper.raw}
+		}
+		err := m.updateStored(id, conf, raw, timeout)
+		m.recordAudit(audit.ActionUpdate, principal, id, before, &configWithRaw{conf, raw}, err)
+		if err == nil {
+			m.addRevision(principal, id, conf, raw)
+		}
+		return err
+	}
+
 	if !exists {
 		return ErrStreamDoesNotExist
 	}
@@ -198,16 +346,46 @@ func (m *Type) Update(id string, conf stream.Config, timeout time.Duration) erro
 		return nil
 	}
 
-	if err := m.Delete(id, timeout); err != nil {
-		return err
+	err := m.deleteStream(id, timeout)
+	if err == nil {
+		err = m.createStream(id, conf, raw)
+	}
+	m.recordAudit(audit.ActionUpdate, principal, id, &configWithRaw{wrapper.config, wrapper.raw}, &configWithRaw{conf, raw}, err)
+	if err == nil {
+		m.addRevision(principal, id, conf, raw)
 	}
-	return m.Create(id, conf)
+	return err
 }
 
 // Delete attempts to stop and remove a stream by its ID. Returns an error if
 // the stream was not found, or if clean shutdown fails in the specified period
 // of time.
 func (m *Type) Delete(id string, timeout time.Duration) error {
+	return m.delete("", false, id, timeout)
+}
+
+func (m *Type) delete(principal string, persist bool, id string, timeout time.Duration) error {
+	var before *configWithRaw
+	m.lock.Lock()
+	if wrapper, exists := m.streams[id]; exists {
+		before = &configWithRaw{wrapper.config, wrapper.raw}
+	}
+	m.lock.Unlock()
+
+	var err error
+	if persist && m.store != nil {
+		err = m.deleteStored(id, timeout)
+	} else {
+		err = m.deleteStream(id, timeout)
+	}
+	m.recordAudit(audit.ActionDelete, principal, id, before, nil, err)
+	if err == nil {
+		m.dropRevisions(id)
+	}
+	return err
+}
+
+func (m *Type) deleteStream(id string, timeout time.Duration) error {
 	m.lock.Lock()
 	if m.closed {
 		m.lock.Unlock()
@@ -236,6 +414,17 @@ func (m *Type) Delete(id string, timeout time.Duration) error {
 // Stop attempts to gracefully shut down all active streams and close the
 // stream manager.
 func (m *Type) Stop(timeout time.Duration) error {
+	// The sync loop must be stopped before acquiring the lock as it may be
+	// blocked on the lock itself.
+	m.syncMut.Lock()
+	select {
+	case <-m.syncCloseChan:
+	default:
+		close(m.syncCloseChan)
+	}
+	m.syncMut.Unlock()
+	m.syncWG.Wait()
+
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
@@ -259,8 +448,21 @@ func (m *Type) Stop(timeout time.Duration) error {
 	}
 
 	m.streams = map[string]*StreamStatus{}
+	m.revisions = map[string]*streamRevisions{}
 	m.closed = true
 
+	if err := m.audit.Close(); err != nil {
+		m.manager.Logger().Errorf("Failed to close audit log: %v\n", err)
+	}
+
+	if m.store != nil {
+		ctx, done := context.WithTimeout(context.Background(), timeout)
+		if err := m.store.Close(ctx); err != nil {
+			m.manager.Logger().Errorf("Failed to close streams store: %v\n", err)
+		}
+		done()
+	}
+
 	if len(failedStreams) > 0 {
 		return fmt.Errorf("failed to gracefully stop the following streams: %v", failedStreams)
 	}
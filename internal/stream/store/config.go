@@ -0,0 +1,155 @@
+package store
+
+import "github.com/benthosdev/benthos/v4/internal/docs"
+
+// DirectoryConfig contains configuration fields for a directory store.
+type DirectoryConfig struct {
+	Path string `json:"path" yaml:"path"`
+}
+
+// S3Config contains configuration fields for an S3 store.
+type S3Config struct {
+	Bucket         string `json:"bucket" yaml:"bucket"`
+	Prefix         string `json:"prefix" yaml:"prefix"`
+	Region         string `json:"region" yaml:"region"`
+	Endpoint       string `json:"endpoint" yaml:"endpoint"`
+	ForcePathStyle bool   `json:"force_path_style_urls" yaml:"force_path_style_urls"`
+}
+
+// ConsulConfig contains configuration fields for a Consul store.
+type ConsulConfig struct {
+	Address string `json:"address" yaml:"address"`
+	Token   string `json:"token" yaml:"token"`
+	Prefix  string `json:"prefix" yaml:"prefix"`
+}
+
+// EtcdStoreConfig contains configuration fields for an etcd store.
+type EtcdStoreConfig struct {
+	Endpoints []string `json:"endpoints" yaml:"endpoints"`
+	Prefix    string   `json:"prefix" yaml:"prefix"`
+}
+
+// KubernetesLeaseConfig contains configuration fields for leader election
+// with a Kubernetes lease.
+type KubernetesLeaseConfig struct {
+	LeaseName string `json:"lease_name" yaml:"lease_name"`
+	Namespace string `json:"namespace" yaml:"namespace"`
+	APIServer string `json:"api_server" yaml:"api_server"`
+}
+
+// EtcdConfig contains configuration fields for leader election with etcd.
+type EtcdConfig struct {
+	Endpoints []string `json:"endpoints" yaml:"endpoints"`
+}
+
+// LeaderElectionConfig contains configuration fields for leader election.
+type LeaderElectionConfig struct {
+	Enabled    bool                  `json:"enabled" yaml:"enabled"`
+	Type       string                `json:"type" yaml:"type"`
+	Key        string                `json:"key" yaml:"key"`
+	SessionTTL string                `json:"session_ttl" yaml:"session_ttl"`
+	Singletons []string              `json:"singletons" yaml:"singletons"`
+	Kubernetes KubernetesLeaseConfig `json:"kubernetes" yaml:"kubernetes"`
+	Etcd       EtcdConfig            `json:"etcd" yaml:"etcd"`
+}
+
+// Config contains configuration fields for the streams mode store.
+type Config struct {
+	Type           string               `json:"type" yaml:"type"`
+	SyncPeriod     string               `json:"sync_period" yaml:"sync_period"`
+	Directory      DirectoryConfig      `json:"directory" yaml:"directory"`
+	S3             S3Config             `json:"s3" yaml:"s3"`
+	Consul         ConsulConfig         `json:"consul" yaml:"consul"`
+	Etcd           EtcdStoreConfig      `json:"etcd" yaml:"etcd"`
+	LeaderElection LeaderElectionConfig `json:"leader_election" yaml:"leader_election"`
+}
+
+// NewConfig creates a new store config with default values.
+func NewConfig() Config {
+	return Config{
+		Type:       "none",
+		SyncPeriod: "10s",
+		Directory: DirectoryConfig{
+			Path: "",
+		},
+		S3: S3Config{
+			Bucket:         "",
+			Prefix:         "benthos/streams/",
+			Region:         "",
+			Endpoint:       "",
+			ForcePathStyle: false,
+		},
+		Consul: ConsulConfig{
+			Address: "http://127.0.0.1:8500",
+			Token:   "",
+			Prefix:  "benthos/streams/",
+		},
+		Etcd: EtcdStoreConfig{
+			Endpoints: []string{"http://127.0.0.1:2379"},
+			Prefix:    "benthos/streams/",
+		},
+		LeaderElection: LeaderElectionConfig{
+			Enabled:    false,
+			Type:       "consul",
+			Key:        "benthos/leader",
+			SessionTTL: "15s",
+			Singletons: []string{},
+			Kubernetes: KubernetesLeaseConfig{
+				LeaseName: "benthos-leader",
+				Namespace: "",
+				APIServer: "",
+			},
+			Etcd: EtcdConfig{
+				Endpoints: []string{"http://127.0.0.1:2379"},
+			},
+		},
+	}
+}
+
+// Spec returns a field spec for the store configuration fields.
+func Spec() docs.FieldSpec {
+	return docs.FieldObject(
+		"streams_store", "Persist the streams created, updated and deleted via the streams mode API so that they are restored after a restart, and synchronised across all instances sharing the same store. This has no effect outside of streams mode.",
+	).WithChildren(
+		docs.FieldString("type", "The type of store to persist stream configs to.").HasOptions("none", "directory", "s3", "consul", "etcd").HasDefault("none"),
+		docs.FieldString("sync_period", "The period at which streams are synchronised with the contents of the store, which allows changes made by other instances to be applied. Set to an empty string in order to only read the store at startup.").HasDefault("10s"),
+		docs.FieldObject("directory", "Persist stream configs as YAML files within a directory.").WithChildren(
+			docs.FieldString("path", "The path of the directory.").HasDefault(""),
+		),
+		docs.FieldObject("s3", "Persist stream configs as YAML objects within an S3 bucket, using the default AWS credentials chain.").WithChildren(
+			docs.FieldString("bucket", "The bucket to store configs in.").HasDefault(""),
+			docs.FieldString("prefix", "A prefix to add to the key of each config.").HasDefault("benthos/streams/"),
+			docs.FieldString("region", "The AWS region to target.").HasDefault(""),
+			docs.FieldString("endpoint", "Allows you to specify a custom endpoint for the AWS API.").HasDefault(""),
+			docs.FieldBool("force_path_style_urls", "Forces the client API to use path style URLs, which helps when connecting to custom endpoints.").HasDefault(false),
+		),
+		docs.FieldObject("consul", "Persist stream configs as keys within the Consul KV store, which also supports leader election.").WithChildren(
+			docs.FieldString("address", "The address of the Consul HTTP API.").HasDefault("http://127.0.0.1:8500"),
+			docs.FieldString("token", "An optional ACL token.").Secret().HasDefault(""),
+			docs.FieldString("prefix", "A prefix to add to the key of each config.").HasDefault("benthos/streams/"),
+		),
+		docs.FieldObject("etcd", "Persist stream configs as keys within etcd, using the JSON gateway of the v3 API.").WithChildren(
+			docs.FieldString("endpoints", "A list of etcd endpoints to connect to, which are attempted in order.").Array().HasDefault([]string{"http://127.0.0.1:2379"}),
+			docs.FieldString("prefix", "A prefix to add to the key of each config.").HasDefault("benthos/streams/"),
+		),
+		docs.FieldObject("leader_election", "Elect a single leader amongst all instances sharing the store, which is the only instance that runs singleton streams.").WithChildren(
+			docs.FieldBool("enabled", "Whether leader election is enabled.").HasDefault(false),
+			docs.FieldString("type", "The mechanism used for the election.").HasAnnotatedOptions(
+				"consul", "Acquire a lock on a key with a Consul session. Requires the `consul` store type.",
+				"kubernetes", "Hold a Kubernetes `Lease` object, using the service account of the pod.",
+				"etcd", "Create a key attached to an etcd lease.",
+			).HasDefault("consul"),
+			docs.FieldString("key", "The key to use as a lock for the election, used by the `consul` and `etcd` types.").HasDefault("benthos/leader"),
+			docs.FieldString("session_ttl", "The TTL of the session or lease holding the lock, after which a new leader is elected if the current leader becomes unresponsive.").HasDefault("15s"),
+			docs.FieldString("singletons", "A list of stream IDs that should only run on the leader, which may contain glob patterns such as `foo_*`.").Array().HasDefault([]string{}),
+			docs.FieldObject("kubernetes", "Configuration for the `kubernetes` election type.").WithChildren(
+				docs.FieldString("lease_name", "The name of the `Lease` object to hold.").HasDefault("benthos-leader"),
+				docs.FieldString("namespace", "The namespace of the `Lease` object. When empty the namespace of the pod is used.").HasDefault(""),
+				docs.FieldString("api_server", "The address of the Kubernetes API server. When empty the in-cluster address and credentials of the pod are used.").HasDefault(""),
+			),
+			docs.FieldObject("etcd", "Configuration for the `etcd` election type.").WithChildren(
+				docs.FieldString("endpoints", "A list of etcd endpoints to connect to, which are attempted in order.").Array().HasDefault([]string{"http://127.0.0.1:2379"}),
+			),
+		),
+	).Advanced()
+}
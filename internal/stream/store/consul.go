@@ -0,0 +1,248 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// consulClient is a minimal client for the Consul HTTP API.
+type consulClient struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+func (c *consulClient) do(ctx context.Context, method, path string, body []byte) ([]byte, int, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.address+path, bodyReader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return nil, res.StatusCode, fmt.Errorf("unexpected status code %v: %s", res.StatusCode, bytes.TrimSpace(resBytes))
+	}
+	return resBytes, res.StatusCode, nil
+}
+
+//------------------------------------------------------------------------------
+
+type consulStore struct {
+	prefix string
+	client *consulClient
+}
+
+func newConsulStore(conf ConsulConfig) (*consulStore, error) {
+	if conf.Address == "" {
+		return nil, errors.New("a consul address must be specified")
+	}
+	return &consulStore{
+		prefix: strings.TrimPrefix(conf.Prefix, "/"),
+		client: &consulClient{
+			address: strings.TrimSuffix(conf.Address, "/"),
+			token:   conf.Token,
+			client:  &http.Client{Timeout: 30 * time.Second},
+		},
+	}, nil
+}
+
+func (c *consulStore) List(ctx context.Context) (map[string][]byte, error) {
+	resBytes, status, err := c.client.do(ctx, "GET", "/v1/kv/"+c.prefix+"?recurse=true", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	confs := map[string][]byte{}
+	if status == http.StatusNotFound {
+		return confs, nil
+	}
+
+	var pairs []struct {
+		Key   string
+		Value []byte
+	}
+	if err := json.Unmarshal(resBytes, &pairs); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	for _, p := range pairs {
+		id := strings.TrimPrefix(p.Key, c.prefix)
+		if id == "" || strings.HasSuffix(id, "/") {
+			continue
+		}
+		confs[id] = p.Value
+	}
+	return confs, nil
+}
+
+func (c *consulStore) Put(ctx context.Context, id string, conf []byte) error {
+	_, status, err := c.client.do(ctx, "PUT", "/v1/kv/"+c.prefix+id, conf)
+	if err == nil && status == http.StatusNotFound {
+		err = errors.New("consul KV endpoint not found")
+	}
+	return err
+}
+
+func (c *consulStore) Delete(ctx context.Context, id string) error {
+	_, _, err := c.client.do(ctx, "DELETE", "/v1/kv/"+c.prefix+id, nil)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+type consulElector struct {
+	client *consulClient
+	key    string
+	ttl    time.Duration
+
+	*leadership
+
+	sessionID string
+	mut       sync.Mutex
+
+	closeChan chan struct{}
+	closedWG  sync.WaitGroup
+}
+
+func newConsulElector(client *consulClient, conf LeaderElectionConfig) (*consulElector, error) {
+	ttl, err := time.ParseDuration(conf.SessionTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse session TTL: %w", err)
+	}
+	if conf.Key == "" {
+		return nil, errors.New("a leader election key must be specified")
+	}
+
+	e := &consulElector{
+		leadership: newLeadership(),
+		client:     client,
+		key:        strings.TrimPrefix(conf.Key, "/"),
+		ttl:        ttl,
+		closeChan:  make(chan struct{}),
+	}
+
+	// Attempt the first election immediately so that the leadership state is
+	// known before any streams are started.
+	e.campaign()
+
+	e.closedWG.Add(1)
+	go e.loop()
+	return e, nil
+}
+
+func (e *consulElector) createSession(ctx context.Context) (string, error) {
+	name, _ := os.Hostname()
+	reqBytes, err := json.Marshal(map[string]string{
+		"Name":     "benthos-" + name,
+		"TTL":      e.ttl.String(),
+		"Behavior": "release",
+	})
+	if err != nil {
+		return "", err
+	}
+	resBytes, _, err := e.client.do(ctx, "PUT", "/v1/session/create", reqBytes)
+	if err != nil {
+		return "", err
+	}
+	var res struct {
+		ID string
+	}
+	if err := json.Unmarshal(resBytes, &res); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	return res.ID, nil
+}
+
+// campaign renews (or creates) the session of this instance and attempts to
+// acquire the leader lock with it. Errors leave the leadership unchanged, as
+// it is relinquished anyway once the session is no longer renewed.
+func (e *consulElector) campaign() {
+	ctx, done := context.WithTimeout(context.Background(), e.ttl/3)
+	defer done()
+
+	e.mut.Lock()
+	sessionID := e.sessionID
+	e.mut.Unlock()
+	defer func() {
+		e.mut.Lock()
+		e.sessionID = sessionID
+		e.mut.Unlock()
+	}()
+
+	start := time.Now()
+	if sessionID != "" {
+		_, status, err := e.client.do(ctx, "PUT", "/v1/session/renew/"+sessionID, nil)
+		if err != nil {
+			return
+		}
+		if status == http.StatusNotFound {
+			// The session has expired, and therefore any lock it held has
+			// been released.
+			sessionID = ""
+			e.lost()
+		}
+	}
+	if sessionID == "" {
+		var err error
+		if sessionID, err = e.createSession(ctx); err != nil {
+			return
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	resBytes, _, err := e.client.do(ctx, "PUT", "/v1/kv/"+e.key+"?acquire="+sessionID, []byte(hostname))
+	if err != nil {
+		return
+	}
+	if strings.TrimSpace(string(resBytes)) == "true" {
+		e.renewed(start, e.ttl)
+	} else {
+		e.lost()
+	}
+}
+
+func (e *consulElector) loop() {
+	defer e.closedWG.Done()
+	campaignLoop(e.ttl/3, e.closeChan, e.campaign)
+}
+
+func (e *consulElector) Close(ctx context.Context) error {
+	close(e.closeChan)
+	e.closedWG.Wait()
+
+	e.lost()
+
+	e.mut.Lock()
+	sessionID := e.sessionID
+	e.mut.Unlock()
+
+	if sessionID == "" {
+		return nil
+	}
+	_, _, err := e.client.do(ctx, "PUT", "/v1/session/destroy/"+sessionID, nil)
+	return err
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type directoryStore struct {
+	path string
+}
+
+func newDirectoryStore(conf DirectoryConfig) (*directoryStore, error) {
+	if conf.Path == "" {
+		return nil, errors.New("a directory path must be specified")
+	}
+	if err := os.MkdirAll(conf.Path, 0o755); err != nil {
+		return nil, err
+	}
+	return &directoryStore{path: conf.Path}, nil
+}
+
+func (d *directoryStore) filePath(id string) string {
+	return filepath.Join(d.path, filepath.FromSlash(id)+".yaml")
+}
+
+func (d *directoryStore) List(ctx context.Context) (map[string][]byte, error) {
+	confs := map[string][]byte{}
+	err := filepath.Walk(d.path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".yaml") {
+			return nil
+		}
+		rel, err := filepath.Rel(d.path, p)
+		if err != nil {
+			return err
+		}
+		confBytes, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		confs[filepath.ToSlash(strings.TrimSuffix(rel, ".yaml"))] = confBytes
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return confs, nil
+}
+
+func (d *directoryStore) Put(ctx context.Context, id string, conf []byte) error {
+	p := d.filePath(id)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a partially written config is
+	// never observed.
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, conf, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func (d *directoryStore) Delete(ctx context.Context, id string) error {
+	if err := os.Remove(d.filePath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// etcdClient is a minimal client for the JSON gateway of the etcd v3 API.
+type etcdClient struct {
+	endpoints []string
+	client    *http.Client
+}
+
+func newEtcdClient(conf EtcdConfig) (*etcdClient, error) {
+	var endpoints []string
+	for _, e := range conf.Endpoints {
+		for _, split := range strings.Split(e, ",") {
+			if split = strings.TrimSpace(split); split != "" {
+				endpoints = append(endpoints, strings.TrimSuffix(split, "/"))
+			}
+		}
+	}
+	if len(endpoints) == 0 {
+		return nil, errors.New("at least one etcd endpoint must be specified")
+	}
+	return &etcdClient{
+		endpoints: endpoints,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// do sends a request to each endpoint in turn until one succeeds.
+func (e *etcdClient) do(ctx context.Context, path string, body, res interface{}) error {
+	reqBytes, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range e.endpoints {
+		var req *http.Request
+		if req, err = http.NewRequestWithContext(ctx, "POST", endpoint+path, bytes.NewReader(reqBytes)); err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		var resp *http.Response
+		if resp, err = e.client.Do(req); err != nil {
+			continue
+		}
+
+		var resBytes []byte
+		resBytes, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("unexpected status code %v: %s", resp.StatusCode, bytes.TrimSpace(resBytes))
+			continue
+		}
+		if err = json.Unmarshal(resBytes, res); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+		return nil
+	}
+	return err
+}
+
+func etcdEncode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// etcdPrefixEnd returns the end of the range of keys beginning with a prefix.
+func etcdPrefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	// All keys are within the range.
+	return "\x00"
+}
+
+//------------------------------------------------------------------------------
+
+type etcdStore struct {
+	prefix string
+	client *etcdClient
+}
+
+func newEtcdStore(conf EtcdStoreConfig) (*etcdStore, error) {
+	client, err := newEtcdClient(EtcdConfig{Endpoints: conf.Endpoints})
+	if err != nil {
+		return nil, err
+	}
+	return &etcdStore{
+		prefix: conf.Prefix,
+		client: client,
+	}, nil
+}
+
+func (e *etcdStore) List(ctx context.Context) (map[string][]byte, error) {
+	key := e.prefix
+	if key == "" {
+		key = "\x00"
+	}
+	var res struct {
+		Kvs []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := e.client.do(ctx, "/v3/kv/range", map[string]interface{}{
+		"key":       etcdEncode(key),
+		"range_end": etcdEncode(etcdPrefixEnd(e.prefix)),
+	}, &res); err != nil {
+		return nil, err
+	}
+
+	confs := map[string][]byte{}
+	for _, kv := range res.Kvs {
+		keyBytes, err := base64.StdEncoding.DecodeString(kv.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key: %w", err)
+		}
+		id := strings.TrimPrefix(string(keyBytes), e.prefix)
+		if id == "" || strings.HasSuffix(id, "/") {
+			continue
+		}
+		if confs[id], err = base64.StdEncoding.DecodeString(kv.Value); err != nil {
+			return nil, fmt.Errorf("failed to decode config %v: %w", id, err)
+		}
+	}
+	return confs, nil
+}
+
+func (e *etcdStore) Put(ctx context.Context, id string, conf []byte) error {
+	var res interface{}
+	return e.client.do(ctx, "/v3/kv/put", map[string]interface{}{
+		"key":   etcdEncode(e.prefix + id),
+		"value": base64.StdEncoding.EncodeToString(conf),
+	}, &res)
+}
+
+func (e *etcdStore) Delete(ctx context.Context, id string) error {
+	var res interface{}
+	return e.client.do(ctx, "/v3/kv/deleterange", map[string]interface{}{
+		"key": etcdEncode(e.prefix + id),
+	}, &res)
+}
+
+//------------------------------------------------------------------------------
+
+// etcdElector elects a leader by creating a key attached to a lease within
+// etcd, using the JSON gateway of the v3 API. The key can only be created when
+// it does not exist, and is removed when the lease of the leader expires.
+type etcdElector struct {
+	*etcdClient
+
+	key      string
+	identity string
+	ttl      time.Duration
+
+	*leadership
+
+	leaseID string
+	mut     sync.Mutex
+
+	closeChan chan struct{}
+	closedWG  sync.WaitGroup
+}
+
+func newEtcdElector(conf LeaderElectionConfig) (*etcdElector, error) {
+	ttl, err := time.ParseDuration(conf.SessionTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse session TTL: %w", err)
+	}
+	if conf.Key == "" {
+		return nil, errors.New("a leader election key must be specified")
+	}
+
+	client, err := newEtcdClient(conf.Etcd)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &etcdElector{
+		etcdClient: client,
+		leadership: newLeadership(),
+		key:        conf.Key,
+		identity:   electionIdentity(),
+		ttl:        ttl,
+		closeChan:  make(chan struct{}),
+	}
+
+	// Attempt the first election immediately so that the leadership state is
+	// known before any streams are started.
+	e.campaign()
+
+	e.closedWG.Add(1)
+	go func() {
+		defer e.closedWG.Done()
+		campaignLoop(e.ttl/3, e.closeChan, e.campaign)
+	}()
+	return e, nil
+}
+
+// leaseTTL returns the TTL of granted leases, which are in whole seconds.
+func (e *etcdElector) leaseTTL() time.Duration {
+	if e.ttl < time.Second {
+		return time.Second
+	}
+	return e.ttl.Truncate(time.Second)
+}
+
+func (e *etcdElector) grantLease(ctx context.Context) (string, error) {
+	var res struct {
+		ID string `json:"ID"`
+	}
+	if err := e.do(ctx, "/v3/lease/grant", map[string]interface{}{
+		"TTL": strconv.FormatInt(int64(e.leaseTTL().Seconds()), 10),
+	}, &res); err != nil {
+		return "", err
+	}
+	if res.ID == "" {
+		return "", errors.New("lease grant response did not contain an ID")
+	}
+	return res.ID, nil
+}
+
+// campaign keeps the lease of this instance alive (or grants a new one) and
+// attempts to create the leader key with it. Errors leave the leadership
+// unchanged, as it is relinquished anyway once the lease is no longer kept
+// alive.
+func (e *etcdElector) campaign() {
+	ctx, done := context.WithTimeout(context.Background(), e.ttl/3)
+	defer done()
+
+	e.mut.Lock()
+	leaseID := e.leaseID
+	e.mut.Unlock()
+	defer func() {
+		e.mut.Lock()
+		e.leaseID = leaseID
+		e.mut.Unlock()
+	}()
+
+	start := time.Now()
+	if leaseID != "" {
+		var res struct {
+			Result struct {
+				TTL string `json:"TTL"`
+			} `json:"result"`
+		}
+		if err := e.do(ctx, "/v3/lease/keepalive", map[string]interface{}{"ID": leaseID}, &res); err != nil {
+			return
+		}
+		if ttl, _ := strconv.ParseInt(res.Result.TTL, 10, 64); ttl <= 0 {
+			// The lease has expired, and therefore any key attached to it has
+			// been removed.
+			leaseID = ""
+			e.lost()
+		}
+	}
+	if leaseID == "" {
+		var err error
+		if leaseID, err = e.grantLease(ctx); err != nil {
+			return
+		}
+	}
+
+	key := etcdEncode(e.key)
+	var res struct {
+		Succeeded bool `json:"succeeded"`
+		Responses []struct {
+			ResponseRange struct {
+				Kvs []struct {
+					Lease string `json:"lease"`
+				} `json:"kvs"`
+			} `json:"response_range"`
+		} `json:"responses"`
+	}
+	if err := e.do(ctx, "/v3/kv/txn", map[string]interface{}{
+		"compare": []interface{}{
+			map[string]interface{}{"key": key, "target": "CREATE", "create_revision": "0"},
+		},
+		"success": []interface{}{
+			map[string]interface{}{"request_put": map[string]interface{}{
+				"key":   key,
+				"value": etcdEncode(e.identity),
+				"lease": leaseID,
+			}},
+		},
+		"failure": []interface{}{
+			map[string]interface{}{"request_range": map[string]interface{}{"key": key}},
+		},
+	}, &res); err != nil {
+		return
+	}
+
+	if res.Succeeded {
+		e.renewed(start, e.leaseTTL())
+		return
+	}
+	for _, r := range res.Responses {
+		for _, kv := range r.ResponseRange.Kvs {
+			if kv.Lease == leaseID {
+				e.renewed(start, e.leaseTTL())
+				return
+			}
+		}
+	}
+	e.lost()
+}
+
+func (e *etcdElector) Close(ctx context.Context) error {
+	close(e.closeChan)
+	e.closedWG.Wait()
+
+	e.lost()
+
+	e.mut.Lock()
+	leaseID := e.leaseID
+	e.mut.Unlock()
+
+	if leaseID == "" {
+		return nil
+	}
+	// Revoking the lease removes the leader key if it is held by this instance.
+	var res interface{}
+	return e.do(ctx, "/v3/lease/revoke", map[string]interface{}{"ID": leaseID}, &res)
+}
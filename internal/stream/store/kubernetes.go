@@ -0,0 +1,281 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	kubeServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount/"
+	kubeMicroTimeFormat   = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// electionIdentity returns a unique identity for this instance, which is the
+// hostname (the pod name in Kubernetes) followed by a random suffix.
+func electionIdentity() string {
+	hostname, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return hostname + "-" + hex.EncodeToString(suffix)
+}
+
+type kubeLeaseSpec struct {
+	HolderIdentity       *string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds *int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *string `json:"acquireTime,omitempty"`
+	RenewTime            *string `json:"renewTime,omitempty"`
+}
+
+type kubeLease struct {
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Metadata   map[string]interface{} `json:"metadata"`
+	Spec       kubeLeaseSpec          `json:"spec"`
+}
+
+// kubeElector elects a leader with a Kubernetes Lease object, which is
+// acquired by writing the identity of this instance as the holder when the
+// lease is either unheld or has not been renewed within its duration.
+type kubeElector struct {
+	baseURL   string
+	leaseName string
+	token     string
+	client    *http.Client
+	identity  string
+	ttl       time.Duration
+
+	*leadership
+
+	closeChan chan struct{}
+	closedWG  sync.WaitGroup
+}
+
+func newKubernetesElector(conf LeaderElectionConfig) (*kubeElector, error) {
+	ttl, err := time.ParseDuration(conf.SessionTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse session TTL: %w", err)
+	}
+	if conf.Kubernetes.LeaseName == "" {
+		return nil, errors.New("a kubernetes lease name must be specified")
+	}
+
+	namespace := conf.Kubernetes.Namespace
+	if namespace == "" {
+		nsBytes, err := os.ReadFile(kubeServiceAccountDir + "namespace")
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect kubernetes namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(nsBytes))
+	}
+
+	apiServer := conf.Kubernetes.APIServer
+	client := &http.Client{Timeout: 30 * time.Second}
+	if apiServer == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, errors.New("a kubernetes API server must be specified when running outside of a cluster")
+		}
+		apiServer = "https://" + net.JoinHostPort(host, port)
+
+		caBytes, err := os.ReadFile(kubeServiceAccountDir + "ca.crt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubernetes CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(caBytes)
+		client.Transport = &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		}
+	}
+
+	var token string
+	if tokenBytes, err := os.ReadFile(kubeServiceAccountDir + "token"); err == nil {
+		token = strings.TrimSpace(string(tokenBytes))
+	}
+
+	e := &kubeElector{
+		leadership: newLeadership(),
+		baseURL:    strings.TrimSuffix(apiServer, "/") + "/apis/coordination.k8s.io/v1/namespaces/" + namespace + "/leases",
+		leaseName:  conf.Kubernetes.LeaseName,
+		token:      token,
+		client:     client,
+		identity:   electionIdentity(),
+		ttl:        ttl,
+		closeChan:  make(chan struct{}),
+	}
+
+	// Attempt the first election immediately so that the leadership state is
+	// known before any streams are started.
+	e.campaign()
+
+	e.closedWG.Add(1)
+	go func() {
+		defer e.closedWG.Done()
+		campaignLoop(e.ttl/3, e.closeChan, e.campaign)
+	}()
+	return e, nil
+}
+
+func (e *kubeElector) do(ctx context.Context, method, path string, body interface{}) (*kubeLease, int, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return nil, 0, err
+		}
+		bodyReader = bytes.NewReader(bodyBytes)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, e.baseURL+path, bodyReader)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound, http.StatusConflict:
+		return nil, res.StatusCode, nil
+	default:
+		return nil, res.StatusCode, fmt.Errorf("unexpected status code %v: %s", res.StatusCode, bytes.TrimSpace(resBytes))
+	}
+
+	var lease kubeLease
+	if err := json.Unmarshal(resBytes, &lease); err != nil {
+		return nil, res.StatusCode, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &lease, res.StatusCode, nil
+}
+
+// campaign attempts to acquire or renew the lease, where a conflict means
+// another instance updated the lease first. Errors leave the leadership
+// unchanged, as it is relinquished anyway once the lease is no longer renewed.
+func (e *kubeElector) campaign() {
+	ctx, done := context.WithTimeout(context.Background(), e.ttl/3)
+	defer done()
+
+	now := time.Now().UTC()
+	nowStr := now.Format(kubeMicroTimeFormat)
+	durationSeconds := int(e.ttl.Seconds())
+	if durationSeconds < 1 {
+		durationSeconds = 1
+	}
+	leaseDuration := time.Duration(durationSeconds) * time.Second
+
+	lease, status, err := e.do(ctx, "GET", "/"+e.leaseName, nil)
+	if err != nil {
+		return
+	}
+
+	if status == http.StatusNotFound {
+		lease = &kubeLease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   map[string]interface{}{"name": e.leaseName},
+			Spec: kubeLeaseSpec{
+				HolderIdentity:       &e.identity,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &nowStr,
+				RenewTime:            &nowStr,
+			},
+		}
+		if _, status, err = e.do(ctx, "POST", "", lease); err != nil {
+			return
+		}
+		if status == http.StatusConflict {
+			e.lost()
+		} else {
+			e.renewed(now, leaseDuration)
+		}
+		return
+	}
+
+	held := lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != ""
+	if held && *lease.Spec.HolderIdentity != e.identity && !kubeLeaseExpired(lease.Spec, now) {
+		e.lost()
+		return
+	}
+	if !held || *lease.Spec.HolderIdentity != e.identity {
+		lease.Spec.AcquireTime = &nowStr
+	}
+	lease.Spec.HolderIdentity = &e.identity
+	lease.Spec.LeaseDurationSeconds = &durationSeconds
+	lease.Spec.RenewTime = &nowStr
+
+	// The resource version within the metadata ensures that the update fails
+	// with a conflict if the lease was modified since we read it.
+	if _, status, err = e.do(ctx, "PUT", "/"+e.leaseName, lease); err != nil {
+		return
+	}
+	if status == http.StatusOK {
+		e.renewed(now, leaseDuration)
+	} else {
+		e.lost()
+	}
+}
+
+func kubeLeaseExpired(spec kubeLeaseSpec, now time.Time) bool {
+	if spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	renewed, err := time.Parse(kubeMicroTimeFormat, *spec.RenewTime)
+	if err != nil {
+		return true
+	}
+	return now.After(renewed.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second))
+}
+
+func (e *kubeElector) Close(ctx context.Context) error {
+	close(e.closeChan)
+	e.closedWG.Wait()
+
+	wasLeader := e.IsLeader()
+	e.lost()
+
+	if !wasLeader {
+		return nil
+	}
+	return e.release(ctx)
+}
+
+// release clears the holder of the lease so that another instance can acquire
+// it without waiting for the lease to expire.
+func (e *kubeElector) release(ctx context.Context) error {
+	lease, status, err := e.do(ctx, "GET", "/"+e.leaseName, nil)
+	if err != nil || status == http.StatusNotFound {
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != e.identity {
+		return nil
+	}
+	empty := ""
+	lease.Spec.HolderIdentity = &empty
+	_, _, err = e.do(ctx, "PUT", "/"+e.leaseName, lease)
+	return err
+}
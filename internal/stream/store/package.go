@@ -0,0 +1,4 @@
+// Package store provides persistence of stream configurations created in
+// streams mode, along with leader election for clustered deployments where
+// certain streams must only run on a single instance.
+package store
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+type s3Store struct {
+	bucket string
+	prefix string
+	client *s3.S3
+}
+
+func newS3Store(conf S3Config) (*s3Store, error) {
+	if conf.Bucket == "" {
+		return nil, errors.New("a bucket must be specified")
+	}
+
+	awsConf := aws.NewConfig()
+	if conf.Region != "" {
+		awsConf = awsConf.WithRegion(conf.Region)
+	}
+	if conf.Endpoint != "" {
+		awsConf = awsConf.WithEndpoint(conf.Endpoint)
+	}
+	if conf.ForcePathStyle {
+		awsConf = awsConf.WithS3ForcePathStyle(true)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsConf,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3Store{
+		bucket: conf.Bucket,
+		prefix: conf.Prefix,
+		client: s3.New(sess),
+	}, nil
+}
+
+func (s *s3Store) List(ctx context.Context) (map[string][]byte, error) {
+	var keys []string
+	if err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, obj := range page.Contents {
+			if strings.HasSuffix(*obj.Key, ".yaml") {
+				keys = append(keys, *obj.Key)
+			}
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	confs := map[string][]byte{}
+	for _, k := range keys {
+		obj, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(k),
+		})
+		if err != nil {
+			var aerr awserr.Error
+			if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+				continue
+			}
+			return nil, err
+		}
+		confBytes, err := io.ReadAll(obj.Body)
+		obj.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		confs[strings.TrimSuffix(strings.TrimPrefix(k, s.prefix), ".yaml")] = confBytes
+	}
+	return confs, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, id string, conf []byte) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.prefix + id + ".yaml"),
+		Body:        bytes.NewReader(conf),
+		ContentType: aws.String("application/x-yaml"),
+	})
+	return err
+}
+
+func (s *s3Store) Delete(ctx context.Context, id string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + id + ".yaml"),
+	})
+	return err
+}
@@ -0,0 +1,246 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+)
+
+// Store persists stream configs as YAML documents by their stream ID.
+type Store interface {
+	// List returns all stream configs within the store.
+	List(ctx context.Context) (map[string][]byte, error)
+
+	// Put adds or replaces a stream config within the store.
+	Put(ctx context.Context, id string, conf []byte) error
+
+	// Delete removes a stream config from the store, deleting a config that
+	// does not exist is not an error.
+	Delete(ctx context.Context, id string) error
+}
+
+// Elector participates in an election for a leader amongst all instances
+// sharing a store.
+type Elector interface {
+	// IsLeader returns whether this instance is currently the leader.
+	IsLeader() bool
+
+	// LeadershipChanged returns a channel that receives a value whenever the
+	// leadership of this instance changes, where changes that occur before
+	// the value is consumed are merged into it.
+	LeadershipChanged() <-chan struct{}
+
+	// Close the elector, relinquishing leadership if held.
+	Close(ctx context.Context) error
+}
+
+// Type combines a store along with an optional elector and the criteria for
+// which streams are singletons.
+type Type struct {
+	Store      Store
+	Elector    Elector
+	SyncPeriod time.Duration
+
+	// Singletons is a list of glob patterns matching the IDs of streams that
+	// should only run on the leader.
+	Singletons []string
+}
+
+// New creates a store from a config, or returns nil if the store is disabled.
+func New(conf Config) (*Type, error) {
+	t := &Type{
+		Singletons: conf.LeaderElection.Singletons,
+	}
+
+	var err error
+	switch conf.Type {
+	case "", "none":
+		if conf.LeaderElection.Enabled {
+			return nil, errors.New("leader election requires a streams store")
+		}
+		return nil, nil
+	case "directory":
+		t.Store, err = newDirectoryStore(conf.Directory)
+	case "s3":
+		t.Store, err = newS3Store(conf.S3)
+	case "consul":
+		t.Store, err = newConsulStore(conf.Consul)
+	case "etcd":
+		t.Store, err = newEtcdStore(conf.Etcd)
+	default:
+		return nil, fmt.Errorf("streams store type '%v' not recognised", conf.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.SyncPeriod != "" {
+		if t.SyncPeriod, err = time.ParseDuration(conf.SyncPeriod); err != nil {
+			return nil, fmt.Errorf("failed to parse sync period: %w", err)
+		}
+	}
+
+	for _, p := range t.Singletons {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid singleton pattern '%v': %w", p, err)
+		}
+	}
+
+	if conf.LeaderElection.Enabled {
+		switch conf.LeaderElection.Type {
+		case "", "consul":
+			cStore, ok := t.Store.(*consulStore)
+			if !ok {
+				return nil, fmt.Errorf("leader election is not supported by the %v store", conf.Type)
+			}
+			t.Elector, err = newConsulElector(cStore.client, conf.LeaderElection)
+		case "kubernetes":
+			t.Elector, err = newKubernetesElector(conf.LeaderElection)
+		case "etcd":
+			t.Elector, err = newEtcdElector(conf.LeaderElection)
+		default:
+			return nil, fmt.Errorf("leader election type '%v' not recognised", conf.LeaderElection.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// leadership tracks whether an elector currently holds leadership and
+// notifies listeners of changes. Leadership is only considered held until a
+// deadline that is renewed by each successful campaign, which means transient
+// errors are tolerated until the deadline passes, and that leadership is
+// relinquished before the lease can be acquired by another instance.
+type leadership struct {
+	isLeader bool
+	timer    *time.Timer
+	gen      int
+	mut      sync.Mutex
+
+	changed chan struct{}
+}
+
+func newLeadership() *leadership {
+	return &leadership{changed: make(chan struct{}, 1)}
+}
+
+// leaderDeadline returns the time at which leadership renewed at start with a
+// lease of the given TTL must be relinquished, which leaves a quarter of the
+// TTL for singleton streams to be stopped before the lease expires.
+func leaderDeadline(start time.Time, ttl time.Duration) time.Time {
+	return start.Add(ttl * 3 / 4)
+}
+
+// renewed marks this instance as the leader until the deadline of a lease that
+// was renewed at start, which must be a time from before the renewal request
+// was sent.
+func (l *leadership) renewed(start time.Time, ttl time.Duration) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.stopTimerLocked()
+	until := time.Until(leaderDeadline(start, ttl))
+	if until <= 0 {
+		l.setLocked(false)
+		return
+	}
+
+	gen := l.gen
+	l.timer = time.AfterFunc(until, func() {
+		l.mut.Lock()
+		defer l.mut.Unlock()
+		if l.gen == gen {
+			l.setLocked(false)
+		}
+	})
+	l.setLocked(true)
+}
+
+// lost marks this instance as not being the leader.
+func (l *leadership) lost() {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.stopTimerLocked()
+	l.setLocked(false)
+}
+
+func (l *leadership) stopTimerLocked() {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	// A timer that has already fired may be waiting on the lock, and the
+	// generation ensures that it has no effect.
+	l.gen++
+}
+
+func (l *leadership) setLocked(leader bool) {
+	if l.isLeader == leader {
+		return
+	}
+	l.isLeader = leader
+	select {
+	case l.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (l *leadership) IsLeader() bool {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	return l.isLeader
+}
+
+func (l *leadership) LeadershipChanged() <-chan struct{} {
+	return l.changed
+}
+
+// campaignLoop calls campaign at each period until the close channel is
+// closed, which is how electors renew their leadership or attempt to acquire
+// it.
+func campaignLoop(period time.Duration, closeChan <-chan struct{}, campaign func()) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			campaign()
+		case <-closeChan:
+			return
+		}
+	}
+}
+
+// IsSingleton returns whether a stream ID matches any singleton pattern.
+func (t *Type) IsSingleton(id string) bool {
+	for _, p := range t.Singletons {
+		if matched, _ := path.Match(p, id); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldRun returns whether a stream should be run by this instance, which is
+// false for singleton streams when this instance is not the leader.
+func (t *Type) ShouldRun(id string) bool {
+	if t.Elector == nil || !t.IsSingleton(id) {
+		return true
+	}
+	return t.Elector.IsLeader()
+}
+
+// Close the store and elector.
+func (t *Type) Close(ctx context.Context) error {
+	if t.Elector != nil {
+		return t.Elector.Close(ctx)
+	}
+	return nil
+}
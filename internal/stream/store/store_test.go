@@ -0,0 +1,636 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectoryStore(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "directory"
+	conf.Directory.Path = t.TempDir()
+
+	s, err := New(conf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	confs, err := s.Store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, confs)
+
+	require.NoError(t, s.Store.Put(ctx, "foo", []byte("input: {}")))
+	require.NoError(t, s.Store.Put(ctx, "bar/baz", []byte("output: {}")))
+	require.NoError(t, s.Store.Put(ctx, "foo", []byte("buffer: {}")))
+
+	confs, err = s.Store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"foo":     []byte("buffer: {}"),
+		"bar/baz": []byte("output: {}"),
+	}, confs)
+
+	require.NoError(t, s.Store.Delete(ctx, "foo"))
+	require.NoError(t, s.Store.Delete(ctx, "nope"))
+
+	confs, err = s.Store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"bar/baz": []byte("output: {}"),
+	}, confs)
+}
+
+func TestStoreConfigErrors(t *testing.T) {
+	conf := NewConfig()
+	conf.LeaderElection.Enabled = true
+	_, err := New(conf)
+	require.EqualError(t, err, "leader election requires a streams store")
+
+	conf = NewConfig()
+	conf.Type = "directory"
+	conf.Directory.Path = t.TempDir()
+	conf.LeaderElection.Enabled = true
+	_, err = New(conf)
+	require.EqualError(t, err, "leader election is not supported by the directory store")
+
+	conf = NewConfig()
+	conf.Type = "directory"
+	conf.Directory.Path = t.TempDir()
+	conf.LeaderElection.Enabled = true
+	conf.LeaderElection.Type = "nope"
+	_, err = New(conf)
+	require.EqualError(t, err, "leader election type 'nope' not recognised")
+
+	conf = NewConfig()
+	conf.Type = "nope"
+	_, err = New(conf)
+	require.EqualError(t, err, "streams store type 'nope' not recognised")
+
+	s, err := New(NewConfig())
+	require.NoError(t, err)
+	assert.Nil(t, s)
+}
+
+func TestSingletons(t *testing.T) {
+	s := &Type{Singletons: []string{"foo", "bar_*"}}
+	assert.True(t, s.IsSingleton("foo"))
+	assert.True(t, s.IsSingleton("bar_baz"))
+	assert.False(t, s.IsSingleton("baz"))
+
+	// Without an elector all streams run.
+	assert.True(t, s.ShouldRun("foo"))
+}
+
+// fakeConsul implements the subset of the Consul HTTP API used by the consul
+// store and elector.
+type fakeConsul struct {
+	mut      sync.Mutex
+	kv       map[string][]byte
+	locks    map[string]string
+	sessions int
+	failing  bool
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/v1/session/create":
+		f.sessions++
+		_ = json.NewEncoder(w).Encode(map[string]string{"ID": "session" + string(rune('0'+f.sessions))})
+	case strings.HasPrefix(r.URL.Path, "/v1/session/renew/"):
+		_, _ = w.Write([]byte("[]"))
+	case strings.HasPrefix(r.URL.Path, "/v1/session/destroy/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v1/session/destroy/")
+		for k, v := range f.locks {
+			if v == id {
+				delete(f.locks, k)
+			}
+		}
+		_, _ = w.Write([]byte("true"))
+	case strings.HasPrefix(r.URL.Path, "/v1/kv/"):
+		key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+		switch r.Method {
+		case "GET":
+			type pair struct {
+				Key   string
+				Value string
+			}
+			var pairs []pair
+			for k, v := range f.kv {
+				if strings.HasPrefix(k, key) {
+					pairs = append(pairs, pair{Key: k, Value: base64.StdEncoding.EncodeToString(v)})
+				}
+			}
+			if len(pairs) == 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(pairs)
+		case "PUT":
+			body, _ := io.ReadAll(r.Body)
+			if session := r.URL.Query().Get("acquire"); session != "" {
+				if holder, exists := f.locks[key]; exists && holder != session {
+					_, _ = w.Write([]byte("false"))
+					return
+				}
+				f.locks[key] = session
+			}
+			f.kv[key] = body
+			_, _ = w.Write([]byte("true"))
+		case "DELETE":
+			delete(f.kv, key)
+			_, _ = w.Write([]byte("true"))
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestConsulStoreAndElection(t *testing.T) {
+	fake := &fakeConsul{kv: map[string][]byte{}, locks: map[string]string{}}
+	ts := httptest.NewServer(fake)
+	t.Cleanup(ts.Close)
+
+	conf := NewConfig()
+	conf.Type = "consul"
+	conf.Consul.Address = ts.URL
+	conf.LeaderElection.Enabled = true
+	conf.LeaderElection.Singletons = []string{"foo"}
+
+	ctx := context.Background()
+
+	sOne, err := New(conf)
+	require.NoError(t, err)
+	sTwo, err := New(conf)
+	require.NoError(t, err)
+
+	assert.True(t, sOne.Elector.IsLeader())
+	assert.False(t, sTwo.Elector.IsLeader())
+
+	assert.True(t, sOne.ShouldRun("foo"))
+	assert.False(t, sTwo.ShouldRun("foo"))
+	assert.True(t, sTwo.ShouldRun("bar"))
+
+	confs, err := sOne.Store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, confs)
+
+	require.NoError(t, sOne.Store.Put(ctx, "foo", []byte("input: {}")))
+	require.NoError(t, sOne.Store.Put(ctx, "bar", []byte("output: {}")))
+
+	confs, err = sTwo.Store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"foo": []byte("input: {}"),
+		"bar": []byte("output: {}"),
+	}, confs)
+
+	require.NoError(t, sTwo.Store.Delete(ctx, "foo"))
+
+	confs, err = sOne.Store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"bar": []byte("output: {}"),
+	}, confs)
+
+	// Closing the leader releases the lock for another instance to acquire.
+	require.NoError(t, sOne.Close(ctx))
+	assert.False(t, sOne.Elector.IsLeader())
+
+	sTwo.Elector.(*consulElector).campaign()
+	assert.True(t, sTwo.Elector.IsLeader())
+	require.NoError(t, sTwo.Close(ctx))
+}
+
+// fakeKubeLeases implements the subset of the Kubernetes coordination API used
+// by the kubernetes elector, including optimistic concurrency on updates.
+type fakeKubeLeases struct {
+	mut     sync.Mutex
+	leases  map[string]map[string]interface{}
+	version int
+	failing bool
+}
+
+func (f *fakeKubeLeases) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	const prefix = "/apis/coordination.k8s.io/v1/namespaces/foo/leases"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+	var body map[string]interface{}
+	if r.Method != "GET" {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		name, _ = body["metadata"].(map[string]interface{})["name"].(string)
+	}
+
+	existing, exists := f.leases[name]
+	switch r.Method {
+	case "GET":
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	case "POST":
+		if exists {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		fallthrough
+	case "PUT":
+		if exists {
+			existingMeta := existing["metadata"].(map[string]interface{})
+			if body["metadata"].(map[string]interface{})["resourceVersion"] != existingMeta["resourceVersion"] {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+		}
+		f.version++
+		body["metadata"].(map[string]interface{})["resourceVersion"] = strconv.Itoa(f.version)
+		f.leases[name] = body
+		existing = body
+	}
+	_ = json.NewEncoder(w).Encode(existing)
+}
+
+func TestKubernetesElection(t *testing.T) {
+	fake := &fakeKubeLeases{leases: map[string]map[string]interface{}{}}
+	ts := httptest.NewServer(fake)
+	t.Cleanup(ts.Close)
+
+	conf := NewConfig()
+	conf.Type = "directory"
+	conf.Directory.Path = t.TempDir()
+	conf.LeaderElection.Enabled = true
+	conf.LeaderElection.Type = "kubernetes"
+	conf.LeaderElection.Kubernetes.Namespace = "foo"
+	conf.LeaderElection.Kubernetes.APIServer = ts.URL
+	conf.LeaderElection.Singletons = []string{"foo"}
+
+	ctx := context.Background()
+
+	sOne, err := New(conf)
+	require.NoError(t, err)
+	sTwo, err := New(conf)
+	require.NoError(t, err)
+
+	assert.True(t, sOne.Elector.IsLeader())
+	assert.False(t, sTwo.Elector.IsLeader())
+
+	assert.True(t, sOne.ShouldRun("foo"))
+	assert.False(t, sTwo.ShouldRun("foo"))
+
+	// Renewing keeps the leadership with the current holder.
+	sOne.Elector.(*kubeElector).campaign()
+	sTwo.Elector.(*kubeElector).campaign()
+	assert.True(t, sOne.Elector.IsLeader())
+	assert.False(t, sTwo.Elector.IsLeader())
+
+	// Closing the leader releases the lease for another instance to acquire.
+	require.NoError(t, sOne.Close(ctx))
+	assert.False(t, sOne.Elector.IsLeader())
+
+	sTwo.Elector.(*kubeElector).campaign()
+	assert.True(t, sTwo.Elector.IsLeader())
+	require.NoError(t, sTwo.Close(ctx))
+}
+
+func TestKubernetesLeaseExpired(t *testing.T) {
+	now := time.Now()
+	renewed := now.Add(-time.Second * 20).UTC().Format(kubeMicroTimeFormat)
+	duration := 15
+
+	assert.True(t, kubeLeaseExpired(kubeLeaseSpec{RenewTime: &renewed, LeaseDurationSeconds: &duration}, now))
+
+	duration = 30
+	assert.False(t, kubeLeaseExpired(kubeLeaseSpec{RenewTime: &renewed, LeaseDurationSeconds: &duration}, now))
+
+	assert.True(t, kubeLeaseExpired(kubeLeaseSpec{}, now))
+}
+
+// fakeEtcd implements the subset of the etcd v3 JSON gateway used by the etcd
+// elector.
+type fakeEtcd struct {
+	mut     sync.Mutex
+	leases  map[string]bool
+	kvs     map[string]string
+	values  map[string][]byte
+	nextID  int
+	failing bool
+}
+
+func fakeEtcdDecode(v interface{}) string {
+	s, _ := v.(string)
+	b, _ := base64.StdEncoding.DecodeString(s)
+	return string(b)
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.failing {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	switch r.URL.Path {
+	case "/v3/lease/grant":
+		f.nextID++
+		id := strconv.Itoa(f.nextID)
+		f.leases[id] = true
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ID": id, "TTL": body["TTL"]})
+	case "/v3/lease/keepalive":
+		id, _ := body["ID"].(string)
+		ttl := "0"
+		if f.leases[id] {
+			ttl = "15"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]interface{}{"ID": id, "TTL": ttl}})
+	case "/v3/lease/revoke":
+		id, _ := body["ID"].(string)
+		delete(f.leases, id)
+		for k, v := range f.kvs {
+			if v == id {
+				delete(f.kvs, k)
+			}
+		}
+		_, _ = w.Write([]byte("{}"))
+	case "/v3/kv/txn":
+		compare := body["compare"].([]interface{})[0].(map[string]interface{})
+		key := compare["key"].(string)
+		if lease, exists := f.kvs[key]; exists {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"succeeded": false,
+				"responses": []interface{}{
+					map[string]interface{}{"response_range": map[string]interface{}{
+						"kvs": []interface{}{map[string]interface{}{"key": key, "lease": lease}},
+					}},
+				},
+			})
+			return
+		}
+		put := body["success"].([]interface{})[0].(map[string]interface{})["request_put"].(map[string]interface{})
+		f.kvs[key] = put["lease"].(string)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"succeeded": true})
+	case "/v3/kv/range":
+		start, end := fakeEtcdDecode(body["key"]), fakeEtcdDecode(body["range_end"])
+		kvs := []interface{}{}
+		for k, v := range f.values {
+			if k >= start && (end == "\x00" || k < end) {
+				kvs = append(kvs, map[string]interface{}{
+					"key":   base64.StdEncoding.EncodeToString([]byte(k)),
+					"value": base64.StdEncoding.EncodeToString(v),
+				})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"kvs": kvs})
+	case "/v3/kv/put":
+		f.values[fakeEtcdDecode(body["key"])] = []byte(fakeEtcdDecode(body["value"]))
+		_, _ = w.Write([]byte("{}"))
+	case "/v3/kv/deleterange":
+		delete(f.values, fakeEtcdDecode(body["key"]))
+		_, _ = w.Write([]byte("{}"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestEtcdStore(t *testing.T) {
+	fake := &fakeEtcd{leases: map[string]bool{}, kvs: map[string]string{}, values: map[string][]byte{}}
+	ts := httptest.NewServer(fake)
+	t.Cleanup(ts.Close)
+
+	// Keys outside of the prefix are ignored.
+	fake.values["benthos/streamz"] = []byte("nope")
+	fake.values["other/foo"] = []byte("nope")
+
+	conf := NewConfig()
+	conf.Type = "etcd"
+	conf.Etcd.Endpoints = []string{"http://127.0.0.1:1", ts.URL}
+
+	ctx := context.Background()
+
+	sOne, err := New(conf)
+	require.NoError(t, err)
+	sTwo, err := New(conf)
+	require.NoError(t, err)
+
+	confs, err := sOne.Store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, confs)
+
+	require.NoError(t, sOne.Store.Put(ctx, "foo", []byte("input: {}")))
+	require.NoError(t, sOne.Store.Put(ctx, "bar/baz", []byte("output: {}")))
+	require.NoError(t, sOne.Store.Put(ctx, "foo", []byte("buffer: {}")))
+
+	confs, err = sTwo.Store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"foo":     []byte("buffer: {}"),
+		"bar/baz": []byte("output: {}"),
+	}, confs)
+
+	require.NoError(t, sTwo.Store.Delete(ctx, "foo"))
+	require.NoError(t, sTwo.Store.Delete(ctx, "nope"))
+
+	confs, err = sOne.Store.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"bar/baz": []byte("output: {}"),
+	}, confs)
+	assert.Equal(t, []byte("output: {}"), fake.values["benthos/streams/bar/baz"])
+}
+
+func TestEtcdPrefixEnd(t *testing.T) {
+	assert.Equal(t, "benthos/streams0", etcdPrefixEnd("benthos/streams/"))
+	assert.Equal(t, "b", etcdPrefixEnd("a\xff"))
+	assert.Equal(t, "\x00", etcdPrefixEnd("\xff\xff"))
+	assert.Equal(t, "\x00", etcdPrefixEnd(""))
+}
+
+func TestEtcdElection(t *testing.T) {
+	fake := &fakeEtcd{leases: map[string]bool{}, kvs: map[string]string{}, values: map[string][]byte{}}
+	ts := httptest.NewServer(fake)
+	t.Cleanup(ts.Close)
+
+	conf := NewConfig()
+	conf.Type = "directory"
+	conf.Directory.Path = t.TempDir()
+	conf.LeaderElection.Enabled = true
+	conf.LeaderElection.Type = "etcd"
+	conf.LeaderElection.Etcd.Endpoints = []string{"http://127.0.0.1:1", ts.URL}
+
+	ctx := context.Background()
+
+	sOne, err := New(conf)
+	require.NoError(t, err)
+	sTwo, err := New(conf)
+	require.NoError(t, err)
+
+	assert.True(t, sOne.Elector.IsLeader())
+	assert.False(t, sTwo.Elector.IsLeader())
+
+	sOne.Elector.(*etcdElector).campaign()
+	sTwo.Elector.(*etcdElector).campaign()
+	assert.True(t, sOne.Elector.IsLeader())
+	assert.False(t, sTwo.Elector.IsLeader())
+
+	// An expired lease results in the key being removed and a new lease being
+	// granted on the next campaign.
+	fake.mut.Lock()
+	for id := range fake.leases {
+		delete(fake.leases, id)
+	}
+	fake.kvs = map[string]string{}
+	fake.mut.Unlock()
+
+	sTwo.Elector.(*etcdElector).campaign()
+	sOne.Elector.(*etcdElector).campaign()
+	assert.False(t, sOne.Elector.IsLeader())
+	assert.True(t, sTwo.Elector.IsLeader())
+
+	require.NoError(t, sOne.Close(ctx))
+	require.NoError(t, sTwo.Close(ctx))
+	assert.Empty(t, fake.kvs)
+}
+
+// testTransientErrors checks that an elector keeps its leadership through
+// failed campaigns until the deadline of its lease, and recovers it once
+// campaigns succeed again.
+func testTransientErrors(t *testing.T, e Elector, campaign func(), setFailing func(bool)) {
+	t.Helper()
+
+	require.True(t, e.IsLeader())
+	select {
+	case <-e.LeadershipChanged():
+	default:
+		t.Fatal("expected leadership change event")
+	}
+
+	setFailing(true)
+	campaign()
+	assert.True(t, e.IsLeader())
+
+	select {
+	case <-e.LeadershipChanged():
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for leadership to be relinquished")
+	}
+	assert.False(t, e.IsLeader())
+
+	setFailing(false)
+	campaign()
+	assert.True(t, e.IsLeader())
+	select {
+	case <-e.LeadershipChanged():
+	default:
+		t.Fatal("expected leadership change event")
+	}
+}
+
+func TestElectionTransientErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("consul", func(t *testing.T) {
+		fake := &fakeConsul{kv: map[string][]byte{}, locks: map[string]string{}}
+		ts := httptest.NewServer(fake)
+		t.Cleanup(ts.Close)
+
+		conf := NewConfig()
+		conf.Type = "consul"
+		conf.Consul.Address = ts.URL
+		conf.LeaderElection.Enabled = true
+		conf.LeaderElection.SessionTTL = "1s"
+
+		s, err := New(conf)
+		require.NoError(t, err)
+		testTransientErrors(t, s.Elector, s.Elector.(*consulElector).campaign, func(b bool) {
+			fake.mut.Lock()
+			fake.failing = b
+			fake.mut.Unlock()
+		})
+		require.NoError(t, s.Close(ctx))
+	})
+
+	t.Run("kubernetes", func(t *testing.T) {
+		fake := &fakeKubeLeases{leases: map[string]map[string]interface{}{}}
+		ts := httptest.NewServer(fake)
+		t.Cleanup(ts.Close)
+
+		conf := NewConfig()
+		conf.Type = "directory"
+		conf.Directory.Path = t.TempDir()
+		conf.LeaderElection.Enabled = true
+		conf.LeaderElection.Type = "kubernetes"
+		conf.LeaderElection.SessionTTL = "1s"
+		conf.LeaderElection.Kubernetes.Namespace = "foo"
+		conf.LeaderElection.Kubernetes.APIServer = ts.URL
+
+		s, err := New(conf)
+		require.NoError(t, err)
+		testTransientErrors(t, s.Elector, s.Elector.(*kubeElector).campaign, func(b bool) {
+			fake.mut.Lock()
+			fake.failing = b
+			fake.mut.Unlock()
+		})
+		require.NoError(t, s.Close(ctx))
+	})
+
+	t.Run("etcd", func(t *testing.T) {
+		fake := &fakeEtcd{leases: map[string]bool{}, kvs: map[string]string{}, values: map[string][]byte{}}
+		ts := httptest.NewServer(fake)
+		t.Cleanup(ts.Close)
+
+		conf := NewConfig()
+		conf.Type = "directory"
+		conf.Directory.Path = t.TempDir()
+		conf.LeaderElection.Enabled = true
+		conf.LeaderElection.Type = "etcd"
+		conf.LeaderElection.SessionTTL = "1s"
+		conf.LeaderElection.Etcd.Endpoints = []string{ts.URL}
+
+		s, err := New(conf)
+		require.NoError(t, err)
+		testTransientErrors(t, s.Elector, s.Elector.(*etcdElector).campaign, func(b bool) {
+			fake.mut.Lock()
+			fake.failing = b
+			fake.mut.Unlock()
+		})
+		require.NoError(t, s.Close(ctx))
+	})
+}
@@ -2,6 +2,8 @@ package stream
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"runtime/pprof"
 	"time"
@@ -11,12 +13,17 @@ import (
 	ibuffer "github.com/benthosdev/benthos/v4/internal/component/buffer"
 	iinput "github.com/benthosdev/benthos/v4/internal/component/input"
 	ioutput "github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/flowcontrol"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/pipeline"
 )
 
 //------------------------------------------------------------------------------
 
+type flowControlProvider interface {
+	FlowControl() *flowcontrol.Controller
+}
+
 // Type creates and manages the lifetime of a Benthos stream.
 type Type struct {
 	conf Config
@@ -28,42 +35,65 @@ type Type struct {
 
 	manager bundle.NewManagement
 
+	readyComponents []string
+
+	drainTimeout time.Duration
+	flushTimeout time.Duration
+	inFlight     inFlightTracker
+	valve        *flowcontrol.Valve
+
 	onClose func()
 }
 
 // New creates a new stream.Type.
 func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, error) {
 	t := &Type{
-		conf:    conf,
-		manager: mgr,
-		onClose: func() {},
+		conf:            conf,
+		manager:         mgr,
+		readyComponents: []string{"input", "output"},
+		onClose:         func() {},
 	}
 	for _, opt := range opts {
 		opt(t)
 	}
+	for _, c := range t.readyComponents {
+		if c != "input" && c != "output" {
+			return nil, fmt.Errorf("ready component '%v' not recognised, expected input or output", c)
+		}
+	}
 	if err := t.start(); err != nil {
 		return nil, err
 	}
 
 	healthCheck := func(w http.ResponseWriter, r *http.Request) {
-		connected := true
-		if !t.inputLayer.Connected() {
-			connected = false
-			w.WriteHeader(http.StatusServiceUnavailable)
-			_, _ = w.Write([]byte("input not connected\n"))
-		}
-		if !t.outputLayer.Connected() {
-			connected = false
-			w.WriteHeader(http.StatusServiceUnavailable)
-			_, _ = w.Write([]byte("output not connected\n"))
+		status := t.ReadyStatus()
+		if r.URL.Query().Get("format") == "json" {
+			resBytes, err := json.Marshal(status)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if !status.Ready {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			_, _ = w.Write(resBytes)
+			return
 		}
-		if connected {
+		if status.Ready {
 			_, _ = w.Write([]byte("OK"))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		for _, name := range []string{"input", "output"} {
+			if c := status.Components[name]; c.Required && !c.Connected {
+				_, _ = fmt.Fprintf(w, "%v not connected\n", name)
+			}
 		}
 	}
 	t.manager.RegisterEndpoint(
 		"/ready",
-		"Returns 200 OK if all inputs and outputs are connected, otherwise a 503 is returned.",
+		"Returns 200 OK if all required inputs and outputs are connected, otherwise a 503 is returned. Set the query parameter `format` to `json` for a JSON object describing the connection state of each component.",
 		healthCheck,
 	)
 	return t, nil
@@ -80,10 +110,78 @@ func OptOnClose(onClose func()) func(*Type) {
 
 //------------------------------------------------------------------------------
 
-// IsReady returns a boolean indicating whether both the input and output layers
-// of the stream are connected.
+// OptShutdownTimeouts sets the maximum period of time to wait for in-flight and
+// buffered messages to be delivered when the stream is stopped, and the
+// maximum period of time to wait for the output to flush pending messages once
+// all upstream components have closed. A zero duration leaves the respective
+// period bound only by the timeout provided to Stop.
+func OptShutdownTimeouts(drain, flush time.Duration) func(*Type) {
+	return func(t *Type) {
+		t.drainTimeout = drain
+		t.flushTimeout = flush
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// OptReadyComponents sets the components of the stream that must be connected
+// in order for the stream to be considered ready, which can be any of input
+// and output. By default both are required.
+func OptReadyComponents(components ...string) func(*Type) {
+	return func(t *Type) {
+		t.readyComponents = components
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ComponentStatus describes the connection state of a stream component.
+type ComponentStatus struct {
+	Connected bool   `json:"connected"`
+	Required  bool   `json:"required"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReadyStatus describes whether a stream is ready along with the connection
+// state of each of its components.
+type ReadyStatus struct {
+	Ready      bool                       `json:"ready"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// ReadyStatus returns the connection state of the input and output layers of
+// the stream, and whether the stream is ready.
+func (t *Type) ReadyStatus() ReadyStatus {
+	status := ReadyStatus{
+		Ready:      true,
+		Components: map[string]ComponentStatus{},
+	}
+	add := func(name string, c interface{ Connected() bool }) {
+		cStatus := ComponentStatus{Connected: c.Connected()}
+		if !cStatus.Connected {
+			if err := component.ConnectionErrorOf(c); err != nil {
+				cStatus.Error = sanitiseConnectionError(err)
+			}
+		}
+		for _, r := range t.readyComponents {
+			if r == name {
+				cStatus.Required = true
+			}
+		}
+		if cStatus.Required && !cStatus.Connected {
+			status.Ready = false
+		}
+		status.Components[name] = cStatus
+	}
+	add("input", t.inputLayer)
+	add("output", t.outputLayer)
+	return status
+}
+
+// IsReady returns a boolean indicating whether the input and output layers of
+// the stream required for readiness are connected.
 func (t *Type) IsReady() bool {
-	return t.inputLayer.Connected() && t.outputLayer.Connected()
+	return t.ReadyStatus().Ready
 }
 
 func (t *Type) start() (err error) {
@@ -112,7 +210,11 @@ func (t *Type) start() (err error) {
 	// Start chaining components
 	var nextTranChan <-chan message.Transaction
 
-	nextTranChan = t.inputLayer.TransactionChan()
+	nextTranChan = t.inFlight.track(t.inputLayer.TransactionChan())
+	if p, ok := t.manager.(flowControlProvider); ok && p.FlowControl() != nil {
+		t.valve = p.FlowControl().NewValve(t.manager.Logger(), t.manager.Metrics())
+		nextTranChan = t.valve.Gate(nextTranChan)
+	}
 	if t.bufferLayer != nil {
 		if err = t.bufferLayer.Consume(nextTranChan); err != nil {
 			return
@@ -125,6 +227,9 @@ func (t *Type) start() (err error) {
 		}
 		nextTranChan = t.pipelineLayer.TransactionChan()
 	}
+	if p, ok := t.manager.(errorEventsProvider); ok && p.ErrorEvents() != nil {
+		nextTranChan = observeDelivery(nextTranChan, p.ErrorEvents())
+	}
 	if err = t.outputLayer.Consume(nextTranChan); err != nil {
 		return
 	}
@@ -132,6 +237,9 @@ func (t *Type) start() (err error) {
 	go func(out ioutput.Streamed) {
 		for {
 			if err := out.WaitForClose(time.Second); err == nil {
+				if t.valve != nil {
+					t.valve.Close()
+				}
 				t.onClose()
 				return
 			}
@@ -184,6 +292,9 @@ func (t *Type) StopGracefully(timeout time.Duration) (err error) {
 	if remaining < 0 {
 		return component.ErrTimeout
 	}
+	if t.flushTimeout > 0 && t.flushTimeout < remaining {
+		remaining = t.flushTimeout
+	}
 	if err = t.outputLayer.WaitForClose(remaining); err != nil {
 		return
 	}
@@ -191,6 +302,13 @@ func (t *Type) StopGracefully(timeout time.Duration) (err error) {
 	return nil
 }
 
+// InFlight returns the number of message batches, and the total number of
+// messages within them, that have been consumed from the input of the stream
+// but are yet to be acknowledged.
+func (t *Type) InFlight() (batches, messages int) {
+	return t.inFlight.Count()
+}
+
 // StopOrdered attempts to close all components of the stream in the order of
 // positions within the stream, this allows data to flush all the way through
 // the pipeline under certain circumstances but is less graceful than
@@ -293,8 +411,14 @@ func (t *Type) StopUnordered(timeout time.Duration) (err error) {
 // Initially the attempt is graceful, but as the timeout draws close the attempt
 // becomes progressively less graceful.
 func (t *Type) Stop(timeout time.Duration) error {
-	tOutUnordered := timeout / 4
-	tOutGraceful := timeout - tOutUnordered
+	defer t.reportInFlight()
+
+	started := time.Now()
+
+	tOutGraceful := timeout - timeout/4
+	if t.drainTimeout > 0 && t.drainTimeout < timeout {
+		tOutGraceful = t.drainTimeout
+	}
 
 	err := t.StopGracefully(tOutGraceful)
 	if err == nil {
@@ -306,6 +430,11 @@ func (t *Type) Stop(timeout time.Duration) error {
 		t.manager.Logger().Errorf("Encountered error whilst shutting down: %v\n", err)
 	}
 
+	tOutUnordered := timeout - time.Since(started)
+	if tOutUnordered < timeout/4 {
+		tOutUnordered = timeout / 4
+	}
+
 	err = t.StopUnordered(tOutUnordered)
 	if err == nil {
 		return nil
@@ -324,4 +453,16 @@ func (t *Type) Stop(timeout time.Duration) error {
 	return err
 }
 
+func (t *Type) reportInFlight() {
+	batches, messages := t.InFlight()
+	if batches == 0 {
+		t.manager.Logger().Debugln("All consumed messages were acknowledged before shutting down.")
+		return
+	}
+	t.manager.Logger().Warnf(
+		"Shutting down with %v messages across %v batches that were consumed but not acknowledged, these may be redelivered by inputs that support it.\n",
+		messages, batches,
+	)
+}
+
 //------------------------------------------------------------------------------
@@ -1,6 +1,8 @@
 package stream_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -120,3 +122,91 @@ func TestTypeCloseUnordered(t *testing.T) {
 	require.NoError(t, err)
 	assert.NoError(t, strm.StopUnordered(time.Minute))
 }
+
+func TestTypeReadyStatus(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "1s"
+	conf.Output.Type = "socket"
+	conf.Output.Socket.Network = "tcp"
+	conf.Output.Socket.Address = "127.0.0.1:1"
+
+	var readyHandler http.HandlerFunc
+	apiReg := mock.NewManager()
+	apiReg.OnRegisterEndpoint = func(path string, h http.HandlerFunc) {
+		if path == "/ready" {
+			readyHandler = h
+		}
+	}
+
+	newMgr, err := manager.New(manager.NewResourceConfig(), apiReg, log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, strm.Stop(time.Second))
+	}()
+
+	assert.Eventually(t, func() bool {
+		status := strm.ReadyStatus()
+		return status.Components["input"].Connected && status.Components["output"].Error != ""
+	}, time.Second*5, time.Millisecond*50)
+
+	require.NotNil(t, readyHandler)
+
+	res := httptest.NewRecorder()
+	readyHandler(res, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, "output not connected\n", res.Body.String())
+
+	res = httptest.NewRecorder()
+	readyHandler(res, httptest.NewRequest(http.MethodGet, "/ready?format=json", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+	assert.Contains(t, res.Body.String(), `"ready":false`)
+	assert.NotContains(t, res.Body.String(), "127.0.0.1")
+
+	status := strm.ReadyStatus()
+	assert.False(t, status.Ready)
+	assert.False(t, strm.IsReady())
+	assert.True(t, status.Components["input"].Required)
+	assert.True(t, status.Components["output"].Required)
+	assert.False(t, status.Components["output"].Connected)
+	assert.Contains(t, status.Components["output"].Error, "connection refused")
+	assert.NotContains(t, status.Components["output"].Error, "127.0.0.1")
+
+	strmInputOnly, err := stream.New(conf, newMgr, stream.OptReadyComponents("input"))
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, strmInputOnly.Stop(time.Second))
+	}()
+
+	assert.Eventually(t, strmInputOnly.IsReady, time.Second*5, time.Millisecond*50)
+	assert.False(t, strmInputOnly.ReadyStatus().Components["output"].Required)
+
+	_, err = stream.New(conf, newMgr, stream.OptReadyComponents("buffer"))
+	require.Error(t, err)
+}
+
+func TestTypeStopInFlight(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "1ms"
+	conf.Output.Type = "drop"
+
+	newMgr, err := manager.New(manager.NewResourceConfig(), mock.NewManager(), log.Noop(), metrics.Noop())
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr, stream.OptShutdownTimeouts(time.Second*5, time.Second*2))
+	require.NoError(t, err)
+
+	<-time.After(time.Millisecond * 50)
+	require.NoError(t, strm.Stop(time.Second*10))
+
+	batches, messages := strm.InFlight()
+	assert.Equal(t, 0, batches)
+	assert.Equal(t, 0, messages)
+}
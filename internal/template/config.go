@@ -24,6 +24,7 @@ type FieldConfig struct {
 	Type        *string      `yaml:"type,omitempty"`
 	Kind        *string      `yaml:"kind,omitempty"`
 	Default     *interface{} `yaml:"default,omitempty"`
+	Options     []string     `yaml:"options,omitempty"`
 	Advanced    bool         `yaml:"advanced"`
 }
 
@@ -59,6 +60,12 @@ func (c FieldConfig) FieldSpec() (docs.FieldSpec, error) {
 		return f, errors.New("missing type field")
 	}
 	f = f.HasType(docs.FieldType(*c.Type))
+	if len(c.Options) > 0 {
+		if *c.Type != string(docs.FieldTypeString) {
+			return f, fmt.Errorf("options are only supported for fields of type string, got %v", *c.Type)
+		}
+		f = f.HasOptions(c.Options...)
+	}
 	if c.Kind != nil {
 		switch *c.Kind {
 		case "map":
@@ -222,6 +229,7 @@ func FieldConfigSpec() docs.FieldSpecs {
 			"scalar", "map", "list",
 		).HasDefault("scalar"),
 		docs.FieldAnything("default", "An optional default value for the field. If a default value is not specified then a configuration without the field is considered incorrect.").Optional(),
+		docs.FieldString("options", "An optional list of values that a field of type `string` is restricted to. Configs that provide any other value are rejected.", []string{"json", "avro"}).Array().Optional(),
 		docs.FieldBool("advanced", "Whether this field is considered advanced.").HasDefault(false),
 	}
 }
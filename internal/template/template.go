@@ -99,6 +99,11 @@ func (c *compiled) ExpandToNode(node *yaml.Node) (*yaml.Node, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid config for template component: %w", err)
 	}
+	for _, f := range c.spec.Config.Children {
+		if err := checkFieldOptions(f, generic[f.Name]); err != nil {
+			return nil, fmt.Errorf("invalid config for template component: field %v: %w", f.Name, err)
+		}
+	}
 
 	msg := message.QuickBatch(nil)
 	part := message.NewPart(nil)
@@ -123,6 +128,37 @@ func (c *compiled) ExpandToNode(node *yaml.Node) (*yaml.Node, error) {
 	return &resultNode, nil
 }
 
+// checkFieldOptions returns an error if a field restricted to a set of options
+// has a value, or in the case of lists and maps any element, that isn't one of
+// those options.
+func checkFieldOptions(f docs.FieldSpec, v interface{}) error {
+	if len(f.Options) == 0 {
+		return nil
+	}
+	switch t := v.(type) {
+	case []interface{}:
+		for _, e := range t {
+			if err := checkFieldOptions(f, e); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for _, e := range t {
+			if err := checkFieldOptions(f, e); err != nil {
+				return err
+			}
+		}
+	case string:
+		for _, o := range f.Options {
+			if t == o {
+				return nil
+			}
+		}
+		return fmt.Errorf("value %v is not a valid option, expected one of: %v", t, f.Options)
+	}
+	return nil
+}
+
 //------------------------------------------------------------------------------
 
 // RegisterTemplate attempts to add a template component to the global list of
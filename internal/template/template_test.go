@@ -29,3 +29,53 @@ func TestTemplateTesting(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateFieldOptions(t *testing.T) {
+	tmpl := `
+name: typed_options_example
+type: processor
+fields:
+  - name: format
+    type: string
+    options: [ json, yaml ]
+  - name: formats
+    type: string
+    kind: list
+    options: [ json, yaml ]
+    default: [ json ]
+mapping: |
+  root.bloblang = "root = this.format(%q)".format(this.format)
+tests:
+  - name: valid
+    config:
+      format: yaml
+      formats: [ json, yaml ]
+`
+
+	tmplPath := filepath.Join(t.TempDir(), "template.yaml")
+	require.NoError(t, os.WriteFile(tmplPath, []byte(tmpl), 0o644))
+
+	conf, lints, err := template.ReadConfig(tmplPath)
+	require.NoError(t, err)
+	assert.Empty(t, lints)
+
+	testErrs, err := conf.Test()
+	require.NoError(t, err)
+	assert.Empty(t, testErrs)
+
+	conf.Tests[0].Config.Content[1].Value = "xml"
+	_, err = conf.Test()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field format: value xml is not a valid option, expected one of: [json yaml]")
+
+	conf.Tests[0].Config.Content[1].Value = "yaml"
+	conf.Tests[0].Config.Content[3].Content[0].Value = "xml"
+	_, err = conf.Test()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field formats: value xml is not a valid option")
+
+	conf.Fields[0].Type = &conf.Fields[0].Name
+	_, err = conf.Test()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "options are only supported for fields of type string")
+}
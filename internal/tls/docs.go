@@ -43,7 +43,7 @@ func FieldSpec() docs.FieldSpec {
 			},
 		).Array().WithChildren(
 			docs.FieldString("cert", "A plain text certificate to use.").HasDefault(""),
-			docs.FieldString("key", "A plain text certificate key to use.").HasDefault(""),
+			docs.FieldString("key", "A plain text certificate key to use.").Secret().HasDefault(""),
 			docs.FieldString("cert_file", "The path to a certificate to use.").HasDefault(""),
 			docs.FieldString("key_file", "The path of a certificate key to use.").HasDefault(""),
 		),
@@ -26,11 +26,12 @@ func GetSpan(p *message.Part) *Span {
 }
 
 // CreateChildSpan takes a message part, extracts an existing span if there is
-// one and returns child span.
+// one and returns child span. Values attached to the context of the part are
+// preserved by the span.
 func CreateChildSpan(operationName string, part *message.Part) *Span {
 	span := GetSpan(part)
 	if span == nil {
-		ctx, t := otel.GetTracerProvider().Tracer(name).Start(context.Background(), operationName)
+		ctx, t := otel.GetTracerProvider().Tracer(name).Start(message.GetContext(part), operationName)
 		span = otelSpan(ctx, t)
 	} else {
 		ctx, t := otel.GetTracerProvider().Tracer(name).Start(span.ctx, operationName)
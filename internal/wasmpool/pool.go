@@ -0,0 +1,120 @@
+// Package wasmpool provides a wazero runtime configured with resource limits
+// and a pool of module instances that are reused across calls.
+package wasmpool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// NewRuntime creates a wazero runtime with support for WASI, where the memory
+// of each module instance is limited to a number of 64KiB pages and executions
+// are aborted once the context of the call is done.
+func NewRuntime(ctx context.Context, memoryLimitPages uint32) (wazero.Runtime, error) {
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(memoryLimitPages).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		_ = r.Close(ctx)
+		return nil, err
+	}
+	return r, nil
+}
+
+// Instance is an instantiated module that is only ever used by one caller at
+// a time.
+type Instance struct {
+	Module api.Module
+
+	functions map[string]api.Function
+}
+
+// Function returns a function exported by the module, or nil if the function
+// does not exist. Lookups are cached for the lifetime of the instance.
+func (i *Instance) Function(name string) api.Function {
+	if fn, exists := i.functions[name]; exists {
+		return fn
+	}
+	fn := i.Module.ExportedFunction(name)
+	i.functions[name] = fn
+	return fn
+}
+
+// Pool is a pool of instances of a compiled module. Instances are reused
+// across calls but never shared between concurrent calls.
+type Pool struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	config   wazero.ModuleConfig
+	validate func(*Instance) error
+
+	mut       sync.Mutex
+	instances []*Instance
+}
+
+// New creates a pool of instances of a compiled module. The validate function
+// is called for each new instance and, if it returns an error, the instance is
+// closed and the error is returned instead.
+func New(runtime wazero.Runtime, compiled wazero.CompiledModule, config wazero.ModuleConfig, validate func(*Instance) error) *Pool {
+	return &Pool{
+		runtime:  runtime,
+		compiled: compiled,
+		config:   config,
+		validate: validate,
+	}
+}
+
+// Get returns an idle instance from the pool, or a new instance if there are
+// none.
+func (p *Pool) Get(ctx context.Context) (*Instance, error) {
+	p.mut.Lock()
+	if l := len(p.instances); l > 0 {
+		inst := p.instances[l-1]
+		p.instances = p.instances[:l-1]
+		p.mut.Unlock()
+		return inst, nil
+	}
+	p.mut.Unlock()
+
+	mod, err := p.runtime.InstantiateModule(ctx, p.compiled, p.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate: %w", err)
+	}
+	inst := &Instance{
+		Module:    mod,
+		functions: map[string]api.Function{},
+	}
+	if p.validate != nil {
+		if err := p.validate(inst); err != nil {
+			_ = mod.Close(ctx)
+			return nil, err
+		}
+	}
+	return inst, nil
+}
+
+// Put returns an instance to the pool once a call has completed successfully.
+func (p *Pool) Put(inst *Instance) {
+	p.mut.Lock()
+	p.instances = append(p.instances, inst)
+	p.mut.Unlock()
+}
+
+// Discard closes an instance that failed during a call, as its state can't be
+// trusted.
+func (p *Pool) Discard(inst *Instance) {
+	_ = inst.Module.Close(context.Background())
+}
+
+// Close removes all idle instances from the pool. Instances are closed along
+// with the runtime they belong to.
+func (p *Pool) Close() {
+	p.mut.Lock()
+	p.instances = nil
+	p.mut.Unlock()
+}
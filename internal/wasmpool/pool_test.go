@@ -0,0 +1,100 @@
+package wasmpool_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero"
+
+	"github.com/benthosdev/benthos/v4/internal/wasmpool"
+)
+
+// exportModule is a module exporting a single function `noop` that takes no
+// arguments and returns nothing.
+var exportModule = []byte{
+	0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+	0x01, 0x04, 0x01, 0x60, 0x00, 0x00, // type section
+	0x03, 0x02, 0x01, 0x00, // function section
+	0x07, 0x08, 0x01, 0x04, 0x6e, 0x6f, 0x6f, 0x70, 0x00, 0x00, // export section
+	0x0a, 0x04, 0x01, 0x02, 0x00, 0x0b, // code section
+}
+
+func TestPoolReuse(t *testing.T) {
+	ctx := context.Background()
+
+	r, err := wasmpool.NewRuntime(ctx, 1)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, r.Close(ctx))
+	})
+
+	compiled, err := r.CompileModule(ctx, exportModule)
+	require.NoError(t, err)
+
+	pool := wasmpool.New(r, compiled, wazero.NewModuleConfig().WithName(""), nil)
+
+	instA, err := pool.Get(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, instA.Function("noop"))
+	assert.Nil(t, instA.Function("nope"))
+
+	// Instances in use are never handed out twice.
+	instB, err := pool.Get(ctx)
+	require.NoError(t, err)
+	assert.NotSame(t, instA, instB)
+
+	pool.Put(instA)
+	instC, err := pool.Get(ctx)
+	require.NoError(t, err)
+	assert.Same(t, instA, instC)
+
+	_, err = instC.Function("noop").Call(ctx)
+	require.NoError(t, err)
+
+	pool.Discard(instB)
+	_, err = instB.Function("noop").Call(ctx)
+	require.Error(t, err)
+}
+
+func TestPoolValidate(t *testing.T) {
+	ctx := context.Background()
+
+	r, err := wasmpool.NewRuntime(ctx, 1)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, r.Close(ctx))
+	})
+
+	compiled, err := r.CompileModule(ctx, exportModule)
+	require.NoError(t, err)
+
+	pool := wasmpool.New(r, compiled, wazero.NewModuleConfig().WithName(""), func(inst *wasmpool.Instance) error {
+		if inst.Function("process") == nil {
+			return errors.New("does not export function 'process'")
+		}
+		return nil
+	})
+
+	_, err = pool.Get(ctx)
+	require.EqualError(t, err, "does not export function 'process'")
+}
+
+func TestRuntimeMemoryLimit(t *testing.T) {
+	ctx := context.Background()
+
+	r, err := wasmpool.NewRuntime(ctx, 1)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, r.Close(ctx))
+	})
+
+	// A module declaring a minimum of two pages of memory.
+	_, err = r.CompileModule(ctx, []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		0x05, 0x03, 0x01, 0x00, 0x02, // memory section
+	})
+	require.Error(t, err)
+}
@@ -84,7 +84,11 @@ func (e *Environment) RegisterMethod(name string, ctor MethodConstructor) error
 			if err != nil {
 				return nil, err
 			}
-			return fn(v)
+			res, err := fn(v)
+			if err != nil {
+				return nil, query.ErrFrom(err, target)
+			}
+			return res, nil
 		}, target.QueryTargets), nil
 	})
 }
@@ -125,7 +129,11 @@ func (e *Environment) RegisterMethodV2(name string, spec *PluginSpec, ctor Metho
 			if err != nil {
 				return nil, err
 			}
-			return fn(v)
+			res, err := fn(v)
+			if err != nil {
+				return nil, query.ErrFrom(err, target)
+			}
+			return res, nil
 		}, target.QueryTargets), nil
 	})
 }
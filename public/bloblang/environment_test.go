@@ -1,6 +1,7 @@
 package bloblang
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -106,3 +107,31 @@ func TestEnvironmentDisabledImports(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "imports are disabled in this context")
 }
+
+func TestEnvironmentMethodErrors(t *testing.T) {
+	env := NewEnvironment()
+
+	require.NoError(t, env.RegisterMethodV2("fails", NewPluginSpec(), func(_ *ParsedParams) (Method, error) {
+		return func(v interface{}) (interface{}, error) {
+			return nil, errors.New("nope")
+		}, nil
+	}))
+
+	require.NoError(t, env.RegisterMethodV2("doubles", NewPluginSpec(), func(_ *ParsedParams) (Method, error) {
+		return Int64Method(func(i int64) (interface{}, error) {
+			return i * 2, nil
+		}), nil
+	}))
+
+	exe, err := env.Parse(`root = this.foo.fails()`)
+	require.NoError(t, err)
+
+	_, err = exe.Query(map[string]interface{}{"foo": "bar"})
+	assert.EqualError(t, err, "failed assignment (line 1): field `this.foo`: nope")
+
+	exe, err = env.Parse(`root = this.foo.doubles()`)
+	require.NoError(t, err)
+
+	_, err = exe.Query(map[string]interface{}{"foo": "bar"})
+	assert.EqualError(t, err, "failed assignment (line 1): expected number value, got string from field `this.foo` (\"bar\")")
+}
@@ -12,7 +12,9 @@ import (
 	_ "github.com/benthosdev/benthos/v4/internal/impl/aws"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/azure"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/cassandra"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/cbor"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/confluent"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/datadog"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/dgraph"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/elasticsearch"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/gcp"
@@ -38,6 +40,8 @@ import (
 	_ "github.com/benthosdev/benthos/v4/internal/impl/snowflake"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/sql"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/statsd"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/useragent"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/wasm"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/xml"
 	"github.com/benthosdev/benthos/v4/internal/template"
 
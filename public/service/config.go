@@ -114,6 +114,22 @@ func NewFloatField(name string) *ConfigField {
 	}
 }
 
+// NewFloatListField describes a new config field consisting of a list of
+// floats.
+func NewFloatListField(name string) *ConfigField {
+	return &ConfigField{
+		field: docs.FieldFloat(name, "").Array(),
+	}
+}
+
+// NewFloatMapField describes a new config field consisting of an object of
+// arbitrary keys with float values.
+func NewFloatMapField(name string) *ConfigField {
+	return &ConfigField{
+		field: docs.FieldFloat(name, "").Map(),
+	}
+}
+
 // NewBoolField describes a new bool type config field.
 func NewBoolField(name string) *ConfigField {
 	return &ConfigField{
@@ -164,6 +180,14 @@ func (c *ConfigField) Advanced() *ConfigField {
 	return c
 }
 
+// Secret marks a config field as containing sensitive information, such as a
+// password or token, and therefore its value is redacted when configs are
+// recorded, for example within the streams mode audit log.
+func (c *ConfigField) Secret() *ConfigField {
+	c.field = c.field.Secret()
+	return c
+}
+
 // Deprecated marks a config field as being deprecated, and therefore it will not
 // appear in documentation examples.
 func (c *ConfigField) Deprecated() *ConfigField {
@@ -645,6 +669,58 @@ func (p *ParsedConfig) FieldFloat(path ...string) (float64, error) {
 	return f, nil
 }
 
+// FieldFloatList accesses a field that is a list of floats from the parsed
+// config by its name and returns the value. Returns an error if the field is
+// not found, or is not a list of floats.
+func (p *ParsedConfig) FieldFloatList(path ...string) ([]float64, error) {
+	v, exists := p.field(path...)
+	if !exists {
+		return nil, fmt.Errorf("field '%v' was not found in the config", p.fullDotPath(path...))
+	}
+	iList, ok := v.([]interface{})
+	if !ok {
+		if fList, ok := v.([]float64); ok {
+			return fList, nil
+		}
+		return nil, fmt.Errorf("expected field '%v' to be a float list, got %T", p.fullDotPath(path...), v)
+	}
+	fList := make([]float64, len(iList))
+	for i, ev := range iList {
+		fv, err := query.IGetNumber(ev)
+		if err != nil {
+			return nil, fmt.Errorf("expected field '%v' to be a float list, found an element of type %T", p.fullDotPath(path...), ev)
+		}
+		fList[i] = fv
+	}
+	return fList, nil
+}
+
+// FieldFloatMap accesses a field that is an object of arbitrary keys and float
+// values from the parsed config by its name and returns the value. Returns an
+// error if the field is not found, or is not an object of floats.
+func (p *ParsedConfig) FieldFloatMap(path ...string) (map[string]float64, error) {
+	v, exists := p.field(path...)
+	if !exists {
+		return nil, fmt.Errorf("field '%v' was not found in the config", p.fullDotPath(path...))
+	}
+	iMap, ok := v.(map[string]interface{})
+	if !ok {
+		if fMap, ok := v.(map[string]float64); ok {
+			return fMap, nil
+		}
+		return nil, fmt.Errorf("expected field '%v' to be a float map, got %T", p.fullDotPath(path...), v)
+	}
+	fMap := make(map[string]float64, len(iMap))
+	for k, ev := range iMap {
+		fv, err := query.IGetNumber(ev)
+		if err != nil {
+			return nil, fmt.Errorf("expected field '%v' to be a float map, found an element of type %T", p.fullDotPath(path...), ev)
+		}
+		fMap[k] = fv
+	}
+	return fMap, nil
+}
+
 // FieldBool accesses a bool field from the parsed config by its name and
 // returns the value. Returns an error if the field is not found or is not a
 // bool.
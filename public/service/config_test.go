@@ -127,6 +127,8 @@ func TestConfigTypedFields(t *testing.T) {
 				NewStringMapField("k"),
 				NewIntListField("l"),
 				NewIntMapField("m"),
+				NewFloatListField("n"),
+				NewFloatMapField("o"),
 			),
 		))
 
@@ -149,6 +151,12 @@ c:
     m:
       first: 21
       second: 22
+    n:
+      - 1.5
+      - 2
+    o:
+      first: 3.5
+      second: 4
 `, nil)
 	require.NoError(t, err)
 
@@ -200,6 +208,14 @@ c:
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]int{"first": 21, "second": 22}, im)
 
+	fl, err := parsedConfig.FieldFloatList("c", "f", "n")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1.5, 2}, fl)
+
+	fm, err := parsedConfig.FieldFloatMap("c", "f", "o")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]float64{"first": 3.5, "second": 4}, fm)
+
 	// Testing namespaces
 	nsC := parsedConfig.Namespace("c")
 	nsFOne := nsC.Namespace("f")
@@ -88,6 +88,10 @@ type BatchInput interface {
 	// then you can wrap your input implementation with AutoRetryNacksBatched to
 	// get automatic retries.
 	//
+	// When only a subset of the messages of a batch failed the error provided
+	// to the AckFunc is a *BatchError, which can be walked in order to nack
+	// the failed messages individually and ack the rest.
+	//
 	// If this method returns ErrNotConnected then ReadBatch will not be called
 	// again until Connect has returned a nil error. If ErrEndOfInput is
 	// returned then Read will no longer be called and the pipeline will
@@ -183,12 +187,15 @@ func (a *airGapBatchReader) ReadWithContext(ctx context.Context) (*message.Batch
 		}
 		return nil, nil, err
 	}
-	tMsg := message.QuickBatch(nil)
-	for _, msg := range batch {
-		tMsg.Append(msg.part)
+	parts := make([]*message.Part, len(batch))
+	for i, msg := range batch {
+		parts[i] = msg.part
 	}
+	group, parts := message.NewSortGroupParts(parts)
+	tMsg := message.QuickBatch(nil)
+	tMsg.SetAll(parts)
 	return tMsg, func(c context.Context, r error) error {
-		return ackFn(c, r)
+		return ackFn(c, fromInternalBatchError(r, batch, group))
 	}, nil
 }
 
@@ -222,7 +229,8 @@ type OwnedInput struct {
 // ReadBatch attemps to read a message batch from the input, along with a
 // function to be called once the entire batch can be either acked (successfully
 // sent or intentionally filtered) or nacked (failed to be processed or
-// dispatched to the output).
+// dispatched to the output). The function can be called with a *BatchError in
+// order to nack only a subset of the messages.
 //
 // If this method returns ErrEndOfInput then that indicates that the input has
 // finished and will no longer yield new messages.
@@ -243,7 +251,9 @@ func (o *OwnedInput) ReadBatch(ctx context.Context) (MessageBatch, AckFunc, erro
 		b = append(b, newMessageFromPart(part))
 		return nil
 	})
-	return b, tran.Ack, nil
+	return b, func(ctx context.Context, err error) error {
+		return tran.Ack(ctx, toInternalBatchError(err, tran.Payload))
+	}, nil
 }
 
 // Close the input.
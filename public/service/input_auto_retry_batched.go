@@ -14,7 +14,8 @@ import (
 // when an ack func is called with an error.
 //
 // When messages fail to be delivered they will be reattempted with back off
-// until success or the stream is stopped.
+// until success or the stream is stopped. If the error is a *BatchError then
+// only the failed messages of the batch are reattempted.
 func AutoRetryNacksBatched(i BatchInput) BatchInput {
 	return &autoRetryInputBatched{
 		child:           i,
@@ -55,6 +56,21 @@ func (i *autoRetryInputBatched) Connect(ctx context.Context) error {
 func (i *autoRetryInputBatched) wrapAckFunc(m messageRetryBatched) (MessageBatch, AckFunc) {
 	return m.msg, func(ctx context.Context, err error) error {
 		if err != nil {
+			// Only reattempt the messages that failed when we're able to
+			// identify them.
+			if berr, ok := err.(*BatchError); ok && berr.IndexedErrors() < len(m.msg) {
+				var resendMsg MessageBatch
+				berr.WalkMessages(func(_ int, msg *Message, merr error) bool {
+					if merr != nil {
+						resendMsg = append(resendMsg, msg)
+					}
+					return true
+				})
+				if len(resendMsg) > 0 {
+					m.msg = resendMsg
+				}
+			}
+
 			i.msgsMut.Lock()
 			i.resendMessages = append(i.resendMessages, m)
 			i.resendInterrupt()
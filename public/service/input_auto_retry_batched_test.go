@@ -345,3 +345,57 @@ func TestBatchAutoRetryBuffer(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, exp3, string(b))
 }
+
+func TestBatchAutoRetryBatchError(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	readerImpl := newMockBatchInput()
+	readerImpl.msgsToSnd = append(readerImpl.msgsToSnd, MessageBatch{
+		NewMessage([]byte("foo")),
+		NewMessage([]byte("bar")),
+		NewMessage([]byte("baz")),
+	})
+
+	pres := AutoRetryNacksBatched(readerImpl)
+
+	go func() {
+		select {
+		case readerImpl.connChan <- nil:
+		case <-time.After(time.Second):
+			t.Error("Timed out")
+		}
+		select {
+		case readerImpl.readChan <- nil:
+		case <-time.After(time.Second):
+			t.Error("Timed out")
+		}
+		select {
+		case readerImpl.ackChan <- nil:
+		case <-time.After(time.Second):
+			t.Error("Timed out")
+		}
+	}()
+
+	require.NoError(t, pres.Connect(ctx))
+
+	batch, aFn, err := pres.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch, 3)
+
+	require.NoError(t, aFn(ctx, NewBatchError(batch, errors.New("meh")).Failed(1, errors.New("nope"))))
+
+	// Only the failed message should be reattempted.
+	batch, aFn, err = pres.ReadBatch(ctx)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	act, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(act))
+
+	require.NoError(t, aFn(ctx, nil))
+	assert.Equal(t, []error{nil}, readerImpl.ackRcvd)
+}
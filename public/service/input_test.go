@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -207,3 +209,59 @@ func TestBatchInputAirGapHappy(t *testing.T) {
 	assert.NoError(t, outAckFn(context.Background(), errors.New("foobar")))
 	assert.EqualError(t, ackErr, "foobar")
 }
+
+func TestBatchInputAirGapBatchError(t *testing.T) {
+	var ackErr error
+	i := &fnBatchInput{
+		connect: func() error {
+			return nil
+		},
+		read: func() (MessageBatch, AckFunc, error) {
+			m := MessageBatch{
+				NewMessage([]byte("first")),
+				NewMessage([]byte("second")),
+				NewMessage([]byte("third")),
+			}
+			return m, func(ctx context.Context, err error) error {
+				ackErr = err
+				return nil
+			}, nil
+		},
+	}
+	agi := newAirGapBatchReader(i)
+
+	outMsg, outAckFn, err := agi.ReadWithContext(context.Background())
+	require.NoError(t, err)
+
+	// Mimic a downstream component that merged our messages with others and
+	// then failed a subset of them.
+	merged := message.QuickBatch([][]byte{[]byte("other")})
+	merged.Append(outMsg.Get(2).Copy())
+	merged.Append(outMsg.Get(0).Copy())
+	merged.Append(outMsg.Get(1).Copy())
+
+	require.NoError(t, outAckFn(context.Background(), batch.NewError(merged, errors.New("meh")).
+		Failed(0, errors.New("other failed")).
+		Failed(1, errors.New("third failed"))))
+
+	berr, ok := ackErr.(*BatchError)
+	require.True(t, ok, "%T", ackErr)
+	assert.EqualError(t, berr, "meh")
+	assert.Equal(t, 1, berr.IndexedErrors())
+
+	var results []string
+	berr.WalkMessages(func(i int, m *Message, err error) bool {
+		b, _ := m.AsBytes()
+		res := "ok"
+		if err != nil {
+			res = err.Error()
+		}
+		results = append(results, string(b)+": "+res)
+		return true
+	})
+	assert.Equal(t, []string{"first: ok", "second: ok", "third: third failed"}, results)
+
+	require.NoError(t, outAckFn(context.Background(), batch.NewError(merged, errors.New("meh")).
+		Failed(0, errors.New("other failed"))))
+	assert.NoError(t, ackErr)
+}
@@ -88,6 +88,34 @@ func (m *Message) WithContext(ctx context.Context) *Message {
 	}
 }
 
+// WithTxnOffset returns a new message with the position of the message within
+// an ordered partition of an upstream source associated with it. Inputs that
+// consume from ordered partitions (such as Kafka topic partitions) can use this
+// in order to allow transactional outputs to record the offsets of the
+// messages they write atomically, and to skip messages that were redelivered
+// after already being written.
+func (m *Message) WithTxnOffset(source, partition string, offset int64) *Message {
+	return &Message{
+		part: message.WithTxnOffset(m.part, message.TxnOffset{
+			Source:    source,
+			Partition: partition,
+			Offset:    offset,
+		}),
+		partCopied: m.partCopied,
+	}
+}
+
+// TxnOffset returns the position of the message within an ordered partition of
+// an upstream source, and a boolean indicating whether one was associated with
+// the message.
+func (m *Message) TxnOffset() (source, partition string, offset int64, ok bool) {
+	var o message.TxnOffset
+	if o, ok = message.GetTxnOffset(m.part); ok {
+		source, partition, offset = o.Source, o.Partition, o.Offset
+	}
+	return
+}
+
 // AsBytes returns the underlying byte array contents of a message or, if the
 // contents are a structured type, attempts to marshal the contents as a JSON
 // document and returns either the byte array result or an error.
@@ -117,12 +145,8 @@ func (m *Message) AsStructured() (interface{}, error) {
 // reference type (slice or map), as the structured contents will be lazily deep
 // cloned if it is still owned by an upstream component.
 func (m *Message) AsStructuredMut() (interface{}, error) {
-	// TODO: Use refactored APIs to determine if the contents are owned.
-	v, err := m.part.JSON()
-	if err != nil {
-		return nil, err
-	}
-	return message.CopyJSON(v)
+	m.ensureCopied()
+	return m.part.JSONMut()
 }
 
 // SetBytes sets the underlying contents of the message as a byte slice.
@@ -239,6 +263,34 @@ func (b MessageBatch) BloblangQuery(index int, blobl *bloblang.Executor) (*Messa
 	return nil, nil
 }
 
+// BloblangQueryAll executes a parsed Bloblang mapping once on an entire message
+// batch and returns a single message back or an error if the mapping fails. The
+// context of the mapping is an array of each message of the batch parsed as a
+// JSON document, and the resulting message adopts the metadata of the first
+// message of the batch. If the mapping results in the root being deleted the
+// returned message will be nil.
+//
+// This method allows mappings to aggregate a batch into a single message.
+func (b MessageBatch) BloblangQueryAll(blobl *bloblang.Executor) (*Message, error) {
+	uw := blobl.XUnwrapper().(interface {
+		Unwrap() *mapping.Executor
+	}).Unwrap()
+
+	msg := message.QuickBatch(nil)
+	for _, m := range b {
+		msg.Append(m.part)
+	}
+
+	res, err := uw.MapBatch(msg)
+	if err != nil {
+		return nil, err
+	}
+	if res != nil {
+		return newMessageFromPart(res), nil
+	}
+	return nil, nil
+}
+
 // InterpolatedString resolves an interpolated string expression on a message
 // batch, from the perspective of a particular message index.
 //
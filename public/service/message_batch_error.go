@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// BatchError is an error type that can be returned by batched outputs in order
+// to indicate which individual messages of a batch failed, allowing the
+// remaining messages to be acknowledged. Inputs that read batches are also
+// given a BatchError within their AckFunc when only a subset of the messages of
+// their batch failed, allowing them to acknowledge messages individually rather
+// than rejecting the entire batch.
+type BatchError struct {
+	err        error
+	b          MessageBatch
+	partErrors map[int]error
+}
+
+// NewBatchError creates a new batch-wide error from a batch and a headline
+// error, where it's possible to add granular errors for individual messages of
+// the batch with Failed.
+func NewBatchError(b MessageBatch, headline error) *BatchError {
+	if berr, ok := headline.(*BatchError); ok {
+		headline = berr.Unwrap()
+	}
+	return &BatchError{
+		err: headline,
+		b:   b,
+	}
+}
+
+// Failed stores an error state for a particular message of the batch by its
+// index. Returns a pointer to the underlying error, allowing the method to be
+// chained.
+//
+// If Failed is not called then all messages are assumed to have failed with
+// the headline error. If it is called at least once then all message indexes
+// that aren't explicitly failed are assumed to have been processed
+// successfully.
+func (e *BatchError) Failed(i int, err error) *BatchError {
+	if e.partErrors == nil {
+		e.partErrors = make(map[int]error)
+	}
+	e.partErrors[i] = err
+	return e
+}
+
+// IndexedErrors returns the number of indexed errors that have been registered
+// for the batch.
+func (e *BatchError) IndexedErrors() int {
+	return len(e.partErrors)
+}
+
+// WalkMessages applies a closure to each message of the batch that the error
+// belongs to. The closure is provided the message index, the message, and its
+// individual error, which is nil if the message itself was processed
+// successfully. The closure returns a bool which indicates whether the
+// iteration should be continued.
+func (e *BatchError) WalkMessages(fn func(int, *Message, error) bool) {
+	for i, m := range e.b {
+		err := e.err
+		if e.partErrors != nil {
+			err = e.partErrors[i]
+		}
+		if !fn(i, m, err) {
+			return
+		}
+	}
+}
+
+// Error implements the common error interface.
+func (e *BatchError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying headline error.
+func (e *BatchError) Unwrap() error {
+	return e.err
+}
+
+//------------------------------------------------------------------------------
+
+// toInternalBatchError converts a BatchError into an error that can be walked
+// by internal components, where msg contains the parts of the messages that
+// the BatchError was created from in the same order.
+func toInternalBatchError(err error, msg *message.Batch) error {
+	berr, ok := err.(*BatchError)
+	if !ok {
+		return err
+	}
+	ierr := batch.NewError(msg, berr.err)
+	for i, perr := range berr.partErrors {
+		if i >= 0 && i < msg.Len() {
+			ierr.Failed(i, perr)
+		}
+	}
+	return ierr
+}
+
+// fromInternalBatchError converts an error that can be walked by internal
+// components into a BatchError for a batch of messages, where each message of
+// the batch is tagged by the provided sort group. When none of the messages of
+// the batch have failed nil is returned.
+func fromInternalBatchError(err error, b MessageBatch, group *message.SortGroup) error {
+	walkable, ok := err.(batch.WalkableError)
+	if !ok {
+		return err
+	}
+
+	headline := errors.Unwrap(walkable)
+	if headline == nil {
+		headline = walkable
+	}
+
+	remainingIndexes := make(map[int]struct{}, len(b))
+	for i := range b {
+		remainingIndexes[i] = struct{}{}
+	}
+
+	berr := NewBatchError(b, headline)
+	walkable.WalkParts(func(_ int, p *message.Part, perr error) bool {
+		if index := group.GetIndex(p); index >= 0 && index < len(b) {
+			delete(remainingIndexes, index)
+			if perr != nil {
+				berr.Failed(index, perr)
+			}
+		}
+		return true
+	})
+
+	// Messages that weren't present in the error have an unknown outcome and
+	// therefore must also be considered failed.
+	for i := range remainingIndexes {
+		berr.Failed(i, headline)
+	}
+	if berr.IndexedErrors() == 0 {
+		return nil
+	}
+	return berr
+}
@@ -76,6 +76,22 @@ func TestMessageCopyAirGap(t *testing.T) {
 	assert.Equal(t, "baz", v)
 }
 
+func TestMessageTxnOffset(t *testing.T) {
+	msg := NewMessage([]byte("hello world"))
+	_, _, _, ok := msg.TxnOffset()
+	assert.False(t, ok)
+
+	msgWithOffset := msg.WithTxnOffset("kafka", "foo/1", 10)
+	_, _, _, ok = msg.TxnOffset()
+	assert.False(t, ok)
+
+	source, partition, offset, ok := msgWithOffset.Copy().TxnOffset()
+	assert.True(t, ok)
+	assert.Equal(t, "kafka", source)
+	assert.Equal(t, "foo/1", partition)
+	assert.Equal(t, int64(10), offset)
+}
+
 func TestMessageQuery(t *testing.T) {
 	p := message.NewPart([]byte(`{"foo":"bar"}`))
 	p.MetaSet("foo", "bar")
@@ -264,6 +280,38 @@ func TestMessageBatchMapping(t *testing.T) {
 	}, resI)
 }
 
+func TestMessageBatchMappingAll(t *testing.T) {
+	partOne := NewMessage([]byte(`{"count":3}`))
+	partOne.MetaSet("foo", "first")
+
+	partTwo := NewMessage([]byte(`{"count":4}`))
+	partTwo.MetaSet("foo", "second")
+
+	blobl, err := bloblang.Parse(`root.total = this.map_each(doc -> doc.count).sum()
+root.size = batch_size()`)
+	require.NoError(t, err)
+
+	res, err := MessageBatch{partOne, partTwo}.BloblangQueryAll(blobl)
+	require.NoError(t, err)
+
+	resI, err := res.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"total": float64(7),
+		"size":  int64(2),
+	}, resI)
+
+	v, _ := res.MetaGet("foo")
+	assert.Equal(t, "first", v)
+
+	blobl, err = bloblang.Parse(`root = deleted()`)
+	require.NoError(t, err)
+
+	res, err = MessageBatch{partOne, partTwo}.BloblangQueryAll(blobl)
+	require.NoError(t, err)
+	assert.Nil(t, res)
+}
+
 func BenchmarkMessageMappingNew(b *testing.B) {
 	part := NewMessage(nil)
 	part.SetStructured(map[string]interface{}{
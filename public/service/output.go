@@ -64,6 +64,10 @@ type BatchOutput interface {
 	// Write a batch of messages to a sink, or return an error if delivery is
 	// not possible.
 	//
+	// If only a subset of the messages failed to be delivered then a
+	// *BatchError created from the provided batch can be returned in order to
+	// indicate which messages failed, allowing the rest to be acknowledged.
+	//
 	// If this method returns ErrNotConnected then write will not be called
 	// again until Connect has returned a nil error.
 	WriteBatch(context.Context, MessageBatch) error
@@ -140,7 +144,7 @@ func (a *airGapBatchWriter) WriteWithContext(ctx context.Context, msg *message.B
 	if err != nil && errors.Is(err, ErrNotConnected) {
 		err = component.ErrNotConnected
 	}
-	return err
+	return toInternalBatchError(err, msg)
 }
 
 func (a *airGapBatchWriter) CloseAsync() {
@@ -205,12 +209,17 @@ func (o *OwnedOutput) Write(ctx context.Context, m *Message) error {
 }
 
 // WriteBatch attempts to write a message batch to the output, and returns an
-// error either if delivery is not possible or the context is cancelled.
+// error either if delivery is not possible or the context is cancelled. If
+// only a subset of the messages failed to be delivered then the error returned
+// is a *BatchError.
 func (o *OwnedOutput) WriteBatch(ctx context.Context, b MessageBatch) error {
-	payload := message.QuickBatch(nil)
-	for _, m := range b {
-		payload.Append(m.part)
+	parts := make([]*message.Part, len(b))
+	for i, m := range b {
+		parts[i] = m.part
 	}
+	group, parts := message.NewSortGroupParts(parts)
+	payload := message.QuickBatch(nil)
+	payload.SetAll(parts)
 
 	resChan := make(chan error, 1)
 	select {
@@ -221,7 +230,7 @@ func (o *OwnedOutput) WriteBatch(ctx context.Context, b MessageBatch) error {
 
 	select {
 	case res := <-resChan:
-		return res
+		return fromInternalBatchError(res, b, group)
 	case <-ctx.Done():
 		return ctx.Err()
 	}
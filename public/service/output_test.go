@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -177,3 +179,32 @@ func TestBatchOutputAirGapHappy(t *testing.T) {
 
 	assert.Equal(t, "hello world", wroteMsg)
 }
+
+func TestBatchOutputAirGapBatchError(t *testing.T) {
+	o := &fnBatchOutput{
+		connect: func() error {
+			return nil
+		},
+		writeBatch: func(m MessageBatch) error {
+			return NewBatchError(m, errors.New("meh")).Failed(1, errors.New("bad message"))
+		},
+	}
+	agi := newAirGapBatchWriter(o)
+
+	inMsg := message.QuickBatch([][]byte{[]byte("first"), []byte("second"), []byte("third")})
+
+	err := agi.WriteWithContext(context.Background(), inMsg)
+	require.EqualError(t, err, "meh")
+
+	walkable, ok := err.(batch.WalkableError)
+	require.True(t, ok)
+
+	var failed []string
+	walkable.WalkParts(func(_ int, p *message.Part, err error) bool {
+		if err != nil {
+			failed = append(failed, string(p.Get())+": "+err.Error())
+		}
+		return true
+	})
+	assert.Equal(t, []string{"second: bad message"}, failed)
+}
@@ -792,7 +792,10 @@ func (s *StreamBuilder) buildConfig() builderConfig {
 //------------------------------------------------------------------------------
 
 func getYAMLNode(b []byte) (*yaml.Node, error) {
-	b = config.ReplaceEnvVariables(b)
+	b, err := config.ReplaceEnvVariables(b)
+	if err != nil {
+		return nil, err
+	}
 	var nconf yaml.Node
 	if err := yaml.Unmarshal(b, &nconf); err != nil {
 		return nil, err